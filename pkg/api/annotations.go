@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
@@ -32,6 +35,43 @@ import (
 // 401: unauthorisedError
 // 500: internalServerError
 func (hs *HTTPServer) GetAnnotations(c *models.ReqContext) response.Response {
+	query, errResp := hs.annotationsQueryFromRequest(c)
+	if errResp != nil {
+		return errResp
+	}
+	query.Limit = c.QueryInt64("limit")
+
+	items, err := hs.annotationsRepo.Find(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(500, "Failed to get annotations", err)
+	}
+
+	// since there are several annotations per dashboard, we can cache dashboard uid
+	dashboardCache := make(map[int64]*string)
+	for _, item := range items {
+		if item.Email != "" {
+			item.AvatarUrl = dtos.GetGravatarUrl(item.Email)
+		}
+
+		if item.DashboardId != 0 {
+			if val, ok := dashboardCache[item.DashboardId]; ok {
+				item.DashboardUID = val
+			} else {
+				query := models.GetDashboardQuery{Id: item.DashboardId, OrgId: c.OrgID}
+				err := hs.DashboardService.GetDashboard(c.Req.Context(), &query)
+				if err == nil && query.Result != nil {
+					item.DashboardUID = &query.Result.Uid
+					dashboardCache[item.DashboardId] = &query.Result.Uid
+				}
+			}
+		}
+	}
+
+	return response.JSON(http.StatusOK, items)
+}
+
+// annotationsQueryFromRequest reads the filters shared by annotation queries
+func (hs *HTTPServer) annotationsQueryFromRequest(c *models.ReqContext) (*annotations.ItemQuery, response.Response) {
 	query := &annotations.ItemQuery{
 		From:         c.QueryInt64("from"),
 		To:           c.QueryInt64("to"),
@@ -41,13 +81,19 @@ func (hs *HTTPServer) GetAnnotations(c *models.ReqContext) response.Response {
 		DashboardId:  c.QueryInt64("dashboardId"),
 		DashboardUid: c.Query("dashboardUID"),
 		PanelId:      c.QueryInt64("panelId"),
-		Limit:        c.QueryInt64("limit"),
 		Tags:         c.QueryStrings("tags"),
 		Type:         c.Query("type"),
 		MatchAny:     c.QueryBool("matchAny"),
+		TimeMatch:    c.Query("timeMatch"),
 		SignedInUser: c.SignedInUser,
 	}
 
+	switch query.TimeMatch {
+	case "", annotations.TimeMatchOverlap, annotations.TimeMatchStart, annotations.TimeMatchWithin:
+	default:
+		return nil, response.Error(http.StatusBadRequest, "Invalid timeMatch, should be overlap, start or within", nil)
+	}
+
 	// When dashboard UID present in the request, we ignore dashboard ID
 	if query.DashboardUid != "" {
 		dq := models.GetDashboardQuery{Uid: query.DashboardUid, OrgId: c.OrgID}
@@ -56,40 +102,48 @@ func (hs *HTTPServer) GetAnnotations(c *models.ReqContext) response.Response {
 			if hs.Features.IsEnabled(featuremgmt.FlagDashboardsFromStorage) {
 				// OK... the storage UIDs do not (yet?) exist in the DashboardService
 			} else {
-				return response.Error(http.StatusBadRequest, "Invalid dashboard UID in annotation request", err)
+				return nil, response.Error(http.StatusBadRequest, "Invalid dashboard UID in annotation request", err)
 			}
 		} else {
 			query.DashboardId = dq.Result.Id
 		}
 	}
 
-	items, err := hs.annotationsRepo.Find(c.Req.Context(), query)
-	if err != nil {
-		return response.Error(500, "Failed to get annotations", err)
+	return query, nil
+}
+
+// swagger:route GET /annotations/aggregate annotations getAnnotationsAggregate
+//
+// Aggregate Annotations.
+//
+// Counts the annotations starting in the time range per time bucket, and per tag in each bucket. The from, to and interval parameters are required and at most 1000 buckets are returned.
+//
+// Responses:
+// 200: getAnnotationsAggregateResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) GetAnnotationsAggregate(c *models.ReqContext) response.Response {
+	itemQuery, errResp := hs.annotationsQueryFromRequest(c)
+	if errResp != nil {
+		return errResp
 	}
 
-	// since there are several annotations per dashboard, we can cache dashboard uid
-	dashboardCache := make(map[int64]*string)
-	for _, item := range items {
-		if item.Email != "" {
-			item.AvatarUrl = dtos.GetGravatarUrl(item.Email)
-		}
+	interval, err := gtime.ParseDuration(c.Query("interval"))
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid interval", err)
+	}
 
-		if item.DashboardId != 0 {
-			if val, ok := dashboardCache[item.DashboardId]; ok {
-				item.DashboardUID = val
-			} else {
-				query := models.GetDashboardQuery{Id: item.DashboardId, OrgId: c.OrgID}
-				err := hs.DashboardService.GetDashboard(c.Req.Context(), &query)
-				if err == nil && query.Result != nil {
-					item.DashboardUID = &query.Result.Uid
-					dashboardCache[item.DashboardId] = &query.Result.Uid
-				}
-			}
+	query := &annotations.AggregateQuery{ItemQuery: *itemQuery, Interval: interval.Milliseconds()}
+	result, err := hs.annotationsRepo.Aggregate(c.Req.Context(), query)
+	if err != nil {
+		if errors.Is(err, annotations.ErrInvalidAggregation) {
+			return response.Error(http.StatusBadRequest, err.Error(), err)
 		}
+		return response.Error(http.StatusInternalServerError, "Failed to aggregate annotations", err)
 	}
 
-	return response.JSON(http.StatusOK, items)
+	return response.JSON(http.StatusOK, result)
 }
 
 type AnnotationError struct {
@@ -165,6 +219,95 @@ func (hs *HTTPServer) PostAnnotation(c *models.ReqContext) response.Response {
 	})
 }
 
+// maxBulkAnnotations is the maximum number of annotations created at once
+const maxBulkAnnotations = 5000
+
+// swagger:route POST /annotations/bulk annotations postAnnotationsBulk
+//
+// Create Annotations.
+//
+// Creates up to 5000 annotations at once, for example to import deployment events. Annotations are given in the same format as when creating a single annotation. Either all annotations are created or none of them.
+//
+// Responses:
+// 200: postAnnotationsBulkResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) PostAnnotationsBulk(c *models.ReqContext) response.Response {
+	cmd := dtos.PostAnnotationsBulkCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if len(cmd.Annotations) == 0 || len(cmd.Annotations) > maxBulkAnnotations {
+		err := &AnnotationError{fmt.Sprintf("between 1 and %d annotations should be given", maxBulkAnnotations)}
+		return response.Error(http.StatusBadRequest, "Failed to save annotations", err)
+	}
+
+	dashboardIDs := make(map[string]int64)
+	canCreate := make(map[int64]bool)
+	items := make([]*annotations.Item, 0, len(cmd.Annotations))
+	for i, a := range cmd.Annotations {
+		// overwrite dashboardId when dashboardUID is not empty
+		if a.DashboardUID != "" {
+			id, ok := dashboardIDs[a.DashboardUID]
+			if !ok {
+				query := models.GetDashboardQuery{OrgId: c.OrgID, Uid: a.DashboardUID}
+				if err := hs.DashboardService.GetDashboard(c.Req.Context(), &query); err == nil {
+					id = query.Result.Id
+				} else {
+					id = a.DashboardId
+				}
+				dashboardIDs[a.DashboardUID] = id
+			}
+			a.DashboardId = id
+		}
+
+		if _, ok := canCreate[a.DashboardId]; !ok {
+			canSave, err := hs.canCreateAnnotation(c, a.DashboardId)
+			if err != nil || !canSave {
+				return dashboardGuardianResponse(err)
+			}
+			canCreate[a.DashboardId] = true
+		}
+
+		if a.Text == "" {
+			err := &AnnotationError{fmt.Sprintf("text field of annotation %d should not be empty", i)}
+			return response.Error(http.StatusBadRequest, "Failed to save annotations", err)
+		}
+
+		items = append(items, &annotations.Item{
+			OrgId:       c.OrgID,
+			UserId:      c.UserID,
+			DashboardId: a.DashboardId,
+			PanelId:     a.PanelId,
+			Epoch:       a.Time,
+			EpochEnd:    a.TimeEnd,
+			Text:        a.Text,
+			Data:        a.Data,
+			Tags:        a.Tags,
+		})
+	}
+
+	if err := hs.annotationsRepo.SaveMany(c.Req.Context(), items); err != nil {
+		if errors.Is(err, annotations.ErrTimerangeMissing) {
+			return response.Error(http.StatusBadRequest, "Failed to save annotations", err)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to save annotations", err)
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message": "Annotations added",
+		"ids":     ids,
+	})
+}
+
 func formatGraphiteAnnotation(what string, data string) string {
 	text := what
 	if data != "" {
@@ -274,6 +417,7 @@ func (hs *HTTPServer) UpdateAnnotation(c *models.ReqContext) response.Response {
 		OrgId:    c.OrgID,
 		UserId:   c.UserID,
 		Id:       annotationID,
+		AlertId:  annotation.AlertId,
 		Epoch:    cmd.Time,
 		EpochEnd: cmd.TimeEnd,
 		Text:     cmd.Text,
@@ -324,6 +468,7 @@ func (hs *HTTPServer) PatchAnnotation(c *models.ReqContext) response.Response {
 		OrgId:    c.OrgID,
 		UserId:   c.UserID,
 		Id:       annotationID,
+		AlertId:  annotation.AlertId,
 		Epoch:    annotation.Time,
 		EpochEnd: annotation.TimeEnd,
 		Text:     annotation.Text,
@@ -395,8 +540,9 @@ func (hs *HTTPServer) MassDeleteAnnotations(c *models.ReqContext) response.Respo
 			}
 			dashboardId = annotation.DashboardId
 			deleteParams = &annotations.DeleteParams{
-				OrgId: c.OrgID,
-				Id:    cmd.AnnotationId,
+				OrgId:   c.OrgID,
+				Id:      cmd.AnnotationId,
+				AlertId: annotation.AlertId,
 			}
 		} else {
 			dashboardId = cmd.DashboardId
@@ -423,7 +569,7 @@ func (hs *HTTPServer) MassDeleteAnnotations(c *models.ReqContext) response.Respo
 	err = hs.annotationsRepo.Delete(c.Req.Context(), deleteParams)
 
 	if err != nil {
-		return response.Error(500, "Failed to delete annotations", err)
+		return response.ErrOrFallback(500, "Failed to delete annotations", err)
 	}
 
 	return response.Success("Annotations deleted")
@@ -482,11 +628,12 @@ func (hs *HTTPServer) DeleteAnnotationByID(c *models.ReqContext) response.Respon
 	}
 
 	err = hs.annotationsRepo.Delete(c.Req.Context(), &annotations.DeleteParams{
-		OrgId: c.OrgID,
-		Id:    annotationID,
+		OrgId:   c.OrgID,
+		Id:      annotationID,
+		AlertId: annotation.AlertId,
 	})
 	if err != nil {
-		return response.Error(500, "Failed to delete annotation", err)
+		return response.ErrOrFallback(500, "Failed to delete annotation", err)
 	}
 
 	return response.Success("Annotation deleted")
@@ -691,13 +838,75 @@ type GetAnnotationsParams struct {
 	// type: array
 	// collectionFormat: multi
 	Tags []string `json:"tags"`
-	// Return alerts or user created annotations
+	// Return alerts, user created annotations or regions
 	// in:query
 	// required:false
 	// Description:
 	// * `alert`
 	// * `annotation`
-	// enum: alert,annotation
+	// * `region`
+	// enum: alert,annotation,region
+	Type string `json:"type"`
+	// Match any or all tags
+	// in:query
+	// required:false
+	MatchAny bool `json:"matchAny"`
+	// How annotations are matched against the time range
+	// in:query
+	// required:false
+	// Description:
+	// * `overlap` - annotations intersecting the time range, including regions starting before it
+	// * `start` - annotations starting in the time range
+	// * `within` - annotations entirely in the time range
+	// enum: overlap,start,within
+	// default: overlap
+	TimeMatch string `json:"timeMatch"`
+}
+
+// swagger:parameters getAnnotationsAggregate
+type GetAnnotationsAggregateParams struct {
+	// Count annotations starting after specific epoch datetime in milliseconds.
+	// in:query
+	// required:true
+	From int64 `json:"from"`
+	// Count annotations starting before specific epoch datetime in milliseconds.
+	// in:query
+	// required:true
+	To int64 `json:"to"`
+	// Size of the time buckets, e.g. 1h or 1d.
+	// in:query
+	// required:true
+	Interval string `json:"interval"`
+	// Limit to annotations created by specific user.
+	// in:query
+	// required:false
+	UserID int64 `json:"userId"`
+	// Limit to annotations for a specified alert.
+	// in:query
+	// required:false
+	AlertID int64 `json:"alertId"`
+	// Limit to annotations that are scoped to a specific dashboard
+	// in:query
+	// required:false
+	DashboardID int64 `json:"dashboardId"`
+	// Limit to annotations that are scoped to a specific dashboard
+	// in:query
+	// required:false
+	DashboardUID string `json:"dashboardUID"`
+	// Limit to annotations that are scoped to a specific panel
+	// in:query
+	// required:false
+	PanelID int64 `json:"panelId"`
+	// Limit to annotations with these tags.
+	// in:query
+	// required:false
+	// type: array
+	// collectionFormat: multi
+	Tags []string `json:"tags"`
+	// Count alerts, user created annotations or regions
+	// in:query
+	// required:false
+	// enum: alert,annotation,region
 	Type string `json:"type"`
 	// Match any or all tags
 	// in:query
@@ -732,6 +941,13 @@ type PostAnnotationParams struct {
 	Body dtos.PostAnnotationsCmd `json:"body"`
 }
 
+// swagger:parameters postAnnotationsBulk
+type PostAnnotationsBulkParams struct {
+	// in:body
+	// required:true
+	Body dtos.PostAnnotationsBulkCmd `json:"body"`
+}
+
 // swagger:parameters postGraphiteAnnotation
 type PostGraphiteAnnotationParams struct {
 	// in:body
@@ -789,6 +1005,28 @@ type PostAnnotationResponse struct {
 	} `json:"body"`
 }
 
+// swagger:response postAnnotationsBulkResponse
+type PostAnnotationsBulkResponse struct {
+	// The response message
+	// in: body
+	Body struct {
+		// IDs Identifiers of the created annotations, in the order they were given.
+		// required: true
+		IDs []int64 `json:"ids"`
+
+		// Message Message of the created annotations.
+		// required: true
+		Message string `json:"message"`
+	} `json:"body"`
+}
+
+// swagger:response getAnnotationsAggregateResponse
+type GetAnnotationsAggregateResponse struct {
+	// The response message
+	// in: body
+	Body annotations.AggregateResult `json:"body"`
+}
+
 // swagger:response getAnnotationTagsResponse
 type GetAnnotationTagsResponse struct {
 	// The response message
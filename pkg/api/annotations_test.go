@@ -709,6 +709,76 @@ func TestAPI_Annotations_AccessControl(t *testing.T) {
 			},
 			want: http.StatusForbidden,
 		},
+		{
+			name: "AccessControl bulk create organization annotations with permissions is allowed",
+			args: args{
+				permissions: []accesscontrol.Permission{{
+					Action: accesscontrol.ActionAnnotationsCreate, Scope: accesscontrol.ScopeAnnotationsTypeOrganization,
+				}},
+				url:    "/api/annotations/bulk",
+				method: http.MethodPost,
+				body:   mockRequestBody(dtos.PostAnnotationsBulkCmd{Annotations: []dtos.PostAnnotationsCmd{postOrganizationCmd, postOrganizationCmd}}),
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "AccessControl bulk create dashboard annotations without permissions is forbidden",
+			args: args{
+				permissions: []accesscontrol.Permission{{
+					Action: accesscontrol.ActionAnnotationsCreate, Scope: accesscontrol.ScopeAnnotationsTypeOrganization,
+				}},
+				url:    "/api/annotations/bulk",
+				method: http.MethodPost,
+				body:   mockRequestBody(dtos.PostAnnotationsBulkCmd{Annotations: []dtos.PostAnnotationsCmd{postOrganizationCmd, postDashboardCmd}}),
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "AccessControl bulk create without annotations is a bad request",
+			args: args{
+				permissions: []accesscontrol.Permission{{Action: accesscontrol.ActionAnnotationsCreate, Scope: accesscontrol.ScopeAnnotationsAll}},
+				url:         "/api/annotations/bulk",
+				method:      http.MethodPost,
+				body:        mockRequestBody(dtos.PostAnnotationsBulkCmd{}),
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "AccessControl aggregating annotations with correct permissions is allowed",
+			args: args{
+				permissions: []accesscontrol.Permission{{Action: accesscontrol.ActionAnnotationsRead, Scope: accesscontrol.ScopeAnnotationsAll}},
+				url:         "/api/annotations/aggregate?from=1&to=3600000&interval=10m",
+				method:      http.MethodGet,
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "AccessControl aggregating annotations without permissions is forbidden",
+			args: args{
+				permissions: []accesscontrol.Permission{},
+				url:         "/api/annotations/aggregate?from=1&to=3600000&interval=10m",
+				method:      http.MethodGet,
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "AccessControl aggregating annotations without interval is a bad request",
+			args: args{
+				permissions: []accesscontrol.Permission{{Action: accesscontrol.ActionAnnotationsRead, Scope: accesscontrol.ScopeAnnotationsAll}},
+				url:         "/api/annotations/aggregate?from=1&to=3600000",
+				method:      http.MethodGet,
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "AccessControl getting annotations with invalid time match is a bad request",
+			args: args{
+				permissions: []accesscontrol.Permission{{Action: accesscontrol.ActionAnnotationsRead, Scope: accesscontrol.ScopeAnnotationsAll}},
+				url:         "/api/annotations?timeMatch=before",
+				method:      http.MethodGet,
+			},
+			want: http.StatusBadRequest,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
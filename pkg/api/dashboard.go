@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/dashlint"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/coremodel/dashboard"
 	"github.com/grafana/grafana/pkg/cuectx"
@@ -431,6 +432,19 @@ func (hs *HTTPServer) postDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		return response.Error(500, "Error while cleaning library panels", err)
 	}
 
+	var lintIssues []dashlint.Issue
+	if hs.Cfg.DashboardLint.Enabled {
+		result := dashlint.New(hs.Cfg.DashboardLint).Lint(dash.Data)
+		if !result.Valid {
+			return response.JSON(http.StatusBadRequest, util.DynMap{
+				"status":  "lint-failed",
+				"message": result.Error(),
+				"issues":  result.Issues,
+			})
+		}
+		lintIssues = result.Issues
+	}
+
 	dashItem := &dashboards.SaveDashboardDTO{
 		Dashboard: dash,
 		Message:   cmd.Message,
@@ -475,15 +489,39 @@ func (hs *HTTPServer) postDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		return response.Error(500, "Error while connecting library panels", err)
 	}
 
-	c.TimeRequest(metrics.MApiDashboardSave)
-	return response.JSON(http.StatusOK, util.DynMap{
+	result := util.DynMap{
 		"status":  "success",
 		"slug":    dashboard.Slug,
 		"version": dashboard.Version,
 		"id":      dashboard.Id,
 		"uid":     dashboard.Uid,
 		"url":     dashboard.GetUrl(),
-	})
+	}
+	if len(lintIssues) > 0 {
+		result["lintIssues"] = lintIssues
+	}
+
+	c.TimeRequest(metrics.MApiDashboardSave)
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:route POST /dashboards/lint dashboards lintDashboard
+//
+// Lint dashboard JSON.
+//
+// Checks the dashboard against the rules configured in the `[dashboards.lint]` section, also when linting on save is disabled.
+//
+// Responses:
+// 200: lintDashboardResponse
+// 400: badRequestError
+// 401: unauthorisedError
+func (hs *HTTPServer) LintDashboard(c *models.ReqContext) response.Response {
+	cmd := dtos.LintDashboardCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	return response.JSON(http.StatusOK, dashlint.New(hs.Cfg.DashboardLint).Lint(cmd.Dashboard))
 }
 
 // swagger:route GET /dashboards/home dashboards getHomeDashboard
@@ -633,6 +671,7 @@ func (hs *HTTPServer) GetDashboardVersions(c *models.ReqContext) response.Respon
 		OrgID:        c.OrgID,
 		DashboardID:  dashID,
 		DashboardUID: dashUID,
+		Tag:          c.Query("tag"),
 		Limit:        c.QueryInt("limit"),
 		Start:        c.QueryInt("start"),
 	}
@@ -831,7 +870,7 @@ func (hs *HTTPServer) CalculateDashboardDiff(c *models.ReqContext) response.Resp
 		return response.Error(500, "Unable to compute diff", err)
 	}
 
-	if options.DiffType == dashdiffs.DiffDelta {
+	if options.DiffType == dashdiffs.DiffDelta || options.DiffType == dashdiffs.DiffSemantic {
 		return response.Respond(http.StatusOK, result.Delta).SetHeader("Content-Type", "application/json")
 	}
 
@@ -873,6 +912,9 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *models.ReqContext) response.Res
 	if err := web.Bind(c.Req, &apiCmd); err != nil {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
+	if apiCmd.Version == 0 && apiCmd.Tag == "" {
+		return response.Error(http.StatusBadRequest, "version or tag is required", nil)
+	}
 	if dashUID == "" {
 		dashID, err = strconv.ParseInt(web.Params(c.Req)[":dashboardId"], 10, 64)
 		if err != nil {
@@ -894,7 +936,7 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *models.ReqContext) response.Res
 		return dashboardGuardianResponse(err)
 	}
 
-	versionQuery := dashver.GetDashboardVersionQuery{DashboardID: dashID, Version: apiCmd.Version, OrgID: c.OrgID}
+	versionQuery := dashver.GetDashboardVersionQuery{DashboardID: dashID, Version: apiCmd.Version, Tag: apiCmd.Tag, OrgID: c.OrgID}
 	version, err := hs.dashboardVersionService.Get(c.Req.Context(), &versionQuery)
 	if err != nil {
 		return response.Error(404, "Dashboard version not found", nil)
@@ -913,6 +955,183 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *models.ReqContext) response.Res
 	return hs.postDashboard(c, saveCmd)
 }
 
+// swagger:route POST /dashboards/uid/{uid}/versions/{DashboardVersionID}/tags dashboard_versions tagDashboardVersionByUID
+//
+// Tag a dashboard version.
+//
+// Tagged versions are never deleted by the version retention. A tag names one version of a dashboard.
+//
+// Responses:
+// 200: tagDashboardVersionResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: conflictError
+// 500: internalServerError
+func (hs *HTTPServer) TagDashboardVersion(c *models.ReqContext) response.Response {
+	cmd := dtos.TagDashboardVersionCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	version, err := strconv.Atoi(web.Params(c.Req)[":id"])
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.OrgID, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgID, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	tag, err := hs.dashboardVersionService.Tag(c.Req.Context(), &dashver.TagDashboardVersionCommand{
+		DashboardID: dash.Id,
+		OrgID:       c.OrgID,
+		Version:     version,
+		Tag:         cmd.Tag,
+		UserID:      c.UserID,
+	})
+	if err != nil {
+		return dashboardVersionErrorResponse(err, "Failed to tag dashboard version")
+	}
+	return response.JSON(http.StatusOK, tag)
+}
+
+// swagger:route DELETE /dashboards/uid/{uid}/versions/tags/{tag} dashboard_versions deleteDashboardVersionTagByUID
+//
+// Delete a dashboard version tag.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) DeleteDashboardVersionTag(c *models.ReqContext) response.Response {
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.OrgID, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgID, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	err := hs.dashboardVersionService.DeleteTag(c.Req.Context(), &dashver.DeleteDashboardVersionTagCommand{
+		DashboardID: dash.Id,
+		OrgID:       c.OrgID,
+		Tag:         web.Params(c.Req)[":tag"],
+	})
+	if err != nil {
+		return dashboardVersionErrorResponse(err, "Failed to delete dashboard version tag")
+	}
+	return response.Success("Dashboard version tag deleted")
+}
+
+func dashboardVersionErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, dashver.ErrDashboardVersionNotFound), errors.Is(err, dashver.ErrVersionTagNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, dashver.ErrVersionTagExists):
+		return response.Error(http.StatusConflict, err.Error(), err)
+	case errors.Is(err, dashver.ErrVersionTagInvalid):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
+
+// swagger:route POST /dashboards/uid/{uid}/merge dashboards mergeDashboardByUID
+//
+// Merge an edit of a dashboard with the changes saved since the version it was edited from.
+//
+// Panels are merged by ID and variables and annotations by name. Properties changed by both are
+// returned as conflicts, the merged dashboard keeps the saved values of conflicting properties.
+// The merged dashboard is only saved if there are no conflicts.
+//
+// Responses:
+// 200: mergeDashboardResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: mergeDashboardResponse
+// 500: internalServerError
+func (hs *HTTPServer) MergeDashboard(c *models.ReqContext) response.Response {
+	cmd := dtos.MergeDashboardCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.OrgID, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgID, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	baseVersion := cmd.BaseVersion
+	if baseVersion == 0 {
+		baseVersion = cmd.Dashboard.Get("version").MustInt()
+	}
+
+	result := &dashdiffs.MergeResult{Dashboard: cmd.Dashboard, Conflicts: []dashdiffs.MergeConflict{}}
+	if baseVersion != dash.Version {
+		versionQuery := dashver.GetDashboardVersionQuery{DashboardID: dash.Id, Version: baseVersion, OrgID: c.OrgID}
+		base, err := hs.dashboardVersionService.Get(c.Req.Context(), &versionQuery)
+		if err != nil {
+			if errors.Is(err, dashver.ErrDashboardVersionNotFound) {
+				return response.Error(404, "Dashboard version not found", err)
+			}
+			return response.Error(500, "Failed to get dashboard version", err)
+		}
+
+		result, err = dashdiffs.Merge(base.Data, cmd.Dashboard, dash.Data)
+		if err != nil {
+			return response.Error(500, "Failed to merge dashboard", err)
+		}
+	}
+
+	if len(result.Conflicts) > 0 {
+		return response.JSON(http.StatusConflict, dtos.MergeDashboardResult{
+			Dashboard: result.Dashboard,
+			Conflicts: result.Conflicts,
+		})
+	}
+
+	if !cmd.Save {
+		changes, err := dashdiffs.CalculateSemanticDiff(dash.Data, result.Dashboard)
+		if err != nil {
+			return response.Error(500, "Failed to compare dashboard", err)
+		}
+		return response.JSON(http.StatusOK, dtos.MergeDashboardResult{
+			Dashboard: result.Dashboard,
+			Conflicts: result.Conflicts,
+			Changes:   changes,
+		})
+	}
+
+	saveCmd := models.SaveDashboardCommand{}
+	saveCmd.OrgId = c.OrgID
+	saveCmd.UserId = c.UserID
+	saveCmd.Dashboard = result.Dashboard
+	saveCmd.Dashboard.Set("id", dash.Id)
+	saveCmd.Dashboard.Set("uid", dash.Uid)
+	saveCmd.Dashboard.Set("version", dash.Version)
+	saveCmd.Message = cmd.Message
+	saveCmd.FolderId = dash.FolderId
+
+	return hs.postDashboard(c, saveCmd)
+}
+
 // swagger:route GET /dashboards/tags dashboards getDashboardTags
 //
 // Get all dashboards tags of an organisation.
@@ -991,6 +1210,16 @@ type RestoreDashboardVersionByUIDParams struct {
 	UID string `json:"uid"`
 }
 
+// swagger:parameters mergeDashboardByUID
+type MergeDashboardByUIDParams struct {
+	// in:body
+	// required:true
+	Body dtos.MergeDashboardCommand
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
 // swagger:parameters getDashboardVersionByID
 type GetDashboardVersionByIDParams struct {
 	// in:path
@@ -1021,6 +1250,33 @@ type GetDashboardVersionsParams struct {
 	// required:false
 	// default:0
 	Start int `json:"start"`
+
+	// Only return versions with the tag
+	// in:query
+	// required:false
+	Tag string `json:"tag"`
+}
+
+// swagger:parameters tagDashboardVersionByUID
+type TagDashboardVersionByUIDParams struct {
+	// in:body
+	// required:true
+	Body dtos.TagDashboardVersionCommand
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// in:path
+	DashboardVersionID int64
+}
+
+// swagger:parameters deleteDashboardVersionTagByUID
+type DeleteDashboardVersionTagByUIDParams struct {
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// in:path
+	// required:true
+	Tag string `json:"tag"`
 }
 
 // swagger:parameters getDashboardByUID
@@ -1067,6 +1323,19 @@ type TrimDashboardParams struct {
 	Body models.TrimDashboardCommand
 }
 
+// swagger:parameters lintDashboard
+type LintDashboardParams struct {
+	// in:body
+	// required:true
+	Body dtos.LintDashboardCommand
+}
+
+// swagger:response lintDashboardResponse
+type LintDashboardResponse struct {
+	// in: body
+	Body dashlint.Result `json:"body"`
+}
+
 // swagger:response dashboardResponse
 type DashboardResponse struct {
 	// The response message
@@ -1138,6 +1407,18 @@ type CalculateDashboardDiffResponse struct {
 	Body []byte `json:"body"`
 }
 
+// swagger:response mergeDashboardResponse
+type MergeDashboardResponse struct {
+	// in: body
+	Body dtos.MergeDashboardResult `json:"body"`
+}
+
+// swagger:response tagDashboardVersionResponse
+type TagDashboardVersionResponse struct {
+	// in: body
+	Body dashver.DashboardVersionTag `json:"body"`
+}
+
 // swagger:response trimDashboardResponse
 type TrimDashboardResponse struct {
 	// in: body
@@ -861,6 +861,114 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 			}, mockSQLStore)
 	})
 
+	t.Run("Given dashboard version restored by tag", func(t *testing.T) {
+		origNewGuardian := guardian.New
+		t.Cleanup(func() {
+			guardian.New = origNewGuardian
+		})
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: true})
+
+		fakeDash := models.NewDashboard("Child dash")
+		fakeDash.Id = 2
+		fakeDash.HasACL = false
+
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*models.GetDashboardQuery")).Run(func(args mock.Arguments) {
+			q := args.Get(1).(*models.GetDashboardQuery)
+			q.Result = fakeDash
+		}).Return(nil).Maybe()
+		dashboardService.On("SaveDashboard", mock.Anything, mock.AnythingOfType("*dashboards.SaveDashboardDTO"), mock.AnythingOfType("bool")).Run(func(args mock.Arguments) {
+			cmd := args.Get(1).(*dashboards.SaveDashboardDTO)
+			cmd.Dashboard = &models.Dashboard{
+				Id: 2, Uid: "uid", Title: "Dash", Slug: "dash", Version: 3,
+			}
+		}).Return(nil, nil).Maybe()
+
+		fakeDashboardVersionService := dashvertest.NewDashboardVersionServiceFake()
+		fakeDashboardVersionService.ExpectedDashboardVersion = &dashver.DashboardVersion{
+			DashboardID: 2,
+			Version:     1,
+			Data:        fakeDash.Data,
+		}
+		mockSQLStore := mockstore.NewSQLStoreMock()
+
+		restoreDashboardVersionScenario(t, "When calling POST on", "/api/dashboards/uid/uid/restore",
+			"/api/dashboards/uid/:uid/restore", dashboardService, fakeDashboardVersionService, dtos.RestoreDashboardVersionCommand{Tag: "release"}, func(sc *scenarioContext) {
+				callRestoreDashboardVersion(sc)
+				assert.Equal(t, 200, sc.resp.Code)
+			}, mockSQLStore)
+
+		restoreDashboardVersionScenario(t, "When calling POST without a version or tag on", "/api/dashboards/uid/uid/restore",
+			"/api/dashboards/uid/:uid/restore", dashboardService, fakeDashboardVersionService, dtos.RestoreDashboardVersionCommand{}, func(sc *scenarioContext) {
+				callRestoreDashboardVersion(sc)
+				assert.Equal(t, 400, sc.resp.Code)
+			}, mockSQLStore)
+	})
+
+	t.Run("Given dashboard edited from an older version being merged", func(t *testing.T) {
+		origNewGuardian := guardian.New
+		t.Cleanup(func() {
+			guardian.New = origNewGuardian
+		})
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: true})
+
+		base, err := simplejson.NewJson([]byte(`{"title": "Dash", "version": 1, "panels": [{"id": 1, "title": "CPU"}, {"id": 2, "title": "Memory"}]}`))
+		require.NoError(t, err)
+		saved, err := simplejson.NewJson([]byte(`{"id": 2, "uid": "uid", "title": "Dash", "version": 2, "panels": [{"id": 1, "title": "CPU"}, {"id": 2, "title": "Memory usage"}]}`))
+		require.NoError(t, err)
+		fakeDash := models.NewDashboardFromJson(saved)
+		fakeDash.Id = 2
+		fakeDash.Version = 2
+
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*models.GetDashboardQuery")).Run(func(args mock.Arguments) {
+			q := args.Get(1).(*models.GetDashboardQuery)
+			q.Result = fakeDash
+		}).Return(nil)
+
+		mockSQLStore := mockstore.NewSQLStoreMock()
+
+		t.Run("should return the merged dashboard", func(t *testing.T) {
+			edited, err := simplejson.NewJson([]byte(`{"title": "Dash", "version": 1, "panels": [{"id": 1, "title": "CPU usage"}, {"id": 2, "title": "Memory"}]}`))
+			require.NoError(t, err)
+			fakeDashboardVersionService := dashvertest.NewDashboardVersionServiceFake()
+			fakeDashboardVersionService.ExpectedDashboardVersion = &dashver.DashboardVersion{DashboardID: 2, Version: 1, Data: base}
+
+			cmd := dtos.MergeDashboardCommand{Dashboard: edited}
+			mergeDashboardScenario(t, "When calling POST on", "/api/dashboards/uid/uid/merge",
+				"/api/dashboards/uid/:uid/merge", dashboardService, fakeDashboardVersionService, cmd, func(sc *scenarioContext) {
+					callPostDashboard(sc)
+					require.Equal(t, 200, sc.resp.Code)
+
+					result := sc.ToJSON()
+					assert.Empty(t, result.Get("conflicts").MustArray())
+					assert.Equal(t, 2, result.Get("dashboard").Get("version").MustInt())
+					panels := result.Get("dashboard").Get("panels")
+					assert.Equal(t, "CPU usage", panels.GetIndex(0).Get("title").MustString())
+					assert.Equal(t, "Memory usage", panels.GetIndex(1).Get("title").MustString())
+					assert.Len(t, result.Get("changes").MustArray(), 1)
+				}, mockSQLStore)
+		})
+
+		t.Run("should return conflicts", func(t *testing.T) {
+			edited, err := simplejson.NewJson([]byte(`{"title": "Dash", "version": 1, "panels": [{"id": 1, "title": "CPU"}, {"id": 2, "title": "RAM"}]}`))
+			require.NoError(t, err)
+			fakeDashboardVersionService := dashvertest.NewDashboardVersionServiceFake()
+			fakeDashboardVersionService.ExpectedDashboardVersion = &dashver.DashboardVersion{DashboardID: 2, Version: 1, Data: base}
+
+			cmd := dtos.MergeDashboardCommand{Dashboard: edited, Save: true}
+			mergeDashboardScenario(t, "When calling POST on", "/api/dashboards/uid/uid/merge",
+				"/api/dashboards/uid/:uid/merge", dashboardService, fakeDashboardVersionService, cmd, func(sc *scenarioContext) {
+					callPostDashboard(sc)
+					require.Equal(t, 409, sc.resp.Code)
+
+					conflicts := sc.ToJSON().Get("conflicts")
+					assert.Len(t, conflicts.MustArray(), 1)
+					assert.Equal(t, "panels[id=2].title", conflicts.GetIndex(0).Get("path").MustString())
+				}, mockSQLStore)
+		})
+	})
+
 	t.Run("Given provisioned dashboard", func(t *testing.T) {
 		mockSQLStore := mockstore.NewSQLStoreMock()
 		dashboardStore := dashboards.NewFakeDashboardStore(t)
@@ -1003,6 +1111,83 @@ func (hs *HTTPServer) callDeleteDashboardByUID(t *testing.T,
 	sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
 }
 
+func TestDashboardLintAPIEndpoint(t *testing.T) {
+	lintCfg := setting.DashboardLintSettings{
+		Enabled:    true,
+		Severities: map[string]string{"datasource_uid": "warning", "max_panels": "error"},
+		MaxPanels:  1,
+	}
+	panel := map[string]interface{}{"id": 1, "datasource": "Prometheus", "targets": []interface{}{map[string]interface{}{"refId": "A"}}}
+
+	t.Run("When linting a dashboard, it should return the issues", func(t *testing.T) {
+		body := dtos.LintDashboardCommand{Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title":  "Dash",
+			"panels": []interface{}{panel, map[string]interface{}{"id": 2, "type": "text"}},
+		})}
+		lintCfg := lintCfg
+		lintCfg.Enabled = false
+		dashboardLintScenario(t, lintCfg, body, nil, func(hs *HTTPServer, c *models.ReqContext) response.Response {
+			return hs.LintDashboard(c)
+		}, func(sc *scenarioContext) {
+			callPostDashboard(sc)
+			require.Equal(t, 200, sc.resp.Code)
+
+			result := sc.ToJSON()
+			assert.False(t, result.Get("valid").MustBool())
+			issues := result.Get("issues").MustArray()
+			require.Len(t, issues, 2)
+			assert.Equal(t, "datasource_uid", result.Get("issues").GetIndex(0).Get("rule").MustString())
+			assert.Equal(t, "warning", result.Get("issues").GetIndex(0).Get("severity").MustString())
+			assert.Equal(t, "max_panels", result.Get("issues").GetIndex(1).Get("rule").MustString())
+			assert.Equal(t, "error", result.Get("issues").GetIndex(1).Get("severity").MustString())
+		})
+	})
+
+	t.Run("When saving a dashboard with lint errors, it should not save it", func(t *testing.T) {
+		cmd := models.SaveDashboardCommand{
+			OrgId:  1,
+			UserId: 5,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title":  "Dash",
+				"panels": []interface{}{panel, map[string]interface{}{"id": 2, "type": "text"}},
+			}),
+		}
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardLintScenario(t, lintCfg, cmd, dashboardService, func(hs *HTTPServer, c *models.ReqContext) response.Response {
+			return hs.PostDashboard(c)
+		}, func(sc *scenarioContext) {
+			callPostDashboard(sc)
+			require.Equal(t, 400, sc.resp.Code)
+
+			result := sc.ToJSON()
+			assert.Equal(t, "lint-failed", result.Get("status").MustString())
+			assert.Len(t, result.Get("issues").MustArray(), 2)
+			dashboardService.AssertNotCalled(t, "SaveDashboard", mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("When saving a dashboard with lint warnings, it should save it and return the warnings", func(t *testing.T) {
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			UserId:    5,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Dash", "panels": []interface{}{panel}}),
+		}
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("SaveDashboard", mock.Anything, mock.AnythingOfType("*dashboards.SaveDashboardDTO"), mock.AnythingOfType("bool")).
+			Return(&models.Dashboard{Id: 2, Uid: "uid", Title: "Dash", Slug: "dash", Version: 1}, nil)
+		dashboardLintScenario(t, lintCfg, cmd, dashboardService, func(hs *HTTPServer, c *models.ReqContext) response.Response {
+			return hs.PostDashboard(c)
+		}, func(sc *scenarioContext) {
+			callPostDashboardShouldReturnSuccess(sc)
+
+			result := sc.ToJSON()
+			assert.Equal(t, "success", result.Get("status").MustString())
+			require.Len(t, result.Get("lintIssues").MustArray(), 1)
+			assert.Equal(t, "datasource_uid", result.Get("lintIssues").GetIndex(0).Get("rule").MustString())
+		})
+	})
+}
+
 func callPostDashboard(sc *scenarioContext) {
 	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
 }
@@ -1052,6 +1237,39 @@ func postDashboardScenario(t *testing.T, desc string, url string, routePattern s
 	})
 }
 
+func dashboardLintScenario(t *testing.T, lintCfg setting.DashboardLintSettings, body interface{}, dashboardService dashboards.DashboardService,
+	handler func(hs *HTTPServer, c *models.ReqContext) response.Response, fn scenarioFunc) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.DashboardLint = lintCfg
+	hs := &HTTPServer{
+		Cfg:                   cfg,
+		ProvisioningService:   provisioning.NewProvisioningServiceMock(context.Background()),
+		QuotaService:          &quotaimpl.Service{Cfg: cfg},
+		pluginStore:           &plugins.FakePluginStore{},
+		LibraryPanelService:   &mockLibraryPanelService{},
+		LibraryElementService: &mockLibraryElementService{},
+		DashboardService:      dashboardService,
+		Features:              featuremgmt.WithFeatures(),
+		Coremodels:            registry.NewBase(),
+	}
+
+	sc := setupScenarioContext(t, "/api/dashboards")
+	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+		c.Req.Body = mockRequestBody(body)
+		c.Req.Header.Add("Content-Type", "application/json")
+		sc.context = c
+		sc.context.SignedInUser = &user.SignedInUser{OrgID: 1, UserID: 5}
+
+		return handler(hs, c)
+	})
+
+	sc.m.Post("/api/dashboards", sc.defaultHandler)
+
+	fn(sc)
+}
+
 func postDiffScenario(t *testing.T, desc string, url string, routePattern string, cmd dtos.CalculateDiffOptions,
 	role org.RoleType, fn scenarioFunc, sqlmock sqlstore.Store, fakeDashboardVersionService *dashvertest.FakeDashboardVersionService) {
 	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
@@ -1130,6 +1348,47 @@ func restoreDashboardVersionScenario(t *testing.T, desc string, url string, rout
 	})
 }
 
+func mergeDashboardScenario(t *testing.T, desc string, url string, routePattern string,
+	mock *dashboards.FakeDashboardService, fakeDashboardVersionService *dashvertest.FakeDashboardVersionService,
+	cmd dtos.MergeDashboardCommand, fn scenarioFunc, sqlStore sqlstore.Store) {
+	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
+		cfg := setting.NewCfg()
+		hs := HTTPServer{
+			Cfg:                     cfg,
+			ProvisioningService:     provisioning.NewProvisioningServiceMock(context.Background()),
+			Live:                    newTestLive(t, sqlstore.InitTestDB(t)),
+			QuotaService:            &quotaimpl.Service{Cfg: cfg},
+			LibraryPanelService:     &mockLibraryPanelService{},
+			LibraryElementService:   &mockLibraryElementService{},
+			DashboardService:        mock,
+			SQLStore:                sqlStore,
+			Features:                featuremgmt.WithFeatures(),
+			dashboardVersionService: fakeDashboardVersionService,
+			Coremodels:              registry.NewBase(),
+		}
+
+		sc := setupScenarioContext(t, url)
+		sc.sqlStore = sqlStore
+		sc.dashboardVersionService = fakeDashboardVersionService
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			c.Req.Body = mockRequestBody(cmd)
+			c.Req.Header.Add("Content-Type", "application/json")
+			sc.context = c
+			sc.context.SignedInUser = &user.SignedInUser{
+				OrgID:  testOrgID,
+				UserID: testUserID,
+			}
+			sc.context.OrgRole = org.RoleAdmin
+
+			return hs.MergeDashboard(c)
+		})
+
+		sc.m.Post(routePattern, sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
 func (sc *scenarioContext) ToJSON() *simplejson.Json {
 	result := simplejson.New()
 	err := json.NewDecoder(sc.resp.Body).Decode(result)
@@ -1187,6 +1446,11 @@ func (l *mockLibraryElementService) ConnectElementsToDashboard(c context.Context
 	return nil
 }
 
+// ConnectElementVersionsToDashboard connects elements to a specific dashboard, pinning them to versions.
+func (l *mockLibraryElementService) ConnectElementVersionsToDashboard(c context.Context, signedInUser *user.SignedInUser, elementVersions map[string]int64, dashboardID int64) error {
+	return nil
+}
+
 // DisconnectElementsFromDashboard disconnects elements from a specific dashboard.
 func (l *mockLibraryElementService) DisconnectElementsFromDashboard(c context.Context, dashboardID int64) error {
 	return nil
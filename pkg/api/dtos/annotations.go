@@ -14,6 +14,11 @@ type PostAnnotationsCmd struct {
 	Data *simplejson.Json `json:"data"`
 }
 
+type PostAnnotationsBulkCmd struct {
+	// required: true
+	Annotations []PostAnnotationsCmd `json:"annotations"`
+}
+
 type UpdateAnnotationsCmd struct {
 	Id      int64    `json:"id"`
 	Time    int64    `json:"time"`
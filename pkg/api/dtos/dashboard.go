@@ -3,6 +3,7 @@ package dtos
 import (
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
@@ -57,6 +58,10 @@ type TrimDashboardFullWithMeta struct {
 	Dashboard *simplejson.Json `json:"dashboard"`
 }
 
+type LintDashboardCommand struct {
+	Dashboard *simplejson.Json `json:"dashboard" binding:"Required"`
+}
+
 type DashboardRedirect struct {
 	RedirectUri string `json:"redirectUri"`
 }
@@ -74,5 +79,32 @@ type CalculateDiffTarget struct {
 }
 
 type RestoreDashboardVersionCommand struct {
-	Version int `json:"version" binding:"Required"`
+	Version int `json:"version"`
+	// Restore the version with the tag instead of Version
+	Tag string `json:"tag"`
+}
+
+type TagDashboardVersionCommand struct {
+	Tag string `json:"tag" binding:"Required"`
+}
+
+type FolderVersionRetention struct {
+	// Number of versions kept for each dashboard in the folder, 0 keeps any number of versions
+	VersionsToKeep int `json:"versionsToKeep"`
+	// Versions older than the max age are deleted, e.g. 90d. Empty keeps versions of any age.
+	MaxAge string `json:"maxAge"`
+}
+
+type MergeDashboardCommand struct {
+	Dashboard *simplejson.Json `json:"dashboard" binding:"Required"`
+	// Version the dashboard was edited from, defaults to the version of the dashboard
+	BaseVersion int    `json:"baseVersion"`
+	Save        bool   `json:"save"`
+	Message     string `json:"message"`
+}
+
+type MergeDashboardResult struct {
+	Dashboard *simplejson.Json           `json:"dashboard"`
+	Conflicts []dashdiffs.MergeConflict  `json:"conflicts"`
+	Changes   []dashdiffs.SemanticChange `json:"changes,omitempty"`
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 )
 
 var regNonAlphaNumeric = regexp.MustCompile("[^a-zA-Z0-9]+")
@@ -70,6 +71,15 @@ type MetricRequest struct {
 	Queries []*simplejson.Json `json:"queries"`
 	// required: false
 	Debug bool `json:"debug"`
+	// Variables are the current values of dashboard template variables, keyed by variable name. When set, variables
+	// referenced in the queries are interpolated before the queries are sent to the data source.
+	// required: false
+	// example: { "host": { "value": ["web01", "web02"] }, "env": { "value": "prod", "text": "Production" } }
+	Variables map[string]*templatevars.Variable `json:"variables,omitempty"`
+	// DashboardUID is the dashboard the queries belong to. When no variables are sent, the variables saved with the
+	// dashboard are interpolated instead. The user needs to be allowed to view the dashboard.
+	// required: false
+	DashboardUID string `json:"dashboardUID,omitempty"`
 
 	PublicDashboardAccessToken string `json:"publicDashboardAccessToken"`
 
@@ -97,11 +107,13 @@ func (mr *MetricRequest) GetUniqueDatasourceTypes() []string {
 
 func (mr *MetricRequest) CloneWithQueries(queries []*simplejson.Json) MetricRequest {
 	return MetricRequest{
-		From:        mr.From,
-		To:          mr.To,
-		Queries:     queries,
-		Debug:       mr.Debug,
-		HTTPRequest: mr.HTTPRequest,
+		From:         mr.From,
+		To:           mr.To,
+		Queries:      queries,
+		Debug:        mr.Debug,
+		Variables:    mr.Variables,
+		DashboardUID: mr.DashboardUID,
+		HTTPRequest:  mr.HTTPRequest,
 	}
 }
 
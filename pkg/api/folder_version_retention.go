@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /folders/{folder_uid}/version-retention folders getFolderVersionRetention
+//
+// Gets the dashboard version retention policy of the folder with the given `uid`.
+//
+// Dashboards in folders without a retention policy keep the number of versions configured by `versions_to_keep`.
+//
+// Responses:
+// 200: folderVersionRetentionResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetFolderVersionRetention(c *models.ReqContext) response.Response {
+	folder, err := hs.folderService.GetFolderByUID(c.Req.Context(), c.SignedInUser, c.OrgID, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	policy, err := hs.dashboardVersionService.GetRetentionPolicy(c.Req.Context(), &dashver.GetRetentionPolicyQuery{
+		OrgID:    c.OrgID,
+		FolderID: folder.Id,
+	})
+	if err != nil {
+		if errors.Is(err, dashver.ErrRetentionPolicyNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get version retention policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route PUT /folders/{folder_uid}/version-retention folders setFolderVersionRetention
+//
+// Sets the dashboard version retention policy of the folder with the given `uid`.
+//
+// Versions of the dashboards in the folder are deleted when there are more than `versionsToKeep` newer versions
+// or when they are older than `maxAge`. The latest and tagged versions are always kept.
+//
+// Responses:
+// 200: folderVersionRetentionResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) SetFolderVersionRetention(c *models.ReqContext) response.Response {
+	cmd := dtos.FolderVersionRetention{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	folder, rsp := hs.getFolderForVersionRetention(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	policy, err := hs.dashboardVersionService.SetRetentionPolicy(c.Req.Context(), &dashver.SetRetentionPolicyCommand{
+		OrgID:          c.OrgID,
+		FolderID:       folder.Id,
+		VersionsToKeep: cmd.VersionsToKeep,
+		MaxAge:         cmd.MaxAge,
+	})
+	if err != nil {
+		if errors.Is(err, dashver.ErrRetentionPolicyInvalid) {
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to set version retention policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route DELETE /folders/{folder_uid}/version-retention folders deleteFolderVersionRetention
+//
+// Deletes the dashboard version retention policy of the folder with the given `uid`.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) DeleteFolderVersionRetention(c *models.ReqContext) response.Response {
+	folder, rsp := hs.getFolderForVersionRetention(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	err := hs.dashboardVersionService.DeleteRetentionPolicy(c.Req.Context(), &dashver.DeleteRetentionPolicyCommand{
+		OrgID:    c.OrgID,
+		FolderID: folder.Id,
+	})
+	if err != nil {
+		if errors.Is(err, dashver.ErrRetentionPolicyNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete version retention policy", err)
+	}
+	return response.Success("Version retention policy deleted")
+}
+
+// getFolderForVersionRetention returns the folder if the user can change its
+// retention policy, which requires admin permission as it deletes history
+func (hs *HTTPServer) getFolderForVersionRetention(c *models.ReqContext) (*models.Folder, response.Response) {
+	folder, err := hs.folderService.GetFolderByUID(c.Req.Context(), c.SignedInUser, c.OrgID, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return nil, apierrors.ToFolderErrorResponse(err)
+	}
+
+	g := guardian.New(c.Req.Context(), folder.Id, c.OrgID, c.SignedInUser)
+	if canAdmin, err := g.CanAdmin(); err != nil || !canAdmin {
+		return nil, apierrors.ToFolderErrorResponse(dashboards.ErrFolderAccessDenied)
+	}
+	return folder, nil
+}
+
+// swagger:parameters getFolderVersionRetention deleteFolderVersionRetention
+type FolderVersionRetentionParams struct {
+	// in:path
+	// required:true
+	FolderUID string `json:"folder_uid"`
+}
+
+// swagger:parameters setFolderVersionRetention
+type SetFolderVersionRetentionParams struct {
+	// in:path
+	// required:true
+	FolderUID string `json:"folder_uid"`
+	// in:body
+	// required:true
+	Body dtos.FolderVersionRetention `json:"body"`
+}
+
+// swagger:response folderVersionRetentionResponse
+type FolderVersionRetentionResponse struct {
+	// in: body
+	Body dashver.RetentionPolicy `json:"body"`
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/manager/process"
 	"github.com/grafana/grafana/pkg/plugins/repo"
 	"github.com/grafana/grafana/pkg/plugins/storage"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -375,7 +376,19 @@ func (hs *HTTPServer) CheckHealth(c *models.ReqContext) response.Response {
 		PluginContext: pCtx,
 		Headers:       map[string]string{},
 	})
+	processStatus, hasProcess := hs.pluginProcessStatus(pluginID)
 	if err != nil {
+		if hasProcess && errors.Is(err, backendplugin.ErrPluginUnavailable) {
+			message := "Plugin process is restarting"
+			if processStatus.CrashLooping {
+				message = "Plugin process keeps crashing and is restarted with backoff"
+			}
+			return response.JSON(503, map[string]interface{}{
+				"status":  backend.HealthStatusError.String(),
+				"message": message,
+				"process": processStatus,
+			})
+		}
 		return translatePluginRequestErrorToAPIError(err)
 	}
 
@@ -383,6 +396,9 @@ func (hs *HTTPServer) CheckHealth(c *models.ReqContext) response.Response {
 		"status":  resp.Status.String(),
 		"message": resp.Message,
 	}
+	if hasProcess {
+		payload["process"] = processStatus
+	}
 
 	// Unmarshal JSONDetails if it's not empty.
 	if len(resp.JSONDetails) > 0 {
@@ -402,6 +418,15 @@ func (hs *HTTPServer) CheckHealth(c *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, payload)
 }
 
+// pluginProcessStatus returns the status of the backend process of a plugin,
+// if it's managed by Grafana.
+func (hs *HTTPServer) pluginProcessStatus(pluginID string) (process.Status, bool) {
+	if hs.pluginProcessManager == nil {
+		return process.Status{}, false
+	}
+	return hs.pluginProcessManager.Status(pluginID)
+}
+
 func (hs *HTTPServer) GetPluginErrorsList(_ *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, hs.pluginErrorResolver.PluginErrors())
 }
@@ -465,6 +490,61 @@ func (hs *HTTPServer) UninstallPlugin(c *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, []byte{})
 }
 
+// LoadPlugin loads a plugin from the plugin directories.
+// /api/plugins/:pluginId/load
+func (hs *HTTPServer) LoadPlugin(c *models.ReqContext) response.Response {
+	pluginID := web.Params(c.Req)[":pluginId"]
+
+	if err := hs.pluginReloader.Load(c.Req.Context(), pluginID); err != nil {
+		var dupeErr plugins.DuplicateError
+		if errors.As(err, &dupeErr) {
+			return response.Error(http.StatusConflict, "Plugin already loaded", err)
+		}
+		var notFoundErr plugins.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return response.Error(http.StatusNotFound, "Plugin not found in plugin directories", err)
+		}
+		return translatePluginReloadError(err, "Failed to load plugin")
+	}
+	return response.Success("Plugin loaded")
+}
+
+// UnloadPlugin stops a plugin and unregisters it without removing it from the
+// file system.
+// /api/plugins/:pluginId/unload
+func (hs *HTTPServer) UnloadPlugin(c *models.ReqContext) response.Response {
+	pluginID := web.Params(c.Req)[":pluginId"]
+
+	if err := hs.pluginReloader.Unload(c.Req.Context(), pluginID); err != nil {
+		return translatePluginReloadError(err, "Failed to unload plugin")
+	}
+	return response.Success("Plugin unloaded")
+}
+
+// ReloadPlugin reloads a plugin from its directory.
+// /api/plugins/:pluginId/reload
+func (hs *HTTPServer) ReloadPlugin(c *models.ReqContext) response.Response {
+	pluginID := web.Params(c.Req)[":pluginId"]
+
+	if err := hs.pluginReloader.Reload(c.Req.Context(), pluginID); err != nil {
+		return translatePluginReloadError(err, "Failed to reload plugin")
+	}
+	return response.Success("Plugin reloaded")
+}
+
+func translatePluginReloadError(err error, message string) response.Response {
+	if errors.Is(err, plugins.ErrPluginNotInstalled) {
+		return response.Error(http.StatusNotFound, "Plugin not loaded", err)
+	}
+	if errors.Is(err, plugins.ErrUnloadCorePlugin) {
+		return response.Error(http.StatusForbidden, "Cannot load or unload a Core plugin", err)
+	}
+	if errors.Is(err, plugins.ErrPluginNotLoaded) {
+		return response.Error(http.StatusUnprocessableEntity, "Plugin could not be loaded, check plugin errors for details", err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
+
 func translatePluginRequestErrorToAPIError(err error) response.Response {
 	if errors.Is(err, backendplugin.ErrPluginNotRegistered) {
 		return response.Error(404, "Plugin not found", err)
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/plugins/repo"
+)
+
+// buildMirrorCommand copies plugins from the plugin repository into a static
+// mirror directory which can be used as a plugin repository by setting the
+// repository URL to its index.json.
+func (cmd Command) buildMirrorCommand(c utils.CommandLine) error {
+	dir := c.Args().First()
+	if dir == "" {
+		return errors.New("please specify the mirror directory")
+	}
+	if c.Args().Len() < 2 {
+		return errors.New("please specify plugins to copy into the mirror")
+	}
+
+	var plugins []repo.MirrorPlugin
+	for _, arg := range c.Args().Slice()[1:] {
+		id, version, _ := strings.Cut(arg, "@")
+		plugins = append(plugins, repo.MirrorPlugin{ID: id, Version: version})
+	}
+
+	repository := repo.New(c.Bool("insecure"), c.PluginRepoURL(), services.Logger)
+	compatOpts := repo.NewCompatOpts(services.GrafanaVersion, runtime.GOOS, runtime.GOARCH)
+	if err := repository.BuildMirror(context.Background(), dir, plugins, c.StringSlice("arch"), compatOpts); err != nil {
+		return err
+	}
+
+	logger.Infof("Mirror index written to %s\n", dir)
+	return nil
+}
@@ -34,7 +34,7 @@ func RunCLI(version string) int {
 			},
 			&cli.StringFlag{
 				Name:    "repo",
-				Usage:   "URL to the plugin repository",
+				Usage:   "URL to the plugin repository, or path or URL to the index.json of a plugin repository mirror",
 				Value:   "https://grafana.com/api/plugins",
 				EnvVars: []string{"GF_PLUGIN_REPO"},
 			},
@@ -7,6 +7,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/datamigrations"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/orgbackup"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/secretsmigrations"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/runner"
@@ -72,6 +73,19 @@ func runDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore
 	}
 }
 
+func runCfgCommand(command func(commandLine utils.CommandLine, cfg *setting.Cfg) error) func(context *cli.Context) error {
+	return func(context *cli.Context) error {
+		cmd := &utils.ContextCommandLine{Context: context}
+
+		cfg, err := initCfg(cmd)
+		if err != nil {
+			return fmt.Errorf("%v: %w", "failed to load configuration", err)
+		}
+
+		return command(cmd, cfg)
+	}
+}
+
 func initCfg(cmd *utils.ContextCommandLine) (*setting.Cfg, error) {
 	configOptions := strings.Split(cmd.String("configOverrides"), " ")
 	cfg, err := setting.NewCfgFromArgs(setting.CommandLineArgs{
@@ -144,6 +158,16 @@ var pluginCommands = []*cli.Command{
 		Aliases: []string{"remove"},
 		Usage:   "uninstall <plugin id>",
 		Action:  runPluginCommand(cmd.removeCommand),
+	}, {
+		Name:   "build-mirror",
+		Usage:  "build-mirror <mirror directory> <plugin id>[@<version>] ...",
+		Action: runPluginCommand(cmd.buildMirrorCommand),
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "arch",
+				Usage: "Systems to copy plugin archives for, e.g. linux-amd64. Defaults to all systems supported by the plugin",
+			},
+		},
 	},
 }
 
@@ -169,6 +193,40 @@ var adminCommands = []*cli.Command{
 				Usage:  "Migrates passwords from unsecured fields to secure_json_data field. Return ok unless there is an error. Safe to execute multiple times.",
 				Action: runDbCommand(datamigrations.EncryptDatasourcePasswords),
 			},
+			{
+				Name:   "alert-annotations",
+				Usage:  "Copies annotations created by alerts between the sql and loki annotation backends, configured in [annotations.loki]. Safe to execute multiple times with --delete-source.",
+				Action: runDbCommand(datamigrations.MigrateAlertAnnotations),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "from",
+						Usage: "Backend to copy annotations from, sql or loki",
+						Value: "sql",
+					},
+					&cli.StringFlag{
+						Name:  "to",
+						Usage: "Backend to copy annotations to, sql or loki",
+						Value: "loki",
+					},
+					&cli.IntFlag{
+						Name:  "org-id",
+						Usage: "Only copy annotations of this org",
+					},
+					&cli.StringFlag{
+						Name:  "max-age",
+						Usage: "Only copy annotations newer than this, e.g. 7d. Annotations of the last 30 days are copied from loki by default",
+					},
+					&cli.BoolFlag{
+						Name:  "delete-source",
+						Usage: "Delete copied annotations from sql",
+					},
+					&cli.IntFlag{
+						Name:  "batch-size",
+						Usage: "Number of annotations copied at once",
+						Value: 500,
+					},
+				},
+			},
 		},
 	},
 	{
@@ -192,6 +250,47 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "lint-dashboards",
+		Usage:  "lint-dashboards <file or directory>... Lints dashboard JSON files with the rules configured in [dashboards.lint]. Returns an error when a dashboard has lint errors.",
+		Action: runCfgCommand(lintDashboardsCommand),
+	},
+	{
+		Name:  "org-backup",
+		Usage: "Backs up an org and restores backups to new orgs",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "create",
+				Usage:  "create <org id> <file>. Writes a tar.gz archive with the dashboards, data sources, alerting, teams and other settings of the org.",
+				Action: runRunnerCommand(orgbackup.CreateBackup),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "passphrase-from-stdin",
+						Usage: "Read the passphrase the secrets are encrypted with from stdin. Secrets are not included without a passphrase",
+					},
+				},
+			},
+			{
+				Name:   "restore",
+				Usage:  "restore <file>. Restores a backup to a new org.",
+				Action: runRunnerCommand(orgbackup.RestoreBackup),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "org-name",
+						Usage: "Name of the new org, defaults to the name of the backed up org",
+					},
+					&cli.BoolFlag{
+						Name:  "new-uids",
+						Usage: "Replace the UIDs of dashboards, folders, data sources, alert rules, library panels and playlists",
+					},
+					&cli.BoolFlag{
+						Name:  "passphrase-from-stdin",
+						Usage: "Read the passphrase the secrets were encrypted with from stdin",
+					},
+				},
+			},
+		},
+	},
 	{
 		Name:  "user-manager",
 		Usage: "Runs different helpful user commands",
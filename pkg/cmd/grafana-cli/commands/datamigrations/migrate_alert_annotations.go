@@ -0,0 +1,44 @@
+package datamigrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationsimpl"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+)
+
+// MigrateAlertAnnotations copies annotations created by alerts between the
+// annotation storage backends.
+func MigrateAlertAnnotations(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	opts := annotationsimpl.MigrationOptions{
+		From:         c.String("from"),
+		To:           c.String("to"),
+		OrgID:        int64(c.Int("org-id")),
+		DeleteSource: c.Bool("delete-source"),
+		BatchSize:    c.Int("batch-size"),
+	}
+	if maxAge := c.String("max-age"); maxAge != "" {
+		d, err := gtime.ParseDuration(maxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max age %q: %w", maxAge, err)
+		}
+		opts.Since = time.Now().Add(-d)
+	}
+
+	tagService := tagimpl.ProvideService(sqlStore, sqlStore.Cfg)
+	migrated, err := annotationsimpl.MigrateAlertAnnotations(context.Background(), sqlStore, sqlStore.Cfg, tagService, opts)
+	if err != nil {
+		return fmt.Errorf("failed to migrate alert annotations after %d annotations: %w", migrated, err)
+	}
+
+	logger.Infof("%s Migrated %d alert annotations from %s to %s\n", color.GreenString("✔"), migrated, opts.From, opts.To)
+	return nil
+}
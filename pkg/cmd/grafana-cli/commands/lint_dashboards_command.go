@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/dashlint"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// lintDashboardsCommand lints dashboard JSON files, or the JSON files in
+// directories, with the rules of the [dashboards.lint] section. It fails when
+// a dashboard has errors.
+func lintDashboardsCommand(c utils.CommandLine, cfg *setting.Cfg) error {
+	var paths []string
+	for _, arg := range c.Args().Slice() {
+		// config overrides are passed as arguments too
+		if !strings.HasPrefix(arg, "cfg:") {
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) == 0 {
+		return errors.New("lint-dashboards requires at least one dashboard file or directory")
+	}
+
+	files, err := findDashboardFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	linter := dashlint.New(cfg.DashboardLint)
+	invalid := 0
+	for _, file := range files {
+		// We can ignore the gosec G304 warning on this one because `file` comes
+		// from the command line arguments.
+		// nolint:gosec
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		dash, err := simplejson.NewJson(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		// dashboards saved from the API are wrapped with their meta data
+		if inner, ok := dash.CheckGet("dashboard"); ok && inner.Get("panels").Interface() != nil {
+			dash = inner
+		}
+
+		result := linter.Lint(dash)
+		for _, issue := range result.Issues {
+			severity := color.YellowString(string(issue.Severity))
+			if issue.Severity == dashlint.SeverityError {
+				severity = color.RedString(string(issue.Severity))
+			}
+			logger.Infof("%s: %s %s\n", file, severity, issue)
+		}
+		if !result.Valid {
+			invalid++
+		}
+	}
+
+	logger.Infof("Linted %d dashboards, %d with errors\n", len(files), invalid)
+	if invalid > 0 {
+		return fmt.Errorf("%d dashboards have lint errors", invalid)
+	}
+	return nil
+}
+
+func findDashboardFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (p == path || strings.EqualFold(filepath.Ext(p), ".json")) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	return files, nil
+}
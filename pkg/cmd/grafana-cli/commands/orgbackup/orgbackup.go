@@ -0,0 +1,124 @@
+package orgbackup
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/runner"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/export"
+)
+
+// CreateBackup writes a backup archive of an org: create <org id> <file>
+func CreateBackup(c utils.CommandLine, runner runner.Runner) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("usage: create <org id> <file>")
+	}
+	orgID, err := strconv.ParseInt(c.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid org id: %s", c.Args().Get(0))
+	}
+	passphrase, err := readPassphrase(c)
+	if err != nil {
+		return err
+	}
+
+	fpath := filepath.Clean(c.Args().Get(1))
+	f, err := os.OpenFile(fpath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	manifest, err := runner.BackupService.Backup(context.Background(), orgID, passphrase, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if removeErr := os.Remove(fpath); removeErr != nil {
+			logger.Warnf("Failed to remove %s: %s\n", fpath, removeErr)
+		}
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	printTables(manifest.Tables)
+	if !manifest.Encrypted {
+		logger.Warn("No passphrase set, data source and contact point secrets are not included\n")
+	}
+	logger.Infof("Backup of org %q written to %s %s\n", manifest.OrgName, fpath, color.GreenString("✔"))
+	return nil
+}
+
+// RestoreBackup restores a backup archive to a new org: restore <file>
+func RestoreBackup(c utils.CommandLine, runner runner.Runner) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("usage: restore <file>")
+	}
+	passphrase, err := readPassphrase(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filepath.Clean(c.Args().First()))
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Warnf("Failed to close backup file: %s\n", err)
+		}
+	}()
+
+	result, err := runner.BackupService.Restore(context.Background(), f, export.RestoreOptions{
+		OrgName:    c.String("org-name"),
+		Passphrase: passphrase,
+		NewUIDs:    c.Bool("new-uids"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	printTables(result.Restored)
+	for _, table := range sortedTables(result.Skipped) {
+		logger.Warnf("Skipped %d rows of %s referencing rows which weren't restored\n", result.Skipped[table], table)
+	}
+	logger.Infof("Backup restored to org %q (id %d) %s\n", result.OrgName, result.OrgID, color.GreenString("✔"))
+	return nil
+}
+
+func readPassphrase(c utils.CommandLine) (string, error) {
+	if !c.Bool("passphrase-from-stdin") {
+		return "", nil
+	}
+
+	logger.Infof("Passphrase: ")
+	scanner := bufio.NewScanner(os.Stdin)
+	if ok := scanner.Scan(); !ok {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("can't read passphrase from stdin: %w", err)
+		}
+		return "", fmt.Errorf("can't read passphrase from stdin")
+	}
+	return scanner.Text(), nil
+}
+
+func printTables(rows map[string]int) {
+	for _, table := range sortedTables(rows) {
+		logger.Infof("%-28s %d\n", table, rows[table])
+	}
+}
+
+func sortedTables(rows map[string]int) []string {
+	tables := make([]string, 0, len(rows))
+	for table := range rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
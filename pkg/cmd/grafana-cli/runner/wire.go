@@ -186,6 +186,8 @@ var wireSet = wire.NewSet(
 	wire.Bind(new(repo.Service), new(*repo.Manager)),
 	manager.ProvideInstaller,
 	wire.Bind(new(plugins.Installer), new(*manager.PluginInstaller)),
+	manager.ProvideReloader,
+	wire.Bind(new(plugins.Reloader), new(*manager.PluginReloader)),
 	client.ProvideService,
 	wire.Bind(new(plugins.Client), new(*client.Service)),
 	managerStore.ProvideService,
@@ -231,6 +233,7 @@ var wireSet = wire.NewSet(
 	searchV2.ProvideService,
 	store.ProvideService,
 	export.ProvideService,
+	export.ProvideBackupService,
 	live.ProvideService,
 	pushhttp.ProvideService,
 	plugincontext.ProvideService,
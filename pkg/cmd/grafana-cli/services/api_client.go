@@ -11,10 +11,12 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/grafana/grafana/pkg/plugins/repo"
 )
 
 type GrafanaComClient struct {
@@ -23,6 +25,20 @@ type GrafanaComClient struct {
 
 func (client *GrafanaComClient) GetPlugin(pluginId, repoUrl string) (models.Plugin, error) {
 	logger.Debugf("getting plugin metadata from: %v pluginId: %v \n", repoUrl, pluginId)
+	if repo.IsMirrorIndex(repoUrl) {
+		index, err := client.ListAllPlugins(repoUrl)
+		if err != nil {
+			return models.Plugin{}, err
+		}
+		for _, p := range index.Plugins {
+			if p.ID == pluginId {
+				return p, nil
+			}
+		}
+		return models.Plugin{}, fmt.Errorf("%v: %w",
+			fmt.Sprintf("Failed to find requested plugin, check if the plugin_id (%s) is correct", pluginId), ErrNotFoundError)
+	}
+
 	body, err := sendRequestGetBytes(HttpClient, repoUrl, "repo", pluginId)
 	if err != nil {
 		if errors.Is(err, ErrNotFoundError) {
@@ -114,7 +130,13 @@ func (client *GrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File
 }
 
 func (client *GrafanaComClient) ListAllPlugins(repoUrl string) (models.PluginRepo, error) {
-	body, err := sendRequestGetBytes(HttpClient, repoUrl, "repo")
+	var body []byte
+	var err error
+	if repo.IsMirrorIndex(repoUrl) {
+		body, err = readMirrorIndex(repoUrl)
+	} else {
+		body, err = sendRequestGetBytes(HttpClient, repoUrl, "repo")
+	}
 
 	if err != nil {
 		logger.Info("Failed to send request", "error", err)
@@ -131,6 +153,21 @@ func (client *GrafanaComClient) ListAllPlugins(repoUrl string) (models.PluginRep
 	return data, nil
 }
 
+// readMirrorIndex reads the index of a static plugin repository mirror from a
+// local path or URL.
+func readMirrorIndex(indexUrl string) ([]byte, error) {
+	u, err := url.Parse(indexUrl)
+	if err != nil || len(u.Scheme) <= 1 {
+		// nolint:gosec
+		// The path stems from command line flag "repo".
+		return os.ReadFile(indexUrl)
+	}
+	if u.Scheme == "file" {
+		return os.ReadFile(filepath.FromSlash(u.Path))
+	}
+	return sendRequestGetBytes(HttpClient, indexUrl)
+}
+
 func sendRequestGetBytes(client http.Client, repoUrl string, subPaths ...string) ([]byte, error) {
 	bodyReader, err := sendRequest(client, repoUrl, subPaths...)
 	if err != nil {
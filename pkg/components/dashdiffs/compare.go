@@ -23,6 +23,7 @@ const (
 	DiffJSON DiffType = iota
 	DiffBasic
 	DiffDelta
+	DiffSemantic
 )
 
 type Options struct {
@@ -50,6 +51,8 @@ func ParseDiffType(diff string) DiffType {
 		return DiffBasic
 	case "delta":
 		return DiffDelta
+	case "semantic":
+		return DiffSemantic
 	}
 	return DiffBasic
 }
@@ -87,6 +90,17 @@ func CalculateDiff(ctx context.Context, options *Options, baseData, newData *sim
 		}
 		result.Delta = basicOutput
 
+	case DiffSemantic:
+		changes, err := CalculateSemanticDiff(baseData, newData)
+		if err != nil {
+			return nil, err
+		}
+		semanticOutput, err := json.Marshal(changes)
+		if err != nil {
+			return nil, err
+		}
+		result.Delta = semanticOutput
+
 	default:
 		return nil, ErrUnsupportedDiffType
 	}
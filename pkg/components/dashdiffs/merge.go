@@ -0,0 +1,254 @@
+package dashdiffs
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// MergeConflict is a property changed differently by both edits
+type MergeConflict struct {
+	// e.g. panels[id=4].title or templating.list[name=env].query
+	Path   string      `json:"path"`
+	Base   interface{} `json:"base"`
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+// MergeResult is the merged dashboard. Conflicting properties keep the
+// value of theirs.
+type MergeResult struct {
+	Dashboard *simplejson.Json `json:"dashboard"`
+	Conflicts []MergeConflict  `json:"conflicts"`
+}
+
+type merger struct {
+	conflicts []MergeConflict
+	// the next ID for panels added by both edits with the same ID
+	nextPanelID int64
+}
+
+// Merge merges two edits of the same base version of a dashboard. Ours is
+// the edit being saved and theirs the dashboard saved in the meantime.
+// Panels are merged by ID and variables and annotations by name, panels
+// added by both edits with the same ID are kept with a new ID for ours.
+// The version of theirs is kept, so the merged dashboard can be saved.
+func Merge(baseData, oursData, theirsData *simplejson.Json) (*MergeResult, error) {
+	base, err := normalizeJSON(baseData)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := normalizeJSON(oursData)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := normalizeJSON(theirsData)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &merger{
+		conflicts:   make([]MergeConflict, 0),
+		nextPanelID: maxPanelID(ours, theirs) + 1,
+	}
+	merged := m.mergeObject("", base, ours, theirs)
+	for _, key := range []string{"id", "uid", "version"} {
+		if v, ok := theirs[key]; ok {
+			merged[key] = v
+		}
+	}
+
+	return &MergeResult{
+		Dashboard: simplejson.NewFromAny(merged),
+		Conflicts: m.conflicts,
+	}, nil
+}
+
+func (m *merger) mergeObject(path string, base, ours, theirs map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(theirs))
+	for _, key := range unionKeys(base, ours, theirs) {
+		b, inBase := base[key]
+		o, inOurs := ours[key]
+		t, inTheirs := theirs[key]
+		v, ok := m.mergeValue(joinPath(path, key), b, o, t, inBase, inOurs, inTheirs)
+		if ok {
+			merged[key] = v
+		}
+	}
+	return merged
+}
+
+// mergeValue returns the merged value, false if the property is removed
+func (m *merger) mergeValue(path string, b, o, t interface{}, inBase, inOurs, inTheirs bool) (interface{}, bool) {
+	switch {
+	case inOurs == inTheirs && reflect.DeepEqual(o, t):
+		return o, inOurs
+	case inBase == inOurs && reflect.DeepEqual(b, o):
+		return t, inTheirs
+	case inBase == inTheirs && reflect.DeepEqual(b, t):
+		return o, inOurs
+	}
+
+	// changed by both, merge the properties of objects and lists of panels,
+	// variables and annotations
+	if inOurs && inTheirs {
+		if key := listKey(path); key != "" {
+			bl, _ := b.([]interface{})
+			ol, oIsList := o.([]interface{})
+			tl, tIsList := t.([]interface{})
+			if oIsList && tIsList && hasKeys(bl, key) && hasKeys(ol, key) && hasKeys(tl, key) {
+				return m.mergeList(path, key, bl, ol, tl), true
+			}
+		}
+		om, oIsMap := o.(map[string]interface{})
+		tm, tIsMap := t.(map[string]interface{})
+		if oIsMap && tIsMap {
+			bm, _ := b.(map[string]interface{})
+			return m.mergeObject(path, bm, om, tm), true
+		}
+	}
+
+	m.conflicts = append(m.conflicts, MergeConflict{Path: path, Base: b, Ours: o, Theirs: t})
+	return t, inTheirs
+}
+
+// mergeList merges lists of objects with a key. The merged list has the
+// order of theirs, with the items added by ours after the items they follow
+// in ours.
+func (m *merger) mergeList(path, key string, base, ours, theirs []interface{}) []interface{} {
+	baseItems := indexList(base, key)
+	oursItems := indexList(ours, key)
+	theirsItems := indexList(theirs, key)
+
+	merged := make([]interface{}, 0, len(theirs)+len(ours))
+	position := make(map[string]int, len(theirs)) // index in merged by key
+	for _, item := range theirs {
+		k := itemKey(item, key)
+		b, inBase := baseItems[k]
+		o, inOurs := oursItems[k]
+		itemPath := fmt.Sprintf("%s[%s=%s]", path, key, k)
+
+		if !inBase && inOurs && key == "id" && !reflect.DeepEqual(o, item) {
+			// both added a panel with the same ID, ours is added later with a new ID
+			position[k] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+		v, ok := m.mergeValue(itemPath, b, o, item, inBase, inOurs, true)
+		if ok {
+			position[k] = len(merged)
+			merged = append(merged, v)
+		}
+	}
+
+	after := -1 // index in merged of the last item of ours
+	for _, item := range ours {
+		k := itemKey(item, key)
+		if i, ok := position[k]; ok {
+			t := theirsItems[k]
+			_, inBase := baseItems[k]
+			if inBase || reflect.DeepEqual(item, t) || key != "id" {
+				after = i
+				continue
+			}
+			item = m.renumberPanel(item)
+		} else if b, inBase := baseItems[k]; inBase {
+			// removed by theirs
+			if _, ok := m.mergeValue(fmt.Sprintf("%s[%s=%s]", path, key, k), b, item, nil, true, true, false); !ok {
+				continue
+			}
+		}
+
+		after++
+		merged = append(merged, nil)
+		copy(merged[after+1:], merged[after:])
+		merged[after] = item
+		for pk, i := range position {
+			if i >= after {
+				position[pk] = i + 1
+			}
+		}
+	}
+	return merged
+}
+
+// renumberPanel gives a panel added by both edits with the same ID a new ID
+func (m *merger) renumberPanel(item interface{}) interface{} {
+	panel, ok := item.(map[string]interface{})
+	if !ok {
+		return item
+	}
+	renumbered := make(map[string]interface{}, len(panel))
+	for k, v := range panel {
+		renumbered[k] = v
+	}
+	renumbered["id"] = float64(m.nextPanelID)
+	m.nextPanelID++
+	return renumbered
+}
+
+// listKey returns the key of the items in panel, variable and annotation
+// lists
+func listKey(path string) string {
+	switch {
+	case path == "templating.list", path == "annotations.list":
+		return "name"
+	case path == "panels", len(path) > 7 && path[len(path)-7:] == ".panels":
+		return "id"
+	}
+	return ""
+}
+
+func hasKeys(list []interface{}, key string) bool {
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		k := itemKey(item, key)
+		if k == "" || seen[k] {
+			return false
+		}
+		seen[k] = true
+	}
+	return true
+}
+
+func indexList(list []interface{}, key string) map[string]interface{} {
+	items := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		items[itemKey(item, key)] = item
+	}
+	return items
+}
+
+func itemKey(item interface{}, key string) string {
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch v := obj[key].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return ""
+}
+
+func maxPanelID(dashboards ...map[string]interface{}) int64 {
+	var max int64
+	for _, dash := range dashboards {
+		for _, p := range flattenPanels(dash) {
+			if id := panelID(p.panel); id > max {
+				max = id
+			}
+		}
+	}
+	return max
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
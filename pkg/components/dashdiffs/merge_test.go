@@ -0,0 +1,110 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const mergeBaseJSON = `{
+	"uid": "abc",
+	"title": "Dashboard",
+	"version": 3,
+	"panels": [
+		{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+		{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}}
+	],
+	"templating": {"list": [{"name": "env", "query": "prod"}]}
+}`
+
+func TestMerge(t *testing.T) {
+	t.Run("non conflicting edits are merged", func(t *testing.T) {
+		// ours changes a title and adds a variable
+		ours := mustJSON(t, `{
+			"uid": "abc",
+			"title": "Dashboard",
+			"version": 3,
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU usage", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+				{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}}
+			],
+			"templating": {"list": [{"name": "env", "query": "prod"}, {"name": "host", "query": "*"}]}
+		}`)
+		// theirs moves the same panel, removes the other and changes the title
+		theirs := mustJSON(t, `{
+			"uid": "abc",
+			"title": "Renamed",
+			"version": 4,
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 8, "w": 24, "h": 8}}
+			],
+			"templating": {"list": [{"name": "env", "query": "prod"}]}
+		}`)
+
+		result, err := Merge(mustJSON(t, mergeBaseJSON), ours, theirs)
+		require.NoError(t, err)
+		require.Empty(t, result.Conflicts)
+
+		merged, err := result.Dashboard.Encode()
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"uid": "abc",
+			"title": "Renamed",
+			"version": 4,
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU usage", "gridPos": {"x": 0, "y": 8, "w": 24, "h": 8}}
+			],
+			"templating": {"list": [{"name": "env", "query": "prod"}, {"name": "host", "query": "*"}]}
+		}`, string(merged))
+	})
+
+	t.Run("panels added by both edits are kept", func(t *testing.T) {
+		ours := mustJSON(t, `{"uid": "abc", "title": "Dashboard", "version": 3, "panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+			{"id": 3, "type": "stat", "title": "Ours", "gridPos": {"x": 0, "y": 8, "w": 12, "h": 8}},
+			{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}}
+		], "templating": {"list": [{"name": "env", "query": "prod"}]}}`)
+		theirs := mustJSON(t, `{"uid": "abc", "title": "Dashboard", "version": 4, "panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+			{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+			{"id": 3, "type": "stat", "title": "Theirs", "gridPos": {"x": 12, "y": 8, "w": 12, "h": 8}}
+		], "templating": {"list": [{"name": "env", "query": "prod"}]}}`)
+
+		result, err := Merge(mustJSON(t, mergeBaseJSON), ours, theirs)
+		require.NoError(t, err)
+		require.Empty(t, result.Conflicts)
+
+		panels := result.Dashboard.Get("panels").MustArray()
+		require.Len(t, panels, 4)
+		titles := make([]string, 0, len(panels))
+		ids := make([]float64, 0, len(panels))
+		for _, p := range panels {
+			panel := p.(map[string]interface{})
+			titles = append(titles, panel["title"].(string))
+			ids = append(ids, panel["id"].(float64))
+		}
+		require.Equal(t, []string{"CPU", "Ours", "Memory", "Theirs"}, titles)
+		require.Equal(t, []float64{1, 4, 2, 3}, ids)
+	})
+
+	t.Run("conflicts keep theirs", func(t *testing.T) {
+		ours := mustJSON(t, `{"uid": "abc", "title": "Ours", "version": 3, "panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}}
+		], "templating": {"list": [{"name": "env", "query": "staging"}]}}`)
+		theirs := mustJSON(t, `{"uid": "abc", "title": "Theirs", "version": 4, "panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+			{"id": 2, "type": "graph", "title": "Memory changed", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}}
+		], "templating": {"list": [{"name": "env", "query": "dev"}]}}`)
+
+		result, err := Merge(mustJSON(t, mergeBaseJSON), ours, theirs)
+		require.NoError(t, err)
+		paths := make([]string, 0, len(result.Conflicts))
+		for _, c := range result.Conflicts {
+			paths = append(paths, c.Path)
+		}
+		require.Equal(t, []string{"panels[id=2]", "templating.list[name=env].query", "title"}, paths)
+		require.Equal(t, "Theirs", result.Dashboard.Get("title").MustString())
+		require.Equal(t, "staging", result.Conflicts[1].Ours)
+		require.Equal(t, "dev", result.Conflicts[1].Theirs)
+	})
+}
@@ -0,0 +1,288 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+type ChangeKind string
+
+const (
+	ChangeKindPanel      ChangeKind = "panel"
+	ChangeKindVariable   ChangeKind = "variable"
+	ChangeKindAnnotation ChangeKind = "annotation"
+	ChangeKindDashboard  ChangeKind = "dashboard"
+)
+
+type ChangeAction string
+
+const (
+	ActionAdded   ChangeAction = "added"
+	ActionRemoved ChangeAction = "removed"
+	ActionChanged ChangeAction = "changed"
+	ActionMoved   ChangeAction = "moved"
+)
+
+// GridPos is the position of a panel
+type GridPos struct {
+	X   int   `json:"x"`
+	Y   int   `json:"y"`
+	W   int   `json:"w"`
+	H   int   `json:"h"`
+	Row int64 `json:"row,omitempty"` // ID of the row the panel is in
+}
+
+// SemanticChange is a change of a panel, variable, annotation or dashboard
+// property
+type SemanticChange struct {
+	Kind   ChangeKind   `json:"kind"`
+	Action ChangeAction `json:"action"`
+	// Panel ID, variable or annotation name or dashboard property
+	Key   string `json:"key"`
+	Title string `json:"title,omitempty"`
+	// Changed properties
+	Fields []string `json:"fields,omitempty"`
+	From   *GridPos `json:"from,omitempty"`
+	To     *GridPos `json:"to,omitempty"`
+}
+
+// dashboard properties which are compared as lists or not at all
+var semanticIgnoredProperties = map[string]bool{
+	"id":          true,
+	"version":     true,
+	"panels":      true,
+	"templating":  true,
+	"annotations": true,
+}
+
+// CalculateSemanticDiff returns the changes of panels by ID, variables and
+// annotations by name and of the other dashboard properties
+func CalculateSemanticDiff(baseData, newData *simplejson.Json) ([]SemanticChange, error) {
+	base, err := normalizeJSON(baseData)
+	if err != nil {
+		return nil, err
+	}
+	dash, err := normalizeJSON(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]SemanticChange, 0)
+	changes = append(changes, diffPanels(flattenPanels(base), flattenPanels(dash))...)
+	changes = append(changes, diffNamedList(ChangeKindVariable, listOf(base, "templating"), listOf(dash, "templating"))...)
+	changes = append(changes, diffNamedList(ChangeKindAnnotation, listOf(base, "annotations"), listOf(dash, "annotations"))...)
+
+	for _, key := range unionKeys(base, dash) {
+		if semanticIgnoredProperties[key] {
+			continue
+		}
+		b, inBase := base[key]
+		n, inNew := dash[key]
+		switch {
+		case !inBase:
+			changes = append(changes, SemanticChange{Kind: ChangeKindDashboard, Action: ActionAdded, Key: key})
+		case !inNew:
+			changes = append(changes, SemanticChange{Kind: ChangeKindDashboard, Action: ActionRemoved, Key: key})
+		case !reflect.DeepEqual(b, n):
+			changes = append(changes, SemanticChange{Kind: ChangeKindDashboard, Action: ActionChanged, Key: key})
+		}
+	}
+	return changes, nil
+}
+
+type flatPanel struct {
+	key   string
+	panel map[string]interface{}
+	pos   GridPos
+}
+
+// flattenPanels returns the panels of a dashboard including the panels of
+// collapsed rows, panels of expanded rows follow the row in the list
+func flattenPanels(dash map[string]interface{}) []flatPanel {
+	var panels []flatPanel
+	var row int64
+	top, _ := dash["panels"].([]interface{})
+	for i, p := range top {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := panelKey(panel, fmt.Sprintf("#%d", i))
+		if panel["type"] == "row" {
+			row = panelID(panel)
+			panels = append(panels, flatPanel{key: key, panel: panel, pos: gridPos(panel, 0)})
+			nested, _ := panel["panels"].([]interface{})
+			for j, np := range nested {
+				if nestedPanel, ok := np.(map[string]interface{}); ok {
+					panels = append(panels, flatPanel{
+						key:   panelKey(nestedPanel, fmt.Sprintf("%s#%d", key, j)),
+						panel: nestedPanel,
+						pos:   gridPos(nestedPanel, row),
+					})
+				}
+			}
+			continue
+		}
+		panels = append(panels, flatPanel{key: key, panel: panel, pos: gridPos(panel, row)})
+	}
+	return panels
+}
+
+func diffPanels(base, dash []flatPanel) []SemanticChange {
+	basePanels := make(map[string]flatPanel, len(base))
+	for _, p := range base {
+		basePanels[p.key] = p
+	}
+
+	var changes []SemanticChange
+	inNew := make(map[string]bool, len(dash))
+	for _, p := range dash {
+		inNew[p.key] = true
+		title, _ := p.panel["title"].(string)
+		b, ok := basePanels[p.key]
+		if !ok {
+			pos := p.pos
+			changes = append(changes, SemanticChange{Kind: ChangeKindPanel, Action: ActionAdded, Key: p.key, Title: title, To: &pos})
+			continue
+		}
+		if b.pos != p.pos {
+			from, to := b.pos, p.pos
+			changes = append(changes, SemanticChange{Kind: ChangeKindPanel, Action: ActionMoved, Key: p.key, Title: title, From: &from, To: &to})
+		}
+		if fields := changedFields(b.panel, p.panel, "gridPos", "panels"); len(fields) > 0 {
+			changes = append(changes, SemanticChange{Kind: ChangeKindPanel, Action: ActionChanged, Key: p.key, Title: title, Fields: fields})
+		}
+	}
+	for _, b := range base {
+		if !inNew[b.key] {
+			title, _ := b.panel["title"].(string)
+			pos := b.pos
+			changes = append(changes, SemanticChange{Kind: ChangeKindPanel, Action: ActionRemoved, Key: b.key, Title: title, From: &pos})
+		}
+	}
+	return changes
+}
+
+// diffNamedList compares the variables or annotations by name
+func diffNamedList(kind ChangeKind, base, dash []interface{}) []SemanticChange {
+	baseItems := make(map[string]map[string]interface{}, len(base))
+	for _, item := range base {
+		if m, ok := item.(map[string]interface{}); ok {
+			if name, ok := m["name"].(string); ok {
+				baseItems[name] = m
+			}
+		}
+	}
+
+	var changes []SemanticChange
+	inNew := make(map[string]bool, len(dash))
+	for _, item := range dash {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, ok := m["name"].(string)
+		if !ok {
+			continue
+		}
+		inNew[name] = true
+		b, ok := baseItems[name]
+		if !ok {
+			changes = append(changes, SemanticChange{Kind: kind, Action: ActionAdded, Key: name})
+			continue
+		}
+		if fields := changedFields(b, m); len(fields) > 0 {
+			changes = append(changes, SemanticChange{Kind: kind, Action: ActionChanged, Key: name, Fields: fields})
+		}
+	}
+	for _, item := range base {
+		if m, ok := item.(map[string]interface{}); ok {
+			if name, ok := m["name"].(string); ok && !inNew[name] {
+				changes = append(changes, SemanticChange{Kind: kind, Action: ActionRemoved, Key: name})
+			}
+		}
+	}
+	return changes
+}
+
+// changedFields returns the sorted properties which differ
+func changedFields(base, dash map[string]interface{}, ignore ...string) []string {
+	ignored := make(map[string]bool, len(ignore))
+	for _, key := range ignore {
+		ignored[key] = true
+	}
+	var fields []string
+	for _, key := range unionKeys(base, dash) {
+		if !ignored[key] && !reflect.DeepEqual(base[key], dash[key]) {
+			fields = append(fields, key)
+		}
+	}
+	return fields
+}
+
+func listOf(dash map[string]interface{}, property string) []interface{} {
+	obj, _ := dash[property].(map[string]interface{})
+	list, _ := obj["list"].([]interface{})
+	return list
+}
+
+func panelID(panel map[string]interface{}) int64 {
+	id, _ := panel["id"].(float64)
+	return int64(id)
+}
+
+// panelKey returns the ID of a panel, or the fallback for panels without an
+// ID
+func panelKey(panel map[string]interface{}, fallback string) string {
+	if _, ok := panel["id"].(float64); ok {
+		return fmt.Sprintf("%d", panelID(panel))
+	}
+	return fallback
+}
+
+func gridPos(panel map[string]interface{}, row int64) GridPos {
+	pos := GridPos{Row: row}
+	m, _ := panel["gridPos"].(map[string]interface{})
+	toInt := func(key string) int {
+		v, _ := m[key].(float64)
+		return int(v)
+	}
+	pos.X, pos.Y, pos.W, pos.H = toInt("x"), toInt("y"), toInt("w"), toInt("h")
+	return pos
+}
+
+func unionKeys(maps ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeJSON returns a copy of the dashboard with numbers as float64, so
+// values can be compared whichever way the JSON was decoded
+func normalizeJSON(data *simplejson.Json) (map[string]interface{}, error) {
+	if data == nil {
+		return map[string]interface{}{}, nil
+	}
+	b, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
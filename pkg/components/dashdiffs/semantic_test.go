@@ -0,0 +1,63 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestCalculateSemanticDiff(t *testing.T) {
+	base := mustJSON(t, `{
+		"title": "Dashboard",
+		"version": 3,
+		"panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+			{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"x": 0, "y": 8, "w": 24, "h": 1}, "panels": [
+				{"id": 4, "type": "table", "title": "Hosts", "gridPos": {"x": 0, "y": 9, "w": 24, "h": 8}}
+			]}
+		],
+		"templating": {"list": [{"name": "env", "query": "prod"}, {"name": "host", "query": "*"}]},
+		"annotations": {"list": [{"name": "Deploys", "enable": true}]}
+	}`)
+	changed := mustJSON(t, `{
+		"title": "Renamed",
+		"version": 4,
+		"panels": [
+			{"id": 1, "type": "graph", "title": "CPU usage", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"x": 0, "y": 8, "w": 24, "h": 1}, "panels": [
+				{"id": 4, "type": "table", "title": "Hosts", "gridPos": {"x": 0, "y": 9, "w": 12, "h": 8}},
+				{"id": 5, "type": "stat", "title": "Uptime", "gridPos": {"x": 12, "y": 9, "w": 12, "h": 8}}
+			]}
+		],
+		"templating": {"list": [{"name": "env", "query": "staging"}, {"name": "region", "query": "*"}]},
+		"annotations": {"list": [{"name": "Deploys", "enable": true}]}
+	}`)
+
+	changes, err := CalculateSemanticDiff(base, changed)
+	require.NoError(t, err)
+	require.Equal(t, []SemanticChange{
+		{Kind: ChangeKindPanel, Action: ActionChanged, Key: "1", Title: "CPU usage", Fields: []string{"title"}},
+		{Kind: ChangeKindPanel, Action: ActionMoved, Key: "4", Title: "Hosts",
+			From: &GridPos{X: 0, Y: 9, W: 24, H: 8, Row: 3}, To: &GridPos{X: 0, Y: 9, W: 12, H: 8, Row: 3}},
+		{Kind: ChangeKindPanel, Action: ActionAdded, Key: "5", Title: "Uptime", To: &GridPos{X: 12, Y: 9, W: 12, H: 8, Row: 3}},
+		{Kind: ChangeKindPanel, Action: ActionRemoved, Key: "2", Title: "Memory", From: &GridPos{X: 12, Y: 0, W: 12, H: 8}},
+		{Kind: ChangeKindVariable, Action: ActionChanged, Key: "env", Fields: []string{"query"}},
+		{Kind: ChangeKindVariable, Action: ActionAdded, Key: "region"},
+		{Kind: ChangeKindVariable, Action: ActionRemoved, Key: "host"},
+		{Kind: ChangeKindDashboard, Action: ActionChanged, Key: "title"},
+	}, changes)
+
+	changes, err = CalculateSemanticDiff(base, base)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func mustJSON(t *testing.T, s string) *simplejson.Json {
+	t.Helper()
+	js, err := simplejson.NewJson([]byte(s))
+	require.NoError(t, err)
+	return js
+}
@@ -0,0 +1,107 @@
+package dashlint
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type Severity string
+
+const (
+	SeverityOff     Severity = "off"
+	SeverityWarning Severity = "warning"
+	// SeverityError issues block saving the dashboard
+	SeverityError Severity = "error"
+)
+
+// Issue is a rule violation found in a dashboard
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// JSON path of the violating property, e.g. panels[2].targets[0]
+	Path    string `json:"path,omitempty"`
+	PanelID int64  `json:"panelId,omitempty"`
+}
+
+// Result of linting a dashboard. A dashboard is valid when it has no error
+// issues.
+type Result struct {
+	Valid  bool    `json:"valid"`
+	Issues []Issue `json:"issues"`
+}
+
+// Error describes the error issues of the result.
+func (r Result) Error() string {
+	messages := make([]string, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, issue.String())
+		}
+	}
+	return "dashboard lint failed: " + strings.Join(messages, "; ")
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%s: %s", i.Rule, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Rule, i.Path, i.Message)
+}
+
+type rule struct {
+	id    string
+	check func(l *Linter, dash *simplejson.Json) []Issue
+}
+
+var rules = []rule{
+	{id: "datasource_uid", check: checkDatasourceUID},
+	{id: "hardcoded_org_id", check: checkHardcodedOrgID},
+	{id: "max_panels", check: checkMaxPanels},
+	{id: "min_interval", check: checkMinInterval},
+}
+
+// Linter checks dashboard JSON against the rules configured in the
+// [dashboards.lint] section.
+type Linter struct {
+	severities  map[string]Severity
+	maxPanels   int
+	minInterval time.Duration
+}
+
+func New(cfg setting.DashboardLintSettings) *Linter {
+	l := &Linter{
+		severities:  make(map[string]Severity, len(cfg.Severities)),
+		maxPanels:   cfg.MaxPanels,
+		minInterval: cfg.MinInterval,
+	}
+	for id, severity := range cfg.Severities {
+		l.severities[id] = Severity(severity)
+	}
+	return l
+}
+
+// Lint runs the enabled rules on the dashboard.
+func (l *Linter) Lint(dash *simplejson.Json) Result {
+	result := Result{Valid: true, Issues: []Issue{}}
+	for _, r := range rules {
+		severity := l.severities[r.id]
+		if severity != SeverityWarning && severity != SeverityError {
+			continue
+		}
+		issues := r.check(l, dash)
+		if len(issues) > 0 && severity == SeverityError {
+			result.Valid = false
+		}
+		for _, issue := range issues {
+			issue.Rule = r.id
+			issue.Severity = severity
+			result.Issues = append(result.Issues, issue)
+		}
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package dashlint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func mustJSON(t *testing.T, s string) *simplejson.Json {
+	t.Helper()
+	j, err := simplejson.NewJson([]byte(s))
+	require.NoError(t, err)
+	return j
+}
+
+func TestLint(t *testing.T) {
+	dash := mustJSON(t, `{
+		"title": "Dashboard",
+		"links": [{"title": "Other", "url": "/d/abc/other?orgId=2&var-env=prod"}],
+		"panels": [
+			{"id": 1, "type": "graph", "datasource": "Prometheus", "interval": "5s", "targets": [{"refId": "A"}]},
+			{"id": 2, "type": "graph", "datasource": {"type": "prometheus", "uid": "P1809F7CD0C75ACF3"}, "interval": "1m", "targets": [
+				{"refId": "A", "datasource": {"type": "loki"}}
+			]},
+			{"id": 3, "type": "row", "collapsed": true, "panels": [
+				{"id": 4, "type": "stat", "datasource": "${DS_PROMETHEUS}", "targets": [{"refId": "A"}]},
+				{"id": 5, "type": "stat", "targets": [{"refId": "A"}], "options": {"link": {"orgId": 1}}}
+			]},
+			{"id": 6, "type": "text", "options": {"content": "# Hello"}},
+			{"id": 7, "libraryPanel": {"uid": "lib", "name": "Library panel"}}
+		]
+	}`)
+
+	t.Run("default rules only report warnings", func(t *testing.T) {
+		cfg := setting.DashboardLintSettings{Severities: map[string]string{
+			"datasource_uid":   "warning",
+			"hardcoded_org_id": "warning",
+			"max_panels":       "off",
+			"min_interval":     "off",
+		}}
+		result := New(cfg).Lint(dash)
+		require.True(t, result.Valid)
+		require.Equal(t, []Issue{
+			{Rule: "datasource_uid", Severity: SeverityWarning, Path: "panels[0].datasource", PanelID: 1, Message: `data source "Prometheus" is referenced by name instead of UID`},
+			{Rule: "datasource_uid", Severity: SeverityWarning, Path: "panels[1].targets[0].datasource", PanelID: 2, Message: "data source reference has no UID"},
+			{Rule: "datasource_uid", Severity: SeverityWarning, Path: "panels[2].panels[1]", PanelID: 5, Message: "panel has no data source and uses the default data source"},
+			{Rule: "hardcoded_org_id", Severity: SeverityWarning, Path: "links[0].url", Message: "URL has a hard-coded orgId, use ${__org} instead"},
+			{Rule: "hardcoded_org_id", Severity: SeverityWarning, Path: "panels[2].panels[1].options.link.orgId", Message: "org ID 1 is hard-coded"},
+		}, result.Issues)
+	})
+
+	t.Run("error rules make the dashboard invalid", func(t *testing.T) {
+		cfg := setting.DashboardLintSettings{
+			Severities: map[string]string{"max_panels": "error", "min_interval": "warning"},
+			MaxPanels:  4,
+		}
+		result := New(cfg).Lint(dash)
+		require.False(t, result.Valid)
+		require.Equal(t, []Issue{
+			{Rule: "max_panels", Severity: SeverityError, Message: "dashboard has 6 panels, more than the limit of 4"},
+			{Rule: "min_interval", Severity: SeverityWarning, Path: "panels[2].panels[0]", PanelID: 4, Message: "panel queries have no min interval"},
+			{Rule: "min_interval", Severity: SeverityWarning, Path: "panels[2].panels[1]", PanelID: 5, Message: "panel queries have no min interval"},
+		}, result.Issues)
+		require.Equal(t, "dashboard lint failed: max_panels: dashboard has 6 panels, more than the limit of 4", result.Error())
+	})
+
+	t.Run("min interval limit", func(t *testing.T) {
+		cfg := setting.DashboardLintSettings{
+			Severities:  map[string]string{"min_interval": "error"},
+			MinInterval: 10 * time.Second,
+		}
+		result := New(cfg).Lint(mustJSON(t, `{"panels": [
+			{"id": 1, "interval": "5s", "targets": [{"refId": "A"}]},
+			{"id": 2, "interval": ">30s", "targets": [{"refId": "A"}]},
+			{"id": 3, "interval": "$interval", "targets": [{"refId": "A"}]},
+			{"id": 4, "interval": "soon", "targets": [{"refId": "A"}]},
+			{"id": 5, "targets": [{"refId": "A", "interval": "1m"}, {"refId": "B", "interval": ">15s"}]},
+			{"id": 6, "targets": [{"refId": "A", "interval": "1s"}, {"refId": "B"}]}
+		]}`))
+		require.False(t, result.Valid)
+		require.Equal(t, []Issue{
+			{Rule: "min_interval", Severity: SeverityError, Path: "panels[0].interval", PanelID: 1, Message: "min interval 5s is shorter than 10s"},
+			{Rule: "min_interval", Severity: SeverityError, Path: "panels[3].interval", PanelID: 4, Message: `min interval "soon" is invalid`},
+			{Rule: "min_interval", Severity: SeverityError, Path: "panels[5].targets[0].interval", PanelID: 6, Message: "min interval 1s is shorter than 10s"},
+			{Rule: "min_interval", Severity: SeverityError, Path: "panels[5].targets[1]", PanelID: 6, Message: "query has no min interval"},
+		}, result.Issues)
+	})
+
+	t.Run("legacy rows", func(t *testing.T) {
+		cfg := setting.DashboardLintSettings{Severities: map[string]string{"datasource_uid": "error"}}
+		result := New(cfg).Lint(mustJSON(t, `{"rows": [{"panels": [{"id": 1, "datasource": "-- Grafana --", "targets": [{"refId": "A"}]}]}]}`))
+		require.False(t, result.Valid)
+		require.Len(t, result.Issues, 1)
+		require.Equal(t, "rows[0].panels[0].datasource", result.Issues[0].Path)
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		result := New(setting.DashboardLintSettings{}).Lint(dash)
+		require.True(t, result.Valid)
+		require.Empty(t, result.Issues)
+	})
+}
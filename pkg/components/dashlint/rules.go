@@ -0,0 +1,212 @@
+package dashlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var orgIDParam = regexp.MustCompile(`[?&]orgId=\d+`)
+
+type panel struct {
+	path string
+	json *simplejson.Json
+}
+
+func (p panel) id() int64 {
+	return p.json.Get("id").MustInt64()
+}
+
+func (p panel) isLibraryPanel() bool {
+	_, ok := p.json.CheckGet("libraryPanel")
+	return ok
+}
+
+// getPanels returns all panels but rows, including the panels of collapsed
+// rows and of the legacy rows schema
+func getPanels(dash *simplejson.Json) []panel {
+	var panels []panel
+	for i, item := range dash.Get("panels").MustArray() {
+		p := panel{path: fmt.Sprintf("panels[%d]", i), json: simplejson.NewFromAny(item)}
+		if p.json.Get("type").MustString() != "row" {
+			panels = append(panels, p)
+			continue
+		}
+		for j, child := range p.json.Get("panels").MustArray() {
+			panels = append(panels, panel{path: fmt.Sprintf("%s.panels[%d]", p.path, j), json: simplejson.NewFromAny(child)})
+		}
+	}
+	for i, row := range dash.Get("rows").MustArray() {
+		for j, item := range simplejson.NewFromAny(row).Get("panels").MustArray() {
+			panels = append(panels, panel{path: fmt.Sprintf("rows[%d].panels[%d]", i, j), json: simplejson.NewFromAny(item)})
+		}
+	}
+	return panels
+}
+
+func isVariable(value string) bool {
+	return strings.HasPrefix(value, "$") || strings.HasPrefix(value, "[[")
+}
+
+// checkDatasourceUID checks that panels and queries reference their data
+// source by UID or a data source variable, not by name or the default data
+// source.
+func checkDatasourceUID(_ *Linter, dash *simplejson.Json) []Issue {
+	var issues []Issue
+	for _, p := range getPanels(dash) {
+		if p.isLibraryPanel() {
+			continue
+		}
+
+		targets := p.json.Get("targets").MustArray()
+		if ds, ok := p.json.CheckGet("datasource"); ok && ds.Interface() != nil {
+			if message := checkDatasourceRef(ds); message != "" {
+				issues = append(issues, Issue{Path: p.path + ".datasource", PanelID: p.id(), Message: message})
+			}
+		} else if len(targets) > 0 {
+			issues = append(issues, Issue{Path: p.path, PanelID: p.id(), Message: "panel has no data source and uses the default data source"})
+		}
+
+		for i := range targets {
+			ds, ok := p.json.Get("targets").GetIndex(i).CheckGet("datasource")
+			if !ok || ds.Interface() == nil {
+				continue
+			}
+			if message := checkDatasourceRef(ds); message != "" {
+				issues = append(issues, Issue{Path: fmt.Sprintf("%s.targets[%d].datasource", p.path, i), PanelID: p.id(), Message: message})
+			}
+		}
+	}
+	return issues
+}
+
+func checkDatasourceRef(ds *simplejson.Json) string {
+	if name, err := ds.String(); err == nil {
+		if isVariable(name) {
+			return ""
+		}
+		return fmt.Sprintf("data source %q is referenced by name instead of UID", name)
+	}
+	if uid := ds.Get("uid").MustString(); uid == "" {
+		return "data source reference has no UID"
+	}
+	return ""
+}
+
+// checkHardcodedOrgID checks that no property or URL sets an org ID, which
+// breaks the dashboard when it is imported into another org.
+func checkHardcodedOrgID(_ *Linter, dash *simplejson.Json) []Issue {
+	return findOrgIDs(dash.Interface(), "", nil)
+}
+
+func findOrgIDs(value interface{}, path string, issues []Issue) []Issue {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if key == "orgId" && isNumber(v[key]) {
+				issues = append(issues, Issue{Path: keyPath, Message: fmt.Sprintf("org ID %v is hard-coded", v[key])})
+				continue
+			}
+			issues = findOrgIDs(v[key], keyPath, issues)
+		}
+	case []interface{}:
+		for i, item := range v {
+			issues = findOrgIDs(item, fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+	case string:
+		if orgIDParam.MatchString(v) {
+			issues = append(issues, Issue{Path: path, Message: "URL has a hard-coded orgId, use ${__org} instead"})
+		}
+	}
+	return issues
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case json.Number, float64, int, int64:
+		return true
+	}
+	return false
+}
+
+// checkMaxPanels checks that the dashboard does not have more panels than the
+// limit.
+func checkMaxPanels(l *Linter, dash *simplejson.Json) []Issue {
+	if l.maxPanels <= 0 {
+		return nil
+	}
+	if count := len(getPanels(dash)); count > l.maxPanels {
+		return []Issue{{Message: fmt.Sprintf("dashboard has %d panels, more than the limit of %d", count, l.maxPanels)}}
+	}
+	return nil
+}
+
+// checkMinInterval checks that panels with queries set a min interval, either
+// for the panel or for each of its queries, which is not shorter than the
+// limit when there is one.
+func checkMinInterval(l *Linter, dash *simplejson.Json) []Issue {
+	var issues []Issue
+	for _, p := range getPanels(dash) {
+		targets := p.json.Get("targets").MustArray()
+		if p.isLibraryPanel() || len(targets) == 0 {
+			continue
+		}
+
+		if interval := strings.TrimPrefix(p.json.Get("interval").MustString(), ">"); interval != "" {
+			if message := checkIntervalLimit(l, interval); message != "" {
+				issues = append(issues, Issue{Path: p.path + ".interval", PanelID: p.id(), Message: message})
+			}
+			continue
+		}
+
+		var missing []Issue
+		for i := range targets {
+			path := fmt.Sprintf("%s.targets[%d]", p.path, i)
+			interval := strings.TrimPrefix(p.json.Get("targets").GetIndex(i).Get("interval").MustString(), ">")
+			if interval == "" {
+				missing = append(missing, Issue{Path: path, PanelID: p.id(), Message: "query has no min interval"})
+			} else if message := checkIntervalLimit(l, interval); message != "" {
+				issues = append(issues, Issue{Path: path + ".interval", PanelID: p.id(), Message: message})
+			}
+		}
+		if len(missing) == len(targets) {
+			issues = append(issues, Issue{Path: p.path, PanelID: p.id(), Message: "panel queries have no min interval"})
+		} else {
+			issues = append(issues, missing...)
+		}
+	}
+	return issues
+}
+
+// checkIntervalLimit returns why a min interval violates the limit, or an
+// empty string if it doesn't.
+func checkIntervalLimit(l *Linter, interval string) string {
+	if l.minInterval <= 0 || isVariable(interval) {
+		return ""
+	}
+
+	d, err := gtime.ParseDuration(interval)
+	if err != nil {
+		return fmt.Sprintf("min interval %q is invalid", interval)
+	}
+	if d < l.minInterval {
+		return fmt.Sprintf("min interval %s is shorter than %s", interval, l.minInterval)
+	}
+	return ""
+}
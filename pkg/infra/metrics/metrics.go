@@ -108,6 +108,12 @@ var (
 
 	// MPublicDashboardDatasourceQuerySuccess is a metric counter for successful queries labelled by datasource
 	MPublicDashboardDatasourceQuerySuccess *prometheus.CounterVec
+
+	// MPublicDashboardRateLimitedRequests is a metric counter for public dashboards requests rejected by rate limits labelled by limit
+	MPublicDashboardRateLimitedRequests *prometheus.CounterVec
+
+	// MPublicDashboardQueryCache is a metric counter for public dashboards panel queries labelled by cache hit/miss
+	MPublicDashboardQueryCache *prometheus.CounterVec
 )
 
 // Timers
@@ -429,6 +435,18 @@ func init() {
 		Namespace: ExporterName,
 	}, []string{"datasource", "status"}, map[string][]string{"status": pubdash.QueryResultStatuses})
 
+	MPublicDashboardRateLimitedRequests = metricutil.NewCounterVecStartingAtZero(prometheus.CounterOpts{
+		Name:      "public_dashboard_rate_limited_requests",
+		Help:      "counter for public dashboards requests rejected by rate limits labelled by limit dashboard/client",
+		Namespace: ExporterName,
+	}, []string{"limit"}, map[string][]string{"limit": pubdash.RateLimits})
+
+	MPublicDashboardQueryCache = metricutil.NewCounterVecStartingAtZero(prometheus.CounterOpts{
+		Name:      "public_dashboard_query_cache",
+		Help:      "counter for public dashboards panel queries labelled by cache result hit/miss",
+		Namespace: ExporterName,
+	}, []string{"result"}, map[string][]string{"result": pubdash.QueryCacheResults})
+
 	MStatTotalDashboards = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name:      "stat_totals_dashboard",
 		Help:      "total amount of dashboards",
@@ -675,5 +693,7 @@ func initMetricVars() {
 		MStatTotalPublicDashboards,
 		MPublicDashboardRequestCount,
 		MPublicDashboardDatasourceQuerySuccess,
+		MPublicDashboardRateLimitedRequests,
+		MPublicDashboardQueryCache,
 	)
 }
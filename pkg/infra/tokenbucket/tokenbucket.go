@@ -0,0 +1,150 @@
+// Package tokenbucket limits rates per key, such as an org or a client IP,
+// with token buckets. Limits are enforced per Grafana instance.
+package tokenbucket
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// minIdleTimeout is how long the bucket of a key without requests is kept at
+// least. Buckets are kept until they are full again, so removing them doesn't
+// change limiting.
+const minIdleTimeout = time.Minute
+
+// Limiter holds a token bucket per key.
+type Limiter[K comparable] struct {
+	limit       rate.Limit
+	burst       int
+	maxKeys     int
+	idleTimeout time.Duration
+
+	mu          sync.Mutex
+	buckets     map[K]*bucket
+	lastCleanup time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// New creates Limiter allowing perSecond tokens per key with bursts of up to
+// burst tokens. At most maxKeys buckets are kept, when it's reached the least
+// recently used one is removed, which only makes limiting of the key idle the
+// longest less strict. Zero maxKeys means no maximum. It returns nil, which
+// allows everything, if perSecond is not positive.
+func New[K comparable](perSecond float64, burst int, maxKeys int) *Limiter[K] {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	idleTimeout := time.Duration(float64(burst) / perSecond * float64(time.Second))
+	if idleTimeout < minIdleTimeout {
+		idleTimeout = minIdleTimeout
+	}
+	return &Limiter[K]{
+		limit:       rate.Limit(perSecond),
+		burst:       burst,
+		maxKeys:     maxKeys,
+		idleTimeout: idleTimeout,
+		buckets:     map[K]*bucket{},
+		lastCleanup: time.Now(),
+	}
+}
+
+// Check is a limit checked by Allow.
+type Check struct {
+	// Name identifies the limit when it's exceeded.
+	Name    string
+	reserve func(now time.Time) *rate.Reservation
+}
+
+// Check returns the check taking n tokens from the bucket of key. Checks of a
+// nil Limiter always pass.
+func (l *Limiter[K]) Check(name string, key K, n int) Check {
+	c := Check{Name: name}
+	if l != nil {
+		c.reserve = func(now time.Time) *rate.Reservation {
+			return l.reserve(key, n, now)
+		}
+	}
+	return c
+}
+
+// Allow takes the tokens of all checks. It returns the name of the first
+// exceeded limit and when to retry, which is rate.InfDuration when the limit
+// never allows that many tokens at once. Nothing is consumed from the limits
+// when one of them is exceeded.
+func Allow(now time.Time, checks ...Check) (string, time.Duration) {
+	reservations := make([]*rate.Reservation, 0, len(checks))
+	for _, c := range checks {
+		if c.reserve == nil {
+			continue
+		}
+
+		r := c.reserve(now)
+		delay := rate.InfDuration
+		if r.OK() {
+			delay = r.DelayFrom(now)
+		}
+		if delay > 0 {
+			r.CancelAt(now)
+			for _, reserved := range reservations {
+				reserved.CancelAt(now)
+			}
+			return c.Name, delay
+		}
+		reservations = append(reservations, r)
+	}
+
+	return "", 0
+}
+
+// reserve takes n tokens from the bucket of key.
+func (l *Limiter[K]) reserve(key K, n int, now time.Time) *rate.Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cleanup(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if l.maxKeys > 0 && len(l.buckets) >= l.maxKeys {
+			l.evictLeastRecentlyUsed()
+		}
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	return b.limiter.ReserveN(now, n)
+}
+
+// cleanup removes buckets not used recently. Must be called with mu held.
+func (l *Limiter[K]) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < l.idleTimeout {
+		return
+	}
+	l.lastCleanup = now
+	for k, b := range l.buckets {
+		if now.Sub(b.lastUsed) > l.idleTimeout {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// evictLeastRecentlyUsed removes the bucket used the longest ago. Must be
+// called with mu held.
+func (l *Limiter[K]) evictLeastRecentlyUsed() {
+	var oldestKey K
+	var oldest time.Time
+	found := false
+	for k, b := range l.buckets {
+		if !found || b.lastUsed.Before(oldest) {
+			oldestKey, oldest, found = k, b.lastUsed, true
+		}
+	}
+	delete(l.buckets, oldestKey)
+}
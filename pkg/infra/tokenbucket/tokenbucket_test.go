@@ -0,0 +1,105 @@
+package tokenbucket
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("no limiter is created without a limit", func(t *testing.T) {
+		require.Nil(t, New[string](0, 1, 0))
+
+		var limiter *Limiter[string]
+		limit, _ := Allow(time.Now(), limiter.Check("client", "10.0.0.1", 1))
+		require.Empty(t, limit)
+	})
+
+	t.Run("allows a burst and refills over time", func(t *testing.T) {
+		limiter := New[string](1, 60, 0)
+		now := time.Now()
+
+		for i := 0; i < 60; i++ {
+			limit, _ := Allow(now, limiter.Check("client", "10.0.0.1", 1))
+			require.Empty(t, limit)
+		}
+
+		limit, retryAfter := Allow(now, limiter.Check("client", "10.0.0.1", 1))
+		require.Equal(t, "client", limit)
+		require.Equal(t, time.Second, retryAfter)
+
+		limit, _ = Allow(now.Add(time.Second), limiter.Check("client", "10.0.0.1", 1))
+		require.Empty(t, limit)
+
+		// other keys have their own bucket
+		limit, _ = Allow(now, limiter.Check("client", "10.0.0.2", 1))
+		require.Empty(t, limit)
+	})
+
+	t.Run("more tokens than the burst are never allowed", func(t *testing.T) {
+		limiter := New[int64](100, 100, 0)
+
+		limit, retryAfter := Allow(time.Now(), limiter.Check("bytes", 1, 101))
+		require.Equal(t, "bytes", limit)
+		require.Equal(t, rate.InfDuration, retryAfter)
+	})
+
+	t.Run("nothing is consumed when another limit rejects the request", func(t *testing.T) {
+		clientLimiter := New[string](1, 10, 0)
+		dashboardLimiter := New[string](1, 1, 0)
+		now := time.Now()
+
+		limit, _ := Allow(now,
+			clientLimiter.Check("client", "10.0.0.1", 1),
+			dashboardLimiter.Check("dashboard", "abc123", 1),
+		)
+		require.Empty(t, limit)
+
+		for i := 0; i < 20; i++ {
+			limit, _ = Allow(now,
+				clientLimiter.Check("client", "10.0.0.1", 1),
+				dashboardLimiter.Check("dashboard", "abc123", 1),
+			)
+			require.Equal(t, "dashboard", limit)
+		}
+
+		// the client still has 9 requests left for other dashboards
+		for i := 0; i < 9; i++ {
+			limit, _ = Allow(now, clientLimiter.Check("client", "10.0.0.1", 1))
+			require.Empty(t, limit)
+		}
+	})
+
+	t.Run("removes idle buckets", func(t *testing.T) {
+		limiter := New[string](1, 1, 0)
+		now := time.Now()
+
+		Allow(now, limiter.Check("client", "10.0.0.1", 1))
+		Allow(now.Add(2*minIdleTimeout), limiter.Check("client", "10.0.0.2", 1))
+
+		require.Len(t, limiter.buckets, 1)
+		require.Contains(t, limiter.buckets, "10.0.0.2")
+	})
+
+	t.Run("keeps buckets until they are full again", func(t *testing.T) {
+		limiter := New[string](1, 600, 0)
+		require.Equal(t, 10*time.Minute, limiter.idleTimeout)
+	})
+
+	t.Run("removes the least recently used bucket when full", func(t *testing.T) {
+		limiter := New[string](1, 1, 100)
+		now := time.Now()
+
+		for i := 0; i < 100; i++ {
+			Allow(now.Add(time.Duration(i)*time.Millisecond), limiter.Check("client", strconv.Itoa(i), 1))
+		}
+		Allow(now.Add(time.Second), limiter.Check("client", "new", 1))
+
+		require.Len(t, limiter.buckets, 100)
+		require.NotContains(t, limiter.buckets, "0")
+		require.Contains(t, limiter.buckets, "new")
+	})
+}
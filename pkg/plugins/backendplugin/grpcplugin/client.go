@@ -2,6 +2,7 @@ package grpcplugin
 
 import (
 	"os/exec"
+	"syscall"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/grpcplugin"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -26,12 +27,13 @@ var handshake = goplugin.HandshakeConfig{
 	MagicCookieValue: grpcplugin.MagicCookieValue,
 }
 
-func newClientConfig(executablePath string, env []string, logger log.Logger,
+func newClientConfig(executablePath string, env []string, attr *syscall.SysProcAttr, logger log.Logger,
 	versionedPlugins map[int]goplugin.PluginSet) *goplugin.ClientConfig {
 	// We can ignore gosec G201 here, since the dynamic part of executablePath comes from the plugin definition
 	// nolint:gosec
 	cmd := exec.Command(executablePath)
 	cmd.Env = env
+	cmd.SysProcAttr = attr
 
 	return &goplugin.ClientConfig{
 		Cmd:              cmd,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"syscall"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -22,8 +23,9 @@ type pluginClient interface {
 
 type grpcPlugin struct {
 	descriptor     PluginDescriptor
-	clientFactory  func() *plugin.Client
+	clientFactory  func(attr *syscall.SysProcAttr) *plugin.Client
 	client         *plugin.Client
+	processAttr    *syscall.SysProcAttr
 	pluginClient   pluginClient
 	logger         log.Logger
 	mutex          sync.RWMutex
@@ -36,8 +38,8 @@ func newPlugin(descriptor PluginDescriptor) backendplugin.PluginFactoryFunc {
 		return &grpcPlugin{
 			descriptor: descriptor,
 			logger:     logger,
-			clientFactory: func() *plugin.Client {
-				return plugin.NewClient(newClientConfig(descriptor.executablePath, env, logger, descriptor.versionedPlugins))
+			clientFactory: func(attr *syscall.SysProcAttr) *plugin.Client {
+				return plugin.NewClient(newClientConfig(descriptor.executablePath, env, attr, logger, descriptor.versionedPlugins))
 			},
 		}, nil
 	}
@@ -55,7 +57,7 @@ func (p *grpcPlugin) Start(ctx context.Context) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	p.client = p.clientFactory()
+	p.client = p.clientFactory(p.processAttr)
 	rpcClient, err := p.client.Client()
 	if err != nil {
 		return err
@@ -107,6 +109,25 @@ func (p *grpcPlugin) Exited() bool {
 	return true
 }
 
+func (p *grpcPlugin) ProcessID() (int, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.client == nil || p.client.Exited() {
+		return 0, false
+	}
+	rc := p.client.ReattachConfig()
+	if rc == nil || rc.Pid == 0 {
+		return 0, false
+	}
+	return rc.Pid, true
+}
+
+func (p *grpcPlugin) SetProcessAttr(attr *syscall.SysProcAttr) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.processAttr = attr
+}
+
 func (p *grpcPlugin) Decommission() error {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
@@ -2,6 +2,7 @@ package backendplugin
 
 import (
 	"context"
+	"syscall"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -23,3 +24,16 @@ type Plugin interface {
 	backend.CallResourceHandler
 	backend.StreamHandler
 }
+
+// ProcessIDProvider is implemented by plugins running in a separate process.
+type ProcessIDProvider interface {
+	// ProcessID returns the ID of the running plugin process.
+	ProcessID() (int, bool)
+}
+
+// ProcessAttrSetter is implemented by plugins running in a separate process.
+type ProcessAttrSetter interface {
+	// SetProcessAttr sets OS specific attributes the plugin process is
+	// started with. Nil resets them.
+	SetProcessAttr(attr *syscall.SysProcAttr)
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 
@@ -28,9 +30,67 @@ type Cfg struct {
 	// Azure Cloud settings
 	Azure *azsettings.AzureSettings
 
+	// Backend plugin process limits, can be overridden per plugin in
+	// [plugin.<id>] sections with max_memory_mb and max_cpu.
+	ProcessLimits            ProcessLimits
+	ProcessMaxRestartBackoff time.Duration
+
+	// HotReload enables reloading External plugins when their files change.
+	HotReload         bool
+	HotReloadInterval time.Duration
+
 	BuildVersion string // TODO Remove
 }
 
+// ProcessLimits are resource limits of a backend plugin process. Zero values
+// mean no limit.
+type ProcessLimits struct {
+	// MaxMemoryMB is the maximum memory in megabytes.
+	MaxMemoryMB int `json:"maxMemoryMB,omitempty"`
+	// MaxCPU is the maximum CPU usage in number of CPUs, e.g. 0.5.
+	MaxCPU float64 `json:"maxCPU,omitempty"`
+}
+
+// Enabled returns true if any limit is set.
+func (l ProcessLimits) Enabled() bool {
+	return l.MaxMemoryMB > 0 || l.MaxCPU > 0
+}
+
+// PluginProcessLimits returns resource limits of the process of a plugin.
+func (cfg *Cfg) PluginProcessLimits(pluginID string) ProcessLimits {
+	limits := cfg.ProcessLimits
+	settings := cfg.PluginSettings[pluginID]
+	if v, ok := settings["max_memory_mb"]; ok {
+		if mb, err := strconv.Atoi(v); err == nil && mb >= 0 {
+			limits.MaxMemoryMB = mb
+		} else {
+			log.New("plugin.cfg").Warn("Invalid max_memory_mb plugin setting", "pluginID", pluginID, "value", v)
+		}
+	}
+	if v, ok := settings["max_cpu"]; ok {
+		if cpu, err := strconv.ParseFloat(v, 64); err == nil && cpu >= 0 {
+			limits.MaxCPU = cpu
+		} else {
+			log.New("plugin.cfg").Warn("Invalid max_cpu plugin setting", "pluginID", pluginID, "value", v)
+		}
+	}
+	return limits
+}
+
+// ProcessLimitsConfigured returns true if resource limits are set for any
+// plugin process.
+func (cfg *Cfg) ProcessLimitsConfigured() bool {
+	if cfg.ProcessLimits.Enabled() {
+		return true
+	}
+	for pluginID := range cfg.PluginSettings {
+		if cfg.PluginProcessLimits(pluginID).Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
 func ProvideConfig(settingProvider setting.Provider, grafanaCfg *setting.Cfg) *Cfg {
 	return NewCfg(settingProvider, grafanaCfg)
 }
@@ -62,6 +122,13 @@ func NewCfg(settingProvider setting.Provider, grafanaCfg *setting.Cfg) *Cfg {
 		PluginsAllowUnsigned:    allowedUnsigned,
 		AWSAllowedAuthProviders: allowedAuth,
 		AWSAssumeRoleEnabled:    aws.KeyValue("assume_role_enabled").MustBool(grafanaCfg.AWSAssumeRoleEnabled),
+		ProcessLimits: ProcessLimits{
+			MaxMemoryMB: grafanaCfg.PluginsProcessMaxMemoryMB,
+			MaxCPU:      grafanaCfg.PluginsProcessMaxCPU,
+		},
+		ProcessMaxRestartBackoff: grafanaCfg.PluginsProcessMaxRestartBackoff,
+		HotReload:                grafanaCfg.PluginsHotReload,
+		HotReloadInterval:        grafanaCfg.PluginsHotReloadInterval,
 		Azure: &azsettings.AzureSettings{
 			Cloud:                   azure.KeyValue("cloud").MustString(grafanaCfg.Azure.Cloud),
 			ManagedIdentityEnabled:  azure.KeyValue("managed_identity_enabled").MustBool(grafanaCfg.Azure.ManagedIdentityEnabled),
@@ -36,3 +36,25 @@ func TestPluginSettings(t *testing.T) {
 	require.Equal(t, ps["secret-plugin"]["secret_key"], "secret")
 	require.Equal(t, ps["secret-plugin"]["normal_key"], "not a secret")
 }
+
+func TestPluginProcessLimits(t *testing.T) {
+	cfg := &Cfg{
+		ProcessLimits: ProcessLimits{MaxMemoryMB: 512, MaxCPU: 1},
+		PluginSettings: setting.PluginSettings{
+			"test-datasource": {"max_memory_mb": "128", "max_cpu": "0.5"},
+			"invalid-plugin":  {"max_memory_mb": "-1", "max_cpu": "a lot"},
+		},
+	}
+
+	require.Equal(t, ProcessLimits{MaxMemoryMB: 128, MaxCPU: 0.5}, cfg.PluginProcessLimits("test-datasource"))
+	require.Equal(t, ProcessLimits{MaxMemoryMB: 512, MaxCPU: 1}, cfg.PluginProcessLimits("invalid-plugin"))
+	require.Equal(t, ProcessLimits{MaxMemoryMB: 512, MaxCPU: 1}, cfg.PluginProcessLimits("other-plugin"))
+	require.False(t, ProcessLimits{}.Enabled())
+	require.True(t, cfg.ProcessLimitsConfigured())
+
+	cfg.ProcessLimits = ProcessLimits{}
+	require.True(t, cfg.ProcessLimitsConfigured())
+
+	cfg.PluginSettings = setting.PluginSettings{"test-datasource": {"max_memory_mb": "0"}}
+	require.False(t, cfg.ProcessLimitsConfigured())
+}
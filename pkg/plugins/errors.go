@@ -13,4 +13,8 @@ var (
 	ErrMethodNotImplemented = errutil.NewBase(errutil.StatusNotImplemented, "plugin.notImplemented")
 	// ErrPluginDownstreamError error returned when a plugin method is not implemented.
 	ErrPluginDownstreamError = errutil.NewBase(errutil.StatusInternal, "plugin.downstreamError", errutil.WithPublicMessage("An error occurred within the plugin"))
+	// ErrPluginRequestTimeout error returned when a plugin request times out.
+	ErrPluginRequestTimeout = errutil.NewBase(errutil.StatusTimeout, "plugin.requestTimeout", errutil.WithPublicMessage("The plugin request timed out"))
+	// ErrPluginResponseTooLarge error returned when a plugin response exceeds the response limit.
+	ErrPluginResponseTooLarge = errutil.NewBase(errutil.StatusInternal, "plugin.responseTooLarge", errutil.WithPublicMessage("The plugin response is too large"))
 )
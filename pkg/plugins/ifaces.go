@@ -23,6 +23,17 @@ type Installer interface {
 	Remove(ctx context.Context, pluginID string) error
 }
 
+// Reloader loads, unloads and reloads External plugins without restarting Grafana.
+type Reloader interface {
+	// Load loads a plugin found in the plugin directories.
+	Load(ctx context.Context, pluginID string) error
+	// Unload stops a plugin and unregisters it, without removing it from the file system.
+	Unload(ctx context.Context, pluginID string) error
+	// Reload reloads a plugin from its directory, restarting its backend process
+	// and validating its signature again.
+	Reload(ctx context.Context, pluginID string) error
+}
+
 type PluginSource struct {
 	Class Class
 	Paths []string
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// CacheMiddlewareName is the middleware name used by CacheMiddleware.
+const CacheMiddlewareName = "cache"
+
+var queryCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Name:      "plugin_query_cache_requests_total",
+	Help:      "Number of query requests to plugins looked up in the query cache.",
+}, []string{"plugin_id", "result"})
+
+// authHeaders are the headers Grafana sets on query requests to authenticate
+// users with data sources, such as forwarded OAuth tokens and cookies.
+var authHeaders = []string{"Authorization", "X-ID-Token", "Cookie"}
+
+// CacheMiddleware caches query responses of plugins for the query_cache_ttl
+// setting. Responses are cached per user, data source, auth and forwarded
+// headers, so only identical requests get cached responses. Other headers,
+// such as the ones propagating traces, differ for every request and aren't
+// part of the cache key. Responses with errors aren't cached.
+func CacheMiddleware() Middleware {
+	return NamedMiddlewareFunc(CacheMiddlewareName, func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+		ttl := opts.Settings.QueryCacheTTL
+		if ttl <= 0 {
+			return next
+		}
+		headers := append([]string{}, authHeaders...)
+		for _, h := range opts.Settings.ForwardHeaders {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+		return &cacheMiddleware{
+			Client:  next,
+			cache:   localcache.New(ttl, 2*ttl),
+			headers: headers,
+		}
+	})
+}
+
+type cacheMiddleware struct {
+	plugins.Client
+	cache *localcache.CacheService
+	// headers are the request headers responses are cached by.
+	headers []string
+}
+
+func (m *cacheMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	key, err := queryCacheKey(req, m.headers)
+	if err != nil {
+		return m.Client.QueryData(ctx, req)
+	}
+
+	// Responses are cached encoded, so callers modifying responses don't
+	// change cached ones.
+	if cached, ok := m.cache.Get(key); ok {
+		resp := &backend.QueryDataResponse{}
+		if err := json.Unmarshal(cached.([]byte), resp); err == nil {
+			queryCacheRequests.WithLabelValues(req.PluginContext.PluginID, "hit").Inc()
+			return resp, nil
+		}
+	}
+	queryCacheRequests.WithLabelValues(req.PluginContext.PluginID, "miss").Inc()
+
+	resp, err := m.Client.QueryData(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	for _, r := range resp.Responses {
+		if r.Error != nil {
+			return resp, nil
+		}
+	}
+	if b, err := json.Marshal(resp); err == nil {
+		m.cache.SetDefault(key, b)
+	}
+	return resp, nil
+}
+
+// queryCacheKey returns the key of the cached response of a query request
+// with the given headers.
+func queryCacheKey(req *backend.QueryDataRequest, headers []string) (string, error) {
+	key := struct {
+		OrgID      int64
+		User       string
+		Datasource string
+		Updated    time.Time
+		Headers    map[string]string
+		Queries    []backend.DataQuery
+	}{
+		OrgID:   req.PluginContext.OrgID,
+		Headers: map[string]string{},
+		Queries: req.Queries,
+	}
+	for _, h := range headers {
+		if v, ok := req.Headers[h]; ok {
+			key.Headers[h] = v
+		}
+	}
+	if u := req.PluginContext.User; u != nil {
+		key.User = u.Login
+	}
+	if ds := req.PluginContext.DataSourceInstanceSettings; ds != nil {
+		key.Datasource = ds.UID
+		key.Updated = ds.Updated
+	}
+
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins/manager/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	var calls int
+	next := &fakes.FakePluginClient{
+		QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			calls++
+			resp := backend.NewQueryDataResponse()
+			for _, q := range req.Queries {
+				if string(q.JSON) == `{"fail":true}` {
+					resp.Responses[q.RefID] = backend.DataResponse{Error: errors.New("query failed")}
+					continue
+				}
+				resp.Responses[q.RefID] = backend.DataResponse{
+					Frames: data.Frames{data.NewFrame("test", data.NewField("value", nil, []float64{1, 2, 3}))},
+				}
+			}
+			return resp, nil
+		},
+	}
+	newRequest := func(user string, query string, headers ...string) *backend.QueryDataRequest {
+		req := &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				PluginID:                   "test-datasource",
+				OrgID:                      1,
+				User:                       &backend.User{Login: user},
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds"},
+			},
+			Queries: []backend.DataQuery{{RefID: "A", JSON: json.RawMessage(query)}},
+			Headers: map[string]string{},
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Headers[headers[i]] = headers[i+1]
+		}
+		return req
+	}
+
+	t.Run("No caching by default", func(t *testing.T) {
+		require.Same(t, next, CacheMiddleware().CreateClientMiddleware(MiddlewareOptions{}, next))
+	})
+
+	client := CacheMiddleware().CreateClientMiddleware(MiddlewareOptions{
+		Settings: setting.PluginClientSettings{QueryCacheTTL: time.Minute, ForwardHeaders: []string{"x-tenant"}},
+	}, next)
+
+	t.Run("Identical requests are cached", func(t *testing.T) {
+		calls = 0
+		resp, err := client.QueryData(context.Background(), newRequest("admin", `{"q":1}`))
+		require.NoError(t, err)
+		// Callers modifying responses don't change cached ones.
+		resp.Responses["A"].Frames[0].Name = "modified"
+
+		resp, err = client.QueryData(context.Background(), newRequest("admin", `{"q":1}`))
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+		require.Equal(t, "test", resp.Responses["A"].Frames[0].Name)
+		require.Equal(t, 3, resp.Responses["A"].Frames[0].Rows())
+	})
+
+	t.Run("Requests of different users and queries aren't shared", func(t *testing.T) {
+		calls = 0
+		_, err := client.QueryData(context.Background(), newRequest("viewer", `{"q":1}`))
+		require.NoError(t, err)
+		_, err = client.QueryData(context.Background(), newRequest("admin", `{"q":2}`))
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("Requests with different auth or forwarded headers aren't shared", func(t *testing.T) {
+		calls = 0
+		_, err := client.QueryData(context.Background(), newRequest("admin", `{"q":3}`, "Authorization", "Bearer a"))
+		require.NoError(t, err)
+		_, err = client.QueryData(context.Background(), newRequest("admin", `{"q":3}`, "Authorization", "Bearer b"))
+		require.NoError(t, err)
+		_, err = client.QueryData(context.Background(), newRequest("admin", `{"q":3}`, "Authorization", "Bearer b", "X-Tenant", "1"))
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("Trace propagation headers don't prevent caching", func(t *testing.T) {
+		calls = 0
+		_, err := client.QueryData(context.Background(), newRequest("admin", `{"q":4}`, "traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"))
+		require.NoError(t, err)
+		_, err = client.QueryData(context.Background(), newRequest("admin", `{"q":4}`, "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("Responses with errors aren't cached", func(t *testing.T) {
+		calls = 0
+		_, err := client.QueryData(context.Background(), newRequest("admin", `{"fail":true}`))
+		require.NoError(t, err)
+		_, err = client.QueryData(context.Background(), newRequest("admin", `{"fail":true}`))
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+	})
+}
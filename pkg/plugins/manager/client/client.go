@@ -4,36 +4,193 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
 	"github.com/grafana/grafana/pkg/plugins/manager/registry"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var _ plugins.Client = (*Service)(nil)
 
 type Service struct {
 	pluginRegistry registry.Service
+	cfg            setting.PluginClientCfg
+	middlewares    []Middleware
+
+	mu      sync.Mutex
+	clients map[string]*middlewareClient
+}
+
+// middlewareClient is the client of a plugin wrapped with middlewares.
+type middlewareClient struct {
+	plugin *plugins.Plugin
+	client plugins.Client
+}
+
+func ProvideService(pluginRegistry registry.Service, cfg *setting.Cfg, tracer tracing.Tracer) *Service {
+	return NewService(pluginRegistry, cfg.PluginClient, DefaultMiddlewares(tracer)...)
 }
 
-func ProvideService(pluginRegistry registry.Service) *Service {
-	return &Service{
+// NewService returns a plugins client wrapping requests to each plugin with
+// the middlewares enabled for its type in cfg.
+func NewService(pluginRegistry registry.Service, cfg setting.PluginClientCfg, middlewares ...Middleware) *Service {
+	s := &Service{
 		pluginRegistry: pluginRegistry,
+		cfg:            cfg,
+		middlewares:    middlewares,
+		clients:        map[string]*middlewareClient{},
 	}
+	s.checkMiddlewareNames()
+	return s
 }
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	plugin, exists := s.plugin(ctx, req.PluginContext.PluginID)
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
 	if !exists {
 		return nil, plugins.ErrPluginNotRegistered.Errorf("%w", backendplugin.ErrPluginNotRegistered)
 	}
 
+	return c.QueryData(ctx, req)
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
+	if !exists {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	return c.CallResource(ctx, req, sender)
+}
+
+func (s *Service) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
+	if !exists {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	return c.CollectMetrics(ctx, req)
+}
+
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
+	if !exists {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	return c.CheckHealth(ctx, req)
+}
+
+func (s *Service) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
+	if !exists {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	return c.SubscribeStream(ctx, req)
+}
+
+func (s *Service) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
+	if !exists {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	return c.PublishStream(ctx, req)
+}
+
+func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	c, exists := s.client(ctx, req.PluginContext.PluginID)
+	if !exists {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	return c.RunStream(ctx, req, sender)
+}
+
+// client returns the client of the plugin with `pluginID` wrapped with
+// middlewares. Clients are created once per plugin instance, so a reloaded
+// plugin gets a new one.
+func (s *Service) client(ctx context.Context, pluginID string) (plugins.Client, bool) {
+	p, exists := s.plugin(ctx, pluginID)
+	if !exists {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.clients[pluginID]; ok && c.plugin == p {
+		return c.client, true
+	}
+
+	opts := MiddlewareOptions{
+		PluginID:   p.ID,
+		PluginType: p.Type,
+		Settings:   s.cfg.ForType(string(p.Type)),
+	}
+	c := &middlewareClient{
+		plugin: p,
+		client: chain(opts, s.middlewares, &pluginClient{plugin: p}),
+	}
+	s.clients[pluginID] = c
+	return c.client, true
+}
+
+// checkMiddlewareNames warns about middlewares enabled in settings that
+// don't exist.
+func (s *Service) checkMiddlewareNames() {
+	names := map[string]bool{}
+	for _, m := range s.middlewares {
+		if n, ok := m.(MiddlewareName); ok {
+			names[n.MiddlewareName()] = true
+		}
+	}
+
+	logger := log.New("plugin.client")
+	check := func(pluginType string, settings setting.PluginClientSettings) {
+		for _, name := range settings.Middlewares {
+			if !names[name] {
+				logger.Warn("Unknown plugin client middleware", "name", name, "pluginType", pluginType)
+			}
+		}
+	}
+	check("", s.cfg.Default)
+	for pluginType, settings := range s.cfg.ByType {
+		check(pluginType, settings)
+	}
+}
+
+// plugin finds a plugin with `pluginID` from the registry that is not decommissioned
+func (s *Service) plugin(ctx context.Context, pluginID string) (*plugins.Plugin, bool) {
+	p, exists := s.pluginRegistry.Plugin(ctx, pluginID)
+	if !exists {
+		return nil, false
+	}
+
+	if p.IsDecommissioned() {
+		return nil, false
+	}
+
+	return p, true
+}
+
+var _ plugins.Client = (*pluginClient)(nil)
+
+// pluginClient calls a plugin, it's the last client of the middleware chain.
+type pluginClient struct {
+	plugin *plugins.Plugin
+}
+
+func (c *pluginClient) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	var resp *backend.QueryDataResponse
 	err := instrumentation.InstrumentQueryDataRequest(req.PluginContext.PluginID, func() (innerErr error) {
-		resp, innerErr = plugin.QueryData(ctx, req)
+		resp, innerErr = c.plugin.QueryData(ctx, req)
 		return
 	})
 
@@ -61,13 +218,9 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 	return resp, err
 }
 
-func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
-	p, exists := s.plugin(ctx, req.PluginContext.PluginID)
-	if !exists {
-		return backendplugin.ErrPluginNotRegistered
-	}
-	err := instrumentation.InstrumentCallResourceRequest(p.PluginID(), func() error {
-		if err := p.CallResource(ctx, req, sender); err != nil {
+func (c *pluginClient) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	err := instrumentation.InstrumentCallResourceRequest(c.plugin.PluginID(), func() error {
+		if err := c.plugin.CallResource(ctx, req, sender); err != nil {
 			return err
 		}
 		return nil
@@ -80,15 +233,10 @@ func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceReq
 	return nil
 }
 
-func (s *Service) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
-	p, exists := s.plugin(ctx, req.PluginContext.PluginID)
-	if !exists {
-		return nil, backendplugin.ErrPluginNotRegistered
-	}
-
+func (c *pluginClient) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
 	var resp *backend.CollectMetricsResult
-	err := instrumentation.InstrumentCollectMetrics(p.PluginID(), func() (innerErr error) {
-		resp, innerErr = p.CollectMetrics(ctx, req)
+	err := instrumentation.InstrumentCollectMetrics(c.plugin.PluginID(), func() (innerErr error) {
+		resp, innerErr = c.plugin.CollectMetrics(ctx, req)
 		return
 	})
 	if err != nil {
@@ -98,15 +246,10 @@ func (s *Service) CollectMetrics(ctx context.Context, req *backend.CollectMetric
 	return resp, nil
 }
 
-func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
-	p, exists := s.plugin(ctx, req.PluginContext.PluginID)
-	if !exists {
-		return nil, backendplugin.ErrPluginNotRegistered
-	}
-
+func (c *pluginClient) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	var resp *backend.CheckHealthResult
-	err := instrumentation.InstrumentCheckHealthRequest(p.PluginID(), func() (innerErr error) {
-		resp, innerErr = p.CheckHealth(ctx, req)
+	err := instrumentation.InstrumentCheckHealthRequest(c.plugin.PluginID(), func() (innerErr error) {
+		resp, innerErr = c.plugin.CheckHealth(ctx, req)
 		return
 	})
 
@@ -125,43 +268,14 @@ func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthReque
 	return resp, nil
 }
 
-func (s *Service) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
-	plugin, exists := s.plugin(ctx, req.PluginContext.PluginID)
-	if !exists {
-		return nil, backendplugin.ErrPluginNotRegistered
-	}
-
-	return plugin.SubscribeStream(ctx, req)
-}
-
-func (s *Service) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
-	plugin, exists := s.plugin(ctx, req.PluginContext.PluginID)
-	if !exists {
-		return nil, backendplugin.ErrPluginNotRegistered
-	}
-
-	return plugin.PublishStream(ctx, req)
+func (c *pluginClient) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return c.plugin.SubscribeStream(ctx, req)
 }
 
-func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
-	plugin, exists := s.plugin(ctx, req.PluginContext.PluginID)
-	if !exists {
-		return backendplugin.ErrPluginNotRegistered
-	}
-
-	return plugin.RunStream(ctx, req, sender)
+func (c *pluginClient) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return c.plugin.PublishStream(ctx, req)
 }
 
-// plugin finds a plugin with `pluginID` from the registry that is not decommissioned
-func (s *Service) plugin(ctx context.Context, pluginID string) (*plugins.Plugin, bool) {
-	p, exists := s.pluginRegistry.Plugin(ctx, pluginID)
-	if !exists {
-		return nil, false
-	}
-
-	if p.IsDecommissioned() {
-		return nil, false
-	}
-
-	return p, true
+func (c *pluginClient) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return c.plugin.RunStream(ctx, req, sender)
 }
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+)
+
+// ForwardHeadersMiddlewareName is the middleware name used by
+// ForwardHeadersMiddleware.
+const ForwardHeadersMiddlewareName = "forward-headers"
+
+// ForwardHeadersMiddleware forwards the headers in the forward_headers setting
+// of the incoming HTTP request to plugins. Headers already set on the plugin
+// request aren't overridden.
+func ForwardHeadersMiddleware() Middleware {
+	return NamedMiddlewareFunc(ForwardHeadersMiddlewareName, func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+		if len(opts.Settings.ForwardHeaders) == 0 {
+			return next
+		}
+		headers := make([]string, 0, len(opts.Settings.ForwardHeaders))
+		for _, h := range opts.Settings.ForwardHeaders {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+		return &forwardHeadersMiddleware{
+			Client:  next,
+			headers: headers,
+		}
+	})
+}
+
+type forwardHeadersMiddleware struct {
+	plugins.Client
+	headers []string
+}
+
+func (m *forwardHeadersMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	req.Headers = m.forwardTo(ctx, req.Headers)
+	return m.Client.QueryData(ctx, req)
+}
+
+func (m *forwardHeadersMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	incoming := incomingHeaders(ctx)
+	for _, h := range m.headers {
+		if _, exists := req.Headers[h]; exists {
+			continue
+		}
+		if values := incoming.Values(h); len(values) > 0 {
+			if req.Headers == nil {
+				req.Headers = map[string][]string{}
+			}
+			req.Headers[h] = values
+		}
+	}
+	return m.Client.CallResource(ctx, req, sender)
+}
+
+func (m *forwardHeadersMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	req.Headers = m.forwardTo(ctx, req.Headers)
+	return m.Client.CheckHealth(ctx, req)
+}
+
+func (m *forwardHeadersMiddleware) forwardTo(ctx context.Context, headers map[string]string) map[string]string {
+	incoming := incomingHeaders(ctx)
+	for _, h := range m.headers {
+		if _, exists := headers[h]; exists {
+			continue
+		}
+		if values := incoming.Values(h); len(values) > 0 {
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers[h] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+// incomingHeaders returns the headers of the HTTP request that caused the
+// plugin request, if any.
+func incomingHeaders(ctx context.Context) http.Header {
+	if c := contexthandler.FromContext(ctx); c != nil && c.Context != nil && c.Req != nil {
+		return c.Req.Header
+	}
+	return http.Header{}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/manager/fakes"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestForwardHeadersMiddleware(t *testing.T) {
+	var queryHeaders map[string]string
+	var resourceHeaders map[string][]string
+	next := &fakes.FakePluginClient{
+		QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			queryHeaders = req.Headers
+			return backend.NewQueryDataResponse(), nil
+		},
+		CallResourceHandlerFunc: func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			resourceHeaders = req.Headers
+			return nil
+		},
+	}
+
+	t.Run("No headers are forwarded by default", func(t *testing.T) {
+		require.Same(t, next, ForwardHeadersMiddleware().CreateClientMiddleware(MiddlewareOptions{}, next))
+	})
+
+	client := ForwardHeadersMiddleware().CreateClientMiddleware(MiddlewareOptions{
+		Settings: setting.PluginClientSettings{ForwardHeaders: []string{"x-tenant-id", "X-Request-Id"}},
+	}, next)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Tenant-Id", "tenant")
+	req.Header.Add("X-Request-Id", "a")
+	req.Header.Add("X-Request-Id", "b")
+	req.Header.Set("X-Other", "other")
+	ctx := ctxkey.Set(context.Background(), &models.ReqContext{Context: &web.Context{Req: req}})
+
+	t.Run("Configured headers are forwarded to queries", func(t *testing.T) {
+		_, err := client.QueryData(ctx, &backend.QueryDataRequest{
+			Headers: map[string]string{"X-Tenant-Id": "set by grafana"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"X-Tenant-Id":  "set by grafana",
+			"X-Request-Id": "a, b",
+		}, queryHeaders)
+	})
+
+	t.Run("Configured headers are forwarded to resource calls", func(t *testing.T) {
+		err := client.CallResource(ctx, &backend.CallResourceRequest{}, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{
+			"X-Tenant-Id":  {"tenant"},
+			"X-Request-Id": {"a", "b"},
+		}, resourceHeaders)
+	})
+
+	t.Run("Requests without incoming HTTP request", func(t *testing.T) {
+		_, err := client.QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.Nil(t, queryHeaders)
+	})
+}
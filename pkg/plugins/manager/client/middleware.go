@@ -0,0 +1,95 @@
+package client
+
+import (
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// MiddlewareOptions are passed to middlewares when creating the client of a
+// plugin.
+type MiddlewareOptions struct {
+	PluginID   string
+	PluginType plugins.Type
+	// Settings are the plugin client settings of the plugin type.
+	Settings setting.PluginClientSettings
+}
+
+// Middleware wraps the client of a plugin to handle requests to it.
+type Middleware interface {
+	// CreateClientMiddleware creates a client handling requests to a plugin,
+	// next is the client to call next in the chain.
+	CreateClientMiddleware(opts MiddlewareOptions, next plugins.Client) plugins.Client
+}
+
+// MiddlewareName is implemented by middlewares that can be enabled by name
+// with the middlewares setting.
+type MiddlewareName interface {
+	// MiddlewareName returns the middleware name.
+	MiddlewareName() string
+}
+
+// The MiddlewareFunc type is an adapter to allow the use of ordinary
+// functions as Middleware.
+type MiddlewareFunc func(opts MiddlewareOptions, next plugins.Client) plugins.Client
+
+// CreateClientMiddleware implements Middleware.
+func (fn MiddlewareFunc) CreateClientMiddleware(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+	return fn(opts, next)
+}
+
+type namedMiddleware struct {
+	name string
+	MiddlewareFunc
+}
+
+func (m namedMiddleware) MiddlewareName() string {
+	return m.name
+}
+
+// NamedMiddlewareFunc returns a named middleware.
+func NamedMiddlewareFunc(name string, fn MiddlewareFunc) Middleware {
+	return namedMiddleware{
+		name:           name,
+		MiddlewareFunc: fn,
+	}
+}
+
+// DefaultMiddlewares returns the built-in middlewares. Each of them can be
+// enabled per plugin type with the middlewares setting.
+func DefaultMiddlewares(tracer tracing.Tracer) []Middleware {
+	return []Middleware{
+		TracingMiddleware(tracer),
+		ForwardHeadersMiddleware(),
+		CacheMiddleware(),
+		ResponseLimitMiddleware(),
+		TimeoutMiddleware(),
+	}
+}
+
+// chain wraps client with the middlewares enabled in settings. Middlewares
+// without a name are always applied, after the named ones.
+func chain(opts MiddlewareOptions, middlewares []Middleware, client plugins.Client) plugins.Client {
+	named := map[string]Middleware{}
+	var unnamed []Middleware
+	for _, m := range middlewares {
+		if n, ok := m.(MiddlewareName); ok {
+			named[n.MiddlewareName()] = m
+			continue
+		}
+		unnamed = append(unnamed, m)
+	}
+
+	var enabled []Middleware
+	for _, name := range opts.Settings.Middlewares {
+		if m, exists := named[name]; exists {
+			enabled = append(enabled, m)
+		}
+	}
+	enabled = append(enabled, unnamed...)
+
+	for i := len(enabled) - 1; i >= 0; i-- {
+		client = enabled[i].CreateClientMiddleware(opts, client)
+	}
+	return client
+}
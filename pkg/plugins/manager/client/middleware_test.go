@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return NamedMiddlewareFunc(name, func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+			return &recordingMiddleware{Client: next, record: func() { calls = append(calls, name) }}
+		})
+	}
+	unnamed := MiddlewareFunc(func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+		return &recordingMiddleware{Client: next, record: func() { calls = append(calls, "unnamed") }}
+	})
+
+	registry := fakes.NewFakePluginRegistry()
+	ds := &plugins.Plugin{JSONData: plugins.JSONData{ID: "test-datasource", Type: plugins.DataSource}}
+	ds.RegisterClient(&fakes.FakePluginClient{
+		QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			calls = append(calls, "plugin")
+			return backend.NewQueryDataResponse(), nil
+		},
+	})
+	app := &plugins.Plugin{JSONData: plugins.JSONData{ID: "test-app", Type: plugins.App}}
+	app.RegisterClient(&fakes.FakePluginClient{
+		QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			calls = append(calls, "plugin")
+			return backend.NewQueryDataResponse(), nil
+		},
+	})
+	require.NoError(t, registry.Add(context.Background(), ds))
+	require.NoError(t, registry.Add(context.Background(), app))
+
+	cfg := setting.PluginClientCfg{
+		Default: setting.PluginClientSettings{Middlewares: []string{"first", "second", "unknown"}},
+		ByType: map[string]setting.PluginClientSettings{
+			"app": {Middlewares: []string{"second"}},
+		},
+	}
+	client := NewService(registry, cfg, unnamed, record("second"), record("first"), record("disabled"))
+
+	t.Run("Enabled middlewares are applied in configured order", func(t *testing.T) {
+		calls = nil
+		_, err := client.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{PluginID: "test-datasource"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"first", "second", "unnamed", "plugin"}, calls)
+	})
+
+	t.Run("Middlewares are configured per plugin type", func(t *testing.T) {
+		calls = nil
+		_, err := client.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{PluginID: "test-app"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"second", "unnamed", "plugin"}, calls)
+	})
+
+	t.Run("Reloaded plugin gets a new client", func(t *testing.T) {
+		c1, exists := client.client(context.Background(), "test-datasource")
+		require.True(t, exists)
+		c2, _ := client.client(context.Background(), "test-datasource")
+		require.Same(t, c1, c2)
+
+		reloaded := &plugins.Plugin{JSONData: plugins.JSONData{ID: "test-datasource", Type: plugins.DataSource}}
+		reloaded.RegisterClient(&fakes.FakePluginClient{})
+		require.NoError(t, registry.Add(context.Background(), reloaded))
+
+		c3, _ := client.client(context.Background(), "test-datasource")
+		require.NotSame(t, c1, c3)
+	})
+}
+
+type recordingMiddleware struct {
+	plugins.Client
+	record func()
+}
+
+func (m *recordingMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	m.record()
+	return m.Client.QueryData(ctx, req)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// ResponseLimitMiddlewareName is the middleware name used by
+// ResponseLimitMiddleware.
+const ResponseLimitMiddlewareName = "response-limit"
+
+// ResponseLimitMiddleware fails requests to plugins with responses larger
+// than the response_limit setting.
+func ResponseLimitMiddleware() Middleware {
+	return NamedMiddlewareFunc(ResponseLimitMiddlewareName, func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+		if opts.Settings.ResponseLimit <= 0 {
+			return next
+		}
+		return &responseLimitMiddleware{
+			Client: next,
+			limit:  opts.Settings.ResponseLimit,
+		}
+	})
+}
+
+type responseLimitMiddleware struct {
+	plugins.Client
+	limit int64
+}
+
+func (m *responseLimitMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp, err := m.Client.QueryData(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	// The size of the JSON encoding is what's sent to the client.
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > m.limit {
+		return nil, plugins.ErrPluginResponseTooLarge.Errorf("query response size %d exceeds the limit of %d bytes", len(b), m.limit)
+	}
+	return resp, nil
+}
+
+func (m *responseLimitMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var size int64
+	return m.Client.CallResource(ctx, req, callResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		size += int64(len(res.Body))
+		if size > m.limit {
+			return plugins.ErrPluginResponseTooLarge.Errorf("resource response exceeds the limit of %d bytes", m.limit)
+		}
+		return sender.Send(res)
+	}))
+}
+
+type callResourceResponseSenderFunc func(res *backend.CallResourceResponse) error
+
+func (fn callResourceResponseSenderFunc) Send(res *backend.CallResourceResponse) error {
+	return fn(res)
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestResponseLimitMiddleware(t *testing.T) {
+	next := &fakes.FakePluginClient{
+		QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{
+				Frames: data.Frames{data.NewFrame("test", data.NewField("value", nil, make([]float64, 1000)))},
+			}
+			return resp, nil
+		},
+		CallResourceHandlerFunc: func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			for i := 0; i < 10; i++ {
+				if err := sender.Send(&backend.CallResourceResponse{Body: make([]byte, 100)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	create := func(limit int64) plugins.Client {
+		return ResponseLimitMiddleware().CreateClientMiddleware(MiddlewareOptions{
+			Settings: setting.PluginClientSettings{ResponseLimit: limit},
+		}, next)
+	}
+
+	t.Run("No limit by default", func(t *testing.T) {
+		require.Same(t, next, create(0))
+	})
+
+	t.Run("Query response within limit", func(t *testing.T) {
+		resp, err := create(1<<20).QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Responses, 1)
+	})
+
+	t.Run("Query response exceeding limit", func(t *testing.T) {
+		_, err := create(100).QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.ErrorIs(t, err, plugins.ErrPluginResponseTooLarge)
+	})
+
+	t.Run("Resource response exceeding limit", func(t *testing.T) {
+		var sent int
+		err := create(500).CallResource(context.Background(), &backend.CallResourceRequest{}, callResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+			sent += len(res.Body)
+			return nil
+		}))
+		require.ErrorIs(t, err, plugins.ErrPluginResponseTooLarge)
+		require.Equal(t, 500, sent)
+	})
+}
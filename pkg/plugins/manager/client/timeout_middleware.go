@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// TimeoutMiddlewareName is the middleware name used by TimeoutMiddleware.
+const TimeoutMiddlewareName = "timeout"
+
+// TimeoutMiddleware cancels requests to plugins that take longer than the
+// timeout setting. Data sources can override the timeout with the
+// pluginRequestTimeout in seconds in their JSON data. It limits whole plugin
+// requests, unlike the timeout of HTTP requests data sources send.
+func TimeoutMiddleware() Middleware {
+	return NamedMiddlewareFunc(TimeoutMiddlewareName, func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+		return &timeoutMiddleware{
+			Client:  next,
+			timeout: opts.Settings.Timeout,
+		}
+	})
+}
+
+type timeoutMiddleware struct {
+	plugins.Client
+	timeout time.Duration
+}
+
+func (m *timeoutMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	timeout := m.requestTimeout(req.PluginContext)
+	if timeout <= 0 {
+		return m.Client.QueryData(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := m.Client.QueryData(ctx, req)
+	return resp, timeoutError(ctx, timeout, err)
+}
+
+func (m *timeoutMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	timeout := m.requestTimeout(req.PluginContext)
+	if timeout <= 0 {
+		return m.Client.CallResource(ctx, req, sender)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return timeoutError(ctx, timeout, m.Client.CallResource(ctx, req, sender))
+}
+
+func (m *timeoutMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	timeout := m.requestTimeout(req.PluginContext)
+	if timeout <= 0 {
+		return m.Client.CheckHealth(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := m.Client.CheckHealth(ctx, req)
+	return resp, timeoutError(ctx, timeout, err)
+}
+
+// requestTimeout returns the plugin request timeout of the data source of a
+// request if it has one, the configured timeout otherwise.
+func (m *timeoutMiddleware) requestTimeout(pCtx backend.PluginContext) time.Duration {
+	ds := pCtx.DataSourceInstanceSettings
+	if ds == nil || len(ds.JSONData) == 0 {
+		return m.timeout
+	}
+
+	var jsonData struct {
+		Timeout json.RawMessage `json:"pluginRequestTimeout"`
+	}
+	if err := json.Unmarshal(ds.JSONData, &jsonData); err != nil || len(jsonData.Timeout) == 0 {
+		return m.timeout
+	}
+	// The timeout is a number of seconds, some data sources store it as a
+	// string.
+	var seconds float64
+	if err := json.Unmarshal(jsonData.Timeout, &seconds); err != nil {
+		var str string
+		if err := json.Unmarshal(jsonData.Timeout, &str); err != nil {
+			return m.timeout
+		}
+		if seconds, err = strconv.ParseFloat(str, 64); err != nil {
+			return m.timeout
+		}
+	}
+	if seconds <= 0 {
+		return m.timeout
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// timeoutError returns a timeout error if err is caused by the request
+// exceeding the timeout.
+func timeoutError(ctx context.Context, timeout time.Duration, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return plugins.ErrPluginRequestTimeout.Errorf("plugin request timed out after %s: %w", timeout, err)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	var deadline time.Duration
+	next := &fakes.FakePluginClient{
+		QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			d, ok := ctx.Deadline()
+			deadline = 0
+			if ok {
+				deadline = time.Until(d)
+			}
+			if req.Queries != nil {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return backend.NewQueryDataResponse(), nil
+		},
+	}
+	create := func(timeout time.Duration) plugins.Client {
+		return TimeoutMiddleware().CreateClientMiddleware(MiddlewareOptions{
+			Settings: setting.PluginClientSettings{Timeout: timeout},
+		}, next)
+	}
+
+	t.Run("No timeout by default", func(t *testing.T) {
+		_, err := create(0).QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.Zero(t, deadline)
+	})
+
+	t.Run("Configured timeout is applied", func(t *testing.T) {
+		_, err := create(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.InDelta(t, time.Minute, deadline, float64(time.Second))
+	})
+
+	t.Run("Data source timeout overrides configured timeout", func(t *testing.T) {
+		for _, jsonData := range []string{`{"pluginRequestTimeout": 30}`, `{"pluginRequestTimeout": "30"}`} {
+			_, err := create(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: backend.PluginContext{
+					DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)},
+				},
+			})
+			require.NoError(t, err)
+			require.InDelta(t, 30*time.Second, deadline, float64(time.Second))
+		}
+	})
+
+	t.Run("Invalid data source timeout is ignored", func(t *testing.T) {
+		_, err := create(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"pluginRequestTimeout": "never"}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.InDelta(t, time.Minute, deadline, float64(time.Second))
+	})
+
+	t.Run("HTTP timeout of data source is ignored", func(t *testing.T) {
+		_, err := create(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"timeout": 5}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.InDelta(t, time.Minute, deadline, float64(time.Second))
+	})
+
+	t.Run("Request exceeding the timeout fails with timeout error", func(t *testing.T) {
+		_, err := create(time.Millisecond).QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{},
+		})
+		require.ErrorIs(t, err, plugins.ErrPluginRequestTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
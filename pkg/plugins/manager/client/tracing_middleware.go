@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// TracingMiddlewareName is the middleware name used by TracingMiddleware.
+const TracingMiddlewareName = "tracing"
+
+// TracingMiddleware creates spans for requests to plugins and propagates
+// them to plugins with request headers.
+func TracingMiddleware(tracer tracing.Tracer) Middleware {
+	return NamedMiddlewareFunc(TracingMiddlewareName, func(opts MiddlewareOptions, next plugins.Client) plugins.Client {
+		return &tracingMiddleware{
+			Client: next,
+			tracer: tracer,
+		}
+	})
+}
+
+type tracingMiddleware struct {
+	plugins.Client
+	tracer tracing.Tracer
+}
+
+func (m *tracingMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	ctx, span := m.start(ctx, "queryData", req.PluginContext)
+	defer span.End()
+	span.SetAttributes("query_count", len(req.Queries), attribute.Int("query_count", len(req.Queries)))
+
+	if req.Headers == nil {
+		req.Headers = map[string]string{}
+	}
+	for k, v := range m.inject(ctx, span) {
+		req.Headers[k] = v[0]
+	}
+
+	resp, err := m.Client.QueryData(ctx, req)
+	endSpan(span, err)
+	return resp, err
+}
+
+func (m *tracingMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	ctx, span := m.start(ctx, "callResource", req.PluginContext)
+	defer span.End()
+	span.SetAttributes("resource_path", req.Path, attribute.String("resource_path", req.Path))
+
+	if req.Headers == nil {
+		req.Headers = map[string][]string{}
+	}
+	for k, v := range m.inject(ctx, span) {
+		req.Headers[k] = v
+	}
+
+	err := m.Client.CallResource(ctx, req, sender)
+	endSpan(span, err)
+	return err
+}
+
+func (m *tracingMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	ctx, span := m.start(ctx, "checkHealth", req.PluginContext)
+	defer span.End()
+
+	if req.Headers == nil {
+		req.Headers = map[string]string{}
+	}
+	for k, v := range m.inject(ctx, span) {
+		req.Headers[k] = v[0]
+	}
+
+	resp, err := m.Client.CheckHealth(ctx, req)
+	endSpan(span, err)
+	return resp, err
+}
+
+func (m *tracingMiddleware) start(ctx context.Context, endpoint string, pCtx backend.PluginContext) (context.Context, tracing.Span) {
+	ctx, span := m.tracer.Start(ctx, "plugin."+endpoint, trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes("plugin_id", pCtx.PluginID, attribute.String("plugin_id", pCtx.PluginID))
+	span.SetAttributes("org_id", pCtx.OrgID, attribute.Int64("org_id", pCtx.OrgID))
+	if ds := pCtx.DataSourceInstanceSettings; ds != nil {
+		span.SetAttributes("datasource_uid", ds.UID, attribute.String("datasource_uid", ds.UID))
+		span.SetAttributes("datasource_name", ds.Name, attribute.String("datasource_name", ds.Name))
+	}
+	return ctx, span
+}
+
+// inject returns the headers identifying the span.
+func (m *tracingMiddleware) inject(ctx context.Context, span tracing.Span) http.Header {
+	headers := http.Header{}
+	m.tracer.Inject(ctx, headers, span)
+	return headers
+}
+
+func endSpan(span tracing.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/manager/process"
 	"github.com/grafana/grafana/pkg/plugins/repo"
 	"github.com/grafana/grafana/pkg/plugins/storage"
 )
@@ -35,8 +36,10 @@ func (i *FakePluginInstaller) Remove(ctx context.Context, pluginID string) error
 }
 
 type FakeLoader struct {
-	LoadFunc   func(_ context.Context, _ plugins.Class, paths []string) ([]*plugins.Plugin, error)
-	UnloadFunc func(_ context.Context, _ string) error
+	LoadFunc       func(_ context.Context, _ plugins.Class, paths []string) ([]*plugins.Plugin, error)
+	UnloadFunc     func(_ context.Context, _ string) error
+	UnregisterFunc func(_ context.Context, _ string) error
+	ReplaceFunc    func(_ context.Context, _ plugins.Class, pluginID string, paths []string) ([]*plugins.Plugin, error)
 }
 
 func (l *FakeLoader) Load(ctx context.Context, class plugins.Class, paths []string) ([]*plugins.Plugin, error) {
@@ -53,6 +56,20 @@ func (l *FakeLoader) Unload(ctx context.Context, pluginID string) error {
 	return nil
 }
 
+func (l *FakeLoader) Unregister(ctx context.Context, pluginID string) error {
+	if l.UnregisterFunc != nil {
+		return l.UnregisterFunc(ctx, pluginID)
+	}
+	return nil
+}
+
+func (l *FakeLoader) Replace(ctx context.Context, class plugins.Class, pluginID string, paths []string) ([]*plugins.Plugin, error) {
+	if l.ReplaceFunc != nil {
+		return l.ReplaceFunc(ctx, class, pluginID, paths)
+	}
+	return nil, nil
+}
+
 type FakePluginClient struct {
 	ID      string
 	Managed bool
@@ -296,6 +313,10 @@ func (m *FakeProcessManager) Stop(ctx context.Context, pluginID string) error {
 	return nil
 }
 
+func (m *FakeProcessManager) Status(_ string) (process.Status, bool) {
+	return process.Status{}, false
+}
+
 type FakeBackendProcessProvider struct {
 	Requested map[string]int
 	Invoked   map[string]int
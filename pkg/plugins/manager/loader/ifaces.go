@@ -12,4 +12,10 @@ type Service interface {
 	Load(ctx context.Context, class plugins.Class, paths []string) ([]*plugins.Plugin, error)
 	// Unload will unload a specified plugin from the file system.
 	Unload(ctx context.Context, pluginID string) error
+	// Unregister will stop a specified plugin and remove it from the registry
+	// without removing it from the file system, so it can be loaded again.
+	Unregister(ctx context.Context, pluginID string) error
+	// Replace will load the plugins in the provided file system paths instead
+	// of a specified plugin, which keeps running if they can't be loaded.
+	Replace(ctx context.Context, class plugins.Class, pluginID string, paths []string) ([]*plugins.Plugin, error)
 }
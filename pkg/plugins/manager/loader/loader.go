@@ -51,8 +51,8 @@ type Loader struct {
 }
 
 func ProvideService(cfg *config.Cfg, license models.Licensing, authorizer plugins.PluginLoaderAuthorizer,
-	pluginRegistry registry.Service, backendProvider plugins.BackendFactoryProvider) *Loader {
-	return New(cfg, license, authorizer, pluginRegistry, backendProvider, process.NewManager(pluginRegistry),
+	pluginRegistry registry.Service, backendProvider plugins.BackendFactoryProvider, processManager process.Service) *Loader {
+	return New(cfg, license, authorizer, pluginRegistry, backendProvider, processManager,
 		storage.FileSystem(logger.NewLogger("loader.fs"), cfg.PluginsPath))
 }
 
@@ -81,6 +81,87 @@ func (l *Loader) Load(ctx context.Context, class plugins.Class, paths []string)
 }
 
 func (l *Loader) loadPlugins(ctx context.Context, class plugins.Class, pluginJSONPaths []string) ([]*plugins.Plugin, error) {
+	verifiedPlugins, err := l.verifyPlugins(ctx, class, pluginJSONPaths, l.registeredPluginIDs(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range verifiedPlugins {
+		if err := l.load(ctx, p); err != nil {
+			l.log.Error("Could not start plugin", "pluginId", p.ID, "err", err)
+		}
+	}
+
+	return verifiedPlugins, nil
+}
+
+// Replace loads the plugins in paths instead of the registered plugin pluginID
+// and its children. They are only unregistered once the plugins in paths are
+// verified, so they keep running when pluginID can't be loaded from paths.
+func (l *Loader) Replace(ctx context.Context, class plugins.Class, pluginID string, paths []string) ([]*plugins.Plugin, error) {
+	replaced, exists := l.pluginRegistry.Plugin(ctx, pluginID)
+	if !exists {
+		return nil, plugins.ErrPluginNotInstalled
+	}
+	if !replaced.IsExternalPlugin() {
+		return nil, plugins.ErrUnloadCorePlugin
+	}
+
+	pluginJSONPaths, err := l.pluginFinder.Find(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	registeredPlugins := l.registeredPluginIDs(ctx)
+	delete(registeredPlugins, replaced.ID)
+	for _, child := range replaced.Children {
+		if registered, exists := l.pluginRegistry.Plugin(ctx, child.ID); exists && registered == child {
+			delete(registeredPlugins, child.ID)
+		}
+	}
+
+	verifiedPlugins, err := l.verifyPlugins(ctx, class, pluginJSONPaths, registeredPlugins)
+	if err != nil {
+		return nil, err
+	}
+	verified := false
+	for _, p := range verifiedPlugins {
+		if p.ID == pluginID {
+			verified = true
+		}
+	}
+	if !verified {
+		return nil, plugins.ErrPluginNotLoaded
+	}
+
+	if err := l.unregister(ctx, replaced); err != nil {
+		return nil, err
+	}
+
+	var startErr error
+	for _, p := range verifiedPlugins {
+		if err := l.load(ctx, p); err != nil {
+			l.log.Error("Could not start plugin", "pluginId", p.ID, "err", err)
+			if startErr == nil {
+				startErr = fmt.Errorf("could not start plugin %s: %w", p.ID, err)
+			}
+		}
+	}
+
+	return verifiedPlugins, startErr
+}
+
+func (l *Loader) registeredPluginIDs(ctx context.Context) map[string]struct{} {
+	registeredPlugins := make(map[string]struct{})
+	for _, p := range l.pluginRegistry.Plugins(ctx) {
+		registeredPlugins[p.ID] = struct{}{}
+	}
+	return registeredPlugins
+}
+
+// verifyPlugins reads the plugins found at pluginJSONPaths, except for the
+// registered ones, and returns the initialized plugins with a valid signature.
+func (l *Loader) verifyPlugins(ctx context.Context, class plugins.Class, pluginJSONPaths []string, registeredPlugins map[string]struct{}) ([]*plugins.Plugin, error) {
 	var foundPlugins = foundPlugins{}
 
 	// load plugin.json files and map directory to JSON data
@@ -104,12 +185,6 @@ func (l *Loader) loadPlugins(ctx context.Context, class plugins.Class, pluginJSO
 		foundPlugins[filepath.Dir(pluginJSONAbsPath)] = plugin
 	}
 
-	// get all registered plugins
-	registeredPlugins := make(map[string]struct{})
-	for _, p := range l.pluginRegistry.Plugins(ctx) {
-		registeredPlugins[p.ID] = struct{}{}
-	}
-
 	foundPlugins.stripDuplicates(registeredPlugins, l.log)
 
 	// calculate initial signature state
@@ -197,12 +272,6 @@ func (l *Loader) loadPlugins(ctx context.Context, class plugins.Class, pluginJSO
 		metrics.SetPluginBuildInformation(p.ID, string(p.Type), p.Info.Version, string(p.Signature))
 	}
 
-	for _, p := range verifiedPlugins {
-		if err := l.load(ctx, p); err != nil {
-			l.log.Error("Could not start plugin", "pluginId", p.ID, "err", err)
-		}
-	}
-
 	return verifiedPlugins, nil
 }
 
@@ -222,6 +291,19 @@ func (l *Loader) Unload(ctx context.Context, pluginID string) error {
 	return nil
 }
 
+func (l *Loader) Unregister(ctx context.Context, pluginID string) error {
+	plugin, exists := l.pluginRegistry.Plugin(ctx, pluginID)
+	if !exists {
+		return plugins.ErrPluginNotInstalled
+	}
+
+	if !plugin.IsExternalPlugin() {
+		return plugins.ErrUnloadCorePlugin
+	}
+
+	return l.unregister(ctx, plugin)
+}
+
 func (l *Loader) load(ctx context.Context, p *plugins.Plugin) error {
 	if err := l.pluginRegistry.Add(ctx, p); err != nil {
 		return err
@@ -241,6 +323,28 @@ func (l *Loader) load(ctx context.Context, p *plugins.Plugin) error {
 }
 
 func (l *Loader) unload(ctx context.Context, p *plugins.Plugin) error {
+	if err := l.unregister(ctx, p); err != nil {
+		return err
+	}
+
+	if err := l.pluginStorage.Remove(ctx, p.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// unregister stops a plugin and its children and removes them from the
+// registry.
+func (l *Loader) unregister(ctx context.Context, p *plugins.Plugin) error {
+	for _, child := range p.Children {
+		// children which failed to load, e.g. because of their signature, aren't registered
+		if registered, exists := l.pluginRegistry.Plugin(ctx, child.ID); exists && registered == child {
+			if err := l.unregister(ctx, child); err != nil {
+				return err
+			}
+		}
+	}
+
 	l.log.Debug("Stopping plugin process", "pluginId", p.ID)
 
 	// TODO confirm the sequence of events is safe
@@ -252,10 +356,6 @@ func (l *Loader) unload(ctx context.Context, p *plugins.Plugin) error {
 		return err
 	}
 	l.log.Debug("Plugin unregistered", "pluginId", p.ID)
-
-	if err := l.pluginStorage.Remove(ctx, p.ID); err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -0,0 +1,15 @@
+//go:build linux && go1.20
+// +build linux,go1.20
+
+package process
+
+import "syscall"
+
+// cgroupSysProcAttr returns attributes which start a process in the cgroup
+// of the given directory.
+func cgroupSysProcAttr(cgroupFD int) *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		UseCgroupFD: true,
+		CgroupFD:    cgroupFD,
+	}
+}
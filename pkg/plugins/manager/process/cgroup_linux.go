@@ -0,0 +1,198 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/grafana/grafana/pkg/plugins/config"
+)
+
+const (
+	cgroupMountPoint = "/sys/fs/cgroup"
+	procSelfCgroup   = "/proc/self/cgroup"
+	// cgroupCPUPeriod is the period of cpu.max in microseconds.
+	cgroupCPUPeriod = 100000
+)
+
+// cgroupLimiter limits resources of plugin processes with cgroups v2. Each
+// plugin gets its own cgroup next to the cgroup of Grafana, which requires
+// the cgroup of Grafana to be delegated (e.g. Delegate=yes with systemd).
+// Since processes can only be in leaf cgroups once controllers are enabled
+// for children, all processes in the cgroup of Grafana are moved into a
+// "grafana" child cgroup first. Plugin processes without limits are started
+// there as well.
+type cgroupLimiter struct {
+	mountPoint     string
+	procSelfCgroup string
+	// cloneIntoCgroup is set if plugin processes can be started in their
+	// cgroup, otherwise they are moved into it after they started.
+	cloneIntoCgroup bool
+
+	once    sync.Once
+	base    string
+	initErr error
+}
+
+func newProcessLimiter() processLimiter {
+	return &cgroupLimiter{
+		mountPoint:      cgroupMountPoint,
+		procSelfCgroup:  procSelfCgroup,
+		cloneIntoCgroup: cloneIntoCgroupSupported(),
+	}
+}
+
+func (c *cgroupLimiter) init() error {
+	c.once.Do(func() {
+		c.base, c.initErr = c.setup()
+	})
+	return c.initErr
+}
+
+func (c *cgroupLimiter) prepare(pluginID string, limits config.ProcessLimits) (*syscall.SysProcAttr, func(), error) {
+	if err := c.init(); err != nil {
+		return nil, nil, err
+	}
+
+	dir := filepath.Join(c.base, "plugin-"+pluginID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create plugin cgroup: %w", err)
+	}
+	memoryMax := "max"
+	if limits.MaxMemoryMB > 0 {
+		memoryMax = strconv.FormatInt(int64(limits.MaxMemoryMB)*1024*1024, 10)
+	}
+	if err := writeCgroupFile(dir, "memory.max", memoryMax); err != nil {
+		return nil, nil, err
+	}
+	if limits.MaxMemoryMB > 0 {
+		// Don't let the plugin swap instead of being killed when it exceeds the
+		// limit. Not all systems have swap accounting.
+		_ = writeCgroupFile(dir, "memory.swap.max", "0")
+	}
+	cpuMax := "max"
+	if limits.MaxCPU > 0 {
+		cpuMax = strconv.Itoa(int(limits.MaxCPU * cgroupCPUPeriod))
+	}
+	if err := writeCgroupFile(dir, "cpu.max", fmt.Sprintf("%s %d", cpuMax, cgroupCPUPeriod)); err != nil {
+		return nil, nil, err
+	}
+
+	if !c.cloneIntoCgroup {
+		return nil, func() {}, nil
+	}
+	// nolint:gosec
+	// The directory is created above.
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open plugin cgroup: %w", err)
+	}
+	attr := cgroupSysProcAttr(int(f.Fd()))
+	if attr == nil {
+		_ = f.Close()
+		return nil, func() {}, nil
+	}
+	return attr, func() { _ = f.Close() }, nil
+}
+
+func (c *cgroupLimiter) apply(pluginID string, pid int) error {
+	if err := c.init(); err != nil {
+		return err
+	}
+	return writeCgroupFile(filepath.Join(c.base, "plugin-"+pluginID), "cgroup.procs", strconv.Itoa(pid))
+}
+
+func (c *cgroupLimiter) remove(pluginID string) {
+	if c.base == "" {
+		return
+	}
+	// Fails while processes are still in the cgroup, it's reused then.
+	_ = os.Remove(filepath.Join(c.base, "plugin-"+pluginID))
+}
+
+// setup moves all processes in the cgroup of Grafana into a leaf cgroup and
+// enables controllers for cgroups of plugins. It returns the cgroup plugin
+// cgroups are created in.
+func (c *cgroupLimiter) setup() (string, error) {
+	if _, err := os.Stat(filepath.Join(c.mountPoint, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroups v2 are not available")
+	}
+
+	// nolint:gosec
+	// The path is a constant outside of tests.
+	b, err := os.ReadFile(c.procSelfCgroup)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cgroup of Grafana: %w", err)
+	}
+	var ownPath string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		// cgroups v2 entry has the format 0::<path>.
+		if p := strings.TrimPrefix(scanner.Text(), "0::"); p != scanner.Text() {
+			ownPath = p
+			break
+		}
+	}
+	if ownPath == "" {
+		return "", errors.New("cgroup of Grafana not found")
+	}
+	base := filepath.Join(c.mountPoint, filepath.FromSlash(ownPath))
+
+	leaf := filepath.Join(base, "grafana")
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cgroup, make sure the cgroup of Grafana is delegated: %w", err)
+	}
+	// Besides Grafana, the cgroup can contain processes of plugins or other
+	// processes started together with Grafana.
+	// nolint:gosec
+	// The path is derived from the cgroup of Grafana.
+	procs, err := os.ReadFile(filepath.Join(base, "cgroup.procs"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read processes of the cgroup of Grafana: %w", err)
+	}
+	for _, pid := range strings.Fields(string(procs)) {
+		// Processes may exit in the meantime.
+		if err := writeCgroupFile(leaf, "cgroup.procs", pid); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return "", err
+		}
+	}
+	if err := writeCgroupFile(base, "cgroup.subtree_control", "+memory +cpu"); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// cloneIntoCgroupSupported returns true if the kernel can start processes in
+// a cgroup, which is supported since Linux 5.7.
+func cloneIntoCgroupSupported() bool {
+	var uts syscall.Utsname
+	if err := syscall.Uname(&uts); err != nil {
+		return false
+	}
+	var release strings.Builder
+	for _, b := range uts.Release {
+		if b == 0 {
+			break
+		}
+		release.WriteByte(byte(b))
+	}
+	var major, minor int
+	if _, err := fmt.Sscanf(release.String(), "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	return major > 5 || major == 5 && minor >= 7
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
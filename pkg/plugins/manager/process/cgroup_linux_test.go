@@ -0,0 +1,66 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins/config"
+)
+
+func TestCgroupLimiter(t *testing.T) {
+	t.Run("Fails without cgroups v2", func(t *testing.T) {
+		l := &cgroupLimiter{mountPoint: t.TempDir()}
+		require.Error(t, l.init())
+		_, _, err := l.prepare("test-datasource", config.ProcessLimits{MaxMemoryMB: 128})
+		require.Error(t, err)
+	})
+
+	t.Run("Creates cgroup for plugin", func(t *testing.T) {
+		mountPoint := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(mountPoint, "cgroup.controllers"), []byte("cpu memory"), 0600))
+		base := filepath.Join(mountPoint, "system.slice", "grafana.service")
+		require.NoError(t, os.MkdirAll(base, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(base, "cgroup.procs"), []byte("4321\n"+strconv.Itoa(os.Getpid())+"\n"), 0600))
+
+		procSelfCgroup := filepath.Join(t.TempDir(), "cgroup")
+		require.NoError(t, os.WriteFile(procSelfCgroup, []byte("0::/system.slice/grafana.service\n"), 0600))
+
+		l := &cgroupLimiter{mountPoint: mountPoint, procSelfCgroup: procSelfCgroup}
+		require.NoError(t, l.init())
+
+		// Every process of the cgroup is moved, the fake cgroup.procs file
+		// only keeps the last one.
+		requireCgroupFile(t, filepath.Join(base, "grafana"), "cgroup.procs", strconv.Itoa(os.Getpid()))
+		requireCgroupFile(t, base, "cgroup.subtree_control", "+memory +cpu")
+
+		attr, release, err := l.prepare("test-datasource", config.ProcessLimits{MaxMemoryMB: 128, MaxCPU: 0.5})
+		require.NoError(t, err)
+		require.Nil(t, attr)
+		release()
+
+		dir := filepath.Join(base, "plugin-test-datasource")
+		requireCgroupFile(t, dir, "memory.max", "134217728")
+		requireCgroupFile(t, dir, "memory.swap.max", "0")
+		requireCgroupFile(t, dir, "cpu.max", "50000 100000")
+
+		require.NoError(t, l.apply("test-datasource", 1234))
+		requireCgroupFile(t, dir, "cgroup.procs", "1234")
+
+		_, release, err = l.prepare("test-datasource", config.ProcessLimits{MaxCPU: 2})
+		require.NoError(t, err)
+		release()
+		requireCgroupFile(t, dir, "memory.max", "max")
+		requireCgroupFile(t, dir, "cpu.max", "200000 100000")
+	})
+}
+
+func requireCgroupFile(t *testing.T, dir, name, expected string) {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.Equal(t, expected, string(b))
+}
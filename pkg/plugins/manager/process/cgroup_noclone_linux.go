@@ -0,0 +1,12 @@
+//go:build linux && !go1.20
+// +build linux,!go1.20
+
+package process
+
+import "syscall"
+
+// cgroupSysProcAttr returns nil since starting processes in a cgroup requires
+// Go 1.20, processes are moved into the cgroup after they started then.
+func cgroupSysProcAttr(_ int) *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package process
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/grafana/grafana/pkg/plugins/config"
+)
+
+var errLimitsUnsupported = errors.New("plugin process limits are only supported on Linux")
+
+type unsupportedLimiter struct{}
+
+func newProcessLimiter() processLimiter {
+	return unsupportedLimiter{}
+}
+
+func (unsupportedLimiter) init() error {
+	return errLimitsUnsupported
+}
+
+func (unsupportedLimiter) prepare(_ string, _ config.ProcessLimits) (*syscall.SysProcAttr, func(), error) {
+	return nil, nil, errLimitsUnsupported
+}
+
+func (unsupportedLimiter) apply(_ string, _ int) error {
+	return errLimitsUnsupported
+}
+
+func (unsupportedLimiter) remove(_ string) {}
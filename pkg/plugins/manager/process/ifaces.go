@@ -1,10 +1,44 @@
 package process
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/config"
+)
 
 type Service interface {
 	// Start executes a backend plugin process.
 	Start(ctx context.Context, pluginID string) error
 	// Stop terminates a backend plugin process.
 	Stop(ctx context.Context, pluginID string) error
+	// Status returns the status of a backend plugin process.
+	Status(pluginID string) (Status, bool)
+}
+
+// State is the state of a backend plugin process.
+type State string
+
+const (
+	StateRunning    State = "running"
+	StateRestarting State = "restarting"
+	StateStopped    State = "stopped"
+)
+
+// Status describes a backend plugin process.
+type Status struct {
+	State State `json:"state"`
+	// Restarts is the number of times the process was restarted after it
+	// exited.
+	Restarts int `json:"restarts"`
+	// CrashLooping is set when the process keeps exiting after restarts, it's
+	// restarted with exponential backoff then.
+	CrashLooping  bool       `json:"crashLooping"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	ExitedAt      *time.Time `json:"exitedAt,omitempty"`
+	NextRestartAt *time.Time `json:"nextRestartAt,omitempty"`
+	// Limits are resource limits of the process, LimitsError is set if they
+	// couldn't be applied.
+	Limits      *config.ProcessLimits `json:"limits,omitempty"`
+	LimitsError string                `json:"limitsError,omitempty"`
 }
@@ -4,32 +4,105 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/config"
 	"github.com/grafana/grafana/pkg/plugins/manager/registry"
 )
 
 var _ Service = (*Manager)(nil)
 
+const (
+	// initialRestartBackoff is the delay before the second restart of a crashing
+	// plugin process, the first restart is immediate. The delay doubles with
+	// every following crash up to the configured maximum.
+	initialRestartBackoff = time.Second
+	// defaultMaxRestartBackoff is used when no maximum is configured.
+	defaultMaxRestartBackoff = 5 * time.Minute
+	// stablePeriod is how long a process has to run to reset the backoff.
+	stablePeriod = time.Minute
+	// crashLoopThreshold is the number of crashes in a row after which a plugin
+	// is reported as crash looping.
+	crashLoopThreshold = 3
+)
+
+var (
+	processRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_restarts_total",
+		Help:      "Number of restarts of backend plugin processes after they exited.",
+	}, []string{"plugin_id"})
+
+	processCrashLooping = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_crash_looping",
+		Help:      "Whether a backend plugin process keeps crashing after restarts.",
+	}, []string{"plugin_id"})
+)
+
+// processLimiter applies resource limits to plugin processes.
+type processLimiter interface {
+	// init prepares limiting plugin processes, it's called before any plugin
+	// process is started.
+	init() error
+	// prepare sets up the limits of a plugin. It returns attributes which
+	// start the plugin process with the limits applied, or nil if the process
+	// has to be passed to apply once it started. The returned function
+	// releases resources once the process started.
+	prepare(pluginID string, limits config.ProcessLimits) (*syscall.SysProcAttr, func(), error)
+	apply(pluginID string, pid int) error
+	remove(pluginID string)
+}
+
 type Manager struct {
 	pluginRegistry registry.Service
+	cfg            *config.Cfg
+	limiter        processLimiter
+
+	// checkInterval is how often exited plugin processes are looked for.
+	checkInterval     time.Duration
+	maxRestartBackoff time.Duration
 
 	mu  sync.Mutex
 	log log.Logger
+
+	statusMu sync.RWMutex
+	statuses map[string]*Status
 }
 
-func ProvideService(pluginRegistry registry.Service) *Manager {
-	return NewManager(pluginRegistry)
+func ProvideService(cfg *config.Cfg, pluginRegistry registry.Service) *Manager {
+	return NewManager(cfg, pluginRegistry)
 }
 
-func NewManager(pluginRegistry registry.Service) *Manager {
-	return &Manager{
-		pluginRegistry: pluginRegistry,
-		log:            log.New("plugin.process.manager"),
+func NewManager(cfg *config.Cfg, pluginRegistry registry.Service) *Manager {
+	maxRestartBackoff := cfg.ProcessMaxRestartBackoff
+	if maxRestartBackoff <= 0 {
+		maxRestartBackoff = defaultMaxRestartBackoff
+	}
+	m := &Manager{
+		pluginRegistry:    pluginRegistry,
+		cfg:               cfg,
+		limiter:           newProcessLimiter(),
+		checkInterval:     time.Second,
+		maxRestartBackoff: maxRestartBackoff,
+		log:               log.New("plugin.process.manager"),
+		statuses:          map[string]*Status{},
+	}
+	// Limits require all processes of Grafana to be in a leaf cgroup, which
+	// has to be set up before plugin processes are started.
+	if cfg.ProcessLimitsConfigured() {
+		if err := m.limiter.init(); err != nil {
+			m.log.Warn("Resource limits of plugin processes can't be applied", "error", err)
+		}
 	}
+	return m
 }
 
 func (m *Manager) Run(ctx context.Context) error {
@@ -51,7 +124,7 @@ func (m *Manager) Start(ctx context.Context, pluginID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if err := startPluginAndRestartKilledProcesses(ctx, p); err != nil {
+	if err := m.startPluginAndRestartKilledProcesses(ctx, p); err != nil {
 		return err
 	}
 
@@ -76,9 +149,26 @@ func (m *Manager) Stop(ctx context.Context, pluginID string) error {
 		return err
 	}
 
+	m.updateStatus(p.ID, func(s *Status) {
+		s.State = StateStopped
+		s.NextRestartAt = nil
+	})
+	m.limiter.remove(p.ID)
 	return nil
 }
 
+// Status returns the status of the process of a plugin started by the
+// manager.
+func (m *Manager) Status(pluginID string) (Status, bool) {
+	m.statusMu.RLock()
+	defer m.statusMu.RUnlock()
+	s, ok := m.statuses[pluginID]
+	if !ok {
+		return Status{}, false
+	}
+	return *s, true
+}
+
 // shutdown stops all backend plugin processes
 func (m *Manager) shutdown(ctx context.Context) {
 	var wg sync.WaitGroup
@@ -96,17 +186,27 @@ func (m *Manager) shutdown(ctx context.Context) {
 	wg.Wait()
 }
 
-func startPluginAndRestartKilledProcesses(ctx context.Context, p *plugins.Plugin) error {
-	if err := p.Start(ctx); err != nil {
+func (m *Manager) startPluginAndRestartKilledProcesses(ctx context.Context, p *plugins.Plugin) error {
+	if p.IsCorePlugin() {
+		return p.Start(ctx)
+	}
+
+	limitsErr, err := m.startProcess(ctx, p)
+	if err != nil {
 		return err
 	}
 
-	if p.IsCorePlugin() {
-		return nil
+	now := time.Now()
+	m.statusMu.Lock()
+	m.statuses[p.ID] = &Status{
+		State:     StateRunning,
+		StartedAt: &now,
 	}
+	m.statusMu.Unlock()
+	m.setLimitsStatus(p, limitsErr)
 
 	go func(ctx context.Context, p *plugins.Plugin) {
-		if err := restartKilledProcess(ctx, p); err != nil {
+		if err := m.restartKilledProcess(ctx, p); err != nil {
 			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
 		}
 	}(ctx, p)
@@ -114,8 +214,15 @@ func startPluginAndRestartKilledProcesses(ctx context.Context, p *plugins.Plugin
 	return nil
 }
 
-func restartKilledProcess(ctx context.Context, p *plugins.Plugin) error {
-	ticker := time.NewTicker(time.Second * 1)
+func (m *Manager) restartKilledProcess(ctx context.Context, p *plugins.Plugin) error {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	// crashes is the number of crashes since the process last ran for the
+	// stable period.
+	var crashes int
+	var nextRestart time.Time
+	defer processCrashLooping.DeleteLabelValues(p.ID)
 
 	for {
 		select {
@@ -130,16 +237,130 @@ func restartKilledProcess(ctx context.Context, p *plugins.Plugin) error {
 				return nil
 			}
 
+			now := time.Now()
 			if !p.Exited() {
+				if crashes > 0 {
+					if status, ok := m.Status(p.ID); ok && status.StartedAt != nil && now.Sub(*status.StartedAt) >= stablePeriod {
+						crashes = 0
+						processCrashLooping.WithLabelValues(p.ID).Set(0)
+						m.updateStatus(p.ID, func(s *Status) { s.CrashLooping = false })
+					}
+				}
+				continue
+			}
+
+			if nextRestart.IsZero() {
+				crashes++
+				nextRestart = now.Add(m.restartBackoff(crashes))
+				m.onExited(p, crashes, now, nextRestart)
+			}
+			if now.Before(nextRestart) {
 				continue
 			}
 
 			p.Logger().Debug("Restarting plugin")
-			if err := p.Start(ctx); err != nil {
+			limitsErr, err := m.startProcess(ctx, p)
+			if err != nil {
 				p.Logger().Error("Failed to restart plugin", "error", err)
+				crashes++
+				nextRestart = now.Add(m.restartBackoff(crashes))
+				m.onExited(p, crashes, now, nextRestart)
 				continue
 			}
+			nextRestart = time.Time{}
+			processRestarts.WithLabelValues(p.ID).Inc()
+			m.updateStatus(p.ID, func(s *Status) {
+				s.State = StateRunning
+				s.Restarts++
+				s.StartedAt = &now
+				s.NextRestartAt = nil
+			})
+			m.setLimitsStatus(p, limitsErr)
 			p.Logger().Debug("Plugin restarted")
 		}
 	}
 }
+
+// onExited records that the plugin process exited and when it's restarted.
+func (m *Manager) onExited(p *plugins.Plugin, crashes int, exitedAt, nextRestart time.Time) {
+	crashLooping := crashes >= crashLoopThreshold
+	if crashLooping {
+		processCrashLooping.WithLabelValues(p.ID).Set(1)
+		p.Logger().Warn("Plugin process keeps crashing, restarting with backoff", "crashes", crashes, "nextRestart", nextRestart)
+	}
+	m.updateStatus(p.ID, func(s *Status) {
+		s.State = StateRestarting
+		s.CrashLooping = crashLooping
+		s.ExitedAt = &exitedAt
+		s.NextRestartAt = &nextRestart
+	})
+}
+
+// restartBackoff returns the delay before restarting a process after the
+// given number of crashes in a row.
+func (m *Manager) restartBackoff(crashes int) time.Duration {
+	if crashes <= 1 {
+		return 0
+	}
+	backoff := initialRestartBackoff
+	for i := 2; i < crashes && backoff < m.maxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > m.maxRestartBackoff {
+		return m.maxRestartBackoff
+	}
+	return backoff
+}
+
+// startProcess starts the plugin process with configured resource limits.
+// Plugins run without limits when they can't be applied, limitsErr reports
+// why.
+func (m *Manager) startProcess(ctx context.Context, p *plugins.Plugin) (limitsErr error, err error) {
+	limits := m.cfg.PluginProcessLimits(p.ID)
+	if !limits.Enabled() {
+		return nil, p.Start(ctx)
+	}
+
+	attr, release, limitsErr := m.limiter.prepare(p.ID, limits)
+	if limitsErr == nil {
+		p.SetProcessAttr(attr)
+		defer p.SetProcessAttr(nil)
+		defer release()
+	}
+	if err := p.Start(ctx); err != nil {
+		return nil, err
+	}
+	if limitsErr == nil && attr == nil {
+		if pid, ok := p.ProcessID(); ok {
+			limitsErr = m.limiter.apply(p.ID, pid)
+		}
+	}
+	if limitsErr != nil {
+		p.Logger().Warn("Failed to apply resource limits to plugin process", "error", limitsErr)
+	}
+	return limitsErr, nil
+}
+
+// setLimitsStatus records the configured resource limits of the plugin process
+// and why they couldn't be applied.
+func (m *Manager) setLimitsStatus(p *plugins.Plugin, limitsErr error) {
+	limits := m.cfg.PluginProcessLimits(p.ID)
+	if !limits.Enabled() {
+		return
+	}
+	m.updateStatus(p.ID, func(s *Status) {
+		s.Limits = &limits
+		s.LimitsError = ""
+		if limitsErr != nil {
+			s.LimitsError = limitsErr.Error()
+		}
+	})
+}
+
+func (m *Manager) updateStatus(pluginID string, fn func(s *Status)) {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+	if s, ok := m.statuses[pluginID]; ok {
+		fn(s)
+	}
+}
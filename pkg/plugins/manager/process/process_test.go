@@ -2,18 +2,22 @@ package process
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/config"
 	"github.com/stretchr/testify/require"
 )
 
 func TestProcessManager_Start(t *testing.T) {
 	t.Run("Plugin not found in registry", func(t *testing.T) {
-		m := NewManager(newFakePluginRegistry(map[string]*plugins.Plugin{}))
+		m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{}))
 		err := m.Start(context.Background(), "non-existing-datasource")
 		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
 	})
@@ -62,7 +66,7 @@ func TestProcessManager_Start(t *testing.T) {
 					plugin.SignatureError = tc.signatureError
 				})
 
-				m := NewManager(newFakePluginRegistry(map[string]*plugins.Plugin{
+				m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{
 					p.ID: p,
 				}))
 
@@ -82,7 +86,7 @@ func TestProcessManager_Start(t *testing.T) {
 
 func TestProcessManager_Stop(t *testing.T) {
 	t.Run("Plugin not found in registry", func(t *testing.T) {
-		m := NewManager(newFakePluginRegistry(map[string]*plugins.Plugin{}))
+		m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{}))
 		err := m.Stop(context.Background(), "non-existing-datasource")
 		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
 	})
@@ -96,7 +100,7 @@ func TestProcessManager_Stop(t *testing.T) {
 			plugin.Backend = true
 		})
 
-		m := NewManager(newFakePluginRegistry(map[string]*plugins.Plugin{
+		m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{
 			pluginID: p,
 		}))
 		err := m.Stop(context.Background(), pluginID)
@@ -115,7 +119,7 @@ func TestProcessManager_ManagedBackendPluginLifecycle(t *testing.T) {
 		plugin.Backend = true
 	})
 
-	m := NewManager(newFakePluginRegistry(map[string]*plugins.Plugin{
+	m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{
 		p.ID: p,
 	}))
 
@@ -161,6 +165,148 @@ func TestProcessManager_ManagedBackendPluginLifecycle(t *testing.T) {
 	})
 }
 
+func TestProcessManager_RestartBackoff(t *testing.T) {
+	m := NewManager(&config.Cfg{ProcessMaxRestartBackoff: 5 * time.Second}, newFakePluginRegistry(map[string]*plugins.Plugin{}))
+
+	require.Equal(t, time.Duration(0), m.restartBackoff(1))
+	require.Equal(t, time.Second, m.restartBackoff(2))
+	require.Equal(t, 2*time.Second, m.restartBackoff(3))
+	require.Equal(t, 4*time.Second, m.restartBackoff(4))
+	require.Equal(t, 5*time.Second, m.restartBackoff(5))
+	require.Equal(t, 5*time.Second, m.restartBackoff(100))
+
+	t.Run("Default maximum is used if not configured", func(t *testing.T) {
+		m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{}))
+		require.Equal(t, defaultMaxRestartBackoff, m.restartBackoff(100))
+	})
+}
+
+func TestProcessManager_CrashLoop(t *testing.T) {
+	bp := newFakeBackendPlugin(true)
+	p := createPlugin(t, bp, func(plugin *plugins.Plugin) {
+		plugin.Backend = true
+	})
+
+	m := NewManager(&config.Cfg{}, newFakePluginRegistry(map[string]*plugins.Plugin{
+		p.ID: p,
+	}))
+	m.checkInterval = time.Millisecond
+	m.maxRestartBackoff = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := m.Start(ctx, p.ID)
+	require.NoError(t, err)
+
+	status, ok := m.Status(p.ID)
+	require.True(t, ok)
+	require.Equal(t, StateRunning, status.State)
+	require.False(t, status.CrashLooping)
+
+	bp.setStartErr(errors.New("plugin crashed"))
+	bp.kill()
+
+	require.Eventually(t, func() bool {
+		status, _ := m.Status(p.ID)
+		return status.CrashLooping
+	}, time.Second, time.Millisecond)
+
+	status, _ = m.Status(p.ID)
+	require.Equal(t, StateRestarting, status.State)
+	require.NotNil(t, status.ExitedAt)
+	require.NotNil(t, status.NextRestartAt)
+	require.Equal(t, 0, status.Restarts)
+
+	bp.setStartErr(nil)
+	require.Eventually(t, func() bool {
+		status, _ := m.Status(p.ID)
+		return status.State == StateRunning
+	}, time.Second, time.Millisecond)
+
+	status, _ = m.Status(p.ID)
+	require.Equal(t, 1, status.Restarts)
+	require.Nil(t, status.NextRestartAt)
+	require.False(t, p.Exited())
+
+	t.Run("Stopped plugin is reported as stopped", func(t *testing.T) {
+		err := m.Stop(ctx, p.ID)
+		require.NoError(t, err)
+
+		status, ok := m.Status(p.ID)
+		require.True(t, ok)
+		require.Equal(t, StateStopped, status.State)
+	})
+}
+
+func TestProcessManager_Limits(t *testing.T) {
+	t.Run("Plugin process is started with resource limits", func(t *testing.T) {
+		bp := newFakeBackendPlugin(true)
+		p := createPlugin(t, bp, func(plugin *plugins.Plugin) {
+			plugin.Backend = true
+		})
+		limiter := &fakeLimiter{attr: &syscall.SysProcAttr{}}
+		m := NewManager(&config.Cfg{ProcessLimits: config.ProcessLimits{MaxMemoryMB: 128}}, newFakePluginRegistry(map[string]*plugins.Plugin{
+			p.ID: p,
+		}))
+		m.limiter = limiter
+
+		err := m.Start(context.Background(), p.ID)
+		require.NoError(t, err)
+		require.Same(t, limiter.attr, bp.startAttr)
+		require.Nil(t, bp.attr)
+		require.True(t, limiter.released)
+
+		status, ok := m.Status(p.ID)
+		require.True(t, ok)
+		require.Equal(t, &config.ProcessLimits{MaxMemoryMB: 128}, status.Limits)
+		require.Empty(t, status.LimitsError)
+	})
+
+	t.Run("Plugin process runs without limits when they can't be applied", func(t *testing.T) {
+		bp := newFakeBackendPlugin(true)
+		p := createPlugin(t, bp, func(plugin *plugins.Plugin) {
+			plugin.Backend = true
+		})
+		m := NewManager(&config.Cfg{ProcessLimits: config.ProcessLimits{MaxCPU: 1}}, newFakePluginRegistry(map[string]*plugins.Plugin{
+			p.ID: p,
+		}))
+		m.limiter = &fakeLimiter{prepareErr: errors.New("cgroups v2 are not available")}
+
+		err := m.Start(context.Background(), p.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, bp.startCount)
+		require.Nil(t, bp.startAttr)
+
+		status, ok := m.Status(p.ID)
+		require.True(t, ok)
+		require.Equal(t, "cgroups v2 are not available", status.LimitsError)
+	})
+}
+
+type fakeLimiter struct {
+	attr       *syscall.SysProcAttr
+	prepareErr error
+	released   bool
+}
+
+func (l *fakeLimiter) init() error {
+	return nil
+}
+
+func (l *fakeLimiter) prepare(_ string, _ config.ProcessLimits) (*syscall.SysProcAttr, func(), error) {
+	if l.prepareErr != nil {
+		return nil, nil, l.prepareErr
+	}
+	return l.attr, func() { l.released = true }, nil
+}
+
+func (l *fakeLimiter) apply(_ string, _ int) error {
+	return nil
+}
+
+func (l *fakeLimiter) remove(_ string) {}
+
 type fakePluginRegistry struct {
 	store map[string]*plugins.Plugin
 }
@@ -202,6 +348,9 @@ type fakeBackendPlugin struct {
 	stopCount      int
 	decommissioned bool
 	running        bool
+	startErr       error
+	attr           *syscall.SysProcAttr
+	startAttr      *syscall.SysProcAttr
 
 	mutex sync.RWMutex
 	backendplugin.Plugin
@@ -216,11 +365,27 @@ func newFakeBackendPlugin(managed bool) *fakeBackendPlugin {
 func (p *fakeBackendPlugin) Start(_ context.Context) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
-	p.running = true
 	p.startCount++
+	p.startAttr = p.attr
+	if p.startErr != nil {
+		return p.startErr
+	}
+	p.running = true
 	return nil
 }
 
+func (p *fakeBackendPlugin) SetProcessAttr(attr *syscall.SysProcAttr) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.attr = attr
+}
+
+func (p *fakeBackendPlugin) setStartErr(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.startErr = err
+}
+
 func (p *fakeBackendPlugin) Stop(_ context.Context) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
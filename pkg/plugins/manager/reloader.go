@@ -0,0 +1,357 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/config"
+	"github.com/grafana/grafana/pkg/plugins/manager/loader"
+	"github.com/grafana/grafana/pkg/plugins/manager/loader/finder"
+	"github.com/grafana/grafana/pkg/plugins/manager/registry"
+	"github.com/grafana/grafana/pkg/plugins/manager/store"
+)
+
+var _ plugins.Reloader = (*PluginReloader)(nil)
+
+// PluginReloader loads, unloads and reloads External plugins. When hot reload
+// is enabled, it watches the plugin directories and reloads plugins whose
+// files changed.
+type PluginReloader struct {
+	cfg            *config.Cfg
+	pluginRegistry registry.Service
+	pluginLoader   loader.Service
+	errorResolver  plugins.ErrorResolver
+	pluginFinder   finder.Finder
+	log            log.Logger
+
+	mu sync.Mutex
+	// watched is the fingerprint of the files of each plugin directory when
+	// they were last applied, pending has changed fingerprints which are
+	// applied once they stop changing.
+	watched map[string]string
+	pending map[string]string
+}
+
+func ProvideReloader(cfg *config.Cfg, pluginRegistry registry.Service, pluginLoader loader.Service,
+	errorResolver plugins.ErrorResolver) *PluginReloader {
+	return NewReloader(cfg, pluginRegistry, pluginLoader, errorResolver)
+}
+
+func NewReloader(cfg *config.Cfg, pluginRegistry registry.Service, pluginLoader loader.Service,
+	errorResolver plugins.ErrorResolver) *PluginReloader {
+	return &PluginReloader{
+		cfg:            cfg,
+		pluginRegistry: pluginRegistry,
+		pluginLoader:   pluginLoader,
+		errorResolver:  errorResolver,
+		pluginFinder:   finder.New(),
+		log:            log.New("plugin.reloader"),
+		watched:        map[string]string{},
+		pending:        map[string]string{},
+	}
+}
+
+// IsDisabled disables watching plugin directories when hot reload isn't enabled.
+func (r *PluginReloader) IsDisabled() bool {
+	return !r.cfg.HotReload
+}
+
+// Run watches the plugin directories for changes.
+func (r *PluginReloader) Run(ctx context.Context) error {
+	r.watch()
+
+	ticker := time.NewTicker(r.cfg.HotReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.scan(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *PluginReloader) Load(ctx context.Context, pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, exists := r.pluginRegistry.Plugin(ctx, pluginID); exists {
+		return plugins.DuplicateError{
+			PluginID:          p.ID,
+			ExistingPluginDir: p.PluginDir,
+		}
+	}
+
+	for dir, id := range r.findPluginDirs() {
+		if id == pluginID {
+			return r.load(ctx, pluginID, dir)
+		}
+	}
+	return plugins.NotFoundError{PluginID: pluginID}
+}
+
+func (r *PluginReloader) Unload(ctx context.Context, pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pluginLoader.Unregister(ctx, pluginID)
+}
+
+func (r *PluginReloader) Reload(ctx context.Context, pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, exists := r.pluginRegistry.Plugin(ctx, pluginID)
+	if !exists {
+		return plugins.ErrPluginNotInstalled
+	}
+	if !p.IsExternalPlugin() {
+		return plugins.ErrUnloadCorePlugin
+	}
+
+	// plugins included in an app are loaded with it
+	root := p
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return r.replace(ctx, root.ID, pluginID, root.PluginDir)
+}
+
+// load loads the plugins in dir and checks that pluginID is one of them.
+func (r *PluginReloader) load(ctx context.Context, pluginID, dir string) error {
+	loaded, err := r.pluginLoader.Load(ctx, plugins.External, []string{dir})
+	if err != nil {
+		return err
+	}
+	return r.checkLoaded(pluginID, dir, loaded)
+}
+
+// replace loads the plugins in dir instead of the registered plugin
+// replacedID and checks that pluginID is one of them. The registered plugin
+// keeps running when replacedID can't be loaded from dir.
+func (r *PluginReloader) replace(ctx context.Context, replacedID, pluginID, dir string) error {
+	loaded, err := r.pluginLoader.Replace(ctx, plugins.External, replacedID, []string{dir})
+	if errors.Is(err, plugins.ErrPluginNotLoaded) {
+		return r.notLoadedError(replacedID)
+	}
+	if err != nil {
+		return err
+	}
+	return r.checkLoaded(pluginID, dir, loaded)
+}
+
+func (r *PluginReloader) checkLoaded(pluginID, dir string, loaded []*plugins.Plugin) error {
+	for _, p := range loaded {
+		if p.ID == pluginID {
+			r.log.Info("Plugin loaded", "pluginID", pluginID, "path", dir)
+			return nil
+		}
+	}
+	return r.notLoadedError(pluginID)
+}
+
+// notLoadedError returns why pluginID wasn't loaded.
+func (r *PluginReloader) notLoadedError(pluginID string) error {
+	for _, pluginErr := range r.errorResolver.PluginErrors() {
+		if pluginErr.PluginID == pluginID {
+			return fmt.Errorf("%w: %s", plugins.ErrPluginNotLoaded, pluginErr.ErrorCode)
+		}
+	}
+	return plugins.ErrPluginNotLoaded
+}
+
+// watch records the current state of the plugin directories, changes are
+// detected against it.
+func (r *PluginReloader) watch() {
+	dirs := r.findPluginDirs()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for dir := range dirs {
+		if fp, err := fingerprint(dir); err == nil {
+			r.watched[dir] = fp
+		}
+	}
+}
+
+// scan applies changes of plugin directories which didn't change since the
+// previous scan, so plugins aren't loaded while their files are copied.
+func (r *PluginReloader) scan(ctx context.Context) {
+	dirs := r.findPluginDirs()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fingerprints := make(map[string]string, len(dirs))
+	for dir := range dirs {
+		fp, err := fingerprint(dir)
+		if err != nil {
+			r.log.Warn("Failed to read plugin directory", "path", dir, "err", err)
+			// keep the plugin as it is until its directory can be read
+			if watched, exists := r.watched[dir]; exists {
+				fingerprints[dir] = watched
+			}
+			continue
+		}
+		fingerprints[dir] = fp
+	}
+
+	changed := map[string]bool{}
+	for dir := range r.watched {
+		if _, exists := fingerprints[dir]; !exists {
+			changed[dir] = true
+		}
+	}
+	for dir, fp := range fingerprints {
+		if r.watched[dir] != fp {
+			changed[dir] = true
+		}
+	}
+	for dir := range r.pending {
+		if !changed[dir] {
+			delete(r.pending, dir)
+		}
+	}
+
+	changedDirs := make([]string, 0, len(changed))
+	for dir := range changed {
+		changedDirs = append(changedDirs, dir)
+	}
+	sort.Strings(changedDirs)
+
+	for _, dir := range changedDirs {
+		fp := fingerprints[dir]
+		if pending, exists := r.pending[dir]; !exists || pending != fp {
+			r.pending[dir] = fp
+			continue
+		}
+		delete(r.pending, dir)
+
+		if fp == "" {
+			delete(r.watched, dir)
+		} else {
+			r.watched[dir] = fp
+		}
+		if err := r.apply(ctx, dir, dirs[dir]); err != nil {
+			r.log.Error("Failed to apply plugin change", "path", dir, "err", err)
+		}
+	}
+}
+
+// apply loads, reloads or unloads the plugin in dir after its files changed.
+func (r *PluginReloader) apply(ctx context.Context, dir, pluginID string) error {
+	if pluginID == "" {
+		for _, p := range r.pluginRegistry.Plugins(ctx) {
+			if p.PluginDir == dir && p.IsExternalPlugin() {
+				r.log.Info("Plugin directory removed, unloading plugin", "pluginID", p.ID, "path", dir)
+				return r.pluginLoader.Unregister(ctx, p.ID)
+			}
+		}
+		return nil
+	}
+
+	p, exists := r.pluginRegistry.Plugin(ctx, pluginID)
+	if !exists {
+		r.log.Info("Plugin added, loading plugin", "pluginID", pluginID, "path", dir)
+		return r.load(ctx, pluginID, dir)
+	}
+	if p.PluginDir != dir || !p.IsExternalPlugin() {
+		r.log.Warn("Skipping plugin as it's already loaded from another directory", "pluginID", pluginID, "path", dir)
+		return nil
+	}
+
+	r.log.Info("Plugin changed, reloading plugin", "pluginID", pluginID, "path", dir)
+	return r.replace(ctx, pluginID, pluginID, dir)
+}
+
+// findPluginDirs returns the ID of the plugin in each directory of External
+// plugins. Directories of plugins included in other plugins are part of
+// their parent's directory.
+func (r *PluginReloader) findPluginDirs() map[string]string {
+	pluginJSONPaths, err := r.pluginFinder.Find(store.ExternalPluginPaths(r.cfg))
+	if err != nil {
+		r.log.Warn("Failed to find plugins", "err", err)
+		return map[string]string{}
+	}
+
+	var dirs []string
+	for _, path := range pluginJSONPaths {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+	sort.Strings(dirs)
+
+	res := map[string]string{}
+	var parent string
+	for _, dir := range dirs {
+		if parent != "" && strings.HasPrefix(dir, parent+string(filepath.Separator)) {
+			continue
+		}
+		parent = dir
+
+		id, err := readPluginID(filepath.Join(dir, "plugin.json"))
+		if err != nil {
+			r.log.Warn("Skipping plugin as its plugin.json could not be read", "path", dir, "err", err)
+			continue
+		}
+		res[dir] = id
+	}
+	return res
+}
+
+func readPluginID(pluginJSONPath string) (string, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path is in a plugin directory.
+	b, err := os.ReadFile(pluginJSONPath)
+	if err != nil {
+		return "", err
+	}
+	var pluginJSON struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(b, &pluginJSON); err != nil {
+		return "", err
+	}
+	if pluginJSON.ID == "" {
+		return "", loader.ErrInvalidPluginJSON
+	}
+	return pluginJSON.ID, nil
+}
+
+// fingerprint returns a hash of the names, sizes and modification times of
+// the files in dir.
+func fingerprint(dir string) (string, error) {
+	h := fnv.New64a()
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if fi.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(h, "%s|%d|%d\n", rel, fi.Size(), fi.ModTime().UnixNano())
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum64()), nil
+}
@@ -0,0 +1,245 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/config"
+	"github.com/grafana/grafana/pkg/plugins/manager/fakes"
+	"github.com/grafana/grafana/pkg/plugins/manager/loader"
+	"github.com/grafana/grafana/pkg/plugins/manager/signature"
+)
+
+func TestPluginReloader(t *testing.T) {
+	t.Run("Load, unload and reload a plugin", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		copyDir(t, "testdata/unsigned-datasource/plugin", filepath.Join(pluginsDir, "test-datasource"))
+		r, reg, proc, fs := newTestReloader(t, pluginsDir)
+		ctx := context.Background()
+
+		err := r.Load(ctx, "test-datasource")
+		require.NoError(t, err)
+		p, exists := reg.Plugin(ctx, "test-datasource")
+		require.True(t, exists)
+		require.Equal(t, 1, proc.Started["test-datasource"])
+
+		err = r.Load(ctx, "test-datasource")
+		require.ErrorAs(t, err, &plugins.DuplicateError{})
+
+		err = r.Reload(ctx, "test-datasource")
+		require.NoError(t, err)
+		reloaded, exists := reg.Plugin(ctx, "test-datasource")
+		require.True(t, exists)
+		require.NotSame(t, p, reloaded)
+		require.Equal(t, 1, proc.Stopped["test-datasource"])
+		require.Equal(t, 2, proc.Started["test-datasource"])
+
+		err = r.Unload(ctx, "test-datasource")
+		require.NoError(t, err)
+		_, exists = reg.Plugin(ctx, "test-datasource")
+		require.False(t, exists)
+		require.Equal(t, 2, proc.Stopped["test-datasource"])
+		require.DirExists(t, filepath.Join(pluginsDir, "test-datasource"))
+		require.Empty(t, fs.Removed)
+
+		err = r.Unload(ctx, "test-datasource")
+		require.ErrorIs(t, err, plugins.ErrPluginNotInstalled)
+		err = r.Reload(ctx, "test-datasource")
+		require.ErrorIs(t, err, plugins.ErrPluginNotInstalled)
+	})
+
+	t.Run("Load a plugin which can't be found", func(t *testing.T) {
+		r, _, _, _ := newTestReloader(t, t.TempDir())
+
+		err := r.Load(context.Background(), "test-datasource")
+		require.ErrorAs(t, err, &plugins.NotFoundError{})
+	})
+
+	t.Run("Reloading a child plugin reloads its parent", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		copyDir(t, "testdata/app-with-child/dist", filepath.Join(pluginsDir, "app-with-child"))
+		r, reg, proc, _ := newTestReloader(t, pluginsDir)
+		ctx := context.Background()
+
+		err := r.Load(ctx, "myorgid-simple-app")
+		require.NoError(t, err)
+
+		err = r.Reload(ctx, "myorgid-simple-panel")
+		require.NoError(t, err)
+		require.Equal(t, 1, proc.Stopped["myorgid-simple-app"])
+		require.Equal(t, 1, proc.Stopped["myorgid-simple-panel"])
+
+		child, exists := reg.Plugin(ctx, "myorgid-simple-panel")
+		require.True(t, exists)
+		parent, exists := reg.Plugin(ctx, "myorgid-simple-app")
+		require.True(t, exists)
+		require.Same(t, parent, child.Parent)
+	})
+
+	t.Run("Reloading a modified signed plugin fails", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		pluginDir := filepath.Join(pluginsDir, "app-with-child")
+		copyDir(t, "testdata/app-with-child/dist", pluginDir)
+		r, reg, proc, _ := newTestReloader(t, pluginsDir)
+		ctx := context.Background()
+
+		err := r.Load(ctx, "myorgid-simple-app")
+		require.NoError(t, err)
+		p, _ := reg.Plugin(ctx, "myorgid-simple-app")
+
+		b, err := os.ReadFile(filepath.Join(pluginDir, "plugin.json"))
+		require.NoError(t, err)
+		writeFile(t, filepath.Join(pluginDir, "plugin.json"), string(b)+"\n")
+		err = r.Reload(ctx, "myorgid-simple-app")
+		require.ErrorIs(t, err, plugins.ErrPluginNotLoaded)
+		require.Contains(t, err.Error(), "signatureModified")
+
+		// the loaded version keeps running
+		loaded, exists := reg.Plugin(ctx, "myorgid-simple-app")
+		require.True(t, exists)
+		require.Same(t, p, loaded)
+		require.Zero(t, proc.Stopped["myorgid-simple-app"])
+	})
+
+	t.Run("Reloading a plugin with an invalid plugin.json keeps the loaded version", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		pluginDir := filepath.Join(pluginsDir, "test-datasource")
+		copyDir(t, "testdata/unsigned-datasource/plugin", pluginDir)
+		r, reg, proc, _ := newTestReloader(t, pluginsDir)
+		ctx := context.Background()
+
+		err := r.Load(ctx, "test-datasource")
+		require.NoError(t, err)
+		p, _ := reg.Plugin(ctx, "test-datasource")
+
+		writeFile(t, filepath.Join(pluginDir, "plugin.json"), `{"id": "test-datasource"}`)
+		err = r.Reload(ctx, "test-datasource")
+		require.ErrorIs(t, err, plugins.ErrPluginNotLoaded)
+
+		loaded, exists := reg.Plugin(ctx, "test-datasource")
+		require.True(t, exists)
+		require.Same(t, p, loaded)
+		require.Zero(t, proc.Stopped["test-datasource"])
+	})
+
+	t.Run("Core plugins can't be reloaded", func(t *testing.T) {
+		r, reg, _, _ := newTestReloader(t, t.TempDir())
+		ctx := context.Background()
+		err := reg.Add(ctx, &plugins.Plugin{JSONData: plugins.JSONData{ID: "core-datasource"}, Class: plugins.Core})
+		require.NoError(t, err)
+
+		err = r.Reload(ctx, "core-datasource")
+		require.ErrorIs(t, err, plugins.ErrUnloadCorePlugin)
+	})
+}
+
+func TestPluginReloader_Scan(t *testing.T) {
+	pluginsDir := t.TempDir()
+	pluginDir := filepath.Join(pluginsDir, "test-datasource")
+	r, reg, proc, _ := newTestReloader(t, pluginsDir)
+	ctx := context.Background()
+	r.watch()
+
+	t.Run("Added plugin is loaded once its files stop changing", func(t *testing.T) {
+		copyDir(t, "testdata/unsigned-datasource/plugin", pluginDir)
+		r.scan(ctx)
+		_, exists := reg.Plugin(ctx, "test-datasource")
+		require.False(t, exists)
+
+		r.scan(ctx)
+		_, exists = reg.Plugin(ctx, "test-datasource")
+		require.True(t, exists)
+		require.Equal(t, 1, proc.Started["test-datasource"])
+	})
+
+	t.Run("Unchanged plugin isn't reloaded", func(t *testing.T) {
+		r.scan(ctx)
+		r.scan(ctx)
+		require.Equal(t, 1, proc.Started["test-datasource"])
+	})
+
+	t.Run("Changed plugin is reloaded", func(t *testing.T) {
+		p, _ := reg.Plugin(ctx, "test-datasource")
+		writeFile(t, filepath.Join(pluginDir, "module.js"), "changed")
+		r.scan(ctx)
+		writeFile(t, filepath.Join(pluginDir, "module.js"), "changed again")
+		r.scan(ctx)
+		require.Equal(t, 0, proc.Stopped["test-datasource"])
+
+		r.scan(ctx)
+		reloaded, exists := reg.Plugin(ctx, "test-datasource")
+		require.True(t, exists)
+		require.NotSame(t, p, reloaded)
+		require.Equal(t, 1, proc.Stopped["test-datasource"])
+		require.Equal(t, 2, proc.Started["test-datasource"])
+	})
+
+	t.Run("Removed plugin is unloaded", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(pluginDir))
+		r.scan(ctx)
+		r.scan(ctx)
+		_, exists := reg.Plugin(ctx, "test-datasource")
+		require.False(t, exists)
+		require.Equal(t, 2, proc.Stopped["test-datasource"])
+	})
+}
+
+type removeTrackingStorage struct {
+	*fakes.FakePluginStorage
+	Removed []string
+}
+
+func (s *removeTrackingStorage) Remove(ctx context.Context, pluginID string) error {
+	s.Removed = append(s.Removed, pluginID)
+	return s.FakePluginStorage.Remove(ctx, pluginID)
+}
+
+func newTestReloader(t *testing.T, pluginsDir string) (*PluginReloader, *fakes.FakePluginRegistry,
+	*fakes.FakeProcessManager, *removeTrackingStorage) {
+	t.Helper()
+
+	cfg := &config.Cfg{
+		PluginsPath:          pluginsDir,
+		PluginsAllowUnsigned: []string{"test-datasource"},
+	}
+	reg := fakes.NewFakePluginRegistry()
+	proc := fakes.NewFakeProcessManager()
+	fs := &removeTrackingStorage{FakePluginStorage: fakes.NewFakePluginStorage()}
+	l := loader.New(cfg, &fakes.FakeLicensingService{}, signature.NewUnsignedAuthorizer(cfg), reg,
+		fakes.NewFakeBackendProcessProvider(), proc, fs)
+
+	return NewReloader(cfg, reg, l, l), reg, proc, fs
+}
+
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+
+	err := filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0750)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), b, 0600)
+	})
+	require.NoError(t, err)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
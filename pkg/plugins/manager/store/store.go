@@ -124,10 +124,15 @@ func pluginSources(gCfg *setting.Cfg, cfg *config.Cfg) []plugins.PluginSource {
 	return []plugins.PluginSource{
 		{Class: plugins.Core, Paths: corePluginPaths(gCfg.StaticRootPath)},
 		{Class: plugins.Bundled, Paths: []string{gCfg.BundledPluginsPath}},
-		{Class: plugins.External, Paths: append([]string{cfg.PluginsPath}, pluginSettingPaths(cfg.PluginSettings)...)},
+		{Class: plugins.External, Paths: ExternalPluginPaths(cfg)},
 	}
 }
 
+// ExternalPluginPaths provides the paths External plugins are loaded from
+func ExternalPluginPaths(cfg *config.Cfg) []string {
+	return append([]string{cfg.PluginsPath}, pluginSettingPaths(cfg.PluginSettings)...)
+}
+
 // corePluginPaths provides a list of the Core plugin paths which need to be scanned on init()
 func corePluginPaths(staticRootPath string) []string {
 	datasourcePaths := filepath.Join(staticRootPath, "app/plugins/datasource")
@@ -15,6 +15,8 @@ var (
 	ErrInstallCorePlugin   = errors.New("cannot install a Core plugin")
 	ErrUninstallCorePlugin = errors.New("cannot uninstall a Core plugin")
 	ErrPluginNotInstalled  = errors.New("plugin is not installed")
+	ErrUnloadCorePlugin    = errors.New("cannot unload a Core plugin")
+	ErrPluginNotLoaded     = errors.New("plugin could not be loaded")
 )
 
 type NotFoundError struct {
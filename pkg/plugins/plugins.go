@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"syscall"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -248,6 +249,23 @@ func (p *Plugin) Exited() bool {
 	return false
 }
 
+// ProcessID returns the ID of the plugin process if the plugin runs in a
+// separate process.
+func (p *Plugin) ProcessID() (int, bool) {
+	if c, ok := p.client.(backendplugin.ProcessIDProvider); ok {
+		return c.ProcessID()
+	}
+	return 0, false
+}
+
+// SetProcessAttr sets OS specific attributes the plugin process is started
+// with if the plugin runs in a separate process.
+func (p *Plugin) SetProcessAttr(attr *syscall.SysProcAttr) {
+	if c, ok := p.client.(backendplugin.ProcessAttrSetter); ok {
+		c.SetProcessAttr(attr)
+	}
+}
+
 func (p *Plugin) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	pluginClient, ok := p.Client()
 	if !ok {
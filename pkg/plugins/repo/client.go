@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"net"
 	"net/http"
@@ -69,7 +70,6 @@ func (c *Client) download(_ context.Context, pluginZipURL, checksum string, comp
 func (c *Client) downloadFile(tmpFile *os.File, pluginURL, checksum string, compatOpts CompatOpts) (err error) {
 	// Try handling URL as a local file path first
 	if _, err := os.Stat(pluginURL); err == nil {
-		// TODO re-verify
 		// We can ignore this gosec G304 warning since `pluginURL` stems from command line flag "pluginUrl". If the
 		// user shouldn't be able to read the file, it should be handled through filesystem permissions.
 		// nolint:gosec
@@ -82,11 +82,12 @@ func (c *Client) downloadFile(tmpFile *os.File, pluginURL, checksum string, comp
 				c.log.Warn("Failed to close file", "err", err)
 			}
 		}()
-		_, err = io.Copy(tmpFile, f)
+		h := sha256.New()
+		_, err = io.Copy(tmpFile, io.TeeReader(f, h))
 		if err != nil {
 			return fmt.Errorf("%v: %w", "Failed to copy plugin archive", err)
 		}
-		return nil
+		return verifyChecksum(h, checksum)
 	}
 
 	c.retryCount = 0
@@ -142,6 +143,10 @@ func (c *Client) downloadFile(tmpFile *os.File, pluginURL, checksum string, comp
 	if err := w.Flush(); err != nil {
 		return fmt.Errorf("failed to write to %q: %w", tmpFile.Name(), err)
 	}
+	return verifyChecksum(h, checksum)
+}
+
+func verifyChecksum(h hash.Hash, checksum string) error {
 	if len(checksum) > 0 && checksum != fmt.Sprintf("%x", h.Sum(nil)) {
 		return fmt.Errorf("expected SHA256 checksum does not match the downloaded archive - please contact security@grafana.com")
 	}
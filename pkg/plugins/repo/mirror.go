@@ -0,0 +1,319 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// MirrorIndexFile is the name of the index file of a static plugin repository
+// mirror. The index has the same format as the plugin list returned by the
+// grafana.com API, archives are stored next to it as
+// <plugin id>/<version>/<os-arch or any>.zip.
+const MirrorIndexFile = "index.json"
+
+// anyArch is the architecture key of archives supported on all systems.
+const anyArch = "any"
+
+// IsMirrorIndex returns true if the repository URL points to the index file of
+// a static mirror rather than to the grafana.com API. Both local paths and
+// URLs are supported.
+func IsMirrorIndex(repoURL string) bool {
+	if u, err := url.Parse(repoURL); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		return path.Base(u.Path) == MirrorIndexFile
+	}
+	// Check both separators so that Windows paths are recognized everywhere.
+	return repoURL[strings.LastIndexAny(repoURL, `/\`)+1:] == MirrorIndexFile
+}
+
+// mirrorLocalPath returns the local path of a mirror index given either as a
+// file path or a file:// URL.
+func mirrorLocalPath(repoURL string) (string, bool) {
+	u, err := url.Parse(repoURL)
+	if err != nil || len(u.Scheme) <= 1 {
+		// Not a URL or a Windows drive letter.
+		return repoURL, true
+	}
+	if u.Scheme == "file" {
+		return filepath.FromSlash(u.Path), true
+	}
+	return "", false
+}
+
+// mirrorIndex reads the index of a static mirror.
+func (m *Manager) mirrorIndex(compatOpts CompatOpts) (PluginRepo, error) {
+	var body []byte
+	if p, ok := mirrorLocalPath(m.baseURL); ok {
+		// nolint:gosec
+		// The path stems from the configuration of the plugin repository.
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return PluginRepo{}, fmt.Errorf("failed to read plugin repository index: %w", err)
+		}
+		body = b
+	} else {
+		u, err := url.Parse(m.baseURL)
+		if err != nil {
+			return PluginRepo{}, err
+		}
+		body, err = m.client.sendReq(u, compatOpts)
+		if err != nil {
+			return PluginRepo{}, err
+		}
+	}
+
+	var index PluginRepo
+	if err := json.Unmarshal(body, &index); err != nil {
+		return PluginRepo{}, fmt.Errorf("failed to parse plugin repository index: %w", err)
+	}
+	return index, nil
+}
+
+func (m *Manager) mirrorPluginMetadata(pluginID string, compatOpts CompatOpts) (Plugin, error) {
+	m.log.Debugf("Fetching metadata for plugin \"%s\" from mirror %s", pluginID, m.baseURL)
+
+	index, err := m.mirrorIndex(compatOpts)
+	if err != nil {
+		return Plugin{}, err
+	}
+	for _, p := range index.Plugins {
+		if p.ID == pluginID {
+			return p, nil
+		}
+	}
+	return Plugin{}, Response4xxError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("plugin %s not found in the plugin repository", pluginID),
+		SystemInfo: compatOpts.String(),
+	}
+}
+
+// mirrorArchiveURL returns the location of a plugin archive in the mirror.
+func (m *Manager) mirrorArchiveURL(pluginID, version, arch string) (string, error) {
+	rel := path.Join(pluginID, version, arch+".zip")
+	if p, ok := mirrorLocalPath(m.baseURL); ok {
+		return filepath.Join(filepath.Dir(p), filepath.FromSlash(rel)), nil
+	}
+	u, err := url.Parse(m.baseURL)
+	if err != nil {
+		return "", err
+	}
+	return u.ResolveReference(&url.URL{Path: rel}).String(), nil
+}
+
+// MirrorPlugin is a plugin to copy into a mirror. The latest version is copied
+// when Version is empty.
+type MirrorPlugin struct {
+	ID      string
+	Version string
+}
+
+// BuildMirror copies plugin archives from the repository into a static mirror
+// in dir, creating or updating its index. Archives are copied for all systems
+// the plugin version supports, or only for the given list of os-arch pairs
+// (e.g. linux-amd64) if not empty.
+func (m *Manager) BuildMirror(ctx context.Context, dir string, plugins []MirrorPlugin, arches []string, compatOpts CompatOpts) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+
+	indexPath := filepath.Join(dir, MirrorIndexFile)
+	var index PluginRepo
+	// nolint:gosec
+	// The path stems from command line arguments.
+	if b, err := os.ReadFile(indexPath); err == nil {
+		if err := json.Unmarshal(b, &index); err != nil {
+			return fmt.Errorf("failed to parse existing mirror index: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for _, mp := range plugins {
+		if err := validMirrorPathElement(mp.ID); err != nil {
+			return err
+		}
+		plugin, err := m.pluginMetadata(mp.ID, compatOpts)
+		if err != nil {
+			return err
+		}
+		ver, err := mirrorVersion(&plugin, mp.Version, compatOpts)
+		if err != nil {
+			return err
+		}
+		if err := validMirrorPathElement(ver.Version); err != nil {
+			return err
+		}
+
+		archMetas := map[string]ArchMeta{}
+		for _, arch := range mirrorArches(ver, arches) {
+			m.log.Infof("Copying %s v%s (%s)...", plugin.ID, ver.Version, arch)
+			checksum, err := m.mirrorArchive(ctx, dir, plugin.ID, ver, arch, compatOpts)
+			if err != nil {
+				return fmt.Errorf("failed to copy %s v%s (%s): %w", plugin.ID, ver.Version, arch, err)
+			}
+			archMetas[arch] = ArchMeta{SHA256: checksum}
+		}
+		if len(archMetas) == 0 {
+			return ErrVersionUnsupported{
+				PluginID:         plugin.ID,
+				RequestedVersion: ver.Version,
+				SystemInfo:       strings.Join(arches, ", "),
+			}
+		}
+
+		addMirrorVersion(&index, plugin, Version{
+			Commit:  ver.Commit,
+			URL:     ver.URL,
+			Version: ver.Version,
+			Arch:    archMetas,
+		})
+	}
+
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0640); err != nil {
+		return fmt.Errorf("failed to write mirror index: %w", err)
+	}
+	return os.Rename(tmpPath, indexPath)
+}
+
+// mirrorArchive downloads the archive of a plugin version for the given
+// architecture into the mirror and returns its checksum.
+func (m *Manager) mirrorArchive(ctx context.Context, dir, pluginID string, ver *Version, arch string, compatOpts CompatOpts) (string, error) {
+	opts := compatOpts
+	if arch != anyArch {
+		parts := strings.SplitN(arch, "-", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("invalid architecture %q", arch)
+		}
+		opts.OS, opts.Arch = parts[0], parts[1]
+	}
+	dlOpts, err := m.downloadOptions(pluginID, ver, opts)
+	if err != nil {
+		return "", err
+	}
+
+	archivePath := filepath.Join(dir, pluginID, ver.Version, arch+".zip")
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0750); err != nil {
+		return "", err
+	}
+	// nolint:gosec
+	// The path is built from validated plugin ID and version.
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			m.log.Warn("Failed to close file", "err", err)
+		}
+	}()
+	if err := m.client.downloadFile(f, dlOpts.PluginZipURL, dlOpts.Checksum, opts); err != nil {
+		return "", err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// mirrorVersion selects the version to copy into a mirror. Unlike
+// selectVersion it doesn't require the version to support the current system.
+func mirrorVersion(plugin *Plugin, version string, compatOpts CompatOpts) (*Version, error) {
+	version = normalizeVersion(version)
+	for _, v := range plugin.Versions {
+		if version == "" || v.Version == version {
+			ver := v
+			return &ver, nil
+		}
+	}
+	return nil, ErrVersionNotFound{
+		PluginID:         plugin.ID,
+		RequestedVersion: version,
+		SystemInfo:       compatOpts.String(),
+	}
+}
+
+// mirrorArches returns architectures of a version to copy into a mirror.
+func mirrorArches(ver *Version, arches []string) []string {
+	if len(ver.Arch) == 0 {
+		return []string{anyArch}
+	}
+	var result []string
+	for arch := range ver.Arch {
+		if arch == anyArch || len(arches) == 0 || containsString(arches, arch) {
+			result = append(result, arch)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// addMirrorVersion adds or replaces a plugin version in the mirror index,
+// keeping versions sorted from the newest.
+func addMirrorVersion(index *PluginRepo, plugin Plugin, ver Version) {
+	i := sort.Search(len(index.Plugins), func(i int) bool { return index.Plugins[i].ID >= plugin.ID })
+	if i == len(index.Plugins) || index.Plugins[i].ID != plugin.ID {
+		index.Plugins = append(index.Plugins, Plugin{})
+		copy(index.Plugins[i+1:], index.Plugins[i:])
+		index.Plugins[i] = Plugin{ID: plugin.ID, Category: plugin.Category}
+	}
+	p := &index.Plugins[i]
+
+	versions := []Version{ver}
+	for _, v := range p.Versions {
+		if v.Version != ver.Version {
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return newerVersion(versions[i].Version, versions[j].Version)
+	})
+	p.Versions = versions
+}
+
+func newerVersion(a, b string) bool {
+	va, errA := version.NewVersion(a)
+	vb, errB := version.NewVersion(b)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return va.GreaterThan(vb)
+}
+
+func validMirrorPathElement(s string) error {
+	if s == "" || s == "." || s == ".." || strings.ContainsAny(s, `/\`) {
+		return fmt.Errorf("invalid plugin ID or version %q", s)
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsMirrorIndex(t *testing.T) {
+	require.True(t, IsMirrorIndex("/opt/plugins/index.json"))
+	require.True(t, IsMirrorIndex("file:///opt/plugins/index.json"))
+	require.True(t, IsMirrorIndex("https://mirror.internal/plugins/index.json"))
+	require.True(t, IsMirrorIndex(`C:\plugins\index.json`))
+	require.False(t, IsMirrorIndex("https://grafana.com/api/plugins"))
+	require.False(t, IsMirrorIndex("/opt/plugins"))
+}
+
+func TestMirror(t *testing.T) {
+	archives := map[string][]byte{
+		"linux-amd64":  testPluginZip(t, "linux"),
+		"darwin-arm64": testPluginZip(t, "darwin"),
+		"any":          testPluginZip(t, "any"),
+	}
+	checksum := func(b []byte) string { return fmt.Sprintf("%x", sha256.Sum256(b)) }
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repo/test-panel", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(Plugin{
+			ID: "test-panel",
+			Versions: []Version{
+				{Version: "2.0.0", Arch: map[string]ArchMeta{
+					"linux-amd64":  {SHA256: checksum(archives["linux-amd64"])},
+					"darwin-arm64": {SHA256: checksum(archives["darwin-arm64"])},
+				}},
+				{Version: "1.0.0"},
+			},
+		}))
+	})
+	mux.HandleFunc("/test-panel/versions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/test-panel/versions/2.0.0/download":
+			_, _ = w.Write(archives[r.Header.Get("grafana-os")+"-"+r.Header.Get("grafana-arch")])
+		case "/test-panel/versions/1.0.0/download":
+			_, _ = w.Write(archives["any"])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	source := New(false, server.URL, &fakeLogger{})
+	compatOpts := NewCompatOpts("9.1.0", "linux", "amd64")
+	err := source.BuildMirror(context.Background(), dir, []MirrorPlugin{{ID: "test-panel"}}, []string{"linux-amd64"}, compatOpts)
+	require.NoError(t, err)
+	err = source.BuildMirror(context.Background(), dir, []MirrorPlugin{{ID: "test-panel", Version: "1.0.0"}}, nil, compatOpts)
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join(dir, "test-panel", "2.0.0", "linux-amd64.zip"))
+	require.NoFileExists(t, filepath.Join(dir, "test-panel", "2.0.0", "darwin-arm64.zip"))
+	require.FileExists(t, filepath.Join(dir, "test-panel", "1.0.0", "any.zip"))
+
+	indexPath := filepath.Join(dir, MirrorIndexFile)
+	var index PluginRepo
+	b, err := os.ReadFile(indexPath)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &index))
+	require.Len(t, index.Plugins, 1)
+	require.Equal(t, []Version{
+		{Version: "2.0.0", Arch: map[string]ArchMeta{"linux-amd64": {SHA256: checksum(archives["linux-amd64"])}}},
+		{Version: "1.0.0", Arch: map[string]ArchMeta{"any": {SHA256: checksum(archives["any"])}}},
+	}, index.Plugins[0].Versions)
+
+	t.Run("installs from a local mirror", func(t *testing.T) {
+		mirror := New(false, indexPath, &fakeLogger{})
+		archive, err := mirror.GetPluginArchive(context.Background(), "test-panel", "", compatOpts)
+		require.NoError(t, err)
+		require.Equal(t, "linux/plugin.json", archive.File.File[0].Name)
+		require.NoError(t, archive.File.Close())
+
+		archive, err = mirror.GetPluginArchive(context.Background(), "test-panel", "1.0.0", compatOpts)
+		require.NoError(t, err)
+		require.Equal(t, "any/plugin.json", archive.File.File[0].Name)
+		require.NoError(t, archive.File.Close())
+
+		// Version 2.0.0 was not mirrored for darwin.
+		ver, err := mirror.GetPluginDownloadOptions(context.Background(), "test-panel", "", NewCompatOpts("9.1.0", "darwin", "arm64"))
+		require.NoError(t, err)
+		require.Equal(t, "1.0.0", ver.Version)
+
+		_, err = mirror.GetPluginArchive(context.Background(), "unknown-panel", "", compatOpts)
+		require.Error(t, err)
+	})
+
+	t.Run("installs from a mirror served over HTTP", func(t *testing.T) {
+		mirrorServer := httptest.NewServer(http.FileServer(http.Dir(dir)))
+		t.Cleanup(mirrorServer.Close)
+
+		mirror := New(false, mirrorServer.URL+"/"+MirrorIndexFile, &fakeLogger{})
+		archive, err := mirror.GetPluginArchive(context.Background(), "test-panel", "2.0.0", compatOpts)
+		require.NoError(t, err)
+		require.Equal(t, "linux/plugin.json", archive.File.File[0].Name)
+		require.NoError(t, archive.File.Close())
+	})
+
+	t.Run("verifies checksums", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "test-panel", "2.0.0", "linux-amd64.zip"), archives["darwin-arm64"], 0600))
+		mirror := New(false, indexPath, &fakeLogger{})
+		_, err := mirror.GetPluginArchive(context.Background(), "test-panel", "2.0.0", compatOpts)
+		require.ErrorContains(t, err, "checksum")
+	})
+}
+
+func testPluginZip(t *testing.T, dir string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(dir + "/plugin.json")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`{"id": "test-panel"}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
@@ -9,24 +9,29 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/plugins/logger"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 type Manager struct {
 	client  *Client
 	baseURL string
+	// mirror is set when baseURL points to the index of a static mirror.
+	mirror bool
 
 	log logger.Logger
 }
 
-func ProvideService() *Manager {
-	defaultBaseURL := "https://grafana.com/api/plugins"
-	return New(false, defaultBaseURL, logger.NewLogger("plugin.repository"))
+func ProvideService(cfg *setting.Cfg) *Manager {
+	return New(cfg.PluginRepositorySkipTLSVerify, cfg.PluginRepositoryURL, logger.NewLogger("plugin.repository"))
 }
 
+// New creates a Manager for the repository at baseURL, which is either the
+// grafana.com plugins API or the index of a static mirror (see IsMirrorIndex).
 func New(skipTLSVerify bool, baseURL string, logger logger.Logger) *Manager {
 	return &Manager{
 		client:  newClient(skipTLSVerify, logger),
 		baseURL: baseURL,
+		mirror:  IsMirrorIndex(baseURL),
 		log:     logger,
 	}
 }
@@ -58,6 +63,32 @@ func (m *Manager) GetPluginDownloadOptions(_ context.Context, pluginID, version
 		return nil, err
 	}
 
+	return m.downloadOptions(pluginID, v, compatOpts)
+}
+
+func (m *Manager) downloadOptions(pluginID string, v *Version, compatOpts CompatOpts) (*PluginDownloadOptions, error) {
+	if m.mirror {
+		arch := compatOpts.OSAndArch()
+		archMeta, exists := v.Arch[arch]
+		if !exists {
+			arch = anyArch
+			archMeta = v.Arch[arch]
+		}
+		// Mirrors always keep checksums of archives.
+		if archMeta.SHA256 == "" {
+			return nil, fmt.Errorf("plugin repository index has no checksum for %s v%s (%s)", pluginID, v.Version, compatOpts.OSAndArch())
+		}
+		archiveURL, err := m.mirrorArchiveURL(pluginID, v.Version, arch)
+		if err != nil {
+			return nil, err
+		}
+		return &PluginDownloadOptions{
+			Version:      v.Version,
+			Checksum:     archMeta.SHA256,
+			PluginZipURL: archiveURL,
+		}, nil
+	}
+
 	// Plugins which are downloaded just as sourcecode zipball from GitHub do not have checksum
 	var checksum string
 	if v.Arch != nil {
@@ -76,6 +107,9 @@ func (m *Manager) GetPluginDownloadOptions(_ context.Context, pluginID, version
 }
 
 func (m *Manager) pluginMetadata(pluginID string, compatOpts CompatOpts) (Plugin, error) {
+	if m.mirror {
+		return m.mirrorPluginMetadata(pluginID, compatOpts)
+	}
 	m.log.Debugf("Fetching metadata for plugin \"%s\" from repo %s", pluginID, m.baseURL)
 
 	u, err := url.Parse(m.baseURL)
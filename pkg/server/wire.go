@@ -79,6 +79,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/pushhttp"
+	"github.com/grafana/grafana/pkg/services/live/pushmqtt"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/login/authinfoservice"
 	authinfodatabase "github.com/grafana/grafana/pkg/services/login/authinfoservice/database"
@@ -193,6 +194,8 @@ var wireBasicSet = wire.NewSet(
 	wire.Bind(new(repo.Service), new(*repo.Manager)),
 	manager.ProvideInstaller,
 	wire.Bind(new(plugins.Installer), new(*manager.PluginInstaller)),
+	manager.ProvideReloader,
+	wire.Bind(new(plugins.Reloader), new(*manager.PluginReloader)),
 	client.ProvideService,
 	wire.Bind(new(plugins.Client), new(*client.Service)),
 	managerStore.ProvideService,
@@ -242,8 +245,10 @@ var wireBasicSet = wire.NewSet(
 	searchV2.ProvideSearchHTTPService,
 	store.ProvideService,
 	export.ProvideService,
+	export.ProvideBackupService,
 	live.ProvideService,
 	pushhttp.ProvideService,
+	pushmqtt.ProvideService,
 	plugincontext.ProvideService,
 	contexthandler.ProvideService,
 	jwt.ProvideService,
@@ -10,15 +10,19 @@ import (
 
 var (
 	ErrTimerangeMissing     = errors.New("missing timerange")
+	ErrInvalidAggregation   = errors.New("invalid aggregation, from, to and interval are required and at most 1000 buckets are allowed")
 	ErrBaseTagLimitExceeded = errutil.NewBase(errutil.StatusBadRequest, "annotations.tag-limit-exceeded", errutil.WithPublicMessage("Tags length exceeds the maximum allowed."))
 )
 
 type Repository interface {
 	Save(ctx context.Context, item *Item) error
+	// SaveMany saves all items or none of them
+	SaveMany(ctx context.Context, items []*Item) error
 	Update(ctx context.Context, item *Item) error
 	Find(ctx context.Context, query *ItemQuery) ([]*ItemDTO, error)
 	Delete(ctx context.Context, params *DeleteParams) error
 	FindTags(ctx context.Context, query *TagsQuery) (FindTagsResult, error)
+	Aggregate(ctx context.Context, query *AggregateQuery) (AggregateResult, error)
 }
 
 // Cleaner is responsible for cleaning up old annotations
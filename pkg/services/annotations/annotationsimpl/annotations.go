@@ -15,14 +15,24 @@ type RepositoryImpl struct {
 }
 
 func ProvideService(db db.DB, cfg *setting.Cfg, tagService tag.Service) *RepositoryImpl {
-	return &RepositoryImpl{
-		store: &xormRepositoryImpl{
-			cfg:               cfg,
-			db:                db,
-			log:               log.New("annotations"),
-			tagService:        tagService,
-			maximumTagsLength: cfg.AnnotationMaximumTagsLength,
-		},
+	var s store = newXormStore(db, cfg, tagService)
+	if cfg.AlertAnnotationsBackend == setting.AnnotationsBackendLoki {
+		s = &compositeStore{
+			primary: s,
+			alerts:  newLokiStore(db, cfg),
+		}
+	}
+
+	return &RepositoryImpl{store: s}
+}
+
+func newXormStore(db db.DB, cfg *setting.Cfg, tagService tag.Service) *xormRepositoryImpl {
+	return &xormRepositoryImpl{
+		cfg:               cfg,
+		db:                db,
+		log:               log.New("annotations"),
+		tagService:        tagService,
+		maximumTagsLength: cfg.AnnotationMaximumTagsLength,
 	}
 }
 
@@ -30,6 +40,10 @@ func (r *RepositoryImpl) Save(ctx context.Context, item *annotations.Item) error
 	return r.store.Add(ctx, item)
 }
 
+func (r *RepositoryImpl) SaveMany(ctx context.Context, items []*annotations.Item) error {
+	return r.store.AddMany(ctx, items)
+}
+
 func (r *RepositoryImpl) Update(ctx context.Context, item *annotations.Item) error {
 	return r.store.Update(ctx, item)
 }
@@ -45,3 +59,12 @@ func (r *RepositoryImpl) Delete(ctx context.Context, params *annotations.DeleteP
 func (r *RepositoryImpl) FindTags(ctx context.Context, query *annotations.TagsQuery) (annotations.FindTagsResult, error) {
 	return r.store.GetTags(ctx, query)
 }
+
+// Aggregate counts the annotations starting in the time range of the query
+func (r *RepositoryImpl) Aggregate(ctx context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	if err := query.Validate(); err != nil {
+		return annotations.AggregateResult{}, err
+	}
+	query.TimeMatch = annotations.TimeMatchStart
+	return r.store.Aggregate(ctx, query)
+}
@@ -0,0 +1,124 @@
+package annotationsimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// compositeStore stores annotations created by alerts in a separate store,
+// other annotations go to the primary store. Alert annotations are read from
+// both stores, since the ones created before the alerts store was enabled
+// remain in the primary store until they are migrated. Annotations are changed
+// in the store their ID is from.
+type compositeStore struct {
+	primary store
+	alerts  store
+}
+
+func (s *compositeStore) Add(ctx context.Context, item *annotations.Item) error {
+	if item.AlertId != 0 {
+		return s.alerts.Add(ctx, item)
+	}
+	return s.primary.Add(ctx, item)
+}
+
+// AddMany saves the annotations of each store at once, so the annotations of
+// one store may be saved when saving to the other fails
+func (s *compositeStore) AddMany(ctx context.Context, items []*annotations.Item) error {
+	var alertItems, otherItems []*annotations.Item
+	for _, item := range items {
+		if item.AlertId != 0 {
+			alertItems = append(alertItems, item)
+		} else {
+			otherItems = append(otherItems, item)
+		}
+	}
+
+	if len(otherItems) > 0 {
+		if err := s.primary.AddMany(ctx, otherItems); err != nil {
+			return err
+		}
+	}
+	if len(alertItems) > 0 {
+		return s.alerts.AddMany(ctx, alertItems)
+	}
+	return nil
+}
+
+func (s *compositeStore) Update(ctx context.Context, item *annotations.Item) error {
+	if isLokiID(item.Id) {
+		return s.alerts.Update(ctx, item)
+	}
+	return s.primary.Update(ctx, item)
+}
+
+func (s *compositeStore) Get(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	items, err := s.primary.Get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Type == "annotation" {
+		return items, nil
+	}
+
+	alertItems, err := s.alerts.Get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(alertItems) == 0 {
+		return items, nil
+	}
+
+	items = append(items, alertItems...)
+	sortAnnotations(items)
+	if int64(len(items)) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
+// Delete deletes annotations by ID in the store the ID is from, annotations
+// deleted by other parameters are deleted in the primary store only
+func (s *compositeStore) Delete(ctx context.Context, params *annotations.DeleteParams) error {
+	if isLokiID(params.Id) {
+		return s.alerts.Delete(ctx, params)
+	}
+	return s.primary.Delete(ctx, params)
+}
+
+func (s *compositeStore) GetTags(ctx context.Context, query *annotations.TagsQuery) (annotations.FindTagsResult, error) {
+	return s.primary.GetTags(ctx, query)
+}
+
+func (s *compositeStore) Aggregate(ctx context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	result, err := s.primary.Aggregate(ctx, query)
+	if err != nil || query.Type == "annotation" {
+		return result, err
+	}
+
+	alertResult, err := s.alerts.Aggregate(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	result.Merge(alertResult)
+	return result, nil
+}
+
+func (s *compositeStore) CleanAnnotations(ctx context.Context, cfg setting.AnnotationCleanupSettings, annotationType string) (int64, error) {
+	affected, err := s.primary.CleanAnnotations(ctx, cfg, annotationType)
+	if err != nil {
+		return affected, err
+	}
+	alertsAffected, err := s.alerts.CleanAnnotations(ctx, cfg, annotationType)
+	return affected + alertsAffected, err
+}
+
+func (s *compositeStore) CleanOrphanedAnnotationTags(ctx context.Context) (int64, error) {
+	return s.primary.CleanOrphanedAnnotationTags(ctx)
+}
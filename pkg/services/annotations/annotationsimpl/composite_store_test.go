@@ -0,0 +1,99 @@
+package annotationsimpl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+)
+
+func TestIntegrationCompositeStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sql := sqlstore.InitTestDB(t)
+	sql.Cfg.AnnotationMaximumTagsLength = 60
+	sql.Cfg.RBACEnabled = false
+
+	fake, server := newFakeLoki(t)
+	lokiStore := newTestLokiStore(t, server.URL)
+	store := &compositeStore{
+		primary: newXormStore(sql, sql.Cfg, tagimpl.ProvideService(sql, sql.Cfg)),
+		alerts:  lokiStore,
+	}
+	ctx := context.Background()
+
+	dashboardAnnotation := &annotations.Item{OrgId: 1, DashboardId: 1, Text: "deploy", Epoch: 1000}
+	require.NoError(t, store.Add(ctx, dashboardAnnotation))
+	alertAnnotation := &annotations.Item{OrgId: 1, AlertId: 2, NewState: "Alerting", Text: "alert", Epoch: 2000}
+	require.NoError(t, store.Add(ctx, alertAnnotation))
+	require.Len(t, fake.entries, 1)
+
+	t.Run("Should find annotations of both stores", func(t *testing.T) {
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 2500})
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, alertAnnotation.Id, items[0].Id)
+		assert.Equal(t, dashboardAnnotation.Id, items[1].Id)
+	})
+
+	t.Run("Should apply limit to merged annotations", func(t *testing.T) {
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 2500, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, alertAnnotation.Id, items[0].Id)
+	})
+
+	t.Run("Should only query primary store for other annotations", func(t *testing.T) {
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 2500, Type: "annotation"})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, dashboardAnnotation.Id, items[0].Id)
+	})
+
+	t.Run("Can update and delete annotations of primary store", func(t *testing.T) {
+		require.NoError(t, store.Update(ctx, &annotations.Item{Id: dashboardAnnotation.Id, OrgId: 1, Text: "rollback"}))
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, AnnotationId: dashboardAnnotation.Id})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "rollback", items[0].Text)
+
+		require.NoError(t, store.Delete(ctx, &annotations.DeleteParams{OrgId: 1, Id: dashboardAnnotation.Id}))
+		items, err = store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 2500})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, alertAnnotation.Id, items[0].Id)
+	})
+	t.Run("Can update and delete alert annotations of primary store", func(t *testing.T) {
+		sqlAlertAnnotation := &annotations.Item{OrgId: 1, AlertId: 3, NewState: "Alerting", Text: "old alert", Epoch: 1500}
+		require.NoError(t, store.primary.Add(ctx, sqlAlertAnnotation))
+
+		require.NoError(t, store.Update(ctx, &annotations.Item{Id: sqlAlertAnnotation.Id, OrgId: 1, AlertId: 3, Text: "changed"}))
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, AnnotationId: sqlAlertAnnotation.Id})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "changed", items[0].Text)
+
+		require.NoError(t, store.Delete(ctx, &annotations.DeleteParams{OrgId: 1, Id: sqlAlertAnnotation.Id, AlertId: 3}))
+		items, err = store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 2500})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, alertAnnotation.Id, items[0].Id)
+	})
+	t.Run("Alert annotations are changed in alerts store", func(t *testing.T) {
+		err := store.Update(ctx, &annotations.Item{Id: alertAnnotation.Id, OrgId: 1, AlertId: 2, Text: "changed"})
+		require.ErrorIs(t, err, errLokiReadOnly)
+		err = store.Delete(ctx, &annotations.DeleteParams{OrgId: 1, Id: alertAnnotation.Id, AlertId: 2})
+		require.ErrorIs(t, err, errLokiReadOnly)
+
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 2500})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "alert", items[0].Text)
+	})
+}
@@ -0,0 +1,127 @@
+package annotationsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const maxLokiErrMsgLen = 1024
+
+// lokiStream is a set of entries sharing the same labels, as sent to and
+// returned by the Loki HTTP API.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiQueryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string       `json:"resultType"`
+		Result     []lokiStream `json:"result"`
+	} `json:"data"`
+}
+
+type lokiDirection string
+
+const (
+	lokiForward  lokiDirection = "forward"
+	lokiBackward lokiDirection = "backward"
+)
+
+// lokiClient pushes and queries log lines using the Loki HTTP API. Pushes are
+// synchronous so that callers know whether an annotation was stored.
+type lokiClient struct {
+	cfg    setting.AnnotationsLokiSettings
+	client *http.Client
+}
+
+func newLokiClient(cfg setting.AnnotationsLokiSettings) *lokiClient {
+	return &lokiClient{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (c *lokiClient) push(ctx context.Context, streams []lokiStream) error {
+	body, err := json.Marshal(lokiPushRequest{Streams: streams})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req)
+	return err
+}
+
+// queryRange returns the entries matching query with a timestamp in
+// [start, end], at most limit entries in the given direction.
+func (c *lokiClient) queryRange(ctx context.Context, query string, start, end time.Time, limit int64, direction lokiDirection) ([]lokiStream, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	// the end of the range is exclusive
+	params.Set("end", strconv.FormatInt(end.UnixNano()+1, 10))
+	params.Set("limit", strconv.FormatInt(limit, 10))
+	params.Set("direction", string(direction))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.URL+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res lokiQueryResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("failed to parse Loki response: %w", err)
+	}
+	if res.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("unexpected Loki result type %q", res.Data.ResultType)
+	}
+	return res.Data.Result, nil
+}
+
+func (c *lokiClient) do(req *http.Request) ([]byte, error) {
+	if c.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", c.cfg.TenantID)
+	}
+	if c.cfg.BasicAuthUser != "" || c.cfg.BasicAuthPassword != "" {
+		req.SetBasicAuth(c.cfg.BasicAuthUser, c.cfg.BasicAuthPassword)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxLokiErrMsgLen))
+		return nil, fmt.Errorf("loki returned HTTP status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return io.ReadAll(resp.Body)
+}
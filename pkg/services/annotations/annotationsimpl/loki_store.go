@@ -0,0 +1,576 @@
+package annotationsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/db"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+	"github.com/grafana/grafana/pkg/services/tag"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+const (
+	lokiLabelFrom  = "from"
+	lokiLabelKind  = "kind"
+	lokiLabelOrgID = "org_id"
+
+	lokiFromGrafana    = "grafana"
+	lokiKindAnnotation = "annotation"
+
+	// lokiDefaultLookback is how far back annotations are looked up when a
+	// query has no time range, matching Loki's default maximum query length.
+	lokiDefaultLookback = 30 * 24 * time.Hour
+	// lokiMaxQueryLimit is Loki's default maximum number of entries returned
+	// by a query
+	lokiMaxQueryLimit = 5000
+	// lokiMaxRegionLength is how long before the time range of a query
+	// regions overlapping it are looked up
+	lokiMaxRegionLength = 24 * time.Hour
+
+	// Annotation IDs are the milliseconds since lokiIDEpoch followed by the
+	// instance and a sequence, so instances sharing Loki don't create the same
+	// IDs. The IDs stay exactly representable in JavaScript for 69 years.
+	lokiIDEpoch        = 1640995200000 // 2022-01-01T00:00:00Z
+	lokiIDInstanceBits = 8
+	lokiIDSequenceBits = 4
+	// lokiMinID is less than the IDs created since a few days after
+	// lokiIDEpoch, and much more than the IDs of the SQL store reach.
+	lokiMinID = 1 << 40
+)
+
+var errLokiReadOnly = errutil.NewBase(errutil.StatusBadRequest, "annotations.lokiReadOnly",
+	errutil.WithPublicMessage("Annotations created by alerts can't be changed or deleted")).
+	Errorf("annotations stored in Loki can't be changed or deleted")
+
+// lokiAnnotation is the log line an annotation is stored as
+type lokiAnnotation struct {
+	ID          int64            `json:"id"`
+	AlertID     int64            `json:"alertId,omitempty"`
+	DashboardID int64            `json:"dashboardId,omitempty"`
+	PanelID     int64            `json:"panelId,omitempty"`
+	UserID      int64            `json:"userId,omitempty"`
+	Text        string           `json:"text"`
+	PrevState   string           `json:"prevState,omitempty"`
+	NewState    string           `json:"newState,omitempty"`
+	Epoch       int64            `json:"epoch"`
+	EpochEnd    int64            `json:"epochEnd"`
+	Created     int64            `json:"created"`
+	Updated     int64            `json:"updated"`
+	Tags        []string         `json:"tags,omitempty"`
+	Data        *simplejson.Json `json:"data,omitempty"`
+}
+
+// lokiStore stores annotations as log lines in Loki, one stream per org. The
+// log lines are immutable, so annotations can't be updated or deleted and
+// their retention is configured in Loki. Annotations are looked up by their
+// start time, so a region starting more than lokiMaxRegionLength before the
+// queried time range is not returned.
+type lokiStore struct {
+	cfg    *setting.Cfg
+	db     db.DB
+	log    log.Logger
+	client *lokiClient
+
+	instance int64
+
+	mu       sync.Mutex
+	lastTime int64
+	sequence int64
+}
+
+func newLokiStore(db db.DB, cfg *setting.Cfg) *lokiStore {
+	return &lokiStore{
+		cfg:      cfg,
+		db:       db,
+		log:      log.New("annotations.loki"),
+		client:   newLokiClient(cfg.AnnotationsLoki),
+		instance: lokiInstanceID(setting.InstanceName),
+	}
+}
+
+// lokiInstanceID returns the instance component of annotation IDs, instances
+// need different names to create different IDs
+func lokiInstanceID(instanceName string) int64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(instanceName))
+	return int64(h.Sum32() & (1<<lokiIDInstanceBits - 1))
+}
+
+// nextID returns a unique annotation ID based on the current time. IDs of
+// annotations in the SQL store are much smaller. When the sequence of a
+// millisecond is used up, or the clock goes back, IDs of the following
+// milliseconds are used.
+func (s *lokiStore) nextID(now time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now.UnixMilli() - lokiIDEpoch
+	if t <= s.lastTime {
+		t = s.lastTime
+		s.sequence++
+		if s.sequence == 1<<lokiIDSequenceBits {
+			t++
+			s.sequence = 0
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTime = t
+	return t<<(lokiIDInstanceBits+lokiIDSequenceBits) | s.instance<<lokiIDSequenceBits | s.sequence
+}
+
+// isLokiID checks if an annotation ID was created by lokiStore
+func isLokiID(id int64) bool {
+	return id >= lokiMinID
+}
+
+func (s *lokiStore) Add(ctx context.Context, item *annotations.Item) error {
+	item.Tags = tag.JoinTagPairs(tag.ParseTagPairs(item.Tags))
+	now := timeNow()
+	item.Created = now.UnixNano() / int64(time.Millisecond)
+	item.Updated = item.Created
+	if item.Epoch == 0 {
+		item.Epoch = item.Created
+	}
+	if err := validateTimeRange(item); err != nil {
+		return err
+	}
+
+	item.Id = s.nextID(now)
+	return s.push(ctx, []*annotations.Item{item})
+}
+
+func (s *lokiStore) AddMany(ctx context.Context, items []*annotations.Item) error {
+	now := timeNow()
+	created := now.UnixNano() / int64(time.Millisecond)
+	for _, item := range items {
+		item.Tags = tag.JoinTagPairs(tag.ParseTagPairs(item.Tags))
+		item.Created = created
+		item.Updated = created
+		if item.Epoch == 0 {
+			item.Epoch = created
+		}
+		if err := validateTimeRange(item); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range items {
+		item.Id = s.nextID(now)
+	}
+	return s.push(ctx, items)
+}
+
+func (s *lokiStore) push(ctx context.Context, items []*annotations.Item) error {
+	streams := map[int64]*lokiStream{}
+	orgIDs := make([]int64, 0)
+	for _, item := range items {
+		line, err := json.Marshal(lokiAnnotation{
+			ID:          item.Id,
+			AlertID:     item.AlertId,
+			DashboardID: item.DashboardId,
+			PanelID:     item.PanelId,
+			UserID:      item.UserId,
+			Text:        item.Text,
+			PrevState:   item.PrevState,
+			NewState:    item.NewState,
+			Epoch:       item.Epoch,
+			EpochEnd:    item.EpochEnd,
+			Created:     item.Created,
+			Updated:     item.Updated,
+			Tags:        item.Tags,
+			Data:        item.Data,
+		})
+		if err != nil {
+			return err
+		}
+
+		stream, ok := streams[item.OrgId]
+		if !ok {
+			stream = &lokiStream{Stream: lokiLabels(item.OrgId)}
+			streams[item.OrgId] = stream
+			orgIDs = append(orgIDs, item.OrgId)
+		}
+		ts := strconv.FormatInt(item.Epoch*int64(time.Millisecond), 10)
+		stream.Values = append(stream.Values, [2]string{ts, string(line)})
+	}
+
+	req := make([]lokiStream, 0, len(orgIDs))
+	for _, orgID := range orgIDs {
+		req = append(req, *streams[orgID])
+	}
+	if err := s.client.push(ctx, req); err != nil {
+		return fmt.Errorf("failed to store annotations in Loki: %w", err)
+	}
+	return nil
+}
+
+func lokiLabels(orgID int64) map[string]string {
+	return map[string]string{
+		lokiLabelFrom:  lokiFromGrafana,
+		lokiLabelKind:  lokiKindAnnotation,
+		lokiLabelOrgID: strconv.FormatInt(orgID, 10),
+	}
+}
+
+// lokiSelector returns the LogQL query for annotations of an org, or of all
+// orgs if orgID is 0, filtered by the given fields of the log line.
+func lokiSelector(orgID int64, fields map[string]int64) string {
+	var q strings.Builder
+	q.WriteString(fmt.Sprintf(`{%s=%q,%s=%q`, lokiLabelFrom, lokiFromGrafana, lokiLabelKind, lokiKindAnnotation))
+	if orgID != 0 {
+		q.WriteString(fmt.Sprintf(`,%s="%d"`, lokiLabelOrgID, orgID))
+	}
+	q.WriteString(`} | json`)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fields[name] == 0 {
+			continue
+		}
+		q.WriteString(fmt.Sprintf(` | %s="%d"`, name, fields[name]))
+	}
+	return q.String()
+}
+
+func (s *lokiStore) Update(ctx context.Context, item *annotations.Item) error {
+	return errLokiReadOnly
+}
+
+func (s *lokiStore) Get(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	if query.Type == "annotation" {
+		// annotations not created by alerts are kept in the SQL store
+		return []*annotations.ItemDTO{}, nil
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	end := timeNow()
+	if query.To > 0 {
+		end = time.UnixMilli(query.To)
+	}
+	start := end.Add(-lokiDefaultLookback)
+	if query.From > 0 {
+		start = time.UnixMilli(query.From)
+		if query.TimeMatch != annotations.TimeMatchStart && query.TimeMatch != annotations.TimeMatchWithin {
+			// regions overlapping the time range may start before it
+			start = start.Add(-lokiMaxRegionLength)
+		}
+	}
+
+	logQL := lokiSelector(query.OrgId, map[string]int64{
+		"id":          query.AnnotationId,
+		"alertId":     query.AlertId,
+		"dashboardId": query.DashboardId,
+		"panelId":     query.PanelId,
+		"userId":      query.UserId,
+	})
+	if query.Type == "alert" {
+		logQL += ` | alertId!=""`
+	}
+
+	// tags and access control are filtered after reading the annotations, so
+	// as many as Loki returns are read to fill the limit
+	streams, err := s.client.queryRange(ctx, logQL, start, end, lokiMaxQueryLimit, lokiBackward)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := tag.ParseTagPairs(query.Tags)
+	items := make([]*annotations.ItemDTO, 0)
+	for _, stream := range streams {
+		for _, value := range stream.Values {
+			var a lokiAnnotation
+			if err := json.Unmarshal([]byte(value[1]), &a); err != nil {
+				s.log.Warn("Skipping annotation that can't be parsed", "error", err)
+				continue
+			}
+			if !query.MatchesTime(a.Epoch, a.EpochEnd) || (query.Type == "region" && a.EpochEnd <= a.Epoch) {
+				continue
+			}
+			if len(tags) > 0 && !matchTags(a.Tags, tags, query.MatchAny) {
+				continue
+			}
+			items = append(items, a.toDTO())
+		}
+	}
+
+	if !ac.IsDisabled(s.cfg) {
+		items, err = s.filterReadable(ctx, query.SignedInUser, items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortAnnotations(items)
+	if int64(len(items)) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
+func (a lokiAnnotation) toDTO() *annotations.ItemDTO {
+	return &annotations.ItemDTO{
+		Id:          a.ID,
+		AlertId:     a.AlertID,
+		DashboardId: a.DashboardID,
+		PanelId:     a.PanelID,
+		UserId:      a.UserID,
+		NewState:    a.NewState,
+		PrevState:   a.PrevState,
+		Created:     a.Created,
+		Updated:     a.Updated,
+		Time:        a.Epoch,
+		TimeEnd:     a.EpochEnd,
+		Text:        a.Text,
+		Tags:        a.Tags,
+		Data:        a.Data,
+	}
+}
+
+func (a lokiAnnotation) toItem(orgID int64) *annotations.Item {
+	return &annotations.Item{
+		Id:          a.ID,
+		OrgId:       orgID,
+		UserId:      a.UserID,
+		DashboardId: a.DashboardID,
+		PanelId:     a.PanelID,
+		Text:        a.Text,
+		AlertId:     a.AlertID,
+		PrevState:   a.PrevState,
+		NewState:    a.NewState,
+		Epoch:       a.Epoch,
+		EpochEnd:    a.EpochEnd,
+		Created:     a.Created,
+		Updated:     a.Updated,
+		Tags:        a.Tags,
+		Data:        a.Data,
+	}
+}
+
+// matchTags checks the tags of an annotation the same way the SQL store does:
+// a filter without a value matches any value of the key
+func matchTags(itemTags []string, filters []*tag.Tag, matchAny bool) bool {
+	parsed := tag.ParseTagPairs(itemTags)
+
+	matched := 0
+	for _, f := range filters {
+		for _, t := range parsed {
+			if t.Key == f.Key && (f.Value == "" || t.Value == f.Value) {
+				matched++
+				break
+			}
+		}
+	}
+
+	if matchAny {
+		return matched > 0
+	}
+	return matched == len(filters)
+}
+
+// filterReadable removes the annotations the user isn't allowed to read,
+// applying the same rules as the access control filter of the SQL store
+func (s *lokiStore) filterReadable(ctx context.Context, user *user.SignedInUser, items []*annotations.ItemDTO) ([]*annotations.ItemDTO, error) {
+	types, err := readableAnnotationTypes(user)
+	if err != nil {
+		return nil, err
+	}
+	_, canReadOrg := types[annotations.Organization.String()]
+	_, canReadDashboards := types[annotations.Dashboard.String()]
+
+	readableDashboards := map[int64]bool{}
+	if canReadDashboards {
+		readableDashboards, err = s.readableDashboards(ctx, user, items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filtered := make([]*annotations.ItemDTO, 0, len(items))
+	for _, item := range items {
+		if (item.DashboardId == 0 && canReadOrg) || (item.DashboardId != 0 && readableDashboards[item.DashboardId]) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *lokiStore) readableDashboards(ctx context.Context, user *user.SignedInUser, items []*annotations.ItemDTO) (map[int64]bool, error) {
+	seen := map[int64]bool{}
+	params := make([]interface{}, 0)
+	for _, item := range items {
+		if item.DashboardId != 0 && !seen[item.DashboardId] {
+			seen[item.DashboardId] = true
+			params = append(params, item.DashboardId)
+		}
+	}
+
+	readable := map[int64]bool{}
+	if len(params) == 0 {
+		return readable, nil
+	}
+
+	filter, filterParams := permissions.NewAccessControlDashboardPermissionFilter(user, models.PERMISSION_VIEW, searchstore.TypeDashboard).Where()
+	sql := fmt.Sprintf("SELECT id FROM dashboard WHERE id IN (?%s) AND (%s)", strings.Repeat(",?", len(params)-1), filter)
+	params = append(params, filterParams...)
+
+	var ids []int64
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(sql, params...).Find(&ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		readable[id] = true
+	}
+	return readable, nil
+}
+
+func (s *lokiStore) Delete(ctx context.Context, params *annotations.DeleteParams) error {
+	return errLokiReadOnly
+}
+
+// GetTags returns no tags, since tags of annotations in Loki are not indexed
+func (s *lokiStore) GetTags(ctx context.Context, query *annotations.TagsQuery) (annotations.FindTagsResult, error) {
+	return annotations.FindTagsResult{Tags: []*annotations.TagsDTO{}}, nil
+}
+
+// Aggregate counts the annotations matching the query, of which at most
+// lokiMaxQueryLimit are read
+func (s *lokiStore) Aggregate(ctx context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	result := annotations.NewAggregateResult(query)
+
+	itemQuery := query.ItemQuery
+	itemQuery.Limit = lokiMaxQueryLimit
+	items, err := s.Get(ctx, &itemQuery)
+	if err != nil {
+		return result, err
+	}
+
+	for _, item := range items {
+		result.Add(item.Time, item.Tags)
+	}
+	return result, nil
+}
+
+// CleanAnnotations does nothing, since the retention of annotations is
+// configured in Loki
+func (s *lokiStore) CleanAnnotations(ctx context.Context, cfg setting.AnnotationCleanupSettings, annotationType string) (int64, error) {
+	return 0, nil
+}
+
+func (s *lokiStore) CleanOrphanedAnnotationTags(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// sortAnnotations sorts annotations in the order of the SQL store, latest
+// first
+func sortAnnotations(items []*annotations.ItemDTO) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].TimeEnd != items[j].TimeEnd {
+			return items[i].TimeEnd > items[j].TimeEnd
+		}
+		return items[i].Time > items[j].Time
+	})
+}
+
+// readAlertAnnotations reads alert annotations in batches, oldest first
+func (s *lokiStore) readAlertAnnotations(ctx context.Context, opts MigrationOptions, fn func([]*annotations.Item) error) error {
+	end := timeNow()
+	start := opts.Since
+	if start.IsZero() {
+		start = end.Add(-lokiDefaultLookback)
+	}
+	logQL := lokiSelector(opts.OrgID, nil) + ` | alertId!=""`
+
+	// the start of the range is inclusive, so the annotations read at the
+	// last timestamp of a batch are returned again in the next one
+	seen := map[int64]bool{}
+	limit := opts.BatchSize
+	for {
+		streams, err := s.client.queryRange(ctx, logQL, start, end, int64(limit), lokiForward)
+		if err != nil {
+			return err
+		}
+
+		var read int
+		last := start
+		items := make([]*annotations.Item, 0)
+		for _, stream := range streams {
+			orgID, err := strconv.ParseInt(stream.Stream[lokiLabelOrgID], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid org ID label: %w", err)
+			}
+			for _, value := range stream.Values {
+				read++
+				ns, err := strconv.ParseInt(value[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid timestamp: %w", err)
+				}
+				var a lokiAnnotation
+				if err := json.Unmarshal([]byte(value[1]), &a); err != nil {
+					return fmt.Errorf("failed to parse annotation: %w", err)
+				}
+
+				if ts := time.Unix(0, ns); ts.After(last) {
+					last = ts
+					seen = map[int64]bool{}
+				}
+				if seen[a.ID] {
+					continue
+				}
+				seen[a.ID] = true
+				items = append(items, a.toItem(orgID))
+			}
+		}
+
+		if len(items) > 0 {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+		if read < limit {
+			return nil
+		}
+		if len(items) == 0 {
+			// all annotations of the batch are at the start of the range and
+			// were read before, read more at once to get past them
+			if limit >= lokiMaxQueryLimit {
+				return fmt.Errorf("more than %d annotations at %s", lokiMaxQueryLimit, last)
+			}
+			limit *= 2
+			continue
+		}
+		limit = opts.BatchSize
+		start = last
+	}
+}
+
+func (s *lokiStore) importAnnotations(ctx context.Context, items []*annotations.Item) error {
+	return s.push(ctx, items)
+}
@@ -0,0 +1,266 @@
+package annotationsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeLoki stores pushed entries and returns them for any query within the
+// requested time range, recording the queries it received
+type fakeLoki struct {
+	mu      sync.Mutex
+	entries []fakeLokiEntry
+	queries []string
+	tenants []string
+}
+
+type fakeLokiEntry struct {
+	labels map[string]string
+	ns     int64
+	line   string
+}
+
+func newFakeLoki(t *testing.T) (*fakeLoki, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeLoki{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/loki/api/v1/push", func(w http.ResponseWriter, r *http.Request) {
+		var req lokiPushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.tenants = append(f.tenants, r.Header.Get("X-Scope-OrgID"))
+		for _, stream := range req.Streams {
+			for _, v := range stream.Values {
+				ns, err := strconv.ParseInt(v[0], 10, 64)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				f.entries = append(f.entries, fakeLokiEntry{labels: stream.Stream, ns: ns, line: v[1]})
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/loki/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.queries = append(f.queries, q.Get("query"))
+
+		matched := make([]fakeLokiEntry, 0)
+		for _, e := range f.entries {
+			if e.ns >= start && e.ns < end {
+				matched = append(matched, e)
+			}
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			if q.Get("direction") == string(lokiBackward) {
+				return matched[i].ns > matched[j].ns
+			}
+			return matched[i].ns < matched[j].ns
+		})
+		if len(matched) > limit {
+			matched = matched[:limit]
+		}
+
+		var res lokiQueryResponse
+		res.Status = "success"
+		res.Data.ResultType = "streams"
+		for _, e := range matched {
+			res.Data.Result = append(res.Data.Result, lokiStream{Stream: e.labels, Values: [][2]string{{strconv.FormatInt(e.ns, 10), e.line}}})
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func newTestLokiStore(t *testing.T, url string) *lokiStore {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.RBACEnabled = false
+	cfg.AnnotationsLoki = setting.AnnotationsLokiSettings{URL: url, TenantID: "tenant", Timeout: 5 * time.Second}
+	return newLokiStore(nil, cfg)
+}
+
+func TestLokiStore(t *testing.T) {
+	fake, server := newFakeLoki(t)
+	store := newTestLokiStore(t, server.URL)
+	ctx := context.Background()
+
+	alert := &annotations.Item{OrgId: 1, AlertId: 10, NewState: "Alerting", PrevState: "Normal", Text: "alert", Epoch: 1000, Tags: []string{"env:prod", "team"}}
+	require.NoError(t, store.Add(ctx, alert))
+	require.NotZero(t, alert.Id)
+	assert.Equal(t, int64(1000), alert.EpochEnd)
+
+	other := &annotations.Item{OrgId: 2, AlertId: 11, Text: "other org", Epoch: 2000}
+	require.NoError(t, store.Add(ctx, other))
+	assert.Greater(t, other.Id, alert.Id)
+	assert.Equal(t, []string{"tenant", "tenant"}, fake.tenants)
+	assert.Equal(t, map[string]string{"from": "grafana", "kind": "annotation", "org_id": "1"}, fake.entries[0].labels)
+	assert.Equal(t, int64(1000*time.Millisecond), fake.entries[0].ns)
+
+	t.Run("Can find annotation", func(t *testing.T) {
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, AlertId: 10, From: 500, To: 1500})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, alert.Id, items[0].Id)
+		assert.Equal(t, int64(10), items[0].AlertId)
+		assert.Equal(t, "Alerting", items[0].NewState)
+		assert.Equal(t, int64(1000), items[0].Time)
+		assert.Equal(t, []string{"env:prod", "team"}, items[0].Tags)
+		assert.Equal(t, `{from="grafana",kind="annotation",org_id="1"} | json | alertId="10"`, fake.queries[len(fake.queries)-1])
+	})
+
+	t.Run("Should not find annotation outside time range", func(t *testing.T) {
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 1500, To: 1800})
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("Should filter by tags", func(t *testing.T) {
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 1500, Tags: []string{"env:prod", "team"}})
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+
+		items, err = store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 1500, Tags: []string{"env:dev", "team"}})
+		require.NoError(t, err)
+		assert.Empty(t, items)
+
+		items, err = store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 1500, Tags: []string{"env:dev", "team"}, MatchAny: true})
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	})
+
+	t.Run("Should fill the limit with annotations matching the tags", func(t *testing.T) {
+		tagged := &annotations.Item{OrgId: 1, AlertId: 14, Text: "tagged", Epoch: 5000, Tags: []string{"env:prod"}}
+		require.NoError(t, store.Add(ctx, tagged))
+		require.NoError(t, store.Add(ctx, &annotations.Item{OrgId: 1, AlertId: 15, Text: "untagged", Epoch: 5100}))
+
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 4500, To: 5500, Tags: []string{"env:prod"}, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, tagged.Id, items[0].Id)
+	})
+
+	t.Run("Should not query Loki for other annotations", func(t *testing.T) {
+		queries := len(fake.queries)
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, Type: "annotation"})
+		require.NoError(t, err)
+		assert.Empty(t, items)
+		assert.Len(t, fake.queries, queries)
+	})
+
+	t.Run("Should filter annotations the user can't read", func(t *testing.T) {
+		store.cfg.RBACEnabled = true
+		t.Cleanup(func() { store.cfg.RBACEnabled = false })
+
+		require.NoError(t, store.Add(ctx, &annotations.Item{OrgId: 1, AlertId: 12, DashboardId: 5, Text: "dashboard", Epoch: 1200}))
+
+		orgReader := &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{
+			1: {accesscontrol.ActionAnnotationsRead: {accesscontrol.ScopeAnnotationsTypeOrganization}},
+		}}
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 1500, SignedInUser: orgReader})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, alert.Id, items[0].Id)
+
+		_, err = store.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 500, To: 1500, SignedInUser: &user.SignedInUser{OrgID: 1}})
+		require.Error(t, err)
+	})
+
+	t.Run("Should find regions starting before time range", func(t *testing.T) {
+		region := &annotations.Item{OrgId: 3, AlertId: 13, Text: "region", Epoch: 1000, EpochEnd: 60 * 60 * 1000}
+		require.NoError(t, store.Add(ctx, region))
+
+		items, err := store.Get(ctx, &annotations.ItemQuery{OrgId: 3, From: 30 * 60 * 1000, To: 90 * 60 * 1000})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, region.Id, items[0].Id)
+
+		items, err = store.Get(ctx, &annotations.ItemQuery{OrgId: 3, From: 30 * 60 * 1000, To: 90 * 60 * 1000, TimeMatch: annotations.TimeMatchStart})
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("Can't change annotations", func(t *testing.T) {
+		require.ErrorIs(t, store.Update(ctx, alert), errLokiReadOnly)
+		require.ErrorIs(t, store.Delete(ctx, &annotations.DeleteParams{OrgId: 1, Id: alert.Id}), errLokiReadOnly)
+	})
+}
+
+func TestLokiStoreError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry too far behind", http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+	store := newTestLokiStore(t, server.URL)
+
+	err := store.Add(context.Background(), &annotations.Item{OrgId: 1, AlertId: 1, Epoch: 1000})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entry too far behind")
+
+	_, err = store.Get(context.Background(), &annotations.ItemQuery{OrgId: 1})
+	require.Error(t, err)
+}
+
+func TestLokiStoreNextID(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	first := &lokiStore{instance: lokiInstanceID("grafana-0")}
+	second := &lokiStore{instance: lokiInstanceID("grafana-1")}
+	require.NotEqual(t, first.instance, second.instance)
+
+	// IDs are unique across instances and increase when the sequence of a
+	// millisecond is used up or the clock goes back
+	ids := map[int64]bool{}
+	var last int64
+	for i := 0; i < 100; i++ {
+		id := first.nextID(now)
+		require.Greater(t, id, last)
+		last = id
+		ids[id] = true
+		ids[second.nextID(now)] = true
+	}
+	require.Len(t, ids, 200)
+	require.True(t, isLokiID(last))
+	require.Greater(t, first.nextID(now.Add(-time.Second)), last)
+
+	// IDs stay exactly representable in JavaScript
+	require.LessOrEqual(t, first.nextID(time.Date(2090, 1, 1, 0, 0, 0, 0, time.UTC)), int64(1<<53))
+}
+
+func TestLokiSelector(t *testing.T) {
+	assert.Equal(t, `{from="grafana",kind="annotation"} | json`, lokiSelector(0, nil))
+	assert.Equal(t,
+		`{from="grafana",kind="annotation",org_id="2"} | json | dashboardId="3" | panelId="4"`,
+		lokiSelector(2, map[string]int64{"panelId": 4, "dashboardId": 3, "alertId": 0}),
+	)
+}
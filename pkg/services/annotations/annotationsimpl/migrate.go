@@ -0,0 +1,91 @@
+package annotationsimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/sqlstore/db"
+	"github.com/grafana/grafana/pkg/services/tag"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const defaultMigrationBatchSize = 500
+
+// MigrationOptions configures a migration of alert annotations between
+// storage backends.
+type MigrationOptions struct {
+	// From and To are the storage backends, setting.AnnotationsBackendSQL or
+	// setting.AnnotationsBackendLoki.
+	From string
+	To   string
+	// OrgID limits the migration to one org. All orgs are migrated if 0.
+	OrgID int64
+	// Since limits the migration to annotations starting after it. All
+	// annotations are migrated from SQL if zero, and the annotations of the
+	// last 30 days from Loki.
+	Since time.Time
+	// DeleteSource deletes migrated annotations from SQL. Annotations in Loki
+	// can't be deleted.
+	DeleteSource bool
+	BatchSize    int
+}
+
+type migrationSource interface {
+	readAlertAnnotations(ctx context.Context, opts MigrationOptions, fn func([]*annotations.Item) error) error
+}
+
+type migrationTarget interface {
+	importAnnotations(ctx context.Context, items []*annotations.Item) error
+}
+
+// MigrateAlertAnnotations copies annotations created by alerts from one
+// storage backend to another and returns the number of copied annotations.
+// When copying from SQL with DeleteSource, each batch is deleted once it is
+// stored in the target, so the migration can be resumed after a failure.
+func MigrateAlertAnnotations(ctx context.Context, db db.DB, cfg *setting.Cfg, tagService tag.Service, opts MigrationOptions) (int64, error) {
+	if opts.From == opts.To {
+		return 0, errors.New("source and target backend must be different")
+	}
+	if opts.DeleteSource && opts.From != setting.AnnotationsBackendSQL {
+		return 0, errors.New("only annotations stored in SQL can be deleted")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultMigrationBatchSize
+	}
+
+	sqlStore := newXormStore(db, cfg, tagService)
+	stores := map[string]store{setting.AnnotationsBackendSQL: sqlStore}
+	if cfg.AnnotationsLoki.URL != "" {
+		stores[setting.AnnotationsBackendLoki] = newLokiStore(db, cfg)
+	}
+
+	source, ok := stores[opts.From].(migrationSource)
+	if !ok {
+		return 0, fmt.Errorf("unsupported source backend %q", opts.From)
+	}
+	target, ok := stores[opts.To].(migrationTarget)
+	if !ok {
+		return 0, fmt.Errorf("unsupported target backend %q", opts.To)
+	}
+
+	var migrated int64
+	err := source.readAlertAnnotations(ctx, opts, func(items []*annotations.Item) error {
+		if err := target.importAnnotations(ctx, items); err != nil {
+			return err
+		}
+		migrated += int64(len(items))
+
+		if !opts.DeleteSource {
+			return nil
+		}
+		ids := make([]int64, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.Id)
+		}
+		return sqlStore.deleteAnnotations(ctx, ids)
+	})
+	return migrated, err
+}
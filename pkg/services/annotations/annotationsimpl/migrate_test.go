@@ -0,0 +1,95 @@
+package annotationsimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationMigrateAlertAnnotations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sql := sqlstore.InitTestDB(t)
+	sql.Cfg.AnnotationMaximumTagsLength = 60
+	sql.Cfg.RBACEnabled = false
+	fake, server := newFakeLoki(t)
+	sql.Cfg.AnnotationsLoki = setting.AnnotationsLokiSettings{URL: server.URL}
+
+	tagService := tagimpl.ProvideService(sql, sql.Cfg)
+	sqlStore := newXormStore(sql, sql.Cfg, tagService)
+	lokiStore := newLokiStore(sql, sql.Cfg)
+	ctx := context.Background()
+
+	for i := int64(1); i <= 5; i++ {
+		require.NoError(t, sqlStore.Add(ctx, &annotations.Item{OrgId: 1, AlertId: i, Text: "alert", Epoch: i * 1000, Tags: []string{"alert"}}))
+	}
+	require.NoError(t, sqlStore.Add(ctx, &annotations.Item{OrgId: 2, AlertId: 6, Text: "other org", Epoch: 6000}))
+	require.NoError(t, sqlStore.Add(ctx, &annotations.Item{OrgId: 1, DashboardId: 1, Text: "deploy", Epoch: 1000}))
+
+	t.Run("Should validate options", func(t *testing.T) {
+		_, err := MigrateAlertAnnotations(ctx, sql, sql.Cfg, tagService, MigrationOptions{From: "sql", To: "sql"})
+		require.Error(t, err)
+		_, err = MigrateAlertAnnotations(ctx, sql, sql.Cfg, tagService, MigrationOptions{From: "loki", To: "sql", DeleteSource: true})
+		require.Error(t, err)
+		_, err = MigrateAlertAnnotations(ctx, sql, sql.Cfg, tagService, MigrationOptions{From: "sql", To: "elasticsearch"})
+		require.Error(t, err)
+	})
+
+	t.Run("Can migrate alert annotations from SQL to Loki", func(t *testing.T) {
+		migrated, err := MigrateAlertAnnotations(ctx, sql, sql.Cfg, tagService, MigrationOptions{
+			From:         setting.AnnotationsBackendSQL,
+			To:           setting.AnnotationsBackendLoki,
+			OrgID:        1,
+			DeleteSource: true,
+			BatchSize:    2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), migrated)
+		assert.Len(t, fake.entries, 5)
+
+		items, err := sqlStore.Get(ctx, &annotations.ItemQuery{OrgId: 1})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "deploy", items[0].Text)
+
+		items, err = sqlStore.Get(ctx, &annotations.ItemQuery{OrgId: 2})
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	})
+
+	t.Run("Can migrate alert annotations from Loki to SQL", func(t *testing.T) {
+		// a second annotation at the time of the last one, so that the last
+		// batch only has annotations read before
+		require.NoError(t, lokiStore.push(ctx, []*annotations.Item{{Id: 100, OrgId: 1, AlertId: 7, Text: "alert", Epoch: 5000, EpochEnd: 5000}}))
+
+		migrated, err := MigrateAlertAnnotations(ctx, sql, sql.Cfg, tagService, MigrationOptions{
+			From:      setting.AnnotationsBackendLoki,
+			To:        setting.AnnotationsBackendSQL,
+			Since:     time.UnixMilli(1),
+			BatchSize: 2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), migrated)
+
+		items, err := sqlStore.Get(ctx, &annotations.ItemQuery{OrgId: 1, Type: "alert"})
+		require.NoError(t, err)
+		require.Len(t, items, 6)
+		assert.Equal(t, int64(5000), items[1].Time)
+		assert.Equal(t, int64(5000), items[0].Time)
+		assert.Equal(t, []string{"alert"}, items[len(items)-1].Tags)
+
+		tags, err := sqlStore.GetTags(ctx, &annotations.TagsQuery{OrgID: 1, Tag: "alert"})
+		require.NoError(t, err)
+		require.Len(t, tags.Tags, 1)
+		assert.Equal(t, int64(5), tags.Tags[0].Count)
+	})
+}
@@ -80,6 +80,61 @@ func (r *xormRepositoryImpl) Add(ctx context.Context, item *annotations.Item) er
 	})
 }
 
+func (r *xormRepositoryImpl) AddMany(ctx context.Context, items []*annotations.Item) error {
+	created := timeNow().UnixNano() / int64(time.Millisecond)
+	uniqueTags := map[string]*tag.Tag{}
+	itemTags := make([][]*tag.Tag, len(items))
+	for i, item := range items {
+		tags := tag.ParseTagPairs(item.Tags)
+		item.Tags = tag.JoinTagPairs(tags)
+		item.Created = created
+		item.Updated = created
+		if item.Epoch == 0 {
+			item.Epoch = created
+		}
+		if err := r.validateItem(item); err != nil {
+			return err
+		}
+
+		// annotations share the tags, so that each tag is looked up once
+		for j, t := range tags {
+			key := t.Key + ":" + t.Value
+			if existing, ok := uniqueTags[key]; ok {
+				tags[j] = existing
+			} else {
+				uniqueTags[key] = t
+			}
+		}
+		itemTags[i] = tags
+	}
+
+	return r.db.InTransaction(ctx, func(ctx context.Context) error {
+		if len(uniqueTags) > 0 {
+			tags := make([]*tag.Tag, 0, len(uniqueTags))
+			for _, t := range uniqueTags {
+				tags = append(tags, t)
+			}
+			if _, err := r.tagService.EnsureTagsExist(ctx, tags); err != nil {
+				return err
+			}
+		}
+
+		return r.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			for i, item := range items {
+				if _, err := sess.Table("annotation").Insert(item); err != nil {
+					return err
+				}
+				for _, tag := range itemTags[i] {
+					if _, err := sess.Exec("INSERT INTO annotation_tag (annotation_id, tag_id) VALUES(?,?)", item.Id, tag.Id); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
 func (r *xormRepositoryImpl) Update(ctx context.Context, item *annotations.Item) error {
 	return r.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
 		var (
@@ -163,116 +218,193 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query *annotations.ItemQue
 				SELECT a.id from annotation a
 			`)
 
-		sql.WriteString(`WHERE a.org_id = ?`)
-		params = append(params, query.OrgId)
-
-		if query.AnnotationId != 0 {
-			// fmt.Print("annotation query")
-			sql.WriteString(` AND a.id = ?`)
-			params = append(params, query.AnnotationId)
+		filter, filterParams, err := r.filterSQL(query)
+		if err != nil {
+			return err
 		}
+		sql.WriteString(`WHERE ` + filter)
+		params = append(params, filterParams...)
 
-		if query.AlertId != 0 {
-			sql.WriteString(` AND a.alert_id = ?`)
-			params = append(params, query.AlertId)
+		if query.Limit == 0 {
+			query.Limit = 100
 		}
 
-		if query.DashboardId != 0 {
-			sql.WriteString(` AND a.dashboard_id = ?`)
-			params = append(params, query.DashboardId)
+		// order of ORDER BY arguments match the order of a sql index for performance
+		sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC" + r.db.GetDialect().Limit(query.Limit) + " ) dt on dt.id = annotation.id")
+		if err := sess.SQL(sql.String(), params...).Find(&items); err != nil {
+			items = nil
+			return err
 		}
+		return nil
+	},
+	)
 
-		if query.PanelId != 0 {
-			sql.WriteString(` AND a.panel_id = ?`)
-			params = append(params, query.PanelId)
-		}
+	return items, err
+}
 
-		if query.UserId != 0 {
-			sql.WriteString(` AND a.user_id = ?`)
-			params = append(params, query.UserId)
-		}
+func (r *xormRepositoryImpl) Aggregate(ctx context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	result := annotations.NewAggregateResult(query)
+	filter, params, err := r.filterSQL(&query.ItemQuery)
+	if err != nil {
+		return result, err
+	}
 
-		if query.From > 0 && query.To > 0 {
-			sql.WriteString(` AND a.epoch <= ? AND a.epoch_end >= ?`)
-			params = append(params, query.To, query.From)
-		}
+	if len(result.Buckets) == 0 {
+		return result, nil
+	}
 
-		if query.Type == "alert" {
-			sql.WriteString(` AND a.alert_id > 0`)
-		} else if query.Type == "annotation" {
-			sql.WriteString(` AND a.alert_id = 0`)
-		}
+	// annotations are counted per bucket and set of tags, annotations starting
+	// outside of the time range aren't counted
+	var rows []struct {
+		Bucket int64
+		Tags   []string
+		Total  int64
+	}
+	sql := `SELECT a.epoch - (a.epoch - ?) % ? AS bucket, a.tags, COUNT(*) AS total
+		FROM annotation a
+		WHERE a.epoch >= ? AND a.epoch <= ? AND ` + filter + `
+		GROUP BY bucket, a.tags`
+	params = append([]interface{}{query.From, query.Interval, query.From, query.To}, params...)
+	err = r.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(sql, params...).Find(&rows)
+	})
+	if err != nil {
+		return result, err
+	}
 
-		if len(query.Tags) > 0 {
-			keyValueFilters := []string{}
+	for _, row := range rows {
+		result.AddCount(row.Bucket, row.Tags, row.Total)
+	}
+	return result, nil
+}
 
-			tags := tag.ParseTagPairs(query.Tags)
-			for _, tag := range tags {
-				if tag.Value == "" {
-					keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ?)")
-					params = append(params, tag.Key)
-				} else {
-					keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ? AND tag."+r.db.GetDialect().Quote("value")+" = ?)")
-					params = append(params, tag.Key, tag.Value)
-				}
-			}
+// filterSQL returns the conditions, on the annotation table aliased as a, of
+// the annotations matching the query
+func (r *xormRepositoryImpl) filterSQL(query *annotations.ItemQuery) (string, []interface{}, error) {
+	var sql bytes.Buffer
+	params := make([]interface{}, 0)
 
-			if len(tags) > 0 {
-				tagsSubQuery := fmt.Sprintf(`
-			SELECT SUM(1) FROM annotation_tag at
-			INNER JOIN tag on tag.id = at.tag_id
-			WHERE at.annotation_id = a.id
-				AND (
-				%s
-				)
-		`, strings.Join(keyValueFilters, " OR "))
-
-				if query.MatchAny {
-					sql.WriteString(fmt.Sprintf(" AND (%s) > 0 ", tagsSubQuery))
-				} else {
-					sql.WriteString(fmt.Sprintf(" AND (%s) = %d ", tagsSubQuery, len(tags)))
-				}
-			}
-		}
+	sql.WriteString(`a.org_id = ?`)
+	params = append(params, query.OrgId)
 
-		if !ac.IsDisabled(r.cfg) {
-			acFilter, acArgs, err := getAccessControlFilter(query.SignedInUser)
-			if err != nil {
-				return err
+	if query.AnnotationId != 0 {
+		// fmt.Print("annotation query")
+		sql.WriteString(` AND a.id = ?`)
+		params = append(params, query.AnnotationId)
+	}
+
+	if query.AlertId != 0 {
+		sql.WriteString(` AND a.alert_id = ?`)
+		params = append(params, query.AlertId)
+	}
+
+	if query.DashboardId != 0 {
+		sql.WriteString(` AND a.dashboard_id = ?`)
+		params = append(params, query.DashboardId)
+	}
+
+	if query.PanelId != 0 {
+		sql.WriteString(` AND a.panel_id = ?`)
+		params = append(params, query.PanelId)
+	}
+
+	if query.UserId != 0 {
+		sql.WriteString(` AND a.user_id = ?`)
+		params = append(params, query.UserId)
+	}
+
+	// the columns of the time range bounds depend on how annotations are
+	// matched, see annotations.ItemQuery.MatchesTime
+	fromColumn, toColumn := "a.epoch_end", "a.epoch"
+	switch query.TimeMatch {
+	case annotations.TimeMatchStart:
+		fromColumn, toColumn = "a.epoch", "a.epoch"
+	case annotations.TimeMatchWithin:
+		fromColumn, toColumn = "a.epoch", "a.epoch_end"
+	}
+	if query.To > 0 {
+		sql.WriteString(` AND ` + toColumn + ` <= ?`)
+		params = append(params, query.To)
+	}
+	if query.From > 0 {
+		sql.WriteString(` AND ` + fromColumn + ` >= ?`)
+		params = append(params, query.From)
+	}
+
+	switch query.Type {
+	case "alert":
+		sql.WriteString(` AND a.alert_id > 0`)
+	case "annotation":
+		sql.WriteString(` AND a.alert_id = 0`)
+	case "region":
+		sql.WriteString(` AND a.epoch_end > a.epoch`)
+	}
+
+	if len(query.Tags) > 0 {
+		keyValueFilters := []string{}
+
+		tags := tag.ParseTagPairs(query.Tags)
+		for _, tag := range tags {
+			if tag.Value == "" {
+				keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ?)")
+				params = append(params, tag.Key)
+			} else {
+				keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ? AND tag."+r.db.GetDialect().Quote("value")+" = ?)")
+				params = append(params, tag.Key, tag.Value)
 			}
-			sql.WriteString(fmt.Sprintf(" AND (%s)", acFilter))
-			params = append(params, acArgs...)
 		}
 
-		if query.Limit == 0 {
-			query.Limit = 100
+		if len(tags) > 0 {
+			tagsSubQuery := fmt.Sprintf(`
+		SELECT SUM(1) FROM annotation_tag at
+		INNER JOIN tag on tag.id = at.tag_id
+		WHERE at.annotation_id = a.id
+			AND (
+			%s
+			)
+	`, strings.Join(keyValueFilters, " OR "))
+
+			if query.MatchAny {
+				sql.WriteString(fmt.Sprintf(" AND (%s) > 0 ", tagsSubQuery))
+			} else {
+				sql.WriteString(fmt.Sprintf(" AND (%s) = %d ", tagsSubQuery, len(tags)))
+			}
 		}
+	}
 
-		// order of ORDER BY arguments match the order of a sql index for performance
-		sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC" + r.db.GetDialect().Limit(query.Limit) + " ) dt on dt.id = annotation.id")
-		if err := sess.SQL(sql.String(), params...).Find(&items); err != nil {
-			items = nil
-			return err
+	if !ac.IsDisabled(r.cfg) {
+		acFilter, acArgs, err := getAccessControlFilter(query.SignedInUser)
+		if err != nil {
+			return "", nil, err
 		}
-		return nil
-	},
-	)
+		sql.WriteString(fmt.Sprintf(" AND (%s)", acFilter))
+		params = append(params, acArgs...)
+	}
 
-	return items, err
+	return sql.String(), params, nil
 }
 
-func getAccessControlFilter(user *user.SignedInUser) (string, []interface{}, error) {
+// readableAnnotationTypes returns the annotation types the user is allowed to read
+func readableAnnotationTypes(user *user.SignedInUser) (map[interface{}]struct{}, error) {
 	if user == nil || user.Permissions[user.OrgID] == nil {
-		return "", nil, errors.New("missing permissions")
+		return nil, errors.New("missing permissions")
 	}
 	scopes, has := user.Permissions[user.OrgID][ac.ActionAnnotationsRead]
 	if !has {
-		return "", nil, errors.New("missing permissions")
+		return nil, errors.New("missing permissions")
 	}
 	types, hasWildcardScope := ac.ParseScopes(ac.ScopeAnnotationsProvider.GetResourceScopeType(""), scopes)
 	if hasWildcardScope {
 		types = map[interface{}]struct{}{annotations.Dashboard.String(): {}, annotations.Organization.String(): {}}
 	}
+	return types, nil
+}
+
+func getAccessControlFilter(user *user.SignedInUser) (string, []interface{}, error) {
+	types, err := readableAnnotationTypes(user)
+	if err != nil {
+		return "", nil, err
+	}
 
 	var filters []string
 	var params []interface{}
@@ -467,3 +599,79 @@ func (r *xormRepositoryImpl) executeUntilDoneOrCancelled(ctx context.Context, sq
 		}
 	}
 }
+
+func (r *xormRepositoryImpl) readAlertAnnotations(ctx context.Context, opts MigrationOptions, fn func([]*annotations.Item) error) error {
+	var lastID int64
+	for {
+		items := make([]*annotations.Item, 0)
+		err := r.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			q := sess.Table("annotation").Where("alert_id > 0 AND id > ?", lastID)
+			if opts.OrgID != 0 {
+				q = q.And("org_id = ?", opts.OrgID)
+			}
+			if !opts.Since.IsZero() {
+				q = q.And("epoch >= ?", opts.Since.UnixMilli())
+			}
+			return q.Asc("id").Limit(opts.BatchSize).Find(&items)
+		})
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		lastID = items[len(items)-1].Id
+		if err := fn(items); err != nil {
+			return err
+		}
+	}
+}
+
+// importAnnotations stores annotations from another store, keeping their
+// creation and update times
+func (r *xormRepositoryImpl) importAnnotations(ctx context.Context, items []*annotations.Item) error {
+	return r.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, item := range items {
+			imported := *item
+			imported.Id = 0
+			if _, err := sess.Table("annotation").Insert(&imported); err != nil {
+				return err
+			}
+
+			if len(imported.Tags) == 0 {
+				continue
+			}
+			tags, err := r.tagService.EnsureTagsExist(ctx, tag.ParseTagPairs(imported.Tags))
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				if _, err := sess.Exec("INSERT INTO annotation_tag (annotation_id, tag_id) VALUES(?,?)", imported.Id, tag.Id); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *xormRepositoryImpl) deleteAnnotations(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	params := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		params = append(params, id)
+	}
+	in := "?" + strings.Repeat(",?", len(ids)-1)
+
+	return r.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec(append([]interface{}{"DELETE FROM annotation_tag WHERE annotation_id IN (" + in + ")"}, params...)...); err != nil {
+			return err
+		}
+		_, err := sess.Exec(append([]interface{}{"DELETE FROM annotation WHERE id IN (" + in + ")"}, params...)...)
+		return err
+	})
+}
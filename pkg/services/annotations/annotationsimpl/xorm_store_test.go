@@ -536,3 +536,117 @@ func TestIntegrationAnnotationListingWithRBAC(t *testing.T) {
 		})
 	}
 }
+
+func TestIntegrationAnnotationsBulkAndAggregate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sql := sqlstore.InitTestDB(t)
+	var maximumTagsLength int64 = 60
+	repo := xormRepositoryImpl{db: sql, cfg: setting.NewCfg(), log: log.New("annotation.test"), tagService: tagimpl.ProvideService(sql, sql.Cfg), maximumTagsLength: maximumTagsLength}
+	ctx := context.Background()
+
+	testUser := &user.SignedInUser{
+		OrgID: 1,
+		Permissions: map[int64]map[string][]string{
+			1: {
+				accesscontrol.ActionAnnotationsRead: []string{accesscontrol.ScopeAnnotationsAll},
+				dashboards.ActionDashboardsRead:     []string{dashboards.ScopeDashboardsAll},
+			},
+		},
+	}
+
+	items := []*annotations.Item{
+		{OrgId: 1, Text: "deploy", Epoch: 1000, EpochEnd: 1000, Tags: []string{"deploy", "env:prod"}},
+		{OrgId: 1, Text: "outage", Epoch: 1500, EpochEnd: 4000, Tags: []string{"outage", "env:prod"}},
+		{OrgId: 1, Text: "deploy", Epoch: 3500, EpochEnd: 3500, Tags: []string{"deploy"}},
+	}
+	require.NoError(t, repo.AddMany(ctx, items))
+	for _, item := range items {
+		require.NotZero(t, item.Id)
+	}
+
+	t.Run("Should save tags of all annotations", func(t *testing.T) {
+		result, err := repo.GetTags(ctx, &annotations.TagsQuery{OrgID: 1, Tag: "env", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Tags, 1)
+		assert.Equal(t, int64(2), result.Tags[0].Count)
+
+		found, err := repo.Get(ctx, &annotations.ItemQuery{OrgId: 1, Tags: []string{"deploy"}, SignedInUser: testUser})
+		require.NoError(t, err)
+		assert.Len(t, found, 2)
+	})
+
+	t.Run("Should save no annotations when one is invalid", func(t *testing.T) {
+		err := repo.AddMany(ctx, []*annotations.Item{
+			{OrgId: 1, Text: "valid", Epoch: 5000},
+			{OrgId: 1, Text: "invalid", Epoch: 6000, Tags: []string{strings.Repeat("a", 60)}},
+		})
+		require.Error(t, err)
+
+		found, err := repo.Get(ctx, &annotations.ItemQuery{OrgId: 1, SignedInUser: testUser})
+		require.NoError(t, err)
+		assert.Len(t, found, 3)
+	})
+
+	t.Run("Should match time range by time match", func(t *testing.T) {
+		for _, tc := range []struct {
+			timeMatch string
+			want      int
+		}{
+			{timeMatch: "", want: 2},
+			{timeMatch: annotations.TimeMatchOverlap, want: 2},
+			{timeMatch: annotations.TimeMatchStart, want: 1},
+			{timeMatch: annotations.TimeMatchWithin, want: 1},
+		} {
+			found, err := repo.Get(ctx, &annotations.ItemQuery{OrgId: 1, From: 2000, To: 5000, TimeMatch: tc.timeMatch, SignedInUser: testUser})
+			require.NoError(t, err)
+			assert.Len(t, found, tc.want, tc.timeMatch)
+		}
+	})
+
+	t.Run("Should find regions by type", func(t *testing.T) {
+		found, err := repo.Get(ctx, &annotations.ItemQuery{OrgId: 1, Type: "region", SignedInUser: testUser})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "outage", found[0].Text)
+	})
+
+	t.Run("Can aggregate annotations by start time", func(t *testing.T) {
+		query := &annotations.AggregateQuery{
+			ItemQuery: annotations.ItemQuery{OrgId: 1, From: 1000, To: 3999, TimeMatch: annotations.TimeMatchStart, SignedInUser: testUser},
+			Interval:  1000,
+		}
+		result, err := repo.Aggregate(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, result.Buckets, 3)
+
+		assert.Equal(t, int64(1000), result.Buckets[0].Time)
+		assert.Equal(t, int64(2), result.Buckets[0].Count)
+		assert.Equal(t, map[string]int64{"deploy": 1, "outage": 1, "env:prod": 2}, result.Buckets[0].Tags)
+		assert.Equal(t, int64(0), result.Buckets[1].Count)
+		assert.Equal(t, int64(1), result.Buckets[2].Count)
+	})
+
+	t.Run("Should count annotations with the same tags per bucket", func(t *testing.T) {
+		require.NoError(t, repo.AddMany(ctx, []*annotations.Item{
+			{OrgId: 1, Text: "deploy", Epoch: 4999, EpochEnd: 4999, Tags: []string{"deploy"}},
+			{OrgId: 1, Text: "deploy", Epoch: 5000, EpochEnd: 5000, Tags: []string{"deploy"}},
+			{OrgId: 1, Text: "deploy", Epoch: 5499, EpochEnd: 5499, Tags: []string{"deploy"}},
+			{OrgId: 1, Text: "deploy", Epoch: 5500, EpochEnd: 5500, Tags: []string{"deploy"}},
+		}))
+
+		query := &annotations.AggregateQuery{
+			ItemQuery: annotations.ItemQuery{OrgId: 1, From: 5000, To: 5999, TimeMatch: annotations.TimeMatchStart, SignedInUser: testUser},
+			Interval:  500,
+		}
+		result, err := repo.Aggregate(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, result.Buckets, 2)
+
+		assert.Equal(t, int64(2), result.Buckets[0].Count)
+		assert.Equal(t, map[string]int64{"deploy": 2}, result.Buckets[0].Tags)
+		assert.Equal(t, int64(5500), result.Buckets[1].Time)
+		assert.Equal(t, int64(1), result.Buckets[1].Count)
+	})
+}
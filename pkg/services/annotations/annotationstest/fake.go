@@ -46,6 +46,15 @@ func (repo *fakeAnnotationsRepo) Save(ctx context.Context, item *annotations.Ite
 	return nil
 }
 
+func (repo *fakeAnnotationsRepo) SaveMany(ctx context.Context, items []*annotations.Item) error {
+	for _, item := range items {
+		if err := repo.Save(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (repo *fakeAnnotationsRepo) Update(_ context.Context, item *annotations.Item) error {
 	return nil
 }
@@ -68,6 +77,17 @@ func (repo *fakeAnnotationsRepo) FindTags(_ context.Context, query *annotations.
 	return result, nil
 }
 
+func (repo *fakeAnnotationsRepo) Aggregate(_ context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	result := annotations.NewAggregateResult(query)
+	for _, item := range repo.annotations {
+		result.Add(item.Epoch, item.Tags)
+	}
+	return result, nil
+}
+
 func (repo *fakeAnnotationsRepo) Len() int {
 	repo.mtx.Lock()
 	defer repo.mtx.Unlock()
@@ -18,11 +18,120 @@ type ItemQuery struct {
 	Tags         []string `json:"tags"`
 	Type         string   `json:"type"`
 	MatchAny     bool     `json:"matchAny"`
+	TimeMatch    string   `json:"timeMatch"`
 	SignedInUser *user.SignedInUser
 
 	Limit int64 `json:"limit"`
 }
 
+// How annotations are matched against the time range of a query
+const (
+	// TimeMatchOverlap matches annotations intersecting the time range,
+	// including regions starting before it. This is the default.
+	TimeMatchOverlap = "overlap"
+	// TimeMatchStart matches annotations starting in the time range.
+	TimeMatchStart = "start"
+	// TimeMatchWithin matches annotations entirely in the time range.
+	TimeMatchWithin = "within"
+)
+
+// MatchesTime checks if an annotation from epoch to epochEnd matches the
+// time range of the query. A time range without from or to is open ended.
+func (q *ItemQuery) MatchesTime(epoch, epochEnd int64) bool {
+	afterFrom := func(t int64) bool { return q.From <= 0 || t >= q.From }
+	beforeTo := func(t int64) bool { return q.To <= 0 || t <= q.To }
+
+	switch q.TimeMatch {
+	case TimeMatchStart:
+		return afterFrom(epoch) && beforeTo(epoch)
+	case TimeMatchWithin:
+		return afterFrom(epoch) && beforeTo(epochEnd)
+	default:
+		return afterFrom(epochEnd) && beforeTo(epoch)
+	}
+}
+
+// AggregateQuery counts the annotations matching the query per tag and time
+// bucket of Interval milliseconds, starting at From.
+type AggregateQuery struct {
+	ItemQuery
+	Interval int64 `json:"interval"`
+}
+
+// MaxAggregateBuckets is the maximum number of time buckets of an aggregate
+// query
+const MaxAggregateBuckets = 1000
+
+func (q *AggregateQuery) Validate() error {
+	if q.From <= 0 || q.To < q.From || q.Interval <= 0 || (q.To-q.From)/q.Interval >= MaxAggregateBuckets {
+		return ErrInvalidAggregation
+	}
+	return nil
+}
+
+// AggregateResult is the result of an aggregate query.
+type AggregateResult struct {
+	Interval int64              `json:"interval"`
+	Buckets  []*AggregateBucket `json:"buckets"`
+}
+
+// NewAggregateResult returns a result with an empty bucket for each interval
+// of the time range of the query.
+func NewAggregateResult(query *AggregateQuery) AggregateResult {
+	if query.Validate() != nil {
+		return AggregateResult{Interval: query.Interval, Buckets: []*AggregateBucket{}}
+	}
+	buckets := make([]*AggregateBucket, 0, (query.To-query.From)/query.Interval+1)
+	for t := query.From; t <= query.To; t += query.Interval {
+		buckets = append(buckets, &AggregateBucket{Time: t, Tags: map[string]int64{}})
+	}
+	return AggregateResult{Interval: query.Interval, Buckets: buckets}
+}
+
+// Add counts an annotation starting at epoch in its bucket. Annotations
+// starting outside of the time range of the result are not counted.
+func (r AggregateResult) Add(epoch int64, tags []string) {
+	r.AddCount(epoch, tags, 1)
+}
+
+// AddCount counts count annotations with the same tags starting at epoch.
+func (r AggregateResult) AddCount(epoch int64, tags []string, count int64) {
+	if len(r.Buckets) == 0 || epoch < r.Buckets[0].Time {
+		return
+	}
+	i := (epoch - r.Buckets[0].Time) / r.Interval
+	if i >= int64(len(r.Buckets)) {
+		return
+	}
+
+	bucket := r.Buckets[i]
+	bucket.Count += count
+	for _, tag := range tags {
+		bucket.Tags[tag] += count
+	}
+}
+
+// Merge adds the counts of another result of the same query.
+func (r AggregateResult) Merge(other AggregateResult) {
+	for i, bucket := range other.Buckets {
+		if i >= len(r.Buckets) {
+			return
+		}
+		r.Buckets[i].Count += bucket.Count
+		for tag, count := range bucket.Tags {
+			r.Buckets[i].Tags[tag] += count
+		}
+	}
+}
+
+// AggregateBucket holds the number of annotations starting in a time bucket,
+// and the number of them per tag.
+type AggregateBucket struct {
+	Time  int64            `json:"time"`
+	Count int64            `json:"count"`
+	Tags  map[string]int64 `json:"tags"`
+}
+
 // TagsQuery is the query for a tags search.
 type TagsQuery struct {
 	OrgID int64  `json:"orgId"`
@@ -57,6 +166,7 @@ type GetAnnotationTagsResponse struct {
 type DeleteParams struct {
 	OrgId       int64
 	Id          int64
+	AlertId     int64 // set when deleting an alert annotation by ID
 	DashboardId int64
 	PanelId     int64
 }
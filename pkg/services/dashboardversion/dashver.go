@@ -8,4 +8,9 @@ type Service interface {
 	Get(context.Context, *GetDashboardVersionQuery) (*DashboardVersion, error)
 	DeleteExpired(context.Context, *DeleteExpiredVersionsCommand) error
 	List(context.Context, *ListDashboardVersionsQuery) ([]*DashboardVersionDTO, error)
+	Tag(context.Context, *TagDashboardVersionCommand) (*DashboardVersionTag, error)
+	DeleteTag(context.Context, *DeleteDashboardVersionTagCommand) error
+	GetRetentionPolicy(context.Context, *GetRetentionPolicyQuery) (*RetentionPolicy, error)
+	SetRetentionPolicy(context.Context, *SetRetentionPolicyCommand) (*RetentionPolicy, error)
+	DeleteRetentionPolicy(context.Context, *DeleteRetentionPolicyCommand) error
 }
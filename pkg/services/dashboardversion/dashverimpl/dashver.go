@@ -2,6 +2,10 @@ package dashverimpl
 
 import (
 	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/sqlstore/db"
@@ -11,6 +15,7 @@ import (
 const (
 	maxVersionsToDeletePerBatch = 100
 	maxVersionDeletionBatches   = 50
+	maxVersionTagLength         = 100
 )
 
 type Service struct {
@@ -27,6 +32,13 @@ func ProvideService(db db.DB) dashver.Service {
 }
 
 func (s *Service) Get(ctx context.Context, query *dashver.GetDashboardVersionQuery) (*dashver.DashboardVersion, error) {
+	if query.Tag != "" {
+		tag, err := s.store.GetTag(ctx, query.OrgID, query.DashboardID, query.Tag)
+		if err != nil {
+			return nil, err
+		}
+		query.Version = tag.Version
+	}
 	version, err := s.store.Get(ctx, query)
 	if err != nil {
 		return nil, err
@@ -35,14 +47,39 @@ func (s *Service) Get(ctx context.Context, query *dashver.GetDashboardVersionQue
 	return version, nil
 }
 
+// DeleteExpired deletes the versions expired by the retention policies of
+// folders and the versions exceeding the versions_to_keep setting of the
+// other dashboards. Tagged versions are never deleted.
 func (s *Service) DeleteExpired(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand) error {
+	policies, err := s.store.ListRetentionPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		createdBefore, ok := retentionCutoff(policy)
+		if !ok {
+			continue
+		}
+		err := s.deleteBatches(ctx, cmd, func(perBatch int) ([]interface{}, error) {
+			return s.store.GetPolicyBatch(ctx, policy, perBatch, createdBefore)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	versionsToKeep := setting.DashboardVersionsToKeep
 	if versionsToKeep < 1 {
 		versionsToKeep = 1
 	}
+	return s.deleteBatches(ctx, cmd, func(perBatch int) ([]interface{}, error) {
+		return s.store.GetBatch(ctx, cmd, perBatch, versionsToKeep)
+	})
+}
 
+func (s *Service) deleteBatches(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, getBatch func(int) ([]interface{}, error)) error {
 	for batch := 0; batch < maxVersionDeletionBatches; batch++ {
-		versionIdsToDelete, batchErr := s.store.GetBatch(ctx, cmd, maxVersionsToDeletePerBatch, versionsToKeep)
+		versionIdsToDelete, batchErr := getBatch(maxVersionsToDeletePerBatch)
 		if batchErr != nil {
 			return batchErr
 		}
@@ -65,10 +102,112 @@ func (s *Service) DeleteExpired(ctx context.Context, cmd *dashver.DeleteExpiredV
 	return nil
 }
 
+// retentionCutoff returns the time versions created before are expired, the
+// zero time if versions don't expire by age. False if the policy doesn't
+// expire any versions.
+func retentionCutoff(policy *dashver.RetentionPolicy) (time.Time, bool) {
+	if policy.MaxAge == "" {
+		return time.Time{}, policy.VersionsToKeep > 0
+	}
+	maxAge, err := gtime.ParseDuration(policy.MaxAge)
+	if err != nil || maxAge <= 0 {
+		return time.Time{}, policy.VersionsToKeep > 0
+	}
+	return time.Now().Add(-maxAge), true
+}
+
 // List all dashboard versions for the given dashboard ID.
 func (s *Service) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error) {
 	if query.Limit == 0 {
 		query.Limit = 1000
 	}
-	return s.store.List(ctx, query)
+	versions, err := s.store.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.store.ListTags(ctx, query.OrgID, query.DashboardID)
+	if err != nil {
+		return nil, err
+	}
+	tagsByVersion := make(map[int][]string, len(tags))
+	for _, tag := range tags {
+		tagsByVersion[tag.Version] = append(tagsByVersion[tag.Version], tag.Tag)
+	}
+	for _, version := range versions {
+		version.Tags = tagsByVersion[version.Version]
+		if version.Tags == nil {
+			version.Tags = []string{}
+		}
+	}
+	return versions, nil
+}
+
+// Tag pins a version of a dashboard, tagged versions are never deleted.
+func (s *Service) Tag(ctx context.Context, cmd *dashver.TagDashboardVersionCommand) (*dashver.DashboardVersionTag, error) {
+	tag := strings.TrimSpace(cmd.Tag)
+	if tag == "" || len(tag) > maxVersionTagLength {
+		return nil, dashver.ErrVersionTagInvalid
+	}
+
+	_, err := s.store.Get(ctx, &dashver.GetDashboardVersionQuery{
+		DashboardID: cmd.DashboardID,
+		OrgID:       cmd.OrgID,
+		Version:     cmd.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versionTag := &dashver.DashboardVersionTag{
+		OrgID:       cmd.OrgID,
+		DashboardID: cmd.DashboardID,
+		Version:     cmd.Version,
+		Tag:         tag,
+		Created:     time.Now(),
+		CreatedBy:   cmd.UserID,
+	}
+	if err := s.store.InsertTag(ctx, versionTag); err != nil {
+		return nil, err
+	}
+	return versionTag, nil
+}
+
+func (s *Service) DeleteTag(ctx context.Context, cmd *dashver.DeleteDashboardVersionTagCommand) error {
+	return s.store.DeleteTag(ctx, cmd)
+}
+
+func (s *Service) GetRetentionPolicy(ctx context.Context, query *dashver.GetRetentionPolicyQuery) (*dashver.RetentionPolicy, error) {
+	return s.store.GetRetentionPolicy(ctx, query)
+}
+
+// SetRetentionPolicy creates or replaces the retention policy of a folder
+func (s *Service) SetRetentionPolicy(ctx context.Context, cmd *dashver.SetRetentionPolicyCommand) (*dashver.RetentionPolicy, error) {
+	if cmd.VersionsToKeep < 0 {
+		return nil, dashver.ErrRetentionPolicyInvalid
+	}
+	if cmd.MaxAge != "" {
+		maxAge, err := gtime.ParseDuration(cmd.MaxAge)
+		if err != nil || maxAge <= 0 {
+			return nil, dashver.ErrRetentionPolicyInvalid
+		}
+	} else if cmd.VersionsToKeep == 0 {
+		return nil, dashver.ErrRetentionPolicyInvalid
+	}
+
+	policy := &dashver.RetentionPolicy{
+		OrgID:          cmd.OrgID,
+		FolderID:       cmd.FolderID,
+		VersionsToKeep: cmd.VersionsToKeep,
+		MaxAge:         cmd.MaxAge,
+		Updated:        time.Now(),
+	}
+	if err := s.store.UpsertRetentionPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *Service) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return s.store.DeleteRetentionPolicy(ctx, cmd)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
@@ -66,11 +67,87 @@ func TestListDashboardVersions(t *testing.T) {
 	})
 }
 
+func TestDashboardVersionTags(t *testing.T) {
+	dashboardVersionStore := newDashboardVersionStoreFake()
+	dashboardVersionService := Service{store: dashboardVersionStore}
+	dashboardVersionStore.ExpectedDashboardVersion = &dashver.DashboardVersion{ID: 11, Version: 2, Data: simplejson.New()}
+	dashboardVersionStore.ExpectedTags = []*dashver.DashboardVersionTag{{DashboardID: 1, Version: 2, Tag: "release-2024-05"}}
+
+	t.Run("Tag a dashboard version", func(t *testing.T) {
+		tag, err := dashboardVersionService.Tag(context.Background(), &dashver.TagDashboardVersionCommand{DashboardID: 1, Version: 2, Tag: " release-2024-05 "})
+		require.NoError(t, err)
+		require.Equal(t, "release-2024-05", tag.Tag)
+	})
+
+	t.Run("Reject an empty tag", func(t *testing.T) {
+		_, err := dashboardVersionService.Tag(context.Background(), &dashver.TagDashboardVersionCommand{DashboardID: 1, Version: 2, Tag: " "})
+		require.ErrorIs(t, err, dashver.ErrVersionTagInvalid)
+	})
+
+	t.Run("Get a dashboard version by tag", func(t *testing.T) {
+		query := &dashver.GetDashboardVersionQuery{DashboardID: 1, Tag: "release-2024-05"}
+		_, err := dashboardVersionService.Get(context.Background(), query)
+		require.NoError(t, err)
+		require.Equal(t, 2, query.Version)
+
+		_, err = dashboardVersionService.Get(context.Background(), &dashver.GetDashboardVersionQuery{DashboardID: 1, Tag: "missing"})
+		require.ErrorIs(t, err, dashver.ErrVersionTagNotFound)
+	})
+
+	t.Run("List dashboard versions with tags", func(t *testing.T) {
+		dashboardVersionStore.ExpectedListVersions = []*dashver.DashboardVersionDTO{{Version: 3}, {Version: 2}}
+		res, err := dashboardVersionService.List(context.Background(), &dashver.ListDashboardVersionsQuery{DashboardID: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{}, res[0].Tags)
+		require.Equal(t, []string{"release-2024-05"}, res[1].Tags)
+	})
+}
+
+func TestRetentionPolicies(t *testing.T) {
+	dashboardVersionStore := newDashboardVersionStoreFake()
+	dashboardVersionService := Service{store: dashboardVersionStore}
+
+	t.Run("Set a retention policy", func(t *testing.T) {
+		policy, err := dashboardVersionService.SetRetentionPolicy(context.Background(), &dashver.SetRetentionPolicyCommand{FolderID: 1, VersionsToKeep: 5, MaxAge: "90d"})
+		require.NoError(t, err)
+		require.Equal(t, 5, policy.VersionsToKeep)
+		require.Equal(t, "90d", policy.MaxAge)
+	})
+
+	t.Run("Reject invalid retention policies", func(t *testing.T) {
+		for _, cmd := range []dashver.SetRetentionPolicyCommand{
+			{FolderID: 1},
+			{FolderID: 1, VersionsToKeep: -1},
+			{FolderID: 1, MaxAge: "forever"},
+			{FolderID: 1, VersionsToKeep: 5, MaxAge: "0d"},
+		} {
+			_, err := dashboardVersionService.SetRetentionPolicy(context.Background(), &cmd)
+			require.ErrorIs(t, err, dashver.ErrRetentionPolicyInvalid)
+		}
+	})
+
+	t.Run("Retention cutoff", func(t *testing.T) {
+		cutoff, ok := retentionCutoff(&dashver.RetentionPolicy{VersionsToKeep: 5})
+		require.True(t, ok)
+		require.True(t, cutoff.IsZero())
+
+		cutoff, ok = retentionCutoff(&dashver.RetentionPolicy{MaxAge: "1d"})
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(-24*time.Hour), cutoff, time.Minute)
+
+		_, ok = retentionCutoff(&dashver.RetentionPolicy{})
+		require.False(t, ok)
+	})
+}
+
 type FakeDashboardVersionStore struct {
 	ExpectedDashboardVersion *dashver.DashboardVersion
 	ExptectedDeletedVersions int64
 	ExpectedVersions         []interface{}
 	ExpectedListVersions     []*dashver.DashboardVersionDTO
+	ExpectedTags             []*dashver.DashboardVersionTag
+	ExpectedPolicies         []*dashver.RetentionPolicy
+	ExpectedPolicyVersions   []interface{}
 	ExpectedError            error
 }
 
@@ -86,6 +163,10 @@ func (f *FakeDashboardVersionStore) GetBatch(ctx context.Context, cmd *dashver.D
 	return f.ExpectedVersions, f.ExpectedError
 }
 
+func (f *FakeDashboardVersionStore) GetPolicyBatch(ctx context.Context, policy *dashver.RetentionPolicy, perBatch int, createdBefore time.Time) ([]interface{}, error) {
+	return f.ExpectedPolicyVersions, f.ExpectedError
+}
+
 func (f *FakeDashboardVersionStore) DeleteBatch(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, versionIdsToDelete []interface{}) (int64, error) {
 	return f.ExptectedDeletedVersions, f.ExpectedError
 }
@@ -93,3 +174,43 @@ func (f *FakeDashboardVersionStore) DeleteBatch(ctx context.Context, cmd *dashve
 func (f *FakeDashboardVersionStore) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error) {
 	return f.ExpectedListVersions, f.ExpectedError
 }
+
+func (f *FakeDashboardVersionStore) GetTag(ctx context.Context, orgID, dashboardID int64, tag string) (*dashver.DashboardVersionTag, error) {
+	for _, t := range f.ExpectedTags {
+		if t.Tag == tag {
+			return t, f.ExpectedError
+		}
+	}
+	return nil, dashver.ErrVersionTagNotFound
+}
+
+func (f *FakeDashboardVersionStore) ListTags(ctx context.Context, orgID, dashboardID int64) ([]*dashver.DashboardVersionTag, error) {
+	return f.ExpectedTags, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) InsertTag(ctx context.Context, tag *dashver.DashboardVersionTag) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) DeleteTag(ctx context.Context, cmd *dashver.DeleteDashboardVersionTagCommand) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) ListRetentionPolicies(ctx context.Context) ([]*dashver.RetentionPolicy, error) {
+	return f.ExpectedPolicies, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) GetRetentionPolicy(ctx context.Context, query *dashver.GetRetentionPolicyQuery) (*dashver.RetentionPolicy, error) {
+	if len(f.ExpectedPolicies) == 0 {
+		return nil, dashver.ErrRetentionPolicyNotFound
+	}
+	return f.ExpectedPolicies[0], f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) UpsertRetentionPolicy(ctx context.Context, policy *dashver.RetentionPolicy) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return f.ExpectedError
+}
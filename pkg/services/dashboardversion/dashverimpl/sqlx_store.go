@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/sqlstore/session"
@@ -29,18 +30,24 @@ func (ss *sqlxStore) Get(ctx context.Context, query *dashver.GetDashboardVersion
 }
 
 func (ss *sqlxStore) GetBatch(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, perBatch int, versionsToKeep int) ([]interface{}, error) {
-	var versionIds []interface{}
-	versionIdsToDeleteQuery := `SELECT id
-	FROM dashboard_version, (
-		SELECT dashboard_id, count(version) as count, min(version) as min
-		FROM dashboard_version
-		GROUP BY dashboard_id
-	) AS vtd
-	WHERE dashboard_version.dashboard_id=vtd.dashboard_id
-	AND version < vtd.min + vtd.count - ?
-	LIMIT ?`
-	err := ss.sess.Get(ctx, &versionIds, versionIdsToDeleteQuery, versionsToKeep, perBatch)
-	return versionIds, err
+	return ss.selectIDs(ctx, defaultBatchSQL, versionsToKeep, perBatch)
+}
+
+func (ss *sqlxStore) GetPolicyBatch(ctx context.Context, policy *dashver.RetentionPolicy, perBatch int, createdBefore time.Time) ([]interface{}, error) {
+	query, args := policyBatchSQL(policy, perBatch, createdBefore)
+	return ss.selectIDs(ctx, query, args...)
+}
+
+func (ss *sqlxStore) selectIDs(ctx context.Context, query string, args ...interface{}) ([]interface{}, error) {
+	var ids []int64
+	if err := ss.sess.Select(ctx, &ids, query, args...); err != nil {
+		return nil, err
+	}
+	versionIds := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		versionIds = append(versionIds, id)
+	}
+	return versionIds, nil
 }
 
 // This service is used by cleanup which need to belong to the same transaction
@@ -61,6 +68,13 @@ func (ss *sqlxStore) DeleteBatch(ctx context.Context, cmd *dashver.DeleteExpired
 
 func (ss *sqlxStore) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error) {
 	var dashboardVersion []*dashver.DashboardVersionDTO
+	args := []interface{}{query.DashboardID, query.OrgID}
+	tagJoin, tagFilter := "", ""
+	if query.Tag != "" {
+		tagJoin = "INNER JOIN dashboard_version_tag ON dashboard_version_tag.dashboard_id = dashboard_version.dashboard_id AND dashboard_version_tag.version = dashboard_version.version"
+		tagFilter = "AND dashboard_version_tag.tag=?"
+		args = append(args, query.Tag)
+	}
 	qr := `SELECT dashboard_version.id,
 				dashboard_version.dashboard_id,
 				dashboard_version.parent_version,
@@ -72,11 +86,12 @@ func (ss *sqlxStore) List(ctx context.Context, query *dashver.ListDashboardVersi
 			FROM dashboard_version
 			LEFT JOIN "user" ON "user".id = dashboard_version.created_by
 			LEFT JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id
-			WHERE dashboard_version.dashboard_id=? AND dashboard.org_id=?
+			` + tagJoin + `
+			WHERE dashboard_version.dashboard_id=? AND dashboard.org_id=? ` + tagFilter + `
 			ORDER BY dashboard_version.version DESC
 			LIMIT ? OFFSET ?`
 
-	err := ss.sess.Select(ctx, &dashboardVersion, qr, query.DashboardID, query.OrgID, query.Limit, query.Start)
+	err := ss.sess.Select(ctx, &dashboardVersion, qr, append(args, query.Limit, query.Start)...)
 	if err != nil {
 		return nil, err
 	}
@@ -85,3 +100,92 @@ func (ss *sqlxStore) List(ctx context.Context, query *dashver.ListDashboardVersi
 	}
 	return dashboardVersion, nil
 }
+
+func (ss *sqlxStore) GetTag(ctx context.Context, orgID, dashboardID int64, tag string) (*dashver.DashboardVersionTag, error) {
+	var versionTag dashver.DashboardVersionTag
+	err := ss.sess.Get(ctx, &versionTag, "SELECT * FROM dashboard_version_tag WHERE org_id=? AND dashboard_id=? AND tag=?", orgID, dashboardID, tag)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, dashver.ErrVersionTagNotFound
+	}
+	return &versionTag, err
+}
+
+func (ss *sqlxStore) ListTags(ctx context.Context, orgID, dashboardID int64) ([]*dashver.DashboardVersionTag, error) {
+	tags := make([]*dashver.DashboardVersionTag, 0)
+	err := ss.sess.Select(ctx, &tags, "SELECT * FROM dashboard_version_tag WHERE org_id=? AND dashboard_id=? ORDER BY tag", orgID, dashboardID)
+	return tags, err
+}
+
+func (ss *sqlxStore) InsertTag(ctx context.Context, tag *dashver.DashboardVersionTag) error {
+	return ss.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		var count int64
+		err := tx.Get(ctx, &count, "SELECT COUNT(*) FROM dashboard_version_tag WHERE dashboard_id=? AND tag=?", tag.DashboardID, tag.Tag)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return dashver.ErrVersionTagExists
+		}
+		tag.ID, err = tx.ExecWithReturningId(ctx,
+			`INSERT INTO dashboard_version_tag (org_id, dashboard_id, version, tag, created, created_by) VALUES (?, ?, ?, ?, ?, ?)`,
+			tag.OrgID, tag.DashboardID, tag.Version, tag.Tag, tag.Created, tag.CreatedBy)
+		return err
+	})
+}
+
+func (ss *sqlxStore) DeleteTag(ctx context.Context, cmd *dashver.DeleteDashboardVersionTagCommand) error {
+	res, err := ss.sess.Exec(ctx, "DELETE FROM dashboard_version_tag WHERE org_id=? AND dashboard_id=? AND tag=?", cmd.OrgID, cmd.DashboardID, cmd.Tag)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return dashver.ErrVersionTagNotFound
+	}
+	return nil
+}
+
+func (ss *sqlxStore) ListRetentionPolicies(ctx context.Context) ([]*dashver.RetentionPolicy, error) {
+	policies := make([]*dashver.RetentionPolicy, 0)
+	err := ss.sess.Select(ctx, &policies, "SELECT * FROM dashboard_version_retention ORDER BY id")
+	return policies, err
+}
+
+func (ss *sqlxStore) GetRetentionPolicy(ctx context.Context, query *dashver.GetRetentionPolicyQuery) (*dashver.RetentionPolicy, error) {
+	var policy dashver.RetentionPolicy
+	err := ss.sess.Get(ctx, &policy, "SELECT * FROM dashboard_version_retention WHERE org_id=? AND folder_id=?", query.OrgID, query.FolderID)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, dashver.ErrRetentionPolicyNotFound
+	}
+	return &policy, err
+}
+
+func (ss *sqlxStore) UpsertRetentionPolicy(ctx context.Context, policy *dashver.RetentionPolicy) error {
+	return ss.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		var id int64
+		err := tx.Get(ctx, &id, "SELECT id FROM dashboard_version_retention WHERE org_id=? AND folder_id=?", policy.OrgID, policy.FolderID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if id > 0 {
+			policy.ID = id
+			_, err = tx.Exec(ctx, "UPDATE dashboard_version_retention SET versions_to_keep=?, max_age=?, updated=? WHERE id=?",
+				policy.VersionsToKeep, policy.MaxAge, policy.Updated, id)
+			return err
+		}
+		policy.ID, err = tx.ExecWithReturningId(ctx,
+			`INSERT INTO dashboard_version_retention (org_id, folder_id, versions_to_keep, max_age, updated) VALUES (?, ?, ?, ?, ?)`,
+			policy.OrgID, policy.FolderID, policy.VersionsToKeep, policy.MaxAge, policy.Updated)
+		return err
+	})
+}
+
+func (ss *sqlxStore) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	res, err := ss.sess.Exec(ctx, "DELETE FROM dashboard_version_retention WHERE org_id=? AND folder_id=?", cmd.OrgID, cmd.FolderID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return dashver.ErrRetentionPolicyNotFound
+	}
+	return nil
+}
@@ -13,3 +13,11 @@ func TestIntegrationSQLxGetDashboardVersion(t *testing.T) {
 		}
 	})
 }
+
+func TestIntegrationSQLxDashboardVersionRetention(t *testing.T) {
+	testIntegrationDashboardVersionRetention(t, func(ss *sqlstore.SQLStore) store {
+		return &sqlxStore{
+			sess: ss.GetSqlxSession(),
+		}
+	})
+}
@@ -2,6 +2,8 @@ package dashverimpl
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 )
@@ -9,6 +11,66 @@ import (
 type store interface {
 	Get(context.Context, *dashver.GetDashboardVersionQuery) (*dashver.DashboardVersion, error)
 	GetBatch(context.Context, *dashver.DeleteExpiredVersionsCommand, int, int) ([]interface{}, error)
+	GetPolicyBatch(context.Context, *dashver.RetentionPolicy, int, time.Time) ([]interface{}, error)
 	DeleteBatch(context.Context, *dashver.DeleteExpiredVersionsCommand, []interface{}) (int64, error)
 	List(context.Context, *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error)
+	GetTag(ctx context.Context, orgID, dashboardID int64, tag string) (*dashver.DashboardVersionTag, error)
+	ListTags(ctx context.Context, orgID, dashboardID int64) ([]*dashver.DashboardVersionTag, error)
+	InsertTag(context.Context, *dashver.DashboardVersionTag) error
+	DeleteTag(context.Context, *dashver.DeleteDashboardVersionTagCommand) error
+	ListRetentionPolicies(context.Context) ([]*dashver.RetentionPolicy, error)
+	GetRetentionPolicy(context.Context, *dashver.GetRetentionPolicyQuery) (*dashver.RetentionPolicy, error)
+	UpsertRetentionPolicy(context.Context, *dashver.RetentionPolicy) error
+	DeleteRetentionPolicy(context.Context, *dashver.DeleteRetentionPolicyCommand) error
+}
+
+// the versions of dashboards without a retention policy, which the default
+// number of versions is kept for
+const defaultBatchSQL = `SELECT id
+	FROM dashboard_version, (
+		SELECT dashboard_id, count(version) as count, min(version) as min
+		FROM dashboard_version
+		GROUP BY dashboard_id
+	) AS vtd
+	WHERE dashboard_version.dashboard_id=vtd.dashboard_id
+	AND version < vtd.min + vtd.count - ?
+	AND NOT EXISTS (
+		SELECT 1 FROM dashboard_version_tag
+		WHERE dashboard_version_tag.dashboard_id = dashboard_version.dashboard_id AND dashboard_version_tag.version = dashboard_version.version
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM dashboard
+		INNER JOIN dashboard_version_retention ON dashboard_version_retention.org_id = dashboard.org_id AND dashboard_version_retention.folder_id = dashboard.folder_id
+		WHERE dashboard.id = dashboard_version.dashboard_id
+	)
+	LIMIT ?`
+
+// policyBatchSQL returns the query for the expired versions of the
+// dashboards in the folder of a retention policy
+func policyBatchSQL(policy *dashver.RetentionPolicy, perBatch int, createdBefore time.Time) (string, []interface{}) {
+	sql := `SELECT dashboard_version.id
+	FROM dashboard_version
+	INNER JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id
+	WHERE dashboard.org_id = ? AND dashboard.folder_id = ?
+	AND dashboard_version.version < dashboard.version
+	AND NOT EXISTS (
+		SELECT 1 FROM dashboard_version_tag
+		WHERE dashboard_version_tag.dashboard_id = dashboard_version.dashboard_id AND dashboard_version_tag.version = dashboard_version.version
+	)`
+	args := []interface{}{policy.OrgID, policy.FolderID}
+
+	var expired []string
+	if policy.VersionsToKeep > 0 {
+		expired = append(expired, `(
+		SELECT count(*) FROM dashboard_version newer
+		WHERE newer.dashboard_id = dashboard_version.dashboard_id AND newer.version > dashboard_version.version
+	) >= ?`)
+		args = append(args, policy.VersionsToKeep)
+	}
+	if !createdBefore.IsZero() {
+		expired = append(expired, `dashboard_version.created < ?`)
+		args = append(args, createdBefore)
+	}
+	sql += "\n\tAND (" + strings.Join(expired, " OR ") + ")\n\tLIMIT ?"
+	return sql, append(args, perBatch)
 }
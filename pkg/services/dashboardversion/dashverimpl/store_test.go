@@ -2,6 +2,7 @@ package dashverimpl
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 
@@ -244,3 +245,149 @@ func updateTestDashboard(t *testing.T, sqlStore *sqlstore.SQLStore, dashboard *m
 
 	require.NoError(t, err)
 }
+
+func testIntegrationDashboardVersionRetention(t *testing.T, fn getStore) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := sqlstore.InitTestDB(t)
+	dashVerStore := fn(ss)
+	ctx := context.Background()
+
+	const folderID int64 = 5
+	old := time.Now().Add(-100 * 24 * time.Hour)
+
+	// versions 1-3 are 100 days old, 4-6 are new
+	inFolder := insertTestDashboard(t, ss, "in folder", 1, folderID, false)
+	inFolderIDs := insertTestVersions(t, ss, inFolder, 6, func(version int) time.Time {
+		if version <= 3 {
+			return old
+		}
+		return time.Now()
+	})
+	inGeneral := insertTestDashboard(t, ss, "in general", 1, 0, false)
+	inGeneralIDs := insertTestVersions(t, ss, inGeneral, 4, func(int) time.Time { return time.Now() })
+
+	for _, dash := range []*models.Dashboard{inFolder, inGeneral} {
+		err := dashVerStore.InsertTag(ctx, &dashver.DashboardVersionTag{OrgID: 1, DashboardID: dash.Id, Version: 1, Tag: "release", Created: time.Now()})
+		require.NoError(t, err)
+	}
+
+	t.Run("Tags", func(t *testing.T) {
+		err := dashVerStore.InsertTag(ctx, &dashver.DashboardVersionTag{OrgID: 1, DashboardID: inFolder.Id, Version: 2, Tag: "release", Created: time.Now()})
+		require.ErrorIs(t, err, dashver.ErrVersionTagExists)
+
+		tag, err := dashVerStore.GetTag(ctx, 1, inFolder.Id, "release")
+		require.NoError(t, err)
+		assert.Equal(t, 1, tag.Version)
+
+		res, err := dashVerStore.List(ctx, &dashver.ListDashboardVersionsQuery{DashboardID: inFolder.Id, OrgID: 1, Tag: "release", Limit: 1000})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, 1, res[0].Version)
+
+		err = dashVerStore.InsertTag(ctx, &dashver.DashboardVersionTag{OrgID: 1, DashboardID: inFolder.Id, Version: 6, Tag: "latest", Created: time.Now()})
+		require.NoError(t, err)
+		tags, err := dashVerStore.ListTags(ctx, 1, inFolder.Id)
+		require.NoError(t, err)
+		require.Len(t, tags, 2)
+
+		err = dashVerStore.DeleteTag(ctx, &dashver.DeleteDashboardVersionTagCommand{OrgID: 1, DashboardID: inFolder.Id, Tag: "latest"})
+		require.NoError(t, err)
+		err = dashVerStore.DeleteTag(ctx, &dashver.DeleteDashboardVersionTagCommand{OrgID: 1, DashboardID: inFolder.Id, Tag: "latest"})
+		require.ErrorIs(t, err, dashver.ErrVersionTagNotFound)
+		_, err = dashVerStore.GetTag(ctx, 1, inFolder.Id, "latest")
+		require.ErrorIs(t, err, dashver.ErrVersionTagNotFound)
+	})
+
+	policy := &dashver.RetentionPolicy{OrgID: 1, FolderID: folderID, VersionsToKeep: 2, Updated: time.Now()}
+	t.Run("Retention policies", func(t *testing.T) {
+		_, err := dashVerStore.GetRetentionPolicy(ctx, &dashver.GetRetentionPolicyQuery{OrgID: 1, FolderID: folderID})
+		require.ErrorIs(t, err, dashver.ErrRetentionPolicyNotFound)
+
+		require.NoError(t, dashVerStore.UpsertRetentionPolicy(ctx, &dashver.RetentionPolicy{OrgID: 1, FolderID: folderID, MaxAge: "30d", Updated: time.Now()}))
+		require.NoError(t, dashVerStore.UpsertRetentionPolicy(ctx, policy))
+
+		res, err := dashVerStore.GetRetentionPolicy(ctx, &dashver.GetRetentionPolicyQuery{OrgID: 1, FolderID: folderID})
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.VersionsToKeep)
+		assert.Equal(t, "", res.MaxAge)
+
+		policies, err := dashVerStore.ListRetentionPolicies(ctx)
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+	})
+
+	t.Run("Expired versions of dashboards with a retention policy", func(t *testing.T) {
+		ids, err := dashVerStore.GetPolicyBatch(ctx, policy, 100, time.Time{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{inFolderIDs[2], inFolderIDs[3], inFolderIDs[4]}, toInt64s(ids))
+
+		byAge := &dashver.RetentionPolicy{OrgID: 1, FolderID: folderID, MaxAge: "30d"}
+		ids, err = dashVerStore.GetPolicyBatch(ctx, byAge, 100, time.Now().Add(-30*24*time.Hour))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{inFolderIDs[2], inFolderIDs[3]}, toInt64s(ids))
+	})
+
+	t.Run("Expired versions of dashboards without a retention policy", func(t *testing.T) {
+		ids, err := dashVerStore.GetBatch(ctx, &dashver.DeleteExpiredVersionsCommand{}, 100, 1)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{inGeneralIDs[2], inGeneralIDs[3]}, toInt64s(ids))
+	})
+
+	t.Run("Delete a retention policy", func(t *testing.T) {
+		err := dashVerStore.DeleteRetentionPolicy(ctx, &dashver.DeleteRetentionPolicyCommand{OrgID: 1, FolderID: folderID})
+		require.NoError(t, err)
+		err = dashVerStore.DeleteRetentionPolicy(ctx, &dashver.DeleteRetentionPolicyCommand{OrgID: 1, FolderID: folderID})
+		require.ErrorIs(t, err, dashver.ErrRetentionPolicyNotFound)
+	})
+}
+
+// insertTestVersions adds versions up to the given version to a dashboard
+// inserted with insertTestDashboard, and returns the version IDs by version
+func insertTestVersions(t *testing.T, sqlStore *sqlstore.SQLStore, dash *models.Dashboard, versions int, created func(int) time.Time) map[int]int64 {
+	t.Helper()
+	ids := make(map[int]int64, versions)
+	err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		first := dashver.DashboardVersion{}
+		if _, err := sess.Where("dashboard_id=? AND version=1", dash.Id).Get(&first); err != nil {
+			return err
+		}
+		ids[1] = first.ID
+		if _, err := sess.Exec("UPDATE dashboard_version SET created=? WHERE id=?", created(1), first.ID); err != nil {
+			return err
+		}
+
+		for v := 2; v <= versions; v++ {
+			dashVersion := &dashver.DashboardVersion{
+				DashboardID:   dash.Id,
+				ParentVersion: v - 1,
+				Version:       v,
+				Created:       created(v),
+				Data:          dash.Data,
+			}
+			if _, err := sess.Insert(dashVersion); err != nil {
+				return err
+			}
+			ids[v] = dashVersion.ID
+		}
+		_, err := sess.Exec("UPDATE dashboard SET version=? WHERE id=?", versions, dash.Id)
+		return err
+	})
+	require.NoError(t, err)
+	return ids
+}
+
+func toInt64s(ids []interface{}) []int64 {
+	res := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		switch v := id.(type) {
+		case int64:
+			res = append(res, v)
+		case []byte:
+			n, _ := strconv.ParseInt(string(v), 10, 64)
+			res = append(res, n)
+		}
+	}
+	return res
+}
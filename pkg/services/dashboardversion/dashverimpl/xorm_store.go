@@ -3,6 +3,7 @@ package dashverimpl
 import (
 	"context"
 	"strings"
+	"time"
 
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -40,22 +41,21 @@ func (ss *sqlStore) Get(ctx context.Context, query *dashver.GetDashboardVersionQ
 func (ss *sqlStore) GetBatch(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, perBatch int, versionsToKeep int) ([]interface{}, error) {
 	var versionIds []interface{}
 	err := ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
-		versionIdsToDeleteQuery := `SELECT id
-			FROM dashboard_version, (
-				SELECT dashboard_id, count(version) as count, min(version) as min
-				FROM dashboard_version
-				GROUP BY dashboard_id
-			) AS vtd
-			WHERE dashboard_version.dashboard_id=vtd.dashboard_id
-			AND version < vtd.min + vtd.count - ?
-			LIMIT ?`
-
-		err := sess.SQL(versionIdsToDeleteQuery, versionsToKeep, perBatch).Find(&versionIds)
+		err := sess.SQL(defaultBatchSQL, versionsToKeep, perBatch).Find(&versionIds)
 		return err
 	})
 	return versionIds, err
 }
 
+func (ss *sqlStore) GetPolicyBatch(ctx context.Context, policy *dashver.RetentionPolicy, perBatch int, createdBefore time.Time) ([]interface{}, error) {
+	var versionIds []interface{}
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		query, args := policyBatchSQL(policy, perBatch, createdBefore)
+		return sess.SQL(query, args...).Find(&versionIds)
+	})
+	return versionIds, err
+}
+
 func (ss *sqlStore) DeleteBatch(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, versionIdsToDelete []interface{}) (int64, error) {
 	var deleted int64
 	err := ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
@@ -75,7 +75,7 @@ func (ss *sqlStore) DeleteBatch(ctx context.Context, cmd *dashver.DeleteExpiredV
 func (ss *sqlStore) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error) {
 	var dashboardVersion []*dashver.DashboardVersionDTO
 	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
-		err := sess.Table("dashboard_version").
+		sess.Table("dashboard_version").
 			Select(`dashboard_version.id,
 				dashboard_version.dashboard_id,
 				dashboard_version.parent_version,
@@ -88,8 +88,12 @@ func (ss *sqlStore) List(ctx context.Context, query *dashver.ListDashboardVersio
 				ss.dialect.Quote("user")+`.login as created_by`).
 			Join("LEFT", ss.dialect.Quote("user"), `dashboard_version.created_by = `+ss.dialect.Quote("user")+`.id`).
 			Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
-			Where("dashboard_version.dashboard_id=? AND dashboard.org_id=?", query.DashboardID, query.OrgID).
-			OrderBy("dashboard_version.version DESC").
+			Where("dashboard_version.dashboard_id=? AND dashboard.org_id=?", query.DashboardID, query.OrgID)
+		if query.Tag != "" {
+			sess.Join("INNER", "dashboard_version_tag", `dashboard_version_tag.dashboard_id = dashboard_version.dashboard_id AND dashboard_version_tag.version = dashboard_version.version`).
+				Where("dashboard_version_tag.tag=?", query.Tag)
+		}
+		err := sess.OrderBy("dashboard_version.version DESC").
 			Limit(query.Limit, query.Start).
 			Find(&dashboardVersion)
 		if err != nil {
@@ -106,3 +110,112 @@ func (ss *sqlStore) List(ctx context.Context, query *dashver.ListDashboardVersio
 	}
 	return dashboardVersion, nil
 }
+
+func (ss *sqlStore) GetTag(ctx context.Context, orgID, dashboardID int64, tag string) (*dashver.DashboardVersionTag, error) {
+	var versionTag dashver.DashboardVersionTag
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id=? AND dashboard_id=? AND tag=?", orgID, dashboardID, tag).Get(&versionTag)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return dashver.ErrVersionTagNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &versionTag, nil
+}
+
+func (ss *sqlStore) ListTags(ctx context.Context, orgID, dashboardID int64) ([]*dashver.DashboardVersionTag, error) {
+	tags := make([]*dashver.DashboardVersionTag, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id=? AND dashboard_id=?", orgID, dashboardID).OrderBy("tag").Find(&tags)
+	})
+	return tags, err
+}
+
+func (ss *sqlStore) InsertTag(ctx context.Context, tag *dashver.DashboardVersionTag) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("dashboard_id=? AND tag=?", tag.DashboardID, tag.Tag).Exist(&dashver.DashboardVersionTag{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return dashver.ErrVersionTagExists
+		}
+		_, err = sess.Insert(tag)
+		return err
+	})
+}
+
+func (ss *sqlStore) DeleteTag(ctx context.Context, cmd *dashver.DeleteDashboardVersionTagCommand) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM dashboard_version_tag WHERE org_id=? AND dashboard_id=? AND tag=?", cmd.OrgID, cmd.DashboardID, cmd.Tag)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return dashver.ErrVersionTagNotFound
+		}
+		return nil
+	})
+}
+
+func (ss *sqlStore) ListRetentionPolicies(ctx context.Context) ([]*dashver.RetentionPolicy, error) {
+	policies := make([]*dashver.RetentionPolicy, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.OrderBy("id").Find(&policies)
+	})
+	return policies, err
+}
+
+func (ss *sqlStore) GetRetentionPolicy(ctx context.Context, query *dashver.GetRetentionPolicyQuery) (*dashver.RetentionPolicy, error) {
+	var policy dashver.RetentionPolicy
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("org_id=? AND folder_id=?", query.OrgID, query.FolderID).Get(&policy)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return dashver.ErrRetentionPolicyNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (ss *sqlStore) UpsertRetentionPolicy(ctx context.Context, policy *dashver.RetentionPolicy) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var existing dashver.RetentionPolicy
+		has, err := sess.Where("org_id=? AND folder_id=?", policy.OrgID, policy.FolderID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if has {
+			policy.ID = existing.ID
+			_, err = sess.ID(existing.ID).Cols("versions_to_keep", "max_age", "updated").Update(policy)
+			return err
+		}
+		_, err = sess.Insert(policy)
+		return err
+	})
+}
+
+func (ss *sqlStore) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM dashboard_version_retention WHERE org_id=? AND folder_id=?", cmd.OrgID, cmd.FolderID)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return dashver.ErrRetentionPolicyNotFound
+		}
+		return nil
+	})
+}
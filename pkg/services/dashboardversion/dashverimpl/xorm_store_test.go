@@ -14,3 +14,12 @@ func TestIntegrationXORMGetDashboardVersion(t *testing.T) {
 		}
 	})
 }
+
+func TestIntegrationXORMDashboardVersionRetention(t *testing.T) {
+	testIntegrationDashboardVersionRetention(t, func(ss *sqlstore.SQLStore) store {
+		return &sqlStore{
+			db:      ss,
+			dialect: ss.GetDialect(),
+		}
+	})
+}
@@ -10,6 +10,8 @@ type FakeDashboardVersionService struct {
 	ExpectedDashboardVersion     *dashver.DashboardVersion
 	ExpectedDashboardVersions    []*dashver.DashboardVersion
 	ExpectedListDashboarVersions []*dashver.DashboardVersionDTO
+	ExpectedTag                  *dashver.DashboardVersionTag
+	ExpectedRetentionPolicy      *dashver.RetentionPolicy
 	counter                      int
 	ExpectedError                error
 }
@@ -33,3 +35,23 @@ func (f *FakeDashboardVersionService) DeleteExpired(ctx context.Context, cmd *da
 func (f *FakeDashboardVersionService) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error) {
 	return f.ExpectedListDashboarVersions, f.ExpectedError
 }
+
+func (f *FakeDashboardVersionService) Tag(ctx context.Context, cmd *dashver.TagDashboardVersionCommand) (*dashver.DashboardVersionTag, error) {
+	return f.ExpectedTag, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionService) DeleteTag(ctx context.Context, cmd *dashver.DeleteDashboardVersionTagCommand) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionService) GetRetentionPolicy(ctx context.Context, query *dashver.GetRetentionPolicyQuery) (*dashver.RetentionPolicy, error) {
+	return f.ExpectedRetentionPolicy, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionService) SetRetentionPolicy(ctx context.Context, cmd *dashver.SetRetentionPolicyCommand) (*dashver.RetentionPolicy, error) {
+	return f.ExpectedRetentionPolicy, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionService) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return f.ExpectedError
+}
@@ -10,6 +10,11 @@ import (
 var (
 	ErrDashboardVersionNotFound = errors.New("dashboard version not found")
 	ErrNoVersionsForDashboardID = errors.New("no dashboard versions found for the given DashboardId")
+	ErrVersionTagExists         = errors.New("a dashboard version with the same tag already exists")
+	ErrVersionTagNotFound       = errors.New("dashboard version tag not found")
+	ErrVersionTagInvalid        = errors.New("dashboard version tag is invalid")
+	ErrRetentionPolicyNotFound  = errors.New("dashboard version retention policy not found")
+	ErrRetentionPolicyInvalid   = errors.New("dashboard version retention policy must keep versions by count or age")
 )
 
 type DashboardVersion struct {
@@ -30,6 +35,8 @@ type GetDashboardVersionQuery struct {
 	DashboardID int64
 	OrgID       int64
 	Version     int
+	// Get the version with the tag instead of Version
+	Tag string
 }
 
 type DeleteExpiredVersionsCommand struct {
@@ -40,8 +47,10 @@ type ListDashboardVersionsQuery struct {
 	DashboardID  int64
 	DashboardUID string
 	OrgID        int64
-	Limit        int
-	Start        int
+	// Only list versions with the tag
+	Tag   string
+	Limit int
+	Start int
 }
 
 type DashboardVersionDTO struct {
@@ -56,6 +65,8 @@ type DashboardVersionDTO struct {
 	// but in reality it will always be set, when database is not corrupted.
 	CreatedBy *string `json:"createdBy" db:"created_by_login"`
 	Message   string  `json:"message" db:"message"`
+	// Tagged versions are never deleted
+	Tags []string `json:"tags" xorm:"-" db:"-"`
 }
 
 // DashboardVersionMeta extends the dashboard version model with the names
@@ -73,3 +84,65 @@ type DashboardVersionMeta struct {
 	Data          *simplejson.Json `json:"data"`
 	CreatedBy     string           `json:"createdBy"`
 }
+
+// DashboardVersionTag pins a dashboard version, tagged versions are never
+// deleted. A tag names one version of a dashboard.
+type DashboardVersionTag struct {
+	ID          int64     `json:"id" xorm:"pk autoincr 'id'" db:"id"`
+	OrgID       int64     `json:"orgId" xorm:"org_id" db:"org_id"`
+	DashboardID int64     `json:"dashboardId" xorm:"dashboard_id" db:"dashboard_id"`
+	Version     int       `json:"version" db:"version"`
+	Tag         string    `json:"tag" db:"tag"`
+	Created     time.Time `json:"created" db:"created"`
+	CreatedBy   int64     `json:"createdBy" db:"created_by"`
+}
+
+type TagDashboardVersionCommand struct {
+	DashboardID int64
+	OrgID       int64
+	Version     int
+	Tag         string
+	UserID      int64
+}
+
+type DeleteDashboardVersionTagCommand struct {
+	DashboardID int64
+	OrgID       int64
+	Tag         string
+}
+
+// RetentionPolicy configures which versions of the dashboards in a folder
+// are kept, instead of the versions_to_keep setting. Versions are deleted
+// when there are more than VersionsToKeep newer versions or when they are
+// older than MaxAge. The latest and tagged versions are always kept.
+type RetentionPolicy struct {
+	ID       int64 `json:"-" xorm:"pk autoincr 'id'" db:"id"`
+	OrgID    int64 `json:"orgId" xorm:"org_id" db:"org_id"`
+	FolderID int64 `json:"folderId" xorm:"folder_id" db:"folder_id"`
+	// 0 keeps any number of versions
+	VersionsToKeep int `json:"versionsToKeep" db:"versions_to_keep"`
+	// Duration like 90d, empty keeps versions of any age
+	MaxAge  string    `json:"maxAge" db:"max_age"`
+	Updated time.Time `json:"updated" db:"updated"`
+}
+
+func (p RetentionPolicy) TableName() string {
+	return "dashboard_version_retention"
+}
+
+type GetRetentionPolicyQuery struct {
+	OrgID    int64
+	FolderID int64
+}
+
+type SetRetentionPolicyCommand struct {
+	OrgID          int64
+	FolderID       int64
+	VersionsToKeep int
+	MaxAge         string
+}
+
+type DeleteRetentionPolicyCommand struct {
+	OrgID    int64
+	FolderID int64
+}
@@ -0,0 +1,507 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// BackupFormatVersion is written to the manifest of every backup, archives
+// with a newer version can't be restored
+const BackupFormatVersion = 1
+
+const (
+	backupManifestFile = "manifest.json"
+	backupSecretsFile  = "secrets.enc"
+	backupTablesDir    = "tables"
+)
+
+// maxBackupArchiveSize limits the decompressed size of all files read from an
+// archive
+var maxBackupArchiveSize int64 = 1 << 30
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	Version        int            `json:"version"`
+	GrafanaVersion string         `json:"grafanaVersion"`
+	OrgID          int64          `json:"orgId"`
+	OrgName        string         `json:"orgName"`
+	Created        int64          `json:"created"`
+	Encrypted      bool           `json:"encrypted"` // secrets are included
+	Tables         map[string]int `json:"tables"`    // rows by table
+}
+
+// backupTableData is written for every table, values are converted to the
+// column type on restore so backups can be restored to another database
+type backupTableData struct {
+	Columns []backupColumn  `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+type backupColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // int, float, bool, time or text
+}
+
+// backupSecrets holds the decrypted secrets, it's only written encrypted
+// with the passphrase of the backup
+type backupSecrets struct {
+	DataSources map[string]map[string]string `json:"datasources"` // by data source UID
+	Receivers   map[string]map[string]string `json:"receivers"`   // by contact point UID
+}
+
+// BackupService writes and restores point-in-time backups of an org. The
+// backups include the SQL rows of the org, IDs and UIDs are remapped when the
+// backup is restored.
+type BackupService struct {
+	logger            log.Logger
+	sql               *sqlstore.SQLStore
+	features          featuremgmt.FeatureToggles
+	secretsService    secrets.Service
+	secretsStore      secretskvs.SecretsKVStore
+	datasourceService datasources.DataSourceService
+}
+
+func ProvideBackupService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles, secretsService secrets.Service,
+	secretsStore secretskvs.SecretsKVStore, datasourceService datasources.DataSourceService) *BackupService {
+	return &BackupService{
+		logger:            log.New("backup_service"),
+		sql:               sql,
+		features:          features,
+		secretsService:    secretsService,
+		secretsStore:      secretsStore,
+		datasourceService: datasourceService,
+	}
+}
+
+// Backup writes a tar.gz archive of the org to w. Secrets are only included
+// when a passphrase is set.
+func (s *BackupService) Backup(ctx context.Context, orgID int64, passphrase string, w io.Writer) (*BackupManifest, error) {
+	manifest := &BackupManifest{
+		Version:        BackupFormatVersion,
+		GrafanaVersion: setting.BuildVersion,
+		OrgID:          orgID,
+		Created:        time.Now().UnixMilli(),
+		Encrypted:      passphrase != "",
+		Tables:         make(map[string]int, len(backupTables)),
+	}
+	sec := &backupSecrets{
+		DataSources: make(map[string]map[string]string),
+		Receivers:   make(map[string]map[string]string),
+	}
+
+	tables, orgName, err := s.readTables(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	manifest.OrgName = orgName
+	for _, t := range backupTables {
+		data := tables[t.name]
+		if t.backup != nil {
+			if err := t.backup(ctx, s, orgID, data, sec); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", t.name, err)
+			}
+		}
+		manifest.Tables[t.name] = len(data.Rows)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeArchiveJSON(tw, backupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	for _, t := range backupTables {
+		if err := writeArchiveJSON(tw, path.Join(backupTablesDir, t.name+".json"), tables[t.name]); err != nil {
+			return nil, err
+		}
+	}
+	if passphrase != "" {
+		b, err := json.Marshal(sec)
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := util.Encrypt(b, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeArchiveFile(tw, backupSecretsFile, encrypted); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, gz.Close()
+}
+
+// readTables reads the rows of the org from all backup tables in one read only
+// transaction, so the backup is point-in-time. Repeatable read makes the
+// transaction a snapshot in PostgreSQL too, it's the default of MySQL and
+// SQLite transactions are serializable.
+func (s *BackupService) readTables(ctx context.Context, orgID int64) (map[string]*backupTableData, string, error) {
+	tables := make(map[string]*backupTableData, len(backupTables))
+	var orgName string
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		tx, err := sess.DB().DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		// nothing to commit
+		defer func() { _ = tx.Rollback() }()
+
+		err = tx.QueryRowContext(ctx, s.rebind(fmt.Sprintf("SELECT name FROM %s WHERE id = ?", s.sql.Quote("org"))), orgID).Scan(&orgName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrOrgNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, t := range backupTables {
+			data, err := s.readTable(ctx, tx, t, orgID)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", t.name, err)
+			}
+			tables[t.name] = data
+		}
+		return nil
+	})
+	return tables, orgName, err
+}
+
+// rebind replaces the "?" placeholders of a query with the ones of the database
+func (s *BackupService) rebind(query string) string {
+	return sqlx.Rebind(sqlx.BindType(s.sql.GetDialect().DriverName()), query)
+}
+
+// readTable reads the rows of the org from a table, every "?" in the filter
+// is replaced with the org ID
+func (s *BackupService) readTable(ctx context.Context, tx *sql.Tx, t *backupTable, orgID int64) (*backupTableData, error) {
+	where := t.where
+	if where == "" {
+		where = "org_id = ?"
+	}
+	args := make([]interface{}, strings.Count(where, "?"))
+	for i := range args {
+		args[i] = orgID
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY %s", s.sql.Quote(t.name), where, t.orderBy())
+
+	rows, err := tx.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.Warn("failed to close rows", "table", t.name, "err", err)
+		}
+	}()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	data := &backupTableData{
+		Rows: make([][]interface{}, 0),
+	}
+	keep := make([]bool, len(columnTypes))
+	for i, ct := range columnTypes {
+		if t.drops(ct.Name()) {
+			continue
+		}
+		keep[i] = true
+		data.Columns = append(data.Columns, backupColumn{
+			Name: ct.Name(),
+			Type: t.columnType(ct.Name(), ct.DatabaseTypeName()),
+		})
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columnTypes))
+		dest := make([]interface{}, len(columnTypes))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := make([]interface{}, 0, len(data.Columns))
+		for i, v := range values {
+			if !keep[i] {
+				continue
+			}
+			cv, err := encodeBackupValue(data.Columns[len(row)].Type, v)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", columnTypes[i].Name(), err)
+			}
+			row = append(row, cv)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data, rows.Err()
+}
+
+// column returns the index of a column, or -1 if the table doesn't have it
+func (d *backupTableData) column(name string) int {
+	for i, c := range d.Columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// stringValue returns a text column of a row
+func (d *backupTableData) stringValue(row []interface{}, name string) string {
+	if i := d.column(name); i >= 0 && i < len(row) {
+		if s, ok := row[i].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func (d *backupTableData) boolValue(row []interface{}, name string) bool {
+	if i := d.column(name); i >= 0 && i < len(row) {
+		if b, ok := row[i].(bool); ok {
+			return b
+		}
+	}
+	return false
+}
+
+func (d *backupTableData) setValue(row []interface{}, name string, v interface{}) {
+	if i := d.column(name); i >= 0 && i < len(row) {
+		row[i] = v
+	}
+}
+
+// encodeBackupValue converts a value read from the database to a JSON value
+// of the column type
+func encodeBackupValue(typ string, v interface{}) (interface{}, error) {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch typ {
+	case "int":
+		switch n := v.(type) {
+		case int64:
+			return n, nil
+		case string:
+			return strconv.ParseInt(n, 10, 64)
+		}
+	case "float":
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int64:
+			return float64(n), nil
+		case string:
+			return strconv.ParseFloat(n, 64)
+		}
+	case "bool":
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case int64:
+			return b != 0, nil
+		case string:
+			return b == "1" || b == "true" || b == "t", nil
+		}
+	case "time":
+		switch t := v.(type) {
+		case time.Time:
+			return t.UTC().Format(time.RFC3339Nano), nil
+		case string:
+			parsed, err := parseBackupTime(t)
+			if err != nil {
+				return nil, err
+			}
+			return parsed.UTC().Format(time.RFC3339Nano), nil
+		}
+	case "text":
+		return fmt.Sprintf("%v", v), nil
+	}
+	return nil, fmt.Errorf("unexpected %T value for %s column", v, typ)
+}
+
+// decodeBackupValue converts a JSON value read from an archive to a value
+// which can be written to any database
+func decodeBackupValue(typ string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch typ {
+	case "int":
+		if n, ok := v.(json.Number); ok {
+			return n.Int64()
+		}
+		if n, ok := v.(int64); ok {
+			return n, nil
+		}
+	case "float":
+		if n, ok := v.(json.Number); ok {
+			return n.Float64()
+		}
+		if n, ok := v.(float64); ok {
+			return n, nil
+		}
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case "time":
+		if s, ok := v.(string); ok {
+			return parseBackupTime(s)
+		}
+	case "text":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %T value for %s column", v, typ)
+}
+
+var backupTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+func parseBackupTime(s string) (time.Time, error) {
+	for _, layout := range backupTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// columnTypeFromDatabase maps the database type of a column to the type
+// written to the archive
+func columnTypeFromDatabase(databaseType string) string {
+	databaseType = strings.ToUpper(databaseType)
+	switch {
+	case strings.Contains(databaseType, "BOOL"):
+		return "bool"
+	case strings.Contains(databaseType, "INT"):
+		return "int"
+	case strings.Contains(databaseType, "DATE"), strings.Contains(databaseType, "TIME"):
+		return "time"
+	case strings.Contains(databaseType, "REAL"), strings.Contains(databaseType, "FLOAT"),
+		strings.Contains(databaseType, "DOUBLE"), strings.Contains(databaseType, "NUMERIC"),
+		strings.Contains(databaseType, "DECIMAL"):
+		return "float"
+	}
+	return "text"
+}
+
+func writeArchiveJSON(tw *tar.Writer, name string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeArchiveFile(tw, name, b)
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, body []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(body)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(body)
+	return err
+}
+
+// readArchive reads all files of a backup archive
+func readArchive(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup archive: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	// one byte over the limit tells a too large archive from one which is
+	// exactly as large as the limit
+	limited := &io.LimitedReader{R: gz, N: maxBackupArchiveSize + 1}
+	tooLarge := fmt.Errorf("backup archive is larger than %d bytes", maxBackupArchiveSize)
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(limited)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if limited.N <= 0 {
+			return nil, tooLarge
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Size >= limited.N {
+			return nil, tooLarge
+		}
+		b, err := io.ReadAll(tr)
+		if limited.N <= 0 {
+			return nil, tooLarge
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup archive: %w", err)
+		}
+		files[path.Clean(hdr.Name)] = b
+	}
+	return files, nil
+}
+
+// readArchiveJSON decodes a JSON file, numbers are kept as json.Number so
+// large IDs aren't rounded
+func readArchiveJSON(files map[string][]byte, name string, v interface{}) error {
+	b, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid backup archive: missing %s", name)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid backup archive: %s: %w", name, err)
+	}
+	return nil
+}
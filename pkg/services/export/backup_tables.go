@@ -0,0 +1,556 @@
+package export
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// backupTable describes how the rows of a table are backed up and restored
+type backupTable struct {
+	name  string
+	where string // filter, every "?" is the org ID. Defaults to org_id = ?
+	order string // defaults to id
+	org   string // column with the org ID, defaults to org_id. "-" if there is none
+	drop  []string
+	bools []string // boolean columns stored as integers
+
+	// IDs of rows in other tables which are replaced with the IDs of the
+	// restored rows. Rows with an unknown ID are skipped, unless the
+	// reference is optional. Zero and negative IDs are kept.
+	refs    map[string]string
+	optRefs map[string]string
+
+	uid     string   // regenerated when restoring with new UIDs
+	uidRefs []string // UIDs of other rows
+	json    []string // JSON with UIDs of other rows
+
+	// modify the rows read from the database
+	backup func(ctx context.Context, s *BackupService, orgID int64, data *backupTableData, sec *backupSecrets) error
+	// modify a row before it's restored, false skips the row
+	restore func(r *restoreHelper, data *backupTableData, row []interface{}) (bool, error)
+	// called with the new ID after a row is restored
+	afterRestore func(r *restoreHelper, data *backupTableData, row []interface{}, id int64) error
+	// replaces the insert, returns the ID of the new or matching row or 0
+	// to skip the row
+	insert func(r *restoreHelper, data *backupTableData, row []interface{}) (int64, error)
+}
+
+// backupTables are backed up and restored in this order, rows are restored
+// after the rows they reference
+var backupTables = []*backupTable{
+	{
+		name:   "user",
+		where:  "id IN (SELECT user_id FROM org_user WHERE org_id = ?)",
+		org:    "-",
+		drop:   []string{"password", "salt", "rands"},
+		bools:  []string{"is_admin", "email_verified", "is_disabled", "is_service_account"},
+		insert: restoreUser,
+	},
+	{
+		name: "org_user",
+		refs: map[string]string{"user_id": "user"},
+	},
+	{
+		name: "team",
+	},
+	{
+		name:  "team_member",
+		bools: []string{"external"},
+		refs:  map[string]string{"team_id": "team", "user_id": "user"},
+	},
+	{
+		// folders are restored before the dashboards in them
+		name:    "dashboard",
+		order:   "is_folder DESC, id",
+		bools:   []string{"is_folder", "has_acl", "is_public"},
+		refs:    map[string]string{"folder_id": "dashboard"},
+		optRefs: map[string]string{"created_by": "user", "updated_by": "user"},
+		uid:     "uid",
+		json:    []string{"data"},
+	},
+	{
+		name:    "dashboard_version",
+		where:   "dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ?)",
+		org:     "-",
+		refs:    map[string]string{"dashboard_id": "dashboard"},
+		optRefs: map[string]string{"created_by": "user"},
+		json:    []string{"data"},
+	},
+	{
+		name:  "dashboard_tag",
+		where: "dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ?)",
+		org:   "-",
+		refs:  map[string]string{"dashboard_id": "dashboard"},
+	},
+	{
+		name: "dashboard_acl",
+		refs: map[string]string{"dashboard_id": "dashboard", "user_id": "user", "team_id": "team"},
+	},
+	{
+		name:    "library_element",
+		refs:    map[string]string{"folder_id": "dashboard"},
+		optRefs: map[string]string{"created_by": "user", "updated_by": "user"},
+		uid:     "uid",
+		json:    []string{"model"},
+	},
+	{
+		name:    "library_element_connection",
+		where:   "element_id IN (SELECT id FROM library_element WHERE org_id = ?)",
+		org:     "-",
+		refs:    map[string]string{"element_id": "library_element", "connection_id": "dashboard"},
+		optRefs: map[string]string{"created_by": "user"},
+	},
+	{
+		name:         "data_source",
+		bools:        []string{"basic_auth", "is_default", "with_credentials", "read_only"},
+		uid:          "uid",
+		backup:       backupDataSourceSecrets,
+		restore:      restoreDataSourceSecrets,
+		afterRestore: storeDataSourceSecrets,
+	},
+	{
+		name:    "alert_rule",
+		uid:     "uid",
+		uidRefs: []string{"namespace_uid", "dashboard_uid"},
+		json:    []string{"data"},
+	},
+	{
+		name:    "alert_rule_version",
+		where:   "rule_org_id = ?",
+		org:     "rule_org_id",
+		uidRefs: []string{"rule_uid", "rule_namespace_uid"},
+		json:    []string{"data"},
+	},
+	{
+		// only the current configuration is kept
+		name:    "alert_configuration",
+		where:   "org_id = ? AND id = (SELECT MAX(id) FROM alert_configuration WHERE org_id = ?)",
+		bools:   []string{"default"},
+		backup:  backupReceiverSecrets,
+		restore: restoreReceiverSecrets,
+	},
+	{
+		name:    "annotation",
+		refs:    map[string]string{"dashboard_id": "dashboard"},
+		optRefs: map[string]string{"alert_id": "alert_rule", "user_id": "user"},
+	},
+	{
+		// tags are shared by all orgs
+		name:   "tag",
+		where:  "id IN (SELECT tag_id FROM annotation_tag WHERE annotation_id IN (SELECT id FROM annotation WHERE org_id = ?))",
+		org:    "-",
+		insert: restoreTag,
+	},
+	{
+		name:  "annotation_tag",
+		where: "annotation_id IN (SELECT id FROM annotation WHERE org_id = ?)",
+		org:   "-",
+		refs:  map[string]string{"annotation_id": "annotation", "tag_id": "tag"},
+	},
+	{
+		name:    "preferences",
+		refs:    map[string]string{"user_id": "user", "team_id": "team"},
+		optRefs: map[string]string{"home_dashboard_id": "dashboard"},
+	},
+	{
+		name: "playlist",
+		uid:  "uid",
+	},
+	{
+		name:    "playlist_item",
+		where:   "playlist_id IN (SELECT id FROM playlist WHERE org_id = ?)",
+		org:     "-",
+		refs:    map[string]string{"playlist_id": "playlist"},
+		restore: restorePlaylistItem,
+	},
+	{
+		name:    "role",
+		bools:   []string{"hidden"},
+		restore: restoreRole,
+	},
+	{
+		name:    "permission",
+		where:   "role_id IN (SELECT id FROM role WHERE org_id = ?)",
+		org:     "-",
+		refs:    map[string]string{"role_id": "role"},
+		restore: restorePermission,
+	},
+	{
+		name: "user_role",
+		refs: map[string]string{"user_id": "user", "role_id": "role"},
+	},
+	{
+		name: "team_role",
+		refs: map[string]string{"team_id": "team", "role_id": "role"},
+	},
+	{
+		name: "builtin_role",
+		refs: map[string]string{"role_id": "role"},
+	},
+}
+
+func (t *backupTable) orderBy() string {
+	if t.order != "" {
+		return t.order
+	}
+	return "id"
+}
+
+func (t *backupTable) orgColumn() string {
+	switch t.org {
+	case "":
+		return "org_id"
+	case "-":
+		return ""
+	}
+	return t.org
+}
+
+func (t *backupTable) drops(column string) bool {
+	for _, c := range t.drop {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *backupTable) columnType(column, databaseType string) string {
+	for _, c := range t.bools {
+		if c == column {
+			return "bool"
+		}
+	}
+	return columnTypeFromDatabase(databaseType)
+}
+
+// restoreUser matches users by login and email, missing users are created
+// with a random password. Restoring fails when another user has the login or
+// the email, as the backup user could be mapped to the wrong account. Service
+// accounts are not restored.
+func restoreUser(r *restoreHelper, data *backupTableData, row []interface{}) (int64, error) {
+	if data.boolValue(row, "is_service_account") {
+		return 0, nil
+	}
+
+	login := data.stringValue(row, "login")
+	email := data.stringValue(row, "email")
+	var existing []struct {
+		ID    int64 `xorm:"id"`
+		Login string
+		Email string
+	}
+	err := r.sess.Table("user").
+		Where("login = ? OR email = ?", login, email).
+		Cols("id", "login", "email").
+		Find(&existing)
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range existing {
+		if !strings.EqualFold(u.Login, login) || !strings.EqualFold(u.Email, email) {
+			return 0, fmt.Errorf("user %s <%s> conflicts with existing user %s <%s>", login, email, u.Login, u.Email)
+		}
+	}
+	if len(existing) > 0 {
+		return existing[0].ID, nil
+	}
+
+	data.setValue(row, "is_admin", false)
+	data.setValue(row, "org_id", r.orgID)
+	cols, vals, err := r.rowValues(data, row)
+	if err != nil {
+		return 0, err
+	}
+	password, err := util.GetRandomString(32)
+	if err != nil {
+		return 0, err
+	}
+	salt, err := util.GetRandomString(10)
+	if err != nil {
+		return 0, err
+	}
+	rands, err := util.GetRandomString(10)
+	if err != nil {
+		return 0, err
+	}
+	encoded, err := util.EncodePassword(password, salt)
+	if err != nil {
+		return 0, err
+	}
+	cols = append(cols, "password", "salt", "rands")
+	vals = append(vals, encoded, salt, rands)
+	return r.insertRow("user", cols, vals)
+}
+
+// restoreTag returns the matching tag or creates it
+func restoreTag(r *restoreHelper, data *backupTableData, row []interface{}) (int64, error) {
+	var id int64
+	_, err := r.sess.Table("tag").
+		Where(r.quote("key")+" = ? AND "+r.quote("value")+" = ?", data.stringValue(row, "key"), data.stringValue(row, "value")).
+		Cols("id").
+		Get(&id)
+	if err != nil || id > 0 {
+		return id, err
+	}
+	return r.insertData("tag", data, row)
+}
+
+// restorePlaylistItem replaces the dashboard in dashboard playlist items
+func restorePlaylistItem(r *restoreHelper, data *backupTableData, row []interface{}) (bool, error) {
+	value := data.stringValue(row, "value")
+	switch data.stringValue(row, "type") {
+	case "dashboard_by_id":
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false, nil
+		}
+		newID, ok := r.ids["dashboard"][id]
+		if !ok {
+			return false, nil
+		}
+		data.setValue(row, "value", strconv.FormatInt(newID, 10))
+	case "dashboard_by_uid":
+		data.setValue(row, "value", r.newUID(value))
+	}
+	return true, nil
+}
+
+// restoreRole creates roles with a new UID, the UIDs are unique in all orgs.
+// Managed roles of users and teams include their ID in the name.
+func restoreRole(r *restoreHelper, data *backupTableData, row []interface{}) (bool, error) {
+	data.setValue(row, "uid", util.GenerateShortUID())
+
+	name := data.stringValue(row, "name")
+	parts := strings.Split(name, ":")
+	if len(parts) == 4 && parts[0] == "managed" && (parts[1] == "users" || parts[1] == "teams") {
+		table := "user"
+		if parts[1] == "teams" {
+			table = "team"
+		}
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return false, nil
+		}
+		newID, ok := r.ids[table][id]
+		if !ok {
+			return false, nil
+		}
+		parts[2] = strconv.FormatInt(newID, 10)
+		data.setValue(row, "name", strings.Join(parts, ":"))
+	}
+	return true, nil
+}
+
+// scopeTables are the tables of the IDs in permission scopes
+var scopeTables = map[string]string{
+	"users":           "user",
+	"serviceaccounts": "user",
+	"teams":           "team",
+	"dashboards":      "dashboard",
+	"folders":         "dashboard",
+	"datasources":     "data_source",
+}
+
+// restorePermission replaces the IDs and UIDs in scopes, permissions for
+// rows which weren't restored are skipped
+func restorePermission(r *restoreHelper, data *backupTableData, row []interface{}) (bool, error) {
+	parts := strings.Split(data.stringValue(row, "scope"), ":")
+	if len(parts) != 3 || parts[2] == "*" {
+		return true, nil
+	}
+	switch parts[1] {
+	case "id":
+		table, ok := scopeTables[parts[0]]
+		if !ok {
+			return true, nil
+		}
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return true, nil
+		}
+		newID, ok := r.ids[table][id]
+		if !ok {
+			return false, nil
+		}
+		parts[2] = strconv.FormatInt(newID, 10)
+	case "uid":
+		parts[2] = r.newUID(parts[2])
+	default:
+		return true, nil
+	}
+	data.setValue(row, "scope", strings.Join(parts, ":"))
+	return true, nil
+}
+
+// backupDataSourceSecrets moves the decrypted secure JSON data and legacy
+// passwords of data sources to the secrets of the backup
+func backupDataSourceSecrets(ctx context.Context, s *BackupService, orgID int64, data *backupTableData, sec *backupSecrets) error {
+	for _, row := range data.Rows {
+		id, _ := row[data.column("id")].(int64)
+		query := &datasources.GetDataSourceQuery{Id: id, OrgId: orgID}
+		if err := s.datasourceService.GetDataSource(ctx, query); err != nil {
+			return err
+		}
+		ds := query.Result
+		values, err := s.datasourceService.DecryptedValues(ctx, ds)
+		if err != nil {
+			return err
+		}
+
+		// passwords saved before secure JSON data
+		if password := data.stringValue(row, "password"); password != "" && values["password"] == "" {
+			values["password"] = password
+		}
+		if password := data.stringValue(row, "basic_auth_password"); password != "" && values["basicAuthPassword"] == "" {
+			values["basicAuthPassword"] = password
+		}
+		if len(values) > 0 {
+			sec.DataSources[ds.Uid] = values
+		}
+
+		data.setValue(row, "password", "")
+		data.setValue(row, "basic_auth_password", "")
+		data.setValue(row, "secure_json_data", nil)
+	}
+	return nil
+}
+
+// restoreDataSourceSecrets encrypts the secure JSON data with the secrets of
+// this instance
+func restoreDataSourceSecrets(r *restoreHelper, data *backupTableData, row []interface{}) (bool, error) {
+	values := r.secrets.DataSources[data.stringValue(row, "uid")]
+	if len(values) == 0 || r.s.features.IsEnabled(featuremgmt.FlagDisableSecretsCompatibility) {
+		return true, nil
+	}
+	encrypted, err := r.s.secretsService.EncryptJsonData(r.ctx, values, secrets.WithoutScope())
+	if err != nil {
+		return false, err
+	}
+	b, err := json.Marshal(encrypted)
+	if err != nil {
+		return false, err
+	}
+	data.setValue(row, "secure_json_data", string(b))
+	return true, nil
+}
+
+func storeDataSourceSecrets(r *restoreHelper, data *backupTableData, row []interface{}, id int64) error {
+	values := r.secrets.DataSources[data.stringValue(row, "uid")]
+	if len(values) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return r.s.secretsStore.Set(r.ctx, r.orgID, data.stringValue(row, "name"), secretskvs.DataSourceSecretType, string(b))
+}
+
+// backupReceiverSecrets moves the decrypted secure settings of contact
+// points to the secrets of the backup
+func backupReceiverSecrets(ctx context.Context, s *BackupService, orgID int64, data *backupTableData, sec *backupSecrets) error {
+	for _, row := range data.Rows {
+		config, err := updateReceiverConfigs(data.stringValue(row, "alertmanager_configuration"), func(uid string, rc map[string]interface{}) error {
+			settings, _ := rc["secureSettings"].(map[string]interface{})
+			values := make(map[string]string, len(settings))
+			for k, v := range settings {
+				encoded, _ := v.(string)
+				encrypted, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					return err
+				}
+				decrypted, err := s.secretsService.Decrypt(ctx, encrypted)
+				if err != nil {
+					return err
+				}
+				values[k] = string(decrypted)
+			}
+			if len(values) > 0 {
+				sec.Receivers[uid] = values
+			}
+			delete(rc, "secureSettings")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		data.setValue(row, "alertmanager_configuration", config)
+	}
+	return nil
+}
+
+// restoreReceiverSecrets encrypts the secure settings of contact points with
+// the secrets of this instance
+func restoreReceiverSecrets(r *restoreHelper, data *backupTableData, row []interface{}) (bool, error) {
+	config, err := updateReceiverConfigs(data.stringValue(row, "alertmanager_configuration"), func(uid string, rc map[string]interface{}) error {
+		values := r.secrets.Receivers[uid]
+		if len(values) == 0 {
+			return nil
+		}
+		settings := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			encrypted, err := r.s.secretsService.Encrypt(r.ctx, []byte(v), secrets.WithoutScope())
+			if err != nil {
+				return err
+			}
+			settings[k] = base64.StdEncoding.EncodeToString(encrypted)
+		}
+		rc["secureSettings"] = settings
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	data.setValue(row, "alertmanager_configuration", config)
+	data.setValue(row, "configuration_hash", fmt.Sprintf("%x", md5.Sum([]byte(config))))
+	return true, nil
+}
+
+// updateReceiverConfigs calls fn for every Grafana managed contact point in
+// an Alertmanager configuration and returns the updated configuration
+func updateReceiverConfigs(config string, fn func(uid string, rc map[string]interface{}) error) (string, error) {
+	if config == "" {
+		return config, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(config))
+	dec.UseNumber()
+	var cfg map[string]interface{}
+	if err := dec.Decode(&cfg); err != nil {
+		return "", fmt.Errorf("invalid alertmanager configuration: %w", err)
+	}
+
+	amConfig, _ := cfg["alertmanager_config"].(map[string]interface{})
+	receivers, _ := amConfig["receivers"].([]interface{})
+	for _, receiver := range receivers {
+		receiver, _ := receiver.(map[string]interface{})
+		configs, _ := receiver["grafana_managed_receiver_configs"].([]interface{})
+		for _, rc := range configs {
+			rc, ok := rc.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			uid, _ := rc["uid"].(string)
+			if err := fn(uid, rc); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,342 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsfakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	secretsmng "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// backupTestDataSources returns the secure JSON data of the data sources
+type backupTestDataSources struct {
+	dsfakes.FakeDataSourceService
+	values map[string]map[string]string
+}
+
+func (s *backupTestDataSources) DecryptedValues(ctx context.Context, ds *datasources.DataSource) (map[string]string, error) {
+	values := make(map[string]string)
+	for k, v := range s.values[ds.Uid] {
+		values[k] = v
+	}
+	return values, nil
+}
+
+func TestIntegrationBackupRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := sqlstore.InitTestDB(t)
+	secretsService := secretsmng.SetupTestService(t, database.ProvideSecretsStore(ss))
+	secretsStore := secretskvs.NewFakeSecretsKVStore()
+	dsService := &backupTestDataSources{}
+	s := ProvideBackupService(ss, featuremgmt.WithFeatures(), secretsService, secretsStore, dsService)
+
+	// the source org
+	editor, err := ss.CreateUser(ctx, user.CreateUserCommand{Login: "editor", Email: "editor@example.com"})
+	require.NoError(t, err)
+	source, err := ss.CreateOrgWithMember("source", editor.ID)
+	require.NoError(t, err)
+
+	now := time.Now()
+	folder := &models.Dashboard{OrgId: source.Id, Uid: "folder", Title: "Folder", Slug: "folder", IsFolder: true,
+		Data: simplejson.NewFromAny(map[string]interface{}{"uid": "folder", "title": "Folder"}), Created: now, Updated: now}
+	dash := &models.Dashboard{OrgId: source.Id, Uid: "dash", Title: "Dash", Slug: "dash", CreatedBy: editor.ID,
+		Data: simplejson.NewFromAny(map[string]interface{}{
+			"uid":    "dash",
+			"title":  "Dash",
+			"panels": []interface{}{map[string]interface{}{"datasource": map[string]interface{}{"uid": "prom"}}},
+		}), Created: now, Updated: now}
+	team := &models.Team{OrgId: source.Id, Name: "team", Created: now, Updated: now}
+	ds := &datasources.DataSource{OrgId: source.Id, Uid: "prom", Name: "Prometheus", Type: "prometheus",
+		JsonData: simplejson.New(), Created: now, Updated: now}
+	dsService.DataSources = []*datasources.DataSource{ds}
+	dsService.values = map[string]map[string]string{"prom": {"httpHeaderValue1": "token"}}
+
+	receiverSecret, err := secretsService.Encrypt(ctx, []byte("webhook-password"), secrets.WithoutScope())
+	require.NoError(t, err)
+	amConfig := fmt.Sprintf(`{"alertmanager_config":{"receivers":[{"name":"hook","grafana_managed_receiver_configs":[{"uid":"hook-uid","type":"webhook","secureSettings":{"password":%q}}]}]}}`,
+		base64.StdEncoding.EncodeToString(receiverSecret))
+
+	err = ss.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, bean := range []interface{}{folder, team, ds} {
+			if _, err := sess.Insert(bean); err != nil {
+				return err
+			}
+		}
+		dash.FolderId = folder.Id
+		if _, err := sess.Insert(dash); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&models.TeamMember{OrgId: source.Id, TeamId: team.Id, UserId: editor.ID, Created: now, Updated: now}); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&ngmodels.AlertConfiguration{OrgID: source.Id, AlertmanagerConfiguration: amConfig, ConfigurationVersion: "v1"}); err != nil {
+			return err
+		}
+
+		role := &accesscontrol.Role{OrgID: source.Id, UID: "managed-team", Name: fmt.Sprintf("managed:teams:%d:permissions", team.Id), Created: now, Updated: now}
+		if _, err := sess.Insert(role); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&accesscontrol.Permission{RoleID: role.ID, Action: "dashboards:read", Scope: "dashboards:uid:dash", Created: now, Updated: now},
+			&accesscontrol.Permission{RoleID: role.ID, Action: "teams:read", Scope: fmt.Sprintf("teams:id:%d", team.Id), Created: now, Updated: now}); err != nil {
+			return err
+		}
+
+		res, err := sess.Exec(`INSERT INTO annotation (org_id, dashboard_id, user_id, type, title, text, prev_state, new_state, data, epoch, epoch_end, created, updated)
+			VALUES (?, ?, ?, '', '', 'deploy', '', '', '{}', 1, 1, 1, 1)`, source.Id, dash.Id, editor.ID)
+		if err != nil {
+			return err
+		}
+		annotationID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		res, err = sess.Exec("INSERT INTO tag (" + ss.Quote("key") + ", " + ss.Quote("value") + ") VALUES ('env', 'prod')")
+		if err != nil {
+			return err
+		}
+		tagID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		_, err = sess.Exec("INSERT INTO annotation_tag (annotation_id, tag_id) VALUES (?, ?)", annotationID, tagID)
+		return err
+	})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	manifest, err := s.Backup(ctx, source.Id, "secret", buf)
+	require.NoError(t, err)
+	require.Equal(t, BackupFormatVersion, manifest.Version)
+	require.Equal(t, "source", manifest.OrgName)
+	require.Equal(t, 2, manifest.Tables["dashboard"])
+	require.Equal(t, 1, manifest.Tables["user"])
+	archive := buf.Bytes()
+
+	t.Run("secrets can't be read without the passphrase", func(t *testing.T) {
+		files, err := readArchive(bytes.NewReader(archive))
+		require.NoError(t, err)
+		require.NotContains(t, string(files["tables/data_source.json"]), "token")
+		require.NotContains(t, string(files["tables/alert_configuration.json"]), "secureSettings")
+
+		_, err = s.Restore(ctx, bytes.NewReader(archive), RestoreOptions{OrgName: "wrong", Passphrase: "wrong"})
+		require.ErrorIs(t, err, ErrBackupPassphrase)
+	})
+
+	t.Run("archives with unknown columns or short rows are rejected", func(t *testing.T) {
+		tamper := func(t *testing.T, modify func(data *backupTableData)) []byte {
+			t.Helper()
+			files, err := readArchive(bytes.NewReader(archive))
+			require.NoError(t, err)
+			data := &backupTableData{}
+			require.NoError(t, readArchiveJSON(files, "tables/dashboard.json", data))
+			modify(data)
+			b, err := json.Marshal(data)
+			require.NoError(t, err)
+			files["tables/dashboard.json"] = b
+
+			buf := &bytes.Buffer{}
+			gz := gzip.NewWriter(buf)
+			tw := tar.NewWriter(gz)
+			for name, body := range files {
+				require.NoError(t, writeArchiveFile(tw, name, body))
+			}
+			require.NoError(t, tw.Close())
+			require.NoError(t, gz.Close())
+			return buf.Bytes()
+		}
+
+		unknownColumn := tamper(t, func(data *backupTableData) {
+			data.Columns[1].Name = "title) VALUES (1); --"
+		})
+		_, err := s.Restore(ctx, bytes.NewReader(unknownColumn), RestoreOptions{OrgName: "unknown column", Passphrase: "secret"})
+		require.ErrorIs(t, err, ErrBackupInvalid)
+
+		shortRow := tamper(t, func(data *backupTableData) {
+			data.Rows[0] = data.Rows[0][:1]
+		})
+		_, err = s.Restore(ctx, bytes.NewReader(shortRow), RestoreOptions{OrgName: "short row", Passphrase: "secret"})
+		require.ErrorIs(t, err, ErrBackupInvalid)
+	})
+
+	t.Run("restore with new UIDs", func(t *testing.T) {
+		result, err := s.Restore(ctx, bytes.NewReader(archive), RestoreOptions{OrgName: "restored", Passphrase: "secret", NewUIDs: true})
+		require.NoError(t, err)
+		require.NotEqual(t, source.Id, result.OrgID)
+		require.Equal(t, 2, result.Restored["dashboard"])
+		require.Equal(t, 1, result.Restored["team_member"])
+		require.Empty(t, result.Skipped)
+
+		err = ss.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			var dashboards []*models.Dashboard
+			require.NoError(t, sess.Where("org_id = ?", result.OrgID).OrderBy("is_folder DESC").Find(&dashboards))
+			require.Len(t, dashboards, 2)
+			newFolder, newDash := dashboards[0], dashboards[1]
+			require.NotEqual(t, "folder", newFolder.Uid)
+			require.NotEqual(t, "dash", newDash.Uid)
+			require.Equal(t, newFolder.Id, newDash.FolderId)
+			require.Equal(t, editor.ID, newDash.CreatedBy)
+			require.Equal(t, newDash.Uid, newDash.Data.Get("uid").MustString())
+
+			var newDS datasources.DataSource
+			_, err := sess.Where("org_id = ?", result.OrgID).Get(&newDS)
+			require.NoError(t, err)
+			require.NotEqual(t, "prom", newDS.Uid)
+			panelDS := newDash.Data.Get("panels").GetIndex(0).Get("datasource").Get("uid").MustString()
+			require.Equal(t, newDS.Uid, panelDS)
+			decrypted, err := secretsService.Decrypt(ctx, newDS.SecureJsonData["httpHeaderValue1"])
+			require.NoError(t, err)
+			require.Equal(t, "token", string(decrypted))
+			secret, ok, err := secretsStore.Get(ctx, result.OrgID, "Prometheus", secretskvs.DataSourceSecretType)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.JSONEq(t, `{"httpHeaderValue1": "token"}`, secret)
+
+			var newTeam models.Team
+			_, err = sess.Where("org_id = ?", result.OrgID).Get(&newTeam)
+			require.NoError(t, err)
+			var member models.TeamMember
+			_, err = sess.Where("org_id = ?", result.OrgID).Get(&member)
+			require.NoError(t, err)
+			require.Equal(t, newTeam.Id, member.TeamId)
+			require.Equal(t, editor.ID, member.UserId)
+
+			var role accesscontrol.Role
+			_, err = sess.Where("org_id = ?", result.OrgID).Get(&role)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("managed:teams:%d:permissions", newTeam.Id), role.Name)
+			require.NotEqual(t, "managed-team", role.UID)
+			var permissions []accesscontrol.Permission
+			require.NoError(t, sess.Where("role_id = ?", role.ID).OrderBy("id").Find(&permissions))
+			require.Len(t, permissions, 2)
+			require.Equal(t, "dashboards:uid:"+newDash.Uid, permissions[0].Scope)
+			require.Equal(t, fmt.Sprintf("teams:id:%d", newTeam.Id), permissions[1].Scope)
+
+			var config ngmodels.AlertConfiguration
+			_, err = sess.Where("org_id = ?", result.OrgID).Get(&config)
+			require.NoError(t, err)
+			_, err = updateReceiverConfigs(config.AlertmanagerConfiguration, func(uid string, rc map[string]interface{}) error {
+				settings := rc["secureSettings"].(map[string]interface{})
+				encrypted, err := base64.StdEncoding.DecodeString(settings["password"].(string))
+				require.NoError(t, err)
+				decrypted, err := secretsService.Decrypt(ctx, encrypted)
+				require.NoError(t, err)
+				require.Equal(t, "webhook-password", string(decrypted))
+				return nil
+			})
+			require.NoError(t, err)
+
+			// tags are shared
+			tags, err := sess.Table("tag").Count()
+			require.NoError(t, err)
+			require.Equal(t, int64(1), tags)
+			annotationTags, err := sess.Table("annotation_tag").Count()
+			require.NoError(t, err)
+			require.Equal(t, int64(2), annotationTags)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("org names must be unique", func(t *testing.T) {
+		_, err := s.Restore(ctx, bytes.NewReader(archive), RestoreOptions{})
+		require.ErrorIs(t, err, models.ErrOrgNameTaken)
+	})
+
+	t.Run("users must match by login and email", func(t *testing.T) {
+		err := ss.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			_, err := sess.Exec("UPDATE "+ss.Quote("user")+" SET email = ? WHERE id = ?", "other@example.com", editor.ID)
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = s.Restore(ctx, bytes.NewReader(archive), RestoreOptions{OrgName: "conflict", Passphrase: "secret"})
+		require.ErrorContains(t, err, "conflicts with existing user editor <other@example.com>")
+	})
+}
+
+func TestReadArchive(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, writeArchiveFile(tw, "a.json", bytes.Repeat([]byte("a"), 600)))
+	require.NoError(t, writeArchiveFile(tw, "b.json", bytes.Repeat([]byte("b"), 600)))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	files, err := readArchive(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Len(t, files["b.json"], 600)
+
+	t.Run("limits the size of all files", func(t *testing.T) {
+		limit := maxBackupArchiveSize
+		t.Cleanup(func() { maxBackupArchiveSize = limit })
+		// large enough for each file, but not for both
+		maxBackupArchiveSize = 2048
+
+		_, err := readArchive(bytes.NewReader(buf.Bytes()))
+		require.ErrorContains(t, err, "backup archive is larger than 2048 bytes")
+	})
+}
+
+func TestBackupValues(t *testing.T) {
+	for _, tc := range []struct {
+		typ   string
+		value interface{}
+		json  string
+		back  interface{}
+	}{
+		{typ: "int", value: int64(12), json: "12", back: int64(12)},
+		{typ: "int", value: []byte("12"), json: "12", back: int64(12)},
+		{typ: "bool", value: int64(1), json: "true", back: true},
+		{typ: "bool", value: false, json: "false", back: false},
+		{typ: "text", value: []byte("text"), json: `"text"`, back: "text"},
+		{typ: "float", value: 1.5, json: "1.5", back: 1.5},
+		{typ: "time", value: "2022-07-01 10:00:00", json: `"2022-07-01T10:00:00Z"`, back: time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)},
+		{typ: "text", value: nil, json: "null", back: nil},
+	} {
+		encoded, err := encodeBackupValue(tc.typ, tc.value)
+		require.NoError(t, err)
+		b, err := json.Marshal(encoded)
+		require.NoError(t, err)
+		require.JSONEq(t, tc.json, string(b))
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var v interface{}
+		require.NoError(t, dec.Decode(&v))
+		decoded, err := decodeBackupValue(tc.typ, v)
+		require.NoError(t, err)
+		require.Equal(t, tc.back, decoded)
+	}
+
+	require.Equal(t, "bool", columnTypeFromDatabase("BOOLEAN"))
+	require.Equal(t, "int", columnTypeFromDatabase("BIGINT"))
+	require.Equal(t, "time", columnTypeFromDatabase("TIMESTAMP"))
+	require.Equal(t, "text", columnTypeFromDatabase("VARCHAR"))
+}
@@ -1,30 +1,58 @@
 package export
 
 import (
-	"encoding/json"
 	"fmt"
 	"path"
-	"time"
 
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
 
+// alertRuleFile is an alert rule as written by exportAlerts and read by
+// planAlertRules
+type alertRuleFile struct {
+	Title           string
+	UID             string
+	NamespaceUID    string
+	RuleGroup       string
+	RuleGroupIndex  int
+	Condition       string
+	Data            []ngmodels.AlertQuery
+	IntervalSeconds int64
+	For             string
+	NoDataState     ngmodels.NoDataState
+	ExecErrState    ngmodels.ExecutionErrorState
+	Annotations     map[string]string `json:",omitempty"`
+	Labels          map[string]string `json:",omitempty"`
+	DashboardUID    *string           `json:",omitempty"`
+	PanelID         *int64            `json:",omitempty"`
+}
+
+func newAlertRuleFile(rule *ngmodels.AlertRule) *alertRuleFile {
+	return &alertRuleFile{
+		Title:           rule.Title,
+		UID:             rule.UID,
+		NamespaceUID:    rule.NamespaceUID,
+		RuleGroup:       rule.RuleGroup,
+		RuleGroupIndex:  rule.RuleGroupIndex,
+		Condition:       rule.Condition,
+		Data:            rule.Data,
+		IntervalSeconds: rule.IntervalSeconds,
+		For:             rule.For.String(),
+		NoDataState:     rule.NoDataState,
+		ExecErrState:    rule.ExecErrState,
+		Annotations:     rule.Annotations,
+		Labels:          rule.Labels,
+		DashboardUID:    rule.DashboardUID,
+		PanelID:         rule.PanelID,
+	}
+}
+
 func exportAlerts(helper *commitHelper, job *gitExportJob) error {
 	alertDir := path.Join(helper.orgDir, "alerts")
 
 	return job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
-		type ruleResult struct {
-			Title        string          `xorm:"title"`
-			UID          string          `xorm:"uid"`
-			NamespaceUID string          `xorm:"namespace_uid"`
-			RuleGroup    string          `xorm:"rule_group"`
-			Condition    json.RawMessage `xorm:"data"`
-			DashboardUID string          `xorm:"dashboard_uid"`
-			PanelID      int64           `xorm:"panel_id"`
-			Updated      time.Time       `xorm:"updated" json:"-"`
-		}
-
-		rows := make([]*ruleResult, 0)
+		rows := make([]*ngmodels.AlertRule, 0)
 
 		sess.Table("alert_rule").Where("org_id = ?", helper.orgID)
 
@@ -36,7 +64,7 @@ func exportAlerts(helper *commitHelper, job *gitExportJob) error {
 		for _, row := range rows {
 			err = helper.add(commitOptions{
 				body: []commitBody{{
-					body:  prettyJSON(row),
+					body:  prettyJSON(newAlertRuleFile(row)),
 					fpath: path.Join(alertDir, row.UID) + ".json", // must be JSON files
 				}},
 				comment: fmt.Sprintf("Alert: %s", row.Title),
@@ -0,0 +1,406 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/playlist"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var _ Job = new(gitImportJob)
+
+const (
+	syncStatusNamespace = "export"
+	syncStatusKey       = "git_sync"
+)
+
+type gitImportJob struct {
+	logger            log.Logger
+	sql               *sqlstore.SQLStore
+	dashboardStore    dashboards.Store
+	datasourceService datasources.DataSourceService
+	playlistService   playlist.Service
+	kvStore           kvstore.KVStore
+	exportDir         string // folder with exports and clones in the data path
+	orgID             int64
+
+	statusMu      sync.Mutex
+	status        ExportStatus
+	cfg           ImportConfig
+	broadcaster   statusBroadcaster
+	stopRequested bool
+}
+
+type importHelper struct {
+	ctx    context.Context
+	orgDir string // includes the orgID
+	orgID  int64
+	commit string
+}
+
+// message is used for the versions of imported dashboards
+func (h *importHelper) message() string {
+	if len(h.commit) >= 8 {
+		return fmt.Sprintf("Imported from git (%s)", h.commit[:8])
+	}
+	return "Imported from git"
+}
+
+func startGitImportJob(cfg ImportConfig, sql *sqlstore.SQLStore, dashboardStore dashboards.Store,
+	datasourceService datasources.DataSourceService, playlistService playlist.Service, kvStore kvstore.KVStore,
+	exportDir string, orgID int64, broadcaster statusBroadcaster) (Job, error) {
+	if (cfg.URL == "") == (cfg.Dir == "") {
+		return nil, fmt.Errorf("either a git url or a folder is required")
+	}
+	if cfg.Dir != "" {
+		dir, err := importDir(exportDir, cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Dir = dir
+	}
+
+	job := &gitImportJob{
+		logger:            log.New("git_import_job"),
+		cfg:               cfg,
+		sql:               sql,
+		dashboardStore:    dashboardStore,
+		datasourceService: datasourceService,
+		playlistService:   playlistService,
+		kvStore:           kvStore,
+		exportDir:         exportDir,
+		orgID:             orgID,
+		broadcaster:       broadcaster,
+		status: ExportStatus{
+			Running: true,
+			Target:  "git import",
+			Started: time.Now().UnixMilli(),
+			Count:   make(map[string]int, len(importers)*3),
+		},
+	}
+
+	broadcaster(job.status)
+	go job.start()
+	return job, nil
+}
+
+// importDir returns the absolute path of a folder in the export folder
+func importDir(exportDir, dir string) (string, error) {
+	abs := filepath.Join(exportDir, filepath.Clean(string(filepath.Separator)+dir))
+	rel, err := filepath.Rel(exportDir, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("invalid folder, must be within the export folder")
+	}
+	fi, err := os.Stat(abs)
+	if err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("folder not found: %s", dir)
+	}
+	return abs, nil
+}
+
+func (e *gitImportJob) getStatus() ExportStatus {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	return e.status
+}
+
+func (e *gitImportJob) getConfig() ExportConfig {
+	return ExportConfig{Format: "git"}
+}
+
+func (e *gitImportJob) requestStop() {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	e.stopRequested = true // will error before the next change
+}
+
+func (e *gitImportJob) updateStatus(fn func(s *ExportStatus)) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	fn(&e.status)
+	e.status.Changed = time.Now().UnixMilli()
+	e.broadcaster(e.status)
+}
+
+func (e *gitImportJob) start() {
+	defer func() {
+		e.logger.Info("Finished git import job")
+		e.statusMu.Lock()
+		defer e.statusMu.Unlock()
+		s := e.status
+		if err := recover(); err != nil {
+			e.logger.Error("import panic", "error", err)
+			s.Status = fmt.Sprintf("ERROR: %v", err)
+		}
+		s.Finished = time.Now().UnixMilli()
+		s.Running = false
+		if s.Status == "" {
+			s.Status = "done"
+		}
+		e.status = s
+		e.broadcaster(s)
+	}()
+
+	err := e.doImport(context.Background())
+	if err != nil {
+		e.logger.Error("import failed", "err", err)
+		e.updateStatus(func(s *ExportStatus) {
+			s.Status = "ERROR"
+			s.Last = err.Error()
+		})
+	}
+}
+
+func (e *gitImportJob) doImport(ctx context.Context) error {
+	dir, source, cleanup, err := e.checkout(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	commit, err := headCommit(dir)
+	if err != nil {
+		return err
+	}
+
+	helper := &importHelper{
+		ctx:    ctx,
+		orgDir: dir,
+		orgID:  e.orgID,
+		commit: commit,
+	}
+	// multiple orgs are exported to one folder per org
+	orgDir := filepath.Join(dir, fmt.Sprintf("org_%d", e.orgID))
+	if fi, err := os.Stat(orgDir); err == nil && fi.IsDir() {
+		helper.orgDir = orgDir
+	}
+
+	plan, err := e.plan(helper)
+	if err != nil {
+		return err
+	}
+	e.updateStatus(func(s *ExportStatus) {
+		s.Target = source
+		s.Plan = plan
+		for _, change := range plan {
+			s.Count[fmt.Sprintf("%s.%s", change.Kind, change.Action)]++
+		}
+	})
+
+	if e.cfg.DryRun {
+		e.updateStatus(func(s *ExportStatus) {
+			s.Status = "dry run"
+		})
+		return nil
+	}
+
+	// all changes are applied or none
+	return e.sql.InTransaction(ctx, func(ctx context.Context) error {
+		for i, change := range plan {
+			e.statusMu.Lock()
+			stopRequested := e.stopRequested
+			e.statusMu.Unlock()
+			if stopRequested {
+				return fmt.Errorf("stop requested")
+			}
+
+			e.updateStatus(func(s *ExportStatus) {
+				s.Index = i + 1
+				s.Last = change.Path
+			})
+			if err := change.apply(ctx); err != nil {
+				return fmt.Errorf("failed to %s %s %q: %w", change.Action, change.Kind, change.Name, err)
+			}
+		}
+
+		return e.setSyncStatus(ctx, SyncStatus{
+			Source:  source,
+			Commit:  commit,
+			Synced:  time.Now().UnixMilli(),
+			Changes: len(plan),
+		})
+	})
+}
+
+func (e *gitImportJob) plan(helper *importHelper) ([]*ImportChange, error) {
+	var plan []*ImportChange
+	for _, imp := range importers {
+		if e.cfg.Exclude[imp.Key] {
+			continue
+		}
+
+		e.updateStatus(func(s *ExportStatus) {
+			s.Target = imp.Key
+		})
+		changes, err := imp.plan(helper, e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan %s: %w", imp.Key, err)
+		}
+		plan = append(plan, changes...)
+	}
+	return plan, nil
+}
+
+// checkout returns the folder to import, a description of the source and a
+// function to clean up after the import
+func (e *gitImportJob) checkout(ctx context.Context) (string, string, func(), error) {
+	if e.cfg.Dir != "" {
+		rel, _ := filepath.Rel(e.exportDir, e.cfg.Dir)
+		return e.cfg.Dir, rel, func() {}, nil
+	}
+
+	dir := filepath.Join(e.exportDir, fmt.Sprintf("import_%d", time.Now().UnixNano()))
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			e.logger.Warn("failed to remove clone", "dir", dir, "err", err)
+		}
+	}
+
+	opts := &git.CloneOptions{
+		URL:          e.cfg.URL,
+		Depth:        1,
+		SingleBranch: true,
+	}
+	if e.cfg.Branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(e.cfg.Branch)
+	}
+	if e.cfg.Token != "" {
+		opts.Auth = &githttp.BasicAuth{
+			Username: "grafana", // anything but empty
+			Password: e.cfg.Token,
+		}
+	}
+
+	source := e.cfg.URL
+	if u, err := url.Parse(e.cfg.URL); err == nil {
+		u.User = nil
+		source = u.String()
+	}
+	e.updateStatus(func(s *ExportStatus) {
+		s.Target = "cloning " + source
+	})
+
+	if _, err := git.PlainCloneContext(ctx, dir, false, opts); err != nil {
+		cleanup()
+		return "", "", nil, fmt.Errorf("failed to clone %s: %w", source, err)
+	}
+	return dir, source, cleanup, nil
+}
+
+func (e *gitImportJob) setSyncStatus(ctx context.Context, s SyncStatus) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return kvstore.WithNamespace(e.kvStore, e.orgID, syncStatusNamespace).Set(ctx, syncStatusKey, string(b))
+}
+
+func getSyncStatus(ctx context.Context, kvStore kvstore.KVStore, orgID int64) (*SyncStatus, error) {
+	v, ok, err := kvstore.WithNamespace(kvStore, orgID, syncStatusNamespace).Get(ctx, syncStatusKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+	s := &SyncStatus{}
+	if err := json.Unmarshal([]byte(v), s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// headCommit returns the commit checked out in dir, or an empty string if it
+// isn't a git repository
+func headCommit(dir string) (string, error) {
+	r, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// findFiles returns the files in dir with the suffix. Entities are only
+// imported when their folder exists, so a partial export doesn't delete
+// everything else.
+func findFiles(dir, suffix string) ([]string, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, true, nil
+}
+
+func readJSON(fpath string, v interface{}) error {
+	// nolint:gosec
+	// The path is within the folder being imported.
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("invalid JSON in %s: %w", filepath.Base(fpath), err)
+	}
+	return nil
+}
+
+// uidFromPath returns a stable UID for entities added to the repository
+// without one
+func uidFromPath(kind, path string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(kind+":"+filepath.ToSlash(path))))[:14]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (h *importHelper) relPath(fpath string) string {
+	rel, err := filepath.Rel(h.orgDir, fpath)
+	if err != nil {
+		return fpath
+	}
+	return filepath.ToSlash(rel)
+}
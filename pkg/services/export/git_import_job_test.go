@@ -0,0 +1,291 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsservice "github.com/grafana/grafana/pkg/services/datasources/service"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	secretsmng "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+)
+
+func TestReadDashboardFiles(t *testing.T) {
+	rootDir := t.TempDir()
+	writeTestFile(t, filepath.Join(rootDir, "home-dash.json"), `{"title": "Home"}`)
+	writeTestFile(t, filepath.Join(rootDir, "ops", folderFileName), `{"title": "Ops"}`)
+	writeTestFile(t, filepath.Join(rootDir, "ops", "nodes-dash.json"), `{"title": "Nodes"}`)
+	writeTestFile(t, filepath.Join(rootDir, "ops", "added-dash.json"), `{"title": "Added", "uid": "added"}`)
+	writeTestFile(t, filepath.Join(rootDir, "general", "other-dash.json"), `{"title": "Other"}`)
+
+	uids := map[string]string{
+		"home-dash.json":      "home",
+		"ops":                 "ops-folder",
+		"ops/nodes-dash.json": "nodes",
+	}
+	folders, dashes, err := readDashboardFiles(rootDir, uids)
+	require.NoError(t, err)
+
+	require.Len(t, folders, 1)
+	require.Equal(t, "ops-folder", folders[0].uid)
+	require.Equal(t, "Ops", folders[0].title)
+
+	byUID := map[string]*importDashboard{}
+	for _, d := range dashes {
+		byUID[d.uid] = d
+	}
+	require.Len(t, byUID, 4)
+	require.Equal(t, "", byUID["home"].folderUID)
+	require.Equal(t, "ops-folder", byUID["nodes"].folderUID)
+	require.Equal(t, "ops-folder", byUID["added"].folderUID)
+
+	// dashboards without a UID get a stable one, general folders aren't created
+	otherUID := uidFromPath("dash", "general/other-dash.json")
+	require.Contains(t, byUID, otherUID)
+	require.Equal(t, "", byUID[otherUID].folderUID)
+}
+
+func TestSameDashboardJSON(t *testing.T) {
+	current := []byte(`{"id": 1, "uid": "abc", "version": 3, "title": "Test", "panels": [{"id": 1}]}`)
+	require.True(t, sameDashboardJSON(current, map[string]interface{}{
+		"title":  "Test",
+		"panels": []interface{}{map[string]interface{}{"id": float64(1)}},
+	}))
+	require.False(t, sameDashboardJSON(current, map[string]interface{}{
+		"title": "Changed",
+	}))
+}
+
+func TestImportDir(t *testing.T) {
+	exportDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(exportDir, "git_1"), 0750))
+
+	dir, err := importDir(exportDir, "git_1")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(exportDir, "git_1"), dir)
+
+	// paths are always within the export folder
+	_, err = importDir(exportDir, "../git_1")
+	require.NoError(t, err)
+	_, err = importDir(exportDir, "/")
+	require.Error(t, err)
+	_, err = importDir(exportDir, "missing")
+	require.Error(t, err)
+}
+
+func TestIntegrationGitImport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := sqlstore.InitTestDB(t)
+	secretsService := secretsmng.SetupTestService(t, fakes.NewFakeSecretsStore())
+	secretsStore := secretskvs.NewSQLSecretsKVStore(sqlStore, secretsService, log.New("test.logger"))
+	dsService := dsservice.ProvideService(sqlStore, secretsService, secretsStore, sqlStore.Cfg, featuremgmt.WithFeatures(), acmock.New().WithDisabled(), acmock.NewMockedPermissionsService())
+	dashboardStore := dashboardsDB.ProvideDashboardStore(sqlStore, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore, sqlStore.Cfg))
+	kvStore := kvstore.ProvideService(sqlStore)
+	ctx := context.Background()
+
+	exportDir := t.TempDir()
+	repoDir := filepath.Join(exportDir, "repo")
+	runImport := func(t *testing.T, dryRun bool) (*gitImportJob, error) {
+		t.Helper()
+		job := &gitImportJob{
+			logger:            log.New("git_import_job"),
+			cfg:               ImportConfig{Dir: repoDir, DryRun: dryRun, Exclude: map[string]bool{"system_playlists": true, "system_preferences": true}},
+			sql:               sqlStore,
+			dashboardStore:    dashboardStore,
+			datasourceService: dsService,
+			kvStore:           kvStore,
+			exportDir:         exportDir,
+			orgID:             1,
+			broadcaster:       func(s ExportStatus) {},
+			status:            ExportStatus{Count: map[string]int{}},
+		}
+		return job, job.doImport(ctx)
+	}
+	dataSourceNames := func(t *testing.T) map[string]string {
+		t.Helper()
+		query := &datasources.GetDataSourcesQuery{OrgId: 1}
+		require.NoError(t, dsService.GetDataSources(ctx, query))
+		names := map[string]string{}
+		for _, ds := range query.Result {
+			names[ds.Uid] = ds.Name
+		}
+		return names
+	}
+	dashboardTitles := func(t *testing.T) map[string]string {
+		t.Helper()
+		titles := map[string]string{}
+		err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			var rows []*models.Dashboard
+			if err := sess.Where("org_id = ?", 1).Find(&rows); err != nil {
+				return err
+			}
+			for _, row := range rows {
+				titles[row.Uid] = row.Title
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return titles
+	}
+	changeCount := func(plan []*ImportChange) map[ImportAction]int {
+		count := map[ImportAction]int{}
+		for _, change := range plan {
+			count[change.Action]++
+		}
+		return count
+	}
+
+	writeTestFile(t, filepath.Join(repoDir, "datasources", "prom-ds.json"), `{"uid": "prom", "name": "Prometheus", "type": "prometheus", "access": "proxy", "url": "http://prometheus:9090"}`)
+	writeTestFile(t, filepath.Join(repoDir, "datasources", "loki-ds.json"), `{"uid": "loki", "name": "Loki", "type": "loki", "access": "proxy", "url": "http://loki:3100"}`)
+	writeTestFile(t, filepath.Join(repoDir, "root", "ops", folderFileName), `{"title": "Ops"}`)
+	writeTestFile(t, filepath.Join(repoDir, "root", "ops", "nodes-dash.json"), `{"title": "Nodes"}`)
+	writeTestFile(t, filepath.Join(repoDir, "root", "home-dash.json"), `{"title": "Home"}`)
+	writeTestFile(t, filepath.Join(repoDir, "root-alias.json"), `{"ops": "ops", "nodes": "ops/nodes-dash.json", "home": "home-dash.json"}`)
+
+	t.Run("dry run doesn't change anything", func(t *testing.T) {
+		job, err := runImport(t, true)
+		require.NoError(t, err)
+		require.Equal(t, "dry run", job.getStatus().Status)
+		require.Equal(t, map[ImportAction]int{ImportActionCreate: 5}, changeCount(job.getStatus().Plan))
+
+		require.Empty(t, dataSourceNames(t))
+		require.Empty(t, dashboardTitles(t))
+		status, err := getSyncStatus(ctx, kvStore, 1)
+		require.NoError(t, err)
+		require.Nil(t, status)
+	})
+
+	t.Run("creates data sources, folders and dashboards", func(t *testing.T) {
+		job, err := runImport(t, false)
+		require.NoError(t, err)
+		require.Equal(t, map[ImportAction]int{ImportActionCreate: 5}, changeCount(job.getStatus().Plan))
+
+		require.Equal(t, map[string]string{"prom": "Prometheus", "loki": "Loki"}, dataSourceNames(t))
+		require.Equal(t, map[string]string{"ops": "Ops", "nodes": "Nodes", "home": "Home"}, dashboardTitles(t))
+		status, err := getSyncStatus(ctx, kvStore, 1)
+		require.NoError(t, err)
+		require.Equal(t, 5, status.Changes)
+
+		// nothing changes when the repository is imported again
+		job, err = runImport(t, false)
+		require.NoError(t, err)
+		require.Empty(t, job.getStatus().Plan)
+	})
+
+	t.Run("updates changed entities and deletes the ones missing from the repository", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(repoDir, "datasources", "prom-ds.json"), `{"uid": "prom", "name": "Prometheus (ops)", "type": "prometheus", "access": "proxy", "url": "http://prometheus:9090"}`)
+		writeTestFile(t, filepath.Join(repoDir, "root", "ops", "nodes-dash.json"), `{"title": "Nodes (ops)"}`)
+		require.NoError(t, os.Remove(filepath.Join(repoDir, "datasources", "loki-ds.json")))
+		require.NoError(t, os.Remove(filepath.Join(repoDir, "root", "home-dash.json")))
+
+		job, err := runImport(t, false)
+		require.NoError(t, err)
+		require.Equal(t, map[ImportAction]int{ImportActionUpdate: 2, ImportActionDelete: 2}, changeCount(job.getStatus().Plan))
+
+		require.Equal(t, map[string]string{"prom": "Prometheus (ops)"}, dataSourceNames(t))
+		require.Equal(t, map[string]string{"ops": "Ops", "nodes": "Nodes (ops)"}, dashboardTitles(t))
+	})
+
+	t.Run("rolls back all changes when one fails", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(repoDir, "root", "home-dash.json"), `{"title": "Home"}`)
+		writeTestFile(t, filepath.Join(repoDir, "datasources", "graphite-ds.json"), `{"uid": "graphite", "name": "Graphite", "type": "graphite", "access": "proxy", "url": "http://graphite"}`)
+		// data sources are applied after dashboards, the name is taken
+		writeTestFile(t, filepath.Join(repoDir, "datasources", "tempo-ds.json"), `{"uid": "tempo", "name": "Graphite", "type": "tempo", "access": "proxy", "url": "http://tempo"}`)
+
+		_, err := runImport(t, false)
+		require.ErrorIs(t, err, datasources.ErrDataSourceNameExists)
+
+		require.Equal(t, map[string]string{"prom": "Prometheus (ops)"}, dataSourceNames(t))
+		require.Equal(t, map[string]string{"ops": "Ops", "nodes": "Nodes (ops)"}, dashboardTitles(t))
+		status, err := getSyncStatus(ctx, kvStore, 1)
+		require.NoError(t, err)
+		require.Equal(t, 4, status.Changes)
+	})
+
+	alertRuleTitles := func(t *testing.T) map[string]string {
+		t.Helper()
+		titles := map[string]string{}
+		err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			var rows []*ngmodels.AlertRule
+			if err := sess.Table("alert_rule").Where("org_id = ?", 1).Find(&rows); err != nil {
+				return err
+			}
+			for _, row := range rows {
+				titles[row.UID] = row.Title
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return titles
+	}
+	alertRuleFile := func(title, folderUID string) string {
+		return fmt.Sprintf(`{
+			"Title": %q, "UID": "cpu", "NamespaceUID": %q, "RuleGroup": "nodes", "Condition": "A",
+			"Data": [{"refId": "A", "datasourceUid": "-100", "relativeTimeRange": {"from": 600, "to": 0}, "model": {"type": "math", "expression": "1 > 0"}}],
+			"IntervalSeconds": 60, "For": "5m0s", "NoDataState": "NoData", "ExecErrState": "Alerting"
+		}`, title, folderUID)
+	}
+
+	t.Run("imports alert rules", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(repoDir, "datasources", "tempo-ds.json")))
+		writeTestFile(t, filepath.Join(repoDir, "alerts", "cpu.json"), alertRuleFile("High CPU", "ops"))
+		_, err := runImport(t, false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"cpu": "High CPU"}, alertRuleTitles(t))
+
+		// exported rules are imported without changes
+		err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			rule := &ngmodels.AlertRule{}
+			if _, err := sess.Table("alert_rule").Where("org_id = ? AND uid = ?", 1, "cpu").Get(rule); err != nil {
+				return err
+			}
+			writeTestFile(t, filepath.Join(repoDir, "alerts", "cpu.json"), string(prettyJSON(newAlertRuleFile(rule))))
+			return nil
+		})
+		require.NoError(t, err)
+		job, err := runImport(t, false)
+		require.NoError(t, err)
+		require.Empty(t, job.getStatus().Plan)
+
+		writeTestFile(t, filepath.Join(repoDir, "alerts", "cpu.json"), alertRuleFile("High CPU (ops)", "ops"))
+		job, err = runImport(t, false)
+		require.NoError(t, err)
+		require.Equal(t, map[ImportAction]int{ImportActionUpdate: 1}, changeCount(job.getStatus().Plan))
+		require.Equal(t, map[string]string{"cpu": "High CPU (ops)"}, alertRuleTitles(t))
+
+		writeTestFile(t, filepath.Join(repoDir, "alerts", "cpu.json"), alertRuleFile("High CPU", "missing"))
+		_, err = runImport(t, false)
+		require.ErrorContains(t, err, `folder "missing" not found`)
+		require.Equal(t, map[string]string{"cpu": "High CPU (ops)"}, alertRuleTitles(t))
+
+		require.NoError(t, os.Remove(filepath.Join(repoDir, "alerts", "cpu.json")))
+		job, err = runImport(t, false)
+		require.NoError(t, err)
+		require.Equal(t, map[ImportAction]int{ImportActionDelete: 1}, changeCount(job.getStatus().Plan))
+		require.Empty(t, alertRuleTitles(t))
+	})
+}
+
+func writeTestFile(t *testing.T, fpath, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(fpath), 0750))
+	require.NoError(t, os.WriteFile(fpath, []byte(content), 0600))
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// planAlertRules reads the alert rules written by exportAlerts. Rules are
+// written with SQL so they keep their UIDs, a new version is recorded for
+// every change like the alerting API does.
+func planAlertRules(helper *importHelper, job *gitImportJob) ([]*ImportChange, error) {
+	files, ok, err := findFiles(filepath.Join(helper.orgDir, "alerts"), ".json")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var rows []*ngmodels.AlertRule
+	err = job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("alert_rule").Where("org_id = ?", helper.orgID).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*ngmodels.AlertRule, len(rows))
+	for _, row := range rows {
+		existing[row.UID] = row
+	}
+
+	var changes []*ImportChange
+	inRepo := make(map[string]bool, len(files))
+	for _, fpath := range files {
+		r := &alertRuleFile{}
+		if err := readJSON(fpath, r); err != nil {
+			return nil, fmt.Errorf("%w, alert rules exported by older versions have to be exported again", err)
+		}
+		if r.UID == "" {
+			r.UID = strings.TrimSuffix(filepath.Base(fpath), ".json")
+		}
+		rule, err := r.alertRule(helper.orgID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert rule in %s: %w", filepath.Base(fpath), err)
+		}
+		inRepo[r.UID] = true
+
+		action := ImportActionCreate
+		current, exists := existing[r.UID]
+		if exists {
+			if sameAlertRule(newAlertRuleFile(current), r) {
+				continue
+			}
+			action = ImportActionUpdate
+		}
+		changes = append(changes, &ImportChange{
+			Kind:   "alerts",
+			Action: action,
+			UID:    r.UID,
+			Name:   r.Title,
+			Path:   helper.relPath(fpath),
+			apply: func(ctx context.Context) error {
+				return job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+					return saveAlertRule(sess, rule, current)
+				})
+			},
+		})
+	}
+
+	for _, row := range rows {
+		row := row
+		if inRepo[row.UID] {
+			continue
+		}
+		changes = append(changes, &ImportChange{
+			Kind:   "alerts",
+			Action: ImportActionDelete,
+			UID:    row.UID,
+			Name:   row.Title,
+			apply: func(ctx context.Context) error {
+				return job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+					return deleteAlertRule(sess, helper.orgID, row.UID)
+				})
+			},
+		})
+	}
+
+	return changes, nil
+}
+
+// alertRule returns the alert rule of the file in an org
+func (r *alertRuleFile) alertRule(orgID int64) (*ngmodels.AlertRule, error) {
+	if r.Title == "" || r.NamespaceUID == "" || r.RuleGroup == "" {
+		return nil, errors.New("title, folder and group are required")
+	}
+	if r.Condition == "" || len(r.Data) == 0 {
+		return nil, errors.New("condition and queries are required")
+	}
+	if r.IntervalSeconds <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+	var forDuration time.Duration
+	if r.For != "" {
+		d, err := time.ParseDuration(r.For)
+		if err != nil {
+			return nil, fmt.Errorf("invalid for duration: %w", err)
+		}
+		forDuration = d
+	}
+	return &ngmodels.AlertRule{
+		OrgID:           orgID,
+		Title:           r.Title,
+		Condition:       r.Condition,
+		Data:            r.Data,
+		IntervalSeconds: r.IntervalSeconds,
+		UID:             r.UID,
+		NamespaceUID:    r.NamespaceUID,
+		DashboardUID:    r.DashboardUID,
+		PanelID:         r.PanelID,
+		RuleGroup:       r.RuleGroup,
+		RuleGroupIndex:  r.RuleGroupIndex,
+		NoDataState:     r.NoDataState,
+		ExecErrState:    r.ExecErrState,
+		For:             forDuration,
+		Annotations:     r.Annotations,
+		Labels:          r.Labels,
+	}, nil
+}
+
+// saveAlertRule creates the rule, or updates current if it exists
+func saveAlertRule(sess *sqlstore.DBSession, rule *ngmodels.AlertRule, current *ngmodels.AlertRule) error {
+	// folders are imported before alert rules
+	folderExists, err := sess.Where("org_id = ? AND uid = ? AND is_folder = ?", rule.OrgID, rule.NamespaceUID, true).Exist(&models.Dashboard{})
+	if err != nil {
+		return err
+	}
+	if !folderExists {
+		return fmt.Errorf("folder %q not found", rule.NamespaceUID)
+	}
+
+	r := *rule
+	if err := r.PreSave(time.Now); err != nil {
+		return err
+	}
+	// xorm sets the version of the rule when it's saved
+	var parentVersion, version int64 = 0, 1
+	if current == nil {
+		if _, err := sess.Insert(&r); err != nil {
+			return err
+		}
+	} else {
+		r.ID = current.ID
+		r.Version = current.Version
+		if _, err := sess.ID(r.ID).AllCols().Update(&r); err != nil {
+			return err
+		}
+		parentVersion, version = current.Version, current.Version+1
+	}
+
+	// inserted as a slice like the alerting store does, xorm sets the version
+	// of single inserted rows to 1
+	_, err = sess.Insert(&[]ngmodels.AlertRuleVersion{{
+		RuleOrgID:        r.OrgID,
+		RuleUID:          r.UID,
+		RuleNamespaceUID: r.NamespaceUID,
+		RuleGroup:        r.RuleGroup,
+		RuleGroupIndex:   r.RuleGroupIndex,
+		ParentVersion:    parentVersion,
+		Version:          version,
+		Created:          r.Updated,
+		Condition:        r.Condition,
+		Title:            r.Title,
+		Data:             r.Data,
+		IntervalSeconds:  r.IntervalSeconds,
+		NoDataState:      r.NoDataState,
+		ExecErrState:     r.ExecErrState,
+		For:              r.For,
+		Annotations:      r.Annotations,
+		Labels:           r.Labels,
+	}})
+	return err
+}
+
+func deleteAlertRule(sess *sqlstore.DBSession, orgID int64, uid string) error {
+	if _, err := sess.Exec("DELETE FROM alert_rule WHERE org_id = ? AND uid = ?", orgID, uid); err != nil {
+		return err
+	}
+	if _, err := sess.Exec("DELETE FROM alert_rule_version WHERE rule_org_id = ? AND rule_uid = ?", orgID, uid); err != nil {
+		return err
+	}
+	_, err := sess.Exec("DELETE FROM alert_instance WHERE rule_org_id = ? AND rule_uid = ?", orgID, uid)
+	return err
+}
+
+// sameAlertRule compares rules by their JSON, so formatting of the query
+// models doesn't matter
+func sameAlertRule(current, imported *alertRuleFile) bool {
+	normalize := func(r *alertRuleFile) interface{} {
+		var v interface{}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return nil
+		}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil
+		}
+		return v
+	}
+	a, b := normalize(current), normalize(imported)
+	return a != nil && reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,331 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const folderFileName = "__folder.json"
+
+type importFolder struct {
+	uid   string
+	title string
+	fpath string
+}
+
+type importDashboard struct {
+	uid       string
+	folderUID string // empty for the general folder
+	fpath     string
+	data      map[string]interface{}
+}
+
+// planDashboards reads the folders and dashboards written by exportDashboards.
+// Only one level of folders is supported, dashboards in a folder without a
+// __folder.json are in the general folder.
+func planDashboards(helper *importHelper, job *gitImportJob) ([]*ImportChange, error) {
+	rootDir := filepath.Join(helper.orgDir, "root")
+	if _, err := os.Stat(rootDir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	// UIDs are not in the dashboard JSON, the alias file maps them to the paths
+	alias := make(map[string]string, 100)
+	err := readJSON(filepath.Join(helper.orgDir, "root-alias.json"), &alias)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	uids := make(map[string]string, len(alias))
+	for uid, p := range alias {
+		uids[p] = uid
+	}
+
+	folders, dashes, err := readDashboardFiles(rootDir, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	type dashRow struct {
+		Id       int64
+		UID      string `xorm:"uid"`
+		IsFolder bool   `xorm:"is_folder"`
+		FolderID int64  `xorm:"folder_id"`
+		Title    string `xorm:"title"`
+		Data     []byte
+	}
+	rows := make([]*dashRow, 0)
+	provisioned := make([]int64, 0)
+	err = job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		sess.Table("dashboard").
+			Where("org_id = ?", helper.orgID).
+			Cols("id", "uid", "is_folder", "folder_id", "title", "data")
+		if err := sess.Find(&rows); err != nil {
+			return err
+		}
+		return sess.Table("dashboard_provisioning").Cols("dashboard_id").Find(&provisioned)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]*dashRow, len(rows))
+	// folder IDs by UID, folders created by the import are added when applied
+	folderIDs := make(map[string]int64)
+	folderUIDs := make(map[int64]string)
+	for _, row := range rows {
+		existing[row.UID] = row
+		if row.IsFolder {
+			folderIDs[row.UID] = row.Id
+			folderUIDs[row.Id] = row.UID
+		}
+	}
+	isProvisioned := make(map[int64]bool, len(provisioned))
+	for _, id := range provisioned {
+		isProvisioned[id] = true
+	}
+
+	var changes, deletes []*ImportChange
+	for _, f := range folders {
+		f := f
+		action := ImportActionCreate
+		var id int64
+		if row, ok := existing[f.uid]; ok {
+			if !row.IsFolder {
+				return nil, fmt.Errorf("folder %s has the UID of a dashboard: %s", helper.relPath(f.fpath), f.uid)
+			}
+			if row.Title == f.title {
+				continue
+			}
+			action = ImportActionUpdate
+			id = row.Id
+		}
+
+		changes = append(changes, &ImportChange{
+			Kind:   "dash",
+			Action: action,
+			UID:    f.uid,
+			Name:   f.title,
+			Path:   helper.relPath(f.fpath),
+			apply: func(ctx context.Context) error {
+				dash, err := job.dashboardStore.SaveDashboard(ctx, models.SaveDashboardCommand{
+					Dashboard: simplejson.NewFromAny(map[string]interface{}{
+						"id":    id,
+						"uid":   f.uid,
+						"title": f.title,
+					}),
+					OrgId:     helper.orgID,
+					IsFolder:  true,
+					Overwrite: true,
+					Message:   helper.message(),
+				})
+				if err != nil {
+					return err
+				}
+				folderIDs[f.uid] = dash.Id
+				return nil
+			},
+		})
+	}
+
+	for _, d := range dashes {
+		d := d
+		title, _ := d.data["title"].(string)
+		action := ImportActionCreate
+		var id int64
+		if row, ok := existing[d.uid]; ok {
+			if row.IsFolder {
+				return nil, fmt.Errorf("dashboard %s has the UID of a folder: %s", helper.relPath(d.fpath), d.uid)
+			}
+			if isProvisioned[row.Id] {
+				continue // managed by provisioning
+			}
+			if folderUIDs[row.FolderID] == d.folderUID && sameDashboardJSON(row.Data, d.data) {
+				continue
+			}
+			action = ImportActionUpdate
+			id = row.Id
+		}
+
+		changes = append(changes, &ImportChange{
+			Kind:   "dash",
+			Action: action,
+			UID:    d.uid,
+			Name:   title,
+			Path:   helper.relPath(d.fpath),
+			apply: func(ctx context.Context) error {
+				data := make(map[string]interface{}, len(d.data))
+				for k, v := range d.data {
+					data[k] = v
+				}
+				// the store updates by ID, the version is set by Overwrite
+				delete(data, "version")
+				data["id"] = id
+				data["uid"] = d.uid
+
+				var folderID int64
+				if d.folderUID != "" {
+					folderID = folderIDs[d.folderUID]
+				}
+				_, err := job.dashboardStore.SaveDashboard(ctx, models.SaveDashboardCommand{
+					Dashboard: simplejson.NewFromAny(data),
+					OrgId:     helper.orgID,
+					FolderId:  folderID,
+					Overwrite: true,
+					Message:   helper.message(),
+				})
+				return err
+			},
+		})
+	}
+
+	inRepo := make(map[string]bool, len(folders)+len(dashes))
+	for _, f := range folders {
+		inRepo[f.uid] = true
+	}
+	for _, d := range dashes {
+		inRepo[d.uid] = true
+	}
+	// dashboards are deleted before folders so deleting a folder doesn't
+	// delete dashboards moved out of it
+	sort.SliceStable(rows, func(i, j int) bool {
+		return !rows[i].IsFolder && rows[j].IsFolder
+	})
+	for _, row := range rows {
+		row := row
+		if inRepo[row.UID] || isProvisioned[row.Id] {
+			continue
+		}
+		deletes = append(deletes, &ImportChange{
+			Kind:   "dash",
+			Action: ImportActionDelete,
+			UID:    row.UID,
+			Name:   row.Title,
+			apply: func(ctx context.Context) error {
+				return job.dashboardStore.DeleteDashboard(ctx, &models.DeleteDashboardCommand{
+					Id:    row.Id,
+					OrgId: helper.orgID,
+				})
+			},
+		})
+	}
+
+	return append(changes, deletes...), nil
+}
+
+// readDashboardFiles reads folders and dashboards in rootDir, uids maps paths
+// relative to rootDir to UIDs. Files without a UID get one based on their path.
+func readDashboardFiles(rootDir string, uids map[string]string) ([]*importFolder, []*importDashboard, error) {
+	var folders []*importFolder
+	var dashes []*importDashboard
+
+	readDashboards := func(dir, folderUID string) error {
+		files, _, err := findFiles(dir, ".json")
+		if err != nil {
+			return err
+		}
+		for _, fpath := range files {
+			if filepath.Base(fpath) == folderFileName {
+				continue
+			}
+			rel, err := filepath.Rel(rootDir, fpath)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			d := &importDashboard{
+				folderUID: folderUID,
+				fpath:     fpath,
+			}
+			if err := readJSON(fpath, &d.data); err != nil {
+				return err
+			}
+			d.uid = uids[rel]
+			if d.uid == "" {
+				d.uid, _ = d.data["uid"].(string)
+			}
+			if d.uid == "" {
+				d.uid = uidFromPath("dash", rel)
+			}
+			dashes = append(dashes, d)
+		}
+		return nil
+	}
+
+	if err := readDashboards(rootDir, ""); err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := os.ReadDir(rootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(rootDir, entry.Name())
+		slug := entry.Name()
+
+		var folderUID string
+		folderPath := filepath.Join(dir, folderFileName)
+		folder := map[string]string{}
+		err := readJSON(folderPath, &folder)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// e.g. the general folder path of the export
+		case err != nil:
+			return nil, nil, err
+		default:
+			f := &importFolder{
+				uid:   uids[slug],
+				title: folder["title"],
+				fpath: folderPath,
+			}
+			if f.uid == "" {
+				f.uid = uidFromPath("folder", slug)
+			}
+			if f.title == "" {
+				f.title = slug
+			}
+			folders = append(folders, f)
+			folderUID = f.uid
+		}
+
+		if err := readDashboards(dir, folderUID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return folders, dashes, nil
+}
+
+// sameDashboardJSON compares dashboard JSON ignoring the properties removed
+// by the export
+func sameDashboardJSON(data []byte, dash map[string]interface{}) bool {
+	var current map[string]interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return false
+	}
+
+	imported := make(map[string]interface{}, len(dash))
+	for k, v := range dash {
+		imported[k] = v
+	}
+	for _, k := range []string{"id", "uid", "version"} {
+		delete(current, k)
+		delete(imported, k)
+	}
+	return reflect.DeepEqual(current, imported)
+}
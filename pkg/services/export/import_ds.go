@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+// planDataSources reads the data sources written by exportDataSources. Secure
+// JSON data is not exported, it's kept when a data source is updated.
+func planDataSources(helper *importHelper, job *gitImportJob) ([]*ImportChange, error) {
+	files, ok, err := findFiles(filepath.Join(helper.orgDir, "datasources"), "-ds.json")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	cmd := &datasources.GetDataSourcesQuery{
+		OrgId: helper.orgID,
+	}
+	if err := job.datasourceService.GetDataSources(helper.ctx, cmd); err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*datasources.DataSource, len(cmd.Result))
+	for _, ds := range cmd.Result {
+		existing[ds.Uid] = ds
+	}
+
+	var changes []*ImportChange
+	inRepo := make(map[string]bool, len(files))
+	for _, fpath := range files {
+		ds := &datasources.DataSource{}
+		if err := readJSON(fpath, ds); err != nil {
+			return nil, err
+		}
+		if ds.Uid == "" {
+			ds.Uid = strings.TrimSuffix(filepath.Base(fpath), "-ds.json")
+		}
+		if ds.JsonData == nil {
+			ds.JsonData = simplejson.New()
+		}
+		inRepo[ds.Uid] = true
+
+		change := &ImportChange{
+			Kind:   "ds",
+			Action: ImportActionCreate,
+			UID:    ds.Uid,
+			Name:   ds.Name,
+			Path:   helper.relPath(fpath),
+		}
+		current, ok := existing[ds.Uid]
+		if ok {
+			if sameDataSource(current, ds) {
+				continue
+			}
+			change.Action = ImportActionUpdate
+			change.apply = func(ctx context.Context) error {
+				return job.datasourceService.UpdateDataSource(ctx, &datasources.UpdateDataSourceCommand{
+					Name:            ds.Name,
+					Type:            ds.Type,
+					Access:          ds.Access,
+					Url:             ds.Url,
+					User:            ds.User,
+					Database:        ds.Database,
+					BasicAuth:       ds.BasicAuth,
+					BasicAuthUser:   ds.BasicAuthUser,
+					WithCredentials: ds.WithCredentials,
+					IsDefault:       ds.IsDefault,
+					JsonData:        ds.JsonData,
+					Version:         current.Version,
+					Uid:             ds.Uid,
+					OrgId:           helper.orgID,
+					Id:              current.Id,
+					ReadOnly:        ds.ReadOnly,
+				})
+			}
+		} else {
+			change.apply = func(ctx context.Context) error {
+				return job.datasourceService.AddDataSource(ctx, &datasources.AddDataSourceCommand{
+					Name:            ds.Name,
+					Type:            ds.Type,
+					Access:          ds.Access,
+					Url:             ds.Url,
+					Database:        ds.Database,
+					User:            ds.User,
+					BasicAuth:       ds.BasicAuth,
+					BasicAuthUser:   ds.BasicAuthUser,
+					WithCredentials: ds.WithCredentials,
+					IsDefault:       ds.IsDefault,
+					JsonData:        ds.JsonData,
+					Uid:             ds.Uid,
+					OrgId:           helper.orgID,
+					ReadOnly:        ds.ReadOnly,
+				})
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	for _, ds := range cmd.Result {
+		ds := ds
+		if inRepo[ds.Uid] {
+			continue
+		}
+		changes = append(changes, &ImportChange{
+			Kind:   "ds",
+			Action: ImportActionDelete,
+			UID:    ds.Uid,
+			Name:   ds.Name,
+			apply: func(ctx context.Context) error {
+				return job.datasourceService.DeleteDataSource(ctx, &datasources.DeleteDataSourceCommand{
+					ID:    ds.Id,
+					UID:   ds.Uid,
+					OrgID: helper.orgID,
+				})
+			},
+		})
+	}
+
+	return changes, nil
+}
+
+// sameDataSource compares data sources ignoring the properties which are
+// not imported
+func sameDataSource(current, imported *datasources.DataSource) bool {
+	clean := func(ds datasources.DataSource) []byte {
+		ds.Id = 0
+		ds.OrgId = 0
+		ds.Version = 0
+		ds.Created = time.Time{}
+		ds.Updated = time.Time{}
+		ds.SecureJsonData = nil
+		if ds.JsonData == nil {
+			ds.JsonData = simplejson.New()
+		}
+		b, _ := json.Marshal(ds)
+		return b
+	}
+	return bytes.Equal(clean(*current), clean(*imported))
+}
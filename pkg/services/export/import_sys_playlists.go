@@ -0,0 +1,156 @@
+package export
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/playlist"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// planSystemPlaylists reads the playlists written by exportSystemPlaylists.
+// Playlists are written with SQL so they keep their UIDs.
+func planSystemPlaylists(helper *importHelper, job *gitImportJob) ([]*ImportChange, error) {
+	files, ok, err := findFiles(filepath.Join(helper.orgDir, "system", "playlists"), "-playlist.json")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	res, err := job.playlistService.Search(helper.ctx, &playlist.GetPlaylistsQuery{
+		OrgId: helper.orgID,
+		Limit: 500000,
+	})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*playlist.PlaylistDTO, len(res))
+	for _, item := range res {
+		dto, err := job.playlistService.Get(helper.ctx, &playlist.GetPlaylistByUidQuery{
+			UID:   item.UID,
+			OrgId: helper.orgID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		existing[item.UID] = dto
+	}
+
+	var changes []*ImportChange
+	inRepo := make(map[string]bool, len(files))
+	for _, fpath := range files {
+		p := &playlist.PlaylistDTO{}
+		if err := readJSON(fpath, p); err != nil {
+			return nil, err
+		}
+		if p.Uid == "" {
+			p.Uid = strings.TrimSuffix(filepath.Base(fpath), "-playlist.json")
+		}
+		inRepo[p.Uid] = true
+
+		action := ImportActionCreate
+		if current, ok := existing[p.Uid]; ok {
+			if samePlaylist(current, p) {
+				continue
+			}
+			action = ImportActionUpdate
+		}
+		changes = append(changes, &ImportChange{
+			Kind:   "system_playlists",
+			Action: action,
+			UID:    p.Uid,
+			Name:   p.Name,
+			Path:   helper.relPath(fpath),
+			apply: func(ctx context.Context) error {
+				return job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+					return savePlaylist(sess, helper.orgID, p)
+				})
+			},
+		})
+	}
+
+	for _, item := range res {
+		item := item
+		if inRepo[item.UID] {
+			continue
+		}
+		changes = append(changes, &ImportChange{
+			Kind:   "system_playlists",
+			Action: ImportActionDelete,
+			UID:    item.UID,
+			Name:   item.Name,
+			apply: func(ctx context.Context) error {
+				return job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+					return deletePlaylist(sess, item.Id)
+				})
+			},
+		})
+	}
+
+	return changes, nil
+}
+
+func savePlaylist(sess *sqlstore.DBSession, orgID int64, dto *playlist.PlaylistDTO) error {
+	p := playlist.Playlist{UID: dto.Uid, OrgId: orgID}
+	exists, err := sess.Get(&p)
+	if err != nil {
+		return err
+	}
+	p.Name = dto.Name
+	p.Interval = dto.Interval
+	if exists {
+		if _, err := sess.ID(p.Id).Cols("name", "interval").Update(&p); err != nil {
+			return err
+		}
+		if _, err := sess.Exec("DELETE FROM playlist_item WHERE playlist_id = ?", p.Id); err != nil {
+			return err
+		}
+	} else if _, err := sess.Insert(&p); err != nil {
+		return err
+	}
+
+	if dto.Items == nil || len(*dto.Items) == 0 {
+		return nil
+	}
+	items := make([]playlist.PlaylistItem, 0, len(*dto.Items))
+	for i, item := range *dto.Items {
+		var title string
+		if item.Title != nil {
+			title = *item.Title
+		}
+		items = append(items, playlist.PlaylistItem{
+			PlaylistId: p.Id,
+			Type:       string(item.Type),
+			Value:      item.Value,
+			Order:      i + 1,
+			Title:      title,
+		})
+	}
+	_, err = sess.Insert(&items)
+	return err
+}
+
+func deletePlaylist(sess *sqlstore.DBSession, id int64) error {
+	if _, err := sess.Exec("DELETE FROM playlist_item WHERE playlist_id = ?", id); err != nil {
+		return err
+	}
+	_, err := sess.Exec("DELETE FROM playlist WHERE id = ?", id)
+	return err
+}
+
+// samePlaylist compares playlists ignoring the unused item titles
+func samePlaylist(current, imported *playlist.PlaylistDTO) bool {
+	clean := func(p playlist.PlaylistDTO) playlist.PlaylistDTO {
+		items := make([]playlist.PlaylistItemDTO, 0)
+		if p.Items != nil {
+			for _, item := range *p.Items {
+				item.Title = nil
+				items = append(items, item)
+			}
+		}
+		p.Items = &items
+		return p
+	}
+	return reflect.DeepEqual(clean(*current), clean(*imported))
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type importPreferences struct {
+	Theme         string                       `json:"theme"`
+	Locale        string                       `json:"locale"`
+	Timezone      string                       `json:"timezone"`
+	WeekStart     string                       `json:"week_start,omitempty"`
+	HomeDashboard string                       `json:"home,omitempty"` // dashboard UID
+	NavBar        *pref.NavbarPreference       `json:"navbar,omitempty"`
+	QueryHistory  *pref.QueryHistoryPreference `json:"queryHistory,omitempty"`
+}
+
+// planSystemPreferences reads the default, team and user preferences written
+// by exportSystemPreferences. Preferences of teams and users which don't
+// exist are skipped, preferences are never deleted.
+func planSystemPreferences(helper *importHelper, job *gitImportJob) ([]*ImportChange, error) {
+	prefsDir := filepath.Join(helper.orgDir, "system", "preferences")
+	if _, err := os.Stat(prefsDir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	type prefsRow struct {
+		UserID        int64                    `xorm:"user_id"`
+		TeamID        int64                    `xorm:"team_id"`
+		Theme         string                   `xorm:"theme"`
+		Timezone      string                   `xorm:"timezone"`
+		WeekStart     string                   `xorm:"week_start"`
+		JSONData      *pref.PreferenceJSONData `xorm:"json_data"`
+		HomeDashboard string                   `xorm:"uid"`
+	}
+	type userRow struct {
+		ID    int64  `xorm:"id"`
+		Login string `xorm:"login"`
+	}
+	rows := make([]*prefsRow, 0)
+	users := make([]*userRow, 0)
+	teams := make([]int64, 0)
+	err := job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		sess.Table("preferences").
+			Join("LEFT", "dashboard", "dashboard.id = preferences.home_dashboard_id").
+			Cols("preferences.user_id", "preferences.team_id", "preferences.theme", "preferences.timezone",
+				"preferences.week_start", "preferences.json_data", "dashboard.uid").
+			Where("preferences.org_id = ?", helper.orgID)
+		if err := sess.Find(&rows); err != nil {
+			return err
+		}
+
+		sess.Table("user").
+			Join("inner", "org_user", "user.id = org_user.user_id").
+			Cols("user.id", "user.login").
+			Where("org_user.org_id = ?", helper.orgID)
+		if err := sess.Find(&users); err != nil {
+			return err
+		}
+
+		return sess.Table("team").Where("org_id = ?", helper.orgID).Cols("id").Find(&teams)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type prefsKey struct {
+		userID int64
+		teamID int64
+	}
+	existing := make(map[prefsKey]importPreferences, len(rows))
+	for _, row := range rows {
+		p := importPreferences{
+			Theme:         row.Theme,
+			Timezone:      row.Timezone,
+			WeekStart:     row.WeekStart,
+			HomeDashboard: row.HomeDashboard,
+		}
+		if row.JSONData != nil {
+			p.Locale = row.JSONData.Locale
+			p.NavBar = &row.JSONData.Navbar
+			p.QueryHistory = &row.JSONData.QueryHistory
+		}
+		existing[prefsKey{userID: row.UserID, teamID: row.TeamID}] = p
+	}
+	userIDs := make(map[string]int64, len(users))
+	for _, u := range users {
+		userIDs[u.Login] = u.ID
+	}
+	teamExists := make(map[int64]bool, len(teams))
+	for _, id := range teams {
+		teamExists[id] = true
+	}
+
+	files := map[string]prefsKey{}
+	if _, err := os.Stat(filepath.Join(prefsDir, "default.json")); err == nil {
+		files[filepath.Join(prefsDir, "default.json")] = prefsKey{}
+	}
+	teamFiles, _, err := findFiles(filepath.Join(prefsDir, "team"), ".json")
+	if err != nil {
+		return nil, err
+	}
+	for _, fpath := range teamFiles {
+		id, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(fpath), ".json"), 10, 64)
+		if err != nil || !teamExists[id] {
+			job.logger.Warn("skipping preferences of unknown team", "path", helper.relPath(fpath))
+			continue
+		}
+		files[fpath] = prefsKey{teamID: id}
+	}
+	userFiles, _, err := findFiles(filepath.Join(prefsDir, "user"), ".json")
+	if err != nil {
+		return nil, err
+	}
+	for _, fpath := range userFiles {
+		id, ok := userIDs[strings.TrimSuffix(filepath.Base(fpath), ".json")]
+		if !ok {
+			job.logger.Warn("skipping preferences of unknown user", "path", helper.relPath(fpath))
+			continue
+		}
+		files[fpath] = prefsKey{userID: id}
+	}
+
+	var changes []*ImportChange
+	for _, fpath := range sortedKeys(files) {
+		key := files[fpath]
+		p := importPreferences{}
+		if err := readJSON(fpath, &p); err != nil {
+			return nil, err
+		}
+
+		action := ImportActionCreate
+		current, ok := existing[key]
+		if ok {
+			action = ImportActionUpdate
+		}
+		if samePreferences(current, p) {
+			continue
+		}
+
+		name := "default"
+		if key.teamID > 0 {
+			name = fmt.Sprintf("team %d", key.teamID)
+		} else if key.userID > 0 {
+			name = "user " + strings.TrimSuffix(filepath.Base(fpath), ".json")
+		}
+		changes = append(changes, &ImportChange{
+			Kind:   "system_preferences",
+			Action: action,
+			Name:   name,
+			Path:   helper.relPath(fpath),
+			apply: func(ctx context.Context) error {
+				return job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+					return savePreferences(sess, helper.orgID, key.userID, key.teamID, p)
+				})
+			},
+		})
+	}
+
+	return changes, nil
+}
+
+func savePreferences(sess *sqlstore.DBSession, orgID, userID, teamID int64, p importPreferences) error {
+	var homeDashboardID int64
+	if p.HomeDashboard != "" {
+		_, err := sess.Table("dashboard").
+			Where("org_id = ? AND uid = ?", orgID, p.HomeDashboard).
+			Cols("id").
+			Get(&homeDashboardID)
+		if err != nil {
+			return err
+		}
+	}
+
+	jsonData := &pref.PreferenceJSONData{
+		Locale: p.Locale,
+	}
+	if p.NavBar != nil {
+		jsonData.Navbar = *p.NavBar
+	}
+	if p.QueryHistory != nil {
+		jsonData.QueryHistory = *p.QueryHistory
+	}
+
+	var row pref.Preference
+	exists, err := sess.Where("org_id=? AND user_id=? AND team_id=?", orgID, userID, teamID).Get(&row)
+	if err != nil {
+		return err
+	}
+	row.OrgID = orgID
+	row.UserID = userID
+	row.TeamID = teamID
+	row.Theme = p.Theme
+	row.Timezone = p.Timezone
+	row.WeekStart = p.WeekStart
+	row.HomeDashboardID = homeDashboardID
+	row.JSONData = jsonData
+	row.Updated = time.Now()
+	if exists {
+		row.Version++
+		_, err = sess.ID(row.ID).AllCols().Update(&row)
+		return err
+	}
+	row.Created = row.Updated
+	_, err = sess.Insert(&row)
+	return err
+}
+
+// samePreferences compares preferences, missing navbar and query history
+// preferences are the same as empty ones
+func samePreferences(current, imported importPreferences) bool {
+	clean := func(p importPreferences) importPreferences {
+		navbar := pref.NavbarPreference{}
+		if p.NavBar != nil {
+			navbar = *p.NavBar
+		}
+		if navbar.SavedItems == nil {
+			navbar.SavedItems = []pref.NavLink{}
+		}
+		p.NavBar = &navbar
+		if p.QueryHistory == nil {
+			p.QueryHistory = &pref.QueryHistoryPreference{}
+		}
+		return p
+	}
+	return reflect.DeepEqual(clean(current), clean(imported))
+}
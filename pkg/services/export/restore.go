@@ -0,0 +1,464 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	ErrBackupVersion    = errors.New("backup archive was created by a newer version")
+	ErrBackupPassphrase = errors.New("invalid passphrase for the backup secrets")
+	ErrBackupInvalid    = errors.New("invalid backup archive")
+)
+
+// RestoreOptions configures how a backup is restored
+type RestoreOptions struct {
+	// Name of the new org, defaults to the name of the backed up org
+	OrgName string
+	// Passphrase the secrets were encrypted with, secrets are not restored
+	// without it
+	Passphrase string
+	// Replace the UIDs of dashboards, folders, data sources, alert rules,
+	// library panels and playlists
+	NewUIDs bool
+	// User added as an admin of the new org
+	AdminUserID int64
+}
+
+// RestoreResult is the summary of a restore
+type RestoreResult struct {
+	OrgID    int64          `json:"orgId"`
+	OrgName  string         `json:"orgName"`
+	Restored map[string]int `json:"restored"` // rows by table
+	Skipped  map[string]int `json:"skipped"`  // rows referencing rows which weren't restored
+}
+
+type restoreHelper struct {
+	ctx     context.Context
+	s       *BackupService
+	sess    *sqlstore.DBSession
+	orgID   int64
+	secrets *backupSecrets
+
+	ids      map[string]map[int64]int64 // new IDs by table
+	uids     map[string]string          // new UIDs
+	replacer *strings.Replacer          // replaces the UIDs in JSON
+}
+
+// Restore creates a new org from a backup archive. IDs are always replaced,
+// all rows are restored in one transaction.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) (*RestoreResult, error) {
+	files, err := readArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &BackupManifest{}
+	if err := readArchiveJSON(files, backupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version > BackupFormatVersion {
+		return nil, ErrBackupVersion
+	}
+
+	tables := make(map[string]*backupTableData, len(backupTables))
+	for _, t := range backupTables {
+		name := path.Join(backupTablesDir, t.name+".json")
+		if _, ok := files[name]; !ok {
+			continue // tables added in later versions
+		}
+		data := &backupTableData{}
+		if err := readArchiveJSON(files, name, data); err != nil {
+			return nil, err
+		}
+		tables[t.name] = data
+	}
+	if err := s.validateTables(ctx, tables); err != nil {
+		return nil, err
+	}
+
+	sec := &backupSecrets{}
+	if encrypted, ok := files[backupSecretsFile]; ok && opts.Passphrase != "" {
+		decrypted, err := util.Decrypt(encrypted, opts.Passphrase)
+		if err != nil {
+			return nil, ErrBackupPassphrase
+		}
+		if err := json.Unmarshal(decrypted, sec); err != nil {
+			return nil, ErrBackupPassphrase
+		}
+	}
+
+	result := &RestoreResult{
+		OrgName:  opts.OrgName,
+		Restored: make(map[string]int, len(tables)),
+		Skipped:  make(map[string]int),
+	}
+	if result.OrgName == "" {
+		result.OrgName = manifest.OrgName
+	}
+
+	err = s.sql.InTransaction(ctx, func(ctx context.Context) error {
+		return s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			orgID, err := createRestoreOrg(sess, result.OrgName)
+			if err != nil {
+				return err
+			}
+			result.OrgID = orgID
+
+			helper := &restoreHelper{
+				ctx:     ctx,
+				s:       s,
+				sess:    sess,
+				orgID:   orgID,
+				secrets: sec,
+				ids:     make(map[string]map[int64]int64, len(tables)),
+				uids:    make(map[string]string),
+			}
+			if opts.NewUIDs {
+				helper.generateUIDs(tables)
+			}
+
+			for _, t := range backupTables {
+				data, ok := tables[t.name]
+				if !ok {
+					continue
+				}
+				restored, skipped, err := helper.restoreTable(t, data)
+				if err != nil {
+					return fmt.Errorf("failed to restore %s: %w", t.name, err)
+				}
+				result.Restored[t.name] = restored
+				if skipped > 0 {
+					result.Skipped[t.name] = skipped
+				}
+			}
+
+			if opts.AdminUserID > 0 {
+				return helper.addAdmin(opts.AdminUserID)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Restored org from backup", "orgId", result.OrgID, "name", result.OrgName, "from", manifest.OrgName)
+	return result, nil
+}
+
+// validateTables checks that the columns of the tables in an archive exist in
+// the database, since they are inserted by name, and that every row has a
+// value for each column.
+func (s *BackupService) validateTables(ctx context.Context, tables map[string]*backupTableData) error {
+	return s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, t := range backupTables {
+			data, ok := tables[t.name]
+			if !ok {
+				continue
+			}
+			cols, err := s.tableColumns(ctx, sess, t.name)
+			if err != nil {
+				return fmt.Errorf("failed to read columns of %s: %w", t.name, err)
+			}
+
+			seen := make(map[string]bool, len(data.Columns))
+			for _, c := range data.Columns {
+				if !cols[c.Name] {
+					return fmt.Errorf("%w: table %s has no column %q", ErrBackupInvalid, t.name, c.Name)
+				}
+				if seen[c.Name] {
+					return fmt.Errorf("%w: column %s of table %s is duplicated", ErrBackupInvalid, c.Name, t.name)
+				}
+				seen[c.Name] = true
+			}
+			for i, row := range data.Rows {
+				if len(row) != len(data.Columns) {
+					return fmt.Errorf("%w: row %d of table %s has %d values, expected %d", ErrBackupInvalid, i, t.name, len(row), len(data.Columns))
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// tableColumns returns the columns of a table in the database
+func (s *BackupService) tableColumns(ctx context.Context, sess *sqlstore.DBSession, table string) (map[string]bool, error) {
+	rows, err := sess.DB().DB.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", s.sql.Quote(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.Warn("failed to close rows", "table", table, "err", err)
+		}
+	}()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]bool, len(names))
+	for _, name := range names {
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+// createRestoreOrg creates the org the backup is restored to, backups are
+// never restored to an existing org
+func createRestoreOrg(sess *sqlstore.DBSession, name string) (int64, error) {
+	exists, err := sess.Where("name = ?", name).Exist(&models.Org{})
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, models.ErrOrgNameTaken
+	}
+
+	o := models.Org{
+		Name:    name,
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	if _, err := sess.Insert(&o); err != nil {
+		return 0, err
+	}
+	sess.PublishAfterCommit(&events.OrgCreated{
+		Timestamp: o.Created,
+		Id:        o.Id,
+		Name:      o.Name,
+	})
+	return o.Id, nil
+}
+
+// generateUIDs creates new UIDs for all rows with UIDs before any row is
+// restored, JSON can reference rows which are restored later. Data source
+// secrets are kept by the new UIDs.
+func (r *restoreHelper) generateUIDs(tables map[string]*backupTableData) {
+	pairs := make([]string, 0)
+	for _, t := range backupTables {
+		data, ok := tables[t.name]
+		if !ok || t.uid == "" {
+			continue
+		}
+		for _, row := range data.Rows {
+			old := data.stringValue(row, t.uid)
+			if old == "" {
+				continue
+			}
+			if _, ok := r.uids[old]; ok {
+				continue
+			}
+			uid := util.GenerateShortUID()
+			r.uids[old] = uid
+			pairs = append(pairs, `"`+old+`"`, `"`+uid+`"`)
+		}
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+
+	dsSecrets := make(map[string]map[string]string, len(r.secrets.DataSources))
+	for uid, values := range r.secrets.DataSources {
+		dsSecrets[r.newUID(uid)] = values
+	}
+	r.secrets.DataSources = dsSecrets
+}
+
+func (r *restoreHelper) newUID(uid string) string {
+	if newUID, ok := r.uids[uid]; ok {
+		return newUID
+	}
+	return uid
+}
+
+func (r *restoreHelper) restoreTable(t *backupTable, data *backupTableData) (int, int, error) {
+	ids := make(map[int64]int64, len(data.Rows))
+	r.ids[t.name] = ids
+	restored, skipped := 0, 0
+	for _, row := range data.Rows {
+		ok, err := r.prepareRow(t, data, row)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !ok {
+			skipped++
+			continue
+		}
+
+		var id int64
+		if t.insert != nil {
+			id, err = t.insert(r, data, row)
+		} else {
+			id, err = r.insertData(t.name, data, row)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if id == 0 && t.insert != nil {
+			skipped++
+			continue
+		}
+
+		if oldID := r.intValue(data, row, "id"); oldID > 0 {
+			ids[oldID] = id
+		}
+		if t.afterRestore != nil {
+			if err := t.afterRestore(r, data, row, id); err != nil {
+				return 0, 0, err
+			}
+		}
+		restored++
+	}
+	return restored, skipped, nil
+}
+
+// prepareRow replaces the org, IDs and UIDs of a row, false if the row
+// references a row which wasn't restored
+func (r *restoreHelper) prepareRow(t *backupTable, data *backupTableData, row []interface{}) (bool, error) {
+	if col := t.orgColumn(); col != "" {
+		data.setValue(row, col, r.orgID)
+	}
+
+	for col, table := range t.refs {
+		id := r.intValue(data, row, col)
+		if id <= 0 {
+			continue
+		}
+		newID, ok := r.ids[table][id]
+		if !ok {
+			return false, nil
+		}
+		data.setValue(row, col, newID)
+	}
+	for col, table := range t.optRefs {
+		id := r.intValue(data, row, col)
+		if id <= 0 {
+			continue
+		}
+		data.setValue(row, col, r.ids[table][id])
+	}
+
+	if len(r.uids) > 0 {
+		if t.uid != "" {
+			data.setValue(row, t.uid, r.newUID(data.stringValue(row, t.uid)))
+		}
+		for _, col := range t.uidRefs {
+			data.setValue(row, col, r.newUID(data.stringValue(row, col)))
+		}
+		for _, col := range t.json {
+			if i := data.column(col); i >= 0 && i < len(row) && row[i] != nil {
+				data.setValue(row, col, r.replacer.Replace(data.stringValue(row, col)))
+			}
+		}
+	}
+
+	if t.restore != nil {
+		return t.restore(r, data, row)
+	}
+	return true, nil
+}
+
+func (r *restoreHelper) intValue(data *backupTableData, row []interface{}, name string) int64 {
+	i := data.column(name)
+	if i < 0 || i >= len(row) {
+		return 0
+	}
+	switch v := row[i].(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	case int64:
+		return v
+	}
+	return 0
+}
+
+// rowValues returns the columns of a row without the ID and the values
+// converted to the column types
+func (r *restoreHelper) rowValues(data *backupTableData, row []interface{}) ([]string, []interface{}, error) {
+	if len(row) != len(data.Columns) {
+		return nil, nil, fmt.Errorf("%w: row has %d values, expected %d", ErrBackupInvalid, len(row), len(data.Columns))
+	}
+	cols := make([]string, 0, len(data.Columns))
+	vals := make([]interface{}, 0, len(data.Columns))
+	for i, c := range data.Columns {
+		if c.Name == "id" {
+			continue
+		}
+		v, err := decodeBackupValue(c.Type, row[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %s: %w", c.Name, err)
+		}
+		cols = append(cols, c.Name)
+		vals = append(vals, v)
+	}
+	return cols, vals, nil
+}
+
+func (r *restoreHelper) quote(name string) string {
+	return r.s.sql.Quote(name)
+}
+
+func (r *restoreHelper) insertData(table string, data *backupTableData, row []interface{}) (int64, error) {
+	cols, vals, err := r.rowValues(data, row)
+	if err != nil {
+		return 0, err
+	}
+	return r.insertRow(table, cols, vals)
+}
+
+// insertRow inserts a row and returns the new ID
+func (r *restoreHelper) insertRow(table string, cols []string, vals []interface{}) (int64, error) {
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = r.quote(c)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", r.quote(table), strings.Join(quoted, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "))
+
+	var id int64
+	if r.s.sql.GetDialect().DriverName() == migrator.Postgres {
+		_, err := r.sess.SQL(query+" RETURNING id", vals...).Get(&id)
+		return id, err
+	}
+	res, err := r.sess.Exec(append([]interface{}{query}, vals...)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// addAdmin makes the user restoring the backup an admin of the new org
+func (r *restoreHelper) addAdmin(userID int64) error {
+	var orgUser models.OrgUser
+	has, err := r.sess.Where("org_id = ? AND user_id = ?", r.orgID, userID).Get(&orgUser)
+	if err != nil {
+		return err
+	}
+	if has {
+		orgUser.Role = org.RoleAdmin
+		_, err = r.sess.ID(orgUser.Id).Cols("role").Update(&orgUser)
+		return err
+	}
+	_, err = r.sess.Insert(&models.OrgUser{
+		OrgId:   r.orgID,
+		UserId:  userID,
+		Role:    org.RoleAdmin,
+		Created: time.Now(),
+		Updated: time.Now(),
+	})
+	return err
+}
@@ -1,7 +1,9 @@
 package export
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,8 +12,10 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
@@ -34,6 +38,18 @@ type ExportService interface {
 
 	// Cancel any running export
 	HandleRequestStop(c *models.ReqContext) response.Response
+
+	// Import an export from a git repository or folder
+	HandleRequestImport(c *models.ReqContext) response.Response
+
+	// Commit the org was last synced to
+	HandleGetSyncStatus(c *models.ReqContext) response.Response
+
+	// Download a backup archive of an org
+	HandleBackup(c *models.ReqContext) response.Response
+
+	// Restore a backup archive to a new org
+	HandleRestore(c *models.ReqContext) response.Response
 }
 
 var exporters = []Exporter{
@@ -144,6 +160,40 @@ var exporters = []Exporter{
 	// },
 }
 
+// Users, alerting configuration and the other exported tables are not imported
+var importers = []Importer{
+	{
+		Key:         "dash",
+		Name:        "Dashboards",
+		Description: "Folders and dashboard JSON",
+		plan:        planDashboards,
+	},
+	{
+		Key:         "ds",
+		Name:        "Data sources",
+		Description: "Data source configurations, secure settings are kept",
+		plan:        planDataSources,
+	},
+	{
+		Key:         "alerts",
+		Name:        "Alerts",
+		Description: "Alert rules, their folders have to exist or be imported",
+		plan:        planAlertRules,
+	},
+	{
+		Key:         "system_playlists",
+		Name:        "Playlists",
+		Description: "Playlists",
+		plan:        planSystemPlaylists,
+	},
+	{
+		Key:         "system_preferences",
+		Name:        "Preferences",
+		Description: "Org, team and user preferences",
+		plan:        planSystemPreferences,
+	},
+}
+
 type StandardExport struct {
 	logger  log.Logger
 	glive   *live.GrafanaLive
@@ -156,6 +206,9 @@ type StandardExport struct {
 	playlistService           playlist.Service
 	orgService                org.Service
 	datasourceService         datasources.DataSourceService
+	dashboardStore            dashboards.Store
+	kvStore                   kvstore.KVStore
+	backupService             *BackupService
 
 	// updated with mutex
 	exportJob Job
@@ -163,7 +216,8 @@ type StandardExport struct {
 
 func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles, gl *live.GrafanaLive, cfg *setting.Cfg,
 	dashboardsnapshotsService dashboardsnapshots.Service, playlistService playlist.Service, orgService org.Service,
-	datasourceService datasources.DataSourceService) ExportService {
+	datasourceService datasources.DataSourceService, dashboardStore dashboards.Store, kvStore kvstore.KVStore,
+	backupService *BackupService) ExportService {
 	if !features.IsEnabled(featuremgmt.FlagExport) {
 		return &StubExport{}
 	}
@@ -176,6 +230,9 @@ func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles,
 		playlistService:           playlistService,
 		orgService:                orgService,
 		datasourceService:         datasourceService,
+		dashboardStore:            dashboardStore,
+		kvStore:                   kvStore,
+		backupService:             backupService,
 		exportJob:                 &stoppedJob{},
 		dataDir:                   cfg.DataPath,
 	}
@@ -184,6 +241,7 @@ func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles,
 func (ex *StandardExport) HandleGetOptions(c *models.ReqContext) response.Response {
 	info := map[string]interface{}{
 		"exporters": exporters,
+		"importers": importers,
 	}
 	return response.JSON(http.StatusOK, info)
 }
@@ -251,6 +309,112 @@ func (ex *StandardExport) HandleRequestExport(c *models.ReqContext) response.Res
 	return response.JSON(http.StatusOK, info)
 }
 
+func (ex *StandardExport) HandleRequestImport(c *models.ReqContext) response.Response {
+	var cfg ImportConfig
+	err := json.NewDecoder(c.Req.Body).Decode(&cfg)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "unable to read config", err)
+	}
+
+	ex.mutex.Lock()
+	defer ex.mutex.Unlock()
+
+	status := ex.exportJob.getStatus()
+	if status.Running {
+		ex.logger.Error("export already running")
+		return response.Error(http.StatusLocked, "export already running", nil)
+	}
+
+	dir := filepath.Join(ex.dataDir, "export_git")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return response.Error(http.StatusBadRequest, "Error creating export folder", nil)
+	}
+	job, err := startGitImportJob(cfg, ex.sql, ex.dashboardStore, ex.datasourceService, ex.playlistService, ex.kvStore,
+		dir, c.OrgID, func(s ExportStatus) {
+			ex.broadcastStatus(c.OrgID, s)
+		})
+	if err != nil {
+		ex.logger.Error("failed to start import job", "err", err)
+		return response.Error(http.StatusBadRequest, "failed to start import job", err)
+	}
+
+	ex.exportJob = job
+
+	cfg.Token = "" // no secrets
+	info := map[string]interface{}{
+		"cfg":    cfg,
+		"status": ex.exportJob.getStatus(),
+	}
+	return response.JSON(http.StatusOK, info)
+}
+
+func (ex *StandardExport) HandleGetSyncStatus(c *models.ReqContext) response.Response {
+	status, err := getSyncStatus(c.Req.Context(), ex.kvStore, c.OrgID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to read sync status", err)
+	}
+	if status == nil {
+		return response.Error(http.StatusNotFound, "org was never synced", nil)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+type backupRequest struct {
+	OrgID      int64  `json:"orgId"`
+	Passphrase string `json:"passphrase"`
+}
+
+func (ex *StandardExport) HandleBackup(c *models.ReqContext) response.Response {
+	var req backupRequest
+	if err := json.NewDecoder(c.Req.Body).Decode(&req); err != nil {
+		return response.Error(http.StatusBadRequest, "unable to read request", err)
+	}
+	if req.OrgID == 0 {
+		req.OrgID = c.OrgID
+	}
+
+	buf := &bytes.Buffer{}
+	manifest, err := ex.backupService.Backup(c.Req.Context(), req.OrgID, req.Passphrase, buf)
+	if err != nil {
+		if errors.Is(err, models.ErrOrgNotFound) {
+			return response.Error(http.StatusNotFound, "org not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to create backup", err)
+	}
+
+	fname := fmt.Sprintf("grafana-org-%d-%s.tar.gz", manifest.OrgID, time.UnixMilli(manifest.Created).UTC().Format("20060102-150405"))
+	return response.Respond(http.StatusOK, buf.Bytes()).
+		SetHeader("Content-Type", "application/gzip").
+		SetHeader("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fname))
+}
+
+// HandleRestore reads a multipart form with the archive and the restore options
+func (ex *StandardExport) HandleRestore(c *models.ReqContext) response.Response {
+	file, _, err := c.Req.FormFile("archive")
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "backup archive is required", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	opts := RestoreOptions{
+		OrgName:     c.Req.FormValue("orgName"),
+		Passphrase:  c.Req.FormValue("passphrase"),
+		NewUIDs:     c.Req.FormValue("newUids") == "true",
+		AdminUserID: c.UserID,
+	}
+	result, err := ex.backupService.Restore(c.Req.Context(), file, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrgNameTaken):
+			return response.Error(http.StatusConflict, "org name is taken", err)
+		case errors.Is(err, ErrBackupVersion), errors.Is(err, ErrBackupPassphrase), errors.Is(err, ErrBackupInvalid):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to restore backup", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 func (ex *StandardExport) broadcastStatus(orgID int64, s ExportStatus) {
 	msg, err := json.Marshal(s)
 	if err != nil {
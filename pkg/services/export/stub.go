@@ -26,3 +26,19 @@ func (ex *StubExport) HandleRequestExport(c *models.ReqContext) response.Respons
 func (ex *StubExport) HandleRequestStop(c *models.ReqContext) response.Response {
 	return response.Error(http.StatusForbidden, "feature not enabled", nil)
 }
+
+func (ex *StubExport) HandleRequestImport(c *models.ReqContext) response.Response {
+	return response.Error(http.StatusForbidden, "feature not enabled", nil)
+}
+
+func (ex *StubExport) HandleGetSyncStatus(c *models.ReqContext) response.Response {
+	return response.Error(http.StatusForbidden, "feature not enabled", nil)
+}
+
+func (ex *StubExport) HandleBackup(c *models.ReqContext) response.Response {
+	return response.Error(http.StatusForbidden, "feature not enabled", nil)
+}
+
+func (ex *StubExport) HandleRestore(c *models.ReqContext) response.Response {
+	return response.Error(http.StatusForbidden, "feature not enabled", nil)
+}
@@ -1,5 +1,7 @@
 package export
 
+import "context"
+
 // Export status.  Only one running at a time
 type ExportStatus struct {
 	Running  bool           `json:"running"`
@@ -11,6 +13,9 @@ type ExportStatus struct {
 	Status   string         `json:"status"` // ERROR, SUCCESS, ETC
 	Index    int            `json:"index,omitempty"`
 	Count    map[string]int `json:"count,omitempty"`
+
+	// Changes planned by an import job
+	Plan []*ImportChange `json:"plan,omitempty"`
 }
 
 // Basic export config (for now)
@@ -27,6 +32,49 @@ type ExportConfig struct {
 
 type GitExportConfig struct{}
 
+// Import config, reads the layout written by the git export
+type ImportConfig struct {
+	// Git repository to clone
+	URL    string `json:"url,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Token  string `json:"token,omitempty"` // used for HTTP basic auth
+
+	// Or a folder with an export, relative to the export folder in the data path
+	Dir string `json:"dir,omitempty"`
+
+	// Only compute the plan, don't change anything
+	DryRun bool `json:"dryRun"`
+
+	Exclude map[string]bool `json:"exclude"`
+}
+
+type ImportAction string
+
+const (
+	ImportActionCreate ImportAction = "create"
+	ImportActionUpdate ImportAction = "update"
+	ImportActionDelete ImportAction = "delete"
+)
+
+// A change applied by an import job
+type ImportChange struct {
+	Kind   string       `json:"kind"` // importer key
+	Action ImportAction `json:"action"`
+	UID    string       `json:"uid,omitempty"`
+	Name   string       `json:"name"`
+	Path   string       `json:"path,omitempty"` // relative to the org folder
+
+	apply func(ctx context.Context) error
+}
+
+// The commit an org was last synced to
+type SyncStatus struct {
+	Source  string `json:"source"` // description of where it came from (no secrets)
+	Commit  string `json:"commit,omitempty"`
+	Synced  int64  `json:"synced"`
+	Changes int    `json:"changes"`
+}
+
 type Job interface {
 	getStatus() ExportStatus
 	getConfig() ExportConfig
@@ -44,3 +92,11 @@ type Exporter struct {
 
 	process func(helper *commitHelper, job *gitExportJob) error
 }
+
+type Importer struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	plan func(helper *importHelper, job *gitImportJob) ([]*ImportChange, error)
+}
@@ -3,9 +3,12 @@ package libraryelements
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -21,6 +24,11 @@ func (l *LibraryElementService) registerAPIEndpoints() {
 		entities.Get("/:uid/connections/", middleware.ReqSignedIn, routing.Wrap(l.getConnectionsHandler))
 		entities.Get("/name/:name", middleware.ReqSignedIn, routing.Wrap(l.getByNameHandler))
 		entities.Patch("/:uid", middleware.ReqSignedIn, routing.Wrap(l.patchHandler))
+		entities.Get("/:uid/versions", middleware.ReqSignedIn, routing.Wrap(l.getVersionsHandler))
+		entities.Get("/:uid/versions/:version", middleware.ReqSignedIn, routing.Wrap(l.getVersionHandler))
+		entities.Post("/:uid/restore", middleware.ReqSignedIn, routing.Wrap(l.restoreVersionHandler))
+		entities.Get("/:uid/diff", middleware.ReqSignedIn, routing.Wrap(l.diffHandler))
+		entities.Get("/:uid/dependencies", middleware.ReqSignedIn, routing.Wrap(l.getDependenciesHandler))
 	})
 }
 
@@ -240,6 +248,159 @@ func (l *LibraryElementService) getByNameHandler(c *models.ReqContext) response.
 	return response.JSON(http.StatusOK, LibraryElementArrayResponse{Result: elements})
 }
 
+// swagger:route GET /library-elements/{library_element_uid}/versions library_elements getLibraryElementVersions
+//
+// Get library element versions.
+//
+// Returns the versions of a library element, latest version first, without their models.
+// Use the `perPage` query parameter to control the maximum number of versions returned; the default limit is `100`.
+// You can also use the `page` query parameter to fetch versions from any page other than the first one.
+//
+// Responses:
+// 200: getLibraryElementVersionsResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (l *LibraryElementService) getVersionsHandler(c *models.ReqContext) response.Response {
+	versions, err := l.getVersions(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], c.QueryInt("perPage"), c.QueryInt("page"))
+	if err != nil {
+		return toLibraryElementError(err, "Failed to get library element versions")
+	}
+
+	return response.JSON(http.StatusOK, LibraryElementVersionsResponse{Result: versions})
+}
+
+// swagger:route GET /library-elements/{library_element_uid}/versions/{library_element_version} library_elements getLibraryElementVersion
+//
+// Get library element version.
+//
+// Returns a version of a library element, including its model.
+//
+// Responses:
+// 200: getLibraryElementVersionResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (l *LibraryElementService) getVersionHandler(c *models.ReqContext) response.Response {
+	version, err := strconv.ParseInt(web.Params(c.Req)[":version"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+
+	elementVersion, err := l.getVersion(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], version)
+	if err != nil {
+		return toLibraryElementError(err, "Failed to get library element version")
+	}
+
+	return response.JSON(http.StatusOK, LibraryElementVersionResponse{Result: elementVersion})
+}
+
+// swagger:route POST /library-elements/{library_element_uid}/restore library_elements restoreLibraryElementVersion
+//
+// Restore library element version.
+//
+// Restores a version of a library element by saving it as a new version. Dashboards pinned to a version
+// of the library element keep using that version.
+//
+// Responses:
+// 200: getLibraryElementResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 412: preconditionFailedError
+// 500: internalServerError
+func (l *LibraryElementService) restoreVersionHandler(c *models.ReqContext) response.Response {
+	cmd := RestoreLibraryElementVersionCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	element, err := l.restoreVersion(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return toLibraryElementError(err, "Failed to restore library element version")
+	}
+
+	return response.JSON(http.StatusOK, LibraryElementResponse{Result: element})
+}
+
+// swagger:route GET /library-elements/{library_element_uid}/diff library_elements diffLibraryElementVersions
+//
+// Compare library element versions.
+//
+// Returns the difference between the models of two versions of a library element.
+// Use the `diffType` query parameter to get the difference as `json`, `basic` or `delta`; the default is `basic`.
+//
+// Produces:
+// - application/json
+// - text/html
+//
+// Responses:
+// 200: calculateDashboardDiffResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (l *LibraryElementService) diffHandler(c *models.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	baseVersion, err := l.getVersion(c.Req.Context(), c.SignedInUser, uid, c.QueryInt64("base"))
+	if err != nil {
+		return toLibraryElementError(err, "Failed to get library element version")
+	}
+	newVersion, err := l.getVersion(c.Req.Context(), c.SignedInUser, uid, c.QueryInt64("new"))
+	if err != nil {
+		return toLibraryElementError(err, "Failed to get library element version")
+	}
+
+	baseData, err := simplejson.NewJson(baseVersion.Model)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Unable to compute diff", err)
+	}
+	newData, err := simplejson.NewJson(newVersion.Model)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Unable to compute diff", err)
+	}
+
+	// the semantic diff only applies to dashboards
+	diffType := dashdiffs.ParseDiffType(c.Query("diffType"))
+	if diffType == dashdiffs.DiffSemantic {
+		return response.Error(http.StatusBadRequest, dashdiffs.ErrUnsupportedDiffType.Error(), dashdiffs.ErrUnsupportedDiffType)
+	}
+
+	result, err := dashdiffs.CalculateDiff(c.Req.Context(), &dashdiffs.Options{OrgId: c.OrgID, DiffType: diffType}, baseData, newData)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Unable to compute diff", err)
+	}
+
+	if diffType == dashdiffs.DiffDelta {
+		return response.Respond(http.StatusOK, result.Delta).SetHeader("Content-Type", "application/json")
+	}
+
+	return response.Respond(http.StatusOK, result.Delta).SetHeader("Content-Type", "text/html")
+}
+
+// swagger:route GET /library-elements/{library_element_uid}/dependencies library_elements getLibraryElementDependencies
+//
+// Get library element dependencies.
+//
+// Returns the dashboards using a library element, the version of the library element each of them uses
+// and the number of dashboards using each version.
+//
+// Responses:
+// 200: getLibraryElementDependenciesResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (l *LibraryElementService) getDependenciesHandler(c *models.ReqContext) response.Response {
+	dependencies, err := l.getDependencies(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toLibraryElementError(err, "Failed to get library element dependencies")
+	}
+
+	return response.JSON(http.StatusOK, LibraryElementDependenciesResponse{Result: dependencies})
+}
+
 func toLibraryElementError(err error, message string) response.Response {
 	if errors.Is(err, errLibraryElementAlreadyExists) {
 		return response.Error(400, errLibraryElementAlreadyExists.Error(), err)
@@ -268,10 +429,13 @@ func toLibraryElementError(err error, message string) response.Response {
 	if errors.Is(err, errLibraryElementUIDTooLong) {
 		return response.Error(400, errLibraryElementUIDTooLong.Error(), err)
 	}
+	if errors.Is(err, errLibraryElementVersionNotFound) {
+		return response.Error(404, errLibraryElementVersionNotFound.Error(), err)
+	}
 	return response.Error(500, message, err)
 }
 
-// swagger:parameters getLibraryElementByUID getLibraryElementConnections
+// swagger:parameters getLibraryElementByUID getLibraryElementConnections getLibraryElementDependencies
 type LibraryElementByUID struct {
 	// in:path
 	// required:true
@@ -386,3 +550,83 @@ type GetLibraryElementConnectionsResponse struct {
 	// in: body
 	Body LibraryElementConnectionsResponse `json:"body"`
 }
+
+// swagger:parameters getLibraryElementVersions
+type GetLibraryElementVersionsParams struct {
+	// in:path
+	// required:true
+	UID string `json:"library_element_uid"`
+	// The number of results per page.
+	// in:query
+	// required:false
+	// default: 100
+	PerPage int `json:"perPage"`
+	// The page for a set of records, given that only perPage records are returned at a time. Numbering starts at 1.
+	// in:query
+	// required:false
+	// default: 1
+	Page int `json:"page"`
+}
+
+// swagger:parameters getLibraryElementVersion
+type GetLibraryElementVersionParams struct {
+	// in:path
+	// required:true
+	UID string `json:"library_element_uid"`
+	// in:path
+	// required:true
+	Version int64 `json:"library_element_version"`
+}
+
+// swagger:parameters restoreLibraryElementVersion
+type RestoreLibraryElementVersionParams struct {
+	// in:body
+	// required:true
+	Body RestoreLibraryElementVersionCommand `json:"body"`
+	// in:path
+	// required:true
+	UID string `json:"library_element_uid"`
+}
+
+// swagger:parameters diffLibraryElementVersions
+type DiffLibraryElementVersionsParams struct {
+	// in:path
+	// required:true
+	UID string `json:"library_element_uid"`
+	// Version to compare from.
+	// in:query
+	// required:true
+	Base int64 `json:"base"`
+	// Version to compare to.
+	// in:query
+	// required:true
+	New int64 `json:"new"`
+	// The type of diff to return.
+	// in:query
+	// required:false
+	// Description:
+	// * basic
+	// * json
+	// * delta
+	// enum: basic,json,delta
+	// default: basic
+	DiffType string `json:"diffType"`
+}
+
+// swagger:response getLibraryElementVersionsResponse
+type GetLibraryElementVersionsResponse struct {
+	// in: body
+	Body LibraryElementVersionsResponse `json:"body"`
+}
+
+// swagger:response getLibraryElementVersionResponse
+type GetLibraryElementVersionResponse struct {
+	// in: body
+	Body LibraryElementVersionResponse `json:"body"`
+}
+
+// swagger:response getLibraryElementDependenciesResponse
+type GetLibraryElementDependenciesResponse struct {
+	// in: body
+	Body LibraryElementDependenciesResponse `json:"body"`
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -102,6 +103,37 @@ func getLibraryElement(dialect migrator.Dialect, session *sqlstore.DBSession, ui
 	return elements[0], nil
 }
 
+// insertLibraryElementVersion stores the current state of a library element as a version.
+func insertLibraryElementVersion(session *sqlstore.DBSession, element *LibraryElement, message string) error {
+	version := libraryElementVersion{
+		ElementID: element.ID,
+		Version:   element.Version,
+		Name:      element.Name,
+		Model:     element.Model,
+		Message:   message,
+		Created:   element.Updated,
+		CreatedBy: element.UpdatedBy,
+	}
+	_, err := session.Insert(&version)
+	return err
+}
+
+func getLibraryElementVersion(dialect migrator.Dialect, session *sqlstore.DBSession, elementID int64, version int64) (libraryElementVersionWithMeta, error) {
+	versions := make([]libraryElementVersionWithMeta, 0)
+	sql := "SELECT lev.*, u1.login AS created_by_name, u1.email AS created_by_email" +
+		" FROM library_element_version AS lev" +
+		" LEFT JOIN " + dialect.Quote("user") + " AS u1 ON lev.created_by = u1.id" +
+		" WHERE lev.element_id=? AND lev.version=?"
+	if err := session.SQL(sql, elementID, version).Find(&versions); err != nil {
+		return libraryElementVersionWithMeta{}, err
+	}
+	if len(versions) == 0 {
+		return libraryElementVersionWithMeta{}, errLibraryElementVersionNotFound
+	}
+
+	return versions[0], nil
+}
+
 // createLibraryElement adds a library element.
 func (l *LibraryElementService) createLibraryElement(c context.Context, signedInUser *user.SignedInUser, cmd CreateLibraryElementCommand) (LibraryElementDTO, error) {
 	if err := l.requireSupportedElementKind(cmd.Kind); err != nil {
@@ -147,7 +179,7 @@ func (l *LibraryElementService) createLibraryElement(c context.Context, signedIn
 			}
 			return err
 		}
-		return nil
+		return insertLibraryElementVersion(session, &element, "")
 	})
 
 	dto := LibraryElementDTO{
@@ -217,6 +249,9 @@ func (l *LibraryElementService) deleteLibraryElement(c context.Context, signedIn
 		} else if rowsAffected != 1 {
 			return ErrLibraryElementNotFound
 		}
+		if _, err := session.Exec("DELETE FROM library_element_version WHERE element_id=?", element.ID); err != nil {
+			return err
+		}
 
 		elementID = element.ID
 		return nil
@@ -523,6 +558,9 @@ func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInU
 		} else if rowsAffected != 1 {
 			return ErrLibraryElementNotFound
 		}
+		if err := insertLibraryElementVersion(session, &libraryElement, cmd.Message); err != nil {
+			return err
+		}
 
 		dto = LibraryElementDTO{
 			ID:          libraryElement.ID,
@@ -586,6 +624,7 @@ func (l *LibraryElementService) getConnections(c context.Context, signedInUser *
 				ElementID:     connection.ElementID,
 				ConnectionID:  connection.ConnectionID,
 				ConnectionUID: connection.ConnectionUID,
+				Version:       connection.Version,
 				Created:       connection.Created,
 				CreatedBy: LibraryElementDTOMetaUser{
 					ID:        connection.CreatedBy,
@@ -618,7 +657,23 @@ func (l *LibraryElementService) getElementsForDashboardID(c context.Context, das
 			return err
 		}
 
-		for _, element := range libraryElements {
+		var pinnedConnections []libraryElementConnection
+		if err := session.SQL("SELECT * FROM "+models.LibraryElementConnectionTableName+" WHERE kind=1 AND connection_id=? AND version<>0", dashboardID).Find(&pinnedConnections); err != nil {
+			return err
+		}
+		pinnedVersions := make(map[int64]int64, len(pinnedConnections))
+		for _, connection := range pinnedConnections {
+			pinnedVersions[connection.ElementID] = connection.Version
+		}
+
+		for i := range libraryElements {
+			element := libraryElements[i]
+			if version, ok := pinnedVersions[element.ID]; ok && version != element.Version {
+				if err := applyLibraryElementVersion(l.SQLStore.Dialect, session, &element, version); err != nil {
+					return err
+				}
+			}
+
 			libraryElementMap[element.UID] = LibraryElementDTO{
 				ID:          element.ID,
 				OrgID:       element.OrgID,
@@ -656,8 +711,43 @@ func (l *LibraryElementService) getElementsForDashboardID(c context.Context, das
 	return libraryElementMap, err
 }
 
-// connectElementsToDashboardID adds connections for all elements Library Elements in a Dashboard.
-func (l *LibraryElementService) connectElementsToDashboardID(c context.Context, signedInUser *user.SignedInUser, elementUIDs []string, dashboardID int64) error {
+// applyLibraryElementVersion replaces the fields of an element with the ones of a previous version.
+func applyLibraryElementVersion(dialect migrator.Dialect, session *sqlstore.DBSession, element *LibraryElementWithMeta, version int64) error {
+	elementVersion, err := getLibraryElementVersion(dialect, session, element.ID, version)
+	if err != nil {
+		return err
+	}
+
+	versioned := LibraryElement{
+		Name:  elementVersion.Name,
+		Kind:  element.Kind,
+		Model: elementVersion.Model,
+	}
+	if err := syncFieldsWithModel(&versioned); err != nil {
+		return err
+	}
+
+	element.Name = versioned.Name
+	element.Type = versioned.Type
+	element.Description = versioned.Description
+	element.Model = versioned.Model
+	element.Version = elementVersion.Version
+	element.Updated = elementVersion.Created
+	element.UpdatedBy = elementVersion.CreatedBy
+	element.UpdatedByName = elementVersion.CreatedByName
+	element.UpdatedByEmail = elementVersion.CreatedByEmail
+	return nil
+}
+
+// connectElementsToDashboardID adds connections for all elements Library Elements in a Dashboard, pinning
+// the elements with a version other than 0 to that version.
+func (l *LibraryElementService) connectElementsToDashboardID(c context.Context, signedInUser *user.SignedInUser, elementVersions map[string]int64, dashboardID int64) error {
+	elementUIDs := make([]string, 0, len(elementVersions))
+	for elementUID := range elementVersions {
+		elementUIDs = append(elementUIDs, elementUID)
+	}
+	sort.Strings(elementUIDs)
+
 	err := l.SQLStore.WithTransactionalDbSession(c, func(session *sqlstore.DBSession) error {
 		_, err := session.Exec("DELETE FROM "+models.LibraryElementConnectionTableName+" WHERE kind=1 AND connection_id=?", dashboardID)
 		if err != nil {
@@ -671,11 +761,18 @@ func (l *LibraryElementService) connectElementsToDashboardID(c context.Context,
 			if err := l.requireViewPermissionsOnFolder(c, signedInUser, element.FolderID); err != nil {
 				return err
 			}
+			version := elementVersions[elementUID]
+			if version != 0 {
+				if _, err := getLibraryElementVersion(l.SQLStore.Dialect, session, element.ID, version); err != nil {
+					return err
+				}
+			}
 
 			connection := libraryElementConnection{
 				ElementID:    element.ID,
 				Kind:         1,
 				ConnectionID: dashboardID,
+				Version:      version,
 				Created:      time.Now(),
 				CreatedBy:    signedInUser.UserID,
 			}
@@ -753,6 +850,9 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 			if err != nil {
 				return err
 			}
+			if _, err := session.Exec("DELETE FROM library_element_version WHERE element_id=?", elementID.ID); err != nil {
+				return err
+			}
 		}
 		if _, err := session.Exec("DELETE FROM library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgID); err != nil {
 			return err
@@ -761,3 +861,180 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 		return nil
 	})
 }
+
+// getVersions gets the versions of a Library Element, latest version first.
+func (l *LibraryElementService) getVersions(c context.Context, signedInUser *user.SignedInUser, uid string, perPage int, page int) ([]LibraryElementVersionDTO, error) {
+	element, err := l.getLibraryElementByUid(c, signedInUser, uid)
+	if err != nil {
+		return nil, err
+	}
+	if perPage <= 0 {
+		perPage = 100
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	versions := make([]LibraryElementVersionDTO, 0)
+	err = l.SQLStore.WithDbSession(c, func(session *sqlstore.DBSession) error {
+		var elementVersions []libraryElementVersionWithMeta
+		builder := sqlstore.NewSqlBuilder(l.Cfg)
+		builder.Write("SELECT lev.id, lev.element_id, lev.version, lev.name, lev.message, lev.created, lev.created_by")
+		builder.Write(", u1.login AS created_by_name, u1.email AS created_by_email")
+		builder.Write(" FROM library_element_version AS lev")
+		builder.Write(" LEFT JOIN " + l.SQLStore.Dialect.Quote("user") + " AS u1 ON lev.created_by = u1.id")
+		builder.Write(" WHERE lev.element_id=?", element.ID)
+		builder.Write(" ORDER BY lev.version DESC")
+		builder.Write(l.SQLStore.Dialect.LimitOffset(int64(perPage), int64((page-1)*perPage)))
+		if err := session.SQL(builder.GetSQLString(), builder.GetParams()...).Find(&elementVersions); err != nil {
+			return err
+		}
+
+		for _, version := range elementVersions {
+			versions = append(versions, toLibraryElementVersionDTO(version, element.UID))
+		}
+		return nil
+	})
+
+	return versions, err
+}
+
+// getVersion gets a version of a Library Element.
+func (l *LibraryElementService) getVersion(c context.Context, signedInUser *user.SignedInUser, uid string, version int64) (LibraryElementVersionDTO, error) {
+	element, err := l.getLibraryElementByUid(c, signedInUser, uid)
+	if err != nil {
+		return LibraryElementVersionDTO{}, err
+	}
+
+	var dto LibraryElementVersionDTO
+	err = l.SQLStore.WithDbSession(c, func(session *sqlstore.DBSession) error {
+		elementVersion, err := getLibraryElementVersion(l.SQLStore.Dialect, session, element.ID, version)
+		if err != nil {
+			return err
+		}
+		dto = toLibraryElementVersionDTO(elementVersion, element.UID)
+		return nil
+	})
+
+	return dto, err
+}
+
+func toLibraryElementVersionDTO(version libraryElementVersionWithMeta, elementUID string) LibraryElementVersionDTO {
+	return LibraryElementVersionDTO{
+		ID:         version.ID,
+		ElementID:  version.ElementID,
+		ElementUID: elementUID,
+		Version:    version.Version,
+		Name:       version.Name,
+		Message:    version.Message,
+		Model:      version.Model,
+		Created:    version.Created,
+		CreatedBy: LibraryElementDTOMetaUser{
+			ID:        version.CreatedBy,
+			Name:      version.CreatedByName,
+			AvatarURL: dtos.GetGravatarUrl(version.CreatedByEmail),
+		},
+	}
+}
+
+// restoreVersion restores a version of a Library Element by saving it as the latest version.
+func (l *LibraryElementService) restoreVersion(c context.Context, signedInUser *user.SignedInUser, uid string, cmd RestoreLibraryElementVersionCommand) (LibraryElementDTO, error) {
+	element, err := l.getLibraryElementByUid(c, signedInUser, uid)
+	if err != nil {
+		return LibraryElementDTO{}, err
+	}
+	version, err := l.getVersion(c, signedInUser, uid, cmd.Version)
+	if err != nil {
+		return LibraryElementDTO{}, err
+	}
+
+	return l.patchLibraryElement(c, signedInUser, PatchLibraryElementCommand{
+		FolderID: -1,
+		Name:     version.Name,
+		Model:    version.Model,
+		Kind:     element.Kind,
+		Version:  element.Version,
+		Message:  fmt.Sprintf("Restored from version %d", version.Version),
+	}, uid)
+}
+
+// getDependencies gets the dashboards using a Library Element and the version each of them uses.
+func (l *LibraryElementService) getDependencies(c context.Context, signedInUser *user.SignedInUser, uid string) (LibraryElementDependenciesDTO, error) {
+	element, err := l.getLibraryElementByUid(c, signedInUser, uid)
+	if err != nil {
+		return LibraryElementDependenciesDTO{}, err
+	}
+
+	result := LibraryElementDependenciesDTO{
+		UID:        element.UID,
+		Version:    element.Version,
+		Dashboards: make([]LibraryElementDashboardDependencyDTO, 0),
+		Versions:   make([]LibraryElementVersionUsageDTO, 0),
+	}
+	err = l.SQLStore.WithDbSession(c, func(session *sqlstore.DBSession) error {
+		var connections []libraryElementConnection
+		sql := "SELECT lec.* FROM " + models.LibraryElementConnectionTableName + " AS lec" +
+			" INNER JOIN dashboard AS dashboard on lec.connection_id = dashboard.id" +
+			" WHERE lec.element_id=? AND lec.kind=1"
+		if err := session.SQL(sql, element.ID).Find(&connections); err != nil {
+			return err
+		}
+
+		// versions are counted for all dashboards, including the ones the user can't view
+		dashboardsByVersion := make(map[int64]int64)
+		for _, connection := range connections {
+			dashboardsByVersion[resolveConnectionVersion(connection.Version, element.Version)]++
+		}
+		for version, count := range dashboardsByVersion {
+			result.Versions = append(result.Versions, LibraryElementVersionUsageDTO{Version: version, Dashboards: count})
+		}
+		sort.Slice(result.Versions, func(i, j int) bool {
+			return result.Versions[i].Version > result.Versions[j].Version
+		})
+
+		var dashboards []struct {
+			ID        int64  `xorm:"id"`
+			UID       string `xorm:"uid"`
+			Title     string
+			FolderUID string `xorm:"folder_uid"`
+			Version   int64
+		}
+		builder := sqlstore.NewSqlBuilder(l.Cfg)
+		builder.Write("SELECT dashboard.id, dashboard.uid, dashboard.title, coalesce(folder.uid, '') AS folder_uid, lec.version")
+		builder.Write(" FROM " + models.LibraryElementConnectionTableName + " AS lec")
+		builder.Write(" INNER JOIN dashboard AS dashboard on lec.connection_id = dashboard.id")
+		builder.Write(" LEFT JOIN dashboard AS folder on dashboard.folder_id = folder.id")
+		builder.Write(` WHERE lec.element_id=? AND lec.kind=1`, element.ID)
+		if signedInUser.OrgRole != org.RoleAdmin {
+			builder.WriteDashboardPermissionFilter(signedInUser, models.PERMISSION_VIEW)
+		}
+		builder.Write(" ORDER BY dashboard.title ASC")
+		if err := session.SQL(builder.GetSQLString(), builder.GetParams()...).Find(&dashboards); err != nil {
+			return err
+		}
+
+		for _, dashboard := range dashboards {
+			result.Dashboards = append(result.Dashboards, LibraryElementDashboardDependencyDTO{
+				ID:            dashboard.ID,
+				UID:           dashboard.UID,
+				Title:         dashboard.Title,
+				FolderUID:     dashboard.FolderUID,
+				PinnedVersion: dashboard.Version,
+				Version:       resolveConnectionVersion(dashboard.Version, element.Version),
+			})
+		}
+		result.HiddenDashboards = int64(len(connections) - len(dashboards))
+
+		return nil
+	})
+
+	return result, err
+}
+
+// resolveConnectionVersion returns the version of an element used by a connection.
+func resolveConnectionVersion(pinnedVersion int64, latestVersion int64) int64 {
+	if pinnedVersion == 0 {
+		return latestVersion
+	}
+	return pinnedVersion
+}
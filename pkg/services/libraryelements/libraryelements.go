@@ -29,6 +29,7 @@ type Service interface {
 	GetElement(c context.Context, signedInUser *user.SignedInUser, UID string) (LibraryElementDTO, error)
 	GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]LibraryElementDTO, error)
 	ConnectElementsToDashboard(c context.Context, signedInUser *user.SignedInUser, elementUIDs []string, dashboardID int64) error
+	ConnectElementVersionsToDashboard(c context.Context, signedInUser *user.SignedInUser, elementVersions map[string]int64, dashboardID int64) error
 	DisconnectElementsFromDashboard(c context.Context, dashboardID int64) error
 	DeleteLibraryElementsInFolder(c context.Context, signedInUser *user.SignedInUser, folderUID string) error
 }
@@ -59,7 +60,17 @@ func (l *LibraryElementService) GetElementsForDashboard(c context.Context, dashb
 
 // ConnectElementsToDashboard connects elements to a specific dashboard.
 func (l *LibraryElementService) ConnectElementsToDashboard(c context.Context, signedInUser *user.SignedInUser, elementUIDs []string, dashboardID int64) error {
-	return l.connectElementsToDashboardID(c, signedInUser, elementUIDs, dashboardID)
+	elementVersions := make(map[string]int64, len(elementUIDs))
+	for _, elementUID := range elementUIDs {
+		elementVersions[elementUID] = 0
+	}
+	return l.connectElementsToDashboardID(c, signedInUser, elementVersions, dashboardID)
+}
+
+// ConnectElementVersionsToDashboard connects elements to a specific dashboard, pinning the elements with a
+// version other than 0 to that version.
+func (l *LibraryElementService) ConnectElementVersionsToDashboard(c context.Context, signedInUser *user.SignedInUser, elementVersions map[string]int64, dashboardID int64) error {
+	return l.connectElementsToDashboardID(c, signedInUser, elementVersions, dashboardID)
 }
 
 // DisconnectElementsFromDashboard disconnects elements from a specific dashboard.
@@ -0,0 +1,209 @@
+package libraryelements
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func patchToGraphPanel(t *testing.T, sc scenarioContext) {
+	t.Helper()
+
+	cmd := PatchLibraryElementCommand{
+		FolderID: -1,
+		Model: []byte(`
+			{
+			  "datasource": "${DS_GDEV-TESTDATA}",
+			  "id": 1,
+			  "title": "Graph - Library Panel",
+			  "type": "graph",
+			  "description": "A description"
+			}
+		`),
+		Kind:    int64(models.PanelElement),
+		Version: 1,
+		Message: "Use graph",
+	}
+	sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID})
+	sc.reqContext.Req.Body = mockRequestBody(cmd)
+	resp := sc.service.patchHandler(sc.reqContext)
+	require.Equal(t, 200, resp.Status())
+}
+
+func createDashboardWithLibraryPanel(t *testing.T, sc scenarioContext) *models.Dashboard {
+	t.Helper()
+
+	dashJSON := map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id": int64(1),
+				"libraryPanel": map[string]interface{}{
+					"uid":  sc.initialResult.Result.UID,
+					"name": sc.initialResult.Result.Name,
+				},
+			},
+		},
+	}
+	dash := models.Dashboard{
+		Title: "Testing library panel versions",
+		Data:  simplejson.NewFromAny(dashJSON),
+	}
+	return createDashboard(t, sc.sqlStore, sc.user, &dash, sc.folder.Id)
+}
+
+func TestLibraryElementVersions(t *testing.T) {
+	scenarioWithPanel(t, "When an admin patches a library panel, it should be able to get the previous version",
+		func(t *testing.T, sc scenarioContext) {
+			patchToGraphPanel(t, sc)
+
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var versions LibraryElementVersionsResponse
+			require.NoError(t, json.Unmarshal(resp.Body(), &versions))
+			require.Len(t, versions.Result, 2)
+			require.Equal(t, int64(2), versions.Result[0].Version)
+			require.Equal(t, "Use graph", versions.Result[0].Message)
+			require.Nil(t, versions.Result[0].Model)
+			require.Equal(t, int64(1), versions.Result[1].Version)
+			require.Equal(t, userInDbName, versions.Result[1].CreatedBy.Name)
+
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID, ":version": "1"})
+			resp = sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var version LibraryElementVersionResponse
+			require.NoError(t, json.Unmarshal(resp.Body(), &version))
+			require.Equal(t, sc.initialResult.Result.UID, version.Result.ElementUID)
+			model, err := simplejson.NewJson(version.Result.Model)
+			require.NoError(t, err)
+			require.Equal(t, "text", model.Get("type").MustString())
+		})
+
+	scenarioWithPanel(t, "When an admin gets a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID, ":version": "2"})
+			resp := sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+
+			sc.ctx.Req = web.SetURLParams(sc.ctx.Req, map[string]string{":uid": sc.initialResult.Result.UID, ":version": "latest"})
+			resp = sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 400, resp.Status())
+		})
+
+	scenarioWithPanel(t, "When an admin restores a library panel version, it should save it as a new version",
+		func(t *testing.T, sc scenarioContext) {
+			patchToGraphPanel(t, sc)
+
+			sc.reqContext.Req.Body = mockRequestBody(RestoreLibraryElementVersionCommand{Version: 1})
+			resp := sc.service.restoreVersionHandler(sc.reqContext)
+			result := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(3), result.Result.Version)
+			require.Equal(t, "text", result.Result.Type)
+			require.Equal(t, sc.folder.Id, result.Result.FolderID)
+
+			resp = sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var versions LibraryElementVersionsResponse
+			require.NoError(t, json.Unmarshal(resp.Body(), &versions))
+			require.Len(t, versions.Result, 3)
+			require.Equal(t, "Restored from version 1", versions.Result[0].Message)
+		})
+
+	scenarioWithPanel(t, "When an admin compares library panel versions, it should return the diff",
+		func(t *testing.T, sc scenarioContext) {
+			patchToGraphPanel(t, sc)
+
+			sc.reqContext.Req.Form = url.Values{"base": {"1"}, "new": {"2"}, "diffType": {"delta"}}
+			resp := sc.service.diffHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			require.Contains(t, string(resp.Body()), "graph")
+
+			sc.reqContext.Req.Form = url.Values{"base": {"1"}, "new": {"3"}}
+			resp = sc.service.diffHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+
+			sc.reqContext.Req.Form = url.Values{"base": {"1"}, "new": {"2"}, "diffType": {"semantic"}}
+			resp = sc.service.diffHandler(sc.reqContext)
+			require.Equal(t, 400, resp.Status())
+		})
+
+	scenarioWithPanel(t, "When an admin deletes a library panel, it should delete its versions",
+		func(t *testing.T, sc scenarioContext) {
+			patchToGraphPanel(t, sc)
+
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			err := sc.sqlStore.WithDbSession(sc.reqContext.Req.Context(), func(session *sqlstore.DBSession) error {
+				count, err := session.Table("library_element_version").Count()
+				require.Zero(t, count)
+				return err
+			})
+			require.NoError(t, err)
+		})
+}
+
+func TestPinnedLibraryElementVersions(t *testing.T) {
+	scenarioWithPanel(t, "When a dashboard is pinned to a library panel version, it should get that version",
+		func(t *testing.T, sc scenarioContext) {
+			dashInDB := createDashboardWithLibraryPanel(t, sc)
+			err := sc.service.ConnectElementVersionsToDashboard(sc.reqContext.Req.Context(), sc.reqContext.SignedInUser, map[string]int64{sc.initialResult.Result.UID: 1}, dashInDB.Id)
+			require.NoError(t, err)
+			patchToGraphPanel(t, sc)
+
+			elements, err := sc.service.GetElementsForDashboard(sc.reqContext.Req.Context(), dashInDB.Id)
+			require.NoError(t, err)
+			element := elements[sc.initialResult.Result.UID]
+			require.Equal(t, int64(1), element.Version)
+			require.Equal(t, "text", element.Type)
+			require.Equal(t, userInDbName, element.Meta.UpdatedBy.Name)
+
+			resp := sc.service.getConnectionsHandler(sc.reqContext)
+			connections := validateAndUnMarshalConnectionResponse(t, resp)
+			require.Len(t, connections.Result, 1)
+			require.Equal(t, int64(1), connections.Result[0].Version)
+		})
+
+	scenarioWithPanel(t, "When a dashboard is pinned to a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			dashInDB := createDashboardWithLibraryPanel(t, sc)
+			err := sc.service.ConnectElementVersionsToDashboard(sc.reqContext.Req.Context(), sc.reqContext.SignedInUser, map[string]int64{sc.initialResult.Result.UID: 2}, dashInDB.Id)
+			require.ErrorIs(t, err, errLibraryElementVersionNotFound)
+		})
+
+	scenarioWithPanel(t, "When an admin gets the dependencies of a library panel, it should return the version each dashboard uses",
+		func(t *testing.T, sc scenarioContext) {
+			pinnedDash := createDashboardWithLibraryPanel(t, sc)
+			err := sc.service.ConnectElementVersionsToDashboard(sc.reqContext.Req.Context(), sc.reqContext.SignedInUser, map[string]int64{sc.initialResult.Result.UID: 1}, pinnedDash.Id)
+			require.NoError(t, err)
+			latestDash := createDashboard(t, sc.sqlStore, sc.user, &models.Dashboard{
+				Title: "A dashboard using the latest version",
+				Data:  simplejson.NewFromAny(map[string]interface{}{"panels": []interface{}{}}),
+			}, 0)
+			err = sc.service.ConnectElementsToDashboard(sc.reqContext.Req.Context(), sc.reqContext.SignedInUser, []string{sc.initialResult.Result.UID}, latestDash.Id)
+			require.NoError(t, err)
+			patchToGraphPanel(t, sc)
+
+			resp := sc.service.getDependenciesHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var result LibraryElementDependenciesResponse
+			require.NoError(t, json.Unmarshal(resp.Body(), &result))
+
+			require.Equal(t, int64(2), result.Result.Version)
+			require.Equal(t, []LibraryElementDashboardDependencyDTO{
+				{ID: latestDash.Id, UID: latestDash.Uid, Title: latestDash.Title, PinnedVersion: 0, Version: 2},
+				{ID: pinnedDash.Id, UID: pinnedDash.Uid, Title: pinnedDash.Title, FolderUID: sc.folder.Uid, PinnedVersion: 1, Version: 1},
+			}, result.Result.Dashboards)
+			require.Equal(t, []LibraryElementVersionUsageDTO{
+				{Version: 2, Dashboards: 1},
+				{Version: 1, Dashboards: 1},
+			}, result.Result.Versions)
+			require.Zero(t, result.Result.HiddenDashboards)
+		})
+}
@@ -109,6 +109,7 @@ type libraryElementConnection struct {
 	ElementID    int64 `xorm:"element_id"`
 	Kind         int64 `xorm:"kind"`
 	ConnectionID int64 `xorm:"connection_id"`
+	Version      int64
 	Created      time.Time
 	CreatedBy    int64
 }
@@ -120,6 +121,7 @@ type libraryElementConnectionWithMeta struct {
 	Kind           int64  `xorm:"kind"`
 	ConnectionID   int64  `xorm:"connection_id"`
 	ConnectionUID  string `xorm:"connection_uid"`
+	Version        int64
 	Created        time.Time
 	CreatedBy      int64
 	CreatedByName  string
@@ -128,13 +130,85 @@ type libraryElementConnectionWithMeta struct {
 
 // LibraryElementConnectionDTO is the frontend DTO for element connections.
 type LibraryElementConnectionDTO struct {
-	ID            int64                     `json:"id"`
-	Kind          int64                     `json:"kind"`
-	ElementID     int64                     `json:"elementId"`
-	ConnectionID  int64                     `json:"connectionId"`
-	ConnectionUID string                    `json:"connectionUid"`
-	Created       time.Time                 `json:"created"`
-	CreatedBy     LibraryElementDTOMetaUser `json:"createdBy"`
+	ID            int64  `json:"id"`
+	Kind          int64  `json:"kind"`
+	ElementID     int64  `json:"elementId"`
+	ConnectionID  int64  `json:"connectionId"`
+	ConnectionUID string `json:"connectionUid"`
+	// Version of the element the connection is pinned to, 0 if it uses the latest version.
+	Version   int64                     `json:"version"`
+	Created   time.Time                 `json:"created"`
+	CreatedBy LibraryElementDTOMetaUser `json:"createdBy"`
+}
+
+// libraryElementVersion is the model for library element versions.
+type libraryElementVersion struct {
+	ID        int64 `xorm:"pk autoincr 'id'"`
+	ElementID int64 `xorm:"element_id"`
+	Version   int64
+	Name      string
+	Model     json.RawMessage
+	Message   string
+	Created   time.Time
+	CreatedBy int64
+}
+
+// libraryElementVersionWithMeta is the model for library element versions with meta.
+type libraryElementVersionWithMeta struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	ElementID      int64 `xorm:"element_id"`
+	Version        int64
+	Name           string
+	Model          json.RawMessage
+	Message        string
+	Created        time.Time
+	CreatedBy      int64
+	CreatedByName  string
+	CreatedByEmail string
+}
+
+// LibraryElementVersionDTO is the frontend DTO for element versions.
+type LibraryElementVersionDTO struct {
+	ID         int64  `json:"id"`
+	ElementID  int64  `json:"elementId"`
+	ElementUID string `json:"elementUid"`
+	Version    int64  `json:"version"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+	// The JSON model of the version, only returned when getting a single version.
+	Model     json.RawMessage           `json:"model,omitempty"`
+	Created   time.Time                 `json:"created"`
+	CreatedBy LibraryElementDTOMetaUser `json:"createdBy"`
+}
+
+// LibraryElementDependenciesDTO is the frontend DTO for the dashboards using an element.
+type LibraryElementDependenciesDTO struct {
+	UID string `json:"uid"`
+	// Latest version of the element.
+	Version    int64                                  `json:"version"`
+	Dashboards []LibraryElementDashboardDependencyDTO `json:"dashboards"`
+	// Number of dashboards using each version of the element, latest version first.
+	Versions []LibraryElementVersionUsageDTO `json:"versions"`
+	// Number of dashboards using the element that the user can't view.
+	HiddenDashboards int64 `json:"hiddenDashboards"`
+}
+
+// LibraryElementDashboardDependencyDTO is a dashboard using an element.
+type LibraryElementDashboardDependencyDTO struct {
+	ID        int64  `json:"id"`
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folderUid"`
+	// Version of the element the dashboard is pinned to, 0 if it uses the latest version.
+	PinnedVersion int64 `json:"pinnedVersion"`
+	// Version of the element the dashboard uses.
+	Version int64 `json:"version"`
+}
+
+// LibraryElementVersionUsageDTO is the number of dashboards using a version of an element.
+type LibraryElementVersionUsageDTO struct {
+	Version    int64 `json:"version"`
+	Dashboards int64 `json:"dashboards"`
 }
 
 var (
@@ -156,6 +230,8 @@ var (
 	errLibraryElementInvalidUID = errors.New("uid contains illegal characters")
 	// errLibraryElementUIDTooLong is an error for when the uid of a library element is invalid
 	errLibraryElementUIDTooLong = errors.New("uid too long, max 40 characters")
+	// errLibraryElementVersionNotFound is an error for when a version of a library element can't be found.
+	errLibraryElementVersionNotFound = errors.New("library element version could not be found")
 )
 
 // Commands
@@ -200,10 +276,19 @@ type PatchLibraryElementCommand struct {
 	Kind int64 `json:"kind" binding:"Required"`
 	// Version of the library element you are updating.
 	Version int64 `json:"version" binding:"Required"`
+	// Message describing the changes of the new version.
+	// required: false
+	Message string `json:"message"`
 	// required: false
 	UID string `json:"uid"`
 }
 
+// RestoreLibraryElementVersionCommand is the command for restoring a version of a LibraryElement
+type RestoreLibraryElementVersionCommand struct {
+	// Version of the library element to restore.
+	Version int64 `json:"version" binding:"Required"`
+}
+
 // searchLibraryElementsQuery is the query used for searching for Elements
 type searchLibraryElementsQuery struct {
 	perPage       int
@@ -236,6 +321,21 @@ type LibraryElementConnectionsResponse struct {
 	Result []LibraryElementConnectionDTO `json:"result"`
 }
 
+// LibraryElementVersionResponse is a response struct for LibraryElementVersionDTO.
+type LibraryElementVersionResponse struct {
+	Result LibraryElementVersionDTO `json:"result"`
+}
+
+// LibraryElementVersionsResponse is a response struct for an array of LibraryElementVersionDTO.
+type LibraryElementVersionsResponse struct {
+	Result []LibraryElementVersionDTO `json:"result"`
+}
+
+// LibraryElementDependenciesResponse is a response struct for LibraryElementDependenciesDTO.
+type LibraryElementDependenciesResponse struct {
+	Result LibraryElementDependenciesDTO `json:"result"`
+}
+
 // DeleteLibraryElementResponse is the response struct for deleting a library element.
 type DeleteLibraryElementResponse struct {
 	ID      int64  `json:"id"`
@@ -95,9 +95,13 @@ func loadLibraryPanelsRecursively(elements map[string]libraryelements.LibraryEle
 				elem.Set("gridPos", gridPos)
 			}
 			elem.Set("id", panelAsJSON.Get("id").MustInt64())
-			elem.Set("libraryPanel", map[string]interface{}{
+			header := map[string]interface{}{
 				"uid": UID,
-			})
+			}
+			if pinnedVersion := libraryPanel.Get("pinnedVersion").MustInt64(); pinnedVersion != 0 {
+				header["pinnedVersion"] = pinnedVersion
+			}
+			elem.Set("libraryPanel", header)
 			continue
 		}
 
@@ -128,7 +132,7 @@ func loadLibraryPanelsRecursively(elements map[string]libraryelements.LibraryEle
 			elem.Set("gridPos", gridPos)
 		}
 		elem.Set("id", panelAsJSON.Get("id").MustInt64())
-		elem.Set("libraryPanel", map[string]interface{}{
+		header := map[string]interface{}{
 			"uid":         elementInDB.UID,
 			"name":        elementInDB.Name,
 			"type":        elementInDB.Type,
@@ -151,7 +155,12 @@ func loadLibraryPanelsRecursively(elements map[string]libraryelements.LibraryEle
 					"avatarUrl": elementInDB.Meta.UpdatedBy.AvatarURL,
 				},
 			},
-		})
+		}
+		// the connection of a pinned panel already resolves the pinned version of the element
+		if pinnedVersion := libraryPanel.Get("pinnedVersion").MustInt64(); pinnedVersion != 0 {
+			header["pinnedVersion"] = pinnedVersion
+		}
+		elem.Set("libraryPanel", header)
 	}
 
 	return nil
@@ -191,12 +200,16 @@ func cleanLibraryPanelsRecursively(parent *simplejson.Json) error {
 		// keep only the necessary JSON properties, the rest of the properties should be safely stored in library_panels table
 		gridPos := panelAsJSON.Get("gridPos").MustMap()
 		ID := panelAsJSON.Get("id").MustInt64(int64(i))
+		header := map[string]interface{}{
+			"uid": UID,
+		}
+		if pinnedVersion := libraryPanel.Get("pinnedVersion").MustInt64(); pinnedVersion != 0 {
+			header["pinnedVersion"] = pinnedVersion
+		}
 		parent.Get("panels").SetIndex(i, map[string]interface{}{
-			"id":      ID,
-			"gridPos": gridPos,
-			"libraryPanel": map[string]interface{}{
-				"uid": UID,
-			},
+			"id":           ID,
+			"gridPos":      gridPos,
+			"libraryPanel": header,
 		})
 	}
 
@@ -204,27 +217,23 @@ func cleanLibraryPanelsRecursively(parent *simplejson.Json) error {
 }
 
 // ConnectLibraryPanelsForDashboard loops through all panels in dashboard JSON and connects any library panels to the dashboard.
+// Library panels with a pinnedVersion are connected to that version of the library panel.
 func (lps *LibraryPanelService) ConnectLibraryPanelsForDashboard(c context.Context, signedInUser *user.SignedInUser, dash *models.Dashboard) error {
 	panels := dash.Data.Get("panels").MustArray()
-	libraryPanels := make(map[string]string)
+	libraryPanels := make(map[string]int64)
 	err := connectLibraryPanelsRecursively(c, panels, libraryPanels)
 	if err != nil {
 		return err
 	}
 
-	elementUIDs := make([]string, 0, len(libraryPanels))
-	for libraryPanel := range libraryPanels {
-		elementUIDs = append(elementUIDs, libraryPanel)
-	}
-
-	return lps.LibraryElementService.ConnectElementsToDashboard(c, signedInUser, elementUIDs, dash.Id)
+	return lps.LibraryElementService.ConnectElementVersionsToDashboard(c, signedInUser, libraryPanels, dash.Id)
 }
 
 func isLibraryPanelOrRow(panel *simplejson.Json, panelType string) bool {
 	return panel.Interface() != nil || panelType == "row"
 }
 
-func connectLibraryPanelsRecursively(c context.Context, panels []interface{}, libraryPanels map[string]string) error {
+func connectLibraryPanelsRecursively(c context.Context, panels []interface{}, libraryPanels map[string]int64) error {
 	for _, panel := range panels {
 		panelAsJSON := simplejson.NewFromAny(panel)
 		libraryPanel := panelAsJSON.Get("libraryPanel")
@@ -248,10 +257,11 @@ func connectLibraryPanelsRecursively(c context.Context, panels []interface{}, li
 		if len(UID) == 0 {
 			return errLibraryPanelHeaderUIDMissing
 		}
-		_, exists := libraryPanels[UID]
-		if !exists {
-			libraryPanels[UID] = UID
+		pinnedVersion := libraryPanel.Get("pinnedVersion").MustInt64()
+		if version, exists := libraryPanels[UID]; exists && version != pinnedVersion {
+			return errLibraryPanelPinnedVersionConflict
 		}
+		libraryPanels[UID] = pinnedVersion
 	}
 
 	return nil
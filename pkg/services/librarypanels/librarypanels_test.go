@@ -1000,6 +1000,96 @@ func TestConnectLibraryPanelsForDashboard(t *testing.T) {
 		})
 }
 
+func TestPinnedLibraryPanelsForDashboard(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin tries to store and load a dashboard with a pinned library panel, it should keep the pinned version",
+		func(t *testing.T, sc scenarioContext) {
+			dashJSON := map[string]interface{}{
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id":    int64(1),
+						"title": "Text - Library Panel",
+						"type":  "text",
+						"libraryPanel": map[string]interface{}{
+							"uid":           sc.initialResult.Result.UID,
+							"name":          sc.initialResult.Result.Name,
+							"pinnedVersion": int64(1),
+						},
+					},
+				},
+			}
+			dash := models.Dashboard{
+				Title: "Testing PinnedLibraryPanelsForDashboard",
+				Data:  simplejson.NewFromAny(dashJSON),
+			}
+			dashInDB := createDashboard(t, sc.sqlStore, sc.user, &dash, sc.folder.Id)
+
+			err := sc.service.ConnectLibraryPanelsForDashboard(sc.ctx, sc.user, dashInDB)
+			require.NoError(t, err)
+			err = sc.service.CleanLibraryPanelsForDashboard(dashInDB)
+			require.NoError(t, err)
+			libraryPanel := dashInDB.Data.Get("panels").GetIndex(0).Get("libraryPanel")
+			require.Equal(t, map[string]interface{}{"uid": sc.initialResult.Result.UID, "pinnedVersion": int64(1)}, libraryPanel.MustMap())
+
+			err = sc.service.LoadLibraryPanelsForDashboard(sc.ctx, dashInDB)
+			require.NoError(t, err)
+			libraryPanel = dashInDB.Data.Get("panels").GetIndex(0).Get("libraryPanel")
+			require.Equal(t, int64(1), libraryPanel.Get("pinnedVersion").MustInt64())
+			require.Equal(t, int64(1), libraryPanel.Get("version").MustInt64())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to store a dashboard pinned to a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			dashJSON := map[string]interface{}{
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id": int64(1),
+						"libraryPanel": map[string]interface{}{
+							"uid":           sc.initialResult.Result.UID,
+							"pinnedVersion": int64(2),
+						},
+					},
+				},
+			}
+			dash := models.Dashboard{
+				Title: "Testing PinnedLibraryPanelsForDashboard",
+				Data:  simplejson.NewFromAny(dashJSON),
+			}
+			dashInDB := createDashboard(t, sc.sqlStore, sc.user, &dash, sc.folder.Id)
+
+			err := sc.service.ConnectLibraryPanelsForDashboard(sc.ctx, sc.user, dashInDB)
+			require.Error(t, err)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to store a dashboard using different versions of a library panel, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			dashJSON := map[string]interface{}{
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id": int64(1),
+						"libraryPanel": map[string]interface{}{
+							"uid":           sc.initialResult.Result.UID,
+							"pinnedVersion": int64(1),
+						},
+					},
+					map[string]interface{}{
+						"id": int64(2),
+						"libraryPanel": map[string]interface{}{
+							"uid": sc.initialResult.Result.UID,
+						},
+					},
+				},
+			}
+			dash := models.Dashboard{
+				Title: "Testing PinnedLibraryPanelsForDashboard",
+				Data:  simplejson.NewFromAny(dashJSON),
+			}
+			dashInDB := createDashboard(t, sc.sqlStore, sc.user, &dash, sc.folder.Id)
+
+			err := sc.service.ConnectLibraryPanelsForDashboard(sc.ctx, sc.user, dashInDB)
+			require.EqualError(t, err, errLibraryPanelPinnedVersionConflict.Error())
+		})
+}
+
 func TestImportLibraryPanelsForDashboard(t *testing.T) {
 	testScenario(t, "When an admin tries to import a dashboard with a library panel that does not exist, it should import the library panel",
 		func(t *testing.T, sc scenarioContext) {
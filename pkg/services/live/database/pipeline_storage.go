@@ -0,0 +1,422 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const defaultChannelRuleHistoryLimit = 100
+
+// PipelineStorage keeps Live pipeline channel rules and write configs in the
+// database, so they are shared by all Grafana instances. Channel rules are
+// versioned: every change is recorded in rule history and updates may be
+// based on a rule version to detect concurrent changes.
+type PipelineStorage struct {
+	store          *sqlstore.SQLStore
+	secretsService secrets.Service
+}
+
+func NewPipelineStorage(store *sqlstore.SQLStore, secretsService secrets.Service) *PipelineStorage {
+	return &PipelineStorage{store: store, secretsService: secretsService}
+}
+
+type liveChannelRule struct {
+	ID       int64 `xorm:"pk autoincr 'id'"`
+	OrgID    int64 `xorm:"org_id"`
+	Pattern  string
+	Settings string
+	Version  int64
+	Created  time.Time
+	Updated  time.Time
+}
+
+func (liveChannelRule) TableName() string {
+	return "live_channel_rule"
+}
+
+type liveChannelRuleVersion struct {
+	ID        int64 `xorm:"pk autoincr 'id'"`
+	OrgID     int64 `xorm:"org_id"`
+	Pattern   string
+	Version   int64
+	Action    string
+	Settings  string
+	Created   time.Time
+	CreatedBy int64
+}
+
+func (liveChannelRuleVersion) TableName() string {
+	return "live_channel_rule_version"
+}
+
+type liveWriteConfig struct {
+	ID             int64  `xorm:"pk autoincr 'id'"`
+	OrgID          int64  `xorm:"org_id"`
+	UID            string `xorm:"uid"`
+	Settings       string
+	SecureSettings string
+	Created        time.Time
+	Updated        time.Time
+}
+
+func (liveWriteConfig) TableName() string {
+	return "live_write_config"
+}
+
+func (s *PipelineStorage) ListChannelRules(ctx context.Context, orgID int64) ([]pipeline.ChannelRule, error) {
+	var rows []liveChannelRule
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("pattern").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]pipeline.ChannelRule, 0, len(rows))
+	for _, row := range rows {
+		rule, err := row.toChannelRule()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (s *PipelineStorage) CreateChannelRule(ctx context.Context, orgID int64, cmd pipeline.ChannelRuleCreateCmd) (pipeline.ChannelRule, error) {
+	rule := pipeline.ChannelRule{
+		OrgId:    orgID,
+		Pattern:  cmd.Pattern,
+		Settings: cmd.Settings,
+		Version:  1,
+	}
+	if ok, reason := rule.Valid(); !ok {
+		return rule, fmt.Errorf("invalid channel rule: %s", reason)
+	}
+	err := s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return s.createChannelRule(sess, rule, cmd.UserID)
+	})
+	return rule, err
+}
+
+func (s *PipelineStorage) createChannelRule(sess *sqlstore.DBSession, rule pipeline.ChannelRule, userID int64) error {
+	exists, err := sess.Where("org_id = ? AND pattern = ?", rule.OrgId, rule.Pattern).Exist(&liveChannelRule{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return pipeline.ErrChannelRuleExists
+	}
+	if err := checkPatterns(sess, rule); err != nil {
+		return err
+	}
+	settings, err := json.Marshal(rule.Settings)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := sess.Insert(&liveChannelRule{
+		OrgID:    rule.OrgId,
+		Pattern:  rule.Pattern,
+		Settings: string(settings),
+		Version:  rule.Version,
+		Created:  now,
+		Updated:  now,
+	}); err != nil {
+		return err
+	}
+	return addChannelRuleVersion(sess, rule, pipeline.ChannelRuleActionCreate, string(settings), userID, now)
+}
+
+func (s *PipelineStorage) UpdateChannelRule(ctx context.Context, orgID int64, cmd pipeline.ChannelRuleUpdateCmd) (pipeline.ChannelRule, error) {
+	rule := pipeline.ChannelRule{
+		OrgId:    orgID,
+		Pattern:  cmd.Pattern,
+		Settings: cmd.Settings,
+	}
+	if ok, reason := rule.Valid(); !ok {
+		return rule, fmt.Errorf("invalid channel rule: %s", reason)
+	}
+	settings, err := json.Marshal(rule.Settings)
+	if err != nil {
+		return rule, err
+	}
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var existing liveChannelRule
+		ok, err := sess.Where("org_id = ? AND pattern = ?", orgID, cmd.Pattern).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if cmd.Version != 0 {
+				return pipeline.ErrChannelRuleNotFound
+			}
+			// Same as FileStorage, updating a missing rule creates it.
+			rule.Version = 1
+			return s.createChannelRule(sess, rule, cmd.UserID)
+		}
+		if cmd.Version != 0 && cmd.Version != existing.Version {
+			return pipeline.ErrChannelRuleVersionMismatch
+		}
+
+		rule.Version = existing.Version + 1
+		now := time.Now()
+		// Conditional on the version read above, so concurrent updates can't
+		// overwrite each other.
+		affected, err := sess.Table("live_channel_rule").
+			Where("id = ? AND version = ?", existing.ID, existing.Version).
+			Cols("settings", "version", "updated").
+			Update(&liveChannelRule{Settings: string(settings), Version: rule.Version, Updated: now})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return pipeline.ErrChannelRuleVersionMismatch
+		}
+		return addChannelRuleVersion(sess, rule, pipeline.ChannelRuleActionUpdate, string(settings), cmd.UserID, now)
+	})
+	return rule, err
+}
+
+func (s *PipelineStorage) DeleteChannelRule(ctx context.Context, orgID int64, cmd pipeline.ChannelRuleDeleteCmd) error {
+	return s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var existing liveChannelRule
+		ok, err := sess.Where("org_id = ? AND pattern = ?", orgID, cmd.Pattern).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return pipeline.ErrChannelRuleNotFound
+		}
+		if _, err := sess.ID(existing.ID).Delete(&liveChannelRule{}); err != nil {
+			return err
+		}
+		rule := pipeline.ChannelRule{OrgId: orgID, Pattern: existing.Pattern, Version: existing.Version + 1}
+		return addChannelRuleVersion(sess, rule, pipeline.ChannelRuleActionDelete, existing.Settings, cmd.UserID, time.Now())
+	})
+}
+
+func (s *PipelineStorage) ListChannelRuleHistory(ctx context.Context, orgID int64, cmd pipeline.ChannelRuleHistoryCmd) ([]pipeline.ChannelRuleVersion, error) {
+	limit := cmd.Limit
+	if limit <= 0 {
+		limit = defaultChannelRuleHistoryLimit
+	}
+	var rows []liveChannelRuleVersion
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ? AND pattern = ?", orgID, cmd.Pattern).Desc("id").Limit(limit).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]pipeline.ChannelRuleVersion, 0, len(rows))
+	for _, row := range rows {
+		v := pipeline.ChannelRuleVersion{
+			Pattern:   row.Pattern,
+			Version:   row.Version,
+			Action:    row.Action,
+			Created:   row.Created,
+			CreatedBy: row.CreatedBy,
+		}
+		if err := json.Unmarshal([]byte(row.Settings), &v.Settings); err != nil {
+			return nil, fmt.Errorf("can't unmarshal settings of channel rule %s version %d: %w", row.Pattern, row.Version, err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (r liveChannelRule) toChannelRule() (pipeline.ChannelRule, error) {
+	rule := pipeline.ChannelRule{
+		OrgId:   r.OrgID,
+		Pattern: r.Pattern,
+		Version: r.Version,
+	}
+	if err := json.Unmarshal([]byte(r.Settings), &rule.Settings); err != nil {
+		return rule, fmt.Errorf("can't unmarshal settings of channel rule %s: %w", r.Pattern, err)
+	}
+	return rule, nil
+}
+
+// checkPatterns checks that the pattern of a new rule doesn't conflict with
+// patterns of other org rules.
+func checkPatterns(sess *sqlstore.DBSession, rule pipeline.ChannelRule) error {
+	var patterns []string
+	if err := sess.Table("live_channel_rule").Where("org_id = ?", rule.OrgId).Cols("pattern").Find(&patterns); err != nil {
+		return err
+	}
+	rules := make([]pipeline.ChannelRule, 0, len(patterns)+1)
+	for _, p := range patterns {
+		rules = append(rules, pipeline.ChannelRule{OrgId: rule.OrgId, Pattern: p})
+	}
+	rules = append(rules, rule)
+	if ok, reason := pipeline.CheckRulesValid(rule.OrgId, rules); !ok {
+		return errors.New(reason)
+	}
+	return nil
+}
+
+func addChannelRuleVersion(sess *sqlstore.DBSession, rule pipeline.ChannelRule, action string, settings string, userID int64, created time.Time) error {
+	_, err := sess.Insert(&liveChannelRuleVersion{
+		OrgID:     rule.OrgId,
+		Pattern:   rule.Pattern,
+		Version:   rule.Version,
+		Action:    action,
+		Settings:  settings,
+		Created:   created,
+		CreatedBy: userID,
+	})
+	return err
+}
+
+func (s *PipelineStorage) ListWriteConfigs(ctx context.Context, orgID int64) ([]pipeline.WriteConfig, error) {
+	var rows []liveWriteConfig
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("uid").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]pipeline.WriteConfig, 0, len(rows))
+	for _, row := range rows {
+		c, err := row.toWriteConfig()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
+
+func (s *PipelineStorage) GetWriteConfig(ctx context.Context, orgID int64, cmd pipeline.WriteConfigGetCmd) (pipeline.WriteConfig, bool, error) {
+	var row liveWriteConfig
+	var ok bool
+	err := s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		ok, err = sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Get(&row)
+		return err
+	})
+	if err != nil || !ok {
+		return pipeline.WriteConfig{}, false, err
+	}
+	c, err := row.toWriteConfig()
+	if err != nil {
+		return pipeline.WriteConfig{}, false, err
+	}
+	return c, true, nil
+}
+
+func (s *PipelineStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd pipeline.WriteConfigCreateCmd) (pipeline.WriteConfig, error) {
+	if cmd.UID == "" {
+		cmd.UID = util.GenerateShortUID()
+	}
+	row, writeConfig, err := s.newWriteConfig(ctx, orgID, cmd.UID, cmd.Settings, cmd.SecureSettings)
+	if err != nil {
+		return pipeline.WriteConfig{}, err
+	}
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Exist(&liveWriteConfig{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("backend already exists in org: %s", cmd.UID)
+		}
+		_, err = sess.Insert(row)
+		return err
+	})
+	return writeConfig, err
+}
+
+func (s *PipelineStorage) UpdateWriteConfig(ctx context.Context, orgID int64, cmd pipeline.WriteConfigUpdateCmd) (pipeline.WriteConfig, error) {
+	row, writeConfig, err := s.newWriteConfig(ctx, orgID, cmd.UID, cmd.Settings, cmd.SecureSettings)
+	if err != nil {
+		return pipeline.WriteConfig{}, err
+	}
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		// Affected rows can't tell a missing write config from an unchanged
+		// one, MySQL doesn't count rows updated with the same values.
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Exist(&liveWriteConfig{})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// Same as FileStorage, updating a missing write config creates it.
+			_, err = sess.Insert(row)
+			return err
+		}
+		_, err = sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).
+			Cols("settings", "secure_settings", "updated").
+			Update(row)
+		return err
+	})
+	return writeConfig, err
+}
+
+func (s *PipelineStorage) DeleteWriteConfig(ctx context.Context, orgID int64, cmd pipeline.WriteConfigDeleteCmd) error {
+	return s.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Delete(&liveWriteConfig{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return fmt.Errorf("write config not found")
+		}
+		return nil
+	})
+}
+
+func (s *PipelineStorage) newWriteConfig(ctx context.Context, orgID int64, uid string, settings pipeline.WriteSettings, secureSettings map[string]string) (*liveWriteConfig, pipeline.WriteConfig, error) {
+	encrypted, err := s.secretsService.EncryptJsonData(ctx, secureSettings, secrets.WithoutScope())
+	if err != nil {
+		return nil, pipeline.WriteConfig{}, fmt.Errorf("error encrypting data: %w", err)
+	}
+	writeConfig := pipeline.WriteConfig{
+		OrgId:          orgID,
+		UID:            uid,
+		Settings:       settings,
+		SecureSettings: encrypted,
+	}
+	if ok, reason := writeConfig.Valid(); !ok {
+		return nil, pipeline.WriteConfig{}, fmt.Errorf("invalid write config: %s", reason)
+	}
+	settingsJSON, err := json.Marshal(writeConfig.Settings)
+	if err != nil {
+		return nil, pipeline.WriteConfig{}, err
+	}
+	secureSettingsJSON, err := json.Marshal(writeConfig.SecureSettings)
+	if err != nil {
+		return nil, pipeline.WriteConfig{}, err
+	}
+	now := time.Now()
+	return &liveWriteConfig{
+		OrgID:          orgID,
+		UID:            uid,
+		Settings:       string(settingsJSON),
+		SecureSettings: string(secureSettingsJSON),
+		Created:        now,
+		Updated:        now,
+	}, writeConfig, nil
+}
+
+func (r liveWriteConfig) toWriteConfig() (pipeline.WriteConfig, error) {
+	c := pipeline.WriteConfig{
+		OrgId: r.OrgID,
+		UID:   r.UID,
+	}
+	if err := json.Unmarshal([]byte(r.Settings), &c.Settings); err != nil {
+		return c, fmt.Errorf("can't unmarshal settings of write config %s: %w", r.UID, err)
+	}
+	if r.SecureSettings != "" {
+		if err := json.Unmarshal([]byte(r.SecureSettings), &c.SecureSettings); err != nil {
+			return c, fmt.Errorf("can't unmarshal secure settings of write config %s: %w", r.UID, err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Files don't keep org IDs, FileStorage serves its rules and write configs to
+// the main org only.
+const importOrgID = 1
+
+// ImportFileStorage imports channel rules and write configs kept by
+// pipeline.FileStorage in the pipeline directory of dataPath. Imported files
+// are renamed with an .imported suffix, so they are imported once. Rules and
+// write configs which already exist in the database are kept.
+func (s *PipelineStorage) ImportFileStorage(ctx context.Context, dataPath string) error {
+	rulesPath := filepath.Join(dataPath, "pipeline", "live-channel-rules.json")
+	writeConfigsPath := filepath.Join(dataPath, "pipeline", "write-configs.json")
+
+	var channelRules pipeline.ChannelRules
+	rulesFound, err := readImportFile(rulesPath, &channelRules)
+	if err != nil {
+		return err
+	}
+	var writeConfigs pipeline.WriteConfigs
+	writeConfigsFound, err := readImportFile(writeConfigsPath, &writeConfigs)
+	if err != nil {
+		return err
+	}
+	if !rulesFound && !writeConfigsFound {
+		return nil
+	}
+
+	err = s.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for _, rule := range channelRules.Rules {
+			if err := s.importChannelRule(sess, rule); err != nil {
+				return fmt.Errorf("can't import channel rule %s: %w", rule.Pattern, err)
+			}
+		}
+		for _, writeConfig := range writeConfigs.Configs {
+			if err := importWriteConfig(sess, writeConfig); err != nil {
+				return fmt.Errorf("can't import write config %s: %w", writeConfig.UID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for path, found := range map[string]bool{rulesPath: rulesFound, writeConfigsPath: writeConfigsFound} {
+		if !found {
+			continue
+		}
+		if err := os.Rename(path, path+".imported"); err != nil {
+			return fmt.Errorf("can't rename imported file: %w", err)
+		}
+	}
+	return nil
+}
+
+func readImportFile(path string, v interface{}) (bool, error) {
+	// Safe to ignore gosec warning G304.
+	// nolint:gosec
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("can't read %s file: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("can't unmarshal %s data: %w", path, err)
+	}
+	return true, nil
+}
+
+func (s *PipelineStorage) importChannelRule(sess *sqlstore.DBSession, rule pipeline.ChannelRule) error {
+	rule.OrgId = importOrgID
+	rule.Version = 1
+	exists, err := sess.Where("org_id = ? AND pattern = ?", rule.OrgId, rule.Pattern).Exist(&liveChannelRule{})
+	if err != nil || exists {
+		return err
+	}
+	if ok, reason := rule.Valid(); !ok {
+		return errors.New(reason)
+	}
+	return s.createChannelRule(sess, rule, 0)
+}
+
+// importWriteConfig inserts the write config as is, its secure settings are
+// encrypted by FileStorage already.
+func importWriteConfig(sess *sqlstore.DBSession, writeConfig pipeline.WriteConfig) error {
+	writeConfig.OrgId = importOrgID
+	exists, err := sess.Where("org_id = ? AND uid = ?", writeConfig.OrgId, writeConfig.UID).Exist(&liveWriteConfig{})
+	if err != nil || exists {
+		return err
+	}
+	if ok, reason := writeConfig.Valid(); !ok {
+		return errors.New(reason)
+	}
+	settingsJSON, err := json.Marshal(writeConfig.Settings)
+	if err != nil {
+		return err
+	}
+	secureSettingsJSON, err := json.Marshal(writeConfig.SecureSettings)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = sess.Insert(&liveWriteConfig{
+		OrgID:          writeConfig.OrgId,
+		UID:            writeConfig.UID,
+		Settings:       string(settingsJSON),
+		SecureSettings: string(secureSettingsJSON),
+		Created:        now,
+		Updated:        now,
+	})
+	return err
+}
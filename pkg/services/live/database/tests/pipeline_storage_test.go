@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+)
+
+func TestIntegrationPipelineChannelRules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	storage := SetupTestPipelineStorage(t)
+	ctx := context.Background()
+
+	settings := pipeline.ChannelRuleSettings{
+		Converter: &pipeline.ConverterConfig{Type: pipeline.ConverterTypeJsonAuto},
+	}
+	rule, err := storage.CreateChannelRule(ctx, 1, pipeline.ChannelRuleCreateCmd{
+		Pattern:  "stream/test/sensor",
+		Settings: settings,
+		UserID:   10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rule.Version)
+
+	_, err = storage.CreateChannelRule(ctx, 1, pipeline.ChannelRuleCreateCmd{Pattern: "stream/test/sensor"})
+	require.ErrorIs(t, err, pipeline.ErrChannelRuleExists)
+
+	// Rules are per org.
+	_, err = storage.CreateChannelRule(ctx, 2, pipeline.ChannelRuleCreateCmd{Pattern: "stream/test/sensor"})
+	require.NoError(t, err)
+
+	rules, err := storage.ListChannelRules(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, "stream/test/sensor", rules[0].Pattern)
+	require.Equal(t, settings, rules[0].Settings)
+
+	t.Run("update is based on rule version", func(t *testing.T) {
+		updated, err := storage.UpdateChannelRule(ctx, 1, pipeline.ChannelRuleUpdateCmd{
+			Pattern: "stream/test/sensor",
+			Version: 1,
+			UserID:  11,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), updated.Version)
+
+		_, err = storage.UpdateChannelRule(ctx, 1, pipeline.ChannelRuleUpdateCmd{
+			Pattern: "stream/test/sensor",
+			Version: 1,
+		})
+		require.ErrorIs(t, err, pipeline.ErrChannelRuleVersionMismatch)
+
+		_, err = storage.UpdateChannelRule(ctx, 1, pipeline.ChannelRuleUpdateCmd{
+			Pattern: "stream/missing",
+			Version: 1,
+		})
+		require.ErrorIs(t, err, pipeline.ErrChannelRuleNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, storage.DeleteChannelRule(ctx, 1, pipeline.ChannelRuleDeleteCmd{Pattern: "stream/test/sensor", UserID: 12}))
+		err := storage.DeleteChannelRule(ctx, 1, pipeline.ChannelRuleDeleteCmd{Pattern: "stream/test/sensor"})
+		require.ErrorIs(t, err, pipeline.ErrChannelRuleNotFound)
+
+		rules, err := storage.ListChannelRules(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, rules, 0)
+		rules, err = storage.ListChannelRules(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		versions, err := storage.ListChannelRuleHistory(ctx, 1, pipeline.ChannelRuleHistoryCmd{Pattern: "stream/test/sensor"})
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+
+		require.Equal(t, pipeline.ChannelRuleActionDelete, versions[0].Action)
+		require.Equal(t, int64(3), versions[0].Version)
+		require.Equal(t, int64(12), versions[0].CreatedBy)
+
+		require.Equal(t, pipeline.ChannelRuleActionUpdate, versions[1].Action)
+		require.Equal(t, int64(2), versions[1].Version)
+		require.Nil(t, versions[1].Settings.Converter)
+
+		require.Equal(t, pipeline.ChannelRuleActionCreate, versions[2].Action)
+		require.Equal(t, settings, versions[2].Settings)
+		require.Equal(t, int64(10), versions[2].CreatedBy)
+
+		versions, err = storage.ListChannelRuleHistory(ctx, 1, pipeline.ChannelRuleHistoryCmd{Pattern: "stream/test/sensor", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+	})
+}
+
+func TestIntegrationPipelineChannelRules_Validation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	storage := SetupTestPipelineStorage(t)
+	ctx := context.Background()
+
+	_, err := storage.CreateChannelRule(ctx, 1, pipeline.ChannelRuleCreateCmd{
+		Pattern: "stream/test/sensor",
+		Settings: pipeline.ChannelRuleSettings{
+			Converter: &pipeline.ConverterConfig{Type: "unknown"},
+		},
+	})
+	require.Error(t, err)
+
+	_, err = storage.CreateChannelRule(ctx, 1, pipeline.ChannelRuleCreateCmd{Pattern: "stream/test/:name"})
+	require.NoError(t, err)
+	// Conflicts with the existing pattern.
+	_, err = storage.CreateChannelRule(ctx, 1, pipeline.ChannelRuleCreateCmd{Pattern: "stream/test/:other"})
+	require.Error(t, err)
+}
+
+func TestIntegrationPipelineWriteConfigs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	storage := SetupTestPipelineStorage(t)
+	ctx := context.Background()
+
+	created, err := storage.CreateWriteConfig(ctx, 1, pipeline.WriteConfigCreateCmd{
+		Settings:       pipeline.WriteSettings{Endpoint: "http://localhost:9090/api/v1/write"},
+		SecureSettings: map[string]string{"basicAuthPassword": "secret"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.UID)
+
+	_, err = storage.CreateWriteConfig(ctx, 1, pipeline.WriteConfigCreateCmd{
+		UID:      created.UID,
+		Settings: pipeline.WriteSettings{Endpoint: "http://localhost:9090/api/v1/write"},
+	})
+	require.Error(t, err)
+
+	wc, ok, err := storage.GetWriteConfig(ctx, 1, pipeline.WriteConfigGetCmd{UID: created.UID})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created, wc)
+
+	_, ok, err = storage.GetWriteConfig(ctx, 2, pipeline.WriteConfigGetCmd{UID: created.UID})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = storage.UpdateWriteConfig(ctx, 1, pipeline.WriteConfigUpdateCmd{
+		UID:      created.UID,
+		Settings: pipeline.WriteSettings{Endpoint: "http://localhost:3100"},
+	})
+	require.NoError(t, err)
+
+	configs, err := storage.ListWriteConfigs(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	require.Equal(t, "http://localhost:3100", configs[0].Settings.Endpoint)
+
+	require.NoError(t, storage.DeleteWriteConfig(ctx, 1, pipeline.WriteConfigDeleteCmd{UID: created.UID}))
+	require.Error(t, storage.DeleteWriteConfig(ctx, 1, pipeline.WriteConfigDeleteCmd{UID: created.UID}))
+}
+
+func TestIntegrationPipelineWriteConfigs_UpdateUnchanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	storage := SetupTestPipelineStorage(t)
+	ctx := context.Background()
+
+	cmd := pipeline.WriteConfigUpdateCmd{
+		UID:      "test",
+		Settings: pipeline.WriteSettings{Endpoint: "http://localhost:9090/api/v1/write"},
+	}
+	// Updating a missing write config creates it, updating it with the same
+	// settings must not try to create it again.
+	for i := 0; i < 2; i++ {
+		_, err := storage.UpdateWriteConfig(ctx, 1, cmd)
+		require.NoError(t, err)
+	}
+	configs, err := storage.ListWriteConfigs(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+}
+
+func TestIntegrationPipelineImportFileStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	storage := SetupTestPipelineStorage(t)
+	ctx := context.Background()
+
+	dataPath := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dataPath, "pipeline"), 0750))
+	writeFile := func(name, data string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dataPath, "pipeline", name), []byte(data), 0600))
+	}
+	writeFile("live-channel-rules.json", `{"rules": [
+		{"pattern": "stream/test/sensor", "settings": {"converter": {"type": "jsonAuto"}}},
+		{"pattern": "stream/test/existing"}
+	]}`)
+	writeFile("write-configs.json", `{"writeConfigs": [
+		{"uid": "prom", "settings": {"endpoint": "http://localhost:9090/api/v1/write"}, "secureSettings": {"basicAuthPassword": "ZW5jcnlwdGVk"}}
+	]}`)
+
+	existing, err := storage.CreateChannelRule(ctx, 1, pipeline.ChannelRuleCreateCmd{
+		Pattern:  "stream/test/existing",
+		Settings: pipeline.ChannelRuleSettings{Converter: &pipeline.ConverterConfig{Type: pipeline.ConverterTypeJsonAuto}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, storage.ImportFileStorage(ctx, dataPath))
+
+	rules, err := storage.ListChannelRules(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, existing, rules[0])
+	require.Equal(t, "stream/test/sensor", rules[1].Pattern)
+	require.Equal(t, int64(1), rules[1].Version)
+	require.Equal(t, pipeline.ConverterTypeJsonAuto, rules[1].Settings.Converter.Type)
+
+	wc, ok, err := storage.GetWriteConfig(ctx, 1, pipeline.WriteConfigGetCmd{UID: "prom"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost:9090/api/v1/write", wc.Settings.Endpoint)
+	require.Equal(t, []byte("encrypted"), wc.SecureSettings["basicAuthPassword"])
+
+	t.Run("files are imported once", func(t *testing.T) {
+		_, err := os.Stat(filepath.Join(dataPath, "pipeline", "live-channel-rules.json"))
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = os.Stat(filepath.Join(dataPath, "pipeline", "live-channel-rules.json.imported"))
+		require.NoError(t, err)
+
+		require.NoError(t, storage.DeleteChannelRule(ctx, 1, pipeline.ChannelRuleDeleteCmd{Pattern: "stream/test/sensor"}))
+		require.NoError(t, storage.ImportFileStorage(ctx, dataPath))
+		rules, err := storage.ListChannelRules(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/services/live/database"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
 
@@ -16,3 +17,10 @@ func SetupTestStorage(t *testing.T) *database.Storage {
 	localCache := localcache.New(time.Hour, time.Hour)
 	return database.NewStorage(sqlStore, localCache)
 }
+
+// SetupTestPipelineStorage initializes a pipeline storage to be used by the
+// integration tests.
+func SetupTestPipelineStorage(t *testing.T) *database.PipelineStorage {
+	sqlStore := sqlstore.InitTestDB(t)
+	return database.NewPipelineStorage(sqlStore, fakes.NewFakeSecretsService())
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/orgchannel"
 	"github.com/grafana/grafana/pkg/services/live/pipeline"
 	"github.com/grafana/grafana/pkg/services/live/pushws"
+	"github.com/grafana/grafana/pkg/services/live/ratelimit"
 	"github.com/grafana/grafana/pkg/services/live/runstream"
 	"github.com/grafana/grafana/pkg/services/live/survey"
 	"github.com/grafana/grafana/pkg/services/org"
@@ -46,6 +47,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/web"
@@ -54,14 +56,24 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/gobwas/glob"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/live"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/sync/errgroup"
 )
 
 var (
 	logger   = log.New("live")
 	loggerCF = log.New("live.centrifuge")
+
+	slowSubscriberDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "live",
+		Name:      "slow_subscriber_disconnects_total",
+		Help:      "Number of Live clients disconnected because they could not read messages fast enough.",
+	})
 )
 
 // CoreGrafanaScope list of core features
@@ -103,9 +115,10 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	// things. For example Node allows to publish messages to channels from server
 	// side with its Publish method.
 	node, err := centrifuge.New(centrifuge.Config{
-		LogHandler:       handleLog,
-		LogLevel:         centrifuge.LogLevelError,
-		MetricsNamespace: "grafana_live",
+		LogHandler:         handleLog,
+		LogLevel:           centrifuge.LogLevelError,
+		MetricsNamespace:   "grafana_live",
+		ClientQueueMaxSize: g.Cfg.LiveClientQueueMaxSize,
 	})
 	if err != nil {
 		return nil, err
@@ -158,7 +171,23 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 
 	channelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, nil)
 
+	g.PushLimiter = ratelimit.NewLimiter(ratelimit.Limits{
+		OrgMessagesPerSecond:     cfg.LivePushOrgMaxMessagesPerSecond,
+		OrgBytesPerSecond:        cfg.LivePushOrgMaxBytesPerSecond,
+		ChannelMessagesPerSecond: cfg.LivePushChannelMaxMessagesPerSecond,
+		ChannelBytesPerSecond:    cfg.LivePushChannelMaxBytesPerSecond,
+	})
+
+	var publishInterval time.Duration
+	if cfg.LiveManagedStreamMaxPublishRate > 0 {
+		publishInterval = time.Duration(float64(time.Second) / cfg.LiveManagedStreamMaxPublishRate)
+	}
+
 	var managedStreamRunner *managedstream.Runner
+	historyLimits := managedstream.HistoryLimits{
+		MaxFrames: cfg.LiveHistoryMaxFrames,
+		MaxAge:    cfg.LiveHistoryMaxAge,
+	}
 	if g.IsHA() {
 		redisClient := redis.NewClient(&redis.Options{
 			Addr: g.Cfg.LiveHAEngineAddress,
@@ -171,12 +200,16 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 			g.Publish,
 			channelLocalPublisher,
 			managedstream.NewRedisFrameCache(redisClient),
+			redisFrameHistory(redisClient, historyLimits),
+			publishInterval,
 		)
 	} else {
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
 			managedstream.NewMemoryFrameCache(),
+			memoryFrameHistory(historyLimits),
+			publishInterval,
 		)
 	}
 
@@ -191,21 +224,25 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 				ChannelHandlerGetter: g,
 			}
 		} else {
-			storage := &pipeline.FileStorage{
-				DataPath:       cfg.DataPath,
-				SecretsService: g.SecretsService,
+			storage := database.NewPipelineStorage(sqlStore, g.SecretsService)
+			// Rules and write configs used to be kept in files.
+			if err := storage.ImportFileStorage(context.Background(), cfg.DataPath); err != nil {
+				return nil, fmt.Errorf("can't import pipeline rules from files: %w", err)
 			}
 			g.pipelineStorage = storage
 			builder = &pipeline.StorageRuleBuilder{
-				Node:                 node,
-				ManagedStream:        g.ManagedStreamRunner,
-				FrameStorage:         pipeline.NewFrameStorage(),
-				Storage:              storage,
-				ChannelHandlerGetter: g,
-				SecretsService:       g.SecretsService,
+				Node:                  node,
+				ManagedStream:         g.ManagedStreamRunner,
+				FrameStorage:          pipeline.NewFrameStorage(),
+				Storage:               storage,
+				ChannelHandlerGetter:  g,
+				SecretsService:        g.SecretsService,
+				AggregateStateStorage: pipeline.NewAggregateStateStorage(),
+				FrameOutputCache:      pipeline.NewFrameOutputCache(),
 			}
 		}
 		channelRuleGetter := pipeline.NewCacheSegmentedTree(builder)
+		g.pipelineRuleCache = channelRuleGetter
 
 		// Pre-build/validate channel rules for all organizations on start.
 		// This can be unreasonable to have in production scenario with many
@@ -253,6 +290,10 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		return nil, err
 	}
 
+	// Notifications are delivered to all nodes through the HA engine, they
+	// are used to keep node caches in sync.
+	node.OnNotification(g.handleNotification)
+
 	// Set ConnectHandler called when client successfully connected to Node. Your code
 	// inside handler must be synchronized since it will be called concurrently from
 	// different goroutines (belonging to different client connections). This is also
@@ -311,6 +352,10 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 			if e.Disconnect.Code == 3001 { // Shutdown
 				return
 			}
+			if e.Disconnect.Code == centrifuge.DisconnectSlow.Code {
+				slowSubscriberDisconnects.Inc()
+				logger.Warn("Slow client disconnected, increase client_queue_max_size in [live] configuration section if it happens often", "user", client.UserID(), "client", client.ID())
+			}
 			logger.Debug("Client disconnected", "user", client.UserID(), "client", client.ID(), "reason", reason, "elapsed", time.Since(connectedAt))
 		})
 	})
@@ -337,13 +382,13 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		CheckOrigin:     checkOrigin,
 	})
 
-	pushWSHandler := pushws.NewHandler(g.ManagedStreamRunner, pushws.Config{
+	pushWSHandler := pushws.NewHandler(g.ManagedStreamRunner, g.PushLimiter, pushws.Config{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 		CheckOrigin:     checkOrigin,
 	})
 
-	pushPipelineWSHandler := pushws.NewPipelinePushHandler(g.Pipeline, pushws.Config{
+	pushPipelineWSHandler := pushws.NewPipelinePushHandler(g.Pipeline, g.PushLimiter, pushws.Config{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 		CheckOrigin:     checkOrigin,
@@ -428,6 +473,10 @@ type GrafanaLive struct {
 	ManagedStreamRunner *managedstream.Runner
 	Pipeline            *pipeline.Pipeline
 	pipelineStorage     pipeline.Storage
+	pipelineRuleCache   *pipeline.CacheSegmentedTree
+
+	// PushLimiter limits the rate of data pushed into Live over HTTP and WebSocket.
+	PushLimiter *ratelimit.Limiter
 
 	contextGetter    *liveplugin.ContextGetter
 	runStreamManager *runstream.Manager
@@ -1047,6 +1096,56 @@ func (g *GrafanaLive) HandleListHTTP(c *models.ReqContext) response.Response {
 	return response.JSONStreaming(http.StatusOK, info)
 }
 
+// memoryFrameHistory returns in-memory managed stream history, or nil if history is disabled.
+func memoryFrameHistory(limits managedstream.HistoryLimits) managedstream.FrameHistory {
+	if !limits.Enabled() {
+		return nil
+	}
+	return managedstream.NewMemoryFrameHistory(limits)
+}
+
+// redisFrameHistory returns managed stream history kept in Redis, or nil if history is disabled.
+func redisFrameHistory(redisClient *redis.Client, limits managedstream.HistoryLimits) managedstream.FrameHistory {
+	if !limits.Enabled() {
+		return nil
+	}
+	return managedstream.NewRedisFrameHistory(redisClient, limits)
+}
+
+// HandleHistoryHTTP returns the frames kept in the history of a managed stream
+// channel within the time range given by the from and to query parameters.
+func (g *GrafanaLive) HandleHistoryHTTP(c *models.ReqContext) response.Response {
+	channel := web.Params(c.Req)["*"]
+	if _, err := live.ParseChannel(channel); err != nil {
+		return response.Error(http.StatusBadRequest, "invalid channel", err)
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" {
+		from = "now-1h"
+	}
+	if to == "" {
+		to = "now"
+	}
+	timeRange := legacydata.NewDataTimeRange(from, to)
+	frame, err := g.ManagedStreamRunner.GetHistory(c.Req.Context(), c.SignedInUser.OrgID, channel,
+		timeRange.GetFromAsTimeUTC(), timeRange.GetToAsTimeUTC())
+	if err != nil {
+		if errors.Is(err, managedstream.ErrHistoryDisabled) {
+			return response.Error(http.StatusNotFound, "managed stream history is disabled", err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to get channel history", err)
+	}
+	if frame == nil {
+		return response.Error(http.StatusNotFound, "no history for channel", nil)
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to encode channel history", err)
+	}
+	return response.JSON(http.StatusOK, json.RawMessage(frameJSON))
+}
+
 // HandleInfoHTTP special http response for
 func (g *GrafanaLive) HandleInfoHTTP(ctx *models.ReqContext) response.Response {
 	path := web.Params(ctx.Req)["*"]
@@ -1083,6 +1182,7 @@ type ConvertDryRunResponse struct {
 
 type DryRunRuleStorage struct {
 	ChannelRules []pipeline.ChannelRule
+	WriteConfigs []pipeline.WriteConfig
 }
 
 func (s *DryRunRuleStorage) GetWriteConfig(_ context.Context, _ int64, _ pipeline.WriteConfigGetCmd) (pipeline.WriteConfig, bool, error) {
@@ -1113,8 +1213,12 @@ func (s *DryRunRuleStorage) DeleteChannelRule(_ context.Context, _ int64, _ pipe
 	return errors.New("not implemented by dry run rule storage")
 }
 
+func (s *DryRunRuleStorage) ListChannelRuleHistory(_ context.Context, _ int64, _ pipeline.ChannelRuleHistoryCmd) ([]pipeline.ChannelRuleVersion, error) {
+	return nil, errors.New("not implemented by dry run rule storage")
+}
+
 func (s *DryRunRuleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]pipeline.WriteConfig, error) {
-	return nil, nil
+	return s.WriteConfigs, nil
 }
 
 func (s *DryRunRuleStorage) ListChannelRules(_ context.Context, _ int64) ([]pipeline.ChannelRule, error) {
@@ -1177,10 +1281,15 @@ func (g *GrafanaLive) HandleChannelRulesPostHTTP(c *models.ReqContext) response.
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "Error decoding channel rule", err)
 	}
+	if err := g.validateChannelRule(c.Req.Context(), c.OrgID, cmd.Pattern, cmd.Settings); err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid channel rule", err)
+	}
+	cmd.UserID = c.UserID
 	rule, err := g.pipelineStorage.CreateChannelRule(c.Req.Context(), c.OrgID, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to create channel rule", err)
+		return channelRuleErrorResponse(err, "Failed to create channel rule")
 	}
+	g.pipelineRulesChanged(c.OrgID)
 	return response.JSON(http.StatusOK, util.DynMap{
 		"rule": rule,
 	})
@@ -1200,10 +1309,15 @@ func (g *GrafanaLive) HandleChannelRulesPutHTTP(c *models.ReqContext) response.R
 	if cmd.Pattern == "" {
 		return response.Error(http.StatusBadRequest, "Rule pattern required", nil)
 	}
+	if err := g.validateChannelRule(c.Req.Context(), c.OrgID, cmd.Pattern, cmd.Settings); err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid channel rule", err)
+	}
+	cmd.UserID = c.UserID
 	rule, err := g.pipelineStorage.UpdateChannelRule(c.Req.Context(), c.OrgID, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to update channel rule", err)
+		return channelRuleErrorResponse(err, "Failed to update channel rule")
 	}
+	g.pipelineRulesChanged(c.OrgID)
 	return response.JSON(http.StatusOK, util.DynMap{
 		"rule": rule,
 	})
@@ -1223,13 +1337,113 @@ func (g *GrafanaLive) HandleChannelRulesDeleteHTTP(c *models.ReqContext) respons
 	if cmd.Pattern == "" {
 		return response.Error(http.StatusBadRequest, "Rule pattern required", nil)
 	}
+	cmd.UserID = c.UserID
 	err = g.pipelineStorage.DeleteChannelRule(c.Req.Context(), c.OrgID, cmd)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Failed to delete channel rule", err)
+		return channelRuleErrorResponse(err, "Failed to delete channel rule")
 	}
+	g.pipelineRulesChanged(c.OrgID)
 	return response.JSON(http.StatusOK, util.DynMap{})
 }
 
+// HandleChannelRuleHistoryHTTP ...
+func (g *GrafanaLive) HandleChannelRuleHistoryHTTP(c *models.ReqContext) response.Response {
+	cmd := pipeline.ChannelRuleHistoryCmd{
+		Pattern: c.Query("pattern"),
+		Limit:   c.QueryInt("limit"),
+	}
+	if cmd.Pattern == "" {
+		return response.Error(http.StatusBadRequest, "Rule pattern required", nil)
+	}
+	versions, err := g.pipelineStorage.ListChannelRuleHistory(c.Req.Context(), c.OrgID, cmd)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get channel rule history", err)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"versions": versions,
+	})
+}
+
+// validateChannelRule builds a rule the same way the pipeline does to make
+// sure it can be used before it's saved.
+func (g *GrafanaLive) validateChannelRule(ctx context.Context, orgID int64, pattern string, settings pipeline.ChannelRuleSettings) error {
+	rule := pipeline.ChannelRule{OrgId: orgID, Pattern: pattern, Settings: settings}
+	if ok, reason := rule.Valid(); !ok {
+		return errors.New(reason)
+	}
+	writeConfigs, err := g.pipelineStorage.ListWriteConfigs(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("error getting write configs: %w", err)
+	}
+	builder := &pipeline.StorageRuleBuilder{
+		Node:          g.node,
+		ManagedStream: g.ManagedStreamRunner,
+		FrameStorage:  pipeline.NewFrameStorage(),
+		Storage: &DryRunRuleStorage{
+			ChannelRules: []pipeline.ChannelRule{rule},
+			WriteConfigs: writeConfigs,
+		},
+		ChannelHandlerGetter: g,
+		SecretsService:       g.SecretsService,
+	}
+	rules, err := builder.BuildRules(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	// outputs like webhooks start goroutines, the rules are only built to be validated
+	pipeline.CloseRules(rules)
+	return nil
+}
+
+func channelRuleErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, pipeline.ErrChannelRuleNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, pipeline.ErrChannelRuleExists), errors.Is(err, pipeline.ErrChannelRuleVersionMismatch):
+		return response.Error(http.StatusConflict, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
+
+const notificationPipelineRulesChanged = "pipeline_rules_changed"
+
+type pipelineRulesChangedNotification struct {
+	OrgID int64 `json:"orgId"`
+}
+
+// pipelineRulesChanged invalidates cached pipeline rules of an org on all
+// nodes after rules or write configs were changed.
+func (g *GrafanaLive) pipelineRulesChanged(orgID int64) {
+	data, err := json.Marshal(pipelineRulesChangedNotification{OrgID: orgID})
+	if err != nil {
+		logger.Error("Error encoding pipeline rules notification", "error", err)
+		return
+	}
+	if err := g.node.Notify(notificationPipelineRulesChanged, data, ""); err != nil {
+		logger.Error("Error sending pipeline rules notification", "error", err)
+		// Other nodes pick up changes on next periodic cache update.
+		if g.pipelineRuleCache != nil {
+			g.pipelineRuleCache.Invalidate(orgID)
+		}
+	}
+}
+
+func (g *GrafanaLive) handleNotification(e centrifuge.NotificationEvent) {
+	switch e.Op {
+	case notificationPipelineRulesChanged:
+		var n pipelineRulesChangedNotification
+		if err := json.Unmarshal(e.Data, &n); err != nil {
+			logger.Error("Error decoding pipeline rules notification", "error", err)
+			return
+		}
+		if g.pipelineRuleCache != nil {
+			g.pipelineRuleCache.Invalidate(n.OrgID)
+		}
+	default:
+		logger.Warn("Unknown notification", "op", e.Op, "fromNode", e.FromNodeID)
+	}
+}
+
 // HandlePipelineEntitiesListHTTP ...
 func (g *GrafanaLive) HandlePipelineEntitiesListHTTP(_ *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, util.DynMap{
@@ -1271,6 +1485,7 @@ func (g *GrafanaLive) HandleWriteConfigsPostHTTP(c *models.ReqContext) response.
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Failed to create write config", err)
 	}
+	g.pipelineRulesChanged(c.OrgID)
 	return response.JSON(http.StatusOK, util.DynMap{
 		"writeConfig": pipeline.WriteConfigToDto(result),
 	})
@@ -1315,6 +1530,7 @@ func (g *GrafanaLive) HandleWriteConfigsPutHTTP(c *models.ReqContext) response.R
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Failed to update write config", err)
 	}
+	g.pipelineRulesChanged(c.OrgID)
 	return response.JSON(http.StatusOK, util.DynMap{
 		"writeConfig": pipeline.WriteConfigToDto(result),
 	})
@@ -1338,6 +1554,7 @@ func (g *GrafanaLive) HandleWriteConfigsDeleteHTTP(c *models.ReqContext) respons
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Failed to delete write config", err)
 	}
+	g.pipelineRulesChanged(c.OrgID)
 	return response.JSON(http.StatusOK, util.DynMap{})
 }
 
@@ -0,0 +1,119 @@
+package managedstream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var coalescedFrames = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "live",
+	Name:      "managed_stream_coalesced_frames_total",
+	Help:      "Number of managed stream frames not published to subscribers because a newer frame replaced them.",
+})
+
+// coalescer limits how often frames are published into channels of a
+// managed stream so that subscribers don't fall behind fast publishers.
+// Frames pushed faster than the publish interval replace each other and only
+// the latest one is published at the end of the interval. Frame cache and
+// history still receive every frame.
+type coalescer struct {
+	interval time.Duration
+	publish  func(path string, frameJSON []byte) error
+
+	mu       sync.Mutex
+	channels map[string]*coalescedChannel
+}
+
+// coalescedChannel is the state of a channel which published a frame during
+// the last interval. It is removed when the interval ends without a frame
+// pending.
+type coalescedChannel struct {
+	lastPublished time.Time
+	pending       *data.FrameJSONCache
+	// schemaChanged is set if any of the replaced frames changed the
+	// schema, the pending frame is published with schema then.
+	schemaChanged bool
+	timer         *time.Timer
+}
+
+func newCoalescer(interval time.Duration, publish func(path string, frameJSON []byte) error) *coalescer {
+	return &coalescer{
+		interval: interval,
+		publish:  publish,
+		channels: map[string]*coalescedChannel{},
+	}
+}
+
+// push publishes a frame right away if nothing was published into the channel
+// during the interval, otherwise the frame is published later unless a newer
+// frame replaces it.
+func (c *coalescer) push(path string, frame data.FrameJSONCache, schemaChanged bool) error {
+	c.mu.Lock()
+	ch, ok := c.channels[path]
+	if !ok {
+		ch = &coalescedChannel{lastPublished: time.Now()}
+		c.channels[path] = ch
+		c.scheduleFlush(path, ch)
+		c.mu.Unlock()
+		return c.publish(path, frame.Bytes(includeFor(schemaChanged)))
+	}
+
+	if ch.pending != nil {
+		coalescedFrames.Inc()
+	}
+	ch.pending = &frame
+	ch.schemaChanged = ch.schemaChanged || schemaChanged
+	c.mu.Unlock()
+	return nil
+}
+
+// scheduleFlush flushes the channel at the end of the interval, c.mu must be
+// held.
+func (c *coalescer) scheduleFlush(path string, ch *coalescedChannel) {
+	ch.timer = time.AfterFunc(ch.lastPublished.Add(c.interval).Sub(time.Now()), func() {
+		c.flush(path)
+	})
+}
+
+// flush publishes the pending frame of the channel at the end of an interval,
+// which starts another interval. The channel is removed if no frame is pending.
+func (c *coalescer) flush(path string) {
+	c.mu.Lock()
+	ch, ok := c.channels[path]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	frame, schemaChanged := ch.pending, ch.schemaChanged
+	if frame == nil {
+		delete(c.channels, path)
+		c.mu.Unlock()
+		return
+	}
+	ch.pending, ch.schemaChanged = nil, false
+	ch.lastPublished = time.Now()
+	c.scheduleFlush(path, ch)
+	c.mu.Unlock()
+
+	if err := c.publish(path, frame.Bytes(includeFor(schemaChanged))); err != nil {
+		logger.Error("Error publishing coalesced frame", "path", path, "error", err)
+	}
+}
+
+func (c *coalescer) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.channels)
+}
+
+func includeFor(schemaChanged bool) data.FrameInclude {
+	if schemaChanged {
+		return data.IncludeAll
+	}
+	return data.IncludeDataOnly
+}
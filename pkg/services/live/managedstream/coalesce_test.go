@@ -0,0 +1,87 @@
+package managedstream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	frames [][]byte
+}
+
+func (p *recordingPublisher) publish(_ string, frameJSON []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frames = append(p.frames, frameJSON)
+	return nil
+}
+
+func (p *recordingPublisher) published() [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]byte(nil), p.frames...)
+}
+
+func testFrameJSON(t *testing.T, value float64) data.FrameJSONCache {
+	t.Helper()
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+		data.NewField("value", nil, []float64{value}),
+	)
+	frameJSON, err := data.FrameToJSONCache(frame)
+	require.NoError(t, err)
+	return frameJSON
+}
+
+func TestCoalescer(t *testing.T) {
+	publisher := &recordingPublisher{}
+	c := newCoalescer(50*time.Millisecond, publisher.publish)
+
+	first := testFrameJSON(t, 1)
+	require.NoError(t, c.push("cpu", first, true))
+	// Published right away.
+	require.Equal(t, [][]byte{first.Bytes(data.IncludeAll)}, publisher.published())
+
+	// Frames pushed during the interval replace each other.
+	require.NoError(t, c.push("cpu", testFrameJSON(t, 2), true))
+	last := testFrameJSON(t, 3)
+	require.NoError(t, c.push("cpu", last, false))
+	require.Len(t, publisher.published(), 1)
+
+	// Other channels are not affected.
+	other := testFrameJSON(t, 4)
+	require.NoError(t, c.push("mem", other, false))
+	require.Len(t, publisher.published(), 2)
+
+	require.Eventually(t, func() bool {
+		return len(publisher.published()) == 3
+	}, time.Second, 10*time.Millisecond)
+	// The latest frame is published with schema since one of the replaced
+	// frames changed it.
+	require.Equal(t, last.Bytes(data.IncludeAll), publisher.published()[2])
+}
+
+func TestCoalescer_RemovesIdleChannels(t *testing.T) {
+	publisher := &recordingPublisher{}
+	c := newCoalescer(20*time.Millisecond, publisher.publish)
+
+	require.NoError(t, c.push("cpu", testFrameJSON(t, 1), true))
+	require.NoError(t, c.push("cpu", testFrameJSON(t, 2), false))
+	require.Equal(t, 1, c.len())
+
+	// The pending frame is flushed at the end of the first interval, the
+	// channel is removed at the end of the second one.
+	require.Eventually(t, func() bool {
+		return c.len() == 0
+	}, time.Second, 5*time.Millisecond)
+	require.Len(t, publisher.published(), 2)
+
+	// A channel which is pushed to again is published right away.
+	require.NoError(t, c.push("cpu", testFrameJSON(t, 3), false))
+	require.Len(t, publisher.published(), 3)
+}
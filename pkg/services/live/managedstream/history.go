@@ -0,0 +1,103 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameHistory keeps the most recent frames pushed into managed stream channels, so
+// that they can be replayed to new subscribers and queried by time range.
+type FrameHistory interface {
+	// Add appends a frame pushed at the given time to the history of a channel in org.
+	// Frames exceeding the history limits are dropped.
+	Add(ctx context.Context, orgID int64, channel string, pushed time.Time, frameJSON json.RawMessage) error
+	// Get returns the JSON frames of a channel in org pushed between from and to, oldest first.
+	Get(ctx context.Context, orgID int64, channel string, from, to time.Time) ([]json.RawMessage, error)
+}
+
+// HistoryLimits limits the frames kept in the history of each channel.
+type HistoryLimits struct {
+	// MaxFrames is the maximum number of frames kept per channel, 0 means no limit.
+	MaxFrames int
+	// MaxAge is the maximum age of the frames kept per channel, 0 means no limit.
+	MaxAge time.Duration
+}
+
+// Enabled returns true if history should be kept at all.
+func (l HistoryLimits) Enabled() bool {
+	return l.MaxFrames > 0 || l.MaxAge > 0
+}
+
+// oldest returns the push time of the oldest frame that is still within MaxAge.
+func (l HistoryLimits) oldest(now time.Time) time.Time {
+	if l.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-l.MaxAge)
+}
+
+// mergeFrames concatenates the rows of the JSON frames into a single frame. Frames
+// with a schema different from the most recent frame are skipped, since they can't
+// be appended. When the frame has a time field, only the rows between from and to
+// are kept. Returns nil when there are no frames.
+func mergeFrames(frames []json.RawMessage, from, to time.Time) (*data.Frame, error) {
+	if len(frames) == 0 {
+		return nil, nil
+	}
+
+	decoded := make([]*data.Frame, 0, len(frames))
+	for _, frameJSON := range frames {
+		var frame data.Frame
+		if err := json.Unmarshal(frameJSON, &frame); err != nil {
+			return nil, fmt.Errorf("error decoding history frame: %w", err)
+		}
+		decoded = append(decoded, &frame)
+	}
+
+	last := decoded[len(decoded)-1]
+	merged := last.EmptyCopy()
+	timeIndex := -1
+	if timeIndices := merged.TypeIndices(data.FieldTypeTime, data.FieldTypeNullableTime); len(timeIndices) > 0 {
+		timeIndex = timeIndices[0]
+	}
+
+	for _, frame := range decoded {
+		if !sameSchema(frame, last) {
+			continue
+		}
+		for i := 0; i < frame.Rows(); i++ {
+			if timeIndex >= 0 && !rowInRange(frame.Fields[timeIndex], i, from, to) {
+				continue
+			}
+			for j, field := range frame.Fields {
+				merged.Fields[j].Append(field.At(i))
+			}
+		}
+	}
+	return merged, nil
+}
+
+func sameSchema(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
+
+func rowInRange(field *data.Field, row int, from, to time.Time) bool {
+	t, ok := field.ConcreteAt(row)
+	if !ok {
+		return true
+	}
+	ts := t.(time.Time)
+	return (from.IsZero() || !ts.Before(from)) && (to.IsZero() || !ts.After(to))
+}
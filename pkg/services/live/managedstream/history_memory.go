@@ -0,0 +1,108 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// historySweepInterval is how often MemoryFrameHistory looks for idle channels.
+	historySweepInterval = time.Minute
+	// historyMaxIdle is how long the frames of an idle channel are kept when the history has no MaxAge.
+	historyMaxIdle = 24 * time.Hour
+)
+
+type historyEntry struct {
+	pushed time.Time
+	frame  json.RawMessage
+}
+
+// MemoryFrameHistory keeps frame history in memory of the current instance. Channels
+// nothing was pushed into for longer than MaxAge, or historyMaxIdle without MaxAge,
+// are removed.
+type MemoryFrameHistory struct {
+	mu        sync.RWMutex
+	limits    HistoryLimits
+	entries   map[int64]map[string][]historyEntry
+	lastSweep time.Time
+}
+
+// NewMemoryFrameHistory creates MemoryFrameHistory with the given limits.
+func NewMemoryFrameHistory(limits HistoryLimits) *MemoryFrameHistory {
+	return &MemoryFrameHistory{
+		limits:  limits,
+		entries: map[int64]map[string][]historyEntry{},
+	}
+}
+
+func (h *MemoryFrameHistory) Add(_ context.Context, orgID int64, channel string, pushed time.Time, frameJSON json.RawMessage) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.evictIdle(time.Now())
+	if _, ok := h.entries[orgID]; !ok {
+		h.entries[orgID] = map[string][]historyEntry{}
+	}
+	entries := append(h.entries[orgID][channel], historyEntry{pushed: pushed, frame: frameJSON})
+
+	drop := 0
+	if h.limits.MaxFrames > 0 && len(entries) > h.limits.MaxFrames {
+		drop = len(entries) - h.limits.MaxFrames
+	}
+	oldest := h.limits.oldest(pushed)
+	for drop < len(entries) && entries[drop].pushed.Before(oldest) {
+		drop++
+	}
+	if drop > 0 {
+		// Copy to let the dropped frames be garbage collected.
+		entries = append([]historyEntry(nil), entries[drop:]...)
+	}
+	if len(entries) == 0 {
+		delete(h.entries[orgID], channel)
+		return nil
+	}
+	h.entries[orgID][channel] = entries
+	return nil
+}
+
+// evictIdle removes the channels whose newest frame is older than the idle limit. It
+// runs at most once per historySweepInterval, h.mu must be held.
+func (h *MemoryFrameHistory) evictIdle(now time.Time) {
+	if now.Sub(h.lastSweep) < historySweepInterval {
+		return
+	}
+	h.lastSweep = now
+
+	maxIdle := h.limits.MaxAge
+	if maxIdle <= 0 {
+		maxIdle = historyMaxIdle
+	}
+	idleSince := now.Add(-maxIdle)
+	for orgID, channels := range h.entries {
+		for channel, entries := range channels {
+			if len(entries) == 0 || entries[len(entries)-1].pushed.Before(idleSince) {
+				delete(channels, channel)
+			}
+		}
+		if len(channels) == 0 {
+			delete(h.entries, orgID)
+		}
+	}
+}
+
+func (h *MemoryFrameHistory) Get(_ context.Context, orgID int64, channel string, from, to time.Time) ([]json.RawMessage, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if oldest := h.limits.oldest(time.Now()); from.Before(oldest) {
+		from = oldest
+	}
+	var frames []json.RawMessage
+	for _, e := range h.entries[orgID][channel] {
+		if e.pushed.Before(from) || e.pushed.After(to) {
+			continue
+		}
+		frames = append(frames, e.frame)
+	}
+	return frames, nil
+}
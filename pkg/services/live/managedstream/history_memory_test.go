@@ -0,0 +1,74 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testFrameHistory expects c to keep at most 3 frames of at most 1 minute age.
+func testFrameHistory(t *testing.T, c FrameHistory) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Frames older than a minute are dropped.
+	err := c.Add(ctx, 1, "test", now.Add(-2*time.Minute), json.RawMessage(`{"old":true}`))
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		err := c.Add(ctx, 1, "test", now.Add(time.Duration(i-4)*time.Second), json.RawMessage(fmt.Sprintf(`{"i":%d}`, i)))
+		require.NoError(t, err)
+	}
+
+	// Only the last 3 frames are kept, oldest first.
+	frames, err := c.Get(ctx, 1, "test", time.Time{}, now)
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	require.JSONEq(t, `{"i":1}`, string(frames[0]))
+	require.JSONEq(t, `{"i":3}`, string(frames[2]))
+
+	// Time range is applied to the push time.
+	frames, err = c.Get(ctx, 1, "test", now.Add(-2500*time.Millisecond), now)
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+
+	// Identical frames are kept.
+	err = c.Add(ctx, 1, "test", now, json.RawMessage(`{"i":3}`))
+	require.NoError(t, err)
+	frames, err = c.Get(ctx, 1, "test", time.Time{}, now)
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	require.JSONEq(t, `{"i":3}`, string(frames[1]))
+	require.JSONEq(t, `{"i":3}`, string(frames[2]))
+
+	// Other orgs are not affected.
+	frames, err = c.Get(ctx, 2, "test", time.Time{}, now)
+	require.NoError(t, err)
+	require.Empty(t, frames)
+}
+
+func TestMemoryFrameHistory(t *testing.T) {
+	c := NewMemoryFrameHistory(HistoryLimits{MaxFrames: 3, MaxAge: time.Minute})
+	require.NotNil(t, c)
+	testFrameHistory(t, c)
+}
+
+func TestMemoryFrameHistory_EvictIdle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewMemoryFrameHistory(HistoryLimits{MaxFrames: 3, MaxAge: time.Minute})
+
+	require.NoError(t, c.Add(ctx, 1, "idle", now.Add(-30*time.Second), json.RawMessage(`{"i":1}`)))
+	require.NoError(t, c.Add(ctx, 2, "active", now, json.RawMessage(`{"i":2}`)))
+
+	c.mu.Lock()
+	c.lastSweep = time.Time{}
+	c.evictIdle(now.Add(45 * time.Second))
+	c.mu.Unlock()
+
+	require.NotContains(t, c.entries, int64(1))
+	require.Contains(t, c.entries[2], "active")
+}
@@ -0,0 +1,78 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// RedisFrameHistory keeps frame history in Redis sorted sets scored by push time,
+// so that it is shared between all Grafana instances.
+type RedisFrameHistory struct {
+	redisClient *redis.Client
+	limits      HistoryLimits
+}
+
+// NewRedisFrameHistory creates RedisFrameHistory with the given limits.
+func NewRedisFrameHistory(redisClient *redis.Client, limits HistoryLimits) *RedisFrameHistory {
+	return &RedisFrameHistory{
+		redisClient: redisClient,
+		limits:      limits,
+	}
+}
+
+func (h *RedisFrameHistory) Add(ctx context.Context, orgID int64, channel string, pushed time.Time, frameJSON json.RawMessage) error {
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+
+	pipe := h.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+
+	// Members of a sorted set are unique, prefix frames with the push time in
+	// nanoseconds to keep identical frames pushed at different times.
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(pushed.UnixMilli()),
+		Member: strconv.FormatInt(pushed.UnixNano(), 10) + ":" + string(frameJSON),
+	})
+	if h.limits.MaxAge > 0 {
+		oldest := h.limits.oldest(pushed).UnixMilli()
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(oldest, 10))
+	}
+	if h.limits.MaxFrames > 0 {
+		pipe.ZRemRangeByRank(ctx, key, 0, int64(-h.limits.MaxFrames-1))
+	}
+	pipe.Expire(ctx, key, frameCacheTTL)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (h *RedisFrameHistory) Get(ctx context.Context, orgID int64, channel string, from, to time.Time) ([]json.RawMessage, error) {
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+	if oldest := h.limits.oldest(time.Now()); from.Before(oldest) {
+		from = oldest
+	}
+	members, err := h.redisClient.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.UnixMilli(), 10),
+		Max: strconv.FormatInt(to.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]json.RawMessage, 0, len(members))
+	for _, member := range members {
+		if i := strings.IndexByte(member, ':'); i >= 0 {
+			frames = append(frames, json.RawMessage(member[i+1:]))
+		}
+	}
+	return frames, nil
+}
+
+func getHistoryKey(channelID string) string {
+	return "gf_live.managed_stream_history." + channelID
+}
@@ -0,0 +1,21 @@
+//go:build redis
+// +build redis
+
+package managedstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisFrameHistory(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	c := NewRedisFrameHistory(redisClient, HistoryLimits{MaxFrames: 3, MaxAge: time.Minute})
+	require.NotNil(t, c)
+	testFrameHistory(t, c)
+}
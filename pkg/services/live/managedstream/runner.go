@@ -3,6 +3,7 @@ package managedstream
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -42,20 +43,44 @@ type Runner struct {
 	publisher      models.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	frameHistory   FrameHistory
+	// publishInterval is a minimum interval between frames published into
+	// a channel, 0 means every frame is published.
+	publishInterval time.Duration
 }
 
+// ErrHistoryDisabled is returned when frame history is requested but not kept.
+var ErrHistoryDisabled = errors.New("managed stream history is disabled")
+
 type LocalPublisher interface {
 	PublishLocal(channel string, data []byte) error
 }
 
-// NewRunner creates new Runner.
-func NewRunner(publisher models.ChannelPublisher, localPublisher LocalPublisher, frameCache FrameCache) *Runner {
+// NewRunner creates new Runner. frameHistory may be nil, in which case only the
+// last frame of each channel is kept. Frames pushed into a channel more often
+// than publishInterval are coalesced, 0 disables coalescing.
+func NewRunner(publisher models.ChannelPublisher, localPublisher LocalPublisher, frameCache FrameCache, frameHistory FrameHistory, publishInterval time.Duration) *Runner {
 	return &Runner{
-		publisher:      publisher,
-		localPublisher: localPublisher,
-		streams:        map[int64]map[string]*NamespaceStream{},
-		frameCache:     frameCache,
+		publisher:       publisher,
+		localPublisher:  localPublisher,
+		streams:         map[int64]map[string]*NamespaceStream{},
+		frameCache:      frameCache,
+		frameHistory:    frameHistory,
+		publishInterval: publishInterval,
+	}
+}
+
+// GetHistory returns the frames pushed into a managed channel between from and to
+// merged into a single frame. Returns nil if there is no history for the channel.
+func (r *Runner) GetHistory(ctx context.Context, orgID int64, channel string, from, to time.Time) (*data.Frame, error) {
+	if r.frameHistory == nil {
+		return nil, ErrHistoryDisabled
+	}
+	frames, err := r.frameHistory.Get(ctx, orgID, channel, from, to)
+	if err != nil {
+		return nil, err
 	}
+	return mergeFrames(frames, from, to)
 }
 
 func (r *Runner) GetManagedChannels(orgID int64) ([]*ManagedChannel, error) {
@@ -125,7 +150,7 @@ func (r *Runner) GetOrCreateStream(orgID int64, scope string, namespace string)
 	prefix := scope + "/" + namespace
 	s, ok := r.streams[orgID][prefix]
 	if !ok {
-		s = NewNamespaceStream(orgID, scope, namespace, r.publisher, r.localPublisher, r.frameCache)
+		s = NewNamespaceStream(orgID, scope, namespace, r.publisher, r.localPublisher, r.frameCache, r.frameHistory, r.publishInterval)
 		r.streams[orgID][prefix] = s
 	}
 	return s, nil
@@ -139,6 +164,8 @@ type NamespaceStream struct {
 	publisher      models.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	frameHistory   FrameHistory
+	coalescer      *coalescer
 	rateMu         sync.RWMutex
 	rates          map[string][60]rateEntry
 }
@@ -156,20 +183,25 @@ type ManagedChannel struct {
 }
 
 // NewNamespaceStream creates new NamespaceStream.
-func NewNamespaceStream(orgID int64, scope string, namespace string, publisher models.ChannelPublisher, localPublisher LocalPublisher, schemaUpdater FrameCache) *NamespaceStream {
-	return &NamespaceStream{
+func NewNamespaceStream(orgID int64, scope string, namespace string, publisher models.ChannelPublisher, localPublisher LocalPublisher, schemaUpdater FrameCache, frameHistory FrameHistory, publishInterval time.Duration) *NamespaceStream {
+	s := &NamespaceStream{
 		orgID:          orgID,
 		scope:          scope,
 		namespace:      namespace,
 		publisher:      publisher,
 		localPublisher: localPublisher,
 		frameCache:     schemaUpdater,
+		frameHistory:   frameHistory,
 		rates:          map[string][60]rateEntry{},
 	}
+	if publishInterval > 0 {
+		s.coalescer = newCoalescer(publishInterval, s.publish)
+	}
+	return s
 }
 
 // Push sends frame to the stream and saves it for later retrieval by subscribers.
-// * Saves the entire frame to cache.
+// * Saves the entire frame to cache and history.
 // * If schema has been changed sends entire frame to channel, otherwise only data.
 func (s *NamespaceStream) Push(ctx context.Context, path string, frame *data.Frame) error {
 	jsonFrameCache, err := data.FrameToJSONCache(frame)
@@ -186,16 +218,29 @@ func (s *NamespaceStream) Push(ctx context.Context, path string, frame *data.Fra
 		return err
 	}
 
+	if s.frameHistory != nil {
+		if err := s.frameHistory.Add(ctx, s.orgID, channel, time.Now(), jsonFrameCache.Bytes(data.IncludeAll)); err != nil {
+			logger.Warn("Error adding frame to managed stream history", "channel", channel, "error", err)
+		}
+	}
+
+	s.incRate(path, time.Now().Unix())
+	if s.coalescer != nil {
+		return s.coalescer.push(path, jsonFrameCache, isUpdated)
+	}
+
 	// When the schema has not changed, just send the data.
 	include := data.IncludeDataOnly
 	if isUpdated {
 		// When the schema has been changed, send all.
 		include = data.IncludeAll
 	}
-	frameJSON := jsonFrameCache.Bytes(include)
+	return s.publish(path, jsonFrameCache.Bytes(include))
+}
 
+func (s *NamespaceStream) publish(path string, frameJSON []byte) error {
+	channel := live.Channel{Scope: s.scope, Namespace: s.namespace, Path: path}.String()
 	logger.Debug("Publish data to channel", "channel", channel, "dataLength", len(frameJSON))
-	s.incRate(path, time.Now().Unix())
 	if s.scope == live.ScopeDatasource || s.scope == live.ScopePlugin {
 		return s.localPublisher.PublishLocal(orgchannel.PrependOrgID(s.orgID, channel), frameJSON)
 	}
@@ -241,6 +286,16 @@ func (s *NamespaceStream) GetHandlerForPath(_ string) (models.ChannelHandler, er
 
 func (s *NamespaceStream) OnSubscribe(ctx context.Context, u *user.SignedInUser, e models.SubscribeEvent) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
 	reply := models.SubscribeReply{}
+	if s.frameHistory != nil {
+		// Replay recent history so that subscribers don't start with a single frame.
+		historyJSON, ok, err := s.historyJSON(ctx, u.OrgID, e.Channel)
+		if err != nil {
+			logger.Warn("Error getting managed stream history", "channel", e.Channel, "error", err)
+		} else if ok {
+			reply.Data = historyJSON
+			return reply, backend.SubscribeStreamStatusOK, nil
+		}
+	}
 	frameJSON, ok, err := s.frameCache.GetFrame(ctx, u.OrgID, e.Channel)
 	if err != nil {
 		return reply, 0, err
@@ -251,6 +306,22 @@ func (s *NamespaceStream) OnSubscribe(ctx context.Context, u *user.SignedInUser,
 	return reply, backend.SubscribeStreamStatusOK, nil
 }
 
+func (s *NamespaceStream) historyJSON(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error) {
+	frames, err := s.frameHistory.Get(ctx, orgID, channel, time.Time{}, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	frame, err := mergeFrames(frames, time.Time{}, time.Time{})
+	if err != nil || frame == nil {
+		return nil, false, err
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, false, err
+	}
+	return frameJSON, true, nil
+}
+
 func (s *NamespaceStream) OnPublish(_ context.Context, _ *user.SignedInUser, _ models.PublishEvent) (models.PublishReply, backend.PublishStreamStatus, error) {
 	return models.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
 }
@@ -2,11 +2,16 @@ package managedstream
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/user"
 )
 
 type testPublisher struct {
@@ -19,13 +24,13 @@ func (p *testPublisher) publish(_ int64, _ string, _ []byte) error {
 
 func TestNewManagedStream(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), nil, 0)
 	require.NotNil(t, c)
 }
 
 func TestManagedStreamMinuteRate(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), nil, 0)
 	require.NotNil(t, c)
 
 	c.incRate("test1", time.Now().Unix())
@@ -48,7 +53,7 @@ func TestManagedStreamMinuteRate(t *testing.T) {
 func TestGetManagedStreams(t *testing.T) {
 	publisher := &testPublisher{t: t}
 	frameCache := NewMemoryFrameCache()
-	runner := NewRunner(publisher.publish, nil, frameCache)
+	runner := NewRunner(publisher.publish, nil, frameCache, nil, 0)
 	s1, err := runner.GetOrCreateStream(1, "stream", "test1")
 	require.NoError(t, err)
 	s2, err := runner.GetOrCreateStream(1, "stream", "test2")
@@ -83,3 +88,42 @@ func TestGetManagedStreams(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, managedChannels, 7) // Not affected by other org.
 }
+
+func TestManagedStreamHistoryReplay(t *testing.T) {
+	publisher := &testPublisher{t: t}
+	history := NewMemoryFrameHistory(HistoryLimits{MaxFrames: 2})
+	runner := NewRunner(publisher.publish, nil, NewMemoryFrameCache(), history, 0)
+	s, err := runner.GetOrCreateStream(1, "stream", "test")
+	require.NoError(t, err)
+
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		frame := data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{t0.Add(time.Duration(i) * time.Second)}),
+			data.NewField("value", nil, []float64{float64(i)}),
+		)
+		require.NoError(t, s.Push(context.Background(), "cpu", frame))
+	}
+
+	// New subscribers receive the kept frames merged into a single frame.
+	reply, status, err := s.OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, models.SubscribeEvent{Channel: "stream/test/cpu"})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, status)
+	var replayed data.Frame
+	require.NoError(t, json.Unmarshal(reply.Data, &replayed))
+	require.Equal(t, 2, replayed.Rows())
+	require.Equal(t, []interface{}{1.0, 2.0}, []interface{}{replayed.Fields[1].At(0), replayed.Fields[1].At(1)})
+
+	// History can be queried by the time of the rows.
+	frame, err := runner.GetHistory(context.Background(), 1, "stream/test/cpu", t0.Add(2*time.Second), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, frame.Rows())
+
+	// Channels without history.
+	frame, err = runner.GetHistory(context.Background(), 1, "stream/test/mem", time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	_, err = NewRunner(publisher.publish, nil, NewMemoryFrameCache(), nil, 0).GetHistory(context.Background(), 1, "stream/test/cpu", t0, time.Now())
+	require.ErrorIs(t, err, ErrHistoryDisabled)
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Windows which don't receive frames for this long, or two windows if
+	// that's longer, are dropped.
+	aggregateStateIdleTimeout = 10 * time.Minute
+	aggregateStatePruneEvery  = time.Minute
+)
+
+// AggregateStateStorage keeps the windows AggregateFrameProcessor aggregates
+// in memory. It outlives processors, so windows survive rebuilding rules.
+// Not usable in HA setup.
+type AggregateStateStorage struct {
+	mu        sync.Mutex
+	states    map[aggregateKey]*aggregateState
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type aggregateKey struct {
+	orgID   int64
+	channel string
+	// processor identifies the rule and the configuration of a processor,
+	// windows of a changed configuration are not merged with old ones.
+	processor string
+}
+
+func NewAggregateStateStorage() *AggregateStateStorage {
+	return &AggregateStateStorage{
+		states: map[aggregateKey]*aggregateState{},
+		now:    time.Now,
+	}
+}
+
+// update calls fn with the window of the key, or nil, and stores the window
+// fn returns.
+func (s *AggregateStateStorage) update(key aggregateKey, window time.Duration, fn func(state *aggregateState) *aggregateState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+
+	state := fn(s.states[key])
+	if state == nil {
+		delete(s.states, key)
+		return
+	}
+	state.lastSeen = now
+	state.idleTimeout = aggregateStateIdleTimeout
+	if 2*window > state.idleTimeout {
+		state.idleTimeout = 2 * window
+	}
+	s.states[key] = state
+}
+
+func (s *AggregateStateStorage) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < aggregateStatePruneEvery {
+		return
+	}
+	s.lastPrune = now
+	for key, state := range s.states {
+		if now.Sub(state.lastSeen) > state.idleTimeout {
+			delete(s.states, key)
+		}
+	}
+}
+
+func (s *AggregateStateStorage) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.states)
+}
@@ -31,6 +31,9 @@ type ChannelRule struct {
 	OrgId    int64               `json:"-"`
 	Pattern  string              `json:"pattern"`
 	Settings ChannelRuleSettings `json:"settings"`
+	// Version is incremented on every rule update by storages which
+	// support optimistic concurrency.
+	Version int64 `json:"version,omitempty"`
 }
 
 type ConverterConfig struct {
@@ -49,11 +52,23 @@ type KeepFieldsFrameProcessorConfig struct {
 	FieldNames []string `json:"fieldNames"`
 }
 
+type AggregateFrameProcessorConfig struct {
+	// Window is a duration like 1s or 1m, windows are aligned to it.
+	Window string `json:"window"`
+	// Reducers to calculate for every numeric field, one of min, max, mean,
+	// count and last. Defaults to all of them.
+	Reducers []string `json:"reducers,omitempty"`
+	// FieldNames limits aggregation to these numeric fields, other fields keep
+	// the last value within a window. Defaults to all numeric fields.
+	FieldNames []string `json:"fieldNames,omitempty"`
+}
+
 type FrameProcessorConfig struct {
 	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
 	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
 	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
 	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	AggregateProcessorConfig  *AggregateFrameProcessorConfig  `json:"aggregate,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -78,6 +93,27 @@ type LokiOutputConfig struct {
 	UID string `json:"uid"`
 }
 
+type WebhookOutputConfig struct {
+	UID string `json:"uid"`
+	// Method of webhook requests, defaults to POST.
+	Method string `json:"method,omitempty"`
+	// ContentType of webhook requests, defaults to application/json.
+	ContentType string `json:"contentType,omitempty"`
+	// BatchSize is the maximum number of frames sent in one request, defaults to 100.
+	BatchSize int `json:"batchSize,omitempty"`
+	// FlushMilliseconds is how often frames are sent when a batch is not full,
+	// defaults to 1000.
+	FlushMilliseconds int64 `json:"flushMilliseconds,omitempty"`
+	// BodyTemplate is a Go text template executed with the batch of frames,
+	// see WebhookBatch. A JSON-encoded batch is sent if not set.
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+}
+
+type KafkaOutputConfig struct {
+	Brokers []string `json:"brokers"`
+	Topic   string   `json:"topic"`
+}
+
 type MultipleSubscriberConfig struct {
 	Subscribers []SubscriberConfig `json:"subscribers"`
 }
@@ -108,6 +144,8 @@ type FrameOutputterConfig struct {
 	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
 	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
 	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	WebhookOutputConfig     *WebhookOutputConfig       `json:"webhook,omitempty"`
+	KafkaOutputConfig       *KafkaOutputConfig         `json:"kafka,omitempty"`
 }
 
 type MultipleFrameConditionCheckerConfig struct {
@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// FrameOutputCache keeps outputs which hold connections or goroutines, like
+// webhook and Kafka outputs, across rebuilds of the rules. An output is reused
+// as long as its settings don't change.
+type FrameOutputCache struct {
+	mu      sync.Mutex
+	outputs map[int64]map[string]FrameOutputter
+}
+
+func NewFrameOutputCache() *FrameOutputCache {
+	return &FrameOutputCache{
+		outputs: map[int64]map[string]FrameOutputter{},
+	}
+}
+
+func (c *FrameOutputCache) get(orgID int64, key string) (FrameOutputter, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out, ok := c.outputs[orgID][key]
+	return out, ok
+}
+
+// set replaces the outputs of an org with the ones used by its latest rules.
+func (c *FrameOutputCache) set(orgID int64, outputs map[string]FrameOutputter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(outputs) == 0 {
+		delete(c.outputs, orgID)
+		return
+	}
+	c.outputs[orgID] = outputs
+}
+
+// frameOutputBuild tracks the outputs used while building the rules of an org.
+type frameOutputBuild struct {
+	orgID   int64
+	cache   *FrameOutputCache
+	used    map[string]FrameOutputter
+	created []FrameOutputter
+}
+
+func newFrameOutputBuild(orgID int64, cache *FrameOutputCache) *frameOutputBuild {
+	return &frameOutputBuild{
+		orgID: orgID,
+		cache: cache,
+		used:  map[string]FrameOutputter{},
+	}
+}
+
+// getOrCreate returns the output built with the same settings for the
+// previous rules, or creates a new one. settings must identify everything the
+// output is created from.
+func (b *frameOutputBuild) getOrCreate(outputType string, settings interface{}, create func() (FrameOutputter, error)) (FrameOutputter, error) {
+	if b.cache == nil {
+		out, err := create()
+		if err != nil {
+			return nil, err
+		}
+		b.created = append(b.created, out)
+		return out, nil
+	}
+
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	key := outputType + ":" + string(settingsJSON)
+	if out, ok := b.used[key]; ok {
+		return out, nil
+	}
+	out, ok := b.cache.get(b.orgID, key)
+	if !ok {
+		out, err = create()
+		if err != nil {
+			return nil, err
+		}
+		b.created = append(b.created, out)
+	}
+	b.used[key] = out
+	return out, nil
+}
+
+// commit stores the outputs used by the built rules for the next build.
+func (b *frameOutputBuild) commit() {
+	if b.cache != nil {
+		b.cache.set(b.orgID, b.used)
+	}
+}
+
+// closeCreated closes the outputs created by a build which failed.
+func (b *frameOutputBuild) closeCreated() {
+	for _, out := range b.created {
+		closeFrameOutputter(out)
+	}
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/segmentio/kafka-go"
+)
+
+const kafkaBatchTimeout = 100 * time.Millisecond
+
+// kafkaMessageWriter is implemented by kafka.Writer.
+type kafkaMessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaFrameOutput produces frames encoded to JSON to a Kafka topic. Messages
+// are keyed by channel so frames of a channel keep their order within a
+// partition. Messages are batched and written asynchronously, write errors
+// are logged. Close flushes pending messages and closes the connections.
+type KafkaFrameOutput struct {
+	writer kafkaMessageWriter
+}
+
+func NewKafkaFrameOutput(config KafkaOutputConfig) *KafkaFrameOutput {
+	return &KafkaFrameOutput{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: kafkaBatchTimeout,
+			Async:        true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					logger.Error("Error writing to Kafka", "topic", config.Topic, "numMessages", len(messages), "error", err)
+				}
+			},
+		},
+	}
+}
+
+const FrameOutputTypeKafka = "kafka"
+
+func (out *KafkaFrameOutput) Type() string {
+	return FrameOutputTypeKafka
+}
+
+func (out *KafkaFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	return nil, out.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(vars.Channel),
+		Value: frameJSON,
+		Headers: []kafka.Header{
+			{Key: "orgId", Value: []byte(strconv.FormatInt(vars.OrgID, 10))},
+			{Key: "channel", Value: []byte(vars.Channel)},
+		},
+	})
+}
+
+func (out *KafkaFrameOutput) Close() error {
+	return out.writer.Close()
+}
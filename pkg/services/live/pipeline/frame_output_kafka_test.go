@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+type testKafkaWriter struct {
+	messages []kafka.Message
+	closed   bool
+}
+
+func (w *testKafkaWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func (w *testKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func TestKafkaFrameOutput_OutputFrame(t *testing.T) {
+	writer := &testKafkaWriter{}
+	out := &KafkaFrameOutput{writer: writer}
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	channelFrames, err := out.OutputFrame(context.Background(), Vars{OrgID: 2, Channel: "stream/test/kafka"}, frame)
+	require.NoError(t, err)
+	require.Nil(t, channelFrames)
+
+	require.Len(t, writer.messages, 1)
+	msg := writer.messages[0]
+	require.Equal(t, "stream/test/kafka", string(msg.Key))
+
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	require.NoError(t, err)
+	require.JSONEq(t, string(frameJSON), string(msg.Value))
+	require.Equal(t, []kafka.Header{
+		{Key: "orgId", Value: []byte("2")},
+		{Key: "channel", Value: []byte("stream/test/kafka")},
+	}, msg.Headers)
+}
+
+func TestKafkaFrameOutput_Close(t *testing.T) {
+	writer := &testKafkaWriter{}
+	out := &KafkaFrameOutput{writer: writer}
+	closeFrameOutputter(NewMultipleFrameOutput(NewConditionalOutput(nil, out)))
+	require.True(t, writer.closed)
+}
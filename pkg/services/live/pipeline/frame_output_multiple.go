@@ -31,6 +31,13 @@ func (out MultipleFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame
 	return frames, nil
 }
 
+func (out *MultipleFrameOutput) Close() error {
+	for _, o := range out.Outputters {
+		closeFrameOutputter(o)
+	}
+	return nil
+}
+
 func NewMultipleFrameOutput(outputters ...FrameOutputter) *MultipleFrameOutput {
 	return &MultipleFrameOutput{Outputters: outputters}
 }
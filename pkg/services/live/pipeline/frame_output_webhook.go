@@ -0,0 +1,263 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultWebhookBatchSize     = 100
+	defaultWebhookFlushInterval = time.Second
+	// Batches are kept for retries until this many batches are waiting, the
+	// oldest entries are dropped after that.
+	webhookMaxPendingBatches = 10
+)
+
+// WebhookEntry is a frame waiting to be sent to a webhook.
+type WebhookEntry struct {
+	OrgID   int64           `json:"orgId"`
+	Channel string          `json:"channel"`
+	Time    time.Time       `json:"time"`
+	Frame   json.RawMessage `json:"frame"`
+}
+
+// WebhookBatch is the data the body of a webhook request is built from.
+type WebhookBatch struct {
+	Entries []WebhookEntry `json:"entries"`
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		if raw, ok := v.(json.RawMessage); ok {
+			return string(raw), nil
+		}
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// WebhookFrameOutput sends frames encoded to JSON to an HTTP endpoint in
+// batches. A batch is sent once it's full or when flush interval passes.
+// The request body is a JSON-encoded WebhookBatch unless a body template
+// is configured. Close stops the flush loop after sending pending batches.
+type WebhookFrameOutput struct {
+	mu     sync.Mutex
+	buffer []WebhookEntry
+
+	endpoint      string
+	basicAuth     *BasicAuth
+	method        string
+	contentType   string
+	batchSize     int
+	flushInterval time.Duration
+	bodyTemplate  *template.Template
+
+	httpClient *http.Client
+	flushCh    chan struct{}
+	closeOnce  sync.Once
+	closeCh    chan struct{}
+	stoppedCh  chan struct{}
+}
+
+func NewWebhookFrameOutput(endpoint string, basicAuth *BasicAuth, config WebhookOutputConfig) (*WebhookFrameOutput, error) {
+	out := &WebhookFrameOutput{
+		endpoint:      endpoint,
+		basicAuth:     basicAuth,
+		method:        config.Method,
+		contentType:   config.ContentType,
+		batchSize:     config.BatchSize,
+		flushInterval: time.Duration(config.FlushMilliseconds) * time.Millisecond,
+		httpClient:    &http.Client{Timeout: 5 * time.Second},
+		flushCh:       make(chan struct{}, 1),
+		closeCh:       make(chan struct{}),
+		stoppedCh:     make(chan struct{}),
+	}
+	if out.method == "" {
+		out.method = http.MethodPost
+	}
+	if out.contentType == "" {
+		out.contentType = "application/json"
+	}
+	if out.batchSize <= 0 {
+		out.batchSize = defaultWebhookBatchSize
+	}
+	if out.flushInterval <= 0 {
+		out.flushInterval = defaultWebhookFlushInterval
+	}
+	if config.BodyTemplate != "" {
+		tmpl, err := template.New("body").Funcs(webhookTemplateFuncs).Parse(config.BodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		out.bodyTemplate = tmpl
+	}
+	if out.endpoint != "" {
+		go out.flushPeriodically()
+	} else {
+		close(out.stoppedCh)
+	}
+	return out, nil
+}
+
+const FrameOutputTypeWebhook = "webhook"
+
+func (out *WebhookFrameOutput) Type() string {
+	return FrameOutputTypeWebhook
+}
+
+func (out *WebhookFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.endpoint == "" {
+		logger.Debug("Skip sending to webhook: no url")
+		return nil, nil
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	out.mu.Lock()
+	out.buffer = append(out.buffer, WebhookEntry{
+		OrgID:   vars.OrgID,
+		Channel: vars.Channel,
+		Time:    time.Now(),
+		Frame:   frameJSON,
+	})
+	full := len(out.buffer) >= out.batchSize
+	out.mu.Unlock()
+	if full {
+		select {
+		case out.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil, nil
+}
+
+func (out *WebhookFrameOutput) flushPeriodically() {
+	defer close(out.stoppedCh)
+	ticker := time.NewTicker(out.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-out.flushCh:
+		case <-out.closeCh:
+			out.flushPending()
+			return
+		}
+		for out.flushBatch() {
+		}
+	}
+}
+
+// flushPending sends all buffered frames once, frames which can't be sent
+// are dropped.
+func (out *WebhookFrameOutput) flushPending() {
+	out.mu.Lock()
+	pending := out.buffer
+	out.buffer = nil
+	out.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > out.batchSize {
+			n = out.batchSize
+		}
+		if err := out.flush(pending[:n]); err != nil {
+			logger.Error("Error flush to webhook on close, dropping frames", "error", err, "numFrames", len(pending))
+			return
+		}
+		pending = pending[n:]
+	}
+}
+
+// Close sends pending frames and stops the flush loop.
+func (out *WebhookFrameOutput) Close() error {
+	out.closeOnce.Do(func() {
+		close(out.closeCh)
+	})
+	<-out.stoppedCh
+	return nil
+}
+
+// flushBatch sends at most one batch from the buffer and returns true if the
+// buffer still has a full batch waiting.
+func (out *WebhookFrameOutput) flushBatch() bool {
+	out.mu.Lock()
+	if len(out.buffer) == 0 {
+		out.mu.Unlock()
+		return false
+	}
+	n := len(out.buffer)
+	if n > out.batchSize {
+		n = out.batchSize
+	}
+	batch := make([]WebhookEntry, n)
+	copy(batch, out.buffer)
+	out.buffer = out.buffer[n:]
+	out.mu.Unlock()
+
+	if err := out.flush(batch); err != nil {
+		logger.Error("Error flush to webhook", "error", err)
+		out.mu.Lock()
+		out.buffer = append(batch, out.buffer...)
+		if maxEntries := webhookMaxPendingBatches * out.batchSize; len(out.buffer) > maxEntries {
+			dropped := len(out.buffer) - maxEntries
+			logger.Warn("Dropping frames not sent to webhook", "numFrames", dropped)
+			out.buffer = out.buffer[dropped:]
+		}
+		out.mu.Unlock()
+		return false
+	}
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	return len(out.buffer) >= out.batchSize
+}
+
+func (out *WebhookFrameOutput) body(entries []WebhookEntry) ([]byte, error) {
+	batch := WebhookBatch{Entries: entries}
+	if out.bodyTemplate == nil {
+		return json.Marshal(batch)
+	}
+	var buf bytes.Buffer
+	if err := out.bodyTemplate.Execute(&buf, batch); err != nil {
+		return nil, fmt.Errorf("error executing body template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (out *WebhookFrameOutput) flush(entries []WebhookEntry) error {
+	body, err := out.body(entries)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Sending to webhook", "url", out.endpoint, "numFrames", len(entries), "bodyLength", len(body))
+	req, err := http.NewRequest(out.method, out.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error constructing webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", out.contentType)
+	if out.basicAuth != nil {
+		req.SetBasicAuth(out.basicAuth.User, out.basicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response code from webhook: %d", resp.StatusCode)
+	}
+	logger.Debug("Successfully sent to webhook", "url", out.endpoint, "elapsed", time.Since(started))
+	return nil
+}
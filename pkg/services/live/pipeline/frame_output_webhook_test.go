@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRequest struct {
+	contentType string
+	user        string
+	body        []byte
+}
+
+func newWebhookServer(t *testing.T, status int) (*httptest.Server, chan webhookRequest) {
+	t.Helper()
+	requests := make(chan webhookRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		user, _, _ := r.BasicAuth()
+		requests <- webhookRequest{contentType: r.Header.Get("Content-Type"), user: user, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func waitWebhookRequest(t *testing.T, requests chan webhookRequest) webhookRequest {
+	t.Helper()
+	select {
+	case r := <-requests:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook request")
+	}
+	return webhookRequest{}
+}
+
+func TestWebhookFrameOutput_Batch(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusOK)
+
+	out, err := NewWebhookFrameOutput(server.URL, &BasicAuth{User: "admin", Password: "secret"}, WebhookOutputConfig{
+		BatchSize:         2,
+		FlushMilliseconds: time.Hour.Milliseconds(),
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	vars := Vars{OrgID: 1, Channel: "stream/test/webhook"}
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+
+	select {
+	case <-requests:
+		t.Fatal("batch must not be sent before it's full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+
+	r := waitWebhookRequest(t, requests)
+	require.Equal(t, "application/json", r.contentType)
+	require.Equal(t, "admin", r.user)
+
+	var batch WebhookBatch
+	require.NoError(t, json.Unmarshal(r.body, &batch))
+	require.Len(t, batch.Entries, 2)
+	require.Equal(t, "stream/test/webhook", batch.Entries[0].Channel)
+	require.Equal(t, int64(1), batch.Entries[0].OrgID)
+
+	var f data.Frame
+	require.NoError(t, json.Unmarshal(batch.Entries[0].Frame, &f))
+	require.Equal(t, "test", f.Name)
+}
+
+func TestWebhookFrameOutput_FlushInterval(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusOK)
+
+	out, err := NewWebhookFrameOutput(server.URL, nil, WebhookOutputConfig{
+		FlushMilliseconds: 10,
+		ContentType:       "text/plain",
+		BodyTemplate:      `{{ range .Entries }}{{ .Channel }}={{ json .Frame }};{{ end }}`,
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/a"}, frame)
+	require.NoError(t, err)
+
+	r := waitWebhookRequest(t, requests)
+	require.Equal(t, "text/plain", r.contentType)
+
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	require.NoError(t, err)
+	require.Equal(t, "stream/test/a="+string(frameJSON)+";", string(r.body))
+}
+
+func TestWebhookFrameOutput_Retry(t *testing.T) {
+	server, _ := newWebhookServer(t, http.StatusInternalServerError)
+
+	out, err := NewWebhookFrameOutput(server.URL, nil, WebhookOutputConfig{
+		BatchSize:         1,
+		FlushMilliseconds: time.Hour.Milliseconds(),
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	out.buffer = []WebhookEntry{{Channel: "stream/test/a"}}
+	require.False(t, out.flushBatch())
+	require.Len(t, out.buffer, 1)
+
+	// Frames above the retry limit are dropped.
+	for i := 0; i < 2*webhookMaxPendingBatches; i++ {
+		_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/a"}, frame)
+		require.NoError(t, err)
+	}
+	require.False(t, out.flushBatch())
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	require.LessOrEqual(t, len(out.buffer), webhookMaxPendingBatches+1)
+}
+
+func TestNewWebhookFrameOutput_InvalidTemplate(t *testing.T) {
+	_, err := NewWebhookFrameOutput("", nil, WebhookOutputConfig{BodyTemplate: "{{ .Entries"})
+	require.Error(t, err)
+}
+
+func TestWebhookFrameOutput_Close(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusOK)
+
+	out, err := NewWebhookFrameOutput(server.URL, nil, WebhookOutputConfig{
+		BatchSize:         10,
+		FlushMilliseconds: time.Hour.Milliseconds(),
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/a"}, frame)
+	require.NoError(t, err)
+
+	// Pending frames are sent on close.
+	require.NoError(t, out.Close())
+	r := waitWebhookRequest(t, requests)
+	var batch WebhookBatch
+	require.NoError(t, json.Unmarshal(r.body, &batch))
+	require.Len(t, batch.Entries, 1)
+
+	select {
+	case <-out.stoppedCh:
+	default:
+		t.Fatal("flush loop must be stopped after close")
+	}
+	require.NoError(t, out.Close())
+
+	noEndpoint, err := NewWebhookFrameOutput("", nil, WebhookOutputConfig{})
+	require.NoError(t, err)
+	require.NoError(t, noEndpoint.Close())
+}
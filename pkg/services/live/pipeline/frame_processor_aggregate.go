@@ -0,0 +1,266 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Reducers supported by AggregateFrameProcessor.
+const (
+	AggregateReducerMin   = "min"
+	AggregateReducerMax   = "max"
+	AggregateReducerMean  = "mean"
+	AggregateReducerCount = "count"
+	AggregateReducerLast  = "last"
+)
+
+var defaultAggregateReducers = []string{
+	AggregateReducerMin, AggregateReducerMax, AggregateReducerMean, AggregateReducerCount, AggregateReducerLast,
+}
+
+// AggregateFrameProcessor downsamples frames by buffering them per channel and
+// emitting a single row with aggregated values for each time window. Windows
+// are aligned to the window duration and based on the time field of frames. A
+// window is emitted once a row of a later window arrives, frames which don't
+// complete a window are dropped. A window in progress is discarded when the
+// frame schema of a channel changes. Windows are kept in the storage, so they
+// continue when the rule is rebuilt.
+type AggregateFrameProcessor struct {
+	config    AggregateFrameProcessorConfig
+	window    time.Duration
+	storage   *AggregateStateStorage
+	processor string
+}
+
+// aggregateState is the window being aggregated for a channel.
+type aggregateState struct {
+	schema *data.Frame
+	start  time.Time
+	fields []*aggregateAccumulator
+
+	lastSeen    time.Time
+	idleTimeout time.Duration
+}
+
+// aggregateAccumulator accumulates the values of a field within a window.
+// Numeric fields are reduced, the last value is kept for other fields.
+type aggregateAccumulator struct {
+	numeric bool
+	count   int
+	sum     float64
+	min     float64
+	max     float64
+	last    interface{}
+}
+
+// NewAggregateFrameProcessor creates a processor for the rule with the pattern.
+// Windows are kept in a storage of the processor when storage is nil.
+func NewAggregateFrameProcessor(storage *AggregateStateStorage, pattern string, config AggregateFrameProcessorConfig) (*AggregateFrameProcessor, error) {
+	window, err := time.ParseDuration(config.Window)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregation window: %w", err)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("aggregation window must be positive, got %s", config.Window)
+	}
+	for _, r := range config.Reducers {
+		switch r {
+		case AggregateReducerMin, AggregateReducerMax, AggregateReducerMean, AggregateReducerCount, AggregateReducerLast:
+		default:
+			return nil, fmt.Errorf("unknown aggregation reducer: %s", r)
+		}
+	}
+	if len(config.Reducers) == 0 {
+		config.Reducers = defaultAggregateReducers
+	}
+	if storage == nil {
+		storage = NewAggregateStateStorage()
+	}
+	return &AggregateFrameProcessor{
+		config:    config,
+		window:    window,
+		storage:   storage,
+		processor: fmt.Sprintf("%s|%s|%v|%v", pattern, window, config.Reducers, config.FieldNames),
+	}, nil
+}
+
+const FrameProcessorTypeAggregate = "aggregate"
+
+func (p *AggregateFrameProcessor) Type() string {
+	return FrameProcessorTypeAggregate
+}
+
+func (p *AggregateFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	timeIndices := frame.TypeIndices(data.FieldTypeTime, data.FieldTypeNullableTime)
+	if len(timeIndices) == 0 {
+		return nil, fmt.Errorf("can't aggregate frame without time field")
+	}
+	timeIndex := timeIndices[0]
+
+	var out *data.Frame
+	key := aggregateKey{orgID: vars.OrgID, channel: vars.Channel, processor: p.processor}
+	p.storage.update(key, p.window, func(state *aggregateState) *aggregateState {
+		if state != nil && !sameFieldSchema(state.schema, frame) {
+			// Windows can't be merged across schemas, start over with the new one.
+			state = nil
+		}
+
+		for i := 0; i < frame.Rows(); i++ {
+			t, ok := frame.Fields[timeIndex].ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			start := t.(time.Time).Truncate(p.window)
+			if state != nil && start.After(state.start) {
+				if out == nil {
+					out = p.outputFrame(state.schema, timeIndex)
+				}
+				p.appendWindow(out, state, timeIndex)
+				state = nil
+			}
+			if state == nil {
+				state = p.newState(frame, start)
+			}
+			for j, field := range frame.Fields {
+				if j != timeIndex {
+					state.fields[j].add(field, i)
+				}
+			}
+		}
+		return state
+	})
+
+	return out, nil
+}
+
+func (p *AggregateFrameProcessor) newState(frame *data.Frame, start time.Time) *aggregateState {
+	fields := make([]*aggregateAccumulator, len(frame.Fields))
+	for i, f := range frame.Fields {
+		fields[i] = &aggregateAccumulator{numeric: f.Type().Numeric() && p.aggregateField(f.Name)}
+	}
+	return &aggregateState{
+		schema: frame.EmptyCopy(),
+		start:  start,
+		fields: fields,
+	}
+}
+
+func (p *AggregateFrameProcessor) aggregateField(name string) bool {
+	if len(p.config.FieldNames) == 0 {
+		return true
+	}
+	for _, n := range p.config.FieldNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// outputFrame creates an empty frame for aggregated rows of frames with the
+// given schema. Numeric fields are replaced with one field per reducer.
+func (p *AggregateFrameProcessor) outputFrame(schema *data.Frame, timeIndex int) *data.Frame {
+	out := data.NewFrame(schema.Name)
+	out.Meta = schema.Meta
+	for i, f := range schema.Fields {
+		if i == timeIndex {
+			out.Fields = append(out.Fields, data.NewField(f.Name, f.Labels, []time.Time{}).SetConfig(f.Config))
+			continue
+		}
+		if !(f.Type().Numeric() && p.aggregateField(f.Name)) {
+			field := data.NewFieldFromFieldType(f.Type(), 0)
+			field.Name = f.Name
+			field.Labels = f.Labels
+			field.Config = f.Config
+			out.Fields = append(out.Fields, field)
+			continue
+		}
+		for _, r := range p.config.Reducers {
+			out.Fields = append(out.Fields, data.NewField(f.Name+"_"+r, f.Labels, []*float64{}))
+		}
+	}
+	return out
+}
+
+func (p *AggregateFrameProcessor) appendWindow(out *data.Frame, state *aggregateState, timeIndex int) {
+	values := make([]interface{}, 0, len(out.Fields))
+	for i, acc := range state.fields {
+		switch {
+		case i == timeIndex:
+			values = append(values, state.start)
+		case !acc.numeric:
+			values = append(values, acc.last)
+		default:
+			for _, r := range p.config.Reducers {
+				values = append(values, acc.reduce(r))
+			}
+		}
+	}
+	for i, v := range values {
+		if v == nil {
+			out.Fields[i].Extend(1)
+			continue
+		}
+		out.Fields[i].Append(v)
+	}
+}
+
+func (a *aggregateAccumulator) add(field *data.Field, row int) {
+	if !a.numeric {
+		a.last = field.At(row)
+		return
+	}
+	if _, ok := field.ConcreteAt(row); !ok {
+		return
+	}
+	v, err := field.FloatAt(row)
+	if err != nil || math.IsNaN(v) {
+		return
+	}
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.count++
+	a.sum += v
+	a.last = v
+}
+
+func (a *aggregateAccumulator) reduce(reducer string) *float64 {
+	v := float64(a.count)
+	if reducer == AggregateReducerCount {
+		return &v
+	}
+	if a.count == 0 {
+		return nil
+	}
+	switch reducer {
+	case AggregateReducerMin:
+		v = a.min
+	case AggregateReducerMax:
+		v = a.max
+	case AggregateReducerMean:
+		v = a.sum / float64(a.count)
+	case AggregateReducerLast:
+		v = a.last.(float64)
+	}
+	return &v
+}
+
+func sameFieldSchema(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAggregateFrameProcessor_InvalidConfig(t *testing.T) {
+	_, err := NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{})
+	require.Error(t, err)
+	_, err = NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{Window: "-1s"})
+	require.Error(t, err)
+	_, err = NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{Window: "1s", Reducers: []string{"median"}})
+	require.Error(t, err)
+}
+
+func TestAggregateFrameProcessor(t *testing.T) {
+	p, err := NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{Window: "1s"})
+	require.NoError(t, err)
+
+	start := time.Unix(100, 0)
+	vars := Vars{OrgID: 1, Channel: "stream/test/sensor"}
+	frame := func(offsets []time.Duration, values []float64, states []string) *data.Frame {
+		times := make([]time.Time, len(offsets))
+		for i, o := range offsets {
+			times[i] = start.Add(o)
+		}
+		return data.NewFrame("sensor",
+			data.NewField("time", nil, times),
+			data.NewField("value", data.Labels{"id": "1"}, values),
+			data.NewField("state", nil, states),
+		)
+	}
+
+	// Rows of an incomplete window are buffered.
+	out, err := p.ProcessFrame(context.Background(), vars, frame(
+		[]time.Duration{0, 250 * time.Millisecond}, []float64{2, 4}, []string{"a", "b"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	// Other channels don't share windows.
+	out, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/other"}, frame(
+		[]time.Duration{1500 * time.Millisecond}, []float64{100}, []string{"x"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	out, err = p.ProcessFrame(context.Background(), vars, frame(
+		[]time.Duration{750 * time.Millisecond, 1100 * time.Millisecond, 2100 * time.Millisecond},
+		[]float64{9, 5, 7},
+		[]string{"c", "d", "e"},
+	))
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	require.Equal(t, 2, out.Rows())
+
+	names := make([]string, 0, len(out.Fields))
+	for _, f := range out.Fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"time", "value_min", "value_max", "value_mean", "value_count", "value_last", "state"}, names)
+	require.Equal(t, data.Labels{"id": "1"}, out.Fields[1].Labels)
+
+	require.Equal(t, start, out.Fields[0].At(0))
+	require.Equal(t, start.Add(time.Second), out.Fields[0].At(1))
+
+	expected := [][]float64{
+		{2, 9, 5, 3, 9},
+		{5, 5, 5, 1, 5},
+	}
+	for row, values := range expected {
+		for i, v := range values {
+			got, ok := out.Fields[i+1].ConcreteAt(row)
+			require.True(t, ok)
+			require.Equal(t, v, got, "row %d field %s", row, out.Fields[i+1].Name)
+		}
+	}
+	require.Equal(t, "c", out.Fields[6].At(0))
+	require.Equal(t, "d", out.Fields[6].At(1))
+}
+
+func TestAggregateFrameProcessor_ReducersAndFields(t *testing.T) {
+	p, err := NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{
+		Window:     "1m",
+		Reducers:   []string{AggregateReducerMax},
+		FieldNames: []string{"a"},
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(600, 0)
+	newFrame := func(ts time.Time, a float64, b *float64) *data.Frame {
+		return data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{ts}),
+			data.NewField("a", nil, []float64{a}),
+			data.NewField("b", nil, []*float64{b}),
+		)
+	}
+	b := 3.0
+
+	out, err := p.ProcessFrame(context.Background(), Vars{}, newFrame(start, 1, &b))
+	require.NoError(t, err)
+	require.Nil(t, out)
+	out, err = p.ProcessFrame(context.Background(), Vars{}, newFrame(start.Add(10*time.Second), 2, nil))
+	require.NoError(t, err)
+	require.Nil(t, out)
+	out, err = p.ProcessFrame(context.Background(), Vars{}, newFrame(start.Add(time.Minute), 0, nil))
+	require.NoError(t, err)
+	require.NotNil(t, out)
+
+	require.Len(t, out.Fields, 3)
+	require.Equal(t, "a_max", out.Fields[1].Name)
+	v, _ := out.Fields[1].ConcreteAt(0)
+	require.Equal(t, 2.0, v)
+	require.Equal(t, "b", out.Fields[2].Name)
+	require.Nil(t, out.Fields[2].At(0))
+}
+
+func TestAggregateFrameProcessor_SchemaChange(t *testing.T) {
+	p, err := NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{Window: "1s"})
+	require.NoError(t, err)
+
+	start := time.Unix(100, 0)
+	out, err := p.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start}),
+		data.NewField("a", nil, []float64{1}),
+	))
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	out, err = p.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start.Add(time.Second), start.Add(2 * time.Second)}),
+		data.NewField("b", nil, []float64{5, 6}),
+	))
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	require.Equal(t, 1, out.Rows())
+	require.Equal(t, "b_min", out.Fields[1].Name)
+	v, _ := out.Fields[1].ConcreteAt(0)
+	require.Equal(t, 5.0, v)
+}
+
+func TestAggregateFrameProcessor_NoTimeField(t *testing.T) {
+	p, err := NewAggregateFrameProcessor(nil, "stream/test/:sensor", AggregateFrameProcessorConfig{Window: "1s"})
+	require.NoError(t, err)
+	_, err = p.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test",
+		data.NewField("a", nil, []float64{1}),
+	))
+	require.Error(t, err)
+}
+
+type testAggregateRuleStorage struct {
+	Storage
+}
+
+func (s *testAggregateRuleStorage) ListChannelRules(_ context.Context, orgID int64) ([]ChannelRule, error) {
+	return []ChannelRule{{
+		OrgId:   orgID,
+		Pattern: "stream/test/:sensor",
+		Settings: ChannelRuleSettings{
+			FrameProcessors: []*FrameProcessorConfig{{
+				Type:                     FrameProcessorTypeAggregate,
+				AggregateProcessorConfig: &AggregateFrameProcessorConfig{Window: "1m", Reducers: []string{AggregateReducerCount}},
+			}},
+		},
+	}}, nil
+}
+
+func (s *testAggregateRuleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]WriteConfig, error) {
+	return nil, nil
+}
+
+func TestAggregateFrameProcessor_RebuildRules(t *testing.T) {
+	s := NewCacheSegmentedTree(&StorageRuleBuilder{
+		Storage:               &testAggregateRuleStorage{},
+		AggregateStateStorage: NewAggregateStateStorage(),
+	})
+
+	start := time.Unix(600, 0)
+	vars := Vars{OrgID: 1, Channel: "stream/test/sensor"}
+	newFrame := func(offset time.Duration) *data.Frame {
+		return data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{start.Add(offset)}),
+			data.NewField("value", nil, []float64{1}),
+		)
+	}
+	process := func(offset time.Duration) *data.Frame {
+		rule, ok, err := s.Get(1, vars.Channel)
+		require.NoError(t, err)
+		require.True(t, ok)
+		out, err := rule.FrameProcessors[0].ProcessFrame(context.Background(), vars, newFrame(offset))
+		require.NoError(t, err)
+		return out
+	}
+
+	require.Nil(t, process(0))
+	first, _, _ := s.Get(1, vars.Channel)
+
+	// The rules are rebuilt in the middle of the window.
+	require.NoError(t, s.fillOrg(1))
+	rebuilt, _, _ := s.Get(1, vars.Channel)
+	require.NotSame(t, first.FrameProcessors[0], rebuilt.FrameProcessors[0])
+
+	require.Nil(t, process(30*time.Second))
+	out := process(time.Minute)
+	require.NotNil(t, out)
+	count, ok := out.Fields[1].ConcreteAt(0)
+	require.True(t, ok)
+	require.Equal(t, 2.0, count)
+}
+
+func TestAggregateStateStorage_EvictsIdleWindows(t *testing.T) {
+	storage := NewAggregateStateStorage()
+	now := time.Unix(1000, 0)
+	storage.now = func() time.Time { return now }
+
+	p, err := NewAggregateFrameProcessor(storage, "stream/test/:sensor", AggregateFrameProcessorConfig{Window: "1s"})
+	require.NoError(t, err)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(100, 0)}),
+		data.NewField("value", nil, []float64{1}),
+	)
+	_, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/a"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, 1, storage.len())
+
+	now = now.Add(aggregateStateIdleTimeout / 2)
+	_, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/b"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, 2, storage.len())
+
+	// The window of channel a is idle for longer than the timeout now.
+	now = now.Add(aggregateStateIdleTimeout/2 + time.Second)
+	_, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/b"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, 1, storage.len())
+}
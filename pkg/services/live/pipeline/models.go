@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/live/pipeline/pattern"
 	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
@@ -129,7 +130,8 @@ type ChannelRules struct {
 	Rules []ChannelRule `json:"rules"`
 }
 
-func checkRulesValid(orgID int64, rules []ChannelRule) (ok bool, reason string) {
+// CheckRulesValid checks that patterns of org rules don't conflict with each other.
+func CheckRulesValid(orgID int64, rules []ChannelRule) (ok bool, reason string) {
 	t := tree.New()
 	defer func() {
 		if r := recover(); r != nil {
@@ -149,13 +151,40 @@ func checkRulesValid(orgID int64, rules []ChannelRule) (ok bool, reason string)
 type ChannelRuleCreateCmd struct {
 	Pattern  string              `json:"pattern"`
 	Settings ChannelRuleSettings `json:"settings"`
+	UserID   int64               `json:"-"`
 }
 
 type ChannelRuleUpdateCmd struct {
 	Pattern  string              `json:"pattern"`
 	Settings ChannelRuleSettings `json:"settings"`
+	// Version of the rule the update is based on. If set, the update fails
+	// with ErrChannelRuleVersionMismatch when the rule was changed since.
+	Version int64 `json:"version,omitempty"`
+	UserID  int64 `json:"-"`
 }
 
 type ChannelRuleDeleteCmd struct {
 	Pattern string `json:"pattern"`
+	UserID  int64  `json:"-"`
+}
+
+type ChannelRuleHistoryCmd struct {
+	Pattern string `json:"pattern"`
+	Limit   int    `json:"limit"`
+}
+
+const (
+	ChannelRuleActionCreate = "create"
+	ChannelRuleActionUpdate = "update"
+	ChannelRuleActionDelete = "delete"
+)
+
+// ChannelRuleVersion is a change of a channel rule.
+type ChannelRuleVersion struct {
+	Pattern   string              `json:"pattern"`
+	Version   int64               `json:"version"`
+	Action    string              `json:"action"`
+	Settings  ChannelRuleSettings `json:"settings"`
+	Created   time.Time           `json:"created"`
+	CreatedBy int64               `json:"createdBy"`
 }
@@ -164,6 +164,67 @@ type LiveChannelRule struct {
 	FrameOutputters []FrameOutputter
 }
 
+// frameOutputCloser is implemented by outputs which hold connections or
+// goroutines, they are closed when the rules they belong to are replaced.
+type frameOutputCloser interface {
+	Close() error
+}
+
+func closeFrameOutputter(out FrameOutputter) {
+	if c, ok := out.(frameOutputCloser); ok {
+		if err := c.Close(); err != nil {
+			logger.Error("Error closing frame output", "type", out.Type(), "error", err)
+		}
+	}
+}
+
+// CloseRules closes the outputs of rules which are not used anymore.
+func CloseRules(rules []*LiveChannelRule) {
+	for _, rule := range rules {
+		for _, out := range rule.FrameOutputters {
+			closeFrameOutputter(out)
+		}
+	}
+}
+
+// closeReplacedRules closes the outputs of replaced rules which are not used
+// by the current rules, outputs can be reused when rules are rebuilt.
+func closeReplacedRules(replaced []*LiveChannelRule, current []*LiveChannelRule) {
+	inUse := map[FrameOutputter]struct{}{}
+	for _, rule := range current {
+		for _, out := range rule.FrameOutputters {
+			walkFrameOutputters(out, func(out FrameOutputter) {
+				inUse[out] = struct{}{}
+			})
+		}
+	}
+	for _, rule := range replaced {
+		for _, out := range rule.FrameOutputters {
+			walkFrameOutputters(out, func(out FrameOutputter) {
+				if _, ok := inUse[out]; !ok {
+					closeFrameOutputter(out)
+				}
+			})
+		}
+	}
+}
+
+// walkFrameOutputters calls fn with the outputs wrapped by out, or out itself
+// if it does not wrap other outputs.
+func walkFrameOutputters(out FrameOutputter, fn func(out FrameOutputter)) {
+	switch o := out.(type) {
+	case *MultipleFrameOutput:
+		for _, child := range o.Outputters {
+			walkFrameOutputters(child, fn)
+		}
+	case *ConditionalOutput:
+		walkFrameOutputters(o.Outputter, fn)
+	case nil:
+	default:
+		fn(out)
+	}
+}
+
 // Label ...
 type Label struct {
 	Name  string `json:"name"`
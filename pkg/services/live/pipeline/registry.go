@@ -48,6 +48,16 @@ var FrameOutputsRegistry = []EntityInfo{
 		Type:        FrameOutputTypeLoki,
 		Description: "output frame as JSON to Loki",
 	},
+	{
+		Type:        FrameOutputTypeWebhook,
+		Description: "output frames in batches to HTTP endpoint",
+		Example:     WebhookOutputConfig{},
+	},
+	{
+		Type:        FrameOutputTypeKafka,
+		Description: "output frame as JSON to Kafka topic",
+		Example:     KafkaOutputConfig{},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -83,6 +93,11 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeAggregate,
+		Description: "downsample data to min, max, mean, count and last values per time window",
+		Example:     AggregateFrameProcessorConfig{Window: "1s"},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
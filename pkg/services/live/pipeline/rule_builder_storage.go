@@ -16,6 +16,12 @@ type StorageRuleBuilder struct {
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
+	// AggregateStateStorage keeps aggregation windows across rebuilds of the
+	// rules, each processor keeps its own windows when it's nil.
+	AggregateStateStorage *AggregateStateStorage
+	// FrameOutputCache reuses outputs with unchanged settings across rebuilds
+	// of the rules, outputs are created on every build when it's nil.
+	FrameOutputCache *FrameOutputCache
 }
 
 func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscriber, error) {
@@ -78,7 +84,7 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 	}
 }
 
-func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig) (FrameProcessor, error) {
+func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig, pattern string) (FrameProcessor, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -94,6 +100,15 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			return nil, missingConfiguration
 		}
 		return NewKeepFieldsFrameProcessor(*config.KeepFieldsProcessorConfig), nil
+	case FrameProcessorTypeAggregate:
+		if config.AggregateProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		proc, err := NewAggregateFrameProcessor(f.AggregateStateStorage, pattern, *config.AggregateProcessorConfig)
+		if err != nil {
+			return nil, err
+		}
+		return proc, nil
 	case FrameProcessorTypeMultiple:
 		if config.MultipleProcessorConfig == nil {
 			return nil, missingConfiguration
@@ -101,7 +116,7 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 		var processors []FrameProcessor
 		for _, outConf := range config.MultipleProcessorConfig.Processors {
 			out := outConf
-			proc, err := f.extractFrameProcessor(&out)
+			proc, err := f.extractFrameProcessor(&out, pattern)
 			if err != nil {
 				return nil, err
 			}
@@ -168,7 +183,7 @@ func (f *StorageRuleBuilder) constructBasicAuth(writeConfig WriteConfig) (*Basic
 	}, nil
 }
 
-func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig, writeConfigs []WriteConfig) (FrameOutputter, error) {
+func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig, writeConfigs []WriteConfig, outputs *frameOutputBuild) (FrameOutputter, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -186,7 +201,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		var outputters []FrameOutputter
 		for _, outConf := range config.MultipleOutputterConfig.Outputters {
 			out := outConf
-			outputter, err := f.extractFrameOutputter(&out, writeConfigs)
+			outputter, err := f.extractFrameOutputter(&out, writeConfigs, outputs)
 			if err != nil {
 				return nil, err
 			}
@@ -205,7 +220,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		if err != nil {
 			return nil, err
 		}
-		outputter, err := f.extractFrameOutputter(config.ConditionalOutputConfig.Outputter, writeConfigs)
+		outputter, err := f.extractFrameOutputter(config.ConditionalOutputConfig.Outputter, writeConfigs, outputs)
 		if err != nil {
 			return nil, err
 		}
@@ -253,6 +268,37 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, missingConfiguration
 		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeWebhook:
+		if config.WebhookOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.WebhookOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.WebhookOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		settings := struct {
+			Endpoint  string
+			BasicAuth *BasicAuth
+			Config    WebhookOutputConfig
+		}{writeConfig.Settings.Endpoint, basicAuth, *config.WebhookOutputConfig}
+		return outputs.getOrCreate(FrameOutputTypeWebhook, settings, func() (FrameOutputter, error) {
+			return NewWebhookFrameOutput(settings.Endpoint, settings.BasicAuth, settings.Config)
+		})
+	case FrameOutputTypeKafka:
+		if config.KafkaOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		if len(config.KafkaOutputConfig.Brokers) == 0 || config.KafkaOutputConfig.Topic == "" {
+			return nil, fmt.Errorf("kafka output requires brokers and topic")
+		}
+		kafkaConfig := *config.KafkaOutputConfig
+		return outputs.getOrCreate(FrameOutputTypeKafka, kafkaConfig, func() (FrameOutputter, error) {
+			return NewKafkaFrameOutput(kafkaConfig), nil
+		})
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -315,70 +361,82 @@ func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*Li
 	}
 
 	var rules []*LiveChannelRule
+	outputs := newFrameOutputBuild(orgID, f.FrameOutputCache)
 
 	for _, ruleConfig := range channelRules {
-		rule := &LiveChannelRule{
-			OrgId:   orgID,
-			Pattern: ruleConfig.Pattern,
+		rule, err := f.buildRule(orgID, ruleConfig, writeConfigs, outputs)
+		if err != nil {
+			// outputs created for the rules built so far are not used, the
+			// reused ones still belong to the current rules
+			outputs.closeCreated()
+			return nil, err
 		}
+		rules = append(rules, rule)
+	}
+	outputs.commit()
 
-		if ruleConfig.Settings.Auth != nil && ruleConfig.Settings.Auth.Subscribe != nil {
-			rule.SubscribeAuth = NewRoleCheckAuthorizer(ruleConfig.Settings.Auth.Subscribe.RequireRole)
-		}
+	return rules, nil
+}
 
-		if ruleConfig.Settings.Auth != nil && ruleConfig.Settings.Auth.Publish != nil {
-			rule.PublishAuth = NewRoleCheckAuthorizer(ruleConfig.Settings.Auth.Publish.RequireRole)
-		}
+func (f *StorageRuleBuilder) buildRule(orgID int64, ruleConfig ChannelRule, writeConfigs []WriteConfig, outputs *frameOutputBuild) (*LiveChannelRule, error) {
+	rule := &LiveChannelRule{
+		OrgId:   orgID,
+		Pattern: ruleConfig.Pattern,
+	}
 
-		var err error
+	if ruleConfig.Settings.Auth != nil && ruleConfig.Settings.Auth.Subscribe != nil {
+		rule.SubscribeAuth = NewRoleCheckAuthorizer(ruleConfig.Settings.Auth.Subscribe.RequireRole)
+	}
 
-		rule.Converter, err = f.extractConverter(ruleConfig.Settings.Converter)
-		if err != nil {
-			return nil, fmt.Errorf("error building converter for %s: %w", rule.Pattern, err)
-		}
+	if ruleConfig.Settings.Auth != nil && ruleConfig.Settings.Auth.Publish != nil {
+		rule.PublishAuth = NewRoleCheckAuthorizer(ruleConfig.Settings.Auth.Publish.RequireRole)
+	}
 
-		var processors []FrameProcessor
-		for _, procConfig := range ruleConfig.Settings.FrameProcessors {
-			proc, err := f.extractFrameProcessor(procConfig)
-			if err != nil {
-				return nil, fmt.Errorf("error building processor for %s: %w", rule.Pattern, err)
-			}
-			processors = append(processors, proc)
-		}
-		rule.FrameProcessors = processors
+	var err error
+	rule.Converter, err = f.extractConverter(ruleConfig.Settings.Converter)
+	if err != nil {
+		return nil, fmt.Errorf("error building converter for %s: %w", rule.Pattern, err)
+	}
 
-		var dataOutputters []DataOutputter
-		for _, outConfig := range ruleConfig.Settings.DataOutputters {
-			out, err := f.extractDataOutputter(outConfig, writeConfigs)
-			if err != nil {
-				return nil, fmt.Errorf("error building data outputter for %s: %w", rule.Pattern, err)
-			}
-			dataOutputters = append(dataOutputters, out)
+	var processors []FrameProcessor
+	for _, procConfig := range ruleConfig.Settings.FrameProcessors {
+		proc, err := f.extractFrameProcessor(procConfig, rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error building processor for %s: %w", rule.Pattern, err)
 		}
-		rule.DataOutputters = dataOutputters
+		processors = append(processors, proc)
+	}
+	rule.FrameProcessors = processors
 
-		var outputters []FrameOutputter
-		for _, outConfig := range ruleConfig.Settings.FrameOutputters {
-			out, err := f.extractFrameOutputter(outConfig, writeConfigs)
-			if err != nil {
-				return nil, fmt.Errorf("error building frame outputter for %s: %w", rule.Pattern, err)
-			}
-			outputters = append(outputters, out)
+	var dataOutputters []DataOutputter
+	for _, outConfig := range ruleConfig.Settings.DataOutputters {
+		out, err := f.extractDataOutputter(outConfig, writeConfigs)
+		if err != nil {
+			return nil, fmt.Errorf("error building data outputter for %s: %w", rule.Pattern, err)
 		}
-		rule.FrameOutputters = outputters
+		dataOutputters = append(dataOutputters, out)
+	}
+	rule.DataOutputters = dataOutputters
 
-		var subscribers []Subscriber
-		for _, subConfig := range ruleConfig.Settings.Subscribers {
-			sub, err := f.extractSubscriber(subConfig)
-			if err != nil {
-				return nil, fmt.Errorf("error building subscriber for %s: %w", rule.Pattern, err)
-			}
-			subscribers = append(subscribers, sub)
+	var outputters []FrameOutputter
+	for _, outConfig := range ruleConfig.Settings.FrameOutputters {
+		out, err := f.extractFrameOutputter(outConfig, writeConfigs, outputs)
+		if err != nil {
+			return nil, fmt.Errorf("error building frame outputter for %s: %w", rule.Pattern, err)
 		}
-		rule.Subscribers = subscribers
+		outputters = append(outputters, out)
+	}
+	rule.FrameOutputters = outputters
 
-		rules = append(rules, rule)
+	var subscribers []Subscriber
+	for _, subConfig := range ruleConfig.Settings.Subscribers {
+		sub, err := f.extractSubscriber(subConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error building subscriber for %s: %w", rule.Pattern, err)
+		}
+		subscribers = append(subscribers, sub)
 	}
+	rule.Subscribers = subscribers
 
-	return rules, nil
+	return rule, nil
 }
@@ -9,17 +9,28 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
 )
 
+// Rules which are replaced are closed after this delay, so frames which are
+// being processed with them can still be written.
+const replacedRulesCloseDelay = 5 * time.Second
+
 // CacheSegmentedTree provides a fast access to channel rule configuration.
 type CacheSegmentedTree struct {
+	// fillMu makes rules be built and replaced one after another, so that the
+	// outputs of the current rules are the ones reused by the next build.
+	fillMu      sync.Mutex
 	radixMu     sync.RWMutex
 	radix       map[int64]*tree.Node
+	rules       map[int64][]*LiveChannelRule
 	ruleBuilder RuleBuilder
+	closeDelay  time.Duration
 }
 
 func NewCacheSegmentedTree(storage RuleBuilder) *CacheSegmentedTree {
 	s := &CacheSegmentedTree{
 		radix:       map[int64]*tree.Node{},
+		rules:       map[int64][]*LiveChannelRule{},
 		ruleBuilder: storage,
+		closeDelay:  replacedRulesCloseDelay,
 	}
 	go s.updatePeriodically()
 	return s
@@ -29,7 +40,7 @@ func (s *CacheSegmentedTree) updatePeriodically() {
 	for {
 		var orgIDs []int64
 		s.radixMu.Lock()
-		for orgID := range s.radix {
+		for orgID := range s.rules {
 			orgIDs = append(orgIDs, orgID)
 		}
 		s.radixMu.Unlock()
@@ -44,6 +55,8 @@ func (s *CacheSegmentedTree) updatePeriodically() {
 }
 
 func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
+	s.fillMu.Lock()
+	defer s.fillMu.Unlock()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	channels, err := s.ruleBuilder.BuildRules(ctx, orgID)
@@ -51,14 +64,35 @@ func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
 		return err
 	}
 	s.radixMu.Lock()
-	defer s.radixMu.Unlock()
+	replaced := s.rules[orgID]
 	s.radix[orgID] = tree.New()
 	for _, ch := range channels {
 		s.radix[orgID].AddRoute("/"+ch.Pattern, ch)
 	}
+	s.rules[orgID] = channels
+	s.radixMu.Unlock()
+	s.closeReplaced(replaced, channels)
 	return nil
 }
 
+// Invalidate drops cached rules of an org, they are built again on next Get
+// or periodic update. The outputs of the dropped rules are closed then unless
+// the new rules reuse them.
+func (s *CacheSegmentedTree) Invalidate(orgID int64) {
+	s.radixMu.Lock()
+	delete(s.radix, orgID)
+	s.radixMu.Unlock()
+}
+
+func (s *CacheSegmentedTree) closeReplaced(replaced []*LiveChannelRule, current []*LiveChannelRule) {
+	if len(replaced) == 0 {
+		return
+	}
+	time.AfterFunc(s.closeDelay, func() {
+		closeReplacedRules(replaced, current)
+	})
+}
+
 func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule, bool, error) {
 	s.radixMu.RLock()
 	_, ok := s.radix[orgID]
@@ -3,8 +3,12 @@ package pipeline
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
 )
 
 type testBuilder struct{}
@@ -53,6 +57,122 @@ func TestStorage_Get(t *testing.T) {
 	require.Equal(t, "stream/boom:er", rule.Pattern)
 }
 
+type testClosableOutput struct {
+	closed chan struct{}
+}
+
+func (out *testClosableOutput) Type() string {
+	return "test"
+}
+
+func (out *testClosableOutput) OutputFrame(_ context.Context, _ Vars, _ *data.Frame) ([]*ChannelFrame, error) {
+	return nil, nil
+}
+
+func (out *testClosableOutput) Close() error {
+	close(out.closed)
+	return nil
+}
+
+type testClosableBuilder struct {
+	outputs []*testClosableOutput
+}
+
+func (b *testClosableBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	out := &testClosableOutput{closed: make(chan struct{})}
+	b.outputs = append(b.outputs, out)
+	return []*LiveChannelRule{{OrgId: 1, Pattern: "stream/test", FrameOutputters: []FrameOutputter{out}}}, nil
+}
+
+func requireOutputClosed(t *testing.T, out *testClosableOutput) {
+	t.Helper()
+	select {
+	case <-out.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("output was not closed")
+	}
+}
+
+func TestStorage_ClosesReplacedRules(t *testing.T) {
+	builder := &testClosableBuilder{}
+	s := &CacheSegmentedTree{
+		radix:       map[int64]*tree.Node{},
+		rules:       map[int64][]*LiveChannelRule{},
+		ruleBuilder: builder,
+	}
+
+	_, ok, err := s.Get(1, "stream/test")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, s.fillOrg(1))
+	require.Len(t, builder.outputs, 2)
+	requireOutputClosed(t, builder.outputs[0])
+	select {
+	case <-builder.outputs[1].closed:
+		t.Fatal("output of the current rules must not be closed")
+	default:
+	}
+
+	// invalidated rules are closed when they are built again
+	s.Invalidate(1)
+	_, ok, err = s.Get(1, "stream/test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	requireOutputClosed(t, builder.outputs[1])
+}
+
+type testKafkaRuleStorage struct {
+	Storage
+	topic string
+}
+
+func (s *testKafkaRuleStorage) ListChannelRules(_ context.Context, orgID int64) ([]ChannelRule, error) {
+	return []ChannelRule{{
+		OrgId:   orgID,
+		Pattern: "stream/test",
+		Settings: ChannelRuleSettings{
+			FrameOutputters: []*FrameOutputterConfig{{
+				Type: FrameOutputTypeMultiple,
+				MultipleOutputterConfig: &MultipleOutputterConfig{Outputters: []FrameOutputterConfig{{
+					Type:              FrameOutputTypeKafka,
+					KafkaOutputConfig: &KafkaOutputConfig{Brokers: []string{"localhost:9092"}, Topic: s.topic},
+				}}},
+			}},
+		},
+	}}, nil
+}
+
+func (s *testKafkaRuleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]WriteConfig, error) {
+	return nil, nil
+}
+
+func TestStorage_ReusesUnchangedOutputs(t *testing.T) {
+	storage := &testKafkaRuleStorage{topic: "a"}
+	s := &CacheSegmentedTree{
+		radix: map[int64]*tree.Node{},
+		rules: map[int64][]*LiveChannelRule{},
+		ruleBuilder: &StorageRuleBuilder{
+			Storage:          storage,
+			FrameOutputCache: NewFrameOutputCache(),
+		},
+	}
+	kafkaOutput := func() *KafkaFrameOutput {
+		rule, ok, err := s.Get(1, "stream/test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		return rule.FrameOutputters[0].(*MultipleFrameOutput).Outputters[0].(*KafkaFrameOutput)
+	}
+
+	first := kafkaOutput()
+	require.NoError(t, s.fillOrg(1))
+	require.Same(t, first, kafkaOutput())
+
+	storage.topic = "b"
+	require.NoError(t, s.fillOrg(1))
+	require.NotSame(t, first, kafkaOutput())
+}
+
 func BenchmarkRuleGet(b *testing.B) {
 	s := NewCacheSegmentedTree(&testBuilder{})
 	for i := 0; i < b.N; i++ {
@@ -1,6 +1,15 @@
 package pipeline
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrChannelRuleNotFound        = errors.New("channel rule not found")
+	ErrChannelRuleExists          = errors.New("channel rule with the same pattern already exists")
+	ErrChannelRuleVersionMismatch = errors.New("channel rule has been changed by someone else")
+)
 
 // Storage describes all methods to manage Live pipeline persistent data.
 type Storage interface {
@@ -13,4 +22,5 @@ type Storage interface {
 	CreateChannelRule(_ context.Context, orgID int64, cmd ChannelRuleCreateCmd) (ChannelRule, error)
 	UpdateChannelRule(_ context.Context, orgID int64, cmd ChannelRuleUpdateCmd) (ChannelRule, error)
 	DeleteChannelRule(_ context.Context, orgID int64, cmd ChannelRuleDeleteCmd) error
+	ListChannelRuleHistory(_ context.Context, orgID int64, cmd ChannelRuleHistoryCmd) ([]ChannelRuleVersion, error)
 }
@@ -220,7 +220,11 @@ func (f *FileStorage) UpdateChannelRule(ctx context.Context, orgID int64, cmd Ch
 	if index > -1 {
 		channelRules.Rules[index] = rule
 	} else {
-		return f.CreateChannelRule(ctx, orgID, ChannelRuleCreateCmd(cmd))
+		return f.CreateChannelRule(ctx, orgID, ChannelRuleCreateCmd{
+			Pattern:  cmd.Pattern,
+			Settings: cmd.Settings,
+			UserID:   cmd.UserID,
+		})
 	}
 
 	err = f.saveChannelRules(orgID, channelRules)
@@ -252,7 +256,7 @@ func (f *FileStorage) readRules() (ChannelRules, error) {
 }
 
 func (f *FileStorage) saveChannelRules(orgID int64, rules ChannelRules) error {
-	ok, reason := checkRulesValid(orgID, rules.Rules)
+	ok, reason := CheckRulesValid(orgID, rules.Rules)
 	if !ok {
 		return errors.New(reason)
 	}
@@ -296,6 +300,11 @@ func (f *FileStorage) DeleteChannelRule(_ context.Context, orgID int64, cmd Chan
 	return f.saveChannelRules(orgID, channelRules)
 }
 
+// ListChannelRuleHistory is not supported, FileStorage doesn't keep history.
+func (f *FileStorage) ListChannelRuleHistory(_ context.Context, _ int64, _ ChannelRuleHistoryCmd) ([]ChannelRuleVersion, error) {
+	return nil, errors.New("channel rule history is not supported by file storage")
+}
+
 func removeWriteConfigByIndex(s []WriteConfig, index int) []WriteConfig {
 	return append(s[:index], s[index+1:]...)
 }
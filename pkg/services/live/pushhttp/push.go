@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
+	"github.com/grafana/grafana/pkg/services/live/ratelimit"
 	"github.com/grafana/grafana/pkg/setting"
 
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
@@ -72,6 +73,10 @@ func (g *Gateway) Handle(ctx *models.ReqContext) {
 		"frameFormat", frameFormat,
 	)
 
+	if !g.allowPush(ctx, liveDto.ScopeStream+"/"+streamID, len(body)) {
+		return
+	}
+
 	metricFrames, err := g.converter.Convert(body, frameFormat)
 	if err != nil {
 		logger.Error("Error converting metrics", "error", err, "frameFormat", frameFormat)
@@ -111,6 +116,10 @@ func (g *Gateway) HandlePipelinePush(ctx *models.ReqContext) {
 		"bodyLength", len(body),
 	)
 
+	if !g.allowPush(ctx, channelID, len(body)) {
+		return
+	}
+
 	ruleFound, err := g.GrafanaLive.Pipeline.ProcessInput(ctx.Req.Context(), ctx.OrgID, channelID, body)
 	if err != nil {
 		logger.Error("Pipeline input processing error", "error", err, "body", string(body))
@@ -127,3 +136,20 @@ func (g *Gateway) HandlePipelinePush(ctx *models.ReqContext) {
 		return
 	}
 }
+
+// allowPush checks push rate limits and responds with 429 when a limit is
+// exceeded.
+func (g *Gateway) allowPush(ctx *models.ReqContext, channel string, size int) bool {
+	err := g.GrafanaLive.PushLimiter.Allow(ctx.SignedInUser.OrgID, channel, size)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, ratelimit.ErrRateLimited) {
+		logger.Warn("Push rate limit exceeded", "error", err)
+		ctx.Resp.WriteHeader(http.StatusTooManyRequests)
+		return false
+	}
+	logger.Error("Error checking push rate limits", "error", err)
+	ctx.Resp.WriteHeader(http.StatusInternalServerError)
+	return false
+}
@@ -0,0 +1,104 @@
+package pushmqtt
+
+import (
+	"context"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	logger = log.New("live.push_mqtt")
+)
+
+const (
+	connectTimeout       = 30 * time.Second
+	maxReconnectInterval = time.Minute
+	disconnectQuiesceMs  = 250
+)
+
+func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Gateway {
+	return &Gateway{
+		Cfg:         cfg,
+		GrafanaLive: live,
+	}
+}
+
+// Gateway subscribes to the configured MQTT topics and pushes received
+// messages into Grafana Live channels.
+type Gateway struct {
+	Cfg         *setting.Cfg
+	GrafanaLive *live.GrafanaLive
+}
+
+// IsDisabled returns true if no MQTT subscriptions are configured.
+func (g *Gateway) IsDisabled() bool {
+	return g.GrafanaLive == nil || len(g.Cfg.LiveMQTTSubscriptions) == 0
+}
+
+// Run connects to the MQTT brokers and keeps the subscriptions until ctx is done.
+func (g *Gateway) Run(ctx context.Context) error {
+	var pipelineInput PipelineInput
+	if g.GrafanaLive.Pipeline != nil {
+		pipelineInput = g.GrafanaLive.Pipeline
+	}
+
+	clients := make([]mqtt.Client, 0, len(g.Cfg.LiveMQTTSubscriptions))
+	for _, s := range g.Cfg.LiveMQTTSubscriptions {
+		h := newMessageHandler(s, pipelineInput, g.GrafanaLive.ManagedStreamRunner)
+		clients = append(clients, connect(ctx, s, h))
+	}
+
+	<-ctx.Done()
+	for _, c := range clients {
+		c.Disconnect(disconnectQuiesceMs)
+	}
+	return ctx.Err()
+}
+
+// connect creates a client for the subscription which keeps reconnecting to
+// the broker until it is disconnected. Subscriptions are renewed on every
+// connect since the client uses clean sessions.
+func connect(ctx context.Context, s setting.LiveMQTTSubscription, h *messageHandler) mqtt.Client {
+	onMessage := func(_ mqtt.Client, msg mqtt.Message) {
+		logger.Debug("MQTT message", "subscription", s.Name, "topic", msg.Topic(), "payloadLength", len(msg.Payload()))
+		if err := h.handle(ctx, msg.Topic(), msg.Payload()); err != nil {
+			logger.Error("Error handling MQTT message", "subscription", s.Name, "topic", msg.Topic(), "error", err)
+		}
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(s.Broker).
+		SetClientID(s.ClientID).
+		SetUsername(s.Username).
+		SetPassword(s.Password).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetMaxReconnectInterval(maxReconnectInterval).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			logger.Info("Connected to MQTT broker", "subscription", s.Name, "broker", s.Broker)
+			token := c.Subscribe(s.Topic, s.QoS, onMessage)
+			go func() {
+				if token.WaitTimeout(connectTimeout) && token.Error() != nil {
+					logger.Error("Error subscribing to MQTT topic", "subscription", s.Name, "topic", s.Topic, "error", token.Error())
+				}
+			}()
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Warn("Lost connection to MQTT broker", "subscription", s.Name, "broker", s.Broker, "error", err)
+		}).
+		SetReconnectingHandler(func(_ mqtt.Client, _ *mqtt.ClientOptions) {
+			logger.Debug("Reconnecting to MQTT broker", "subscription", s.Name, "broker", s.Broker)
+		})
+
+	client := mqtt.NewClient(opts)
+	// With ConnectRetry the token only completes once connected, retries
+	// happen in the background.
+	client.Connect()
+	return client
+}
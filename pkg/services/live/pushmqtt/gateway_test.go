@@ -0,0 +1,149 @@
+package pushmqtt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-co/mqtt/server"
+	"github.com/mochi-co/mqtt/server/listeners"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type publication struct {
+	orgID   int64
+	channel string
+	data    []byte
+}
+
+type testPublisher struct {
+	mu           sync.Mutex
+	publications []publication
+}
+
+func (p *testPublisher) publish(orgID int64, channel string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.publications = append(p.publications, publication{orgID: orgID, channel: channel, data: data})
+	return nil
+}
+
+func (p *testPublisher) get() []publication {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]publication(nil), p.publications...)
+}
+
+type testPipeline struct {
+	channels map[string]bool
+	inputs   []string
+}
+
+func (p *testPipeline) ProcessInput(_ context.Context, _ int64, channelID string, body []byte) (bool, error) {
+	if !p.channels[channelID] {
+		return false, nil
+	}
+	p.inputs = append(p.inputs, channelID+" "+string(body))
+	return true, nil
+}
+
+func startBroker(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	server := mochi.NewServer(nil)
+	require.NoError(t, server.AddListener(listeners.NewTCP("tcp", addr), nil))
+	require.NoError(t, server.Serve())
+	t.Cleanup(func() { _ = server.Close() })
+	return addr
+}
+
+func TestGateway(t *testing.T) {
+	addr := startBroker(t)
+
+	publisher := &testPublisher{}
+	runner := managedstream.NewRunner(publisher.publish, nil, managedstream.NewMemoryFrameCache(), nil, 0)
+	s := setting.LiveMQTTSubscription{
+		Name:     "sensors",
+		Broker:   "tcp://" + addr,
+		ClientID: "grafana-test",
+		Topic:    "sensors/#",
+		OrgID:    1,
+		Channel:  "stream/mqtt/${topic}",
+		Format:   "json",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := connect(ctx, s, newMessageHandler(s, nil, runner))
+	defer client.Disconnect(disconnectQuiesceMs)
+
+	// Publish from another client once the subscription is in place.
+	pub := mqtt.NewClient(mqtt.NewClientOptions().AddBroker("tcp://" + addr).SetClientID("publisher"))
+	token := pub.Connect()
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.NoError(t, token.Error())
+	defer pub.Disconnect(disconnectQuiesceMs)
+
+	require.Eventually(t, func() bool {
+		token := pub.Publish("sensors/room 1/temperature", 0, false, `{"value": 21.5}`)
+		token.Wait()
+		return len(publisher.get()) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	p := publisher.get()[0]
+	require.Equal(t, int64(1), p.orgID)
+	require.Equal(t, "stream/mqtt/sensors/room_1/temperature", p.channel)
+
+	var frame struct {
+		Data struct {
+			Values []interface{} `json:"values"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(p.data, &frame))
+	require.Len(t, frame.Data.Values, 2)
+	require.Equal(t, []interface{}{21.5}, frame.Data.Values[1])
+}
+
+func TestMessageHandler(t *testing.T) {
+	publisher := &testPublisher{}
+	runner := managedstream.NewRunner(publisher.publish, nil, managedstream.NewMemoryFrameCache(), nil, 0)
+
+	t.Run("influx line protocol is split by measurement", func(t *testing.T) {
+		s := setting.LiveMQTTSubscription{OrgID: 2, Channel: "stream/telegraf/host1", Format: "influx"}
+		h := newMessageHandler(s, nil, runner)
+		err := h.handle(context.Background(), "telegraf", []byte("cpu,host=host1 usage=0.5 1640995200000000000\nmem,host=host1 used=1024 1640995200000000000"))
+		require.NoError(t, err)
+
+		p := publisher.get()
+		require.Len(t, p, 2)
+		require.Equal(t, "stream/telegraf/host1/cpu", p[0].channel)
+		require.Equal(t, "stream/telegraf/host1/mem", p[1].channel)
+	})
+
+	t.Run("channel rules take precedence", func(t *testing.T) {
+		pipe := &testPipeline{channels: map[string]bool{"stream/mqtt/ruled": true}}
+		s := setting.LiveMQTTSubscription{OrgID: 1, Channel: "stream/mqtt/${topic}", Format: "json"}
+		h := newMessageHandler(s, pipe, runner)
+
+		require.NoError(t, h.handle(context.Background(), "ruled", []byte(`{"value": 1}`)))
+		require.Equal(t, []string{`stream/mqtt/ruled {"value": 1}`}, pipe.inputs)
+	})
+
+	t.Run("invalid channel", func(t *testing.T) {
+		s := setting.LiveMQTTSubscription{OrgID: 1, Channel: "stream/${topic}", Format: "json"}
+		h := newMessageHandler(s, nil, runner)
+		require.Error(t, h.handle(context.Background(), "a", []byte(`{}`)))
+	})
+}
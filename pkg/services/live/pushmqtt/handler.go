@@ -0,0 +1,114 @@
+package pushmqtt
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// PipelineInput processes data pushed into a channel according to channel rules.
+type PipelineInput interface {
+	ProcessInput(ctx context.Context, orgID int64, channelID string, body []byte) (bool, error)
+}
+
+// StreamGetter returns managed streams data is pushed into when there is no
+// channel rule.
+type StreamGetter interface {
+	GetOrCreateStream(orgID int64, scope string, namespace string) (*managedstream.NamespaceStream, error)
+}
+
+// messageHandler feeds messages received on the topics of a subscription into
+// Live channels.
+type messageHandler struct {
+	subscription setting.LiveMQTTSubscription
+	pipeline     PipelineInput
+	streams      StreamGetter
+	converter    pipeline.Converter
+}
+
+func newMessageHandler(s setting.LiveMQTTSubscription, pipelineInput PipelineInput, streams StreamGetter) *messageHandler {
+	var converter pipeline.Converter
+	if s.Format == "influx" {
+		converter = pipeline.NewAutoInfluxConverter(pipeline.AutoInfluxConverterConfig{
+			FrameFormat: "labels_column",
+		})
+	} else {
+		converter = pipeline.NewAutoJsonConverter(pipeline.AutoJsonConverterConfig{})
+	}
+	return &messageHandler{
+		subscription: s,
+		pipeline:     pipelineInput,
+		streams:      streams,
+		converter:    converter,
+	}
+}
+
+// handle pushes a message received on topic into the mapped channel. If the
+// channel has a pipeline rule the message is processed by the rule, otherwise
+// it's converted automatically according to the subscription format and pushed
+// into a managed stream.
+func (h *messageHandler) handle(ctx context.Context, topic string, payload []byte) error {
+	channelID := channelForTopic(h.subscription.Channel, topic)
+	channel, err := live.ParseChannel(channelID)
+	if err != nil {
+		return fmt.Errorf("invalid channel %q for topic %q: %w", channelID, topic, err)
+	}
+
+	if h.pipeline != nil {
+		ruleFound, err := h.pipeline.ProcessInput(ctx, h.subscription.OrgID, channelID, payload)
+		if err != nil {
+			return err
+		}
+		if ruleFound {
+			return nil
+		}
+	}
+
+	channelFrames, err := h.converter.Convert(ctx, pipeline.Vars{
+		OrgID:     h.subscription.OrgID,
+		Channel:   channelID,
+		Scope:     channel.Scope,
+		Namespace: channel.Namespace,
+		Path:      channel.Path,
+	}, payload)
+	if err != nil {
+		return fmt.Errorf("error converting message: %w", err)
+	}
+
+	for _, cf := range channelFrames {
+		frameChannel := channel
+		if cf.Channel != "" {
+			frameChannel, err = live.ParseChannel(cf.Channel)
+			if err != nil {
+				return fmt.Errorf("invalid channel %q: %w", cf.Channel, err)
+			}
+		}
+		stream, err := h.streams.GetOrCreateStream(h.subscription.OrgID, frameChannel.Scope, frameChannel.Namespace)
+		if err != nil {
+			return err
+		}
+		if err := stream.Push(ctx, frameChannel.Path, cf.Frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var invalidPathChars = regexp.MustCompile(`[^A-Za-z0-9_\-/=.]`)
+
+// channelForTopic replaces ${topic} in the channel template with the topic,
+// characters not allowed in channel paths are replaced with underscores.
+func channelForTopic(template string, topic string) string {
+	if !strings.Contains(template, "${topic}") {
+		return template
+	}
+	path := invalidPathChars.ReplaceAllString(strings.Trim(topic, "/"), "_")
+	return strings.ReplaceAll(template, "${topic}", path)
+}
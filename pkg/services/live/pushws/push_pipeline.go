@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/live/ratelimit"
 
 	"github.com/gorilla/websocket"
 )
@@ -13,13 +14,14 @@ import (
 // PipelinePushHandler handles WebSocket client connections that push data to Live Pipeline.
 type PipelinePushHandler struct {
 	pipeline  *pipeline.Pipeline
+	limiter   *ratelimit.Limiter
 	config    Config
 	upgrade   *websocket.Upgrader
 	converter *convert.Converter
 }
 
 // NewPathHandler creates new PipelinePushHandler.
-func NewPipelinePushHandler(pipeline *pipeline.Pipeline, c Config) *PipelinePushHandler {
+func NewPipelinePushHandler(pipeline *pipeline.Pipeline, limiter *ratelimit.Limiter, c Config) *PipelinePushHandler {
 	if c.CheckOrigin == nil {
 		c.CheckOrigin = sameHostOriginCheck()
 	}
@@ -30,6 +32,7 @@ func NewPipelinePushHandler(pipeline *pipeline.Pipeline, c Config) *PipelinePush
 	}
 	return &PipelinePushHandler{
 		pipeline:  pipeline,
+		limiter:   limiter,
 		config:    c,
 		upgrade:   upgrade,
 		converter: convert.NewConverter(),
@@ -71,6 +74,11 @@ func (s *PipelinePushHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 			"bodyLength", len(body),
 		)
 
+		if err := s.limiter.Allow(user.OrgID, channelID, len(body)); err != nil {
+			logger.Warn("Dropping push message", "error", err)
+			continue
+		}
+
 		ruleFound, err := s.pipeline.ProcessInput(r.Context(), user.OrgID, channelID, body)
 		if err != nil {
 			logger.Error("Pipeline input processing error", "error", err, "body", string(body))
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
+	"github.com/grafana/grafana/pkg/services/live/ratelimit"
 
 	"github.com/gorilla/websocket"
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
@@ -15,13 +16,14 @@ import (
 // Handler handles WebSocket client connections that push data to Live.
 type Handler struct {
 	managedStreamRunner *managedstream.Runner
+	limiter             *ratelimit.Limiter
 	config              Config
 	upgrade             *websocket.Upgrader
 	converter           *convert.Converter
 }
 
 // NewHandler creates new Handler.
-func NewHandler(managedStreamRunner *managedstream.Runner, c Config) *Handler {
+func NewHandler(managedStreamRunner *managedstream.Runner, limiter *ratelimit.Limiter, c Config) *Handler {
 	if c.CheckOrigin == nil {
 		c.CheckOrigin = sameHostOriginCheck()
 	}
@@ -32,6 +34,7 @@ func NewHandler(managedStreamRunner *managedstream.Runner, c Config) *Handler {
 	}
 	return &Handler{
 		managedStreamRunner: managedStreamRunner,
+		limiter:             limiter,
 		config:              c,
 		upgrade:             upgrade,
 		converter:           convert.NewConverter(),
@@ -67,6 +70,11 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			break
 		}
 
+		if err := s.limiter.Allow(user.OrgID, liveDto.ScopeStream+"/"+streamID, len(body)); err != nil {
+			logger.Warn("Dropping push message", "error", err)
+			continue
+		}
+
 		stream, err := s.managedStreamRunner.GetOrCreateStream(user.OrgID, liveDto.ScopeStream, streamID)
 		if err != nil {
 			logger.Error("Error getting stream", "error", err)
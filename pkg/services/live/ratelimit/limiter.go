@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/tokenbucket"
+)
+
+// ErrRateLimited is returned when a push exceeds one of the limits.
+var ErrRateLimited = errors.New("push rate limit exceeded")
+
+// Limit names, used in errors and metrics.
+const (
+	LimitOrgMessages     = "org_messages"
+	LimitOrgBytes        = "org_bytes"
+	LimitChannelMessages = "channel_messages"
+	LimitChannelBytes    = "channel_bytes"
+)
+
+var (
+	pushedMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "live",
+		Name:      "pushed_messages_total",
+		Help:      "Number of messages accepted by Live push endpoints.",
+	})
+	pushedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "live",
+		Name:      "pushed_bytes_total",
+		Help:      "Number of bytes accepted by Live push endpoints.",
+	})
+	rateLimitedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "live",
+		Name:      "push_rate_limited_total",
+		Help:      "Number of messages rejected by Live push endpoints because of rate limits.",
+	}, []string{"limit"})
+)
+
+// Limits configures push rate limits. Zero values mean no limit.
+type Limits struct {
+	OrgMessagesPerSecond     float64
+	OrgBytesPerSecond        float64
+	ChannelMessagesPerSecond float64
+	ChannelBytesPerSecond    float64
+}
+
+// Enabled returns true if any limit is set.
+func (l Limits) Enabled() bool {
+	return l.OrgMessagesPerSecond > 0 || l.OrgBytesPerSecond > 0 || l.ChannelMessagesPerSecond > 0 || l.ChannelBytesPerSecond > 0
+}
+
+// Error describes which limit was exceeded, it matches ErrRateLimited with
+// errors.Is.
+type Error struct {
+	Limit   string
+	OrgID   int64
+	Channel string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s limit for channel %s in org %d", ErrRateLimited, e.Limit, e.Channel, e.OrgID)
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Limiter enforces message and byte rates of pushes per org and per channel
+// using token buckets which allow bursts of up to one second worth of the
+// limit. Limits are enforced per Grafana instance.
+type Limiter struct {
+	limits Limits
+
+	orgMessages     *tokenbucket.Limiter[int64]
+	orgBytes        *tokenbucket.Limiter[int64]
+	channelMessages *tokenbucket.Limiter[channelKey]
+	channelBytes    *tokenbucket.Limiter[channelKey]
+}
+
+type channelKey struct {
+	orgID   int64
+	channel string
+}
+
+// NewLimiter creates Limiter with the given limits.
+func NewLimiter(limits Limits) *Limiter {
+	return &Limiter{
+		limits:          limits,
+		orgMessages:     tokenbucket.New[int64](limits.OrgMessagesPerSecond, burst(limits.OrgMessagesPerSecond), 0),
+		orgBytes:        tokenbucket.New[int64](limits.OrgBytesPerSecond, burst(limits.OrgBytesPerSecond), 0),
+		channelMessages: tokenbucket.New[channelKey](limits.ChannelMessagesPerSecond, burst(limits.ChannelMessagesPerSecond), 0),
+		channelBytes:    tokenbucket.New[channelKey](limits.ChannelBytesPerSecond, burst(limits.ChannelBytesPerSecond), 0),
+	}
+}
+
+// Allow checks if a message of the given size can be pushed into a channel.
+// Nothing is consumed from the limits when the message is rejected.
+func (l *Limiter) Allow(orgID int64, channel string, size int) error {
+	if l == nil || !l.limits.Enabled() {
+		pushedMessages.Inc()
+		pushedBytes.Add(float64(size))
+		return nil
+	}
+
+	key := channelKey{orgID: orgID, channel: channel}
+	limit, _ := tokenbucket.Allow(time.Now(),
+		l.orgMessages.Check(LimitOrgMessages, orgID, 1),
+		l.orgBytes.Check(LimitOrgBytes, orgID, size),
+		l.channelMessages.Check(LimitChannelMessages, key, 1),
+		l.channelBytes.Check(LimitChannelBytes, key, size),
+	)
+	if limit != "" {
+		rateLimitedMessages.WithLabelValues(limit).Inc()
+		return &Error{Limit: limit, OrgID: orgID, Channel: channel}
+	}
+
+	pushedMessages.Inc()
+	pushedBytes.Add(float64(size))
+	return nil
+}
+
+// burst allows one second worth of the limit at once. Messages larger than
+// the bytes per second limit are never allowed.
+func burst(perSecond float64) int {
+	return int(math.Max(1, math.Ceil(perSecond)))
+}
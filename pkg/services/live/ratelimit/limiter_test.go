@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireLimit(t *testing.T, err error, limit string) {
+	t.Helper()
+	require.ErrorIs(t, err, ErrRateLimited)
+	var limitErr *Error
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, limit, limitErr.Limit)
+}
+
+func TestLimiter_NoLimits(t *testing.T) {
+	l := NewLimiter(Limits{})
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, l.Allow(1, "stream/test", 1000))
+	}
+	var nilLimiter *Limiter
+	require.NoError(t, nilLimiter.Allow(1, "stream/test", 1000))
+}
+
+func TestLimiter_ChannelMessages(t *testing.T) {
+	l := NewLimiter(Limits{ChannelMessagesPerSecond: 2})
+
+	require.NoError(t, l.Allow(1, "stream/a", 10))
+	require.NoError(t, l.Allow(1, "stream/a", 10))
+	requireLimit(t, l.Allow(1, "stream/a", 10), LimitChannelMessages)
+
+	// Other channels and orgs have their own limits.
+	require.NoError(t, l.Allow(1, "stream/b", 10))
+	require.NoError(t, l.Allow(2, "stream/a", 10))
+}
+
+func TestLimiter_OrgMessages(t *testing.T) {
+	l := NewLimiter(Limits{OrgMessagesPerSecond: 2, ChannelMessagesPerSecond: 10})
+
+	require.NoError(t, l.Allow(1, "stream/a", 10))
+	require.NoError(t, l.Allow(1, "stream/b", 10))
+	requireLimit(t, l.Allow(1, "stream/c", 10), LimitOrgMessages)
+	require.NoError(t, l.Allow(2, "stream/a", 10))
+}
+
+func TestLimiter_Bytes(t *testing.T) {
+	l := NewLimiter(Limits{ChannelBytesPerSecond: 100, OrgBytesPerSecond: 200})
+
+	require.NoError(t, l.Allow(1, "stream/a", 80))
+	requireLimit(t, l.Allow(1, "stream/a", 80), LimitChannelBytes)
+	// The rejected message didn't consume org limit.
+	require.NoError(t, l.Allow(1, "stream/b", 100))
+	requireLimit(t, l.Allow(1, "stream/c", 30), LimitOrgBytes)
+
+	// Messages larger than the limit are never allowed.
+	requireLimit(t, l.Allow(2, "stream/a", 101), LimitChannelBytes)
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	l := NewLimiter(Limits{ChannelMessagesPerSecond: 100})
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Allow(1, "stream/a", 1))
+	}
+	requireLimit(t, l.Allow(1, "stream/a", 1), LimitChannelMessages)
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, l.Allow(1, "stream/a", 1))
+}
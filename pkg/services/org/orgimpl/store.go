@@ -227,6 +227,8 @@ func (ss *sqlStore) Delete(ctx context.Context, cmd *org.DeleteOrgCommand) error
 			"DELETE FROM alert WHERE org_id = ?",
 			"DELETE FROM annotation WHERE org_id = ?",
 			"DELETE FROM kv_store WHERE org_id = ?",
+			"DELETE FROM dashboard_version_tag WHERE org_id = ?",
+			"DELETE FROM dashboard_version_retention WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
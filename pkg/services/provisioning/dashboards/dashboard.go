@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/grafana/grafana/pkg/components/dashlint"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -23,7 +24,7 @@ type DashboardProvisioner interface {
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
-type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, utils.OrgStore, utils.DashboardStore) (DashboardProvisioner, error)
+type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, utils.OrgStore, utils.DashboardStore, *dashlint.Linter) (DashboardProvisioner, error)
 
 // Provisioner is responsible for syncing dashboard from disk to Grafana's database.
 type Provisioner struct {
@@ -38,8 +39,9 @@ func (provider *Provisioner) HasDashboardSources() bool {
 	return len(provider.fileReaders) > 0
 }
 
-// New returns a new DashboardProvisioner
-func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgStore utils.OrgStore, dashboardStore utils.DashboardStore) (DashboardProvisioner, error) {
+// New returns a new DashboardProvisioner. Dashboards are linted before they are
+// saved when linter is not nil.
+func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgStore utils.OrgStore, dashboardStore utils.DashboardStore, linter *dashlint.Linter) (DashboardProvisioner, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger, orgStore: orgStore}
 	configs, err := cfgReader.readConfig(ctx)
@@ -47,7 +49,7 @@ func New(ctx context.Context, configDirectory string, provisioner dashboards.Das
 		return nil, fmt.Errorf("%v: %w", "Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger, provisioner, dashboardStore)
+	fileReaders, err := getFileReaders(configs, logger, provisioner, dashboardStore, linter)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", "Failed to initialize file readers", err)
 	}
@@ -127,7 +129,7 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 }
 
 func getFileReaders(
-	configs []*config, logger log.Logger, service dashboards.DashboardProvisioningService, store utils.DashboardStore,
+	configs []*config, logger log.Logger, service dashboards.DashboardProvisioningService, store utils.DashboardStore, linter *dashlint.Linter,
 ) ([]*FileReader, error) {
 	var readers []*FileReader
 
@@ -138,6 +140,7 @@ func getFileReaders(
 			if err != nil {
 				return nil, fmt.Errorf("failed to create file reader for config %v: %w", config.Name, err)
 			}
+			fileReader.linter = linter
 			readers = append(readers, fileReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashlint"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
@@ -35,6 +36,7 @@ type FileReader struct {
 	dashboardProvisioningService dashboards.DashboardProvisioningService
 	dashboardStore               utils.DashboardStore
 	FoldersFromFilesStructure    bool
+	linter                       *dashlint.Linter
 
 	mux                     sync.RWMutex
 	usageTracker            *usageTracker
@@ -259,6 +261,10 @@ func (fr *FileReader) saveDashboard(ctx context.Context, path string, folderID i
 		dash.Dashboard.SetId(provisionedData.DashboardId)
 	}
 
+	if !fr.lintDashboard(path, dash) {
+		return provisioningMetadata, nil
+	}
+
 	if !fr.isDatabaseAccessRestricted() {
 		fr.log.Debug("saving new dashboard", "provisioner", fr.Cfg.Name, "file", path, "folderId", dash.Dashboard.FolderId)
 		dp := &models.DashboardProvisioning{
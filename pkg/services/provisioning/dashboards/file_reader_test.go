@@ -12,9 +12,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/components/dashlint"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -125,6 +127,41 @@ func TestDashboardFileReader(t *testing.T) {
 			require.NoError(t, err)
 		})
 
+		t.Run("Dashboards with lint errors are not saved", func(t *testing.T) {
+			setup()
+			cfg.Options["path"] = defaultDashboards
+
+			fakeService := &dashboards.FakeDashboardProvisioning{}
+			fakeService.On("GetProvisionedDashboardData", configName).Return(nil, nil).Once()
+
+			reader, err := NewDashboardFileReader(cfg, logger, nil, fakeStore)
+			reader.dashboardProvisioningService = fakeService
+			reader.linter = dashlint.New(setting.DashboardLintSettings{Severities: map[string]string{"datasource_uid": "error"}})
+			require.NoError(t, err)
+
+			err = reader.walkDisk(context.Background())
+			require.NoError(t, err)
+			fakeService.AssertNotCalled(t, "SaveProvisionedDashboard", mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		t.Run("Dashboards with lint warnings are saved", func(t *testing.T) {
+			setup()
+			cfg.Options["path"] = defaultDashboards
+
+			fakeService := &dashboards.FakeDashboardProvisioning{}
+			defer fakeService.AssertExpectations(t)
+			fakeService.On("GetProvisionedDashboardData", configName).Return(nil, nil).Once()
+			fakeService.On("SaveProvisionedDashboard", mock.Anything, mock.Anything, mock.Anything).Return(&models.Dashboard{Id: 2}, nil).Times(2)
+
+			reader, err := NewDashboardFileReader(cfg, logger, nil, fakeStore)
+			reader.dashboardProvisioningService = fakeService
+			reader.linter = dashlint.New(setting.DashboardLintSettings{Severities: map[string]string{"datasource_uid": "warning"}})
+			require.NoError(t, err)
+
+			err = reader.walkDisk(context.Background())
+			require.NoError(t, err)
+		})
+
 		t.Run("Can read default dashboard and replace old version in database", func(t *testing.T) {
 			setup()
 			cfg.Options["path"] = oneDashboard
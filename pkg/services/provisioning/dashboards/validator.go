@@ -4,7 +4,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashlint"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 )
 
 type duplicate struct {
@@ -148,6 +150,27 @@ func (c *duplicateValidator) validate() {
 	c.takeAwayWritePermissions(duplicates)
 }
 
+// lintDashboard logs the lint issues of a provisioned dashboard and returns
+// false when it has errors and must not be saved.
+func (fr *FileReader) lintDashboard(path string, dash *dashboards.SaveDashboardDTO) bool {
+	if fr.linter == nil {
+		return true
+	}
+
+	result := fr.linter.Lint(dash.Dashboard.Data)
+	for _, issue := range result.Issues {
+		if issue.Severity == dashlint.SeverityError {
+			fr.log.Error("dashboard lint error", "file", path, "rule", issue.Rule, "path", issue.Path, "message", issue.Message)
+		} else {
+			fr.log.Warn("dashboard lint warning", "file", path, "rule", issue.Rule, "path", issue.Path, "message", issue.Message)
+		}
+	}
+	if !result.Valid {
+		fr.log.Error("not saving dashboard because of lint errors", "provisioner", fr.Cfg.Name, "file", path)
+	}
+	return result.Valid
+}
+
 func keysToSlice(data map[string]struct{}) []string {
 	entries := make([]string, 0, len(data))
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"errors"
+	"net"
 	"net/http"
 	"strconv"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tokenbucket"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -17,6 +19,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -27,6 +30,10 @@ type Api struct {
 	AccessControl          accesscontrol.AccessControl
 	Features               *featuremgmt.FeatureManager
 	Log                    log.Logger
+
+	clientLimiter    *tokenbucket.Limiter[string]
+	dashboardLimiter *tokenbucket.Limiter[string]
+	trustedProxies   []*net.IPNet
 }
 
 func ProvideApi(
@@ -34,6 +41,7 @@ func ProvideApi(
 	rr routing.RouteRegister,
 	ac accesscontrol.AccessControl,
 	features *featuremgmt.FeatureManager,
+	cfg *setting.Cfg,
 ) *Api {
 	api := &Api{
 		PublicDashboardService: pd,
@@ -41,6 +49,9 @@ func ProvideApi(
 		AccessControl:          ac,
 		Features:               features,
 		Log:                    log.New("publicdashboards.api"),
+		clientLimiter:          NewRequestLimiter(cfg.PublicDashboards.ClientMaxRequestsPerMinute),
+		dashboardLimiter:       NewRequestLimiter(cfg.PublicDashboards.DashboardMaxRequestsPerMinute),
+		trustedProxies:         cfg.PublicDashboards.TrustedProxies,
 	}
 
 	// attach api if PublicDashboards feature flag is enabled
@@ -59,9 +70,11 @@ func (api *Api) RegisterAPIEndpoints() {
 	// because it is deeply dependent on the HTTPServer.Index() method and would result in a
 	// circular dependency
 
-	// public endpoints
+	// public endpoints, the ones running data source queries are rate limited
+	rateLimit := RateLimit(api.clientLimiter, api.dashboardLimiter, api.trustedProxies)
 	api.RouteRegister.Get("/api/public/dashboards/:accessToken", routing.Wrap(api.GetPublicDashboard))
-	api.RouteRegister.Post("/api/public/dashboards/:accessToken/panels/:panelId/query", routing.Wrap(api.QueryPublicDashboard))
+	api.RouteRegister.Post("/api/public/dashboards/:accessToken/panels/:panelId/query", rateLimit, routing.Wrap(api.QueryPublicDashboard))
+	api.RouteRegister.Get("/api/public/dashboards/:accessToken/annotations", rateLimit, routing.Wrap(api.GetAnnotations))
 
 	// Create/Update Public Dashboard
 	uidScope := dashboards.ScopeDashboardsProvider.GetResourceScopeUID(accesscontrol.Parameter(":uid"))
@@ -174,6 +187,22 @@ func (api *Api) QueryPublicDashboard(c *models.ReqContext) response.Response {
 	return toJsonStreamingResponse(api.Features, resp)
 }
 
+// GetAnnotations returns annotations for a public dashboard
+// GET /api/public/dashboards/:accessToken/annotations
+func (api *Api) GetAnnotations(c *models.ReqContext) response.Response {
+	reqDTO := AnnotationsQueryDTO{
+		From: c.Query("from"),
+		To:   c.Query("to"),
+	}
+
+	annotations, err := api.PublicDashboardService.FindAnnotations(c.Req.Context(), reqDTO, web.Params(c.Req)[":accessToken"])
+	if err != nil {
+		return api.handleError(http.StatusInternalServerError, "error getting public dashboard annotations", err)
+	}
+
+	return response.JSON(http.StatusOK, annotations)
+}
+
 // util to help us unpack dashboard and publicdashboard errors or use default http code and message
 // we should look to do some future refactoring of these errors as publicdashboard err is the same as a dashboarderr, just defined in a
 // different package.
@@ -22,6 +22,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	dashboardStore "github.com/grafana/grafana/pkg/services/dashboards/database"
 	"github.com/grafana/grafana/pkg/services/datasources"
@@ -484,6 +485,47 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 	})
 }
 
+func TestAPIGetAnnotations(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		cfg := setting.NewCfg()
+		cfg.RBACEnabled = false
+
+		testServer := setupTestServer(
+			t,
+			cfg,
+			featuremgmt.WithFeatures(featuremgmt.FlagPublicDashboards),
+			service,
+			nil,
+			anonymousUser,
+		)
+
+		return testServer, service
+	}
+
+	t.Run("Returns annotations for the requested time range", func(t *testing.T) {
+		server, fakeDashboardService := setup()
+		reqDTO := AnnotationsQueryDTO{From: "now-1h", To: "now"}
+		fakeDashboardService.On("FindAnnotations", mock.Anything, reqDTO, "abc123").Return([]AnnotationEvent{{Id: 1, Text: "deploy"}}, nil)
+
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/abc123/annotations?from=now-1h&to=now", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var events []AnnotationEvent
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		require.Equal(t, "deploy", events[0].Text)
+	})
+
+	t.Run("Status code is 400 when the time range is not allowed", func(t *testing.T) {
+		server, fakeDashboardService := setup()
+		fakeDashboardService.On("FindAnnotations", mock.Anything, mock.Anything, "abc123").Return(nil, ErrPublicDashboardBadRequest)
+
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/abc123/annotations?from=now-10y&to=now", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
 func TestIntegrationUnauthenticatedUserCanGetPubdashPanelQueryData(t *testing.T) {
 	db := sqlstore.InitTestDB(t)
 
@@ -544,7 +586,7 @@ func TestIntegrationUnauthenticatedUserCanGetPubdashPanelQueryData(t *testing.T)
 	store := publicdashboardsStore.ProvideStore(db)
 	cfg := setting.NewCfg()
 	cfg.RBACEnabled = false
-	service := publicdashboardsService.ProvideService(cfg, store, qds)
+	service := publicdashboardsService.ProvideService(cfg, store, qds, annotationstest.NewFakeAnnotationsRepo())
 	pubdash, err := service.SavePublicDashboardConfig(context.Background(), &user.SignedInUser{}, savePubDashboardCmd)
 	require.NoError(t, err)
 
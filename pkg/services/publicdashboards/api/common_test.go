@@ -69,7 +69,7 @@ func setupTestServer(
 
 	// build api, this will mount the routes at the same time if
 	// featuremgmt.FlagPublicDashboard is enabled
-	ProvideApi(service, rr, ac, features)
+	ProvideApi(service, rr, ac, features, cfg)
 
 	// connect routes to mux
 	rr.Register(m.Router)
@@ -139,6 +139,7 @@ func buildQueryDataService(t *testing.T, cs datasources.CacheService, fpc *fakeP
 		&fakeDatasources.FakeDataSourceService{},
 		fpc,
 		&fakeOAuthTokenService{},
+		nil,
 	)
 }
 
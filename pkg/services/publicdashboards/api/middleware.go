@@ -1,12 +1,19 @@
 package api
 
 import (
+	"math"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/tokenbucket"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -52,3 +59,73 @@ func CountPublicDashboardRequest() func(c *models.ReqContext) {
 		metrics.MPublicDashboardRequestCount.Inc()
 	}
 }
+
+// RateLimit rejects requests exceeding the per client IP or per public
+// dashboard limits with 429 Too Many Requests. The client IP is the address of
+// the connection, or the one set by one of trustedProxies.
+func RateLimit(clientLimiter *tokenbucket.Limiter[string], dashboardLimiter *tokenbucket.Limiter[string], trustedProxies []*net.IPNet) func(c *models.ReqContext) {
+	logger := log.New("publicdashboards.ratelimit")
+
+	return func(c *models.ReqContext) {
+		if clientLimiter == nil && dashboardLimiter == nil {
+			return
+		}
+
+		clientIP := getClientIP(c.Req, trustedProxies)
+		limit, retryAfter := tokenbucket.Allow(time.Now(),
+			clientLimiter.Check(RateLimitClient, clientIP, 1),
+			dashboardLimiter.Check(RateLimitDashboard, web.Params(c.Req)[":accessToken"], 1),
+		)
+		if limit == "" {
+			return
+		}
+
+		metrics.MPublicDashboardRateLimitedRequests.WithLabelValues(limit).Inc()
+		logger.Warn("Public dashboard request rate limited", "limit", limit, "clientIP", clientIP)
+
+		c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JsonApiErr(http.StatusTooManyRequests, "Too many requests", nil)
+	}
+}
+
+// getClientIP returns the address of the connection. When it's a trusted proxy,
+// the client IP is read from X-Real-IP, or is the last address of
+// X-Forwarded-For which isn't a trusted proxy, as clients may send their own
+// X-Forwarded-For.
+func getClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	addr := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !isTrustedProxy(net.ParseIP(addr), trustedProxies) {
+		return addr
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	forwardedFor := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwardedFor[i]))
+		if ip == nil {
+			break
+		}
+		addr = ip.String()
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return addr
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,12 +3,14 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
 	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
 	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
@@ -79,9 +81,10 @@ func mockAccessTokenExistsResponse(returnArguments ...interface{}) *publicdashbo
 			},
 		},
 		&fakeOAuthTokenService{},
+		nil,
 	)
 
-	return publicdashboardsService.ProvideService(setting.NewCfg(), fakeStore, qds)
+	return publicdashboardsService.ProvideService(setting.NewCfg(), fakeStore, qds, annotationstest.NewFakeAnnotationsRepo())
 }
 
 func runMiddleware(request *http.Request, pubdashService *publicdashboardsService.PublicDashboardServiceImpl) *httptest.ResponseRecorder {
@@ -103,3 +106,109 @@ func mockValidRequestHandler(c *models.ReqContext) {
 	resp["message"] = "Valid request"
 	c.JSON(http.StatusOK, resp)
 }
+
+func TestRateLimit(t *testing.T) {
+	run := func(handler func(c *models.ReqContext), accessToken string, clientIP string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("GET", "/api/public/ma/events/"+accessToken, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = clientIP + ":1234"
+
+		recorder := httptest.NewRecorder()
+		m := web.New()
+		m.Use(func(c *web.Context) {
+			c.Req = c.Req.WithContext(ctxkey.Set(c.Req.Context(), &models.ReqContext{Context: c}))
+		})
+		m.Get("/api/public/ma/events/:accessToken", handler, mockValidRequestHandler)
+		m.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	t.Run("Allows every request without limits", func(t *testing.T) {
+		handler := RateLimit(NewRequestLimiter(0), NewRequestLimiter(0), nil)
+		for i := 0; i < 10; i++ {
+			require.Equal(t, http.StatusOK, run(handler, "abc123", "10.0.0.1").Code)
+		}
+	})
+
+	t.Run("Returns 429 when a client exceeds its limit", func(t *testing.T) {
+		handler := RateLimit(NewRequestLimiter(2), nil, nil)
+		require.Equal(t, http.StatusOK, run(handler, "abc123", "10.0.0.1").Code)
+		require.Equal(t, http.StatusOK, run(handler, "abc123", "10.0.0.1").Code)
+
+		resp := run(handler, "abc123", "10.0.0.1")
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+		require.Equal(t, "30", resp.Header().Get("Retry-After"))
+
+		// other clients are not limited
+		require.Equal(t, http.StatusOK, run(handler, "abc123", "10.0.0.2").Code)
+	})
+
+	t.Run("Returns 429 when a public dashboard exceeds its limit", func(t *testing.T) {
+		handler := RateLimit(nil, NewRequestLimiter(1), nil)
+		require.Equal(t, http.StatusOK, run(handler, "abc123", "10.0.0.1").Code)
+		require.Equal(t, http.StatusTooManyRequests, run(handler, "abc123", "10.0.0.2").Code)
+
+		// other public dashboards are not limited
+		require.Equal(t, http.StatusOK, run(handler, "def456", "10.0.0.2").Code)
+	})
+}
+
+func TestGetClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trustedProxies := []*net.IPNet{proxies}
+
+	testCases := []struct {
+		desc           string
+		remoteAddr     string
+		headers        map[string]string
+		trustedProxies []*net.IPNet
+		expected       string
+	}{
+		{
+			desc:       "connection address without trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "192.168.1.1", "X-Forwarded-For": "192.168.1.2"},
+			expected:   "10.0.0.1",
+		},
+		{
+			desc:           "connection address when it's not a trusted proxy",
+			remoteAddr:     "192.168.1.1:1234",
+			headers:        map[string]string{"X-Real-IP": "192.168.1.2"},
+			trustedProxies: trustedProxies,
+			expected:       "192.168.1.1",
+		},
+		{
+			desc:           "X-Real-IP of a trusted proxy",
+			remoteAddr:     "10.0.0.1:1234",
+			headers:        map[string]string{"X-Real-IP": "192.168.1.1", "X-Forwarded-For": "192.168.1.2"},
+			trustedProxies: trustedProxies,
+			expected:       "192.168.1.1",
+		},
+		{
+			desc:           "last untrusted X-Forwarded-For address of a trusted proxy",
+			remoteAddr:     "10.0.0.1:1234",
+			headers:        map[string]string{"X-Forwarded-For": "1.1.1.1, 192.168.1.1, 10.0.0.2"},
+			trustedProxies: trustedProxies,
+			expected:       "192.168.1.1",
+		},
+		{
+			desc:           "trusted proxy without headers",
+			remoteAddr:     "10.0.0.1:1234",
+			trustedProxies: trustedProxies,
+			expected:       "10.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			require.Equal(t, tc.expected, getClientIP(req, tc.trustedProxies))
+		})
+	}
+}
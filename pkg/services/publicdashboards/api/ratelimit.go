@@ -0,0 +1,16 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/infra/tokenbucket"
+)
+
+// maxBuckets caps the number of keys a limiter tracks.
+const maxBuckets = 10000
+
+// NewRequestLimiter creates a limiter allowing perMinute requests per key,
+// such as a public dashboard access token or a client IP, with bursts of up
+// to one minute worth of the limit. It returns nil, which allows every
+// request, if perMinute is not positive.
+func NewRequestLimiter(perMinute int) *tokenbucket.Limiter[string] {
+	return tokenbucket.New[string](float64(perMinute)/60, perMinute, maxBuckets)
+}
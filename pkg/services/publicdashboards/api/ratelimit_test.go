@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tokenbucket"
+)
+
+func TestRequestLimiter(t *testing.T) {
+	t.Run("no limiter is created without a limit", func(t *testing.T) {
+		require.Nil(t, NewRequestLimiter(0))
+	})
+
+	t.Run("allows a burst of one minute worth of requests and refills over time", func(t *testing.T) {
+		limiter := NewRequestLimiter(60)
+		now := time.Now()
+
+		for i := 0; i < 60; i++ {
+			limit, _ := tokenbucket.Allow(now, limiter.Check("client", "10.0.0.1", 1))
+			require.Empty(t, limit)
+		}
+
+		limit, retryAfter := tokenbucket.Allow(now, limiter.Check("client", "10.0.0.1", 1))
+		require.Equal(t, "client", limit)
+		require.Equal(t, time.Second, retryAfter)
+
+		limit, _ = tokenbucket.Allow(now.Add(time.Second), limiter.Check("client", "10.0.0.1", 1))
+		require.Empty(t, limit)
+	})
+}
@@ -362,9 +362,13 @@ func TestIntegrationUpdatePublicDashboard(t *testing.T) {
 			DashboardUid: savedDashboard.Uid,
 			OrgId:        savedDashboard.OrgId,
 			IsEnabled:    false,
-			TimeSettings: &TimeSettings{From: "now-8", To: "now"},
+			TimeSettings: &TimeSettings{From: "now-8", To: "now", MaxRange: "7d"},
 			UpdatedAt:    time.Now().UTC().Round(time.Second),
 			UpdatedBy:    8,
+
+			TimeSelectionEnabled: true,
+			TemplateVariables:    TemplateVariables{"host": {"a", "b"}},
+			AnnotationsEnabled:   true,
 		}
 		// update initial record
 		err = publicdashboardStore.UpdatePublicDashboardConfig(context.Background(), SavePublicDashboardConfigCommand{
@@ -380,6 +384,10 @@ func TestIntegrationUpdatePublicDashboard(t *testing.T) {
 		// make sure we're correctly updated IsEnabled because we have to call
 		// UseBool with xorm
 		assert.Equal(t, updatedPublicDashboard.IsEnabled, pdRetrieved.IsEnabled)
+		assert.Equal(t, updatedPublicDashboard.TimeSettings, pdRetrieved.TimeSettings)
+		assert.True(t, pdRetrieved.TimeSelectionEnabled)
+		assert.Equal(t, updatedPublicDashboard.TemplateVariables, pdRetrieved.TemplateVariables)
+		assert.True(t, pdRetrieved.AnnotationsEnabled)
 
 		// not updated dashboard shouldn't have changed
 		pdNotUpdatedRetrieved, err := publicdashboardStore.GetPublicDashboardConfig(context.Background(), anotherSavedDashboard.OrgId, anotherSavedDashboard.Uid)
@@ -5,8 +5,10 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 )
 
 // PublicDashboardErr represents a dashboard error.
@@ -29,6 +31,16 @@ const QueryFailure = "failure"
 
 var QueryResultStatuses = []string{QuerySuccess, QueryFailure}
 
+const RateLimitDashboard = "dashboard"
+const RateLimitClient = "client"
+
+var RateLimits = []string{RateLimitDashboard, RateLimitClient}
+
+const QueryCacheHit = "hit"
+const QueryCacheMiss = "miss"
+
+var QueryCacheResults = []string{QueryCacheHit, QueryCacheMiss}
+
 var (
 	ErrPublicDashboardFailedGenerateUniqueUid = PublicDashboardErr{
 		Reason:     "failed to generate unique public dashboard id",
@@ -53,9 +65,21 @@ var (
 		StatusCode: 400,
 	}
 	ErrPublicDashboardHasTemplateVariables = PublicDashboardErr{
-		Reason:     "public dashboard has template variables",
+		Reason:     "public dashboard has template variables without allowed values",
+		StatusCode: 422,
+	}
+	ErrPublicDashboardUnsupportedTemplateVariable = PublicDashboardErr{
+		Reason:     "public dashboard has ad hoc or data source template variables",
 		StatusCode: 422,
 	}
+	ErrPublicDashboardInvalidTemplateVariables = PublicDashboardErr{
+		Reason:     "allowed template variable values are invalid",
+		StatusCode: 400,
+	}
+	ErrPublicDashboardInvalidTimeSettings = PublicDashboardErr{
+		Reason:     "time selection limits are invalid",
+		StatusCode: 400,
+	}
 	ErrPublicDashboardBadRequest = PublicDashboardErr{
 		Reason:     "bad Request",
 		StatusCode: 400,
@@ -70,6 +94,14 @@ type PublicDashboard struct {
 	IsEnabled    bool          `json:"isEnabled" xorm:"is_enabled"`
 	AccessToken  string        `json:"accessToken" xorm:"access_token"`
 
+	// TimeSelectionEnabled allows viewers to change the time range within
+	// the limits set in TimeSettings
+	TimeSelectionEnabled bool `json:"timeSelectionEnabled" xorm:"time_selection_enabled"`
+	// TemplateVariables maps each template variable name to the values
+	// viewers are allowed to pick
+	TemplateVariables  TemplateVariables `json:"templateVariables" xorm:"template_variables"`
+	AnnotationsEnabled bool              `json:"annotationsEnabled" xorm:"annotations_enabled"`
+
 	CreatedBy int64 `json:"createdBy" xorm:"created_by"`
 	UpdatedBy int64 `json:"updatedBy" xorm:"updated_by"`
 
@@ -84,6 +116,11 @@ func (pd PublicDashboard) TableName() string {
 type TimeSettings struct {
 	From string `json:"from,omitempty"`
 	To   string `json:"to,omitempty"`
+
+	// MaxRange is the longest time range viewers can select, e.g. "7d"
+	MaxRange string `json:"maxRange,omitempty"`
+	// MaxLookback is how far back from now viewers can select, e.g. "30d"
+	MaxLookback string `json:"maxLookback,omitempty"`
 }
 
 func (ts *TimeSettings) FromDB(data []byte) error {
@@ -94,6 +131,26 @@ func (ts *TimeSettings) ToDB() ([]byte, error) {
 	return json.Marshal(ts)
 }
 
+type TemplateVariables map[string][]string
+
+func (tv *TemplateVariables) FromDB(data []byte) error {
+	return json.Unmarshal(data, tv)
+}
+
+func (tv *TemplateVariables) ToDB() ([]byte, error) {
+	return json.Marshal(tv)
+}
+
+// IsAllowed reports whether value is an allowed value of the variable name
+func (tv TemplateVariables) IsAllowed(name, value string) bool {
+	for _, allowed := range tv[name] {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
 // build time settings object from json on public dashboard. If empty, use
 // defaults on the dashboard
 func (pd PublicDashboard) BuildTimeSettings(dashboard *models.Dashboard) TimeSettings {
@@ -114,6 +171,40 @@ func (pd PublicDashboard) BuildTimeSettings(dashboard *models.Dashboard) TimeSet
 	return ts
 }
 
+// BuildTemplateVariableValues returns the values of the template variables
+// on the dashboard to interpolate queries with. Requested values are expected
+// to be validated already. Variables not in the request fall back to the
+// dashboard default when it is allowed, or the first allowed value otherwise.
+// Constants always have their dashboard value.
+func (pd PublicDashboard) BuildTemplateVariableValues(dashboard *models.Dashboard, requested map[string]string) map[string]*templatevars.Variable {
+	variables := templatevars.FromDashboard(dashboard.Data)
+
+	for _, variableObj := range dashboard.Data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(variableObj)
+		name := variable.Get("name").MustString()
+		if name == "" || variable.Get("type").MustString() == "constant" {
+			continue
+		}
+
+		if value, ok := requested[name]; ok {
+			variables[name] = &templatevars.Variable{Value: []string{value}}
+			continue
+		}
+
+		current := variables[name]
+		if current != nil && !current.Multi && len(current.Value) == 1 && pd.TemplateVariables.IsAllowed(name, current.Value[0]) {
+			continue
+		}
+		if allowed := pd.TemplateVariables[name]; len(allowed) > 0 {
+			variables[name] = &templatevars.Variable{Value: []string{allowed[0]}}
+		} else {
+			delete(variables, name)
+		}
+	}
+
+	return variables
+}
+
 // DTO for transforming user input in the api
 type SavePublicDashboardConfigDTO struct {
 	DashboardUid    string
@@ -125,6 +216,76 @@ type SavePublicDashboardConfigDTO struct {
 type PublicDashboardQueryDTO struct {
 	IntervalMs    int64
 	MaxDataPoints int64
+
+	// TimeRange and Variables are only honoured when the public dashboard
+	// allows them
+	TimeRange *TimeRange
+	Variables map[string]string
+}
+
+// TimeRange is a time range selected by a viewer. From and To are either
+// epoch milliseconds or relative times such as "now-6h".
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BuildTimeSettings converts the time range to epoch milliseconds
+func (tr TimeRange) BuildTimeSettings() TimeSettings {
+	timeRange := legacydata.NewDataTimeRange(tr.From, tr.To)
+
+	return TimeSettings{
+		From: strconv.FormatInt(timeRange.GetFromAsMsEpoch(), 10),
+		To:   strconv.FormatInt(timeRange.GetToAsMsEpoch(), 10),
+	}
+}
+
+type AnnotationsQueryDTO struct {
+	From string
+	To   string
+}
+
+// AnnotationEvent is an annotation as exposed on a public dashboard. It
+// leaves out who created the annotation.
+type AnnotationEvent struct {
+	Id          int64          `json:"id"`
+	DashboardId int64          `json:"dashboardId"`
+	PanelId     int64          `json:"panelId"`
+	Tags        []string       `json:"tags"`
+	IsRegion    bool           `json:"isRegion"`
+	Text        string         `json:"text"`
+	Color       string         `json:"color"`
+	Time        int64          `json:"time"`
+	TimeEnd     int64          `json:"timeEnd"`
+	Source      DashAnnotation `json:"source"`
+}
+
+// DashAnnotation is an annotation query defined on a dashboard
+type DashAnnotation struct {
+	Datasource *AnnotationDatasource `json:"datasource"`
+	Enable     bool                  `json:"enable"`
+	Hide       bool                  `json:"hide"`
+	IconColor  string                `json:"iconColor"`
+	Name       string                `json:"name"`
+	Target     *AnnotationTarget     `json:"target"`
+}
+
+type AnnotationDatasource struct {
+	Type string `json:"type"`
+	Uid  string `json:"uid"`
+}
+
+type AnnotationTarget struct {
+	Limit    int64    `json:"limit"`
+	MatchAny bool     `json:"matchAny"`
+	Tags     []string `json:"tags"`
+	Type     string   `json:"type"`
+}
+
+type AnnotationsDto struct {
+	Annotations struct {
+		List []DashAnnotation `json:"list"`
+	} `json:"annotations"`
 }
 
 //
@@ -6,7 +6,9 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPublicDashboardTableName(t *testing.T) {
@@ -48,3 +50,45 @@ func TestBuildTimeSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildTemplateVariableValues(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "host", "type": "custom", "current": {"value": "b", "text": "b"}},
+				{"name": "region", "type": "custom", "current": {"value": "us"}},
+				{"name": "env", "type": "constant", "query": "prod"},
+				{"name": "dc", "type": "custom", "multi": true, "current": {"value": ["ams", "fra"]}},
+				{"name": "pod", "type": "custom", "current": {"value": "web"}}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &models.Dashboard{Data: dashboardData}
+	pubdash := PublicDashboard{TemplateVariables: TemplateVariables{"host": {"a", "b"}, "region": {"eu", "ap"}, "dc": {"fra", "ams"}}}
+
+	t.Run("uses dashboard defaults when allowed and the first allowed value otherwise", func(t *testing.T) {
+		values := pubdash.BuildTemplateVariableValues(dashboard, nil)
+		assert.Equal(t, map[string]*templatevars.Variable{
+			"host":   {Value: []string{"b"}, Text: []string{"b"}},
+			"region": {Value: []string{"eu"}},
+			"env":    {Value: []string{"prod"}},
+			"dc":     {Value: []string{"fra"}},
+		}, values)
+	})
+
+	t.Run("uses requested values", func(t *testing.T) {
+		values := pubdash.BuildTemplateVariableValues(dashboard, map[string]string{"host": "a", "region": "ap"})
+		assert.Equal(t, map[string]*templatevars.Variable{
+			"host":   {Value: []string{"a"}},
+			"region": {Value: []string{"ap"}},
+			"env":    {Value: []string{"prod"}},
+			"dc":     {Value: []string{"fra"}},
+		}, values)
+	})
+}
+
+func TestTimeRangeBuildTimeSettings(t *testing.T) {
+	tr := TimeRange{From: "1661990400000", To: "1662033600000"}
+	assert.Equal(t, TimeSettings{From: "1661990400000", To: "1662033600000"}, tr.BuildTimeSettings())
+}
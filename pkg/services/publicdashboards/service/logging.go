@@ -22,6 +22,13 @@ func LogQueryFailure(datasources []string, log log.Logger, err error) {
 	metrics.MPublicDashboardDatasourceQuerySuccess.WithLabelValues(label, models.QueryFailure).Inc()
 }
 
+// LogQueryCacheResult records every panel query of a cached public dashboard,
+// also the ones served from the cache
+func LogQueryCacheResult(log log.Logger, result string) {
+	log.Info("Public dashboard panel query", "cache", result)
+	metrics.MPublicDashboardQueryCache.WithLabelValues(result).Inc()
+}
+
 func getLabelName(datasources []string) string {
 	size := len(datasources)
 
@@ -2,14 +2,25 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"math/bits"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
@@ -18,8 +29,10 @@ import (
 	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 )
 
 // Define the Service Implementation. We're generating mock implementation
@@ -30,10 +43,24 @@ type PublicDashboardServiceImpl struct {
 	store              publicdashboards.Store
 	intervalCalculator intervalv2.Calculator
 	QueryDataService   *query.Service
+	AnnotationsRepo    annotations.Repository
+
+	// queryCache holds panel query results for the minimum refresh interval,
+	// nil when caching is disabled
+	queryCache *localcache.CacheService
+	queryGroup singleflight.Group
 }
 
 var LogPrefix = "publicdashboards.service"
 
+const (
+	// queryCacheMaxItems caps the number of cached panel query results
+	queryCacheMaxItems = 1000
+	// sharedQueryTimeout limits panel queries shared by concurrent viewers,
+	// which aren't cancelled with the request of any viewer
+	sharedQueryTimeout = time.Minute
+)
+
 // Gives us compile time error if the service does not adhere to the contract of
 // the interface
 var _ publicdashboards.Service = (*PublicDashboardServiceImpl)(nil)
@@ -44,6 +71,7 @@ func ProvideService(
 	cfg *setting.Cfg,
 	store publicdashboards.Store,
 	qds *query.Service,
+	anno annotations.Repository,
 ) *PublicDashboardServiceImpl {
 	return &PublicDashboardServiceImpl{
 		log:                log.New(LogPrefix),
@@ -51,7 +79,22 @@ func ProvideService(
 		store:              store,
 		intervalCalculator: intervalv2.NewCalculator(),
 		QueryDataService:   qds,
+		AnnotationsRepo:    anno,
+		queryCache:         newQueryCache(cfg.PublicDashboards.MinRefreshInterval),
+	}
+}
+
+func newQueryCache(minRefreshInterval time.Duration) *localcache.CacheService {
+	if minRefreshInterval <= 0 {
+		return nil
 	}
+
+	cleanupInterval := time.Minute
+	if minRefreshInterval > cleanupInterval {
+		cleanupInterval = minRefreshInterval
+	}
+
+	return localcache.New(minRefreshInterval, cleanupInterval)
 }
 
 func (pd *PublicDashboardServiceImpl) GetDashboard(ctx context.Context, dashboardUid string) (*models.Dashboard, error) {
@@ -113,13 +156,14 @@ func (pd *PublicDashboardServiceImpl) SavePublicDashboardConfig(ctx context.Cont
 		return nil, err
 	}
 
+	err = validation.ValidateSavePublicDashboard(dto, dashboard)
+	if err != nil {
+		return nil, err
+	}
+
 	// save changes
 	var pubdashUid string
 	if existingPubdash == nil {
-		err = validation.ValidateSavePublicDashboard(dto, dashboard)
-		if err != nil {
-			return nil, err
-		}
 		pubdashUid, err = pd.savePublicDashboardConfig(ctx, dto)
 	} else {
 		pubdashUid, err = pd.updatePublicDashboardConfig(ctx, dto)
@@ -162,6 +206,10 @@ func (pd *PublicDashboardServiceImpl) savePublicDashboardConfig(ctx context.Cont
 			CreatedBy:    dto.UserId,
 			CreatedAt:    time.Now(),
 			AccessToken:  accessToken,
+
+			TimeSelectionEnabled: dto.PublicDashboard.TimeSelectionEnabled,
+			TemplateVariables:    dto.PublicDashboard.TemplateVariables,
+			AnnotationsEnabled:   dto.PublicDashboard.AnnotationsEnabled,
 		},
 	}
 
@@ -183,23 +231,87 @@ func (pd *PublicDashboardServiceImpl) updatePublicDashboardConfig(ctx context.Co
 			TimeSettings: dto.PublicDashboard.TimeSettings,
 			UpdatedBy:    dto.UserId,
 			UpdatedAt:    time.Now(),
+
+			TimeSelectionEnabled: dto.PublicDashboard.TimeSelectionEnabled,
+			TemplateVariables:    dto.PublicDashboard.TemplateVariables,
+			AnnotationsEnabled:   dto.PublicDashboard.AnnotationsEnabled,
 		},
 	}
 
 	return dto.PublicDashboard.Uid, pd.store.UpdatePublicDashboardConfig(ctx, cmd)
 }
 
+// GetQueryDataResponse runs the queries of a public dashboard panel. The
+// response may be cached or shared with concurrent requests for the same
+// queries, so callers must not modify it.
 func (pd *PublicDashboardServiceImpl) GetQueryDataResponse(ctx context.Context, skipCache bool, queryDto PublicDashboardQueryDTO, panelId int64, accessToken string) (*backend.QueryDataResponse, error) {
 	publicDashboard, dashboard, err := pd.GetPublicDashboard(ctx, accessToken)
 	if err != nil {
 		return nil, err
 	}
 
+	if pd.queryCache != nil {
+		queryDto = pd.roundQueryResolution(queryDto)
+	}
 	metricReq, err := pd.GetMetricRequest(ctx, dashboard, publicDashboard, panelId, queryDto)
 	if err != nil {
 		return nil, err
 	}
 
+	logger := pd.log.New("publicDashboardUid", publicDashboard.Uid, "dashboardUid", dashboard.Uid, "panelId", panelId)
+
+	if pd.queryCache == nil {
+		return pd.queryData(ctx, logger, skipCache, dashboard, metricReq)
+	}
+
+	// viewers can't skip the cache, it is what keeps a popular public
+	// dashboard from overloading its data sources
+	metricReq.From, metricReq.To = alignTimeRange(metricReq.From, metricReq.To, pd.cfg.PublicDashboards.MinRefreshInterval)
+	cacheKey, err := queryCacheKey(publicDashboard, dashboard, panelId, metricReq)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := pd.queryCache.Get(cacheKey); ok {
+		LogQueryCacheResult(logger, QueryCacheHit)
+		return cached.(*backend.QueryDataResponse), nil
+	}
+	LogQueryCacheResult(logger, QueryCacheMiss)
+
+	// concurrent requests for the same results share one query, which isn't
+	// cancelled when the viewer who started it goes away
+	resCh := pd.queryGroup.DoChan(cacheKey, func() (interface{}, error) {
+		queryCtx, cancel := context.WithTimeout(detachedContext{ctx}, sharedQueryTimeout)
+		defer cancel()
+
+		res, err := pd.queryData(queryCtx, logger, skipCache, dashboard, metricReq)
+		if err != nil {
+			return nil, err
+		}
+		pd.setCachedQueryData(cacheKey, res)
+		return res, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-resCh:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*backend.QueryDataResponse), nil
+	}
+}
+
+// detachedContext keeps the values of its parent, but not its deadline and
+// cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (pd *PublicDashboardServiceImpl) queryData(ctx context.Context, logger log.Logger, skipCache bool, dashboard *models.Dashboard, metricReq dtos.MetricRequest) (*backend.QueryDataResponse, error) {
 	anonymousUser, err := pd.BuildAnonymousUser(ctx, dashboard)
 	if err != nil {
 		return nil, err
@@ -209,18 +321,90 @@ func (pd *PublicDashboardServiceImpl) GetQueryDataResponse(ctx context.Context,
 
 	reqDatasources := metricReq.GetUniqueDatasourceTypes()
 	if err != nil {
-		LogQueryFailure(reqDatasources, pd.log, err)
+		LogQueryFailure(reqDatasources, logger, err)
 		return nil, err
 	}
-	LogQuerySuccess(reqDatasources, pd.log)
+	LogQuerySuccess(reqDatasources, logger)
 
 	queries.SanitizeMetadataFromQueryData(res)
 
 	return res, nil
 }
 
+// queryCacheKey identifies the results of a panel query. It's built from the
+// queries of the dashboard and the variable values they are interpolated
+// with, so viewers can only get new keys by values the public dashboard
+// allows. It changes when the dashboard or the public dashboard configuration
+// is updated.
+func queryCacheKey(publicDashboard *PublicDashboard, dashboard *models.Dashboard, panelId int64, metricReq dtos.MetricRequest) (string, error) {
+	// maps are marshalled with sorted keys, so equal queries have equal keys
+	queriesJSON, err := json.Marshal(struct {
+		Queries   []*simplejson.Json
+		Variables map[string]*templatevars.Variable
+	}{metricReq.Queries, metricReq.Variables})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(queriesJSON)
+	return fmt.Sprintf("%s:%d:%d:%d:%s:%s:%x", publicDashboard.Uid, publicDashboard.UpdatedAt.UnixNano(), dashboard.Version, panelId, metricReq.From, metricReq.To, sum), nil
+}
+
+// roundQueryResolution rounds the interval and max data points requested by a
+// viewer of a cached public dashboard, as arbitrary values would make every
+// request miss the cache.
+func (pd *PublicDashboardServiceImpl) roundQueryResolution(queryDto PublicDashboardQueryDTO) PublicDashboardQueryDTO {
+	if queryDto.IntervalMs > 0 {
+		// the safe interval of a time range one interval long is the rounded interval
+		interval := time.Duration(queryDto.IntervalMs) * time.Millisecond
+		rounded := pd.intervalCalculator.CalculateSafeInterval(backend.TimeRange{To: time.Time{}.Add(interval)}, 1)
+		queryDto.IntervalMs = rounded.Value.Milliseconds()
+	}
+	if queryDto.MaxDataPoints > 0 {
+		// round down to a power of two
+		queryDto.MaxDataPoints = 1 << (bits.Len64(uint64(queryDto.MaxDataPoints)) - 1)
+	}
+	return queryDto
+}
+
+// alignTimeRange truncates the epoch milliseconds time range to the minimum
+// refresh interval, so relative time ranges such as now-1h have the same
+// results, and cache key, for the whole interval.
+func alignTimeRange(from, to string, minRefreshInterval time.Duration) (string, string) {
+	align := func(ms string) string {
+		v, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			return ms
+		}
+		return strconv.FormatInt(v-v%minRefreshInterval.Milliseconds(), 10)
+	}
+	if minRefreshInterval.Milliseconds() <= 0 {
+		return from, to
+	}
+	return align(from), align(to)
+}
+
+// setCachedQueryData caches query results unless one of the queries failed,
+// so errors are retried by the next viewer
+func (pd *PublicDashboardServiceImpl) setCachedQueryData(key string, res *backend.QueryDataResponse) {
+	for _, dataResponse := range res.Responses {
+		if dataResponse.Error != nil {
+			return
+		}
+	}
+
+	if pd.queryCache.ItemCount() >= queryCacheMaxItems {
+		pd.queryCache.DeleteExpired()
+		if pd.queryCache.ItemCount() >= queryCacheMaxItems {
+			pd.log.Warn("Public dashboard query cache is full", "maxItems", queryCacheMaxItems)
+			return
+		}
+	}
+	pd.queryCache.SetDefault(key, res)
+}
+
 func (pd *PublicDashboardServiceImpl) GetMetricRequest(ctx context.Context, dashboard *models.Dashboard, publicDashboard *PublicDashboard, panelId int64, queryDto PublicDashboardQueryDTO) (dtos.MetricRequest, error) {
-	if err := validation.ValidateQueryPublicDashboardRequest(queryDto); err != nil {
+	if err := validation.ValidateQueryPublicDashboardRequest(queryDto, publicDashboard); err != nil {
 		return dtos.MetricRequest{}, ErrPublicDashboardBadRequest
 	}
 
@@ -243,28 +427,139 @@ func (pd *PublicDashboardServiceImpl) GetMetricRequest(ctx context.Context, dash
 func (pd *PublicDashboardServiceImpl) buildMetricRequest(ctx context.Context, dashboard *models.Dashboard, publicDashboard *PublicDashboard, panelId int64, reqDTO PublicDashboardQueryDTO) (dtos.MetricRequest, error) {
 	// group queries by panel
 	queriesByPanel := queries.GroupQueriesByPanelId(dashboard.Data)
-	queries, ok := queriesByPanel[panelId]
+	panelQueries, ok := queriesByPanel[panelId]
 	if !ok {
 		return dtos.MetricRequest{}, ErrPublicDashboardPanelNotFound
 	}
 
 	ts := publicDashboard.BuildTimeSettings(dashboard)
+	if reqDTO.TimeRange != nil {
+		ts = reqDTO.TimeRange.BuildTimeSettings()
+	}
+
+	variables := publicDashboard.BuildTemplateVariableValues(dashboard, reqDTO.Variables)
 
 	// determine safe resolution to query data at
 	safeInterval, safeResolution := pd.getSafeIntervalAndMaxDataPoints(reqDTO, ts)
-	for i := range queries {
-		queries[i].Set("intervalMs", safeInterval)
-		queries[i].Set("maxDataPoints", safeResolution)
+	for i := range panelQueries {
+		panelQueries[i].Set("intervalMs", safeInterval)
+		panelQueries[i].Set("maxDataPoints", safeResolution)
 	}
 
+	// the query service interpolates the variables the way each data source
+	// expects them
 	return dtos.MetricRequest{
-		From:    ts.From,
-		To:      ts.To,
-		Queries: queries,
+		From:      ts.From,
+		To:        ts.To,
+		Queries:   panelQueries,
+		Variables: variables,
 	}, nil
 }
 
+// FindAnnotations returns the annotations of the built in Grafana annotation
+// queries enabled on the dashboard. Annotations from other data sources are
+// queried by the frontend through the panel query endpoint.
+func (pd *PublicDashboardServiceImpl) FindAnnotations(ctx context.Context, reqDTO AnnotationsQueryDTO, accessToken string) ([]AnnotationEvent, error) {
+	publicDashboard, dashboard, err := pd.GetPublicDashboard(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !publicDashboard.AnnotationsEnabled {
+		return []AnnotationEvent{}, nil
+	}
+
+	ts := publicDashboard.BuildTimeSettings(dashboard)
+	if reqDTO.From != "" || reqDTO.To != "" {
+		tr := TimeRange{From: reqDTO.From, To: reqDTO.To}
+		if err := validation.ValidateTimeRange(tr, publicDashboard, time.Now()); err != nil {
+			return nil, ErrPublicDashboardBadRequest
+		}
+		ts = tr.BuildTimeSettings()
+	}
+	timeRange := legacydata.NewDataTimeRange(ts.From, ts.To)
+
+	annoDto := AnnotationsDto{}
+	dashJSON, err := dashboard.Data.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(dashJSON, &annoDto); err != nil {
+		return nil, err
+	}
+
+	anonymousUser, err := pd.BuildAnonymousUser(ctx, dashboard)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []AnnotationEvent{}
+	seen := make(map[int64]bool)
+	for _, anno := range annoDto.Annotations.List {
+		if !anno.Enable || !isGrafanaAnnotation(anno) {
+			continue
+		}
+
+		annoQuery := &annotations.ItemQuery{
+			From:         timeRange.GetFromAsMsEpoch(),
+			To:           timeRange.GetToAsMsEpoch(),
+			OrgId:        dashboard.OrgId,
+			DashboardId:  dashboard.Id,
+			DashboardUid: dashboard.Uid,
+			SignedInUser: anonymousUser,
+		}
+
+		if anno.Target != nil {
+			annoQuery.Limit = anno.Target.Limit
+			annoQuery.MatchAny = anno.Target.MatchAny
+			if anno.Target.Type == "tags" {
+				annoQuery.DashboardId = 0
+				annoQuery.DashboardUid = ""
+				annoQuery.Tags = anno.Target.Tags
+			}
+		}
+
+		items, err := pd.AnnotationsRepo.Find(ctx, annoQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if seen[item.Id] {
+				continue
+			}
+			seen[item.Id] = true
+
+			events = append(events, AnnotationEvent{
+				Id:          item.Id,
+				DashboardId: item.DashboardId,
+				PanelId:     item.PanelId,
+				Tags:        item.Tags,
+				IsRegion:    item.TimeEnd > 0 && item.Time != item.TimeEnd,
+				Text:        item.Text,
+				Color:       anno.IconColor,
+				Time:        item.Time,
+				TimeEnd:     item.TimeEnd,
+				Source:      anno,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+func isGrafanaAnnotation(anno DashAnnotation) bool {
+	if anno.Datasource == nil {
+		return false
+	}
+
+	return anno.Datasource.Type == "grafana" ||
+		anno.Datasource.Uid == grafanads.DatasourceUID ||
+		anno.Datasource.Uid == grafanads.DatasourceName
+}
+
 // BuildAnonymousUser creates a user with permissions to read from all datasources used in the dashboard
+// and to read the annotations of the dashboard
 func (pd *PublicDashboardServiceImpl) BuildAnonymousUser(ctx context.Context, dashboard *models.Dashboard) (*user.SignedInUser, error) {
 	datasourceUids := queries.GetUniqueDashboardDatasourceUids(dashboard.Data)
 
@@ -279,6 +574,8 @@ func (pd *PublicDashboardServiceImpl) BuildAnonymousUser(ctx context.Context, da
 	}
 	permissions[datasources.ActionQuery] = queryScopes
 	permissions[datasources.ActionRead] = readScopes
+	permissions[accesscontrol.ActionAnnotationsRead] = []string{accesscontrol.ScopeAnnotationsTypeDashboard, accesscontrol.ScopeAnnotationsTypeOrganization}
+	permissions[dashboards.ActionDashboardsRead] = []string{dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboard.Uid)}
 	anonymousUser.Permissions[dashboard.OrgId] = permissions
 
 	return anonymousUser, nil
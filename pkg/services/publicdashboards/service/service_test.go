@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/user"
 
 	"github.com/google/uuid"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -18,12 +21,16 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationsimpl"
 	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/database"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 )
 
 var timeSettings = &TimeSettings{From: "now-12h", To: "now"}
@@ -394,6 +401,313 @@ func TestGetMetricRequest(t *testing.T) {
 	})
 }
 
+func TestGetMetricRequestWithViewerSelection(t *testing.T) {
+	// queries are modified in place, so every request gets a freshly loaded dashboard
+	newDashboard := func() *models.Dashboard {
+		return models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id": 1,
+					"targets": []interface{}{
+						map[string]interface{}{
+							"datasource": map[string]interface{}{"type": "prometheus", "uid": "ds1"},
+							"expr":       "up{host=\"$host\"}",
+							"refId":      "A",
+						},
+					},
+				},
+			},
+			"templating": map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"name": "host", "type": "custom", "current": map[string]interface{}{"value": "a"}},
+				},
+			},
+			"time": map[string]interface{}{"from": "now-1h", "to": "now"},
+		}))
+	}
+	publicDashboard := &PublicDashboard{
+		IsEnabled:            true,
+		TimeSelectionEnabled: true,
+		TimeSettings:         &TimeSettings{MaxRange: "1d"},
+		TemplateVariables:    TemplateVariables{"host": {"a", "b"}},
+	}
+	service := &PublicDashboardServiceImpl{
+		log:                log.New("test.logger"),
+		intervalCalculator: intervalv2.NewCalculator(),
+	}
+
+	t.Run("uses the dashboard defaults when the viewer selected nothing", func(t *testing.T) {
+		metricReq, err := service.GetMetricRequest(context.Background(), newDashboard(), publicDashboard, 1, PublicDashboardQueryDTO{})
+		require.NoError(t, err)
+		require.Equal(t, `up{host="$host"}`, metricReq.Queries[0].Get("expr").MustString())
+		require.Equal(t, []string{"a"}, metricReq.Variables["host"].Value)
+	})
+
+	t.Run("uses the time range and variable values selected by the viewer", func(t *testing.T) {
+		queryDTO := PublicDashboardQueryDTO{
+			TimeRange: &TimeRange{From: "1661990400000", To: "1662033600000"},
+			Variables: map[string]string{"host": "b"},
+		}
+
+		metricReq, err := service.GetMetricRequest(context.Background(), newDashboard(), publicDashboard, 1, queryDTO)
+		require.NoError(t, err)
+		require.Equal(t, "1661990400000", metricReq.From)
+		require.Equal(t, "1662033600000", metricReq.To)
+		require.Equal(t, []string{"b"}, metricReq.Variables["host"].Value)
+	})
+
+	t.Run("returns bad request when the time range exceeds the limits", func(t *testing.T) {
+		queryDTO := PublicDashboardQueryDTO{TimeRange: &TimeRange{From: "now-2d", To: "now"}}
+
+		_, err := service.GetMetricRequest(context.Background(), newDashboard(), publicDashboard, 1, queryDTO)
+		require.ErrorIs(t, err, ErrPublicDashboardBadRequest)
+	})
+
+	t.Run("returns bad request when the variable value is not allowed", func(t *testing.T) {
+		queryDTO := PublicDashboardQueryDTO{Variables: map[string]string{"host": "c"}}
+
+		_, err := service.GetMetricRequest(context.Background(), newDashboard(), publicDashboard, 1, queryDTO)
+		require.ErrorIs(t, err, ErrPublicDashboardBadRequest)
+	})
+}
+
+func TestFindAnnotations(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	tagService := tagimpl.ProvideService(sqlStore, sqlStore.Cfg)
+	dashboardStore := dashboardsDB.ProvideDashboardStore(sqlStore, featuremgmt.WithFeatures(), tagService)
+	publicdashboardStore := database.ProvideStore(sqlStore)
+	sqlStore.Cfg.AnnotationMaximumTagsLength = 60
+	annotationsRepo := annotationsimpl.ProvideService(sqlStore, sqlStore.Cfg, tagService)
+
+	dashboard, err := dashboardStore.SaveDashboard(context.Background(), models.SaveDashboardCommand{
+		OrgId: 1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "annotated",
+			"time":  map[string]interface{}{"from": "now-1h", "to": "now"},
+			"annotations": map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{
+						"builtIn":    1,
+						"datasource": map[string]interface{}{"type": "grafana", "uid": "-- Grafana --"},
+						"enable":     true,
+						"iconColor":  "red",
+						"name":       "Annotations & Alerts",
+						"target":     map[string]interface{}{"type": "dashboard", "limit": 100},
+					},
+					map[string]interface{}{
+						"datasource": map[string]interface{}{"type": "prometheus", "uid": "ds1"},
+						"enable":     true,
+						"name":       "not supported",
+					},
+				},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	err = annotationsRepo.Save(context.Background(), &annotations.Item{
+		OrgId:       1,
+		DashboardId: dashboard.Id,
+		Text:        "deploy",
+		Epoch:       now.Add(-10 * time.Minute).UnixMilli(),
+		EpochEnd:    now.Add(-5 * time.Minute).UnixMilli(),
+	})
+	require.NoError(t, err)
+
+	service := &PublicDashboardServiceImpl{
+		log:             log.New("test.logger"),
+		store:           publicdashboardStore,
+		AnnotationsRepo: annotationsRepo,
+	}
+
+	savePublicDashboard := func(annotationsEnabled bool) string {
+		pubdash, err := service.SavePublicDashboardConfig(context.Background(), SignedInUser, &SavePublicDashboardConfigDTO{
+			DashboardUid: dashboard.Uid,
+			OrgId:        dashboard.OrgId,
+			PublicDashboard: &PublicDashboard{
+				IsEnabled:          true,
+				AnnotationsEnabled: annotationsEnabled,
+			},
+		})
+		require.NoError(t, err)
+		return pubdash.AccessToken
+	}
+
+	t.Run("returns no annotations when annotations are disabled", func(t *testing.T) {
+		events, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, savePublicDashboard(false))
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+
+	t.Run("returns annotations of the built in annotation query", func(t *testing.T) {
+		events, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, savePublicDashboard(true))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "deploy", events[0].Text)
+		assert.Equal(t, "red", events[0].Color)
+		assert.True(t, events[0].IsRegion)
+	})
+
+	t.Run("returns bad request for a time range when time selection is disabled", func(t *testing.T) {
+		_, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{From: "now-1d", To: "now"}, savePublicDashboard(true))
+		require.ErrorIs(t, err, ErrPublicDashboardBadRequest)
+	})
+}
+
+func TestGetQueryDataResponseCache(t *testing.T) {
+	// an absolute time range, so the aligned time range doesn't change while
+	// the test runs
+	dashboard := newTestDashboard()
+	dashboard.Data.Set("time", map[string]interface{}{"from": "1667000000000", "to": "1667003600000"})
+	publicDashboard := &PublicDashboard{Uid: "pubdash1", IsEnabled: true, AccessToken: "abc123"}
+
+	fakeStore := FakePublicDashboardStore{}
+	fakeStore.On("GetPublicDashboard", mock.Anything, "abc123").Return(publicDashboard, dashboard, nil)
+
+	// without a query data service the results can only come from the cache
+	cfg := setting.NewCfg()
+	cfg.PublicDashboards.MinRefreshInterval = time.Minute
+	service := &PublicDashboardServiceImpl{
+		log:                log.New("test.logger"),
+		cfg:                cfg,
+		store:              &fakeStore,
+		intervalCalculator: intervalv2.NewCalculator(),
+		queryCache:         newQueryCache(time.Minute),
+	}
+
+	queryDto := PublicDashboardQueryDTO{IntervalMs: 10000, MaxDataPoints: 512}
+	metricReq, err := service.GetMetricRequest(context.Background(), dashboard, publicDashboard, 1, queryDto)
+	require.NoError(t, err)
+	metricReq.From, metricReq.To = alignTimeRange(metricReq.From, metricReq.To, time.Minute)
+	key, err := queryCacheKey(publicDashboard, dashboard, 1, metricReq)
+	require.NoError(t, err)
+
+	cached := &backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{}}}
+	service.setCachedQueryData(key, cached)
+
+	t.Run("returns cached results even when the viewer asks to skip the cache", func(t *testing.T) {
+		res, err := service.GetQueryDataResponse(context.Background(), true, queryDto, 1, "abc123")
+		require.NoError(t, err)
+		require.Same(t, cached, res)
+	})
+
+	t.Run("viewers can't miss the cache with arbitrary resolutions", func(t *testing.T) {
+		res, err := service.GetQueryDataResponse(context.Background(), false, PublicDashboardQueryDTO{IntervalMs: 10400, MaxDataPoints: 1000}, 1, "abc123")
+		require.NoError(t, err)
+		require.Same(t, cached, res)
+	})
+
+	t.Run("cache key changes with the queries, variables, time range, dashboard and configuration", func(t *testing.T) {
+		otherReq := metricReq
+		otherReq.From = "1667000060000"
+		otherKey, err := queryCacheKey(publicDashboard, dashboard, 1, otherReq)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+
+		otherReq = metricReq
+		otherReq.Queries = []*simplejson.Json{simplejson.NewFromAny(map[string]interface{}{"refId": "A", "expr": "up"})}
+		otherKey, err = queryCacheKey(publicDashboard, dashboard, 1, otherReq)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+
+		otherReq = metricReq
+		otherReq.Variables = map[string]*templatevars.Variable{"host": {Value: []string{"b"}}}
+		otherKey, err = queryCacheKey(publicDashboard, dashboard, 1, otherReq)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+
+		otherKey, err = queryCacheKey(publicDashboard, dashboard, 2, metricReq)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+
+		updatedDashboard := *dashboard
+		updatedDashboard.Version++
+		otherKey, err = queryCacheKey(publicDashboard, &updatedDashboard, 1, metricReq)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+
+		updatedPublicDashboard := *publicDashboard
+		updatedPublicDashboard.UpdatedAt = time.Now()
+		otherKey, err = queryCacheKey(&updatedPublicDashboard, dashboard, 1, metricReq)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+	})
+
+	t.Run("does not cache failed queries", func(t *testing.T) {
+		failed := &backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{Error: errors.New("failed")}}}
+		service.setCachedQueryData("failed", failed)
+
+		_, ok := service.queryCache.Get("failed")
+		require.False(t, ok)
+	})
+
+	t.Run("does not cache more than the max items", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.New("test.logger"), queryCache: newQueryCache(time.Minute)}
+		for i := 0; i <= queryCacheMaxItems; i++ {
+			service.setCachedQueryData(strconv.Itoa(i), cached)
+		}
+		require.Equal(t, queryCacheMaxItems, service.queryCache.ItemCount())
+	})
+
+	t.Run("no cache without a minimum refresh interval", func(t *testing.T) {
+		require.Nil(t, newQueryCache(0))
+	})
+}
+
+func TestAlignTimeRange(t *testing.T) {
+	from, to := alignTimeRange("1667000012345", "1667003612345", 10*time.Second)
+	require.Equal(t, "1667000010000", from)
+	require.Equal(t, "1667003610000", to)
+
+	from, to = alignTimeRange("1667000012345", "1667003612345", 0)
+	require.Equal(t, "1667000012345", from)
+	require.Equal(t, "1667003612345", to)
+}
+
+func TestRoundQueryResolution(t *testing.T) {
+	service := &PublicDashboardServiceImpl{intervalCalculator: intervalv2.NewCalculator()}
+
+	dto := service.roundQueryResolution(PublicDashboardQueryDTO{IntervalMs: 10400, MaxDataPoints: 1000})
+	require.Equal(t, int64(10000), dto.IntervalMs)
+	require.Equal(t, int64(512), dto.MaxDataPoints)
+
+	dto = service.roundQueryResolution(PublicDashboardQueryDTO{})
+	require.Equal(t, int64(0), dto.IntervalMs)
+	require.Equal(t, int64(0), dto.MaxDataPoints)
+}
+
+func TestDetachedContext(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	cancel()
+
+	detached := detachedContext{ctx}
+	require.NoError(t, detached.Err())
+	require.Nil(t, detached.Done())
+	require.Equal(t, "value", detached.Value(key{}))
+}
+
+func newTestDashboard() *models.Dashboard {
+	dashboard := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id": 1,
+				"targets": []interface{}{
+					map[string]interface{}{
+						"datasource": map[string]interface{}{"type": "prometheus", "uid": "ds1"},
+						"refId":      "A",
+					},
+				},
+			},
+		},
+		"time": map[string]interface{}{"from": "now-1h", "to": "now"},
+	}))
+	dashboard.Uid = "dash1"
+	dashboard.Version = 1
+	return dashboard
+}
+
 func TestBuildMetricRequest(t *testing.T) {
 	sqlStore := sqlstore.InitTestDB(t)
 	dashboardStore := dashboardsDB.ProvideDashboardStore(sqlStore, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore, sqlStore.Cfg))
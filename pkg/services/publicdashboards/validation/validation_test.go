@@ -1,7 +1,9 @@
 package validation
 
 import (
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
@@ -42,3 +44,139 @@ func TestValidateSavePublicDashboard(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestValidateSavePublicDashboardTemplateVariables(t *testing.T) {
+	dashboardData, _ := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "host", "type": "custom"},
+				{"name": "env", "type": "constant", "query": "prod"}
+			]
+		}
+	}`))
+	dashboard := models.NewDashboardFromJson(dashboardData)
+
+	t.Run("Returns no validation error when every variable has allowed values", func(t *testing.T) {
+		dto := &publicdashboardModels.SavePublicDashboardConfigDTO{PublicDashboard: &publicdashboardModels.PublicDashboard{
+			TemplateVariables: publicdashboardModels.TemplateVariables{"host": {"a", "b"}},
+		}}
+
+		require.NoError(t, ValidateSavePublicDashboard(dto, dashboard))
+	})
+
+	t.Run("Returns validation error when a variable has no allowed values", func(t *testing.T) {
+		dto := &publicdashboardModels.SavePublicDashboardConfigDTO{PublicDashboard: &publicdashboardModels.PublicDashboard{
+			TemplateVariables: publicdashboardModels.TemplateVariables{"host": {}},
+		}}
+
+		require.ErrorIs(t, ValidateSavePublicDashboard(dto, dashboard), publicdashboardModels.ErrPublicDashboardHasTemplateVariables)
+	})
+
+	t.Run("Returns validation error when an allowed variable is not on the dashboard", func(t *testing.T) {
+		dto := &publicdashboardModels.SavePublicDashboardConfigDTO{PublicDashboard: &publicdashboardModels.PublicDashboard{
+			TemplateVariables: publicdashboardModels.TemplateVariables{"host": {"a"}, "region": {"eu"}},
+		}}
+
+		require.ErrorIs(t, ValidateSavePublicDashboard(dto, dashboard), publicdashboardModels.ErrPublicDashboardInvalidTemplateVariables)
+	})
+
+	t.Run("Returns validation error when dashboard has ad hoc filters", func(t *testing.T) {
+		adhocData, _ := simplejson.NewJson([]byte(`{"templating": {"list": [{"name": "filters", "type": "adhoc"}]}}`))
+		dto := &publicdashboardModels.SavePublicDashboardConfigDTO{PublicDashboard: &publicdashboardModels.PublicDashboard{
+			TemplateVariables: publicdashboardModels.TemplateVariables{"filters": {"a"}},
+		}}
+
+		require.ErrorIs(t, ValidateSavePublicDashboard(dto, models.NewDashboardFromJson(adhocData)), publicdashboardModels.ErrPublicDashboardUnsupportedTemplateVariable)
+	})
+
+	t.Run("Returns validation error when time selection limits are invalid", func(t *testing.T) {
+		dto := &publicdashboardModels.SavePublicDashboardConfigDTO{PublicDashboard: &publicdashboardModels.PublicDashboard{
+			TimeSettings:      &publicdashboardModels.TimeSettings{MaxRange: "a week"},
+			TemplateVariables: publicdashboardModels.TemplateVariables{"host": {"a"}},
+		}}
+
+		require.ErrorIs(t, ValidateSavePublicDashboard(dto, dashboard), publicdashboardModels.ErrPublicDashboardInvalidTimeSettings)
+	})
+
+	t.Run("Returns validation error when time selection is enabled without a max range", func(t *testing.T) {
+		for _, ts := range []*publicdashboardModels.TimeSettings{nil, {MaxLookback: "30d"}} {
+			dto := &publicdashboardModels.SavePublicDashboardConfigDTO{PublicDashboard: &publicdashboardModels.PublicDashboard{
+				TimeSelectionEnabled: true,
+				TimeSettings:         ts,
+				TemplateVariables:    publicdashboardModels.TemplateVariables{"host": {"a"}},
+			}}
+
+			require.ErrorIs(t, ValidateSavePublicDashboard(dto, dashboard), publicdashboardModels.ErrPublicDashboardInvalidTimeSettings)
+		}
+	})
+}
+
+func TestValidateQueryPublicDashboardRequest(t *testing.T) {
+	pubdash := &publicdashboardModels.PublicDashboard{
+		TemplateVariables: publicdashboardModels.TemplateVariables{"host": {"a", "b"}},
+	}
+
+	t.Run("Returns no error for allowed variable values", func(t *testing.T) {
+		req := publicdashboardModels.PublicDashboardQueryDTO{Variables: map[string]string{"host": "b"}}
+		require.NoError(t, ValidateQueryPublicDashboardRequest(req, pubdash))
+	})
+
+	t.Run("Returns error for values that are not allowed", func(t *testing.T) {
+		req := publicdashboardModels.PublicDashboardQueryDTO{Variables: map[string]string{"host": "c"}}
+		require.Error(t, ValidateQueryPublicDashboardRequest(req, pubdash))
+	})
+
+	t.Run("Returns error for variables that are not allowed", func(t *testing.T) {
+		req := publicdashboardModels.PublicDashboardQueryDTO{Variables: map[string]string{"region": "a"}}
+		require.Error(t, ValidateQueryPublicDashboardRequest(req, pubdash))
+	})
+
+	t.Run("Returns error for a time range when time selection is disabled", func(t *testing.T) {
+		req := publicdashboardModels.PublicDashboardQueryDTO{TimeRange: &publicdashboardModels.TimeRange{From: "now-1h", To: "now"}}
+		require.Error(t, ValidateQueryPublicDashboardRequest(req, pubdash))
+	})
+}
+
+func TestValidateTimeRange(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	pubdash := &publicdashboardModels.PublicDashboard{
+		TimeSelectionEnabled: true,
+		TimeSettings:         &publicdashboardModels.TimeSettings{MaxRange: "1d", MaxLookback: "7d"},
+	}
+
+	testCases := []struct {
+		name      string
+		timeRange publicdashboardModels.TimeRange
+		valid     bool
+	}{
+		{name: "relative range within limits", timeRange: publicdashboardModels.TimeRange{From: "now-6h", To: "now"}, valid: true},
+		{name: "epoch range within limits", timeRange: publicdashboardModels.TimeRange{
+			From: strconv.FormatInt(now.Add(-48*time.Hour).UnixMilli(), 10),
+			To:   strconv.FormatInt(now.Add(-36*time.Hour).UnixMilli(), 10),
+		}, valid: true},
+		{name: "range longer than max range", timeRange: publicdashboardModels.TimeRange{From: "now-2d", To: "now"}},
+		{name: "range starting before max lookback", timeRange: publicdashboardModels.TimeRange{From: "now-8d", To: "now-7d-12h"}},
+		{name: "from after to", timeRange: publicdashboardModels.TimeRange{From: "now", To: "now-1h"}},
+		{name: "unparsable time", timeRange: publicdashboardModels.TimeRange{From: "yesterday", To: "now"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateTimeRange(test.timeRange, pubdash, now)
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+
+	t.Run("applies the default max range when none is set", func(t *testing.T) {
+		for _, ts := range []*publicdashboardModels.TimeSettings{nil, {MaxLookback: "30d"}} {
+			pubdash := &publicdashboardModels.PublicDashboard{TimeSelectionEnabled: true, TimeSettings: ts}
+
+			require.NoError(t, ValidateTimeRange(publicdashboardModels.TimeRange{From: "now-6h", To: "now"}, pubdash, now))
+			require.Error(t, ValidateTimeRange(publicdashboardModels.TimeRange{From: "now-1y", To: "now"}, pubdash, now))
+		}
+	})
+}
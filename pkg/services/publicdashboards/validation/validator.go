@@ -2,26 +2,90 @@ package validation
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	publicDashboardModels "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 )
 
+// DefaultMaxRange limits the time range viewers can select on public
+// dashboards saved without a max range
+const DefaultMaxRange = "1d"
+
 func ValidateSavePublicDashboard(dto *publicDashboardModels.SavePublicDashboardConfigDTO, dashboard *models.Dashboard) error {
-	if hasTemplateVariables(dashboard) {
-		return publicDashboardModels.ErrPublicDashboardHasTemplateVariables
+	var allowed publicDashboardModels.TemplateVariables
+	if dto.PublicDashboard != nil {
+		allowed = dto.PublicDashboard.TemplateVariables
+
+		if err := validateTimeSettings(dto.PublicDashboard.TimeSelectionEnabled, dto.PublicDashboard.TimeSettings); err != nil {
+			return err
+		}
+	}
+
+	return validateTemplateVariables(dashboard, allowed)
+}
+
+// validateTemplateVariables checks that every template variable a viewer can
+// change has at least one allowed value, and that only variables present on
+// the dashboard are allow-listed
+func validateTemplateVariables(dashboard *models.Dashboard, allowed publicDashboardModels.TemplateVariables) error {
+	names := make(map[string]bool)
+
+	for _, variableObj := range dashboard.Data.Get("templating").Get("list").MustArray() {
+		variable := simplejson.NewFromAny(variableObj)
+		name := variable.Get("name").MustString()
+		names[name] = true
+
+		switch variable.Get("type").MustString() {
+		case "constant":
+			continue
+		case "adhoc", "datasource":
+			return publicDashboardModels.ErrPublicDashboardUnsupportedTemplateVariable
+		}
+
+		if len(allowed[name]) == 0 {
+			return publicDashboardModels.ErrPublicDashboardHasTemplateVariables
+		}
+	}
+
+	for name, values := range allowed {
+		if !names[name] || len(values) == 0 {
+			return publicDashboardModels.ErrPublicDashboardInvalidTemplateVariables
+		}
 	}
 
 	return nil
 }
 
-func hasTemplateVariables(dashboard *models.Dashboard) bool {
-	templateVariables := dashboard.Data.Get("templating").Get("list").MustArray()
+// validateTimeSettings checks the limits of the time range viewers can select,
+// a max range is required when time selection is enabled
+func validateTimeSettings(timeSelectionEnabled bool, ts *publicDashboardModels.TimeSettings) error {
+	if ts == nil {
+		if timeSelectionEnabled {
+			return publicDashboardModels.ErrPublicDashboardInvalidTimeSettings
+		}
+		return nil
+	}
+	if timeSelectionEnabled && ts.MaxRange == "" {
+		return publicDashboardModels.ErrPublicDashboardInvalidTimeSettings
+	}
+
+	for _, limit := range []string{ts.MaxRange, ts.MaxLookback} {
+		if limit == "" {
+			continue
+		}
+		if d, err := gtime.ParseDuration(limit); err != nil || d <= 0 {
+			return publicDashboardModels.ErrPublicDashboardInvalidTimeSettings
+		}
+	}
 
-	return len(templateVariables) > 0
+	return nil
 }
 
-func ValidateQueryPublicDashboardRequest(req publicDashboardModels.PublicDashboardQueryDTO) error {
+func ValidateQueryPublicDashboardRequest(req publicDashboardModels.PublicDashboardQueryDTO, publicDashboard *publicDashboardModels.PublicDashboard) error {
 	if req.IntervalMs < 0 {
 		return fmt.Errorf("intervalMS should be greater than 0")
 	}
@@ -30,5 +94,69 @@ func ValidateQueryPublicDashboardRequest(req publicDashboardModels.PublicDashboa
 		return fmt.Errorf("maxDataPoints should be greater than 0")
 	}
 
+	if req.TimeRange != nil {
+		if err := ValidateTimeRange(*req.TimeRange, publicDashboard, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range req.Variables {
+		if !publicDashboard.TemplateVariables.IsAllowed(name, value) {
+			return fmt.Errorf("value of template variable %q is not allowed", name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTimeRange checks a viewer selected time range against the limits
+// set on the public dashboard. DefaultMaxRange applies when no max range is
+// set.
+func ValidateTimeRange(tr publicDashboardModels.TimeRange, publicDashboard *publicDashboardModels.PublicDashboard, now time.Time) error {
+	if !publicDashboard.TimeSelectionEnabled {
+		return fmt.Errorf("time range selection is not enabled")
+	}
+
+	timeRange := legacydata.DataTimeRange{From: tr.From, To: tr.To, Now: now}
+	from, err := timeRange.ParseFrom()
+	if err != nil {
+		return fmt.Errorf("invalid from: %w", err)
+	}
+	to, err := timeRange.ParseTo()
+	if err != nil {
+		return fmt.Errorf("invalid to: %w", err)
+	}
+
+	if !from.Before(to) {
+		return fmt.Errorf("from should be before to")
+	}
+
+	ts := publicDashboard.TimeSettings
+	if ts == nil {
+		ts = &publicDashboardModels.TimeSettings{}
+	}
+
+	maxRangeSetting := ts.MaxRange
+	if maxRangeSetting == "" {
+		maxRangeSetting = DefaultMaxRange
+	}
+	maxRange, err := gtime.ParseDuration(maxRangeSetting)
+	if err != nil {
+		return err
+	}
+	if to.Sub(from) > maxRange {
+		return fmt.Errorf("time range should not be longer than %s", maxRangeSetting)
+	}
+
+	if ts.MaxLookback != "" {
+		maxLookback, err := gtime.ParseDuration(ts.MaxLookback)
+		if err != nil {
+			return err
+		}
+		if from.Before(now.Add(-maxLookback)) {
+			return fmt.Errorf("time range should not start more than %s ago", ts.MaxLookback)
+		}
+	}
+
 	return nil
 }
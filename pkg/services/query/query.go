@@ -2,6 +2,7 @@ package query
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -14,7 +15,9 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	publicDashboards "github.com/grafana/grafana/pkg/services/publicdashboards/queries"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -23,6 +26,7 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
@@ -36,6 +40,7 @@ func ProvideService(
 	dataSourceService datasources.DataSourceService,
 	pluginClient plugins.Client,
 	oAuthTokenService oauthtoken.OAuthTokenService,
+	dashboardService dashboards.DashboardService,
 ) *Service {
 	g := &Service{
 		cfg:                    cfg,
@@ -45,6 +50,7 @@ func ProvideService(
 		dataSourceService:      dataSourceService,
 		pluginClient:           pluginClient,
 		oAuthTokenService:      oAuthTokenService,
+		dashboardService:       dashboardService,
 		log:                    log.New("query_data"),
 	}
 	g.log.Info("Query Service initialization")
@@ -59,6 +65,7 @@ type Service struct {
 	dataSourceService      datasources.DataSourceService
 	pluginClient           plugins.Client
 	oAuthTokenService      oauthtoken.OAuthTokenService
+	dashboardService       dashboards.DashboardService
 	log                    log.Logger
 }
 
@@ -225,10 +232,24 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *user.SignedInUse
 		parsedQueries: []parsedQuery{},
 	}
 
+	variables := reqDTO.Variables
+	if len(variables) == 0 && reqDTO.DashboardUID != "" {
+		var err error
+		if variables, err = s.getDashboardVariables(ctx, user, reqDTO.DashboardUID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Parse the queries
 	datasourcesByUid := map[string]*datasources.DataSource{}
 	for _, query := range reqDTO.Queries {
-		ds, err := s.getDataSourceFromQuery(ctx, user, skipCache, query, datasourcesByUid)
+		dsQuery := query
+		if len(variables) > 0 {
+			// Interpolate the data source before resolving it, so that data source variables are supported too.
+			dsQuery = interpolateDataSource(query, variables)
+		}
+
+		ds, err := s.getDataSourceFromQuery(ctx, user, skipCache, dsQuery, datasourcesByUid)
 		if err != nil {
 			return nil, err
 		}
@@ -236,6 +257,18 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *user.SignedInUse
 			return nil, ErrInvalidDatasourceID
 		}
 
+		if len(variables) > 0 {
+			if requiresParameterization(ds) {
+				// The data source binds the variables as query parameters itself, so it gets the query as it was
+				// written.
+				query = simplejson.NewFromAny(dsQuery.Interface())
+				query.Set("variables", variables)
+			} else {
+				// Variables without an explicit format are formatted the way the frontend of the data source does.
+				query = simplejson.NewFromAny(templatevars.InterpolateValue(dsQuery.Interface(), variables, templatevars.DataSourceFormat(ds.Type)))
+			}
+		}
+
 		datasourcesByUid[ds.Uid] = ds
 		if expr.IsDataSource(ds.Uid) {
 			req.hasExpression = true
@@ -278,6 +311,50 @@ func (s *Service) parseMetricRequest(ctx context.Context, user *user.SignedInUse
 	return req, nil
 }
 
+// getDashboardVariables returns the saved template variables of the dashboard, for requests that reference a
+// dashboard without sending the current variable values. Unknown dashboards have no variables, and requests without a
+// user, which can't be checked for access to the dashboard, don't use its variables.
+func (s *Service) getDashboardVariables(ctx context.Context, user *user.SignedInUser, dashboardUID string) (map[string]*templatevars.Variable, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	query := &models.GetDashboardQuery{Uid: dashboardUID, OrgId: user.OrgID}
+	if err := s.dashboardService.GetDashboard(ctx, query); err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	canView, err := guardian.New(ctx, query.Result.Id, user.OrgID, user).CanView()
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrDashboardAccessDenied
+	}
+	return templatevars.FromDashboard(query.Result.Data), nil
+}
+
+// interpolateDataSource returns a copy of the query with the variables in its data source reference interpolated.
+func interpolateDataSource(query *simplejson.Json, variables map[string]*templatevars.Variable) *simplejson.Json {
+	copied := make(map[string]interface{})
+	for k, v := range query.MustMap() {
+		copied[k] = v
+	}
+	if ds, ok := copied["datasource"]; ok {
+		copied["datasource"] = templatevars.InterpolateValue(ds, variables, "")
+	}
+	return simplejson.NewFromAny(copied)
+}
+
+// requiresParameterization returns whether the data source must receive template variables separately from the
+// query, instead of having them interpolated into it.
+func requiresParameterization(ds *datasources.DataSource) bool {
+	return ds.JsonData != nil && ds.JsonData.Get("requireParameterization").MustBool()
+}
+
 func (s *Service) getDataSourceFromQuery(ctx context.Context, user *user.SignedInUser, skipCache bool, query *simplejson.Json, history map[string]*datasources.DataSource) (*datasources.DataSource, error) {
 	var err error
 	uid := query.Get("datasource").Get("uid").MustString()
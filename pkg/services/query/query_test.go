@@ -8,24 +8,29 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/expr"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	dsSvc "github.com/grafana/grafana/pkg/services/datasources/service"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
 	secretsmng "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 )
 
 func TestQueryDataMultipleSources(t *testing.T) {
@@ -172,6 +177,138 @@ func TestQueryData(t *testing.T) {
 
 		require.Equal(t, map[string]string{"Cookie": "bar=rab; foo=oof"}, tc.pluginContext.req.Headers)
 	})
+
+	t.Run("it interpolates template variables in the queries", func(t *testing.T) {
+		tc := setup(t)
+
+		q, err := simplejson.NewJson([]byte(`{"datasourceId": 1, "expr": "up{host=~\"${host:regex}\", env=\"$env\"} $__interval"}`))
+		require.NoError(t, err)
+		metricReq := dtos.MetricRequest{
+			Queries: []*simplejson.Json{q},
+			Variables: map[string]*templatevars.Variable{
+				"host": {Value: []string{"web01", "web02"}, Multi: true},
+				"env":  {Value: []string{"prod"}},
+			},
+		}
+		_, err = tc.queryService.QueryData(context.Background(), nil, true, metricReq, false)
+		require.NoError(t, err)
+
+		model, err := simplejson.NewJson(tc.pluginContext.req.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, `up{host=~"(web01|web02)", env="prod"} $__interval`, model.Get("expr").MustString())
+	})
+
+	t.Run("it formats template variables the way the data source does by default", func(t *testing.T) {
+		tc := setup(t)
+		tc.dataSourceCache.ds.Type = "mysql"
+
+		q, err := simplejson.NewJson([]byte(`{"datasourceId": 1, "rawSql": "SELECT * FROM hosts WHERE env = '$env' AND host IN ($host)"}`))
+		require.NoError(t, err)
+		metricReq := dtos.MetricRequest{
+			Queries: []*simplejson.Json{q},
+			Variables: map[string]*templatevars.Variable{
+				"host": {Value: []string{"web01", "web02"}, Multi: true},
+				"env":  {Value: []string{"prod"}},
+			},
+		}
+		_, err = tc.queryService.QueryData(context.Background(), nil, true, metricReq, false)
+		require.NoError(t, err)
+
+		model, err := simplejson.NewJson(tc.pluginContext.req.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM hosts WHERE env = 'prod' AND host IN ('web01','web02')", model.Get("rawSql").MustString())
+	})
+
+	t.Run("it interpolates the variables saved with the dashboard when none are sent", func(t *testing.T) {
+		tc := setup(t)
+		mockDashboardGuardian(t, true)
+
+		dashboardData, err := simplejson.NewJson([]byte(`{"templating": {"list": [{"name": "env", "type": "custom", "current": {"value": "prod"}}]}}`))
+		require.NoError(t, err)
+		tc.dashboardService.On("GetDashboard", mock.Anything, mock.MatchedBy(func(q *models.GetDashboardQuery) bool {
+			return q.Uid == "dash" && q.OrgId == 1
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.GetDashboardQuery).Result = &models.Dashboard{Data: dashboardData}
+		}).Return(nil)
+
+		q, err := simplejson.NewJson([]byte(`{"datasourceId": 1, "expr": "up{env=\"$env\"}"}`))
+		require.NoError(t, err)
+		metricReq := dtos.MetricRequest{
+			Queries:      []*simplejson.Json{q},
+			DashboardUID: "dash",
+		}
+		_, err = tc.queryService.QueryData(context.Background(), &user.SignedInUser{OrgID: 1}, true, metricReq, false)
+		require.NoError(t, err)
+
+		model, err := simplejson.NewJson(tc.pluginContext.req.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, `up{env="prod"}`, model.Get("expr").MustString())
+	})
+
+	t.Run("it doesn't use the variables of a dashboard the user can't view", func(t *testing.T) {
+		tc := setup(t)
+		mockDashboardGuardian(t, false)
+
+		dashboardData, err := simplejson.NewJson([]byte(`{"templating": {"list": [{"name": "env", "type": "custom", "current": {"value": "prod"}}]}}`))
+		require.NoError(t, err)
+		tc.dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.GetDashboardQuery).Result = &models.Dashboard{Id: 2, Data: dashboardData}
+		}).Return(nil)
+
+		q, err := simplejson.NewJson([]byte(`{"datasourceId": 1, "expr": "up{env=\"$env\"}"}`))
+		require.NoError(t, err)
+		metricReq := dtos.MetricRequest{
+			Queries:      []*simplejson.Json{q},
+			DashboardUID: "dash",
+		}
+		_, err = tc.queryService.QueryData(context.Background(), &user.SignedInUser{OrgID: 1}, true, metricReq, false)
+		require.ErrorIs(t, err, query.ErrDashboardAccessDenied)
+	})
+
+	t.Run("it ignores unknown dashboards", func(t *testing.T) {
+		tc := setup(t)
+		tc.dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(dashboards.ErrDashboardNotFound)
+
+		q, err := simplejson.NewJson([]byte(`{"datasourceId": 1, "expr": "up{env=\"$env\"}"}`))
+		require.NoError(t, err)
+		metricReq := dtos.MetricRequest{
+			Queries:      []*simplejson.Json{q},
+			DashboardUID: "unknown",
+		}
+		_, err = tc.queryService.QueryData(context.Background(), &user.SignedInUser{OrgID: 1}, true, metricReq, false)
+		require.NoError(t, err)
+
+		model, err := simplejson.NewJson(tc.pluginContext.req.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, `up{env="$env"}`, model.Get("expr").MustString())
+	})
+
+	t.Run("it passes template variables separately to data sources requiring parameterization", func(t *testing.T) {
+		tc := setup(t)
+		tc.dataSourceCache.ds.JsonData = simplejson.NewFromAny(map[string]interface{}{"requireParameterization": true})
+
+		q, err := simplejson.NewJson([]byte(`{"datasourceId": 1, "rawSql": "SELECT * FROM t WHERE env = '$env'"}`))
+		require.NoError(t, err)
+		metricReq := dtos.MetricRequest{
+			Queries: []*simplejson.Json{q},
+			Variables: map[string]*templatevars.Variable{
+				"env": {Value: []string{"prod"}},
+			},
+		}
+		_, err = tc.queryService.QueryData(context.Background(), nil, true, metricReq, false)
+		require.NoError(t, err)
+
+		model, err := simplejson.NewJson(tc.pluginContext.req.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM t WHERE env = '$env'", model.Get("rawSql").MustString())
+		require.Equal(t, "prod", model.GetPath("variables", "env", "value").MustString())
+	})
+}
+
+func mockDashboardGuardian(t *testing.T, canView bool) {
+	origNew := guardian.New
+	t.Cleanup(func() { guardian.New = origNew })
+	guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: canView})
 }
 
 func setup(t *testing.T) *testContext {
@@ -179,6 +316,7 @@ func setup(t *testing.T) *testContext {
 	dc := &fakeDataSourceCache{ds: &datasources.DataSource{}}
 	tc := &fakeOAuthTokenService{}
 	rv := &fakePluginRequestValidator{}
+	dashboardService := dashboards.NewFakeDashboardService(t)
 
 	sqlStore := sqlstore.InitTestDB(t)
 	secretsService := secretsmng.SetupTestService(t, fakes.NewFakeSecretsStore())
@@ -197,7 +335,8 @@ func setup(t *testing.T) *testContext {
 		dataSourceCache:        dc,
 		oauthTokenService:      tc,
 		pluginRequestValidator: rv,
-		queryService:           query.ProvideService(nil, dc, exprService, rv, ds, pc, tc),
+		dashboardService:       dashboardService,
+		queryService:           query.ProvideService(nil, dc, exprService, rv, ds, pc, tc, dashboardService),
 	}
 }
 
@@ -207,6 +346,7 @@ type testContext struct {
 	dataSourceCache        *fakeDataSourceCache
 	oauthTokenService      *fakeOAuthTokenService
 	pluginRequestValidator *fakePluginRequestValidator
+	dashboardService       *dashboards.FakeDashboardService
 	queryService           *query.Service
 }
 
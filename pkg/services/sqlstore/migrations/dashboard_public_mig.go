@@ -65,4 +65,13 @@ func addPublicDashboardMigration(mg *Migrator) {
 
 	// rename table
 	addTableRenameMigration(mg, "dashboard_public_config", "dashboard_public", "v2")
+
+	// viewer time range selection and annotations
+	dashboardPublic := Table{Name: "dashboard_public"}
+	mg.AddMigration("Add time_selection_enabled column to dashboard_public", NewAddColumnMigration(dashboardPublic, &Column{
+		Name: "time_selection_enabled", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("Add annotations_enabled column to dashboard_public", NewAddColumnMigration(dashboardPublic, &Column{
+		Name: "annotations_enabled", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
 }
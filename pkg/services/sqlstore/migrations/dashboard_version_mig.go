@@ -57,3 +57,43 @@ FROM dashboard;`
 	mg.AddMigration("alter dashboard_version.data to mediumtext v1", NewRawSQLMigration("").
 		Mysql("ALTER TABLE dashboard_version MODIFY data MEDIUMTEXT;"))
 }
+
+func addDashboardVersionTagMigrations(mg *Migrator) {
+	dashboardVersionTagV1 := Table{
+		Name: "dashboard_version_tag",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "tag", Type: DB_NVarchar, Length: 100, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "tag"}, Type: UniqueIndex},
+			{Cols: []string{"dashboard_id", "version"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_version_tag table v1", NewAddTableMigration(dashboardVersionTagV1))
+	addTableIndicesMigrations(mg, "v1", dashboardVersionTagV1)
+
+	dashboardVersionRetentionV1 := Table{
+		Name: "dashboard_version_retention",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "versions_to_keep", Type: DB_Int, Nullable: false},
+			{Name: "max_age", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "folder_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create dashboard_version_retention table v1", NewAddTableMigration(dashboardVersionRetentionV1))
+	addTableIndicesMigrations(mg, "v1", dashboardVersionRetentionV1)
+}
@@ -58,4 +58,37 @@ func addLibraryElementsMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("increase max description length to 2048", migrator.NewTableCharsetMigration("library_element", []*migrator.Column{
 		{Name: "description", Type: migrator.DB_NVarchar, Length: 2048, Nullable: false},
 	}))
+
+	libraryElementVersionV1 := migrator.Table{
+		Name: "library_element_version",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "element_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 150, Nullable: false},
+			{Name: "model", Type: migrator.DB_Text, Nullable: false},
+			{Name: "message", Type: migrator.DB_NVarchar, Length: 255, Nullable: false, Default: "''"},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"element_id", "version"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create library_element_version table v1", migrator.NewAddTableMigration(libraryElementVersionV1))
+	mg.AddMigration("add unique index library_element_version element_id-version", migrator.NewAddIndexMigration(libraryElementVersionV1, libraryElementVersionV1.Indices[0]))
+	mg.AddMigration("copy library_element to library_element_version", migrator.NewCopyTableDataMigration("library_element_version", "library_element", map[string]string{
+		"element_id": "id",
+		"version":    "version",
+		"name":       "name",
+		"model":      "model",
+		"created":    "updated",
+		"created_by": "updated_by",
+	}))
+
+	// a version of 0 connects the dashboard to the latest version of the element
+	mg.AddMigration("add version column to "+models.LibraryElementConnectionTableName, migrator.NewAddColumnMigration(libraryElementConnectionV1, &migrator.Column{
+		Name: "version", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
 }
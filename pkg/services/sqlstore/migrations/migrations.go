@@ -98,6 +98,9 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	ualert.UpdateRuleGroupIndexMigration(mg)
 	accesscontrol.AddManagedFolderAlertActionsRepeatMigration(mg)
 	accesscontrol.AddAdminOnlyMigration(mg)
+
+	addLivePipelineMigrations(mg)
+	addDashboardVersionTagMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
@@ -214,6 +214,8 @@ func (ss *SQLStore) DeleteOrg(ctx context.Context, cmd *models.DeleteOrgCommand)
 			"DELETE FROM alert WHERE org_id = ?",
 			"DELETE FROM annotation WHERE org_id = ?",
 			"DELETE FROM kv_store WHERE org_id = ?",
+			"DELETE FROM dashboard_version_tag WHERE org_id = ?",
+			"DELETE FROM dashboard_version_retention WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -275,6 +275,23 @@ type Cfg struct {
 	PluginCatalogHiddenPlugins       []string
 	PluginAdminEnabled               bool
 	PluginAdminExternalManageEnabled bool
+	// PluginRepositoryURL is the grafana.com plugins API or the index.json
+	// of a static mirror plugins are installed from.
+	PluginRepositoryURL           string
+	PluginRepositorySkipTLSVerify bool
+	// PluginsProcessMaxMemoryMB and PluginsProcessMaxCPU limit resources of
+	// each backend plugin process, 0 means no limit.
+	PluginsProcessMaxMemoryMB int
+	PluginsProcessMaxCPU      float64
+	// PluginsProcessMaxRestartBackoff is the longest delay before restarting a
+	// crashing backend plugin process.
+	PluginsProcessMaxRestartBackoff time.Duration
+	// PluginsHotReload enables reloading external plugins when their files
+	// change, they are looked for every PluginsHotReloadInterval.
+	PluginsHotReload         bool
+	PluginsHotReloadInterval time.Duration
+	// PluginClient configures middlewares of requests to backend plugins.
+	PluginClient PluginClientCfg
 
 	// Panels
 	DisableSanitizeHtml bool
@@ -384,6 +401,8 @@ type Cfg struct {
 	AlertingAnnotationCleanupSetting   AnnotationCleanupSettings
 	DashboardAnnotationCleanupSettings AnnotationCleanupSettings
 	APIAnnotationCleanupSettings       AnnotationCleanupSettings
+	AlertAnnotationsBackend            string
+	AnnotationsLoki                    AnnotationsLokiSettings
 
 	// Sentry config
 	Sentry Sentry
@@ -444,6 +463,32 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveHistoryMaxFrames is a maximum number of frames kept in the history
+	// of each managed stream channel. 0 means no limit.
+	LiveHistoryMaxFrames int
+	// LiveHistoryMaxAge is a maximum age of frames kept in the history of each
+	// managed stream channel. 0 means no limit. History is disabled when both
+	// limits are 0.
+	LiveHistoryMaxAge time.Duration
+	// LivePushOrgMaxMessagesPerSecond, LivePushOrgMaxBytesPerSecond,
+	// LivePushChannelMaxMessagesPerSecond and LivePushChannelMaxBytesPerSecond
+	// limit the rate of messages pushed into Live over HTTP and WebSocket
+	// (per Grafana server instance). 0 means no limit.
+	LivePushOrgMaxMessagesPerSecond     float64
+	LivePushOrgMaxBytesPerSecond        float64
+	LivePushChannelMaxMessagesPerSecond float64
+	LivePushChannelMaxBytesPerSecond    float64
+	// LiveManagedStreamMaxPublishRate is a maximum number of frames per second
+	// published into each managed stream channel, frames pushed faster are
+	// coalesced. 0 means every frame is published.
+	LiveManagedStreamMaxPublishRate float64
+	// LiveClientQueueMaxSize is a maximum size in bytes of messages queued for
+	// a client, slow clients exceeding it are disconnected. 0 means the
+	// default of the Live server.
+	LiveClientQueueMaxSize int
+	// LiveMQTTSubscriptions are MQTT topics feeding Live channels, configured
+	// in [live.mqtt.<name>] sections.
+	LiveMQTTSubscriptions []LiveMQTTSubscription
 
 	// Grafana.com URL
 	GrafanaComURL string
@@ -464,6 +509,10 @@ type Cfg struct {
 
 	Search SearchSettings
 
+	PublicDashboards PublicDashboardsSettings
+
+	DashboardLint DashboardLintSettings
+
 	// Access Control
 	RBACEnabled         bool
 	RBACPermissionCache bool
@@ -627,6 +676,20 @@ func (cfg *Cfg) readAnnotationSettings() error {
 		cfg.AnnotationMaximumTagsLength = 500
 	}
 
+	cfg.AlertAnnotationsBackend = section.Key("alert_annotations_backend").In(AnnotationsBackendSQL, []string{AnnotationsBackendSQL, AnnotationsBackendLoki})
+
+	lokiSection := cfg.Raw.Section("annotations.loki")
+	cfg.AnnotationsLoki = AnnotationsLokiSettings{
+		URL:               strings.TrimSuffix(lokiSection.Key("url").MustString(""), "/"),
+		TenantID:          lokiSection.Key("tenant_id").MustString(""),
+		BasicAuthUser:     lokiSection.Key("basic_auth_user").MustString(""),
+		BasicAuthPassword: lokiSection.Key("basic_auth_password").MustString(""),
+		Timeout:           lokiSection.Key("timeout").MustDuration(30 * time.Second),
+	}
+	if cfg.AlertAnnotationsBackend == AnnotationsBackendLoki && cfg.AnnotationsLoki.URL == "" {
+		return fmt.Errorf("[annotations.loki] url is required when alert annotations are stored in Loki")
+	}
+
 	dashboardAnnotation := cfg.Raw.Section("annotations.dashboard")
 	apiIAnnotation := cfg.Raw.Section("annotations.api")
 	alertingSection := cfg.Raw.Section("alerting")
@@ -660,6 +723,21 @@ type AnnotationCleanupSettings struct {
 	MaxCount int64
 }
 
+const (
+	AnnotationsBackendSQL  = "sql"
+	AnnotationsBackendLoki = "loki"
+)
+
+// AnnotationsLokiSettings configures the Loki instance annotations are
+// stored in when the Loki annotations backend is enabled.
+type AnnotationsLokiSettings struct {
+	URL               string
+	TenantID          string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	Timeout           time.Duration
+}
+
 func EnvKey(sectionName string, keyName string) string {
 	sN := strings.ToUpper(strings.ReplaceAll(sectionName, ".", "_"))
 	sN = strings.ReplaceAll(sN, "-", "_")
@@ -1029,6 +1107,10 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 		return err
 	}
 
+	if cfg.PluginClient, err = readPluginClientSettings(iniFile); err != nil {
+		return err
+	}
+
 	if err := cfg.readFeatureToggles(iniFile); err != nil {
 		return err
 	}
@@ -1062,6 +1144,12 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.DashboardPreviews = readDashboardPreviewsSettings(iniFile)
 	cfg.Storage = readStorageSettings(iniFile)
 	cfg.Search = readSearchSettings(iniFile)
+	if cfg.PublicDashboards, err = readPublicDashboardsSettings(iniFile); err != nil {
+		return err
+	}
+	if cfg.DashboardLint, err = readDashboardLintSettings(iniFile); err != nil {
+		return err
+	}
 
 	if VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		cfg.Logger.Warn("require_email_validation is enabled but smtp is disabled")
@@ -1705,5 +1793,37 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return err
 	}
 	cfg.LiveAllowedOrigins = originPatterns
+
+	cfg.LiveHistoryMaxFrames = section.Key("history_max_frames").MustInt(0)
+	if cfg.LiveHistoryMaxFrames < 0 {
+		return fmt.Errorf("unexpected value %d for [live] history_max_frames", cfg.LiveHistoryMaxFrames)
+	}
+	historyMaxAge := section.Key("history_max_age").MustString("0")
+	cfg.LiveHistoryMaxAge, err = gtime.ParseDuration(historyMaxAge)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for [live] history_max_age: %w", historyMaxAge, err)
+	}
+
+	for key, value := range map[string]*float64{
+		"push_org_max_messages_per_second":     &cfg.LivePushOrgMaxMessagesPerSecond,
+		"push_org_max_bytes_per_second":        &cfg.LivePushOrgMaxBytesPerSecond,
+		"push_channel_max_messages_per_second": &cfg.LivePushChannelMaxMessagesPerSecond,
+		"push_channel_max_bytes_per_second":    &cfg.LivePushChannelMaxBytesPerSecond,
+		"managed_stream_max_publish_rate":      &cfg.LiveManagedStreamMaxPublishRate,
+	} {
+		*value = section.Key(key).MustFloat64(0)
+		if *value < 0 {
+			return fmt.Errorf("unexpected value %v for [live] %s", *value, key)
+		}
+	}
+	cfg.LiveClientQueueMaxSize = section.Key("client_queue_max_size").MustInt(0)
+	if cfg.LiveClientQueueMaxSize < 0 {
+		return fmt.Errorf("unexpected value %d for [live] client_queue_max_size", cfg.LiveClientQueueMaxSize)
+	}
+
+	cfg.LiveMQTTSubscriptions, err = extractLiveMQTTSubscriptions(iniFile.Sections())
+	if err != nil {
+		return err
+	}
 	return nil
 }
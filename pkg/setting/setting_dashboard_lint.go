@@ -0,0 +1,51 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// DashboardLintRules are the IDs of the dashboard lint rules, which are also
+// the keys of their severity in the [dashboards.lint] section.
+var DashboardLintRules = []string{"datasource_uid", "hardcoded_org_id", "max_panels", "min_interval"}
+
+var defaultDashboardLintSeverities = map[string]string{
+	"datasource_uid":   "warning",
+	"hardcoded_org_id": "warning",
+	"max_panels":       "off",
+	"min_interval":     "off",
+}
+
+type DashboardLintSettings struct {
+	// Enabled lints dashboards saved through the API or provisioned, blocking
+	// the dashboards with errors.
+	Enabled bool
+	// Severities maps rule IDs to off, warning or error.
+	Severities map[string]string
+	// MaxPanels is the panel limit of the max_panels rule.
+	MaxPanels int
+	// MinInterval is the shortest min interval the min_interval rule allows.
+	// 0 only requires queries to have a min interval.
+	MinInterval time.Duration
+}
+
+func readDashboardLintSettings(iniFile *ini.File) (DashboardLintSettings, error) {
+	s := DashboardLintSettings{Severities: make(map[string]string, len(DashboardLintRules))}
+
+	section := iniFile.Section("dashboards.lint")
+	s.Enabled = section.Key("enabled").MustBool(false)
+	for _, rule := range DashboardLintRules {
+		severity := section.Key(rule).MustString(defaultDashboardLintSeverities[rule])
+		switch severity {
+		case "off", "warning", "error":
+		default:
+			return s, fmt.Errorf("[dashboards.lint] %s must be off, warning or error, got %q", rule, severity)
+		}
+		s.Severities[rule] = severity
+	}
+	s.MaxPanels = section.Key("max_panels_limit").MustInt(100)
+	s.MinInterval = section.Key("min_interval_limit").MustDuration(0)
+	return s, nil
+}
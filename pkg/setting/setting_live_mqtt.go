@@ -0,0 +1,71 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+const liveMQTTSectionPrefix = "live.mqtt."
+
+// LiveMQTTSubscription configures an MQTT topic subscription which feeds
+// received messages into a Grafana Live channel.
+type LiveMQTTSubscription struct {
+	// Name is the name of the [live.mqtt.<name>] section.
+	Name     string
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	// Topic is an MQTT topic filter, it may contain the + and # wildcards.
+	Topic string
+	QoS   byte
+	OrgID int64
+	// Channel is the Live channel messages are pushed into. ${topic} is
+	// replaced with the topic a message was received on.
+	Channel string
+	// Format of the message payloads, either "json" or "influx".
+	Format string
+}
+
+func extractLiveMQTTSubscriptions(sections []*ini.Section) ([]LiveMQTTSubscription, error) {
+	var subscriptions []LiveMQTTSubscription
+	for _, section := range sections {
+		if !strings.HasPrefix(section.Name(), liveMQTTSectionPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(section.Name(), liveMQTTSectionPrefix)
+
+		s := LiveMQTTSubscription{
+			Name:     name,
+			Broker:   section.Key("broker").MustString(""),
+			ClientID: section.Key("client_id").MustString("grafana-" + name),
+			Username: section.Key("username").MustString(""),
+			Password: section.Key("password").MustString(""),
+			Topic:    section.Key("topic").MustString(""),
+			OrgID:    section.Key("org_id").MustInt64(1),
+			Channel:  section.Key("channel").MustString(""),
+			Format:   section.Key("format").MustString("json"),
+		}
+		if s.Broker == "" || s.Topic == "" || s.Channel == "" {
+			return nil, fmt.Errorf("[%s] requires broker, topic and channel", section.Name())
+		}
+		if !strings.HasPrefix(s.Channel, "stream/") {
+			return nil, fmt.Errorf("[%s] channel must be in the stream scope, got %q", section.Name(), s.Channel)
+		}
+		switch s.Format {
+		case "json", "influx":
+		default:
+			return nil, fmt.Errorf("[%s] unsupported format: %s", section.Name(), s.Format)
+		}
+		qos := section.Key("qos").MustInt(0)
+		if qos < 0 || qos > 2 {
+			return nil, fmt.Errorf("[%s] unexpected value %d for qos", section.Name(), qos)
+		}
+		s.QoS = byte(qos)
+
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,74 @@
+package setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestLiveMQTTSubscriptions(t *testing.T) {
+	t.Run("reads subscriptions", func(t *testing.T) {
+		f, err := ini.Load([]byte(`
+[live]
+max_connections = 100
+
+[live.mqtt.sensors]
+broker = tcp://localhost:1883
+` + "topic = `sensors/#`" + `
+channel = stream/sensors/${topic}
+qos = 1
+
+[live.mqtt.telegraf]
+broker = ssl://broker:8883
+client_id = grafana
+username = user
+password = secret
+topic = telegraf
+org_id = 2
+channel = stream/telegraf/metrics
+format = influx
+`))
+		require.NoError(t, err)
+
+		subscriptions, err := extractLiveMQTTSubscriptions(f.Sections())
+		require.NoError(t, err)
+		require.Equal(t, []LiveMQTTSubscription{
+			{
+				Name:     "sensors",
+				Broker:   "tcp://localhost:1883",
+				ClientID: "grafana-sensors",
+				Topic:    "sensors/#",
+				QoS:      1,
+				OrgID:    1,
+				Channel:  "stream/sensors/${topic}",
+				Format:   "json",
+			},
+			{
+				Name:     "telegraf",
+				Broker:   "ssl://broker:8883",
+				ClientID: "grafana",
+				Username: "user",
+				Password: "secret",
+				Topic:    "telegraf",
+				OrgID:    2,
+				Channel:  "stream/telegraf/metrics",
+				Format:   "influx",
+			},
+		}, subscriptions)
+	})
+
+	t.Run("validates subscriptions", func(t *testing.T) {
+		for _, section := range []string{
+			"[live.mqtt.a]\ntopic = a\nchannel = stream/a/b",
+			"[live.mqtt.a]\nbroker = tcp://localhost:1883\ntopic = a\nchannel = plugin/a/b",
+			"[live.mqtt.a]\nbroker = tcp://localhost:1883\ntopic = a\nchannel = stream/a/b\nformat = xml",
+			"[live.mqtt.a]\nbroker = tcp://localhost:1883\ntopic = a\nchannel = stream/a/b\nqos = 3",
+		} {
+			f, err := ini.Load([]byte(section))
+			require.NoError(t, err)
+			_, err = extractLiveMQTTSubscriptions(f.Sections())
+			require.Error(t, err, section)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// DefaultPluginClientMiddlewares are the middlewares of requests to backend
+// plugins in the order they're applied, the first one is the outermost.
+var DefaultPluginClientMiddlewares = []string{"tracing", "forward-headers", "cache", "response-limit", "timeout"}
+
+// PluginClientSettings configures the middlewares of requests to backend
+// plugins.
+type PluginClientSettings struct {
+	// Middlewares are the names of the enabled middlewares in the order
+	// they're applied.
+	Middlewares []string
+	// Timeout of requests, 0 means no timeout. Data sources can override it
+	// with the pluginRequestTimeout in their JSON data.
+	Timeout time.Duration
+	// ResponseLimit is the maximum size of responses in bytes, 0 means
+	// unlimited.
+	ResponseLimit int64
+	// QueryCacheTTL is how long query responses are cached, 0 disables
+	// caching.
+	QueryCacheTTL time.Duration
+	// ForwardHeaders are the names of headers of the incoming HTTP request
+	// that are forwarded to plugins.
+	ForwardHeaders []string
+}
+
+// PluginClientCfg holds the plugin client settings of each plugin type.
+type PluginClientCfg struct {
+	Default PluginClientSettings
+	// ByType has the settings of plugin types overriding the defaults.
+	ByType map[string]PluginClientSettings
+}
+
+// ForType returns the plugin client settings of a plugin type.
+func (c PluginClientCfg) ForType(pluginType string) PluginClientSettings {
+	if s, ok := c.ByType[pluginType]; ok {
+		return s
+	}
+	return c.Default
+}
+
+func readPluginClientSettings(iniFile *ini.File) (PluginClientCfg, error) {
+	var cfg PluginClientCfg
+	var err error
+
+	cfg.Default, err = readPluginClientSection(iniFile.Section("plugin_client"), PluginClientSettings{
+		Middlewares: DefaultPluginClientMiddlewares,
+	})
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.ByType = map[string]PluginClientSettings{}
+	for _, section := range iniFile.Sections() {
+		pluginType := strings.TrimPrefix(section.Name(), "plugin_client.")
+		if pluginType == section.Name() || pluginType == "" {
+			continue
+		}
+		cfg.ByType[pluginType], err = readPluginClientSection(section, cfg.Default)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// readPluginClientSection reads the settings of a section, settings missing in
+// it are taken from defaults.
+func readPluginClientSection(section *ini.Section, defaults PluginClientSettings) (PluginClientSettings, error) {
+	s := defaults
+	if section.HasKey("middlewares") {
+		s.Middlewares = util.SplitString(section.Key("middlewares").String())
+	}
+	if section.HasKey("timeout") {
+		timeout, err := time.ParseDuration(section.Key("timeout").String())
+		if err != nil || timeout < 0 {
+			return s, fmt.Errorf("invalid value %q for [%s] timeout", section.Key("timeout").String(), section.Name())
+		}
+		s.Timeout = timeout
+	}
+	if section.HasKey("response_limit") {
+		limit, err := section.Key("response_limit").Int64()
+		if err != nil || limit < 0 {
+			return s, fmt.Errorf("invalid value %q for [%s] response_limit", section.Key("response_limit").String(), section.Name())
+		}
+		s.ResponseLimit = limit
+	}
+	if section.HasKey("query_cache_ttl") {
+		ttl, err := time.ParseDuration(section.Key("query_cache_ttl").String())
+		if err != nil || ttl < 0 {
+			return s, fmt.Errorf("invalid value %q for [%s] query_cache_ttl", section.Key("query_cache_ttl").String(), section.Name())
+		}
+		s.QueryCacheTTL = ttl
+	}
+	if section.HasKey("forward_headers") {
+		s.ForwardHeaders = util.SplitString(section.Key("forward_headers").String())
+	}
+	return s, nil
+}
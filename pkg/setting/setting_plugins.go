@@ -1,8 +1,10 @@
 package setting
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"gopkg.in/ini.v1"
 )
 
@@ -40,6 +42,28 @@ func (cfg *Cfg) readPluginSettings(iniFile *ini.File) error {
 	cfg.PluginCatalogURL = pluginsSection.Key("plugin_catalog_url").MustString("https://grafana.com/grafana/plugins/")
 	cfg.PluginAdminEnabled = pluginsSection.Key("plugin_admin_enabled").MustBool(true)
 	cfg.PluginAdminExternalManageEnabled = pluginsSection.Key("plugin_admin_external_manage_enabled").MustBool(false)
+	cfg.PluginRepositoryURL = pluginsSection.Key("plugin_repository_url").MustString("https://grafana.com/api/plugins")
+	cfg.PluginRepositorySkipTLSVerify = pluginsSection.Key("plugin_repository_tls_skip_verify_insecure").MustBool(false)
+	cfg.PluginsProcessMaxMemoryMB = pluginsSection.Key("backend_process_max_memory_mb").MustInt(0)
+	if cfg.PluginsProcessMaxMemoryMB < 0 {
+		return fmt.Errorf("unexpected value %d for [plugins] backend_process_max_memory_mb", cfg.PluginsProcessMaxMemoryMB)
+	}
+	cfg.PluginsProcessMaxCPU = pluginsSection.Key("backend_process_max_cpu").MustFloat64(0)
+	if cfg.PluginsProcessMaxCPU < 0 {
+		return fmt.Errorf("unexpected value %v for [plugins] backend_process_max_cpu", cfg.PluginsProcessMaxCPU)
+	}
+	maxRestartBackoff := pluginsSection.Key("backend_process_max_restart_backoff").MustString("5m")
+	var err error
+	cfg.PluginsProcessMaxRestartBackoff, err = gtime.ParseDuration(maxRestartBackoff)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for [plugins] backend_process_max_restart_backoff: %w", maxRestartBackoff, err)
+	}
+	cfg.PluginsHotReload = pluginsSection.Key("hot_reload").MustBool(false)
+	hotReloadInterval := pluginsSection.Key("hot_reload_interval").MustString("5s")
+	cfg.PluginsHotReloadInterval, err = gtime.ParseDuration(hotReloadInterval)
+	if err != nil || cfg.PluginsHotReloadInterval <= 0 {
+		return fmt.Errorf("invalid value %q for [plugins] hot_reload_interval", hotReloadInterval)
+	}
 	catalogHiddenPlugins := pluginsSection.Key("plugin_catalog_hidden_plugins").MustString("")
 
 	for _, plug := range strings.Split(catalogHiddenPlugins, ",") {
@@ -2,8 +2,10 @@ package setting
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
 )
 
 func TestPluginSettings(t *testing.T) {
@@ -41,3 +43,61 @@ func TestPluginSettings(t *testing.T) {
 	require.Equal(t, ps["plugin2"]["key3"], "value3")
 	require.Equal(t, ps["plugin2"]["key4"], "value4")
 }
+
+func TestPluginClientSettings(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := readPluginClientSettings(ini.Empty())
+		require.NoError(t, err)
+		require.Equal(t, PluginClientSettings{Middlewares: DefaultPluginClientMiddlewares}, cfg.ForType("datasource"))
+	})
+
+	t.Run("Plugin type settings override defaults", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[plugin_client]
+timeout = 30s
+forward_headers = X-Tenant-Id
+
+[plugin_client.datasource]
+timeout = 1m
+query_cache_ttl = 10s
+response_limit = 1024
+
+[plugin_client.app]
+middlewares = tracing, timeout
+`))
+		require.NoError(t, err)
+
+		cfg, err := readPluginClientSettings(iniFile)
+		require.NoError(t, err)
+		require.Equal(t, PluginClientSettings{
+			Middlewares:    DefaultPluginClientMiddlewares,
+			Timeout:        30 * time.Second,
+			ForwardHeaders: []string{"X-Tenant-Id"},
+		}, cfg.ForType("panel"))
+		require.Equal(t, PluginClientSettings{
+			Middlewares:    DefaultPluginClientMiddlewares,
+			Timeout:        time.Minute,
+			QueryCacheTTL:  10 * time.Second,
+			ResponseLimit:  1024,
+			ForwardHeaders: []string{"X-Tenant-Id"},
+		}, cfg.ForType("datasource"))
+		require.Equal(t, PluginClientSettings{
+			Middlewares:    []string{"tracing", "timeout"},
+			Timeout:        30 * time.Second,
+			ForwardHeaders: []string{"X-Tenant-Id"},
+		}, cfg.ForType("app"))
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		for _, raw := range []string{
+			"[plugin_client]\ntimeout = soon",
+			"[plugin_client.datasource]\nresponse_limit = -1",
+			"[plugin_client]\nquery_cache_ttl = -1s",
+		} {
+			iniFile, err := ini.Load([]byte(raw))
+			require.NoError(t, err)
+			_, err = readPluginClientSettings(iniFile)
+			require.Error(t, err, raw)
+		}
+	})
+}
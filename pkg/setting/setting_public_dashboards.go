@@ -0,0 +1,53 @@
+package setting
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type PublicDashboardsSettings struct {
+	// DashboardMaxRequestsPerMinute limits query and annotation requests to
+	// each public dashboard. 0 means no limit.
+	DashboardMaxRequestsPerMinute int
+	// ClientMaxRequestsPerMinute limits query and annotation requests to
+	// public dashboards from each client IP. 0 means no limit.
+	ClientMaxRequestsPerMinute int
+	// TrustedProxies are the proxies whose X-Real-IP and X-Forwarded-For
+	// headers are used as client IP. Otherwise the client IP is the address
+	// of the connection.
+	TrustedProxies []*net.IPNet
+	// MinRefreshInterval is how long panel query results are cached and served
+	// to all viewers. 0 disables the cache.
+	MinRefreshInterval time.Duration
+}
+
+func readPublicDashboardsSettings(iniFile *ini.File) (PublicDashboardsSettings, error) {
+	s := PublicDashboardsSettings{}
+
+	section := iniFile.Section("public_dashboards")
+	s.DashboardMaxRequestsPerMinute = section.Key("dashboard_max_requests_per_minute").MustInt(0)
+	s.ClientMaxRequestsPerMinute = section.Key("client_max_requests_per_minute").MustInt(0)
+	for _, proxy := range util.SplitString(section.Key("trusted_proxies").MustString("")) {
+		cidr := proxy
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return s, fmt.Errorf("[public_dashboards] trusted_proxies must be IP addresses or CIDR ranges, got %q", proxy)
+		}
+		s.TrustedProxies = append(s.TrustedProxies, network)
+	}
+	s.MinRefreshInterval = section.Key("min_refresh_interval").MustDuration(10 * time.Second)
+	return s, nil
+}
@@ -6,6 +6,7 @@ import (
 	"hash/fnv"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
@@ -122,6 +123,15 @@ func adjustLogsFrame(frame *data.Frame, query *lokiQuery) error {
 
 	frame.Meta.ExecutedQueryString = "Expr: " + query.Expr
 
+	// loki does not tell us when the line limit cut the result short, we only know that
+	// we got as many lines as we asked for, so there may be more.
+	if query.MaxLines > 0 && frame.Rows() >= query.MaxLines {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The line limit of %d was reached, the result may be incomplete. Narrow the time range or increase the line limit to see all lines.", query.MaxLines),
+		})
+	}
+
 	// we need to send to the browser the nanosecond-precision timestamp too.
 	// usually timestamps become javascript-date-objects in the browser automatically, which only
 	// have millisecond-precision.
@@ -136,6 +146,164 @@ func adjustLogsFrame(frame *data.Frame, query *lokiQuery) error {
 	return nil
 }
 
+func isLogsFrame(frame *data.Frame) bool {
+	return len(frame.Fields) == 4 && frame.Fields[0].Type() == data.FieldTypeJSON
+}
+
+// mergeFrames merges the frames returned by the sub-queries of a split query.
+// metric frames of the same series are joined into one frame, the results have to be
+// in time order. rows with a timestamp that is not newer than the last row of the series are dropped,
+// these are the evaluations at the shared sub-range boundaries.
+// log frames are joined into one frame keeping the order of the results, and
+// log lines that were already returned by a previous sub-query are removed.
+// the query stats of all sub-queries are summed up and set on every merged frame.
+func mergeFrames(results []data.Frames) data.Frames {
+	merged := data.Frames{}
+	series := make(map[string]*data.Frame)
+	var logs *data.Frame
+	seenLines := make(map[string]struct{})
+	var stats map[string]interface{}
+
+	for _, frames := range results {
+		if s := responseStats(frames); s != nil {
+			if stats == nil {
+				stats = make(map[string]interface{})
+			}
+			addStats(stats, s)
+		}
+
+		currentLines := make(map[string]struct{})
+		for _, frame := range frames {
+			if isLogsFrame(frame) {
+				if logs == nil {
+					logs = frame.EmptyCopy()
+					logs.Meta = frame.Meta
+					merged = append(merged, logs)
+				}
+				appendLogRows(logs, frame, seenLines, currentLines)
+				continue
+			}
+
+			if len(frame.Fields) != 2 || frame.Fields[0].Type() != data.FieldTypeTime {
+				merged = append(merged, frame)
+				continue
+			}
+
+			key := frame.Name + frame.Fields[1].Labels.String()
+			existing, ok := series[key]
+			if !ok {
+				series[key] = frame
+				merged = append(merged, frame)
+				continue
+			}
+			appendMetricRows(existing, frame)
+		}
+		for key := range currentLines {
+			seenLines[key] = struct{}{}
+		}
+	}
+
+	if stats != nil {
+		updateStatsRates(stats)
+		for _, frame := range merged {
+			setStats(frame, stats)
+		}
+	}
+
+	return merged
+}
+
+// responseStats returns the raw query stats of a loki response. they are the same for every frame of the response.
+func responseStats(frames data.Frames) map[string]interface{} {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			continue
+		}
+		if customMap, ok := frame.Meta.Custom.(map[string]interface{}); ok {
+			if stats, ok := customMap["stats"].(map[string]interface{}); ok {
+				return stats
+			}
+		}
+	}
+	return nil
+}
+
+// addStats adds the numeric values of src to dst, nested objects are added recursively.
+func addStats(dst map[string]interface{}, src map[string]interface{}) {
+	for key, value := range src {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			d, ok := dst[key].(map[string]interface{})
+			if !ok {
+				d = make(map[string]interface{})
+				dst[key] = d
+			}
+			addStats(d, v)
+		case float64:
+			d, _ := dst[key].(float64)
+			dst[key] = d + v
+		}
+	}
+}
+
+// updateStatsRates recalculates the per-second summary values from the summed totals, summing the rates of
+// consecutive requests would overstate them.
+func updateStatsRates(stats map[string]interface{}) {
+	summary, ok := stats["summary"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	execTime, _ := summary["execTime"].(float64)
+	if execTime <= 0 {
+		return
+	}
+	if bytes, ok := summary["totalBytesProcessed"].(float64); ok {
+		summary["bytesProcessedPerSecond"] = bytes / execTime
+	}
+	if lines, ok := summary["totalLinesProcessed"].(float64); ok {
+		summary["linesProcessedPerSecond"] = lines / execTime
+	}
+}
+
+func setStats(frame *data.Frame, stats map[string]interface{}) {
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	if frame.Meta.Custom == nil {
+		frame.Meta.Custom = make(map[string]interface{})
+	}
+	if customMap, ok := frame.Meta.Custom.(map[string]interface{}); ok {
+		customMap["stats"] = stats
+	}
+}
+
+func appendLogRows(dst *data.Frame, src *data.Frame, seen map[string]struct{}, current map[string]struct{}) {
+	labelsField, timeField, lineField, stringTimeField := src.Fields[0], src.Fields[1], src.Fields[2], src.Fields[3]
+	for i := 0; i < src.Rows(); i++ {
+		key := stringTimeField.At(i).(string) + "\x00" + lineField.At(i).(string) + "\x00" + string(labelsField.At(i).(json.RawMessage))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		current[key] = struct{}{}
+		dst.Fields[0].Append(labelsField.At(i))
+		dst.Fields[1].Append(timeField.At(i))
+		dst.Fields[2].Append(lineField.At(i))
+		dst.Fields[3].Append(stringTimeField.At(i))
+	}
+}
+
+func appendMetricRows(dst *data.Frame, src *data.Frame) {
+	dstTime := dst.Fields[0]
+	for i := 0; i < src.Rows(); i++ {
+		t := src.Fields[0].At(i).(time.Time)
+		if n := dstTime.Len(); n > 0 && !t.After(dstTime.At(n-1).(time.Time)) {
+			continue
+		}
+		dstTime.Append(t)
+		dst.Fields[1].Append(src.Fields[1].At(i))
+	}
+}
+
 func calculateCheckSum(time string, line string, labels []byte) (string, error) {
 	input := []byte(line + "_")
 	input = append(input, labels...)
@@ -89,6 +89,28 @@ func TestAdjustFrame(t *testing.T) {
 		require.Equal(t, "1641092765000000006_948c1a7d_A", idField.At(3))
 	})
 
+	t.Run("logs-frame should get a notice when the line limit is reached", func(t *testing.T) {
+		makeFrame := func() *data.Frame {
+			return data.NewFrame("",
+				data.NewField("__labels", nil, []json.RawMessage{json.RawMessage(`{"level":"info"}`), json.RawMessage(`{"level":"info"}`)}),
+				data.NewField("Time", nil, []time.Time{time.Unix(1, 0), time.Unix(2, 0)}),
+				data.NewField("Line", nil, []string{"line1", "line2"}),
+				data.NewField("TS", nil, []string{"1000000000", "2000000000"}),
+			)
+		}
+
+		frame := makeFrame()
+		err := adjustFrame(frame, &lokiQuery{QueryType: QueryTypeRange, MaxLines: 2})
+		require.NoError(t, err)
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, frame.Meta.Notices[0].Severity)
+
+		frame = makeFrame()
+		err = adjustFrame(frame, &lokiQuery{QueryType: QueryTypeRange, MaxLines: 3})
+		require.NoError(t, err)
+		require.Empty(t, frame.Meta.Notices)
+	})
+
 	t.Run("naming inside metric fields should be correct", func(t *testing.T) {
 		field1 := data.NewField("", nil, make([]time.Time, 0))
 		field2 := data.NewField("", nil, make([]float64, 0))
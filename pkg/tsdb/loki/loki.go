@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"go.opentelemetry.io/otel/attribute"
 )
 
@@ -50,6 +52,10 @@ type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
 
+	// SplitInterval is the maximum time range of a single request, longer range queries are split into several
+	// requests. Zero disables splitting.
+	SplitInterval time.Duration
+
 	// open streams
 	streams   map[string]data.FrameJSONCache
 	streamsMu sync.RWMutex
@@ -67,6 +73,10 @@ type QueryJSONModel struct {
 	VolumeQuery  bool   `json:"volumeQuery"`
 }
 
+type datasourceJSONData struct {
+	SplitInterval string `json:"splitInterval"`
+}
+
 func parseQueryModel(raw json.RawMessage) (*QueryJSONModel, error) {
 	model := &QueryJSONModel{}
 	err := json.Unmarshal(raw, model)
@@ -85,10 +95,26 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jsonData := datasourceJSONData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		var splitInterval time.Duration
+		if jsonData.SplitInterval != "" {
+			splitInterval, err = intervalv2.ParseIntervalStringToTimeDuration(jsonData.SplitInterval)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing split interval: %w", err)
+			}
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
-			streams:    make(map[string]data.FrameJSONCache),
+			HTTPClient:    client,
+			URL:           settings.URL,
+			SplitInterval: splitInterval,
+			streams:       make(map[string]data.FrameJSONCache),
 		}
 		return model, nil
 	}
@@ -205,7 +231,7 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 		span.SetAttributes("stop_unixnano", query.End, attribute.Key("stop_unixnano").Int64(query.End.UnixNano()))
 		defer span.End()
 
-		frames, err := runQuery(ctx, api, query)
+		frames, err := runQuery(ctx, api, query, dsInfo.SplitInterval)
 
 		queryRes := backend.DataResponse{}
 
@@ -221,8 +247,8 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 }
 
 // we extracted this part of the functionality to make it easy to unit-test it
-func runQuery(ctx context.Context, api *LokiAPI, query *lokiQuery) (data.Frames, error) {
-	frames, err := api.DataQuery(ctx, *query)
+func runQuery(ctx context.Context, api *LokiAPI, query *lokiQuery, splitInterval time.Duration) (data.Frames, error) {
+	frames, err := runSplitQuery(ctx, api, query, splitInterval)
 	if err != nil {
 		return data.Frames{}, err
 	}
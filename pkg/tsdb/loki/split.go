@@ -0,0 +1,93 @@
+package loki
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// splitQuery splits a range query into consecutive queries over sub-ranges that are no longer than splitInterval,
+// ordered by time. The sub-range length is a multiple of the step, so metric queries are evaluated at the same
+// points in time as without splitting. Sub-ranges share their boundaries, duplicated results are removed when the
+// frames are merged.
+func splitQuery(query *lokiQuery, splitInterval time.Duration) []*lokiQuery {
+	if query.QueryType != QueryTypeRange || splitInterval <= 0 || query.End.Sub(query.Start) <= splitInterval {
+		return []*lokiQuery{query}
+	}
+
+	if query.Step > 0 {
+		if rem := splitInterval % query.Step; rem != 0 {
+			splitInterval += query.Step - rem
+		}
+	}
+
+	queries := []*lokiQuery{}
+	for start := query.Start; start.Before(query.End); start = start.Add(splitInterval) {
+		end := start.Add(splitInterval)
+		if end.After(query.End) {
+			end = query.End
+		}
+		q := *query
+		q.Start = start
+		q.End = end
+		queries = append(queries, &q)
+	}
+	return queries
+}
+
+// runSplitQuery runs the query as several smaller queries if its time range is longer than splitInterval and merges
+// the results. Sub-queries are run in the direction of the query, so log queries stop as soon as the line limit is
+// reached.
+func runSplitQuery(ctx context.Context, api *LokiAPI, query *lokiQuery, splitInterval time.Duration) (data.Frames, error) {
+	queries := splitQuery(query, splitInterval)
+	if len(queries) == 1 {
+		return api.DataQuery(ctx, *query)
+	}
+
+	api.log.Debug("Splitting query", "query", query.Expr, "parts", len(queries), "interval", splitInterval)
+
+	if query.Direction == DirectionBackward {
+		reverseQueries(queries)
+	}
+
+	results := make([]data.Frames, 0, len(queries))
+	lines := 0
+	isLogs := false
+	for _, q := range queries {
+		if isLogs && query.MaxLines > 0 {
+			q.MaxLines = query.MaxLines - lines
+		}
+
+		frames, err := api.DataQuery(ctx, *q)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, frames)
+
+		for _, frame := range frames {
+			if isLogsFrame(frame) {
+				isLogs = true
+				lines += frame.Rows()
+			}
+		}
+		if isLogs && query.MaxLines > 0 && lines >= query.MaxLines {
+			break
+		}
+	}
+
+	// metric frames are merged in time order, log frames in the order of the query direction
+	if !isLogs && query.Direction == DirectionBackward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	return mergeFrames(results), nil
+}
+
+func reverseQueries(queries []*lokiQuery) {
+	for i, j := 0, len(queries)-1; i < j; i, j = i+1, j-1 {
+		queries[i], queries[j] = queries[j], queries[i]
+	}
+}
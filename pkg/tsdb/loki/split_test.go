@@ -0,0 +1,158 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitQuery(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("short queries are not split", func(t *testing.T) {
+		query := &lokiQuery{QueryType: QueryTypeRange, Start: start, End: start.Add(time.Hour), Step: time.Minute}
+		require.Equal(t, []*lokiQuery{query}, splitQuery(query, 24*time.Hour))
+	})
+
+	t.Run("instant queries are not split", func(t *testing.T) {
+		query := &lokiQuery{QueryType: QueryTypeInstant, Start: start, End: start.Add(72 * time.Hour)}
+		require.Equal(t, []*lokiQuery{query}, splitQuery(query, 24*time.Hour))
+	})
+
+	t.Run("long queries are split into sub-ranges that are a multiple of step", func(t *testing.T) {
+		query := &lokiQuery{QueryType: QueryTypeRange, Start: start, End: start.Add(3 * time.Hour), Step: 7 * time.Minute}
+		queries := splitQuery(query, time.Hour)
+
+		require.Len(t, queries, 3)
+		require.Equal(t, start, queries[0].Start)
+		require.Equal(t, start.Add(63*time.Minute), queries[0].End)
+		require.Equal(t, start.Add(63*time.Minute), queries[1].Start)
+		require.Equal(t, start.Add(126*time.Minute), queries[2].Start)
+		require.Equal(t, start.Add(3*time.Hour), queries[2].End)
+	})
+}
+
+func TestRunSplitQuery(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	streams := []byte(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"level":"info"},"values":[["1640995200000000000","line 1"],["1640995201000000000","line 2"]]}]}}`)
+
+	t.Run("log queries stop when the line limit is reached", func(t *testing.T) {
+		var limits []string
+		api := makeMockedAPI(http.StatusOK, "application/json", streams, func(req *http.Request) {
+			limits = append(limits, req.URL.Query().Get("limit"))
+		})
+
+		query := &lokiQuery{QueryType: QueryTypeRange, Direction: DirectionBackward, Start: start, End: start.Add(72 * time.Hour), Step: time.Minute, MaxLines: 3}
+		frames, err := runQuery(context.Background(), api, query, 24*time.Hour)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"3", "1"}, limits)
+		require.Len(t, frames, 1)
+		// the same lines were returned twice, the duplicates are removed
+		require.Equal(t, 2, frames[0].Rows())
+	})
+
+	t.Run("metric queries are merged by series", func(t *testing.T) {
+		matrix := []byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"level":"info"},"values":[[1640995200,"1"],[1640995260,"2"]]}]}}`)
+		calls := 0
+		api := makeMockedAPI(http.StatusOK, "application/json", matrix, func(req *http.Request) {
+			calls++
+		})
+
+		query := &lokiQuery{QueryType: QueryTypeRange, Direction: DirectionBackward, Start: start, End: start.Add(72 * time.Hour), Step: time.Minute}
+		frames, err := runQuery(context.Background(), api, query, 24*time.Hour)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, calls)
+		require.Len(t, frames, 1)
+		// every sub-query returned the same samples, rows that are not newer are dropped
+		require.Equal(t, 2, frames[0].Rows())
+	})
+}
+
+func TestMergeFrames(t *testing.T) {
+	time1 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	time2 := time1.Add(time.Minute)
+	time3 := time2.Add(time.Minute)
+
+	metricFrame := func(times []time.Time, values []float64) *data.Frame {
+		return data.NewFrame("",
+			data.NewField("Time", nil, times),
+			data.NewField("Value", data.Labels{"level": "info"}, values))
+	}
+
+	logsFrame := func(times []time.Time, lines []string) *data.Frame {
+		labels := make([]json.RawMessage, len(times))
+		tsNs := make([]string, len(times))
+		for i, ts := range times {
+			labels[i] = json.RawMessage(`{"level":"info"}`)
+			tsNs[i] = ts.Format(time.RFC3339Nano)
+		}
+		return data.NewFrame("",
+			data.NewField("__labels", nil, labels),
+			data.NewField("Time", nil, times),
+			data.NewField("Line", nil, lines),
+			data.NewField("TS", nil, tsNs))
+	}
+
+	t.Run("metric frames of the same series are joined", func(t *testing.T) {
+		merged := mergeFrames([]data.Frames{
+			{metricFrame([]time.Time{time1, time2}, []float64{1, 2})},
+			{metricFrame([]time.Time{time2, time3}, []float64{2, 3})},
+		})
+
+		require.Len(t, merged, 1)
+		require.Equal(t, 3, merged[0].Rows())
+		require.Equal(t, 3.0, merged[0].Fields[1].At(2))
+	})
+
+	t.Run("log lines returned by more than one sub-query are removed", func(t *testing.T) {
+		merged := mergeFrames([]data.Frames{
+			{logsFrame([]time.Time{time3, time2}, []string{"c", "b"})},
+			{logsFrame([]time.Time{time2, time1, time1}, []string{"b", "a", "a"})},
+		})
+
+		require.Len(t, merged, 1)
+		require.Equal(t, 4, merged[0].Rows())
+		require.Equal(t, []string{"c", "b", "a", "a"}, []string{
+			merged[0].Fields[2].At(0).(string),
+			merged[0].Fields[2].At(1).(string),
+			merged[0].Fields[2].At(2).(string),
+			merged[0].Fields[2].At(3).(string),
+		})
+	})
+	t.Run("query stats of all sub-queries are summed", func(t *testing.T) {
+		withStats := func(frame *data.Frame, bytes float64, execTime float64) *data.Frame {
+			frame.Meta = &data.FrameMeta{Custom: map[string]interface{}{
+				"stats": map[string]interface{}{
+					"summary": map[string]interface{}{
+						"totalBytesProcessed":     bytes,
+						"bytesProcessedPerSecond": bytes / execTime,
+						"execTime":                execTime,
+					},
+				},
+			}}
+			return frame
+		}
+
+		merged := mergeFrames([]data.Frames{
+			{withStats(logsFrame([]time.Time{time2}, []string{"b"}), 100, 1)},
+			{withStats(logsFrame([]time.Time{time1}, []string{"a"}), 300, 3)},
+		})
+
+		require.Len(t, merged, 1)
+		stats := parseStats(merged[0].Meta.Custom)
+		values := make(map[string]float64)
+		for _, stat := range stats {
+			values[stat.DisplayName] = stat.Value
+		}
+		require.Equal(t, 400.0, values["Summary: total bytes processed"])
+		require.Equal(t, 4.0, values["Summary: exec time"])
+		require.Equal(t, 100.0, values["Summary: bytes processed per second"])
+	})
+}
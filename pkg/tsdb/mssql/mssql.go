@@ -88,6 +88,7 @@ func newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFactoryFunc {
 			DSInfo:            dsInfo,
 			MetricColumnTypes: []string{"VARCHAR", "CHAR", "NVARCHAR", "NCHAR"},
 			RowLimit:          cfg.DataProxyRowLimit,
+			Placeholder:       sqleng.AtPPlaceholder,
 		}
 
 		queryResultTransformer := mssqlQueryResultTransformer{
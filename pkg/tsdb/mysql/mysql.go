@@ -116,6 +116,8 @@ func newInstanceSettings(cfg *setting.Cfg, httpClientProvider httpclient.Provide
 			TimeColumnNames:   []string{"time", "time_sec"},
 			MetricColumnTypes: []string{"CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT"},
 			RowLimit:          cfg.DataProxyRowLimit,
+			Placeholder:       sqleng.QuestionPlaceholder,
+			BackslashEscapes:  true,
 		}
 
 		rowTransformer := mysqlQueryResultTransformer{
@@ -0,0 +1,187 @@
+package opentsdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const expressionQueryType = "expression"
+
+func isExpressionQuery(query backend.DataQuery) bool {
+	model, err := simplejson.NewJson(query.JSON)
+	if err != nil {
+		return false
+	}
+	return model.Get("queryType").MustString() == expressionQueryType
+}
+
+// executeExpressionQuery runs a query against the OpenTSDB expression API (/api/query/exp). Unlike metric queries,
+// every expression query is sent on its own since the time section is shared by all metrics of a request.
+func (s *Service) executeExpressionQuery(ctx context.Context, dsInfo *datasourceInfo, query backend.DataQuery) backend.DataResponse {
+	expQuery, err := s.buildExpressionQuery(query)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	request, err := s.createExpressionRequest(ctx, dsInfo, expQuery)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	res, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	frames, err := s.parseExpressionResponse(res)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	return backend.DataResponse{Frames: frames}
+}
+
+func (s *Service) buildExpressionQuery(query backend.DataQuery) (*OpenTsdbExpQuery, error) {
+	var expQuery OpenTsdbExpQuery
+	if err := json.Unmarshal(query.JSON, &expQuery); err != nil {
+		return nil, fmt.Errorf("failed to parse expression query: %w", err)
+	}
+
+	if len(expQuery.Metrics) == 0 {
+		return nil, fmt.Errorf("expression query must contain at least one metric")
+	}
+
+	model, err := simplejson.NewJson(query.JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	expQuery.Time = OpenTsdbExpTime{
+		Start:      query.TimeRange.From.UnixNano() / int64(time.Millisecond),
+		End:        query.TimeRange.To.UnixNano() / int64(time.Millisecond),
+		Aggregator: model.Get("aggregator").MustString("sum"),
+		Rate:       model.Get("shouldComputeRate").MustBool(),
+	}
+
+	if !model.Get("disableDownsampling").MustBool() {
+		downsampleInterval := model.Get("downsampleInterval").MustString()
+		if downsampleInterval == "" {
+			downsampleInterval = "1m" // default value for blank
+		}
+		downsampler := map[string]interface{}{
+			"interval":   downsampleInterval,
+			"aggregator": model.Get("downsampleAggregator").MustString("avg"),
+		}
+		if fillPolicy := model.Get("downsampleFillPolicy").MustString(); fillPolicy != "" && fillPolicy != "none" {
+			downsampler["fillPolicy"] = map[string]interface{}{"policy": fillPolicy}
+		}
+		expQuery.Time.Downsampler = downsampler
+	}
+
+	return &expQuery, nil
+}
+
+func (s *Service) createExpressionRequest(ctx context.Context, dsInfo *datasourceInfo, expQuery *OpenTsdbExpQuery) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "api/query/exp")
+
+	postData, err := json.Marshal(expQuery)
+	if err != nil {
+		s.logger.Info("Failed marshaling data", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(postData))
+	if err != nil {
+		s.logger.Info("Failed to create request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (s *Service) parseExpressionResponse(res *http.Response) (data.Frames, error) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode/100 != 2 {
+		s.logger.Info("Request failed", "status", res.Status, "body", string(body))
+		return nil, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+
+	var responseData OpenTsdbExpResponse
+	if err := json.Unmarshal(body, &responseData); err != nil {
+		s.logger.Info("Failed to unmarshal opentsdb expression response", "error", err, "status", res.Status, "body", string(body))
+		return nil, err
+	}
+
+	frames := data.Frames{}
+	for _, output := range responseData.Outputs {
+		timeVector := make([]time.Time, 0, len(output.DataPoints))
+		for _, dp := range output.DataPoints {
+			if len(dp) == 0 {
+				continue
+			}
+			timeVector = append(timeVector, time.UnixMilli(int64(dp[0])).UTC())
+		}
+
+		// The first meta entry describes the timestamp column, the following ones the value columns.
+		for _, meta := range output.Meta {
+			if meta.Index == 0 {
+				continue
+			}
+
+			values := make([]float64, 0, len(output.DataPoints))
+			for _, dp := range output.DataPoints {
+				if len(dp) == 0 {
+					continue
+				}
+				if meta.Index >= len(dp) {
+					return nil, fmt.Errorf("expression output %q has no value for series %d", output.ID, meta.Index)
+				}
+				values = append(values, dp[meta.Index])
+			}
+
+			frames = append(frames, data.NewFrame(expressionSeriesName(output, meta),
+				data.NewField("time", nil, timeVector),
+				data.NewField("value", meta.CommonTags, values)))
+		}
+	}
+
+	return frames, nil
+}
+
+func expressionSeriesName(output OpenTsdbExpOutput, meta OpenTsdbExpSeries) string {
+	name := output.Alias
+	if name == "" {
+		name = output.ID
+	}
+	if len(meta.Metrics) > 0 && len(output.Meta) > 2 {
+		name += " " + strings.Join(meta.Metrics, ",")
+	}
+	return name
+}
@@ -0,0 +1,113 @@
+package opentsdb
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestOpenTsdbExpressions(t *testing.T) {
+	service := &Service{
+		logger: log.New("test"),
+	}
+
+	t.Run("Build expression query", func(t *testing.T) {
+		query := backend.DataQuery{
+			TimeRange: backend.TimeRange{
+				From: time.UnixMilli(1000),
+				To:   time.UnixMilli(2000),
+			},
+			JSON: []byte(`
+					{
+						"queryType": "expression",
+						"aggregator": "max",
+						"downsampleInterval": "5m",
+						"downsampleAggregator": "sum",
+						"downsampleFillPolicy": "nan",
+						"shouldComputeRate": true,
+						"metrics": [{"id": "a", "metric": "sys.cpu.user"}],
+						"expressions": [{"id": "e", "expr": "a * 2"}]
+					}`,
+			),
+		}
+
+		require.True(t, isExpressionQuery(query))
+
+		expQuery, err := service.buildExpressionQuery(query)
+		require.NoError(t, err)
+
+		require.Equal(t, OpenTsdbExpTime{
+			Start:      1000,
+			End:        2000,
+			Aggregator: "max",
+			Rate:       true,
+			Downsampler: map[string]interface{}{
+				"interval":   "5m",
+				"aggregator": "sum",
+				"fillPolicy": map[string]interface{}{"policy": "nan"},
+			},
+		}, expQuery.Time)
+		require.Len(t, expQuery.Metrics, 1)
+		require.Len(t, expQuery.Expressions, 1)
+	})
+
+	t.Run("Build expression query without metrics", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`{"queryType": "expression", "expressions": [{"id": "e", "expr": "a * 2"}]}`),
+		}
+
+		_, err := service.buildExpressionQuery(query)
+		require.Error(t, err)
+	})
+
+	t.Run("Parse expression response", func(t *testing.T) {
+		response := `
+		{
+			"outputs": [
+				{
+					"id": "e",
+					"alias": "doubled",
+					"dps": [[1405544146000, 10, 20], [1405544206000, 11, 21]],
+					"meta": [
+						{"index": 0, "metrics": ["timestamp"]},
+						{"index": 1, "metrics": ["sys.cpu.user"], "commonTags": {"host": "web01"}},
+						{"index": 2, "metrics": ["sys.cpu.user"], "commonTags": {"host": "web02"}}
+					]
+				}
+			]
+		}`
+
+		resp := http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+		frames, err := service.parseExpressionResponse(&resp)
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+
+		times := []time.Time{
+			time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
+			time.Date(2014, 7, 16, 20, 56, 46, 0, time.UTC),
+		}
+		testFrame := data.NewFrame("doubled sys.cpu.user",
+			data.NewField("time", nil, times),
+			data.NewField("value", map[string]string{"host": "web02"}, []float64{20, 21}),
+		)
+
+		if diff := cmp.Diff(testFrame, frames[1], data.FrameTestCompareOptions()...); diff != "" {
+			t.Errorf("Result mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Parse expression response should handle failed requests", func(t *testing.T) {
+		resp := http.Response{StatusCode: 400, Status: "400 Bad Request", Body: io.NopCloser(strings.NewReader(`{}`))}
+		_, err := service.parseExpressionResponse(&resp)
+		require.Error(t, err)
+	})
+}
@@ -64,14 +64,33 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 }
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	result := backend.NewQueryDataResponse()
+	metricQueries := make([]backend.DataQuery, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		if isExpressionQuery(query) {
+			result.Responses[query.RefID] = s.executeExpressionQuery(ctx, dsInfo, query)
+			continue
+		}
+		metricQueries = append(metricQueries, query)
+	}
+
+	if len(metricQueries) == 0 {
+		return result, nil
+	}
+
 	var tsdbQuery OpenTsdbQuery
 
-	q := req.Queries[0]
+	q := metricQueries[0]
 
 	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
 	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
 
-	for _, query := range req.Queries {
+	for _, query := range metricQueries {
 		metric := s.buildMetric(query)
 		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
 	}
@@ -81,11 +100,6 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		s.logger.Debug("OpenTsdb request", "params", tsdbQuery)
 	}
 
-	dsInfo, err := s.getDSInfo(req.PluginContext)
-	if err != nil {
-		return nil, err
-	}
-
 	request, err := s.createRequest(ctx, dsInfo, tsdbQuery)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
@@ -96,11 +110,15 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		return &backend.QueryDataResponse{}, err
 	}
 
-	result, err := s.parseResponse(res)
+	metricResult, err := s.parseResponse(res)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
 	}
 
+	for refID, r := range metricResult.Responses {
+		result.Responses[refID] = r
+	}
+
 	return result, nil
 }
 
@@ -211,23 +229,28 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
 		rateOptions := make(map[string]interface{})
 		rateOptions["counter"] = model.Get("isCounter").MustBool()
 
-		counterMax, counterMaxCheck := model.CheckGet("counterMax")
+		counterMax, counterMaxCheck := getFloat(model, "counterMax")
 		if counterMaxCheck {
-			rateOptions["counterMax"] = counterMax.MustFloat64()
+			rateOptions["counterMax"] = counterMax
 		}
 
-		resetValue, resetValueCheck := model.CheckGet("counterResetValue")
+		resetValue, resetValueCheck := getFloat(model, "counterResetValue")
 		if resetValueCheck {
-			rateOptions["resetValue"] = resetValue.MustFloat64()
+			rateOptions["resetValue"] = resetValue
 		}
 
-		if !counterMaxCheck && (!resetValueCheck || resetValue.MustFloat64() == 0) {
+		if !counterMaxCheck && (!resetValueCheck || resetValue == 0) {
 			rateOptions["dropResets"] = true
 		}
 
 		metric["rateOptions"] = rateOptions
 	}
 
+	// Setting explicit tags, only series with exactly the given tags are returned
+	if model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
+	}
+
 	// Setting tags
 	tags, tagsCheck := model.CheckGet("tags")
 	if tagsCheck && len(tags.MustMap()) > 0 {
@@ -243,6 +266,32 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
 	return metric
 }
 
+// getFloat returns the numeric value of key. The query editor stores numbers as strings so numeric strings are
+// accepted too, while empty strings are treated as not set.
+func getFloat(model *simplejson.Json, key string) (float64, bool) {
+	value, ok := model.CheckGet(key)
+	if !ok {
+		return 0, false
+	}
+
+	if str, err := value.String(); err == nil {
+		if strings.TrimSpace(str) == "" {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	}
+
+	f, err := value.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
 func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
 	i, err := s.im.Get(pluginCtx)
 	if err != nil {
@@ -245,4 +245,44 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(45), metricRateOptions["counterMax"])
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
+	t.Run("Build metric with rate options given as strings", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"aggregator": "avg",
+						"disableDownsampling": true,
+						"shouldComputeRate": true,
+						"isCounter": true,
+						"counterMax": "45",
+						"counterResetValue": ""
+					}`,
+			),
+		}
+
+		metric := service.buildMetric(query)
+
+		metricRateOptions := metric["rateOptions"].(map[string]interface{})
+		require.Len(t, metricRateOptions, 2)
+		require.True(t, metricRateOptions["counter"].(bool))
+		require.Equal(t, float64(45), metricRateOptions["counterMax"])
+	})
+
+	t.Run("Build metric with explicit tags", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"aggregator": "avg",
+						"disableDownsampling": true,
+						"explicitTags": true
+					}`,
+			),
+		}
+
+		metric := service.buildMetric(query)
+
+		require.Len(t, metric, 3)
+		require.True(t, metric["explicitTags"].(bool))
+	})
 }
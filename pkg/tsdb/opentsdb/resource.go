@@ -0,0 +1,104 @@
+package opentsdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var _ backend.CallResourceHandler = (*Service)(nil)
+
+// resourcePaths are the OpenTSDB API endpoints the query editor needs for suggestions.
+var resourcePaths = []string{
+	"api/suggest",
+	"api/search/lookup",
+	"api/aggregators",
+	"api/config/filters",
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	if req.Method != http.MethodGet {
+		return sender.Send(&backend.CallResourceResponse{
+			Status: http.StatusMethodNotAllowed,
+			Headers: map[string][]string{
+				"Allow": {http.MethodGet},
+			},
+		})
+	}
+
+	resourceURL, err := url.Parse(req.URL)
+	if err != nil {
+		return err
+	}
+	if !isAllowedResourcePath(resourceURL.Path) {
+		return sender.Send(&backend.CallResourceResponse{
+			Status: http.StatusNotFound,
+		})
+	}
+
+	request, err := s.createResourceRequest(ctx, dsInfo, resourceURL)
+	if err != nil {
+		return err
+	}
+
+	res, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status: res.StatusCode,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
+
+func isAllowedResourcePath(p string) bool {
+	p = strings.Trim(p, "/")
+	for _, allowed := range resourcePaths {
+		if p == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) createResourceRequest(ctx context.Context, dsInfo *datasourceInfo, resourceURL *url.URL) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, resourceURL.Path)
+	u.RawQuery = resourceURL.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		s.logger.Info("Failed to create request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,93 @@
+package opentsdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestCallResource(t *testing.T) {
+	var requestedURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedURL = r.URL.String()
+		_, _ = w.Write([]byte(`["sys.cpu.user"]`))
+	}))
+	t.Cleanup(server.Close)
+
+	service := &Service{
+		logger: log.New("test"),
+		im: &fakeInstanceManager{instance: &datasourceInfo{
+			HTTPClient: server.Client(),
+			URL:        server.URL,
+		}},
+	}
+
+	t.Run("proxies allowed resources", func(t *testing.T) {
+		sender := &fakeSender{}
+		err := service.CallResource(context.Background(), &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   "api/suggest",
+			URL:    "api/suggest?type=metrics&q=sys&max=10",
+		}, sender)
+		require.NoError(t, err)
+
+		require.Equal(t, "/api/suggest?type=metrics&q=sys&max=10", requestedURL)
+		require.Equal(t, http.StatusOK, sender.resp.Status)
+		require.Equal(t, `["sys.cpu.user"]`, string(sender.resp.Body))
+	})
+
+	t.Run("rejects other resources", func(t *testing.T) {
+		requestedURL = ""
+		sender := &fakeSender{}
+		err := service.CallResource(context.Background(), &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			URL:    "api/put",
+		}, sender)
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusNotFound, sender.resp.Status)
+		require.Empty(t, requestedURL)
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		requestedURL = ""
+		sender := &fakeSender{}
+		err := service.CallResource(context.Background(), &backend.CallResourceRequest{
+			Method: http.MethodPost,
+			URL:    "api/suggest",
+		}, sender)
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusMethodNotAllowed, sender.resp.Status)
+		require.Equal(t, []string{http.MethodGet}, sender.resp.Headers["Allow"])
+		require.Empty(t, requestedURL)
+	})
+}
+
+type fakeInstanceManager struct {
+	instance instancemgmt.Instance
+}
+
+func (im *fakeInstanceManager) Get(_ backend.PluginContext) (instancemgmt.Instance, error) {
+	return im.instance, nil
+}
+
+func (im *fakeInstanceManager) Do(_ backend.PluginContext, _ instancemgmt.InstanceCallbackFunc) error {
+	return nil
+}
+
+type fakeSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
@@ -11,3 +11,36 @@ type OpenTsdbResponse struct {
 	Tags       map[string]string  `json:"tags"`
 	DataPoints map[string]float64 `json:"dps"`
 }
+
+type OpenTsdbExpQuery struct {
+	Time        OpenTsdbExpTime          `json:"time"`
+	Filters     []map[string]interface{} `json:"filters,omitempty"`
+	Metrics     []map[string]interface{} `json:"metrics"`
+	Expressions []map[string]interface{} `json:"expressions,omitempty"`
+	Outputs     []map[string]interface{} `json:"outputs,omitempty"`
+}
+
+type OpenTsdbExpTime struct {
+	Start       int64                  `json:"start"`
+	End         int64                  `json:"end"`
+	Aggregator  string                 `json:"aggregator"`
+	Downsampler map[string]interface{} `json:"downsampler,omitempty"`
+	Rate        bool                   `json:"rate,omitempty"`
+}
+
+type OpenTsdbExpResponse struct {
+	Outputs []OpenTsdbExpOutput `json:"outputs"`
+}
+
+type OpenTsdbExpOutput struct {
+	ID         string              `json:"id"`
+	Alias      string              `json:"alias"`
+	DataPoints [][]float64         `json:"dps"`
+	Meta       []OpenTsdbExpSeries `json:"meta"`
+}
+
+type OpenTsdbExpSeries struct {
+	Index      int               `json:"index"`
+	Metrics    []string          `json:"metrics"`
+	CommonTags map[string]string `json:"commonTags"`
+}
@@ -91,6 +91,7 @@ func (s *Service) newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFacto
 			DSInfo:            dsInfo,
 			MetricColumnTypes: []string{"UNKNOWN", "TEXT", "VARCHAR", "CHAR"},
 			RowLimit:          cfg.DataProxyRowLimit,
+			Placeholder:       sqleng.DollarPlaceholder,
 		}
 
 		queryResultTransformer := postgresQueryResultTransformer{
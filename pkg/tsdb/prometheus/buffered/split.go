@@ -0,0 +1,74 @@
+package buffered
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
+	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// queryRange runs a range query. If splitting is enabled for the data source, long ranges are queried as several
+// smaller range queries over consecutive step aligned sub-ranges and the resulting matrices are stitched together.
+func (b *Buffered) queryRange(ctx context.Context, query *PrometheusQuery, timeRange apiv1.Range) (model.Value, error) {
+	if !b.splitter.Enabled() {
+		value, _, err := b.client.QueryRange(ctx, query.Expr, timeRange)
+		return value, err
+	}
+
+	ranges := models.SplitTimeRange(models.TimeRange{
+		Start: timeRange.Start,
+		End:   timeRange.End,
+		Step:  timeRange.Step,
+	}, b.splitter.Interval, query.UtcOffsetSec)
+	if len(ranges) == 1 {
+		value, _, err := b.client.QueryRange(ctx, query.Expr, timeRange)
+		return value, err
+	}
+
+	b.log.Debug("Splitting range query", "query", query.Expr, "parts", len(ranges), "interval", b.splitter.Interval)
+
+	values := make([]model.Value, len(ranges))
+	err := b.splitter.Run(ctx, len(ranges), func(ctx context.Context, i int) error {
+		value, _, err := b.client.QueryRange(ctx, query.Expr, apiv1.Range{
+			Start: ranges[i].Start,
+			End:   ranges[i].End,
+			Step:  ranges[i].Step,
+		})
+		if err != nil {
+			return err
+		}
+		values[i] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeMatrices(values), nil
+}
+
+// mergeMatrices stitches the matrices of the sub-range queries together. Values must be ordered by time. Anything
+// that is not a matrix is returned as is since range queries always return matrices.
+func mergeMatrices(values []model.Value) model.Value {
+	result := model.Matrix{}
+	index := map[model.Fingerprint]*model.SampleStream{}
+
+	for _, value := range values {
+		matrix, ok := value.(model.Matrix)
+		if !ok {
+			return value
+		}
+		for _, stream := range matrix {
+			fp := stream.Metric.Fingerprint()
+			if existing, ok := index[fp]; ok {
+				existing.Values = append(existing.Values, stream.Values...)
+				continue
+			}
+			index[fp] = stream
+			result = append(result, stream)
+		}
+	}
+
+	return result
+}
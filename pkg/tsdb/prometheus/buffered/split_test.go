@@ -0,0 +1,28 @@
+package buffered
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMatrices(t *testing.T) {
+	a := model.Metric{"__name__": "up", "job": "a"}
+	b := model.Metric{"__name__": "up", "job": "b"}
+
+	merged := mergeMatrices([]model.Value{
+		model.Matrix{
+			{Metric: a, Values: []model.SamplePair{{Timestamp: 1000, Value: 1}}},
+		},
+		model.Matrix{
+			{Metric: a, Values: []model.SamplePair{{Timestamp: 2000, Value: 2}}},
+			{Metric: b, Values: []model.SamplePair{{Timestamp: 2000, Value: 3}}},
+		},
+	})
+
+	require.Equal(t, model.Matrix{
+		{Metric: a, Values: []model.SamplePair{{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 2}}},
+		{Metric: b, Values: []model.SamplePair{{Timestamp: 2000, Value: 3}}},
+	}, merged)
+}
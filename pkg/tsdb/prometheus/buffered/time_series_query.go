@@ -62,6 +62,7 @@ type Buffered struct {
 	ID                 int64
 	URL                string
 	TimeInterval       string
+	splitter           *utils.Splitter
 }
 
 // New creates and object capable of executing and parsing a Prometheus queries. It's "buffered" because there is
@@ -82,6 +83,11 @@ func New(roundTripper http.RoundTripper, tracer tracing.Tracer, settings backend
 		return nil, err
 	}
 
+	splitter, err := utils.NewSplitter(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Buffered{
 		intervalCalculator: intervalv2.NewCalculator(),
 		tracer:             tracer,
@@ -90,6 +96,7 @@ func New(roundTripper http.RoundTripper, tracer tracing.Tracer, settings backend
 		TimeInterval:       timeInterval,
 		ID:                 settings.ID,
 		URL:                settings.URL,
+		splitter:           splitter,
 	}, nil
 }
 
@@ -141,7 +148,7 @@ func (b *Buffered) runQueries(ctx context.Context, queries []*PrometheusQuery) (
 		}
 
 		if query.RangeQuery {
-			rangeResponse, _, err := b.client.QueryRange(ctx, query.Expr, timeRange)
+			rangeResponse, err := b.queryRange(ctx, query, timeRange)
 			if err != nil {
 				b.log.Error("Range query failed", "query", query.Expr, "err", err)
 				result.Responses[query.RefId] = backend.DataResponse{Error: err}
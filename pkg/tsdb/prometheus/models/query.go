@@ -230,3 +230,41 @@ func AlignTimeRange(t time.Time, step time.Duration, offset int64) time.Time {
 	stepNano := float64(step.Nanoseconds())
 	return time.Unix(0, int64(math.Floor((float64(t.UnixNano())+offsetNano)/stepNano)*stepNano-offsetNano)).UTC()
 }
+
+// SplitTimeRange splits an aligned time range into consecutive sub-ranges that are no longer than interval. The
+// interval is rounded up to a multiple of the step and sub-range boundaries are aligned to it, so each sub-range
+// starts on a step boundary and no sample is returned by more than one sub-range.
+func SplitTimeRange(tr TimeRange, interval time.Duration, offset int64) []TimeRange {
+	if interval <= 0 || tr.Step <= 0 || tr.End.Sub(tr.Start) <= interval {
+		return []TimeRange{tr}
+	}
+
+	if rem := interval % tr.Step; rem != 0 {
+		interval += tr.Step - rem
+	}
+
+	ranges := []TimeRange{}
+	for start := tr.Start; !start.After(tr.End); {
+		next := AlignTimeRange(start.Add(interval), interval, offset)
+		end := next.Add(-tr.Step)
+		if end.After(tr.End) {
+			end = tr.End
+		}
+		ranges = append(ranges, TimeRange{Start: start, End: end, Step: tr.Step})
+		start = next
+	}
+	return ranges
+}
+
+// Split returns one query per sub-range of the query time range as returned by SplitTimeRange.
+func (query *Query) Split(interval time.Duration) []*Query {
+	ranges := SplitTimeRange(query.TimeRange(), interval, query.UtcOffsetSec)
+	queries := make([]*Query, 0, len(ranges))
+	for _, tr := range ranges {
+		q := *query
+		q.Start = tr.Start
+		q.End = tr.End
+		queries = append(queries, &q)
+	}
+	return queries
+}
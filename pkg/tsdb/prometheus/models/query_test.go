@@ -474,3 +474,52 @@ func TestAlignTimeRange(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitTimeRange(t *testing.T) {
+	start := time.Date(2022, 1, 1, 22, 0, 0, 0, time.UTC)
+
+	t.Run("range shorter than interval is not split", func(t *testing.T) {
+		tr := models.TimeRange{Start: start, End: start.Add(time.Hour), Step: time.Minute}
+		require.Equal(t, []models.TimeRange{tr}, models.SplitTimeRange(tr, 24*time.Hour, 0))
+	})
+
+	t.Run("zero interval disables splitting", func(t *testing.T) {
+		tr := models.TimeRange{Start: start, End: start.Add(72 * time.Hour), Step: time.Minute}
+		require.Equal(t, []models.TimeRange{tr}, models.SplitTimeRange(tr, 0, 0))
+	})
+
+	t.Run("range is split on interval boundaries", func(t *testing.T) {
+		tr := models.TimeRange{Start: start, End: start.Add(48 * time.Hour), Step: time.Minute}
+		ranges := models.SplitTimeRange(tr, 24*time.Hour, 0)
+		require.Equal(t, []models.TimeRange{
+			{Start: start, End: start.Add(2*time.Hour - time.Minute), Step: time.Minute},
+			{Start: start.Add(2 * time.Hour), End: start.Add(26*time.Hour - time.Minute), Step: time.Minute},
+			{Start: start.Add(26 * time.Hour), End: start.Add(48 * time.Hour), Step: time.Minute},
+		}, ranges)
+	})
+
+	t.Run("interval is rounded up to a multiple of step", func(t *testing.T) {
+		tr := models.TimeRange{
+			Start: models.AlignTimeRange(start, 7*time.Minute, 0),
+			End:   models.AlignTimeRange(start.Add(3*time.Hour), 7*time.Minute, 0),
+			Step:  7 * time.Minute,
+		}
+		ranges := models.SplitTimeRange(tr, time.Hour, 0)
+		for _, r := range ranges {
+			require.Zero(t, r.Start.UnixNano()%int64(7*time.Minute))
+			require.Zero(t, r.End.UnixNano()%int64(7*time.Minute))
+		}
+		for i := 1; i < len(ranges); i++ {
+			require.Equal(t, ranges[i-1].End.Add(7*time.Minute), ranges[i].Start)
+		}
+		require.Equal(t, tr.Start, ranges[0].Start)
+		require.Equal(t, tr.End, ranges[len(ranges)-1].End)
+	})
+
+	t.Run("boundaries follow the utc offset", func(t *testing.T) {
+		tr := models.TimeRange{Start: start.Add(time.Hour), End: start.Add(48 * time.Hour), Step: time.Hour}
+		ranges := models.SplitTimeRange(tr, 24*time.Hour, 3600)
+		require.Len(t, ranges, 2)
+		require.Equal(t, start.Add(25*time.Hour), ranges[1].Start)
+	})
+}
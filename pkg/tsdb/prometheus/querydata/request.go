@@ -40,6 +40,7 @@ type QueryData struct {
 	URL                string
 	TimeInterval       string
 	enableWideSeries   bool
+	splitter           *utils.Splitter
 }
 
 func New(
@@ -60,6 +61,16 @@ func New(
 		return nil, err
 	}
 
+	splitter, err := utils.NewSplitter(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	enableWideSeries := features.IsEnabled(featuremgmt.FlagPrometheusWideSeries)
+	if splitter.Enabled() && enableWideSeries {
+		plog.Warn("Range queries are not split with the wide series format", "datasource", settings.Name)
+	}
+
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
 
 	return &QueryData{
@@ -70,7 +81,8 @@ func New(
 		TimeInterval:       timeInterval,
 		ID:                 settings.ID,
 		URL:                settings.URL,
-		enableWideSeries:   features.IsEnabled(featuremgmt.FlagPrometheusWideSeries),
+		enableWideSeries:   enableWideSeries,
+		splitter:           splitter,
 	}, nil
 }
 
@@ -142,6 +154,12 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 }
 
 func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.Query, headers map[string]string) (*backend.DataResponse, error) {
+	// Wide frames hold all series in a single frame which can't be stitched together by appending rows, so splitting
+	// is only done for the multi frame format.
+	if s.splitter.Enabled() && !s.enableWideSeries {
+		return s.splitRangeQuery(ctx, c, q, headers)
+	}
+
 	res, err := c.QueryRange(ctx, q, sdkHeaderToHttpHeader(headers))
 	if err != nil {
 		return nil, err
@@ -0,0 +1,95 @@
+package querydata
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
+)
+
+// splitRangeQuery runs a range query as several smaller range queries over consecutive step aligned sub-ranges and
+// stitches the resulting frames back together. This prevents long range queries from timing out in Prometheus.
+func (s *QueryData) splitRangeQuery(ctx context.Context, c *client.Client, q *models.Query, headers map[string]string) (*backend.DataResponse, error) {
+	queries := q.Split(s.splitter.Interval)
+	if len(queries) == 1 {
+		res, err := c.QueryRange(ctx, q, sdkHeaderToHttpHeader(headers))
+		if err != nil {
+			return nil, err
+		}
+		return s.parseResponse(ctx, q, res)
+	}
+
+	s.log.Debug("Splitting range query", "query", q.Expr, "parts", len(queries), "interval", s.splitter.Interval)
+
+	responses := make([]*backend.DataResponse, len(queries))
+	err := s.splitter.Run(ctx, len(queries), func(ctx context.Context, i int) error {
+		res, err := c.QueryRange(ctx, queries[i], sdkHeaderToHttpHeader(headers))
+		if err != nil {
+			return err
+		}
+		r, err := s.parseResponse(ctx, queries[i], res)
+		if err != nil {
+			return err
+		}
+		responses[i] = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeResponses(responses), nil
+}
+
+// mergeResponses stitches the responses of the sub-range queries together. Responses must be ordered by time, frames
+// of the same series are merged by appending their rows.
+func mergeResponses(responses []*backend.DataResponse) *backend.DataResponse {
+	result := &backend.DataResponse{Frames: data.Frames{}}
+	index := map[string]*data.Frame{}
+
+	for _, r := range responses {
+		if r.Error != nil {
+			return r
+		}
+		for _, frame := range r.Frames {
+			key := frameKey(frame)
+			existing, ok := index[key]
+			if !ok || !appendFrame(existing, frame) {
+				index[key] = frame
+				result.Frames = append(result.Frames, frame)
+			}
+		}
+	}
+
+	return result
+}
+
+// frameKey identifies the series held by a frame of the multi frame format.
+func frameKey(frame *data.Frame) string {
+	key := frame.Name
+	for _, f := range frame.Fields {
+		key += "\x00" + f.Name + "\x00" + f.Labels.String()
+	}
+	return key
+}
+
+// appendFrame appends the rows of src to dst. It returns false if the frames have different fields.
+func appendFrame(dst *data.Frame, src *data.Frame) bool {
+	if len(dst.Fields) != len(src.Fields) {
+		return false
+	}
+	for i, f := range dst.Fields {
+		if f.Type() != src.Fields[i].Type() {
+			return false
+		}
+	}
+
+	for i, f := range dst.Fields {
+		for row := 0; row < src.Fields[i].Len(); row++ {
+			f.Append(src.Fields[i].At(row))
+		}
+	}
+	return true
+}
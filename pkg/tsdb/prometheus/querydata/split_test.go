@@ -0,0 +1,100 @@
+package querydata_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/querydata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryData_SplitRangeQuery(t *testing.T) {
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(71 * time.Hour)
+
+	t.Run("long range query is split and frames are stitched together", func(t *testing.T) {
+		rt := &rangeRoundTripper{}
+		qd := setupSplit(t, rt, `{"timeInterval": "15s", "splitInterval": "1d", "splitConcurrency": 2}`)
+
+		res, err := qd.Execute(context.Background(), splitQueryRequest(from, to))
+		require.NoError(t, err)
+
+		require.Len(t, rt.ranges, 3)
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, 6, frames[0].Rows())
+
+		var last time.Time
+		for i := 0; i < frames[0].Rows(); i++ {
+			ts := frames[0].Fields[0].At(i).(time.Time)
+			require.True(t, ts.After(last))
+			last = ts
+		}
+	})
+
+	t.Run("range query is not split without split interval", func(t *testing.T) {
+		rt := &rangeRoundTripper{}
+		qd := setupSplit(t, rt, `{"timeInterval": "15s"}`)
+
+		res, err := qd.Execute(context.Background(), splitQueryRequest(from, to))
+		require.NoError(t, err)
+
+		require.Len(t, rt.ranges, 1)
+		require.Equal(t, 2, res.Responses["A"].Frames[0].Rows())
+	})
+}
+
+func setupSplit(t *testing.T, rt http.RoundTripper, jsonData string) *querydata.QueryData {
+	t.Helper()
+	settings := backend.DataSourceInstanceSettings{
+		URL:      "http://localhost:9090",
+		JSONData: json.RawMessage(jsonData),
+	}
+	features := &fakeFeatureToggles{flags: map[string]bool{"prometheusStreamingJSONParser": true}}
+	qd, err := querydata.New(&http.Client{Transport: rt}, features, nil, settings, &fakeLogger{})
+	require.NoError(t, err)
+	return qd
+}
+
+func splitQueryRequest(from, to time.Time) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      []byte(`{"expr": "up", "range": true, "interval": "1h"}`),
+			},
+		},
+	}
+}
+
+// rangeRoundTripper responds to range queries with a sample at the start and at the end of the requested range.
+type rangeRoundTripper struct {
+	mu     sync.Mutex
+	ranges [][2]string
+}
+
+func (rt *rangeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	start, end := req.Form.Get("start"), req.Form.Get("end")
+
+	rt.mu.Lock()
+	rt.ranges = append(rt.ranges, [2]string{start, end})
+	rt.mu.Unlock()
+
+	body := fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","job":"a"},"values":[[%s,"1"],[%s,"2"]]}]}}`, start, end)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}, nil
+}
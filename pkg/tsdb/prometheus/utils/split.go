@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/util/maputil"
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultSplitConcurrency = 4
+
+// Splitter holds the data source settings used to split long range queries into smaller ones. The concurrency
+// limit is shared by all queries of the data source instance the splitter was created for.
+type Splitter struct {
+	Interval time.Duration
+	sem      chan struct{}
+}
+
+// NewSplitter creates a Splitter from the "splitInterval" and "splitConcurrency" data source settings. Splitting is
+// disabled if no split interval is set.
+func NewSplitter(jsonData map[string]interface{}) (*Splitter, error) {
+	splitInterval, err := maputil.GetStringOptional(jsonData, "splitInterval")
+	if err != nil {
+		return nil, err
+	}
+
+	var interval time.Duration
+	if splitInterval != "" {
+		interval, err = intervalv2.ParseIntervalStringToTimeDuration(splitInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing split interval: %w", err)
+		}
+	}
+
+	concurrency := defaultSplitConcurrency
+	if v, ok := jsonData["splitConcurrency"]; ok && v != nil {
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("splitConcurrency field must be a number")
+		}
+		if f >= 1 {
+			concurrency = int(f)
+		}
+	}
+
+	return &Splitter{
+		Interval: interval,
+		sem:      make(chan struct{}, concurrency),
+	}, nil
+}
+
+// Enabled returns whether range queries should be split.
+func (s *Splitter) Enabled() bool {
+	return s != nil && s.Interval > 0
+}
+
+// Run calls fn for indexes 0 to n-1 concurrently, never running more calls than the concurrency limit of the data
+// source at once. The first error cancels the context passed to the remaining calls and is returned.
+func (s *Splitter) Run(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	g, gCtx := errgroup.WithContext(ctx)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func() error {
+			select {
+			case s.sem <- struct{}{}:
+			case <-gCtx.Done():
+				return gCtx.Err()
+			}
+			defer func() { <-s.sem }()
+			return fn(gCtx, i)
+		})
+	}
+	return g.Wait()
+}
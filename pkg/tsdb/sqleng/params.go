@@ -0,0 +1,168 @@
+package sqleng
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util/templatevars"
+)
+
+// Placeholder returns the bind parameter placeholder of the driver for the n-th parameter, counting from 1.
+type Placeholder func(n int) string
+
+// QuestionPlaceholder is the placeholder style of MySQL.
+func QuestionPlaceholder(_ int) string {
+	return "?"
+}
+
+// DollarPlaceholder is the placeholder style of PostgreSQL.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// AtPPlaceholder is the placeholder style of Microsoft SQL Server.
+func AtPPlaceholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+// bindVariables rewrites the references to the given template variables in sql into bind parameter placeholders and
+// returns the values to bind. Multi-value variables expand to a comma separated list of placeholders, so that they
+// can be used with IN. A reference which is a whole string literal, like '$var', is replaced including the quotes.
+// backslashEscapes is set for databases that treat backslashes in string literals as escape characters.
+//
+// Some references can't be bound as they are, these are rejected when strict is set:
+//   - References which are part of a string literal, like '%$var%', are interpolated escaped for the literal.
+//   - References with an explicit format, like ${var:csv}, bind the formatted text as a single value. The sqlstring
+//     format quotes every value, so its values are bound just like without a format.
+//   - A custom all value is query text, like a sub-query, so it is interpolated as it is.
+//
+// References to unknown variables are left as they are.
+func bindVariables(sql string, variables map[string]*templatevars.Variable, placeholder Placeholder,
+	backslashEscapes bool, strict bool) (string, []interface{}, error) {
+	if len(variables) == 0 {
+		return sql, nil, nil
+	}
+
+	var (
+		sb       strings.Builder
+		args     []interface{}
+		last     int
+		literals = findStringLiterals(sql, backslashEscapes)
+	)
+	bind := func(values []string) string {
+		if len(values) == 0 {
+			return "NULL"
+		}
+		placeholders := make([]string, 0, len(values))
+		for _, value := range values {
+			args = append(args, value)
+			placeholders = append(placeholders, placeholder(len(args)))
+		}
+		return strings.Join(placeholders, ",")
+	}
+
+	for _, ref := range templatevars.FindReferences(sql) {
+		v, ok := variables[ref.Name]
+		if !ok || v == nil {
+			continue
+		}
+
+		start, end := ref.Start, ref.End
+		inLiteral := false
+		for len(literals) > 0 && literals[0].end <= ref.Start {
+			literals = literals[1:]
+		}
+		if len(literals) > 0 && literals[0].start < ref.Start {
+			if literals[0].start == ref.Start-1 && literals[0].end == ref.End+1 {
+				start, end = literals[0].start, literals[0].end
+			} else {
+				inLiteral = true
+			}
+		}
+		if start < last {
+			continue
+		}
+
+		if strict {
+			switch {
+			case inLiteral:
+				return "", nil, fmt.Errorf("variable %q is part of a string literal, which is not supported when parameterized queries are required, use it as a whole literal like '$%s' and concatenate it", ref.Name, ref.Name)
+			case ref.Format != "":
+				return "", nil, fmt.Errorf("variable %q uses the format %q, formats are not supported when parameterized queries are required", ref.Name, ref.Format)
+			case v.IsCustomAll():
+				return "", nil, fmt.Errorf("variable %q has a custom all value, which is not supported when parameterized queries are required", ref.Name)
+			}
+		}
+
+		var replacement string
+		switch {
+		case inLiteral:
+			text := strings.Join(v.Values(), ",")
+			if ref.Format != "" {
+				text = templatevars.FormatValue(ref.Name, v, ref.Format)
+			}
+			replacement = escapeLiteral(text, backslashEscapes)
+		case v.IsCustomAll():
+			if start != ref.Start {
+				replacement = "'" + escapeLiteral(v.CustomAllValue, backslashEscapes) + "'"
+			} else {
+				replacement = v.CustomAllValue
+			}
+		case ref.Format != "" && ref.Format != templatevars.FormatSQLString:
+			replacement = bind([]string{templatevars.FormatValue(ref.Name, v, ref.Format)})
+		default:
+			replacement = bind(v.Values())
+		}
+
+		sb.WriteString(sql[last:start])
+		sb.WriteString(replacement)
+		last = end
+	}
+	sb.WriteString(sql[last:])
+
+	return sb.String(), args, nil
+}
+
+// escapeLiteral escapes s for use in a single quoted string literal.
+func escapeLiteral(s string, backslashEscapes bool) string {
+	if backslashEscapes {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return strings.ReplaceAll(s, "'", "''")
+}
+
+// stringLiteral is the offset of the opening quote of a string literal and the offset after its closing quote.
+type stringLiteral struct {
+	start, end int
+}
+
+// findStringLiterals returns the single quoted string literals of sql in order. Quotes in literals are escaped by
+// doubling them, or with a backslash if backslashEscapes is set. An unterminated literal ends with sql.
+func findStringLiterals(sql string, backslashEscapes bool) []stringLiteral {
+	var literals []stringLiteral
+	start := -1
+	for i := 0; i < len(sql); i++ {
+		if backslashEscapes && start >= 0 && sql[i] == '\\' {
+			i++
+			continue
+		}
+		if sql[i] != '\'' {
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == '\'' {
+			i++
+			continue
+		}
+		literals = append(literals, stringLiteral{start: start, end: i + 1})
+		start = -1
+	}
+	if start >= 0 {
+		literals = append(literals, stringLiteral{start: start, end: len(sql)})
+	}
+	return literals
+}
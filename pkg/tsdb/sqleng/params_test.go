@@ -0,0 +1,154 @@
+package sqleng
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/util/templatevars"
+)
+
+func TestBindVariables(t *testing.T) {
+	variables := map[string]*templatevars.Variable{
+		"host": {Value: []string{"web01", "web'02"}, Multi: true},
+		"env":  {Value: []string{"prod"}},
+		"dc":   {Value: []string{templatevars.AllValue}, Options: []string{"eu", "us"}},
+		"none": {Value: []string{}, Multi: true},
+		"path": {Value: []string{`C:\`}},
+		"any":  {Value: []string{templatevars.AllValue}, Options: []string{"a"}, CustomAllValue: "SELECT host FROM hosts"},
+	}
+
+	tests := []struct {
+		name        string
+		sql         string
+		placeholder Placeholder
+		backslash   bool
+		strict      bool
+		wantSQL     string
+		wantArgs    []interface{}
+		wantErr     string
+	}{
+		{
+			name:        "single value in quotes",
+			sql:         "SELECT * FROM t WHERE env = '$env'",
+			placeholder: DollarPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE env = $1",
+			wantArgs:    []interface{}{"prod"},
+		},
+		{
+			name:        "multi value",
+			sql:         "SELECT * FROM t WHERE host IN ($host) AND env = ${env}",
+			placeholder: AtPPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE host IN (@p1,@p2) AND env = @p3",
+			wantArgs:    []interface{}{"web01", "web'02", "prod"},
+		},
+		{
+			name:        "all value expands to the options",
+			sql:         "SELECT * FROM t WHERE dc IN ([[dc]])",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE dc IN (?,?)",
+			wantArgs:    []interface{}{"eu", "us"},
+		},
+		{
+			name:        "empty value",
+			sql:         "SELECT * FROM t WHERE host IN ($none)",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE host IN (NULL)",
+		},
+		{
+			name:        "unknown variables and macros are kept",
+			sql:         "SELECT $__timeGroup(time, '1m'), $unknown FROM t WHERE env = '$env'",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT $__timeGroup(time, '1m'), $unknown FROM t WHERE env = ?",
+			wantArgs:    []interface{}{"prod"},
+		},
+		{
+			name:        "whole literal is bound when strict",
+			sql:         "SELECT * FROM t WHERE env = '$env' AND note = 'it''s'",
+			placeholder: QuestionPlaceholder,
+			strict:      true,
+			wantSQL:     "SELECT * FROM t WHERE env = ? AND note = 'it''s'",
+			wantArgs:    []interface{}{"prod"},
+		},
+		{
+			name:        "part of a literal is interpolated escaped",
+			sql:         "SELECT * FROM t WHERE name LIKE '%$env%' AND host LIKE '$host-%' AND host IN ($host)",
+			placeholder: DollarPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE name LIKE '%prod%' AND host LIKE 'web01,web''02-%' AND host IN ($1,$2)",
+			wantArgs:    []interface{}{"web01", "web'02"},
+		},
+		{
+			name:        "literals with escaped quotes",
+			sql:         "SELECT * FROM t WHERE note = 'it''s $env' AND env = $env",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE note = 'it''s prod' AND env = ?",
+			wantArgs:    []interface{}{"prod"},
+		},
+		{
+			name:        "part of a literal is rejected when strict",
+			sql:         "SELECT * FROM t WHERE name LIKE '%$env%'",
+			placeholder: QuestionPlaceholder,
+			strict:      true,
+			wantErr:     `variable "env" is part of a string literal`,
+		},
+		{
+			name:        "backslashes are escaped in literals when the database uses them as escapes",
+			sql:         "SELECT * FROM t WHERE name LIKE '%$path%' AND note = 'it\\'s $env'",
+			placeholder: QuestionPlaceholder,
+			backslash:   true,
+			wantSQL:     "SELECT * FROM t WHERE name LIKE '%C:\\\\%' AND note = 'it\\'s prod'",
+		},
+		{
+			name:        "backslashes are kept in literals of other databases",
+			sql:         "SELECT * FROM t WHERE name LIKE '%$path%'",
+			placeholder: DollarPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE name LIKE '%C:\\%'",
+		},
+		{
+			name:        "explicit format in a literal is escaped",
+			sql:         "SELECT * FROM t WHERE name LIKE '%${host:raw}%'",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE name LIKE '%web01,web''02%'",
+		},
+		{
+			name:        "explicit format binds the formatted text",
+			sql:         "SELECT * FROM t WHERE hosts = ${host:csv} AND host IN (${host:sqlstring})",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE hosts = ? AND host IN (?,?)",
+			wantArgs:    []interface{}{"web01,web'02", "web01", "web'02"},
+		},
+		{
+			name:        "custom all value is query text",
+			sql:         "SELECT * FROM t WHERE host IN ($any) AND note LIKE '%$any%'",
+			placeholder: QuestionPlaceholder,
+			wantSQL:     "SELECT * FROM t WHERE host IN (SELECT host FROM hosts) AND note LIKE '%SELECT host FROM hosts%'",
+		},
+		{
+			name:        "custom all value is rejected when strict",
+			sql:         "SELECT * FROM t WHERE host IN ($any)",
+			placeholder: QuestionPlaceholder,
+			strict:      true,
+			wantErr:     `variable "any" has a custom all value`,
+		},
+		{
+			name:        "explicit format is rejected when strict",
+			sql:         "SELECT * FROM ${env:raw}",
+			placeholder: QuestionPlaceholder,
+			strict:      true,
+			wantErr:     `variable "env" uses the format "raw"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := bindVariables(tt.sql, variables, tt.placeholder, tt.backslash, tt.strict)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sql)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
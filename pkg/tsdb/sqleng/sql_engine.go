@@ -21,6 +21,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/util/templatevars"
 )
 
 // MetaKeyExecutedQueryString is the key where the executed query should get stored
@@ -51,20 +52,21 @@ var NewXormEngine = func(driverName string, connectionString string) (*xorm.Engi
 }
 
 type JsonData struct {
-	MaxOpenConns        int    `json:"maxOpenConns"`
-	MaxIdleConns        int    `json:"maxIdleConns"`
-	ConnMaxLifetime     int    `json:"connMaxLifetime"`
-	Timescaledb         bool   `json:"timescaledb"`
-	Mode                string `json:"sslmode"`
-	ConfigurationMethod string `json:"tlsConfigurationMethod"`
-	TlsSkipVerify       bool   `json:"tlsSkipVerify"`
-	RootCertFile        string `json:"sslRootCertFile"`
-	CertFile            string `json:"sslCertFile"`
-	CertKeyFile         string `json:"sslKeyFile"`
-	Timezone            string `json:"timezone"`
-	Encrypt             string `json:"encrypt"`
-	Servername          string `json:"servername"`
-	TimeInterval        string `json:"timeInterval"`
+	MaxOpenConns            int    `json:"maxOpenConns"`
+	MaxIdleConns            int    `json:"maxIdleConns"`
+	ConnMaxLifetime         int    `json:"connMaxLifetime"`
+	Timescaledb             bool   `json:"timescaledb"`
+	Mode                    string `json:"sslmode"`
+	ConfigurationMethod     string `json:"tlsConfigurationMethod"`
+	TlsSkipVerify           bool   `json:"tlsSkipVerify"`
+	RootCertFile            string `json:"sslRootCertFile"`
+	CertFile                string `json:"sslCertFile"`
+	CertKeyFile             string `json:"sslKeyFile"`
+	Timezone                string `json:"timezone"`
+	Encrypt                 string `json:"encrypt"`
+	Servername              string `json:"servername"`
+	TimeInterval            string `json:"timeInterval"`
+	RequireParameterization bool   `json:"requireParameterization"`
 }
 
 type DataSourceInfo struct {
@@ -85,6 +87,10 @@ type DataPluginConfiguration struct {
 	TimeColumnNames   []string
 	MetricColumnTypes []string
 	RowLimit          int64
+	// Placeholder is the bind parameter style of the driver, template variables are only bound when it is set.
+	Placeholder Placeholder
+	// BackslashEscapes is set for databases that treat backslashes in string literals as escape characters.
+	BackslashEscapes bool
 }
 type DataSourceHandler struct {
 	macroEngine            SQLMacroEngine
@@ -95,6 +101,8 @@ type DataSourceHandler struct {
 	log                    log.Logger
 	dsInfo                 DataSourceInfo
 	rowLimit               int64
+	placeholder            Placeholder
+	backslashEscapes       bool
 }
 type QueryJson struct {
 	RawSql       string  `json:"rawSql"`
@@ -103,6 +111,8 @@ type QueryJson struct {
 	FillMode     string  `json:"fillMode"`
 	FillValue    float64 `json:"fillValue"`
 	Format       string  `json:"format"`
+	// Variables are template variables to bind as query parameters instead of interpolating them into rawSql.
+	Variables map[string]*templatevars.Variable `json:"variables,omitempty"`
 }
 
 func (e *DataSourceHandler) transformQueryError(err error) error {
@@ -133,6 +143,8 @@ func NewQueryDataHandler(config DataPluginConfiguration, queryResultTransformer
 		log:                    log,
 		dsInfo:                 config.DSInfo,
 		rowLimit:               config.RowLimit,
+		placeholder:            config.Placeholder,
+		backslashEscapes:       config.BackslashEscapes,
 	}
 
 	if len(config.TimeColumnNames) > 0 {
@@ -257,11 +269,26 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		return
 	}
 
+	// template variables
+	var args []interface{}
+	if len(queryJson.Variables) > 0 {
+		if e.placeholder == nil {
+			errAppendDebug("interpolation failed", errors.New("data source does not support query parameters"), interpolatedQuery)
+			return
+		}
+		interpolatedQuery, args, err = bindVariables(interpolatedQuery, queryJson.Variables, e.placeholder,
+			e.backslashEscapes, e.dsInfo.JsonData.RequireParameterization)
+		if err != nil {
+			errAppendDebug("interpolation failed", err, queryJson.RawSql)
+			return
+		}
+	}
+
 	session := e.engine.NewSession()
 	defer session.Close()
 	db := session.DB()
 
-	rows, err := db.QueryContext(queryContext, interpolatedQuery)
+	rows, err := db.QueryContext(queryContext, interpolatedQuery, args...)
 	if err != nil {
 		errAppendDebug("db query error", e.transformQueryError(err), interpolatedQuery)
 		return
@@ -0,0 +1,54 @@
+package templatevars
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// FromDashboard returns the variables stored in the templating list of a dashboard with their saved current values.
+// Options are only stored for variables that are not refreshed when the dashboard loads, so "All" of a query variable
+// that refreshes on load expands to no values unless the variable has a custom all value.
+func FromDashboard(dashboard *simplejson.Json) map[string]*Variable {
+	variables := make(map[string]*Variable)
+
+	for _, item := range dashboard.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		name := variable.Get("name").MustString()
+		if name == "" {
+			continue
+		}
+
+		if variable.Get("type").MustString() == "constant" {
+			query := variable.Get("query").MustString()
+			variables[name] = &Variable{Value: []string{query}}
+			continue
+		}
+
+		value, multi := stringOrList(variable.GetPath("current", "value"))
+		text, _ := stringOrList(variable.GetPath("current", "text"))
+		v := &Variable{
+			Value:          value,
+			Text:           text,
+			Multi:          multi || variable.Get("multi").MustBool(),
+			CustomAllValue: variable.Get("allValue").MustString(),
+		}
+		for _, option := range variable.Get("options").MustArray() {
+			optionValue := simplejson.NewFromAny(option).Get("value").MustString()
+			if optionValue != "" && optionValue != AllValue {
+				v.Options = append(v.Options, optionValue)
+			}
+		}
+		variables[name] = v
+	}
+
+	return variables
+}
+
+func stringOrList(j *simplejson.Json) ([]string, bool) {
+	if s, err := j.String(); err == nil {
+		return []string{s}, false
+	}
+	if list, err := j.StringArray(); err == nil {
+		return list, true
+	}
+	return nil, false
+}
@@ -0,0 +1,30 @@
+package templatevars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestFromDashboard(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "host", "type": "custom", "multi": true, "current": {"value": ["a", "b"], "text": ["A", "B"]}},
+				{"name": "region", "type": "custom", "includeAll": true, "current": {"value": "$__all", "text": "All"},
+					"options": [{"value": "$__all"}, {"value": "eu"}, {"value": "us"}]},
+				{"name": "env", "type": "constant", "query": "prod"}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	variables := FromDashboard(dashboard)
+
+	require.Equal(t, "a|b", Interpolate("$host", variables, FormatPipe))
+	require.Equal(t, "A + B", Interpolate("${host:text}", variables, ""))
+	require.Equal(t, "(eu|us)", Interpolate("$region", variables, FormatRegex))
+	require.Equal(t, "prod", Interpolate("$env", variables, ""))
+}
@@ -0,0 +1,86 @@
+package templatevars
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Default formats of data sources that interpolate variables without an explicit format with their own function in
+// the frontend, instead of using the glob format. They are only used as default format, a reference like
+// ${var:prometheus} falls back to glob like any other unknown format.
+const (
+	FormatPrometheus = "prometheus"
+	FormatLoki       = "loki"
+	FormatSQL        = "sql"
+)
+
+var dataSourceFormatters = map[string]formatter{
+	FormatPrometheus: formatPrometheus,
+	FormatLoki:       formatLoki,
+	FormatSQL:        formatSQL,
+}
+
+var dataSourceFormats = map[string]string{
+	"prometheus": FormatPrometheus,
+	"loki":       FormatLoki,
+	"mysql":      FormatSQL,
+	"postgres":   FormatSQL,
+	"mssql":      FormatSQL,
+}
+
+// DataSourceFormat returns the format the frontend of the data source type uses for variables without an explicit
+// format. It is empty for data sources that use the glob format.
+func DataSourceFormat(dsType string) string {
+	return dataSourceFormats[dsType]
+}
+
+var prometheusRegexSpecialChars = regexp.MustCompile(`[$^*{}\[\]'+?.()|]`)
+
+func prometheusRegularEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `'`, `\\'`)
+}
+
+func prometheusRegexEscape(s string) string {
+	return prometheusRegexSpecialChars.ReplaceAllString(strings.ReplaceAll(s, `\`, `\\\\`), `\\$0`)
+}
+
+// formatPrometheus formats like interpolateQueryExpr of the Prometheus data source: single values are only escaped
+// for use in a string, multiple values are joined into a regex alternation.
+func formatPrometheus(v value, _ []string) string {
+	if !v.multi {
+		return prometheusRegularEscape(v.single())
+	}
+	escaped := mapValues(v.values, prometheusRegexEscape)
+	if len(escaped) == 1 {
+		return escaped[0]
+	}
+	return "(" + strings.Join(escaped, "|") + ")"
+}
+
+var lokiRegexSpecialChars = regexp.MustCompile(`[$^*{}\[\]+?.()|]`)
+
+func lokiRegularEscape(s string) string {
+	return strings.ReplaceAll(s, `'`, `\\'`)
+}
+
+func lokiRegexEscape(s string) string {
+	return lokiRegularEscape(lokiRegexSpecialChars.ReplaceAllString(strings.ReplaceAll(s, `\`, `\\\\`), `\\$0`))
+}
+
+// formatLoki formats like interpolateQueryExpr of the Loki data source, which unlike Prometheus does not wrap
+// multiple values in parentheses.
+func formatLoki(v value, _ []string) string {
+	if !v.multi {
+		return lokiRegularEscape(v.single())
+	}
+	return strings.Join(mapValues(v.values, lokiRegexEscape), "|")
+}
+
+// formatSQL formats like interpolateVariable of the SQL data sources: single values are used as they are, multiple
+// values are quoted as string literals and separated by commas.
+func formatSQL(v value, args []string) string {
+	if !v.multi {
+		return v.single()
+	}
+	return formatSQLString(v, args)
+}
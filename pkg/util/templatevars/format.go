@@ -0,0 +1,303 @@
+package templatevars
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats supported by FormatValue, they match the formats of the frontend format registry.
+const (
+	FormatLucene        = "lucene"
+	FormatRaw           = "raw"
+	FormatRegex         = "regex"
+	FormatPipe          = "pipe"
+	FormatDistributed   = "distributed"
+	FormatCSV           = "csv"
+	FormatHTML          = "html"
+	FormatJSON          = "json"
+	FormatPercentEncode = "percentencode"
+	FormatSingleQuote   = "singlequote"
+	FormatDoubleQuote   = "doublequote"
+	FormatSQLString     = "sqlstring"
+	FormatDate          = "date"
+	FormatGlob          = "glob"
+	FormatText          = "text"
+	FormatQueryParam    = "queryparam"
+)
+
+// value is a variable value being formatted. It is a list if multi is set, a single string otherwise.
+type value struct {
+	name   string
+	values []string
+	text   []string
+	multi  bool
+}
+
+type formatter func(v value, args []string) string
+
+var formatters = map[string]formatter{
+	FormatLucene:        formatLucene,
+	FormatRaw:           formatRaw,
+	FormatRegex:         formatRegex,
+	FormatPipe:          formatPipe,
+	FormatDistributed:   formatDistributed,
+	FormatCSV:           formatCSV,
+	FormatHTML:          formatHTML,
+	FormatJSON:          formatJSON,
+	FormatPercentEncode: formatPercentEncode,
+	FormatSingleQuote:   formatSingleQuote,
+	FormatDoubleQuote:   formatDoubleQuote,
+	FormatSQLString:     formatSQLString,
+	FormatDate:          formatDate,
+	FormatGlob:          formatGlob,
+	FormatText:          formatText,
+	FormatQueryParam:    formatQueryParam,
+}
+
+// IsKnownFormat returns whether format, without its arguments, is supported.
+func IsKnownFormat(format string) bool {
+	_, ok := formatters[strings.SplitN(format, ":", 2)[0]]
+	return ok
+}
+
+// FormatValue formats the current value of the variable named name. The format may have arguments separated by
+// colons, like "date:seconds". Unknown formats and an empty format fall back to the glob format.
+func FormatValue(name string, v *Variable, format string) string {
+	args := strings.Split(format, ":")
+	format, args = args[0], args[1:]
+
+	f, ok := formatters[format]
+	if !ok {
+		f = formatGlob
+	}
+	return formatVariable(name, v, format, f, args)
+}
+
+func formatVariable(name string, v *Variable, format string, f formatter, args []string) string {
+	values := v.Value
+	text := v.text()
+	multi := v.Multi
+
+	if v.isAll() {
+		text = []string{AllText}
+		if v.CustomAllValue != "" && format != FormatText {
+			// custom all values are used as they are, without any formatting
+			return v.CustomAllValue
+		}
+		values = v.Options
+		multi = true
+	}
+
+	return f(value{name: name, values: values, text: text, multi: multi}, args)
+}
+
+// single returns the value of a single-valued value.
+func (v value) single() string {
+	if len(v.values) == 0 {
+		return ""
+	}
+	return v.values[0]
+}
+
+func mapValues(values []string, fn func(string) string) []string {
+	res := make([]string, len(values))
+	for i, s := range values {
+		res[i] = fn(s)
+	}
+	return res
+}
+
+var luceneSpecialChars = regexp.MustCompile(`([\!\*\+\-\=<>\s\&\|\(\)\[\]\{\}\^\~\?\:\\/"])`)
+
+func luceneEscape(s string) string {
+	// numbers are not escaped, javascript considers blank strings to be numbers too
+	if trimmed := strings.TrimSpace(s); trimmed == "" {
+		return s
+	} else if _, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return s
+	}
+	return luceneSpecialChars.ReplaceAllString(s, `\$1`)
+}
+
+func formatLucene(v value, _ []string) string {
+	if !v.multi {
+		return luceneEscape(v.single())
+	}
+	if len(v.values) == 0 {
+		return "__empty__"
+	}
+	quoted := mapValues(v.values, func(s string) string {
+		return `"` + luceneEscape(s) + `"`
+	})
+	return "(" + strings.Join(quoted, " OR ") + ")"
+}
+
+func formatRaw(v value, _ []string) string {
+	return strings.Join(v.values, ",")
+}
+
+var regexSpecialChars = regexp.MustCompile(`[\\^$*+?.()|[\]{}/]`)
+
+func regexEscape(s string) string {
+	return regexSpecialChars.ReplaceAllString(s, `\$0`)
+}
+
+func formatRegex(v value, _ []string) string {
+	if !v.multi {
+		return regexEscape(v.single())
+	}
+	escaped := mapValues(v.values, regexEscape)
+	if len(escaped) == 1 {
+		return escaped[0]
+	}
+	return "(" + strings.Join(escaped, "|") + ")"
+}
+
+func formatPipe(v value, _ []string) string {
+	return strings.Join(v.values, "|")
+}
+
+func formatDistributed(v value, _ []string) string {
+	if !v.multi {
+		return v.single()
+	}
+	parts := make([]string, len(v.values))
+	for i, s := range v.values {
+		if i == 0 {
+			parts[i] = s
+		} else {
+			parts[i] = v.name + "=" + s
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatCSV(v value, _ []string) string {
+	return strings.Join(v.values, ",")
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func formatHTML(v value, _ []string) string {
+	if !v.multi {
+		return htmlEscaper.Replace(v.single())
+	}
+	return htmlEscaper.Replace(strings.Join(v.values, ", "))
+}
+
+func formatJSON(v value, _ []string) string {
+	var b []byte
+	if v.multi {
+		values := v.values
+		if values == nil {
+			values = []string{}
+		}
+		b, _ = json.Marshal(values)
+	} else {
+		b, _ = json.Marshal(v.single())
+	}
+	return string(b)
+}
+
+// percentEncode encodes s like javascript's encodeURIComponent, additionally encoding the sub-delims
+// "!", "'", "(", ")" and "*" as required by RFC 3986.
+func percentEncode(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' {
+			sb.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", b)
+	}
+	return sb.String()
+}
+
+func formatPercentEncode(v value, _ []string) string {
+	if !v.multi {
+		return percentEncode(v.single())
+	}
+	return percentEncode("{" + strings.Join(v.values, ",") + "}")
+}
+
+func formatSingleQuote(v value, _ []string) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+	}
+	if !v.multi {
+		return quote(v.single())
+	}
+	return strings.Join(mapValues(v.values, quote), ",")
+}
+
+func formatDoubleQuote(v value, _ []string) string {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	if !v.multi {
+		return quote(v.single())
+	}
+	return strings.Join(mapValues(v.values, quote), ",")
+}
+
+func formatSQLString(v value, _ []string) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	if !v.multi {
+		return quote(v.single())
+	}
+	return strings.Join(mapValues(v.values, quote), ",")
+}
+
+// formatDate formats a value holding milliseconds since the epoch. Besides "ms", "seconds" and "iso", the frontend
+// accepts moment.js format strings, these are not supported here and are formatted as "iso".
+func formatDate(v value, args []string) string {
+	arg := "iso"
+	if len(args) > 0 {
+		arg = args[0]
+	}
+
+	s := v.single()
+	if arg == "ms" {
+		return s
+	}
+
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return s
+	}
+
+	if arg == "seconds" {
+		return strconv.FormatInt(int64(float64(ms)/1000+0.5), 10)
+	}
+	return time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func formatGlob(v value, _ []string) string {
+	if v.multi && len(v.values) > 1 {
+		return "{" + strings.Join(v.values, ",") + "}"
+	}
+	return strings.Join(v.values, ",")
+}
+
+func formatText(v value, _ []string) string {
+	if !v.multi && len(v.text) > 0 {
+		if v.single() == AllValue {
+			return AllText
+		}
+		return v.text[0]
+	}
+	return strings.Join(v.text, " + ")
+}
+
+func formatQueryParam(v value, _ []string) string {
+	params := mapValues(v.values, func(s string) string {
+		return "var-" + v.name + "=" + percentEncode(s)
+	})
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,70 @@
+package templatevars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatValue(t *testing.T) {
+	single := &Variable{Value: []string{"a.b"}, Text: []string{"A.B"}}
+	multi := &Variable{Value: []string{"a.b", "c'd"}, Text: []string{"A.B", "C'D"}, Multi: true}
+
+	tests := []struct {
+		format string
+		v      *Variable
+		want   string
+	}{
+		{format: "", v: single, want: "a.b"},
+		{format: "", v: multi, want: "{a.b,c'd}"},
+		{format: "glob", v: &Variable{Value: []string{"a"}, Multi: true}, want: "a"},
+		{format: "unknown", v: multi, want: "{a.b,c'd}"},
+		{format: "raw", v: multi, want: "a.b,c'd"},
+		{format: "regex", v: single, want: `a\.b`},
+		{format: "regex", v: multi, want: `(a\.b|c'd)`},
+		{format: "regex", v: &Variable{Value: []string{"a/b"}, Multi: true}, want: `a\/b`},
+		{format: "pipe", v: multi, want: "a.b|c'd"},
+		{format: "distributed", v: multi, want: "a.b,host=c'd"},
+		{format: "csv", v: multi, want: "a.b,c'd"},
+		{format: "html", v: &Variable{Value: []string{`<a href="x">&`}}, want: "&lt;a href=&quot;x&quot;&gt;&amp;"},
+		{format: "json", v: single, want: `"a.b"`},
+		{format: "json", v: multi, want: `["a.b","c'd"]`},
+		{format: "percentencode", v: &Variable{Value: []string{"a b!*"}}, want: "a%20b%21%2A"},
+		{format: "percentencode", v: multi, want: "%7Ba.b%2Cc%27d%7D"},
+		{format: "singlequote", v: multi, want: `'a.b','c\'d'`},
+		{format: "doublequote", v: &Variable{Value: []string{`a"b`}}, want: `"a\"b"`},
+		{format: "sqlstring", v: multi, want: `'a.b','c''d'`},
+		{format: "sqlstring", v: single, want: `'a.b'`},
+		{format: "lucene", v: &Variable{Value: []string{"a b:c"}}, want: `a\ b\:c`},
+		{format: "lucene", v: &Variable{Value: []string{"-1.5"}}, want: `-1.5`},
+		{format: "lucene", v: multi, want: `("a.b" OR "c'd")`},
+		{format: "lucene", v: &Variable{Multi: true}, want: `__empty__`},
+		{format: "text", v: single, want: "A.B"},
+		{format: "text", v: multi, want: "A.B + C'D"},
+		{format: "queryparam", v: multi, want: "var-host=a.b&var-host=c%27d"},
+		{format: "date", v: &Variable{Value: []string{"1500000000123"}}, want: "2017-07-14T02:40:00.123Z"},
+		{format: "date:ms", v: &Variable{Value: []string{"1500000000123"}}, want: "1500000000123"},
+		{format: "date:seconds", v: &Variable{Value: []string{"1500000000623"}}, want: "1500000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			require.Equal(t, tt.want, FormatValue("host", tt.v, tt.format))
+		})
+	}
+}
+
+func TestFormatValueAll(t *testing.T) {
+	t.Run("all option uses the values of all options", func(t *testing.T) {
+		v := &Variable{Value: []string{AllValue}, Text: []string{"All"}, Options: []string{"a", "b"}}
+		require.Equal(t, "{a,b}", FormatValue("host", v, ""))
+		require.Equal(t, "(a|b)", FormatValue("host", v, "regex"))
+		require.Equal(t, "All", FormatValue("host", v, "text"))
+	})
+
+	t.Run("custom all value is not formatted", func(t *testing.T) {
+		v := &Variable{Value: []string{AllValue}, Options: []string{"a", "b"}, CustomAllValue: ".*"}
+		require.Equal(t, ".*", FormatValue("host", v, "regex"))
+		require.Equal(t, "All", FormatValue("host", v, "text"))
+	})
+}
@@ -0,0 +1,106 @@
+package templatevars
+
+import (
+	"regexp"
+)
+
+// variableRegex matches the variable syntaxes $var, [[var]], [[var:format]], ${var}, ${var:format} and
+// ${var.fieldPath}, it is the same expression the frontend uses. Field paths only apply to object values which
+// variables here never hold, so they are ignored.
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\${(\w+)(?:\.([^:^\}]+))?(?::([^\}]+))?}`)
+
+// Interpolate replaces the variables referenced in target with their formatted values. Variables without an explicit
+// format use defaultFormat, which is either a format or a data source format as returned by DataSourceFormat. An
+// empty default format means glob. References to unknown variables are kept as they are, just like the frontend does.
+func Interpolate(target string, variables map[string]*Variable, defaultFormat string) string {
+	if len(variables) == 0 {
+		return target
+	}
+
+	return variableRegex.ReplaceAllStringFunc(target, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		name := firstNonEmpty(groups[1], groups[2], groups[4])
+		format := firstNonEmpty(groups[3], groups[6])
+
+		v, ok := variables[name]
+		if !ok || v == nil {
+			return match
+		}
+		if format == "" {
+			if f, ok := dataSourceFormatters[defaultFormat]; ok {
+				return formatVariable(name, v, defaultFormat, f, nil)
+			}
+			format = defaultFormat
+		}
+		return FormatValue(name, v, format)
+	})
+}
+
+// Reference is a variable reference found in a string.
+type Reference struct {
+	// Name is the name of the referenced variable.
+	Name string
+	// Format is the format requested by the reference, it is empty when the reference has no explicit format.
+	Format string
+	// Start and End are the byte offsets of the reference in the string.
+	Start int
+	End   int
+}
+
+// FindReferences returns all variable references in target, in the order they appear.
+func FindReferences(target string) []Reference {
+	matches := variableRegex.FindAllStringSubmatchIndex(target, -1)
+	refs := make([]Reference, 0, len(matches))
+	for _, m := range matches {
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return target[m[2*i]:m[2*i+1]]
+		}
+		refs = append(refs, Reference{
+			Name:   firstNonEmpty(group(1), group(2), group(4)),
+			Format: firstNonEmpty(group(3), group(6)),
+			Start:  m[0],
+			End:    m[1],
+		})
+	}
+	return refs
+}
+
+// ContainsVariable returns whether target references any variable.
+func ContainsVariable(target string) bool {
+	return variableRegex.MatchString(target)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// InterpolateValue interpolates all strings held by v, which is a value as decoded by encoding/json. Maps and slices
+// are copied, object keys are left as they are.
+func InterpolateValue(v interface{}, variables map[string]*Variable, defaultFormat string) interface{} {
+	switch value := v.(type) {
+	case string:
+		return Interpolate(value, variables, defaultFormat)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			res[k] = InterpolateValue(item, variables, defaultFormat)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(value))
+		for i, item := range value {
+			res[i] = InterpolateValue(item, variables, defaultFormat)
+		}
+		return res
+	default:
+		return v
+	}
+}
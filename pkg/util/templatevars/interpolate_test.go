@@ -0,0 +1,93 @@
+package templatevars
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]*Variable{
+		"host": {Value: []string{"web01", "web02"}, Multi: true},
+		"env":  {Value: []string{"prod"}},
+	}
+
+	tests := []struct {
+		name          string
+		target        string
+		defaultFormat string
+		want          string
+	}{
+		{name: "dollar syntax", target: "up{env=\"$env\"}", want: "up{env=\"prod\"}"},
+		{name: "braces syntax", target: "${env}_total", want: "prod_total"},
+		{name: "braces syntax with format", target: "host=~\"${host:regex}\"", want: "host=~\"(web01|web02)\""},
+		{name: "brackets syntax with format", target: "[[host:csv]]", want: "web01,web02"},
+		{name: "default format", target: "$host", defaultFormat: "pipe", want: "web01|web02"},
+		{name: "prometheus default format", target: `up{host=~"$host", env="$env"}`, defaultFormat: FormatPrometheus, want: `up{host=~"(web01|web02)", env="prod"}`},
+		{name: "loki default format", target: `{host=~"$host"}`, defaultFormat: FormatLoki, want: `{host=~"web01|web02"}`},
+		{name: "sql default format", target: "WHERE env = '$env' AND host IN ($host)", defaultFormat: FormatSQL, want: "WHERE env = 'prod' AND host IN ('web01','web02')"},
+		{name: "data source formats can't be requested explicitly", target: "${host:prometheus}", want: "{web01,web02}"},
+		{name: "explicit format wins over default format", target: "${host:csv}", defaultFormat: "pipe", want: "web01,web02"},
+		{name: "unknown variables are kept", target: "$__interval $unknown ${other:csv}", want: "$__interval $unknown ${other:csv}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Interpolate(tt.target, variables, tt.defaultFormat))
+		})
+	}
+}
+
+func TestFindReferences(t *testing.T) {
+	target := "SELECT * FROM t WHERE host IN ($host) AND env = '${env:sqlstring}' AND dc = [[dc]]"
+	refs := FindReferences(target)
+
+	require.Equal(t, []Reference{
+		{Name: "host", Start: 31, End: 36},
+		{Name: "env", Format: "sqlstring", Start: 49, End: 65},
+		{Name: "dc", Start: 76, End: 82},
+	}, refs)
+	for _, ref := range refs {
+		require.Contains(t, target[ref.Start:ref.End], ref.Name)
+	}
+}
+
+func TestInterpolateValue(t *testing.T) {
+	variables := map[string]*Variable{
+		"env": {Value: []string{"prod"}},
+	}
+
+	var query interface{}
+	err := json.Unmarshal([]byte(`{"expr": "up{env=\"$env\"}", "hide": false, "nested": {"items": ["$env", 1]}}`), &query)
+	require.NoError(t, err)
+
+	res := InterpolateValue(query, variables, "")
+
+	require.Equal(t, map[string]interface{}{
+		"expr":   "up{env=\"prod\"}",
+		"hide":   false,
+		"nested": map[string]interface{}{"items": []interface{}{"prod", float64(1)}},
+	}, res)
+}
+
+func TestVariableJSON(t *testing.T) {
+	var variables map[string]*Variable
+	err := json.Unmarshal([]byte(`{
+		"env": {"value": "prod", "text": "Production"},
+		"host": {"value": ["web01", "web02"]},
+		"all": {"value": "$__all", "options": ["a", "b"], "allValue": ".*"}
+	}`), &variables)
+	require.NoError(t, err)
+
+	require.Equal(t, &Variable{Value: []string{"prod"}, Text: []string{"Production"}}, variables["env"])
+	require.Equal(t, &Variable{Value: []string{"web01", "web02"}, Multi: true}, variables["host"])
+	require.Equal(t, &Variable{Value: []string{AllValue}, Options: []string{"a", "b"}, CustomAllValue: ".*"}, variables["all"])
+
+	b, err := json.Marshal(variables["env"])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value": "prod", "text": "Production"}`, string(b))
+
+	err = json.Unmarshal([]byte(`{"value": 1}`), &Variable{})
+	require.Error(t, err)
+}
@@ -0,0 +1,133 @@
+// Package templatevars implements the dashboard template variable interpolation of the frontend template service,
+// so that queries can be interpolated on the server before they are sent to data sources.
+package templatevars
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AllValue is the value of a variable that has its "All" option selected.
+const AllValue = "$__all"
+
+// AllText is the display text of the "All" option.
+const AllText = "All"
+
+// Variable is the current state of a dashboard template variable.
+type Variable struct {
+	// Value holds the selected values, single-valued variables hold exactly one value.
+	Value []string
+	// Text holds the display text of the selected values, it defaults to the values.
+	Text []string
+	// Multi is set when the value is a list, lists are formatted as such even when they hold a single value.
+	Multi bool
+	// Options are the values of all options of the variable, they are used when "All" is selected.
+	Options []string
+	// CustomAllValue is used as is instead of the option values when "All" is selected.
+	CustomAllValue string
+}
+
+type variableJSON struct {
+	Value          json.RawMessage `json:"value"`
+	Text           json.RawMessage `json:"text"`
+	Options        []string        `json:"options,omitempty"`
+	CustomAllValue string          `json:"allValue,omitempty"`
+}
+
+// UnmarshalJSON reads a variable from the JSON representation of the current value of a variable in a dashboard,
+// where value and text are either a string or a list of strings.
+func (v *Variable) UnmarshalJSON(b []byte) error {
+	var raw variableJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	value, multi, err := readStringOrList(raw.Value)
+	if err != nil {
+		return fmt.Errorf("invalid variable value: %w", err)
+	}
+	text, _, err := readStringOrList(raw.Text)
+	if err != nil {
+		return fmt.Errorf("invalid variable text: %w", err)
+	}
+
+	*v = Variable{
+		Value:          value,
+		Text:           text,
+		Multi:          multi,
+		Options:        raw.Options,
+		CustomAllValue: raw.CustomAllValue,
+	}
+	return nil
+}
+
+// MarshalJSON writes the variable in the format read by UnmarshalJSON.
+func (v Variable) MarshalJSON() ([]byte, error) {
+	raw := struct {
+		Value          interface{} `json:"value"`
+		Text           interface{} `json:"text,omitempty"`
+		Options        []string    `json:"options,omitempty"`
+		CustomAllValue string      `json:"allValue,omitempty"`
+	}{
+		Value:          v.Value,
+		Options:        v.Options,
+		CustomAllValue: v.CustomAllValue,
+	}
+	if !v.Multi && len(v.Value) == 1 {
+		raw.Value = v.Value[0]
+	}
+	if len(v.Text) > 0 {
+		raw.Text = v.Text
+		if !v.Multi && len(v.Text) == 1 {
+			raw.Text = v.Text[0]
+		}
+	}
+	return json.Marshal(raw)
+}
+
+func readStringOrList(raw json.RawMessage) ([]string, bool, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, false, nil
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return []string{str}, false, nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, false, err
+	}
+	return list, true, nil
+}
+
+// isAll returns whether the "All" option of the variable is selected.
+func (v *Variable) isAll() bool {
+	return len(v.Value) > 0 && v.Value[0] == AllValue
+}
+
+// IsCustomAll returns whether the "All" option of the variable is selected and the variable has a custom all value.
+func (v *Variable) IsCustomAll() bool {
+	return v.isAll() && v.CustomAllValue != ""
+}
+
+// Values returns the values the variable expands to. When "All" is selected these are the values of all options, or
+// only the custom all value if the variable has one.
+func (v *Variable) Values() []string {
+	if !v.isAll() {
+		return v.Value
+	}
+	if v.CustomAllValue != "" {
+		return []string{v.CustomAllValue}
+	}
+	return v.Options
+}
+
+// text returns the display text of the selected values.
+func (v *Variable) text() []string {
+	if len(v.Text) > 0 {
+		return v.Text
+	}
+	return v.Value
+}
@@ -46,6 +46,18 @@ export interface MultipleOutputterConfig {
   outputs: FrameOutputterConfig[];
 }
 export interface ManagedStreamOutputConfig {}
+export interface WebhookOutputConfig {
+  uid: string;
+  method?: string;
+  contentType?: string;
+  batchSize?: number;
+  flushMilliseconds?: number;
+  bodyTemplate?: string;
+}
+export interface KafkaOutputConfig {
+  brokers: string[];
+  topic: string;
+}
 export interface FrameOutputterConfig {
   type: Omit<keyof FrameOutputterConfig, 'type'>;
   managedStream?: ManagedStreamOutputConfig;
@@ -56,6 +68,8 @@ export interface FrameOutputterConfig {
   remoteWrite?: RemoteWriteOutputConfig;
   loki?: LokiOutputConfig;
   changeLog?: ChangeLogOutputConfig;
+  webhook?: WebhookOutputConfig;
+  kafka?: KafkaOutputConfig;
 }
 export interface MultipleFrameProcessorConfig {
   processors: FrameProcessorConfig[];
@@ -66,11 +80,17 @@ export interface KeepFieldsFrameProcessorConfig {
 export interface DropFieldsFrameProcessorConfig {
   fieldNames: string[];
 }
+export interface AggregateFrameProcessorConfig {
+  window: string;
+  reducers?: string[];
+  fieldNames?: string[];
+}
 export interface FrameProcessorConfig {
   type: Omit<keyof FrameProcessorConfig, 'type'>;
   dropFields?: DropFieldsFrameProcessorConfig;
   keepFields?: KeepFieldsFrameProcessorConfig;
   multiple?: MultipleFrameProcessorConfig;
+  aggregate?: AggregateFrameProcessorConfig;
 }
 export interface JsonFrameConverterConfig {}
 export interface AutoInfluxConverterConfig {
@@ -129,4 +149,5 @@ export interface ChannelRuleSettings {
 export interface ChannelRule {
   pattern: string;
   settings: ChannelRuleSettings;
+  version?: number;
 }
@@ -25,12 +25,21 @@ import { getTimeSrv } from 'app/features/dashboard/services/TimeSrv';
 import { VariableWithMultiSupport } from '../../../variables/types';
 import { getSearchFilterScopedVar, SearchFilterOptions } from '../../../variables/utils';
 import { MACRO_NAMES } from '../constants';
-import { DB, SQLQuery, SQLOptions, ResponseParser, SqlQueryModel, QueryFormat } from '../types';
+import {
+  DB,
+  SQLQuery,
+  SQLOptions,
+  ResponseParser,
+  SqlQueryModel,
+  QueryFormat,
+  SQLTemplateVariable,
+} from '../types';
 
 export abstract class SqlDatasource extends DataSourceWithBackend<SQLQuery, SQLOptions> {
   id: number;
   name: string;
   interval: string;
+  requireParameterization: boolean;
   db: DB;
   annotations = {};
 
@@ -43,6 +52,7 @@ export abstract class SqlDatasource extends DataSourceWithBackend<SQLQuery, SQLO
     this.id = instanceSettings.id;
     const settingsData = instanceSettings.jsonData || {};
     this.interval = settingsData.timeInterval || '1m';
+    this.requireParameterization = settingsData.requireParameterization ?? false;
     this.db = this.getDB();
   }
 
@@ -97,7 +107,18 @@ export abstract class SqlDatasource extends DataSourceWithBackend<SQLQuery, SQLO
   applyTemplateVariables(
     target: SQLQuery,
     scopedVars: ScopedVars
-  ): Record<string, string | DataSourceRef | SQLQuery['format']> {
+  ): Record<string, string | DataSourceRef | SQLQuery['format'] | Record<string, SQLTemplateVariable>> {
+    if (this.requireParameterization) {
+      // the backend binds the variables as query parameters
+      return {
+        refId: target.refId,
+        datasource: this.getRef(),
+        rawSql: target.rawSql ?? '',
+        format: target.format,
+        variables: this.getTemplateVariables(scopedVars),
+      };
+    }
+
     const queryModel = this.getQueryModel(target, this.templateSrv, scopedVars);
     const rawSql = this.clean(queryModel.interpolate());
     return {
@@ -108,6 +129,32 @@ export abstract class SqlDatasource extends DataSourceWithBackend<SQLQuery, SQLO
     };
   }
 
+  getTemplateVariables(scopedVars: ScopedVars): Record<string, SQLTemplateVariable> {
+    const variables: Record<string, SQLTemplateVariable> = {};
+    for (const variable of this.templateSrv.getVariables()) {
+      if (!('current' in variable)) {
+        continue;
+      }
+      variables[variable.name] = {
+        value: variable.current.value,
+        text: variable.current.text,
+        options: variable.options.filter((o) => o.value !== '$__all').map((o) => String(o.value)),
+        allValue: ('allValue' in variable && variable.allValue) || undefined,
+      };
+    }
+
+    for (const [name, scopedVar] of Object.entries(scopedVars ?? {})) {
+      // built-in variables like $__interval are handled by the backend
+      if (!scopedVar || name.startsWith('__')) {
+        continue;
+      }
+      const value = Array.isArray(scopedVar.value) ? scopedVar.value.map(String) : String(scopedVar.value);
+      variables[name] = { value, text: scopedVar.text };
+    }
+
+    return variables;
+  }
+
   clean(value: string) {
     return value.replace(/''/g, "'");
   }
@@ -42,6 +42,17 @@ export interface SQLOptions extends SQLConnectionLimits, DataSourceJsonData {
   database: string;
   url: string;
   timeInterval: string;
+  requireParameterization?: boolean;
+}
+
+/**
+ * Current value of a template variable, sent along with queries that bind variables as query parameters.
+ */
+export interface SQLTemplateVariable {
+  value: string | string[];
+  text?: string | string[];
+  options?: string[];
+  allValue?: string;
 }
 
 export enum QueryFormat {
@@ -8,6 +8,7 @@ import { LokiOptions } from '../types';
 
 import { DerivedFields } from './DerivedFields';
 import { MaxLinesField } from './MaxLinesField';
+import { SplitIntervalField } from './SplitIntervalField';
 
 export type Props = DataSourcePluginOptionsEditorProps<LokiOptions>;
 
@@ -24,6 +25,7 @@ const makeJsonUpdater =
   };
 
 const setMaxLines = makeJsonUpdater('maxLines');
+const setSplitInterval = makeJsonUpdater('splitInterval');
 const setDerivedFields = makeJsonUpdater('derivedFields');
 
 export const ConfigEditor = (props: Props) => {
@@ -54,6 +56,14 @@ export const ConfigEditor = (props: Props) => {
             />
           </div>
         </div>
+        <div className="gf-form-inline">
+          <div className="gf-form">
+            <SplitIntervalField
+              value={options.jsonData.splitInterval || ''}
+              onChange={(value) => onOptionsChange(setSplitInterval(options, value))}
+            />
+          </div>
+        </div>
       </div>
 
       <DerivedFields
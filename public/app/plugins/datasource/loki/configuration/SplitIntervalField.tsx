@@ -0,0 +1,37 @@
+import React from 'react';
+
+import { LegacyForms } from '@grafana/ui';
+const { FormField } = LegacyForms;
+
+type Props = {
+  value: string;
+  onChange: (value: string) => void;
+};
+
+export const SplitIntervalField = (props: Props) => {
+  const { value, onChange } = props;
+  return (
+    <FormField
+      label="Split interval"
+      labelWidth={11}
+      inputWidth={20}
+      inputEl={
+        <input
+          type="text"
+          className="gf-form-input width-8 gf-form-input--has-help-icon"
+          value={value}
+          onChange={(event) => onChange(event.currentTarget.value)}
+          spellCheck={false}
+          placeholder="1d"
+        />
+      }
+      tooltip={
+        <>
+          Split range queries over a longer time range into requests over sub-ranges of this length, which are run one
+          after the other and merged. Log queries stop requesting further sub-ranges once the maximum lines are
+          reached. Leave empty to disable splitting.
+        </>
+      }
+    />
+  );
+};
@@ -53,6 +53,7 @@ export interface LokiQuery extends DataQuery {
 
 export interface LokiOptions extends DataSourceJsonData {
   maxLines?: string;
+  splitInterval?: string;
   derivedFields?: DerivedFieldConfig[];
   alertmanager?: string;
   keepCookies?: string[];
@@ -35,6 +35,7 @@ import { OpenTsdbOptions, OpenTsdbQuery } from './types';
 export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenTsdbOptions> {
   type: any;
   url: any;
+  access: any;
   name: any;
   withCredentials: any;
   basicAuth: any;
@@ -50,6 +51,7 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
     super(instanceSettings);
     this.type = 'opentsdb';
     this.url = instanceSettings.url;
+    this.access = instanceSettings.access;
     this.name = instanceSettings.name;
     this.withCredentials = instanceSettings.withCredentials;
     this.basicAuth = instanceSettings.basicAuth;
@@ -320,6 +322,15 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
     relativeUrl: string,
     params?: { type?: string; q?: string; max?: number; m?: any; limit?: number }
   ): Observable<FetchResponse> {
+    if (this.access !== 'direct') {
+      // with server access the suggestions are requested by the backend
+      return getBackendSrv().fetch({
+        method: 'GET',
+        url: `/api/datasources/uid/${this.uid}/resources${relativeUrl}`,
+        params: params,
+      });
+    }
+
     const options = {
       method: 'GET',
       url: this.url + relativeUrl,
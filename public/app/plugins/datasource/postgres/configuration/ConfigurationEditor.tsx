@@ -267,6 +267,26 @@ export const PostgresConfigEditor = (props: DataSourcePluginOptionsEditorProps<P
             onChange={onUpdateDatasourceJsonDataOption(props, 'timeInterval')}
           ></Input>
         </InlineField>
+        <InlineField
+          tooltip={
+            <span>
+              Bind template variables as query parameters instead of interpolating them into the query text, so that
+              variable values can&apos;t change the structure of a query. Variables referenced with an explicit format,
+              like <code>${var:raw}</code>, are rejected.
+            </span>
+          }
+          labelWidth={labelWidthShort}
+          label="Parameterized queries"
+          htmlFor="requireParameterization"
+        >
+          <InlineSwitch
+            id="requireParameterization"
+            value={jsonData.requireParameterization || false}
+            onChange={(event: SyntheticEvent<HTMLInputElement>) =>
+              updateDatasourcePluginJsonDataOption(props, 'requireParameterization', event.currentTarget.checked)
+            }
+          ></InlineSwitch>
+        </InlineField>
       </FieldSet>
 
       <Alert title="User Permission" severity="info">
@@ -24,6 +24,7 @@ export interface PostgresOptions extends DataSourceJsonData, SQLConnectionLimits
   sslKeyFile: string;
   postgresVersion: number;
   timescaledb: boolean;
+  requireParameterization?: boolean;
 }
 
 export interface SecureJsonData {
@@ -77,6 +77,25 @@ export const PromSettings = (props: Props) => {
             />
           </div>
         </div>
+        <div className="gf-form-inline">
+          <div className="gf-form">
+            <FormField
+              label="Split interval"
+              labelWidth={13}
+              inputEl={
+                <Input
+                  className="width-6"
+                  value={options.jsonData.splitInterval}
+                  onChange={onChangeHandler('splitInterval', options, onOptionsChange)}
+                  spellCheck={false}
+                  placeholder="1d"
+                  validationEvents={promSettingsValidationEvents}
+                />
+              }
+              tooltip="Split long range queries into step aligned queries over sub-ranges of this length, which are run concurrently and stitched together. Leave empty to disable splitting. Not used with the prometheusWideSeries feature toggle."
+            />
+          </div>
+        </div>
         <div className="gf-form">
           <InlineFormLabel
             width={13}
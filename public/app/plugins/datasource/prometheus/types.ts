@@ -25,6 +25,8 @@ export interface PromQuery extends DataQuery {
 export interface PromOptions extends DataSourceJsonData {
   timeInterval?: string;
   queryTimeout?: string;
+  splitInterval?: string;
+  splitConcurrency?: number;
   httpMethod?: string;
   directUrl?: string;
   customQueryParameters?: string;